@@ -0,0 +1,213 @@
+package buildkitelogs
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"hash/maphash"
+	"iter"
+	"slices"
+	"strings"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// Mitigation names a way of trimming CI output that SizeReport recommendations suggest.
+type Mitigation string
+
+const (
+	MitigateGroup      Mitigation = "reduce-group"     // Make a large group's commands less verbose
+	MitigateRepeats    Mitigation = "dedupe-lines"     // Stop printing the same line over and over
+	MitigateANSI       Mitigation = "disable-color"    // Turn off colored output
+	MitigateProgress   Mitigation = "disable-progress" // Turn off progress bars redrawn with carriage returns
+	MitigateHeartbeats Mitigation = "drop-heartbeats"  // Stop printing heartbeat/keepalive lines
+)
+
+// AdviseOptions configures Advise.
+type AdviseOptions struct {
+	TopN       int // Groups and repeated lines to report (0 = 10)
+	MinRepeats int // Occurrences for a line to count as repeated (0 = 10)
+}
+
+// SizeReport breaks down what makes a log large and recommends how to trim it. Sizes are
+// content bytes plus a line terminator per entry, so they approximate the raw log size.
+type SizeReport struct {
+	Entries         int64            `json:"entries"`
+	Bytes           int64            `json:"bytes"`
+	Groups          []GroupSize      `json:"groups"`           // Largest groups first
+	GroupCount      int              `json:"group_count"`      // Distinct groups, including ones not in Groups
+	RepeatedLines   []RepeatedLine   `json:"repeated_lines"`   // Largest total size first
+	RepeatedBytes   int64            `json:"repeated_bytes"`   // Bytes of every repetition after a line's first occurrence
+	ANSIBytes       int64            `json:"ansi_bytes"`       // Bytes of ANSI escape sequences
+	ProgressEntries int64            `json:"progress_entries"` // Entries redrawn with carriage returns
+	ProgressBytes   int64            `json:"progress_bytes"`   // Bytes overwritten by a later redraw in the same entry
+	HeartbeatBytes  int64            `json:"heartbeat_bytes"`  // Bytes of heartbeat/keepalive lines
+	Recommendations []Recommendation `json:"recommendations"`  // Largest estimated savings first
+}
+
+// GroupSize is the size of one group's output. Entries without a group are reported under
+// the empty name.
+type GroupSize struct {
+	Name    string `json:"name"`
+	Entries int64  `json:"entries"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// RepeatedLine is a line, compared without ANSI codes and surrounding whitespace, that
+// appears at least AdviseOptions.MinRepeats times.
+type RepeatedLine struct {
+	Content string `json:"content"`
+	Count   int64  `json:"count"`
+	Bytes   int64  `json:"bytes"` // Bytes of every occurrence
+}
+
+// Recommendation is a mitigation with the bytes it is estimated to save. Estimates of
+// different recommendations overlap, so they don't add up.
+type Recommendation struct {
+	Mitigation Mitigation `json:"mitigation"`
+	Target     string     `json:"target,omitempty"` // Group the recommendation applies to, if any
+	Savings    int64      `json:"savings_bytes"`
+	Message    string     `json:"message"`
+}
+
+// minRecommendedSavings is the share of the log a mitigation must save to be recommended.
+const minRecommendedSavings = 0.01
+
+// Advise reads every entry and reports the largest contributors to the log's size.
+func (pr *ParquetReader) Advise(ctx context.Context, opts AdviseOptions) (*SizeReport, error) {
+	return AdviseFromEntries(readParquetFileIter(ctx, pr.source, nil), opts)
+}
+
+// AdviseFromEntries reports the largest contributors to the size of entries: groups,
+// repeated lines, ANSI codes, progress redraws and heartbeats, and the mitigations that
+// would save the most.
+func AdviseFromEntries(entries iter.Seq2[ParquetLogEntry, error], opts AdviseOptions) (*SizeReport, error) {
+	topN := cmp.Or(opts.TopN, 10)
+	minRepeats := int64(cmp.Or(opts.MinRepeats, 10))
+
+	report := &SizeReport{}
+	var groups []GroupSize
+	groupIndex := make(map[string]int)
+
+	// Lines are counted by hash so that unique lines, usually most of a log, cost no
+	// more than a map entry. The content is kept from the second occurrence on.
+	seed := maphash.MakeSeed()
+	lines := make(map[uint64]*RepeatedLine)
+
+	for entry, err := range entries {
+		if err != nil {
+			return nil, fmt.Errorf("error reading entries: %w", err)
+		}
+		size := int64(len(entry.Content)) + 1
+		report.Entries++
+		report.Bytes += size
+
+		i, ok := groupIndex[entry.Group]
+		if !ok {
+			i = len(groups)
+			groupIndex[entry.Group] = i
+			groups = append(groups, GroupSize{Name: entry.Group})
+		}
+		groups[i].Entries++
+		groups[i].Bytes += size
+
+		plain := StripANSI(entry.Content)
+		report.ANSIBytes += int64(len(entry.Content) - len(plain))
+
+		if last := strings.LastIndexByte(entry.Content, '\r'); last >= 0 {
+			report.ProgressEntries++
+			report.ProgressBytes += int64(last + 1)
+		}
+		if !entry.IsGroup() && logparser.IsHeartbeat(plain) {
+			report.HeartbeatBytes += size
+		}
+
+		line := strings.TrimSpace(plain)
+		if line == "" {
+			continue
+		}
+		key := maphash.String(seed, line)
+		repeated, ok := lines[key]
+		if !ok {
+			lines[key] = &RepeatedLine{Count: 1, Bytes: size}
+			continue
+		}
+		if repeated.Count == 1 {
+			repeated.Content = line
+		}
+		repeated.Count++
+		repeated.Bytes += size
+	}
+
+	report.GroupCount = len(groups)
+	slices.SortStableFunc(groups, func(a, b GroupSize) int { return cmp.Compare(b.Bytes, a.Bytes) })
+	report.Groups = groups[:min(len(groups), topN)]
+
+	for _, line := range lines {
+		if line.Count < minRepeats {
+			continue
+		}
+		report.RepeatedBytes += line.Bytes - line.Bytes/line.Count
+		report.RepeatedLines = append(report.RepeatedLines, *line)
+	}
+	slices.SortFunc(report.RepeatedLines, func(a, b RepeatedLine) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), strings.Compare(a.Content, b.Content))
+	})
+	report.RepeatedLines = slices.Clip(report.RepeatedLines[:min(len(report.RepeatedLines), topN)])
+	if report.RepeatedLines == nil {
+		report.RepeatedLines = []RepeatedLine{}
+	}
+
+	report.Recommendations = recommend(report)
+	return report, nil
+}
+
+func recommend(report *SizeReport) []Recommendation {
+	recommendations := []Recommendation{}
+	add := func(r Recommendation) {
+		if report.Bytes > 0 && float64(r.Savings) >= minRecommendedSavings*float64(report.Bytes) {
+			recommendations = append(recommendations, r)
+		}
+	}
+	percent := func(n int64) float64 {
+		return 100 * float64(n) / float64(report.Bytes)
+	}
+
+	// Only groups that dominate the log are worth singling out
+	for _, group := range report.Groups {
+		if percent(group.Bytes) < 20 || group.Name == "" {
+			continue
+		}
+		add(Recommendation{
+			Mitigation: MitigateGroup,
+			Target:     group.Name,
+			Savings:    group.Bytes,
+			Message: fmt.Sprintf("Group %q is %.0f%% of the log; run its commands with quieter flags or write their output to an artifact",
+				group.Name, percent(group.Bytes)),
+		})
+	}
+	add(Recommendation{
+		Mitigation: MitigateRepeats,
+		Savings:    report.RepeatedBytes,
+		Message:    fmt.Sprintf("Repeated lines are %.0f%% of the log; print them once or summarize them", percent(report.RepeatedBytes)),
+	})
+	add(Recommendation{
+		Mitigation: MitigateANSI,
+		Savings:    report.ANSIBytes,
+		Message:    fmt.Sprintf("ANSI escape codes are %.0f%% of the log; disable color in CI (e.g. NO_COLOR=1 or --no-color)", percent(report.ANSIBytes)),
+	})
+	add(Recommendation{
+		Mitigation: MitigateProgress,
+		Savings:    report.ProgressBytes,
+		Message: fmt.Sprintf("Progress redraws in %d entries are %.0f%% of the log; disable progress bars in CI (e.g. --no-progress or --quiet)",
+			report.ProgressEntries, percent(report.ProgressBytes)),
+	})
+	add(Recommendation{
+		Mitigation: MitigateHeartbeats,
+		Savings:    report.HeartbeatBytes,
+		Message:    fmt.Sprintf("Heartbeat lines are %.0f%% of the log; log them less often or at debug level", percent(report.HeartbeatBytes)),
+	})
+
+	slices.SortStableFunc(recommendations, func(a, b Recommendation) int { return cmp.Compare(b.Savings, a.Savings) })
+	return recommendations
+}
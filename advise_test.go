@@ -0,0 +1,68 @@
+package buildkitelogs
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestAdviseFromEntries(t *testing.T) {
+	var entries []ParquetLogEntry
+	for range 20 {
+		entries = append(entries, ParquetLogEntry{Content: "\x1b[32mDownloading dependency\x1b[0m", Group: "Build"})
+	}
+	for i := range 5 {
+		entries = append(entries, ParquetLogEntry{Content: fmt.Sprintf("ok test %d", i), Group: "Tests"})
+	}
+	entries = append(entries,
+		ParquetLogEntry{Content: "10%\r50%\r100%", Group: "Tests"},
+		ParquetLogEntry{Content: "Heartbeat sent"},
+	)
+
+	seq := func(yield func(ParquetLogEntry, error) bool) {
+		for _, entry := range entries {
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+
+	report, err := AdviseFromEntries(seq, AdviseOptions{})
+	if err != nil {
+		t.Fatalf("AdviseFromEntries failed: %v", err)
+	}
+
+	if report.Entries != 27 || report.Bytes != 718 {
+		t.Errorf("entries = %d, bytes = %d, want 27 and 718", report.Entries, report.Bytes)
+	}
+	if report.GroupCount != 3 || report.Groups[0].Name != "Build" || report.Groups[0].Bytes != 640 {
+		t.Errorf("groups = %+v (%d total)", report.Groups, report.GroupCount)
+	}
+	if len(report.RepeatedLines) != 1 || report.RepeatedLines[0].Content != "Downloading dependency" || report.RepeatedLines[0].Count != 20 {
+		t.Errorf("repeated lines = %+v", report.RepeatedLines)
+	}
+	if report.RepeatedBytes != 608 {
+		t.Errorf("repeated bytes = %d, want 608", report.RepeatedBytes)
+	}
+	if report.ANSIBytes != 180 {
+		t.Errorf("ANSI bytes = %d, want 180", report.ANSIBytes)
+	}
+	if report.ProgressEntries != 1 || report.ProgressBytes != 8 {
+		t.Errorf("progress = %d entries, %d bytes, want 1 and 8", report.ProgressEntries, report.ProgressBytes)
+	}
+	if report.HeartbeatBytes != 15 {
+		t.Errorf("heartbeat bytes = %d, want 15", report.HeartbeatBytes)
+	}
+
+	var mitigations []Mitigation
+	for _, r := range report.Recommendations {
+		mitigations = append(mitigations, r.Mitigation)
+	}
+	want := []Mitigation{MitigateGroup, MitigateRepeats, MitigateANSI, MitigateHeartbeats, MitigateProgress}
+	if !slices.Equal(mitigations, want) {
+		t.Errorf("recommendations = %v, want %v", mitigations, want)
+	}
+	if report.Recommendations[0].Target != "Build" {
+		t.Errorf("group recommendation targets %q, want Build", report.Recommendations[0].Target)
+	}
+}
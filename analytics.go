@@ -0,0 +1,256 @@
+package buildkitelogs
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// CatalogEntry is a cached job log listed by BlobStorage.Catalog.
+type CatalogEntry struct {
+	Key      string        `json:"key"`
+	Size     int64         `json:"size_bytes"` // Parquet bytes
+	ModTime  time.Time     `json:"mod_time"`
+	Metadata *BlobMetadata `json:"metadata"` // Nil for blobs written without metadata
+}
+
+// Catalog iterates over the cached job logs in storage with their metadata. Errors
+// views, annotation sidecars and other blobs are skipped. Listing reads each log's
+// attributes but not its content.
+func (bs *BlobStorage) Catalog(ctx context.Context) iter.Seq2[CatalogEntry, error] {
+	return func(yield func(CatalogEntry, error) bool) {
+		it := bs.bucket.List(nil)
+		for {
+			obj, err := it.Next(ctx)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(CatalogEntry{}, fmt.Errorf("failed to list blobs: %w", err))
+				return
+			}
+			if obj.IsDir || !strings.HasSuffix(obj.Key, ".parquet") || strings.HasSuffix(obj.Key, "-errors.parquet") {
+				continue
+			}
+
+			metadata, err := bs.ReadWithMetadata(ctx, obj.Key)
+			if err != nil {
+				if !yield(CatalogEntry{}, err) {
+					return
+				}
+				continue
+			}
+			if !yield(CatalogEntry{Key: obj.Key, Size: obj.Size, ModTime: obj.ModTime, Metadata: metadata}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// AnalyticsOptions configures Analytics.
+type AnalyticsOptions struct {
+	Since        time.Time // Only include logs cached at or after Since (zero = all)
+	TopErrors    int       // Error signatures reported per pipeline (0 = 5)
+	MetadataOnly bool      // Only use blob metadata: faster, but no durations or error signatures
+}
+
+// AnalyticsReport summarizes the cached logs of every pipeline in storage.
+type AnalyticsReport struct {
+	Since        time.Time           `json:"since,omitzero"`
+	Jobs         int                 `json:"jobs"`
+	Unattributed int                 `json:"unattributed"` // Logs without metadata naming their pipeline
+	Pipelines    []PipelineAnalytics `json:"pipelines"`    // Largest log volume first
+}
+
+// PipelineAnalytics is the health summary of one pipeline's cached logs.
+type PipelineAnalytics struct {
+	Org             string           `json:"organization"`
+	Pipeline        string           `json:"pipeline"`
+	Jobs            int              `json:"jobs"`
+	Builds          int              `json:"builds"`
+	LogBytes        int64            `json:"log_bytes"`     // Raw log size, where recorded
+	ParquetBytes    int64            `json:"parquet_bytes"` // Cached size
+	Entries         int64            `json:"entries"`
+	TimedJobs       int              `json:"timed_jobs"`                // Jobs with timestamps to measure
+	AverageDuration time.Duration    `json:"average_duration_ns"`       // From first to last timestamp, over TimedJobs
+	ErrorLines      int64            `json:"error_lines"`               // Lines matching logparser.IsErrorLine
+	TopErrors       []ErrorSignature `json:"top_errors,omitempty"`      // Most frequent first
+	UnreadableJobs  int              `json:"unreadable_jobs,omitempty"` // Logs that failed to read and are only counted by metadata
+}
+
+// ErrorSignature is an error line with numbers replaced, so the same error from
+// different builds counts together.
+type ErrorSignature struct {
+	Signature string `json:"signature"`
+	Count     int64  `json:"count"`
+	Jobs      int    `json:"jobs"` // Jobs the signature appears in
+}
+
+// Analytics reads the catalog of cached logs in bs and reports per-pipeline totals: log
+// volume, average job duration and the most frequent error signatures. It reads storage
+// directly, without the per-job access checks of Client, so it needs credentials for
+// the whole bucket and shows every pipeline cached there.
+//
+// Cache hit rates are not stored with cached logs, so they are not reported; use Client
+// hooks (Hooks.AddAfterCacheCheck) to measure them as logs are read.
+func Analytics(ctx context.Context, bs *BlobStorage, opts AnalyticsOptions) (*AnalyticsReport, error) {
+	topErrors := cmp.Or(opts.TopErrors, 5)
+	report := &AnalyticsReport{Since: opts.Since, Pipelines: []PipelineAnalytics{}}
+
+	type pipelineState struct {
+		analytics     PipelineAnalytics
+		builds        map[string]bool
+		totalDuration time.Duration
+		errors        map[string]*ErrorSignature
+	}
+	pipelines := make(map[string]*pipelineState)
+	var order []string
+
+	for entry, err := range bs.Catalog(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		cachedAt := entry.ModTime
+		if entry.Metadata != nil && !entry.Metadata.CachedAt.IsZero() {
+			cachedAt = entry.Metadata.CachedAt
+		}
+		if cachedAt.Before(opts.Since) {
+			continue
+		}
+		report.Jobs++
+		if entry.Metadata == nil || entry.Metadata.Pipeline == "" {
+			report.Unattributed++
+			continue
+		}
+
+		name := entry.Metadata.Organization + "/" + entry.Metadata.Pipeline
+		state, ok := pipelines[name]
+		if !ok {
+			state = &pipelineState{
+				analytics: PipelineAnalytics{Org: entry.Metadata.Organization, Pipeline: entry.Metadata.Pipeline},
+				builds:    make(map[string]bool),
+				errors:    make(map[string]*ErrorSignature),
+			}
+			pipelines[name] = state
+			order = append(order, name)
+		}
+		p := &state.analytics
+		p.Jobs++
+		state.builds[entry.Metadata.Build] = true
+		p.LogBytes += entry.Metadata.LogSize
+		p.ParquetBytes += entry.Size
+		p.Entries += int64(entry.Metadata.RowCount)
+
+		if opts.MetadataOnly {
+			continue
+		}
+		stats, err := readJobAnalytics(ctx, bs, entry.Key)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			p.UnreadableJobs++
+			continue
+		}
+		if stats.timed {
+			p.TimedJobs++
+			state.totalDuration += stats.duration
+		}
+		for signature, count := range stats.errors {
+			p.ErrorLines += count
+			sig, ok := state.errors[signature]
+			if !ok {
+				sig = &ErrorSignature{Signature: signature}
+				state.errors[signature] = sig
+			}
+			sig.Count += count
+			sig.Jobs++
+		}
+	}
+
+	for _, name := range order {
+		state := pipelines[name]
+		p := state.analytics
+		p.Builds = len(state.builds)
+		if p.TimedJobs > 0 {
+			p.AverageDuration = state.totalDuration / time.Duration(p.TimedJobs)
+		}
+		for _, sig := range state.errors {
+			p.TopErrors = append(p.TopErrors, *sig)
+		}
+		slices.SortFunc(p.TopErrors, func(a, b ErrorSignature) int {
+			return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Signature, b.Signature))
+		})
+		p.TopErrors = slices.Clip(p.TopErrors[:min(len(p.TopErrors), topErrors)])
+		report.Pipelines = append(report.Pipelines, p)
+	}
+	slices.SortStableFunc(report.Pipelines, func(a, b PipelineAnalytics) int {
+		return cmp.Or(cmp.Compare(b.LogBytes, a.LogBytes), cmp.Compare(b.ParquetBytes, a.ParquetBytes))
+	})
+	return report, nil
+}
+
+// jobAnalytics is what Analytics reads from one cached log.
+type jobAnalytics struct {
+	timed    bool
+	duration time.Duration
+	errors   map[string]int64 // Error line counts by signature
+}
+
+func readJobAnalytics(ctx context.Context, bs *BlobStorage, key string) (*jobAnalytics, error) {
+	r, err := bs.OpenReaderAt(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	stats := &jobAnalytics{errors: make(map[string]int64)}
+	var first, last int64
+	for entry, err := range NewParquetReaderFromReaderAt(r, r.Size()).ReadEntriesIter(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		if entry.HasTime() {
+			if !stats.timed || entry.Timestamp < first {
+				first = entry.Timestamp
+			}
+			if !stats.timed || entry.Timestamp > last {
+				last = entry.Timestamp
+			}
+			stats.timed = true
+		}
+		if !entry.IsGroup() && logparser.IsErrorLine(entry.Content) {
+			stats.errors[errorSignature(entry.Content)]++
+		}
+	}
+	stats.duration = time.Duration(last-first) * time.Millisecond
+	return stats, nil
+}
+
+// signatureNumberRegex matches numbers and hex IDs, which vary between runs of the same
+// error. Units after a number ("30s") are kept; digits inside words ("v2") are not numbers.
+var signatureNumberRegex = regexp.MustCompile(`\b(?:0x)?[0-9a-fA-F]*[0-9][0-9a-fA-F]*`)
+
+// maxSignatureLength bounds signatures, as long lines rarely differ in a useful way at
+// the end
+const maxSignatureLength = 160
+
+// errorSignature normalizes an error line for counting: ANSI codes are removed,
+// whitespace collapsed and numbers replaced with N.
+func errorSignature(content string) string {
+	signature := strings.Join(strings.Fields(StripANSI(content)), " ")
+	signature = signatureNumberRegex.ReplaceAllString(signature, "N")
+	if len(signature) > maxSignatureLength {
+		signature = signature[:maxSignatureLength]
+	}
+	return signature
+}
@@ -0,0 +1,121 @@
+package buildkitelogs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func writeAnalyticsTestLog(t *testing.T, storage *BlobStorage, key string, metadata *BlobMetadata, start time.Time, lines ...string) {
+	t.Helper()
+	var entries []*logparser.Entry
+	for i, line := range lines {
+		entries = append(entries, &logparser.Entry{Timestamp: start.Add(time.Duration(i) * time.Minute), Content: line})
+	}
+	data, err := os.ReadFile(writeGroupIndexTestFile(t, entries))
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+	if err := storage.WriteWithMetadata(t.Context(), key, data, metadata); err != nil {
+		t.Fatalf("WriteWithMetadata failed: %v", err)
+	}
+}
+
+func TestAnalytics(t *testing.T) {
+	storage, err := NewBlobStorage(t.Context(), "mem://", nil)
+	if err != nil {
+		t.Fatalf("NewBlobStorage failed: %v", err)
+	}
+	defer storage.Close()
+
+	now := time.Now()
+	start := time.UnixMilli(1745322209000)
+	api := func(build, job string) *BlobMetadata {
+		return &BlobMetadata{JobID: job, Organization: "org", Pipeline: "api", Build: build, CachedAt: now, LogSize: 1000, RowCount: 3}
+	}
+	writeAnalyticsTestLog(t, storage, "org-api-1-a.parquet", api("1", "a"), start,
+		"building", "ERROR: timeout after 30s", "done")
+	writeAnalyticsTestLog(t, storage, "org-api-1-b.parquet", api("1", "b"), start,
+		"building", "ERROR: timeout after 45s", "ERROR: disk full", "done")
+	writeAnalyticsTestLog(t, storage, "org-api-2-c.parquet", api("2", "c"), start,
+		"ok")
+	writeAnalyticsTestLog(t, storage, "org-web-1-d.parquet",
+		&BlobMetadata{Organization: "org", Pipeline: "web", Build: "1", CachedAt: now, LogSize: 5000}, start,
+		"ERROR: disk full")
+	writeAnalyticsTestLog(t, storage, "org-old-1-e.parquet",
+		&BlobMetadata{Organization: "org", Pipeline: "old", Build: "1", CachedAt: now.Add(-48 * time.Hour)}, start,
+		"ERROR: ancient")
+	writeAnalyticsTestLog(t, storage, "unknown.parquet", nil, start, "no metadata")
+	// Sidecars are not job logs
+	writeAnalyticsTestLog(t, storage, "org-api-1-a-errors.parquet", api("1", "a"), start, "ERROR: timeout after 30s")
+	if err := storage.WriteWithMetadata(t.Context(), "org-api-1-a.annotations.json", []byte("[]"), nil); err != nil {
+		t.Fatalf("WriteWithMetadata failed: %v", err)
+	}
+
+	report, err := Analytics(t.Context(), storage, AnalyticsOptions{Since: now.Add(-24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("Analytics failed: %v", err)
+	}
+
+	if report.Jobs != 5 || report.Unattributed != 1 {
+		t.Errorf("Jobs = %d, Unattributed = %d; want 5, 1", report.Jobs, report.Unattributed)
+	}
+	if len(report.Pipelines) != 2 {
+		t.Fatalf("got %d pipelines, want 2: %+v", len(report.Pipelines), report.Pipelines)
+	}
+
+	web, api2 := report.Pipelines[0], report.Pipelines[1]
+	if web.Pipeline != "web" || api2.Pipeline != "api" {
+		t.Fatalf("pipelines = %s, %s; want web, api (largest log volume first)", web.Pipeline, api2.Pipeline)
+	}
+	if api2.Jobs != 3 || api2.Builds != 2 || api2.LogBytes != 3000 || api2.Entries != 9 {
+		t.Errorf("api totals = %+v", api2)
+	}
+	if api2.TimedJobs != 3 || api2.AverageDuration != 5*time.Minute/3 {
+		t.Errorf("api durations = %d timed, average %s; want 3, %s", api2.TimedJobs, api2.AverageDuration, 5*time.Minute/3)
+	}
+	if api2.ErrorLines != 3 {
+		t.Errorf("api ErrorLines = %d, want 3", api2.ErrorLines)
+	}
+	want := []ErrorSignature{
+		{Signature: "ERROR: timeout after Ns", Count: 2, Jobs: 2},
+		{Signature: "ERROR: disk full", Count: 1, Jobs: 1},
+	}
+	if len(api2.TopErrors) != len(want) {
+		t.Fatalf("api TopErrors = %+v, want %+v", api2.TopErrors, want)
+	}
+	for i := range want {
+		if api2.TopErrors[i] != want[i] {
+			t.Errorf("api TopErrors[%d] = %+v, want %+v", i, api2.TopErrors[i], want[i])
+		}
+	}
+
+	report, err = Analytics(t.Context(), storage, AnalyticsOptions{MetadataOnly: true, TopErrors: 1})
+	if err != nil {
+		t.Fatalf("Analytics failed: %v", err)
+	}
+	if report.Jobs != 6 || len(report.Pipelines) != 3 {
+		t.Errorf("without Since: Jobs = %d, pipelines = %d; want 6, 3", report.Jobs, len(report.Pipelines))
+	}
+	for _, p := range report.Pipelines {
+		if p.TimedJobs != 0 || p.ErrorLines != 0 || len(p.TopErrors) != 0 {
+			t.Errorf("MetadataOnly read log content of %s: %+v", p.Pipeline, p)
+		}
+	}
+}
+
+func TestErrorSignature(t *testing.T) {
+	tests := map[string]string{
+		"ERROR: timeout after 30s":                       "ERROR: timeout after Ns",
+		"\x1b[31mERROR\x1b[0m:   exit   status 137":      "ERROR: exit status N",
+		"error: commit deadbeef1 not found on port 8080": "error: commit N not found on port N",
+		"Error in module v2":                             "Error in module v2",
+	}
+	for content, want := range tests {
+		if got := errorSignature(content); got != want {
+			t.Errorf("errorSignature(%q) = %q, want %q", content, got, want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Annotation marks a log entry for triage, e.g. with the label "known-flaky" or a
+// "root cause" comment. Annotations are keyed by job and row number, which is stable for
+// the log of a finished job.
+type Annotation struct {
+	RowNumber int64     `json:"row_number"`
+	Label     string    `json:"label,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+	Author    string    `json:"author,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AnnotatedEntry is a log entry with the annotations on its row.
+type AnnotatedEntry struct {
+	ParquetLogEntry
+	Annotations []Annotation `json:"annotations"`
+}
+
+// AnnotationStore keeps annotations in a JSON sidecar blob next to each job's cached
+// log. The sidecar is not removed when the cached log is invalidated or refreshed.
+//
+// Updates read, modify and rewrite the sidecar. They are serialized within a store, but
+// concurrent writers in other processes may overwrite each other's changes.
+type AnnotationStore struct {
+	blobStorage *BlobStorage
+	mu          sync.Mutex
+}
+
+// NewAnnotationStore creates an annotation store in blobStorage.
+func NewAnnotationStore(blobStorage *BlobStorage) *AnnotationStore {
+	return &AnnotationStore{blobStorage: blobStorage}
+}
+
+// AnnotationKey returns the blob key of a job's annotation sidecar.
+func AnnotationKey(org, pipeline, build, job string) string {
+	return strings.TrimSuffix(GenerateBlobKey(org, pipeline, build, job), ".parquet") + ".annotations.json"
+}
+
+// List returns a job's annotations ordered by row number, or nil if it has none.
+func (s *AnnotationStore) List(ctx context.Context, location JobLocation) ([]Annotation, error) {
+	if err := ValidateAPIParams(location.Org, location.Pipeline, location.Build, location.Job); err != nil {
+		return nil, err
+	}
+	return s.read(ctx, annotationKey(location))
+}
+
+// Add records an annotation on a job's log entry. CreatedAt defaults to now.
+func (s *AnnotationStore) Add(ctx context.Context, location JobLocation, annotation Annotation) error {
+	if err := ValidateAPIParams(location.Org, location.Pipeline, location.Build, location.Job); err != nil {
+		return err
+	}
+	if annotation.RowNumber < 0 {
+		return fmt.Errorf("row number must not be negative, got %d", annotation.RowNumber)
+	}
+	if annotation.Label == "" && annotation.Comment == "" {
+		return fmt.Errorf("annotation needs a label or comment")
+	}
+	if annotation.CreatedAt.IsZero() {
+		annotation.CreatedAt = time.Now().UTC()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := annotationKey(location)
+	annotations, err := s.read(ctx, key)
+	if err != nil {
+		return err
+	}
+	annotations = append(annotations, annotation)
+	sortAnnotations(annotations)
+	return s.write(ctx, key, annotations)
+}
+
+// Remove deletes a job's annotations on row, or only those with label if it is not
+// empty (ignoring case), and reports how many were removed.
+func (s *AnnotationStore) Remove(ctx context.Context, location JobLocation, row int64, label string) (int, error) {
+	if err := ValidateAPIParams(location.Org, location.Pipeline, location.Build, location.Job); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := annotationKey(location)
+	annotations, err := s.read(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	kept := slices.DeleteFunc(slices.Clone(annotations), func(a Annotation) bool {
+		return a.RowNumber == row && (label == "" || strings.EqualFold(a.Label, label))
+	})
+	removed := len(annotations) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	if len(kept) == 0 {
+		return removed, s.blobStorage.Delete(ctx, key)
+	}
+	return removed, s.write(ctx, key, kept)
+}
+
+func annotationKey(location JobLocation) string {
+	return AnnotationKey(location.Org, location.Pipeline, location.Build, location.Job)
+}
+
+func (s *AnnotationStore) read(ctx context.Context, key string) ([]Annotation, error) {
+	exists, err := s.blobStorage.Exists(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check annotations: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	reader, err := s.blobStorage.Reader(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read annotations: %w", err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read annotations: %w", err)
+	}
+	var annotations []Annotation
+	if err := json.Unmarshal(data, &annotations); err != nil {
+		return nil, fmt.Errorf("failed to decode annotations: %w", err)
+	}
+	return annotations, nil
+}
+
+func (s *AnnotationStore) write(ctx context.Context, key string, annotations []Annotation) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(annotations); err != nil {
+		return fmt.Errorf("failed to encode annotations: %w", err)
+	}
+	if err := s.blobStorage.WriteWithMetadata(ctx, key, buf.Bytes(), nil); err != nil {
+		return fmt.Errorf("failed to write annotations: %w", err)
+	}
+	return nil
+}
+
+func sortAnnotations(annotations []Annotation) {
+	slices.SortStableFunc(annotations, func(a, b Annotation) int {
+		return cmp.Or(cmp.Compare(a.RowNumber, b.RowNumber), a.CreatedAt.Compare(b.CreatedAt))
+	})
+}
+
+// WithAnnotations merges annotations into the reader's, so that Annotations and
+// AnnotatedEntriesIter report them. It returns the reader.
+func (pr *ParquetReader) WithAnnotations(annotations []Annotation) *ParquetReader {
+	pr.annotations = append(pr.annotations, annotations...)
+	sortAnnotations(pr.annotations)
+	return pr
+}
+
+// Annotations returns the annotations attached to the reader, ordered by row number.
+// Readers created by Client include the job's stored annotations.
+func (pr *ParquetReader) Annotations() []Annotation {
+	return pr.annotations
+}
+
+// AnnotatedEntriesIter yields the annotated entries in row order, reading only their
+// rows. If label is not empty, only entries with an annotation carrying that label
+// (ignoring case) are yielded, though each entry still lists all its annotations.
+func (pr *ParquetReader) AnnotatedEntriesIter(ctx context.Context, label string) iter.Seq2[AnnotatedEntry, error] {
+	return func(yield func(AnnotatedEntry, error) bool) {
+		byRow := make(map[int64][]Annotation)
+		for _, a := range pr.annotations {
+			byRow[a.RowNumber] = append(byRow[a.RowNumber], a)
+		}
+
+		for entry, err := range pr.viewEntries(readParquetFileRowRangesIter(ctx, pr.source, pr.annotationRanges(label), pr.readColumns())) {
+			if err != nil {
+				yield(AnnotatedEntry{}, err)
+				return
+			}
+			if !yield(AnnotatedEntry{ParquetLogEntry: entry, Annotations: byRow[entry.RowNumber]}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// annotationRanges returns the sorted, merged row ranges of the annotations with label,
+// or of all annotations if label is empty.
+func (pr *ParquetReader) annotationRanges(label string) []GroupRange {
+	var ranges []GroupRange
+	for _, a := range pr.annotations {
+		if label != "" && !strings.EqualFold(a.Label, label) {
+			continue
+		}
+		if n := len(ranges); n > 0 && ranges[n-1].LastRow >= a.RowNumber-1 {
+			ranges[n-1].LastRow = max(ranges[n-1].LastRow, a.RowNumber)
+			continue
+		}
+		ranges = append(ranges, GroupRange{FirstRow: a.RowNumber, LastRow: a.RowNumber})
+	}
+	return ranges
+}
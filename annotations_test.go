@@ -0,0 +1,116 @@
+package buildkitelogs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnnotationStore(t *testing.T) {
+	storage, err := NewBlobStorage(t.Context(), "mem://", nil)
+	if err != nil {
+		t.Fatalf("NewBlobStorage failed: %v", err)
+	}
+	defer storage.Close()
+
+	store := NewAnnotationStore(storage)
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"}
+
+	annotations, err := store.List(t.Context(), location)
+	if err != nil || len(annotations) != 0 {
+		t.Fatalf("List on empty store = %v, %v", annotations, err)
+	}
+
+	for _, a := range []Annotation{
+		{RowNumber: 20, Label: "root-cause", Comment: "DNS timeout"},
+		{RowNumber: 5, Label: "known-flaky"},
+		{RowNumber: 20, Label: "known-flaky"},
+	} {
+		if err := store.Add(t.Context(), location, a); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	if err := store.Add(t.Context(), location, Annotation{RowNumber: 1}); err == nil {
+		t.Error("expected error for annotation without label or comment")
+	}
+
+	annotations, err = store.List(t.Context(), location)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(annotations) != 3 || annotations[0].RowNumber != 5 || annotations[1].Label != "root-cause" {
+		t.Fatalf("unexpected annotations: %+v", annotations)
+	}
+	if annotations[0].CreatedAt.IsZero() {
+		t.Error("CreatedAt was not set")
+	}
+
+	removed, err := store.Remove(t.Context(), location, 20, "KNOWN-FLAKY")
+	if err != nil || removed != 1 {
+		t.Fatalf("Remove = %d, %v; want 1", removed, err)
+	}
+	removed, err = store.Remove(t.Context(), location, 20, "")
+	if err != nil || removed != 1 {
+		t.Fatalf("Remove = %d, %v; want 1", removed, err)
+	}
+	removed, err = store.Remove(t.Context(), location, 5, "")
+	if err != nil || removed != 1 {
+		t.Fatalf("Remove = %d, %v; want 1", removed, err)
+	}
+	if exists, _ := storage.Exists(t.Context(), AnnotationKey("org", "pipeline", "1", "job")); exists {
+		t.Error("empty annotation sidecar was not deleted")
+	}
+}
+
+func TestParquetReader_AnnotatedEntriesIter(t *testing.T) {
+	filename := writeGroupIndexTestFile(t, groupIndexTestEntries())
+	reader := NewParquetReader(filename).WithAnnotations([]Annotation{
+		{RowNumber: 2000, Label: "root-cause"},
+		{RowNumber: 4, Label: "known-flaky"},
+		{RowNumber: 5, Label: "known-flaky", Comment: "retry passes"},
+		{RowNumber: 5, Label: "root-cause"},
+	})
+
+	var rows []int64
+	for entry, err := range reader.AnnotatedEntriesIter(t.Context(), "") {
+		if err != nil {
+			t.Fatalf("AnnotatedEntriesIter failed: %v", err)
+		}
+		rows = append(rows, entry.RowNumber)
+		if entry.RowNumber == 5 && len(entry.Annotations) != 2 {
+			t.Errorf("row 5 has %d annotations, want 2", len(entry.Annotations))
+		}
+	}
+	if len(rows) != 3 || rows[0] != 4 || rows[1] != 5 || rows[2] != 2000 {
+		t.Errorf("annotated rows = %v, want [4 5 2000]", rows)
+	}
+
+	rows = nil
+	for entry, err := range reader.AnnotatedEntriesIter(t.Context(), "Root-Cause") {
+		if err != nil {
+			t.Fatalf("AnnotatedEntriesIter failed: %v", err)
+		}
+		rows = append(rows, entry.RowNumber)
+	}
+	if len(rows) != 2 || rows[0] != 5 || rows[1] != 2000 {
+		t.Errorf("root-cause rows = %v, want [5 2000]", rows)
+	}
+}
+
+func TestClient_ReaderIncludesAnnotations(t *testing.T) {
+	client := newTestClient(t, newTerminalMock())
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "test-job"}
+
+	if err := client.Annotations().Add(t.Context(), location, Annotation{RowNumber: 0, Label: "root-cause"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	reader, err := client.NewReader(t.Context(), "org", "pipeline", "1", "test-job", time.Minute, false)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	if got := reader.Annotations(); len(got) != 1 || got[0].Label != "root-cause" {
+		t.Errorf("reader annotations = %+v", got)
+	}
+}
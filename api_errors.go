@@ -0,0 +1,118 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v5"
+)
+
+// Response headers recorded on API errors.
+const (
+	requestIDHeader          = "X-Request-Id"
+	rateLimitLimitHeader     = "RateLimit-Limit"
+	rateLimitRemainingHeader = "RateLimit-Remaining"
+	rateLimitResetHeader     = "RateLimit-Reset"
+	retryAfterHeader         = "Retry-After"
+)
+
+// APIError wraps a failed Buildkite API call with the identifiers of the response, so
+// support requests to Buildkite can reference the exact request. It unwraps to the
+// underlying go-buildkite error.
+type APIError struct {
+	StatusCode         int           // HTTP status, or 0 if no response was received
+	RequestID          string        // Value of the X-Request-Id response header
+	RateLimitLimit     int           // Requests allowed in the current window, if reported
+	RateLimitRemaining int           // Requests left in the current window, if reported
+	RateLimitReset     time.Duration // Time until the window resets, if reported
+	RetryAfter         time.Duration // Wait requested by the Retry-After header, if sent
+	Err                error
+}
+
+func (e *APIError) Error() string {
+	if e.RequestID == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v (request ID %s)", e.Err, e.RequestID)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// RequestIDFromError returns the Buildkite request ID recorded on err, if any.
+func RequestIDFromError(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RequestID
+	}
+	return ""
+}
+
+// wrapAPIError records the response identifiers of a failed go-buildkite call on err.
+// resp may be nil; error responses also carry the HTTP response themselves.
+func wrapAPIError(resp *buildkite.Response, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var httpResp *http.Response
+	if resp != nil {
+		httpResp = resp.Response
+	}
+	var errResp *buildkite.ErrorResponse
+	if httpResp == nil && errors.As(err, &errResp) {
+		httpResp = errResp.Response
+	}
+	if httpResp == nil {
+		return err
+	}
+
+	header := httpResp.Header
+	apiErr := &APIError{
+		StatusCode: httpResp.StatusCode,
+		RequestID:  header.Get(requestIDHeader),
+		Err:        err,
+	}
+	apiErr.RateLimitLimit, _ = strconv.Atoi(header.Get(rateLimitLimitHeader))
+	apiErr.RateLimitRemaining, _ = strconv.Atoi(header.Get(rateLimitRemainingHeader))
+	if reset, convErr := strconv.Atoi(header.Get(rateLimitResetHeader)); convErr == nil {
+		apiErr.RateLimitReset = time.Duration(reset) * time.Second
+	}
+	apiErr.RetryAfter = parseRetryAfter(header.Get(retryAfterHeader), time.Now())
+	return apiErr
+}
+
+// parseRetryAfter returns the wait a Retry-After header value asks for, given in seconds
+// or as an HTTP date, or 0 if it is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+// serverRetryWait returns how long the response of a failed call asked clients to wait
+// before retrying: its Retry-After header, or the rate limit reset of a 429.
+func serverRetryWait(err error) time.Duration {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return 0
+	}
+	if apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+	if apiErr.StatusCode == http.StatusTooManyRequests {
+		return apiErr.RateLimitReset
+	}
+	return 0
+}
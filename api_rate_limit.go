@@ -0,0 +1,81 @@
+package buildkitelogs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiting how often Buildkite API calls start. It is safe
+// for concurrent use, so one limiter can be shared by several clients with
+// WithRateLimiter to keep them within a single budget.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens added per second
+	burst  float64 // Most tokens the bucket holds
+	tokens float64 // Available tokens; negative while calls wait for reserved tokens
+	last   time.Time
+}
+
+// NewRateLimiter returns a limiter allowing rps calls per second on average and up to
+// burst calls at once. burst is at least 1. rps <= 0 returns nil, which never waits, as
+// for WithRateLimit.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	b := float64(max(burst, 1))
+	return &RateLimiter{
+		rate:   rps,
+		burst:  b,
+		tokens: b,
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a call may start, or returns the context's error if ctx is done
+// first. A nil or zero RateLimiter never waits.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil || l.rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, l.burst)
+	l.last = now
+	l.tokens--
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if err := sleepContext(ctx, wait); err != nil {
+		// Hand back the reservation so waiting callers aren't delayed by a call that never ran
+		l.mu.Lock()
+		l.tokens = min(l.tokens+1, l.burst)
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// WithRateLimit limits the client to rps Buildkite API calls per second on average, with
+// bursts of up to burst calls. Every call of the client, such as job status lookups, log
+// downloads, build listings and their retries, draws from the same budget, so a fan-out
+// over many jobs stays under Buildkite's API limits. Calls are not limited by default,
+// and rps <= 0 removes the limit.
+func WithRateLimit(rps float64, burst int) APIClientOption {
+	return func(c *BuildkiteAPIClient) {
+		c.limiter = NewRateLimiter(rps, burst)
+	}
+}
+
+// WithRateLimiter makes the client draw from limiter, which may be shared with other
+// clients. A nil limiter removes the limit.
+func WithRateLimiter(limiter *RateLimiter) APIClientOption {
+	return func(c *BuildkiteAPIClient) {
+		c.limiter = limiter
+	}
+}
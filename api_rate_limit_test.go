@@ -0,0 +1,137 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v5"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	limiter := NewRateLimiter(20, 2)
+
+	start := time.Now()
+	for range 4 {
+		if err := limiter.Wait(t.Context()); err != nil {
+			t.Fatalf("Wait: %v", err)
+		}
+	}
+	// The burst of 2 starts at once; the other 2 calls wait 50ms each
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("4 calls took %s, want at least 100ms", elapsed)
+	}
+}
+
+func TestRateLimiter_WaitCanceled(t *testing.T) {
+	limiter := NewRateLimiter(0.1, 1)
+	if err := limiter.Wait(t.Context()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestRateLimit_SharedAcrossOperations(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if strings.HasSuffix(r.URL.Path, "/log") {
+			_, _ = io.WriteString(w, "log line\n")
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"job-1","state":"passed"}`))
+	}))
+	defer server.Close()
+
+	bkClient, err := buildkite.NewOpts(buildkite.WithBaseURL(server.URL), buildkite.WithTokenAuth("test-token"))
+	if err != nil {
+		t.Fatalf("NewOpts: %v", err)
+	}
+	api := NewBuildkiteAPIExistingClient(bkClient, WithRateLimit(10, 1))
+
+	start := time.Now()
+	if _, err := api.GetJobStatus(t.Context(), "org", "pipeline", "123", "job-1"); err != nil {
+		t.Fatalf("GetJobStatus: %v", err)
+	}
+	reader, err := api.GetJobLog(t.Context(), "org", "pipeline", "123", "job-1")
+	if err != nil {
+		t.Fatalf("GetJobLog: %v", err)
+	}
+	defer reader.Close()
+	if _, err := io.ReadAll(reader); err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+
+	if requests.Load() != 2 {
+		t.Errorf("requests = %d, want 2", requests.Load())
+	}
+	// The download had to wait for the token the status lookup used
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("calls took %s, want at least 100ms", elapsed)
+	}
+}
+
+func TestNewRateLimiter_NonPositiveRate(t *testing.T) {
+	for _, rps := range []float64{0, -1} {
+		limiter := NewRateLimiter(rps, 1)
+		if limiter != nil {
+			t.Errorf("NewRateLimiter(%v, 1) = %+v, want nil", rps, limiter)
+		}
+		ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+		for range 3 {
+			if err := limiter.Wait(ctx); err != nil {
+				t.Fatalf("Wait: %v", err)
+			}
+		}
+		cancel()
+	}
+}
+
+func TestRateLimit_BuildListings(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if strings.HasSuffix(r.URL.Path, "/builds") {
+			_, _ = w.Write([]byte(`[{"number":123,"state":"passed"}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"number":123,"state":"passed"}`))
+	}))
+	defer server.Close()
+
+	bkClient, err := buildkite.NewOpts(buildkite.WithBaseURL(server.URL), buildkite.WithTokenAuth("test-token"))
+	if err != nil {
+		t.Fatalf("NewOpts: %v", err)
+	}
+	api := NewBuildkiteAPIExistingClient(bkClient, WithRateLimit(10, 1))
+
+	start := time.Now()
+	if _, err := api.GetBuild(t.Context(), "org", "pipeline", "123"); err != nil {
+		t.Fatalf("GetBuild: %v", err)
+	}
+	if _, err := api.GetLatestBuild(t.Context(), "org", "pipeline", "main"); err != nil {
+		t.Fatalf("GetLatestBuild: %v", err)
+	}
+	if _, err := api.ListOrgBuilds(t.Context(), "org", time.Time{}, ""); err != nil {
+		t.Fatalf("ListOrgBuilds: %v", err)
+	}
+
+	if requests.Load() != 3 {
+		t.Errorf("requests = %d, want 3", requests.Load())
+	}
+	// The burst of 1 starts at once; the other 2 calls wait 100ms each
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("calls took %s, want at least 200ms", elapsed)
+	}
+}
@@ -0,0 +1,69 @@
+package buildkitelogs
+
+import (
+	"context"
+	"io"
+)
+
+// WithRetryPolicy sets how failed Buildkite API calls are retried: job status lookups,
+// log access checks and log downloads, by pipeline or by organization. Retries wait at
+// least as long as the failed response asks (Retry-After, or RateLimit-Reset on a 429),
+// so a rate limited fan-out over many jobs recovers instead of failing. A log download
+// is only retried until its first bytes arrive. A response asking for a longer wait than
+// the policy's MaxBackoff is not retried. The default, the zero RetryPolicy, makes a
+// single attempt; DefaultRetryPolicy is a reasonable start.
+//
+// A Client over an API client with this policy leaves job status retries to it, ignoring
+// WithJobStatusRetry.
+func WithRetryPolicy(policy RetryPolicy) APIClientOption {
+	return func(c *BuildkiteAPIClient) {
+		c.retry = policy
+	}
+}
+
+// withRetry runs call until it succeeds or the client's retry policy gives up, and
+// returns the last error. Each attempt waits for the client's rate limiter first.
+// canRetry, if set, is also asked before each retry. Retries are reported to the
+// OnRetryFunc hooks of the Client stage making the call.
+func (c *BuildkiteAPIClient) withRetry(ctx context.Context, call func() error, canRetry func() bool) error {
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		err := call()
+		if err == nil || attempt >= c.retry.attempts() || !c.retry.retryable(err) || (canRetry != nil && !canRetry()) {
+			return err
+		}
+		wait, ok := c.retry.delay(attempt+1, err)
+		if !ok {
+			return err
+		}
+		notifyRetry(ctx, attempt, err)
+		if sleepErr := sleepContext(ctx, wait); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// retryingAPI is implemented by API clients that retry failed calls themselves.
+type retryingAPI interface {
+	retriesCalls() bool
+}
+
+// retriesCalls reports whether the client's retry policy makes more than one attempt.
+func (c *BuildkiteAPIClient) retriesCalls() bool {
+	return c.retry.attempts() > 1
+}
+
+// writeTracker records whether anything was written through it.
+type writeTracker struct {
+	w       io.Writer
+	written bool
+}
+
+func (t *writeTracker) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		t.written = true
+	}
+	return t.w.Write(p)
+}
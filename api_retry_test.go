@@ -0,0 +1,147 @@
+package buildkitelogs
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v5"
+)
+
+func newRetryTestAPI(t *testing.T, handler http.HandlerFunc, policy RetryPolicy) *BuildkiteAPIClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	bkClient, err := buildkite.NewOpts(buildkite.WithBaseURL(server.URL), buildkite.WithTokenAuth("test-token"))
+	if err != nil {
+		t.Fatalf("NewOpts: %v", err)
+	}
+	return NewBuildkiteAPIExistingClient(bkClient, WithRetryPolicy(policy))
+}
+
+func TestGetJobLog_RetriesRateLimit(t *testing.T) {
+	var requests atomic.Int32
+	api := newRetryTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"message":"rate limited"}`))
+			return
+		}
+		_, _ = io.WriteString(w, "log line\n")
+	}, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	start := time.Now()
+	reader, err := api.GetJobLog(t.Context(), "org", "pipeline", "123", "job-1")
+	if err != nil {
+		t.Fatalf("GetJobLog: %v", err)
+	}
+	defer reader.Close()
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != "log line\n" {
+		t.Errorf("log = %q", got)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("requests = %d, want 2", requests.Load())
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want the requested Retry-After of 1s", elapsed)
+	}
+}
+
+func TestGetJobStatus_RetryGivesUp(t *testing.T) {
+	var requests atomic.Int32
+	api := newRetryTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"message":"unavailable"}`))
+	}, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	if _, err := api.GetJobStatus(t.Context(), "org", "pipeline", "123", "job-1"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if requests.Load() != 3 {
+		t.Errorf("requests = %d, want 3", requests.Load())
+	}
+}
+
+func TestGetJobStatus_NoRetryByDefault(t *testing.T) {
+	var requests atomic.Int32
+	api := newRetryTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"message":"rate limited"}`))
+	}, RetryPolicy{})
+
+	if _, err := api.GetJobStatus(t.Context(), "org", "pipeline", "123", "job-1"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if requests.Load() != 1 {
+		t.Errorf("requests = %d, want 1", requests.Load())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 4, 22, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"-5", 0},
+		{now.Add(time.Minute).Format(http.TimeFormat), time.Minute},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestGetJobStatus_RetryAfterBeyondMaxBackoff(t *testing.T) {
+	var requests atomic.Int32
+	api := newRetryTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"message":"rate limited"}`))
+	}, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond})
+
+	start := time.Now()
+	if _, err := api.GetJobStatus(t.Context(), "org", "pipeline", "123", "job-1"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if requests.Load() != 1 {
+		t.Errorf("requests = %d, want 1", requests.Load())
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("gave up after %s, want no wait for a Retry-After beyond MaxBackoff", elapsed)
+	}
+}
+
+func TestClient_JobStatusRetryDefersToAPIClient(t *testing.T) {
+	var requests atomic.Int32
+	api := newRetryTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"message":"unavailable"}`))
+	}, RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+	client := newTestClient(t, api, WithJobStatusRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+	if _, err := client.getJobStatus(t.Context(), api, "org", "pipeline", "123", "job-1"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if requests.Load() != 2 {
+		t.Errorf("requests = %d, want the API client's 2 attempts only", requests.Load())
+	}
+}
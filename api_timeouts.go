@@ -0,0 +1,83 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// DefaultAPIRequestTimeout bounds each Buildkite API call other than log downloads, such
+// as job status and build lookups.
+const DefaultAPIRequestTimeout = 10 * time.Second
+
+// DefaultLogIdleTimeout is how long a log download may go without receiving data before
+// it is abandoned.
+const DefaultLogIdleTimeout = 60 * time.Second
+
+// ErrLogStalled is returned when a log download receives no data for the client's log
+// idle timeout.
+var ErrLogStalled = errors.New("log download stalled")
+
+// APIClientOption configures a BuildkiteAPIClient.
+type APIClientOption func(*BuildkiteAPIClient)
+
+// WithRequestTimeout sets the deadline of each Buildkite API call other than log
+// downloads, applied on top of the caller's context. Default is DefaultAPIRequestTimeout;
+// 0 leaves the calls bounded only by their context.
+func WithRequestTimeout(timeout time.Duration) APIClientOption {
+	return func(c *BuildkiteAPIClient) {
+		c.requestTimeout = timeout
+	}
+}
+
+// WithLogIdleTimeout sets how long a log download may wait for the response or go
+// without receiving data before it fails with ErrLogStalled. Downloads have no overall
+// deadline, so a large log that keeps arriving is never cut off; bound them with the
+// caller's context instead. Default is DefaultLogIdleTimeout; 0 disables it.
+func WithLogIdleTimeout(timeout time.Duration) APIClientOption {
+	return func(c *BuildkiteAPIClient) {
+		c.logIdleTimeout = timeout
+	}
+}
+
+// requestContext returns the context for an API call other than a log download
+func (c *BuildkiteAPIClient) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.requestTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.requestTimeout)
+}
+
+// idleTimeoutWriter cancels its download with ErrLogStalled when no data was written
+// to it for the idle timeout.
+type idleTimeoutWriter struct {
+	w       io.Writer
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+// newIdleTimeoutWriter returns a context for the download feeding w that is canceled
+// with ErrLogStalled when the download stalls, and a stop function that releases it.
+// A zero timeout only ties the download to ctx.
+func newIdleTimeoutWriter(ctx context.Context, w io.Writer, timeout time.Duration) (context.Context, *idleTimeoutWriter, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	iw := &idleTimeoutWriter{w: w, timeout: timeout}
+	if timeout > 0 {
+		iw.timer = time.AfterFunc(timeout, func() { cancel(ErrLogStalled) })
+	}
+	stop := func() {
+		if iw.timer != nil {
+			iw.timer.Stop()
+		}
+		cancel(nil)
+	}
+	return ctx, iw, stop
+}
+
+func (w *idleTimeoutWriter) Write(p []byte) (int, error) {
+	if w.timer != nil {
+		w.timer.Reset(w.timeout)
+	}
+	return w.w.Write(p)
+}
@@ -0,0 +1,169 @@
+package buildkitelogs
+
+import (
+	"context"
+	"iter"
+	"sync"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v5"
+)
+
+// DefaultAttachTTL is how long JobLog reads reuse the cached log of a running job before
+// downloading it again.
+const DefaultAttachTTL = 30 * time.Second
+
+// Attachment adds cached job log queries to an existing go-buildkite client, e.g.
+//
+//	logs := buildkitelogs.Attach(client)
+//	defer logs.Close()
+//	for result, err := range logs.Job(org, pipeline, build, job).Search(ctx, opts) {
+//
+// The Client doing the caching is created on first use, so attaching is free for
+// programs that never read a log.
+type Attachment struct {
+	api        BuildkiteAPI
+	storageURL string
+	opts       []ClientOption
+
+	mu     sync.Mutex
+	client *Client
+}
+
+// Attach returns an Attachment caching logs in the default storage location (see
+// StorageMode). The options configure the Client created on first use.
+func Attach(client *buildkite.Client, opts ...ClientOption) *Attachment {
+	return AttachWithStorage(client, "", opts...)
+}
+
+// AttachWithStorage returns an Attachment caching logs in storageURL (file://path,
+// s3://bucket, etc).
+func AttachWithStorage(client *buildkite.Client, storageURL string, opts ...ClientOption) *Attachment {
+	return attachAPI(NewBuildkiteAPIExistingClient(client), storageURL, opts...)
+}
+
+func attachAPI(api BuildkiteAPI, storageURL string, opts ...ClientOption) *Attachment {
+	return &Attachment{api: api, storageURL: storageURL, opts: opts}
+}
+
+// Client returns the Client doing the caching, creating it on first use. A failed
+// creation is retried by the next call.
+func (a *Attachment) Client(ctx context.Context) (*Client, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.client == nil {
+		client, err := NewClientWithAPI(ctx, a.api, a.storageURL, a.opts...)
+		if err != nil {
+			return nil, err
+		}
+		a.client = client
+	}
+	return a.client, nil
+}
+
+// Close closes the Client, if one was created.
+func (a *Attachment) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.client == nil {
+		return nil
+	}
+	return a.client.Close()
+}
+
+// Job returns a handle on a job's log. Nothing is downloaded until it is read.
+func (a *Attachment) Job(org, pipeline, build, job string) *JobLog {
+	return &JobLog{
+		attachment: a,
+		location:   JobLocation{Org: org, Pipeline: pipeline, Build: build, Job: job},
+		ttl:        DefaultAttachTTL,
+	}
+}
+
+// JobLog is a job's log, downloaded and cached the first time it is read.
+type JobLog struct {
+	attachment *Attachment
+	location   JobLocation
+	ttl        time.Duration
+}
+
+// WithTTL returns a copy of the handle whose reads reuse the cached log of a running job
+// for ttl. Logs of finished jobs are cached for good regardless.
+func (j *JobLog) WithTTL(ttl time.Duration) *JobLog {
+	copied := *j
+	copied.ttl = ttl
+	return &copied
+}
+
+// Location returns the job the handle refers to.
+func (j *JobLog) Location() JobLocation {
+	return j.location
+}
+
+// Reader returns a reader over the cached log, downloading it if needed. Close the
+// reader when done.
+func (j *JobLog) Reader(ctx context.Context) (*ParquetReader, error) {
+	client, err := j.attachment.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewReader(ctx, j.location.Org, j.location.Pipeline, j.location.Build, j.location.Job, j.ttl, false)
+}
+
+// Entries iterates over every entry of the log. See ParquetReader.ReadEntriesIter.
+func (j *JobLog) Entries(ctx context.Context) iter.Seq2[ParquetLogEntry, error] {
+	return func(yield func(ParquetLogEntry, error) bool) {
+		reader, err := j.Reader(ctx)
+		if err != nil {
+			yield(ParquetLogEntry{}, err)
+			return
+		}
+		defer reader.Close()
+
+		for entry, err := range reader.ReadEntriesIter(ctx) {
+			if !yield(entry, err) {
+				return
+			}
+		}
+	}
+}
+
+// Search iterates over the log's entries matching options. See
+// ParquetReader.SearchEntriesIter.
+func (j *JobLog) Search(ctx context.Context, options SearchOptions) iter.Seq2[SearchResult, error] {
+	return func(yield func(SearchResult, error) bool) {
+		reader, err := j.Reader(ctx)
+		if err != nil {
+			yield(SearchResult{}, err)
+			return
+		}
+		defer reader.Close()
+
+		for result, err := range reader.SearchEntriesIter(ctx, options) {
+			if !yield(result, err) {
+				return
+			}
+		}
+	}
+}
+
+// Info returns the job's metadata and cache status without downloading the log. See
+// Client.JobInfo.
+func (j *JobLog) Info(ctx context.Context) (*JobInfo, error) {
+	client, err := j.attachment.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.JobInfo(ctx, j.location.Org, j.location.Pipeline, j.location.Build, j.location.Job)
+}
+
+// Status returns the job's status without downloading the log. See Client.JobStatus.
+func (j *JobLog) Status(ctx context.Context) (*JobStatus, error) {
+	client, err := j.attachment.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.JobStatus(ctx, j.location)
+}
@@ -0,0 +1,49 @@
+package buildkitelogs
+
+import (
+	"testing"
+)
+
+func TestAttachment_JobSearch(t *testing.T) {
+	api := newTerminalMock()
+	logs := attachAPI(api, "file://"+t.TempDir())
+	t.Cleanup(func() { _ = logs.Close() })
+
+	job := logs.Job("org", "pipeline", "1", "test-job")
+	if logCalls, _ := api.calls(); logCalls != 0 {
+		t.Fatalf("Job made %d log calls before the log was read", logCalls)
+	}
+
+	var matches []string
+	for result, err := range job.Search(t.Context(), SearchOptions{Pattern: "log entry"}) {
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		matches = append(matches, result.Match.Content)
+	}
+	if len(matches) != 1 || matches[0] != "Test log entry" {
+		t.Errorf("matches = %q, want [\"Test log entry\"]", matches)
+	}
+
+	// The second read is served from the cache
+	entries := 0
+	for _, err := range job.Entries(t.Context()) {
+		if err != nil {
+			t.Fatalf("Entries failed: %v", err)
+		}
+		entries++
+	}
+	if entries != 1 {
+		t.Errorf("entries = %d, want 1", entries)
+	}
+	if logCalls, _ := api.calls(); logCalls != 1 {
+		t.Errorf("log calls = %d, want 1", logCalls)
+	}
+}
+
+func TestAttachment_CloseWithoutUse(t *testing.T) {
+	logs := attachAPI(newTerminalMock(), "file://"+t.TempDir())
+	if err := logs.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"iter"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// ExportSeq2ToBlob writes log entries as Parquet straight to a blob, without a local
+// file. Metadata is stored as given: blob attributes are fixed when the write starts,
+// so fields only known afterwards (ParquetSize, RowCount, ContentSHA256) must be set by
+// the caller or left empty. The client's cache still writes through a temp file for that reason.
+//
+// The blob only appears once every entry was written; if iteration, encoding or ctx
+// fails, the upload is aborted and any existing blob under key is left as it was. Read
+// the blob back with BlobStorage.OpenReaderAt and NewParquetReaderFromReaderAt, which
+// fetch only the byte ranges a query needs.
+func ExportSeq2ToBlob(ctx context.Context, seq iter.Seq2[*logparser.Entry, error], storage *BlobStorage, key string, metadata *BlobMetadata, opts ...ParquetWriterOption) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	blobWriter, err := storage.NewWriter(ctx, key, metadata)
+	if err != nil {
+		return 0, err
+	}
+	writer, err := NewParquetWriterForWriter(blobWriter, opts...)
+	if err != nil {
+		cancel()
+		_ = blobWriter.Close()
+		return 0, err
+	}
+
+	rows, err := ExportSeq2ToLogWriter(seq, writer, nil)
+	if err != nil {
+		// Cancel before closing so the partial file is discarded, not committed
+		cancel()
+		_ = writer.Close()
+		return rows, err
+	}
+	// Closing the Parquet writer writes the footer and closes the blob writer
+	if err := writer.Close(); err != nil {
+		return rows, fmt.Errorf("failed to write blob: %w", err)
+	}
+	return rows, nil
+}
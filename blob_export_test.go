@@ -0,0 +1,117 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"iter"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestExportSeq2ToBlob(t *testing.T) {
+	storage, err := NewBlobStorage(t.Context(), "mem://", nil)
+	if err != nil {
+		t.Fatalf("NewBlobStorage failed: %v", err)
+	}
+	defer storage.Close()
+
+	const key = "org-pipeline-1-job.parquet"
+	log := "\x1b_bk;t=1745322209921\x07first\n\x1b_bk;t=1745322209922\x07second\n"
+	rows, err := ExportSeq2ToBlob(t.Context(), logparser.New().All(strings.NewReader(log)), storage, key, &BlobMetadata{JobID: "job", IsTerminal: true})
+	if err != nil {
+		t.Fatalf("ExportSeq2ToBlob failed: %v", err)
+	}
+	if rows != 2 {
+		t.Errorf("rows = %d, want 2", rows)
+	}
+
+	metadata, err := storage.ReadWithMetadata(t.Context(), key)
+	if err != nil {
+		t.Fatalf("ReadWithMetadata failed: %v", err)
+	}
+	if metadata == nil || metadata.JobID != "job" || !metadata.IsTerminal {
+		t.Errorf("metadata = %+v", metadata)
+	}
+
+	blob, err := storage.OpenReaderAt(t.Context(), key)
+	if err != nil {
+		t.Fatalf("OpenReaderAt failed: %v", err)
+	}
+	defer blob.Close()
+	var contents []string
+	for entry, err := range NewParquetReaderFromReaderAt(blob, blob.Size()).ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		contents = append(contents, entry.Content)
+	}
+	if strings.Join(contents, ",") != "first,second" {
+		t.Errorf("contents = %q", contents)
+	}
+}
+
+func TestExportSeq2ToBlob_AbortsOnError(t *testing.T) {
+	storage, err := NewBlobStorage(t.Context(), "mem://", nil)
+	if err != nil {
+		t.Fatalf("NewBlobStorage failed: %v", err)
+	}
+	defer storage.Close()
+
+	errBroken := errors.New("connection reset")
+	seq := iter.Seq2[*logparser.Entry, error](func(yield func(*logparser.Entry, error) bool) {
+		if yield(&logparser.Entry{Content: "partial"}, nil) {
+			yield(nil, errBroken)
+		}
+	})
+
+	const key = "org-pipeline-1-job.parquet"
+	if _, err := ExportSeq2ToBlob(t.Context(), seq, storage, key, nil); !errors.Is(err, errBroken) {
+		t.Fatalf("error = %v, want %v", err, errBroken)
+	}
+	exists, err := storage.Exists(t.Context(), key)
+	if err != nil {
+		t.Fatalf("Exists failed: %v", err)
+	}
+	if exists {
+		t.Error("failed export left a blob behind")
+	}
+}
+
+func TestNewParquetReaderFromBlob(t *testing.T) {
+	storage, err := NewBlobStorage(t.Context(), "mem://", nil)
+	if err != nil {
+		t.Fatalf("NewBlobStorage failed: %v", err)
+	}
+	defer storage.Close()
+
+	const key = "org-pipeline-1-job.parquet"
+	log := "\x1b_bk;t=1745322209921\x07first\n\x1b_bk;t=1745322209922\x07second\n\x1b_bk;t=1745322209923\x07third\n"
+	if _, err := ExportSeq2ToBlob(t.Context(), logparser.New().All(strings.NewReader(log)), storage, key, nil); err != nil {
+		t.Fatalf("ExportSeq2ToBlob failed: %v", err)
+	}
+
+	reader := NewParquetReaderFromBlob(t.Context(), storage, key)
+	defer reader.Close()
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	if info.RowCount != 3 {
+		t.Errorf("RowCount = %d, want 3", info.RowCount)
+	}
+	var contents []string
+	for entry, err := range reader.SeekToRow(t.Context(), 2) {
+		if err != nil {
+			t.Fatalf("SeekToRow failed: %v", err)
+		}
+		contents = append(contents, entry.Content)
+	}
+	if strings.Join(contents, ",") != "third" {
+		t.Errorf("contents from row 2 = %q, want [third]", contents)
+	}
+
+	if _, err := NewParquetReaderFromBlob(t.Context(), storage, "missing.parquet").GetFileInfo(); err == nil {
+		t.Error("expected an error for a missing blob")
+	}
+}
@@ -3,6 +3,7 @@ package buildkitelogs
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/url"
@@ -13,13 +14,21 @@ import (
 	"time"
 
 	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/azureblob"
 	_ "gocloud.dev/blob/fileblob"
+	_ "gocloud.dev/blob/memblob"
 	_ "gocloud.dev/blob/s3blob"
 )
 
+// MemoryStorageURL is the storage URL of an in-memory cache. Blobs are kept only by the
+// BlobStorage that opened it, and each NewBlobStorage call with it starts out empty.
+const MemoryStorageURL = "mem://"
+
 // BlobStorage provides an abstraction over blob storage backends
 type BlobStorage struct {
-	bucket *blob.Bucket
+	bucket     *blob.Bucket
+	url        string
+	objectTags bool // Tag S3 objects with their metadata, see WithObjectTags
 }
 
 // BlobMetadata contains metadata for cached blobs
@@ -32,10 +41,25 @@ type BlobMetadata struct {
 	Organization string    `json:"organization"`
 	Pipeline     string    `json:"pipeline"`
 	Build        string    `json:"build"`
-	LogSize      int64     `json:"log_size_bytes,omitempty"`
+	LogSize      int64     `json:"log_size_bytes,omitempty"` // Bytes of the raw log parsed into the blob
 	ParquetSize  int64     `json:"parquet_size_bytes,omitempty"`
 	RowCount     int       `json:"row_count,omitempty"`
 	ProcessedAt  time.Time `json:"processed_at"`
+	// Hex encoded SHA-256 of the blob's content, checked when the blob is read into the
+	// local cache, see ErrCorruptCache. Empty for blobs written without one.
+	ContentSHA256 string `json:"content_sha256,omitempty"`
+	// Retry linkage of the job when it was cached, see GroupRetryAttempts. A job retried
+	// after it was cached has no RetriedInJobID until it is cached again.
+	RetryOfJobID   string `json:"retry_of_job_id,omitempty"`
+	RetriedInJobID string `json:"retried_in_job_id,omitempty"`
+	// Byte offset just past the last complete line of the cached log, and the rows parsed
+	// from the bytes before it, from which WithIncrementalRefresh continues. Zero when the
+	// log can't be continued, such as when it was truncated.
+	LogOffset     int64 `json:"log_offset_bytes,omitempty"`
+	LogOffsetRows int   `json:"log_offset_rows,omitempty"`
+	// Generation of the segments WithIncrementalRefresh appends to a running job's log,
+	// set on the log and on each of its segments. Empty when the log takes no segments.
+	SegmentGeneration string `json:"segment_generation,omitempty"`
 }
 
 // BlobStorageOptions contains configuration options for blob storage
@@ -49,34 +73,54 @@ type BlobStorageOptions struct {
 	// "invalid cross-device link" errors if the temp directory is on a different filesystem
 	// than the storage directory.
 	NoTempDir bool
+
+	// StorageMode selects the cache location when the storage URL is empty. The zero
+	// value, StorageModeAuto, detects it from the environment.
+	StorageMode StorageMode
+
+	// ObjectTags tags blobs written with metadata with their organization, pipeline,
+	// build and terminal state on backends with object tags (S3). See WithObjectTags.
+	ObjectTags bool
 }
 
 // NewBlobStorage creates a new blob storage instance from a storage URL
-// Supports file:// URLs for local filesystem storage
+// Supports file:// URLs for local filesystem storage, s3:// for Amazon S3, azblob:// for
+// Azure Blob Storage and mem:// for memory, see GetDefaultStorageURL
 //
 // The opts parameter allows configuring blob storage behavior. Pass nil to use default options.
 func NewBlobStorage(ctx context.Context, storageURL string, opts *BlobStorageOptions) (*BlobStorage, error) {
 	noTempDir := false
+	mode := StorageModeAuto
+	objectTags := false
 	if opts != nil {
 		noTempDir = opts.NoTempDir
+		mode = opts.StorageMode
+		objectTags = opts.ObjectTags
 	}
 
-	storageURL, err := GetDefaultStorageURL(storageURL, noTempDir)
+	storageURL, err := StorageURLForMode(storageURL, mode, noTempDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get default storage URL: %w", err)
 	}
 
-	// Open the bucket (supports file://, s3://, gcs://, etc.)
+	// Open the bucket (supports file://, s3://, azblob://, mem://)
 	bucket, err := blob.OpenBucket(ctx, storageURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open blob bucket %s: %w", storageURL, err)
 	}
 
 	return &BlobStorage{
-		bucket: bucket,
+		bucket:     bucket,
+		url:        storageURL,
+		objectTags: objectTags,
 	}, nil
 }
 
+// URL returns the storage URL the blob storage was opened with, after defaults were applied.
+func (bs *BlobStorage) URL() string {
+	return bs.url
+}
+
 // addNoTmpDirParam adds the no_tmp_dir=true parameter to a URL if not already present.
 // Uses proper URL parsing to handle existing query parameters correctly.
 func addNoTmpDirParam(rawURL string) (string, error) {
@@ -102,27 +146,42 @@ func addNoTmpDirParam(rawURL string) (string, error) {
 // as the final destination, avoiding cross-filesystem rename errors.
 //
 // This function applies the noTempDir setting to both user-provided and default URLs.
+//
+// A user-provided URL selects the backend by its scheme:
+//
+//	file:///var/cache/bklog      local directory
+//	s3://bucket?region=us-east-1 Amazon S3, credentials from the AWS SDK defaults
+//	azblob://container           Azure Blob Storage container
+//	mem://                       memory of the process, see MemoryStorageURL
+//
+// azblob:// URLs name a container in the storage account set by AZURE_STORAGE_ACCOUNT,
+// authenticated with AZURE_STORAGE_KEY, AZURE_STORAGE_SAS_TOKEN or, when neither is set,
+// the Azure default credential chain (environment, workload identity, managed identity,
+// Azure CLI). The domain, protocol and localemu query parameters point it at other
+// endpoints, such as Azurite: azblob://container?protocol=http&domain=127.0.0.1:10000&localemu=true
 func GetDefaultStorageURL(storageURL string, noTempDir bool) (string, error) {
+	return StorageURLForMode(storageURL, StorageModeAuto, noTempDir)
+}
+
+// StorageURLForMode is GetDefaultStorageURL with the default location chosen by mode
+// (see ResolveStorageMode) rather than always detected from the environment.
+func StorageURLForMode(storageURL string, mode StorageMode, noTempDir bool) (string, error) {
 	var finalURL string
 
 	if storageURL != "" {
 		finalURL = storageURL
 	} else {
-		var dirPath string
-
-		// Check if we're in a containerized environment (Docker/Kubernetes)
-		if IsContainerizedEnvironment() {
-			dirPath = fmt.Sprintf("%s/bklog", os.TempDir())
-		} else {
-			// Default to user's home directory for desktop usage
-			homeDir, err := os.UserHomeDir()
-			if err != nil {
-				// Fallback to temp directory if home directory is unavailable
-				dirPath = fmt.Sprintf("%s/bklog", os.TempDir())
-			} else {
-				dirPath = fmt.Sprintf("%s/.bklog", homeDir)
-			}
+		resolved, err := ResolveStorageMode(mode)
+		if err != nil {
+			return "", err
 		}
+		if resolved == StorageModeCustom {
+			return "", fmt.Errorf("storage mode %s requires a storage URL", StorageModeCustom)
+		}
+		if resolved == StorageModeMemory {
+			return MemoryStorageURL, nil
+		}
+		dirPath := defaultStorageDir(resolved)
 
 		if err := os.MkdirAll(dirPath, 0755); err != nil {
 			return "", fmt.Errorf("failed to create storage directory %s: %w", dirPath, err)
@@ -186,36 +245,9 @@ func (bs *BlobStorage) WriteWithMetadata(ctx context.Context, key string, data [
 
 // WriteWithMetadataFrom streams data to blob storage with metadata.
 func (bs *BlobStorage) WriteWithMetadataFrom(ctx context.Context, key string, r io.Reader, metadata *BlobMetadata) error {
-	opts := &blob.WriterOptions{}
-
-	if metadata != nil {
-		opts.Metadata = map[string]string{
-			"job_id":       metadata.JobID,
-			"job_state":    metadata.JobState,
-			"is_terminal":  fmt.Sprintf("%t", metadata.IsTerminal),
-			"cached_at":    metadata.CachedAt.Format(time.RFC3339),
-			"ttl":          metadata.TTL,
-			"organization": metadata.Organization,
-			"pipeline":     metadata.Pipeline,
-			"build":        metadata.Build,
-		}
-		if metadata.LogSize > 0 {
-			opts.Metadata["log_size_bytes"] = fmt.Sprintf("%d", metadata.LogSize)
-		}
-		if metadata.ParquetSize > 0 {
-			opts.Metadata["parquet_size_bytes"] = fmt.Sprintf("%d", metadata.ParquetSize)
-		}
-		if metadata.RowCount > 0 {
-			opts.Metadata["row_count"] = fmt.Sprintf("%d", metadata.RowCount)
-		}
-		if !metadata.ProcessedAt.IsZero() {
-			opts.Metadata["processed_at"] = metadata.ProcessedAt.Format(time.RFC3339)
-		}
-	}
-
-	writer, err := bs.bucket.NewWriter(ctx, key, opts)
+	writer, err := bs.NewWriter(ctx, key, metadata)
 	if err != nil {
-		return fmt.Errorf("failed to create blob writer: %w", err)
+		return err
 	}
 
 	if _, err := io.Copy(writer, r); err != nil {
@@ -230,6 +262,68 @@ func (bs *BlobStorage) WriteWithMetadataFrom(ctx context.Context, key string, r
 	return nil
 }
 
+// NewWriter returns a writer that streams a blob with metadata to storage. The blob
+// appears once the writer is closed; canceling ctx before Close aborts the write and
+// leaves any existing blob under key as it was.
+func (bs *BlobStorage) NewWriter(ctx context.Context, key string, metadata *BlobMetadata) (io.WriteCloser, error) {
+	writer, err := bs.bucket.NewWriter(ctx, key, bs.writerOptions(metadata))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blob writer: %w", err)
+	}
+	return writer, nil
+}
+
+// writerOptions returns the blob writer options storing metadata as blob attributes.
+func (bs *BlobStorage) writerOptions(metadata *BlobMetadata) *blob.WriterOptions {
+	opts := &blob.WriterOptions{}
+	if metadata == nil {
+		return opts
+	}
+
+	opts.Metadata = map[string]string{
+		"job_id":       metadata.JobID,
+		"job_state":    metadata.JobState,
+		"is_terminal":  fmt.Sprintf("%t", metadata.IsTerminal),
+		"cached_at":    metadata.CachedAt.Format(time.RFC3339),
+		"ttl":          metadata.TTL,
+		"organization": metadata.Organization,
+		"pipeline":     metadata.Pipeline,
+		"build":        metadata.Build,
+	}
+	if metadata.LogSize > 0 {
+		opts.Metadata["log_size_bytes"] = fmt.Sprintf("%d", metadata.LogSize)
+	}
+	if metadata.ParquetSize > 0 {
+		opts.Metadata["parquet_size_bytes"] = fmt.Sprintf("%d", metadata.ParquetSize)
+	}
+	if metadata.RowCount > 0 {
+		opts.Metadata["row_count"] = fmt.Sprintf("%d", metadata.RowCount)
+	}
+	if metadata.LogOffset > 0 {
+		opts.Metadata["log_offset_bytes"] = fmt.Sprintf("%d", metadata.LogOffset)
+		opts.Metadata["log_offset_rows"] = fmt.Sprintf("%d", metadata.LogOffsetRows)
+	}
+	if metadata.ContentSHA256 != "" {
+		opts.Metadata["content_sha256"] = metadata.ContentSHA256
+	}
+	if metadata.SegmentGeneration != "" {
+		opts.Metadata["segment_generation"] = metadata.SegmentGeneration
+	}
+	if metadata.RetryOfJobID != "" {
+		opts.Metadata["retry_of_job_id"] = metadata.RetryOfJobID
+	}
+	if metadata.RetriedInJobID != "" {
+		opts.Metadata["retried_in_job_id"] = metadata.RetriedInJobID
+	}
+	if !metadata.ProcessedAt.IsZero() {
+		opts.Metadata["processed_at"] = metadata.ProcessedAt.Format(time.RFC3339)
+	}
+	if bs.objectTags {
+		setObjectTags(opts, metadata)
+	}
+	return opts
+}
+
 // ReadWithMetadata reads data from blob storage with metadata
 func (bs *BlobStorage) ReadWithMetadata(ctx context.Context, key string) (*BlobMetadata, error) {
 	attrs, err := bs.bucket.Attributes(ctx, key)
@@ -249,6 +343,10 @@ func (bs *BlobStorage) ReadWithMetadata(ctx context.Context, key string) (*BlobM
 		metadata.Pipeline = attrMap["pipeline"]
 		metadata.Build = attrMap["build"]
 		metadata.TTL = attrMap["ttl"]
+		metadata.RetryOfJobID = attrMap["retry_of_job_id"]
+		metadata.RetriedInJobID = attrMap["retried_in_job_id"]
+		metadata.ContentSHA256 = attrMap["content_sha256"]
+		metadata.SegmentGeneration = attrMap["segment_generation"]
 
 		if cachedAtStr := attrMap["cached_at"]; cachedAtStr != "" {
 			if cachedAt, err := time.Parse(time.RFC3339, cachedAtStr); err == nil {
@@ -275,6 +373,16 @@ func (bs *BlobStorage) ReadWithMetadata(ctx context.Context, key string) (*BlobM
 				metadata.RowCount = rowCount
 			}
 		}
+		if logOffsetStr := attrMap["log_offset_bytes"]; logOffsetStr != "" {
+			if logOffset, err := strconv.ParseInt(logOffsetStr, 10, 64); err == nil {
+				metadata.LogOffset = logOffset
+			}
+		}
+		if logOffsetRowsStr := attrMap["log_offset_rows"]; logOffsetRowsStr != "" {
+			if logOffsetRows, err := strconv.Atoi(logOffsetRowsStr); err == nil {
+				metadata.LogOffsetRows = logOffsetRows
+			}
+		}
 	}
 
 	return metadata, nil
@@ -286,6 +394,44 @@ func (bs *BlobStorage) Reader(ctx context.Context, key string) (io.ReadCloser, e
 	return bs.bucket.NewReader(ctx, key, nil)
 }
 
+// LocalPath returns the path of a blob on the local filesystem when the backend stores
+// blobs as plain files (file:// URLs), or "" for other backends.
+func (bs *BlobStorage) LocalPath(ctx context.Context, key string) (string, error) {
+	var path string
+	reader, err := bs.bucket.NewReader(ctx, key, &blob.ReaderOptions{
+		BeforeRead: func(as func(any) bool) error {
+			var f *os.File
+			if as(&f) {
+				path = f.Name()
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := reader.Close(); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// ContentVersion returns a string that changes whenever the blob's content changes,
+// based on its MD5 hash when the backend records one and its ETag otherwise.
+func (bs *BlobStorage) ContentVersion(ctx context.Context, key string) (string, error) {
+	attrs, err := bs.bucket.Attributes(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to get blob attributes: %w", err)
+	}
+	if len(attrs.MD5) > 0 {
+		return hex.EncodeToString(attrs.MD5), nil
+	}
+	if etag := strings.Trim(attrs.ETag, `"`); etag != "" {
+		return etag, nil
+	}
+	return fmt.Sprintf("%x-%x", attrs.ModTime.UnixNano(), attrs.Size), nil
+}
+
 // GetModTime returns the modification time of a blob
 func (bs *BlobStorage) GetModTime(ctx context.Context, key string) (time.Time, error) {
 	attrs, err := bs.bucket.Attributes(ctx, key)
@@ -566,3 +566,77 @@ func TestUserProvidedURLWithNoTempDir(t *testing.T) {
 		t.Fatal("Blob should exist")
 	}
 }
+
+func TestResolveStorageMode(t *testing.T) {
+	t.Setenv(StorageModeEnv, "Desktop")
+	if mode, err := ResolveStorageMode(StorageModeAuto); err != nil || mode != StorageModeDesktop {
+		t.Errorf("ResolveStorageMode(auto) with %s=Desktop = %q, %v", StorageModeEnv, mode, err)
+	}
+	// An explicitly configured mode wins over the environment
+	if mode, err := ResolveStorageMode(StorageModeContainer); err != nil || mode != StorageModeContainer {
+		t.Errorf("ResolveStorageMode(container) = %q, %v", mode, err)
+	}
+
+	t.Setenv(StorageModeEnv, "laptop")
+	if _, err := ResolveStorageMode(StorageModeAuto); err == nil {
+		t.Error("expected an error for an unknown storage mode")
+	}
+}
+
+func TestStorageURLForMode(t *testing.T) {
+	home := t.TempDir()
+	tmp := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("TMPDIR", tmp)
+
+	desktopURL, err := StorageURLForMode("", StorageModeDesktop, false)
+	if err != nil {
+		t.Fatalf("StorageURLForMode(desktop): %v", err)
+	}
+	if want := "file://" + home + "/.bklog"; desktopURL != want {
+		t.Errorf("desktop URL = %s, want %s", desktopURL, want)
+	}
+
+	containerURL, err := StorageURLForMode("", StorageModeContainer, false)
+	if err != nil {
+		t.Fatalf("StorageURLForMode(container): %v", err)
+	}
+	if want := "file://" + tmp + "/bklog"; containerURL != want {
+		t.Errorf("container URL = %s, want %s", containerURL, want)
+	}
+
+	if _, err := StorageURLForMode("", StorageModeCustom, false); err == nil {
+		t.Error("expected an error for custom mode without a storage URL")
+	}
+	if url, err := StorageURLForMode("s3://bucket", StorageModeCustom, false); err != nil || url != "s3://bucket" {
+		t.Errorf("StorageURLForMode(custom) = %s, %v", url, err)
+	}
+
+	if url, err := StorageURLForMode("", StorageModeMemory, true); err != nil || url != MemoryStorageURL {
+		t.Errorf("StorageURLForMode(memory) = %s, %v, want %s", url, err, MemoryStorageURL)
+	}
+}
+
+func TestNewBlobStorage_AzureBlob(t *testing.T) {
+	// Azurite's well-known development account; opening a bucket makes no requests
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "devstoreaccount1")
+	t.Setenv("AZURE_STORAGE_KEY", "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==")
+
+	storageURL := "azblob://bklog-cache?protocol=http&domain=127.0.0.1:10000&localemu=true"
+	blobStorage, err := NewBlobStorage(t.Context(), storageURL, &BlobStorageOptions{NoTempDir: true})
+	if err != nil {
+		t.Fatalf("NewBlobStorage(%s) failed: %v", storageURL, err)
+	}
+	defer blobStorage.Close()
+
+	// no_tmp_dir only applies to file:// URLs
+	if blobStorage.URL() != storageURL {
+		t.Errorf("URL() = %s, want %s", blobStorage.URL(), storageURL)
+	}
+}
+
+func TestNewBlobStorage_UnknownScheme(t *testing.T) {
+	if _, err := NewBlobStorage(t.Context(), "ftp://bucket", nil); err == nil {
+		t.Error("expected an error for an unregistered scheme")
+	}
+}
@@ -0,0 +1,191 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	buildkite "github.com/buildkite/go-buildkite/v5"
+)
+
+// ErrStepNotFound is returned when no job in a build matches a step label or key.
+var ErrStepNotFound = errors.New("no job matches step")
+
+// ErrAmbiguousStep is returned when more than one job in a build matches a step label or key.
+var ErrAmbiguousStep = errors.New("step matches more than one job")
+
+// ErrNoBuilds is returned when a pipeline has no builds matching a latest-build lookup.
+var ErrNoBuilds = errors.New("no builds found")
+
+// LatestBuild can be passed as the build to ResolveBuild and Client.ResolveBuild to select
+// the most recent build of a pipeline, optionally restricted to a branch.
+const LatestBuild = "latest"
+
+// BuildProvider fetches a build, including its jobs. The build may be identified by
+// number or UUID.
+type BuildProvider interface {
+	GetBuild(ctx context.Context, org, pipeline, build string) (buildkite.Build, error)
+}
+
+// LatestBuildProvider finds the most recent build of a pipeline. An empty branch matches
+// builds on any branch.
+type LatestBuildProvider interface {
+	GetLatestBuild(ctx context.Context, org, pipeline, branch string) (buildkite.Build, error)
+}
+
+// GetBuild fetches a build and its jobs using go-buildkite.
+func (c *BuildkiteAPIClient) GetBuild(ctx context.Context, org, pipeline, build string) (buildkite.Build, error) {
+	var b buildkite.Build
+	err := c.withRetry(ctx, func() error {
+		ctx, cancel := c.requestContext(ctx)
+		defer cancel()
+
+		var resp *buildkite.Response
+		var err error
+		b, resp, err = c.client.Builds.Get(ctx, org, pipeline, build, nil)
+		return wrapAPIError(resp, err)
+	}, nil)
+	if err != nil {
+		return buildkite.Build{}, fmt.Errorf("failed to get build: %w", err)
+	}
+	return b, nil
+}
+
+// GetLatestBuild fetches the most recent build of a pipeline using go-buildkite.
+func (c *BuildkiteAPIClient) GetLatestBuild(ctx context.Context, org, pipeline, branch string) (buildkite.Build, error) {
+	opts := &buildkite.BuildsListOptions{
+		ExcludeJobs:     true,
+		ExcludePipeline: true,
+		ListOptions:     buildkite.ListOptions{PerPage: 1},
+	}
+	if branch != "" {
+		opts.Branch = []string{branch}
+	}
+
+	var builds []buildkite.Build
+	err := c.withRetry(ctx, func() error {
+		ctx, cancel := c.requestContext(ctx)
+		defer cancel()
+
+		var resp *buildkite.Response
+		var err error
+		builds, resp, err = c.client.Builds.ListByPipeline(ctx, org, pipeline, opts)
+		return wrapAPIError(resp, err)
+	}, nil)
+	if err != nil {
+		return buildkite.Build{}, fmt.Errorf("failed to list builds: %w", err)
+	}
+	if len(builds) == 0 {
+		return buildkite.Build{}, ErrNoBuilds
+	}
+	return builds[0], nil
+}
+
+// ResolveBuild returns build unchanged unless it is LatestBuild, in which case the number
+// of the pipeline's most recent build on branch (or any branch, if empty) is returned.
+func ResolveBuild(ctx context.Context, api LatestBuildProvider, org, pipeline, build, branch string) (string, error) {
+	if build != LatestBuild {
+		if branch != "" {
+			return "", fmt.Errorf("branch can only be used with build %q", LatestBuild)
+		}
+		return build, nil
+	}
+	if org == "" || pipeline == "" {
+		return "", fmt.Errorf("organization and pipeline are required to resolve the latest build")
+	}
+
+	b, err := api.GetLatestBuild(ctx, org, pipeline, branch)
+	if err != nil {
+		if errors.Is(err, ErrNoBuilds) && branch != "" {
+			return "", fmt.Errorf("%w for %s/%s on branch %q", ErrNoBuilds, org, pipeline, branch)
+		}
+		return "", err
+	}
+
+	return strconv.Itoa(b.Number), nil
+}
+
+// ResolveJobByStep finds the job in a build whose step key or label matches step.
+//
+// Matching prefers an exact step key, then an exact label (ignoring case), then a label
+// containing step (ignoring case). Only command jobs are considered, and jobs that have
+// been retried are skipped in favour of their latest retry. If the best tier matches more
+// than one job, ErrAmbiguousStep is returned with the candidates listed.
+//
+// The returned location uses the build number, so build UUIDs resolve to the same cache
+// keys as build numbers.
+func ResolveJobByStep(ctx context.Context, api BuildProvider, org, pipeline, build, step string) (JobLocation, error) {
+	if err := ValidateAPIParams(org, pipeline, build, step); err != nil {
+		return JobLocation{}, err
+	}
+
+	b, err := api.GetBuild(ctx, org, pipeline, build)
+	if err != nil {
+		return JobLocation{}, err
+	}
+
+	job, err := matchStepJob(b.Jobs, step)
+	if err != nil {
+		return JobLocation{}, err
+	}
+
+	if b.Number > 0 {
+		build = strconv.Itoa(b.Number)
+	}
+
+	return JobLocation{
+		Org:      org,
+		Pipeline: pipeline,
+		Build:    build,
+		Job:      job.ID,
+	}, nil
+}
+
+// matchStepJob selects the single job matching step from a build's jobs.
+func matchStepJob(jobs []buildkite.Job, step string) (buildkite.Job, error) {
+	want := strings.TrimSpace(step)
+	tiers := []func(job buildkite.Job) bool{
+		func(job buildkite.Job) bool { return job.StepKey != "" && job.StepKey == want },
+		func(job buildkite.Job) bool { return strings.EqualFold(strings.TrimSpace(stepLabel(job)), want) },
+		func(job buildkite.Job) bool {
+			return strings.Contains(strings.ToLower(stepLabel(job)), strings.ToLower(want))
+		},
+	}
+
+	for _, matches := range tiers {
+		var candidates []buildkite.Job
+		for _, job := range jobs {
+			if job.Type != "script" || job.Retried {
+				continue
+			}
+			if matches(job) {
+				candidates = append(candidates, job)
+			}
+		}
+
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+			return candidates[0], nil
+		default:
+			names := make([]string, len(candidates))
+			for i, job := range candidates {
+				names[i] = fmt.Sprintf("%q (%s)", stepLabel(job), job.ID)
+			}
+			return buildkite.Job{}, fmt.Errorf("%w %q: %s", ErrAmbiguousStep, step, strings.Join(names, ", "))
+		}
+	}
+
+	return buildkite.Job{}, fmt.Errorf("%w %q", ErrStepNotFound, step)
+}
+
+// stepLabel returns the display label for a job.
+func stepLabel(job buildkite.Job) string {
+	if job.Label != "" {
+		return job.Label
+	}
+	return job.Name
+}
@@ -0,0 +1,156 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v5"
+)
+
+type stepBuildAPI struct {
+	*mockBuildkiteAPI
+	build  buildkite.Build
+	latest map[string]buildkite.Build // keyed by branch
+}
+
+func (a *stepBuildAPI) GetBuild(ctx context.Context, org, pipeline, build string) (buildkite.Build, error) {
+	return a.build, nil
+}
+
+func (a *stepBuildAPI) GetLatestBuild(ctx context.Context, org, pipeline, branch string) (buildkite.Build, error) {
+	b, ok := a.latest[branch]
+	if !ok {
+		return buildkite.Build{}, ErrNoBuilds
+	}
+	return b, nil
+}
+
+func stepTestBuild() buildkite.Build {
+	return buildkite.Build{
+		ID:     "0190046e-e199-453b-a302-a21a4d649d31",
+		Number: 42,
+		Jobs: []buildkite.Job{
+			{ID: "wait-1", Type: "waiter"},
+			{ID: "lint", Type: "script", Label: ":eslint: Lint", StepKey: "lint"},
+			{ID: "unit-old", Type: "script", Label: "Run unit tests", Retried: true},
+			{ID: "unit", Type: "script", Label: "Run unit tests"},
+			{ID: "integration-1", Type: "script", Label: "Run integration tests 1/2", StepKey: "integration"},
+			{ID: "integration-2", Type: "script", Label: "Run integration tests 2/2", StepKey: "integration"},
+		},
+	}
+}
+
+func TestResolveJobByStep(t *testing.T) {
+	api := &stepBuildAPI{build: stepTestBuild()}
+
+	tests := []struct {
+		step    string
+		wantJob string
+		wantErr error
+	}{
+		{step: "lint", wantJob: "lint"},
+		{step: "run unit tests", wantJob: "unit"},
+		{step: "eslint", wantJob: "lint"},
+		{step: "integration", wantErr: ErrAmbiguousStep},
+		{step: "integration tests 2/2", wantJob: "integration-2"},
+		{step: "deploy", wantErr: ErrStepNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.step, func(t *testing.T) {
+			location, err := ResolveJobByStep(t.Context(), api, "org", "pipeline", stepTestBuild().ID, tt.step)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveJobByStep failed: %v", err)
+			}
+			if location.Job != tt.wantJob {
+				t.Errorf("job = %q, want %q", location.Job, tt.wantJob)
+			}
+			if location.Build != "42" {
+				t.Errorf("build = %q, want build number 42", location.Build)
+			}
+		})
+	}
+}
+
+func TestClient_NewReaderByStep(t *testing.T) {
+	api := &stepBuildAPI{mockBuildkiteAPI: newTerminalMock(), build: stepTestBuild()}
+	client := newTestClient(t, api)
+
+	reader, err := client.NewReaderByStep(t.Context(), "org", "pipeline", "42", "lint", 0, false)
+	if err != nil {
+		t.Fatalf("NewReaderByStep failed: %v", err)
+	}
+	defer reader.Close()
+
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	if info.RowCount != 1 {
+		t.Errorf("row count = %d, want 1", info.RowCount)
+	}
+}
+
+func TestClient_NewReaderByStepRequiresBuildProvider(t *testing.T) {
+	client := newTestClient(t, newTerminalMock())
+
+	if _, err := client.NewReaderByStep(t.Context(), "org", "pipeline", "42", "lint", 0, false); err == nil {
+		t.Fatal("expected error for API without build listing support")
+	}
+}
+
+func TestResolveBuild(t *testing.T) {
+	api := &stepBuildAPI{latest: map[string]buildkite.Build{
+		"":     {Number: 101},
+		"main": {Number: 99},
+	}}
+
+	tests := []struct {
+		build   string
+		branch  string
+		want    string
+		wantErr bool
+	}{
+		{build: "123", want: "123"},
+		{build: LatestBuild, want: "101"},
+		{build: LatestBuild, branch: "main", want: "99"},
+		{build: LatestBuild, branch: "missing", wantErr: true},
+		{build: "123", branch: "main", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.build+"/"+tt.branch, func(t *testing.T) {
+			got, err := ResolveBuild(t.Context(), api, "org", "pipeline", tt.build, tt.branch)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got build %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveBuild failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("build = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_ResolveBuildRequiresProvider(t *testing.T) {
+	client := newTestClient(t, newTerminalMock())
+
+	if build, err := client.ResolveBuild(t.Context(), "org", "pipeline", "42", ""); err != nil || build != "42" {
+		t.Fatalf("ResolveBuild(42) = %q, %v", build, err)
+	}
+	if _, err := client.ResolveBuild(t.Context(), "org", "pipeline", LatestBuild, "main"); err == nil {
+		t.Fatal("expected error for API without build listing support")
+	}
+}
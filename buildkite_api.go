@@ -2,6 +2,7 @@ package buildkitelogs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -36,37 +37,52 @@ type BuildkiteAPI interface {
 // BuildkiteAPIClient provides methods to interact with the Buildkite API
 // Now wraps the official go-buildkite v4 client
 type BuildkiteAPIClient struct {
-	client       *buildkite.Client
-	requireToken bool
-	apiToken     string
+	client         *buildkite.Client
+	requireToken   bool
+	apiToken       string
+	requestTimeout time.Duration // Deadline of calls other than log downloads (0 = none)
+	logIdleTimeout time.Duration // Longest wait for log data during a download (0 = none)
+	retry          RetryPolicy   // Retries of failed calls (zero value = single attempt)
+	limiter        *RateLimiter  // Limits how often calls start (nil = unlimited)
 }
 
-// NewBuildkiteAPIClient creates a new Buildkite API client using go-buildkite v4
-func NewBuildkiteAPIClient(apiToken, version string) *BuildkiteAPIClient {
+// NewBuildkiteAPIClient creates a new Buildkite API client using go-buildkite v4.
+// The HTTP client has no overall timeout; each call is bounded by its context and the
+// client's per-operation timeouts (see WithRequestTimeout and WithLogIdleTimeout).
+func NewBuildkiteAPIClient(apiToken, version string, opts ...APIClientOption) *BuildkiteAPIClient {
 	userAgent := fmt.Sprintf("buildkite-logs-parquet/%s (Go; %s; %s)", version, runtime.GOOS, runtime.GOARCH)
 
-	httpClient := &http.Client{
-		Timeout: time.Second * 30,
-	}
-
 	client, _ := buildkite.NewOpts(
 		buildkite.WithTokenAuth(apiToken),
 		buildkite.WithUserAgent(userAgent),
-		buildkite.WithHTTPClient(httpClient),
+		buildkite.WithHTTPClient(&http.Client{}),
 	)
 
-	return &BuildkiteAPIClient{
+	c := &BuildkiteAPIClient{
 		client:       client,
 		requireToken: true,
 		apiToken:     apiToken,
 	}
+	c.applyOptions(opts)
+	return c
 }
 
-// NewBuildkiteAPI creates a new Buildkite API client using the provided go-buildkite client
-func NewBuildkiteAPIExistingClient(client *buildkite.Client) *BuildkiteAPIClient {
-	return &BuildkiteAPIClient{
+// NewBuildkiteAPI creates a new Buildkite API client using the provided go-buildkite client.
+// The per-operation timeouts apply on top of any timeout of the client's HTTP client.
+func NewBuildkiteAPIExistingClient(client *buildkite.Client, opts ...APIClientOption) *BuildkiteAPIClient {
+	c := &BuildkiteAPIClient{
 		client: client,
 	}
+	c.applyOptions(opts)
+	return c
+}
+
+func (c *BuildkiteAPIClient) applyOptions(opts []APIClientOption) {
+	c.requestTimeout = DefaultAPIRequestTimeout
+	c.logIdleTimeout = DefaultLogIdleTimeout
+	for _, opt := range opts {
+		opt(c)
+	}
 }
 
 // GetJobLog fetches the log output for a specific job using go-buildkite
@@ -79,16 +95,16 @@ func (c *BuildkiteAPIClient) GetJobLog(ctx context.Context, org, pipeline, build
 		return nil, fmt.Errorf("missing Buildkite API token")
 	}
 
+	reader, writer := io.Pipe()
 	u := fmt.Sprintf("v2/organizations/%s/pipelines/%s/builds/%s/jobs/%s/log", org, pipeline, build, job)
-	req, err := c.client.NewRequest(ctx, http.MethodGet, u, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create job log request: %w", err)
-	}
-	req.Header.Set("Accept", "text/plain")
 
-	reader, writer := io.Pipe()
 	go func() {
-		_, err := c.client.Do(req, writer)
+		// A download is only retried until data arrives; the reader has seen it by then
+		progress := &writeTracker{w: writer}
+		err := c.withRetry(ctx, func() error {
+			_, err := c.downloadJobLog(ctx, u, "", progress)
+			return err
+		}, func() bool { return !progress.written })
 		if err != nil {
 			err = &logDownloadError{err: err}
 		}
@@ -98,6 +114,32 @@ func (c *BuildkiteAPIClient) GetJobLog(ctx context.Context, org, pipeline, build
 	return reader, nil
 }
 
+// downloadJobLog makes one attempt at streaming the log at u to w. A non-empty byteRange
+// is sent as the Range header; partial reports whether the server honored it, rather
+// than sending the whole log.
+func (c *BuildkiteAPIClient) downloadJobLog(ctx context.Context, u, byteRange string, w io.Writer) (partial bool, err error) {
+	downloadCtx, idleWriter, stop := newIdleTimeoutWriter(ctx, w, c.logIdleTimeout)
+	defer stop()
+
+	req, err := c.client.NewRequest(downloadCtx, http.MethodGet, u, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create job log request: %w", err)
+	}
+	req.Header.Set("Accept", "text/plain")
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+
+	resp, err := c.client.Do(req, idleWriter)
+	if err != nil {
+		if errors.Is(context.Cause(downloadCtx), ErrLogStalled) {
+			err = fmt.Errorf("%w: no data received for %s", ErrLogStalled, c.logIdleTimeout)
+		}
+		return false, wrapAPIError(resp, err)
+	}
+	return resp != nil && resp.Response != nil && resp.StatusCode == http.StatusPartialContent, nil
+}
+
 // JobLogExists checks whether the current API identity can access a job log
 // without downloading its contents.
 func (c *BuildkiteAPIClient) JobLogExists(ctx context.Context, org, pipeline, build, job string) (bool, error) {
@@ -105,7 +147,16 @@ func (c *BuildkiteAPIClient) JobLogExists(ctx context.Context, org, pipeline, bu
 		return false, fmt.Errorf("missing Buildkite API token")
 	}
 
-	exists, _, err := c.client.Jobs.JobLogExists(ctx, org, pipeline, build, job)
+	var exists bool
+	err := c.withRetry(ctx, func() error {
+		ctx, cancel := c.requestContext(ctx)
+		defer cancel()
+
+		var resp *buildkite.Response
+		var err error
+		exists, resp, err = c.client.Jobs.JobLogExists(ctx, org, pipeline, build, job)
+		return wrapAPIError(resp, err)
+	}, nil)
 	if err != nil {
 		return false, fmt.Errorf("failed to check job log: %w", err)
 	}
@@ -114,7 +165,16 @@ func (c *BuildkiteAPIClient) JobLogExists(ctx context.Context, org, pipeline, bu
 
 // GetJobStatus gets the current status of a job
 func (c *BuildkiteAPIClient) GetJobStatus(ctx context.Context, org, pipeline, build, jobID string) (*JobStatus, error) {
-	job, _, err := c.client.Jobs.GetJob(ctx, org, pipeline, build, jobID)
+	var job buildkite.Job
+	err := c.withRetry(ctx, func() error {
+		ctx, cancel := c.requestContext(ctx)
+		defer cancel()
+
+		var resp *buildkite.Response
+		var err error
+		job, resp, err = c.client.Jobs.GetJob(ctx, org, pipeline, build, jobID)
+		return wrapAPIError(resp, err)
+	}, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get job: %w", err)
 	}
@@ -3,10 +3,13 @@ package buildkitelogs
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/buildkite/go-buildkite/v5"
 )
@@ -301,3 +304,114 @@ func TestGetJobLog_StreamsPlainText(t *testing.T) {
 		t.Fatalf("log content = %q, want %q", string(got), logContent)
 	}
 }
+
+func TestGetJobStatus_RecordsRequestID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-123")
+		w.Header().Set("RateLimit-Limit", "200")
+		w.Header().Set("RateLimit-Remaining", "7")
+		w.Header().Set("RateLimit-Reset", "42")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message":"Forbidden"}`))
+	}))
+	defer server.Close()
+
+	bkClient, err := buildkite.NewOpts(
+		buildkite.WithBaseURL(server.URL),
+		buildkite.WithTokenAuth("test-token"),
+	)
+	if err != nil {
+		t.Fatalf("NewOpts: %v", err)
+	}
+
+	_, err = NewBuildkiteAPIExistingClient(bkClient).GetJobStatus(t.Context(), "org", "pipeline", "123", "job")
+	if err == nil {
+		t.Fatal("expected error for forbidden response")
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error %v is not an *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusForbidden || apiErr.RequestID != "req-123" {
+		t.Errorf("unexpected API error: %+v", apiErr)
+	}
+	if apiErr.RateLimitLimit != 200 || apiErr.RateLimitRemaining != 7 || apiErr.RateLimitReset != 42*time.Second {
+		t.Errorf("unexpected rate limit: %+v", apiErr)
+	}
+	if got := RequestIDFromError(err); got != "req-123" {
+		t.Errorf("RequestIDFromError = %q", got)
+	}
+	if !strings.Contains(err.Error(), "request ID req-123") {
+		t.Errorf("error %q does not mention the request ID", err)
+	}
+}
+
+func newTimeoutTestClient(t *testing.T, handler http.HandlerFunc, opts ...APIClientOption) *BuildkiteAPIClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	bkClient, err := buildkite.NewOpts(
+		buildkite.WithBaseURL(server.URL),
+		buildkite.WithTokenAuth("test-token"),
+	)
+	if err != nil {
+		t.Fatalf("NewOpts: %v", err)
+	}
+	return NewBuildkiteAPIExistingClient(bkClient, opts...)
+}
+
+func TestGetJobStatus_RequestTimeout(t *testing.T) {
+	client := newTimeoutTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}, WithRequestTimeout(20*time.Millisecond))
+
+	_, err := client.GetJobStatus(t.Context(), "org", "pipeline", "123", "job")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetJobStatus error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestGetJobLog_NotBoundByRequestTimeout(t *testing.T) {
+	client := newTimeoutTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		for range 5 {
+			_, _ = io.WriteString(w, "line\n")
+			w.(http.Flusher).Flush()
+			time.Sleep(20 * time.Millisecond)
+		}
+	}, WithRequestTimeout(10*time.Millisecond), WithLogIdleTimeout(time.Second))
+
+	reader, err := client.GetJobLog(t.Context(), "org", "pipeline", "123", "job")
+	if err != nil {
+		t.Fatalf("GetJobLog: %v", err)
+	}
+	defer reader.Close()
+
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if want := strings.Repeat("line\n", 5); string(got) != want {
+		t.Errorf("log content = %q, want %q", got, want)
+	}
+}
+
+func TestGetJobLog_IdleTimeout(t *testing.T) {
+	client := newTimeoutTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "first line\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}, WithLogIdleTimeout(50*time.Millisecond))
+
+	reader, err := client.GetJobLog(t.Context(), "org", "pipeline", "123", "job")
+	if err != nil {
+		t.Fatalf("GetJobLog: %v", err)
+	}
+	defer reader.Close()
+
+	_, err = io.ReadAll(reader)
+	if !errors.Is(err, ErrLogStalled) {
+		t.Fatalf("ReadAll error = %v, want ErrLogStalled", err)
+	}
+}
@@ -0,0 +1,121 @@
+package buildkitelogs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// BulkIndexMapping is an Elasticsearch/OpenSearch index mapping for the documents
+// ExportSeq2ToESBulk writes. Create the index with it before the first bulk request,
+// e.g. PUT /<index> with this body; otherwise dynamic mapping indexes "group" as text.
+const BulkIndexMapping = `{
+  "mappings": {
+    "properties": {
+      "@timestamp": {"type": "date"},
+      "message": {"type": "text"},
+      "group": {"type": "keyword"},
+      "parent_group": {"type": "keyword"},
+      "is_group": {"type": "boolean"},
+      "flags": {"type": "integer"},
+      "row": {"type": "long"},
+      "org": {"type": "keyword"},
+      "pipeline": {"type": "keyword"},
+      "build": {"type": "keyword"},
+      "job": {"type": "keyword"}
+    }
+  }
+}`
+
+// BulkDocument is the document ExportSeq2ToESBulk writes for each log entry.
+type BulkDocument struct {
+	Timestamp   string             `json:"@timestamp,omitempty"`   // RFC 3339 in UTC, omitted for entries without a timestamp
+	Message     string             `json:"message"`                // Content with ANSI escape codes removed
+	Group       string             `json:"group,omitempty"`        // Group the entry belongs to
+	ParentGroup string             `json:"parent_group,omitempty"` // Group Group is nested in
+	IsGroup     bool               `json:"is_group"`               // The entry is a group header
+	Flags       logparser.LogFlags `json:"flags"`                  // Same bits as the Parquet flags column
+	Row         int64              `json:"row"`                    // Position of the entry among those exported (0-based)
+
+	// Job the log belongs to, set WithBulkJob
+	Org      string `json:"org,omitempty"`
+	Pipeline string `json:"pipeline,omitempty"`
+	Build    string `json:"build,omitempty"`
+	Job      string `json:"job,omitempty"`
+}
+
+// BulkOption configures ExportSeq2ToESBulk.
+type BulkOption func(*BulkDocument)
+
+// WithBulkJob sets the org, pipeline, build and job of every document, so the logs of
+// many jobs can share an index.
+func WithBulkJob(location JobLocation) BulkOption {
+	return func(doc *BulkDocument) {
+		doc.Org, doc.Pipeline, doc.Build, doc.Job = location.Org, location.Pipeline, location.Build, location.Job
+	}
+}
+
+type bulkAction struct {
+	Index bulkIndex `json:"index"`
+}
+
+type bulkIndex struct {
+	Index string `json:"_index,omitempty"`
+}
+
+// ExportSeq2ToESBulk writes log entries to w in the newline-delimited JSON format of the
+// Elasticsearch and OpenSearch _bulk API: an index action for indexName followed by a
+// BulkDocument for each entry. An empty indexName leaves it to the request path
+// (POST /<index>/_bulk). It returns the number of entries written.
+func ExportSeq2ToESBulk(seq iter.Seq2[*logparser.Entry, error], w io.Writer, indexName string, opts ...BulkOption) (int, error) {
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	encoder.SetEscapeHTML(false)
+	action := bulkAction{Index: bulkIndex{Index: indexName}}
+
+	var base BulkDocument
+	for _, opt := range opts {
+		opt(&base)
+	}
+
+	rows := 0
+	for entry, err := range seq {
+		if err != nil {
+			return rows, fmt.Errorf("error during iteration: %w", err)
+		}
+
+		doc := base
+		doc.Message = StripANSI(entry.Content)
+		doc.Group = entry.Group
+		doc.ParentGroup = entry.ParentGroup
+		doc.IsGroup = entry.IsGroup()
+		doc.Flags = entry.ComputeFlags()
+		doc.Row = int64(rows)
+		if entry.HasTimestamp() {
+			doc.Timestamp = entry.Timestamp.UTC().Format(time.RFC3339Nano)
+		}
+
+		if err := encoder.Encode(action); err != nil {
+			return rows, fmt.Errorf("failed to write bulk action: %w", err)
+		}
+		if err := encoder.Encode(doc); err != nil {
+			return rows, fmt.Errorf("failed to write bulk document: %w", err)
+		}
+		rows++
+	}
+
+	if err := bw.Flush(); err != nil {
+		return rows, fmt.Errorf("failed to write bulk export: %w", err)
+	}
+	return rows, nil
+}
+
+// ExportSeq2ToBulkNDJSON is ExportSeq2ToESBulk without options.
+func ExportSeq2ToBulkNDJSON(seq iter.Seq2[*logparser.Entry, error], w io.Writer, index string) (int, error) {
+	return ExportSeq2ToESBulk(seq, w, index)
+}
@@ -0,0 +1,103 @@
+package buildkitelogs
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestExportSeq2ToBulkNDJSON(t *testing.T) {
+	log := "\x1b_bk;t=1745322209921\x07~~~ Setup\n\x1b_bk;t=1745322209922\x07\x1b[31mfailed\x1b[0m <html>\nno timestamp\n"
+	var out strings.Builder
+
+	rows, err := ExportSeq2ToBulkNDJSON(logparser.New().All(strings.NewReader(log)), &out, "buildkite-logs")
+	if err != nil {
+		t.Fatalf("ExportSeq2ToBulkNDJSON failed: %v", err)
+	}
+	if rows != 3 {
+		t.Fatalf("rows = %d, want 3", rows)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("wrote %d lines, want 6:\n%s", len(lines), out.String())
+	}
+	for i := 0; i < len(lines); i += 2 {
+		if lines[i] != `{"index":{"_index":"buildkite-logs"}}` {
+			t.Errorf("action line %d = %s", i, lines[i])
+		}
+	}
+
+	var docs []BulkDocument
+	for i := 1; i < len(lines); i += 2 {
+		var doc BulkDocument
+		if err := json.Unmarshal([]byte(lines[i]), &doc); err != nil {
+			t.Fatalf("line %d is not a document: %v", i, err)
+		}
+		docs = append(docs, doc)
+	}
+	if !docs[0].IsGroup || docs[0].Group != "~~~ Setup" || docs[0].Timestamp != "2025-04-22T11:43:29.921Z" {
+		t.Errorf("first document = %+v", docs[0])
+	}
+	if docs[1].Message != "failed <html>" || docs[1].Row != 1 || docs[1].IsGroup {
+		t.Errorf("second document = %+v", docs[1])
+	}
+	if docs[2].Timestamp != "" || strings.Contains(lines[5], "@timestamp") {
+		t.Errorf("entry without a timestamp has one: %s", lines[5])
+	}
+}
+
+func TestExportSeq2ToBulkNDJSON_NoIndex(t *testing.T) {
+	var out strings.Builder
+	if _, err := ExportSeq2ToBulkNDJSON(logparser.New().All(strings.NewReader("hello\n")), &out, ""); err != nil {
+		t.Fatalf("ExportSeq2ToBulkNDJSON failed: %v", err)
+	}
+	first, _, _ := bufio.NewReader(strings.NewReader(out.String())).ReadLine()
+	if string(first) != `{"index":{}}` {
+		t.Errorf("action line = %s, want {\"index\":{}}", first)
+	}
+}
+
+func TestBulkIndexMappingIsJSON(t *testing.T) {
+	if !json.Valid([]byte(BulkIndexMapping)) {
+		t.Error("BulkIndexMapping is not valid JSON")
+	}
+}
+
+func TestExportSeq2ToESBulk_Job(t *testing.T) {
+	log := "\x1b_bk;t=1745322209921\x07~~~ Running tests\n\x1b_bk;t=1745322209922\x07--- unit\nok\n"
+	var out strings.Builder
+
+	location := JobLocation{Org: "myorg", Pipeline: "mypipeline", Build: "123", Job: "0190a7a4-5b3c-7d1e-9f00-1234567890ab"}
+	rows, err := ExportSeq2ToESBulk(logparser.New().All(strings.NewReader(log)), &out, "buildkite-logs", WithBulkJob(location))
+	if err != nil {
+		t.Fatalf("ExportSeq2ToESBulk failed: %v", err)
+	}
+	if rows != 3 {
+		t.Fatalf("rows = %d, want 3", rows)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	var docs []BulkDocument
+	for i := 1; i < len(lines); i += 2 {
+		var doc BulkDocument
+		if err := json.Unmarshal([]byte(lines[i]), &doc); err != nil {
+			t.Fatalf("line %d is not a document: %v", i, err)
+		}
+		docs = append(docs, doc)
+	}
+	for _, doc := range docs {
+		if doc.Org != location.Org || doc.Pipeline != location.Pipeline || doc.Build != location.Build || doc.Job != location.Job {
+			t.Errorf("document %d job = %s/%s/%s/%s, want %+v", doc.Row, doc.Org, doc.Pipeline, doc.Build, doc.Job, location)
+		}
+	}
+	if !docs[0].Flags.IsGroup() || !docs[0].Flags.HasTimestamp() {
+		t.Errorf("header flags = %b, want group and timestamp", docs[0].Flags)
+	}
+	if docs[2].Group != "--- unit" || docs[2].ParentGroup != "~~~ Running tests" || docs[2].Flags != 0 {
+		t.Errorf("nested document = %+v", docs[2])
+	}
+}
@@ -2,31 +2,150 @@ package buildkitelogs
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
-// createLocalCacheFile creates a local file from blob storage for compatibility
-func createLocalCacheFile(ctx context.Context, blobStorage *BlobStorage, blobKey string) (string, error) {
-	cacheFilePath, err := os.CreateTemp("", "bklog-")
+// staleLocalTempAge is how old a temp file in the local cache directory must be before
+// it is treated as stranded by a process that exited mid-copy and removed.
+const staleLocalTempAge = time.Hour
+
+// DefaultLocalCacheDir returns the directory blobs from remote backends are copied to
+// for reading.
+func DefaultLocalCacheDir() string {
+	return filepath.Join(os.TempDir(), "bklog-local")
+}
+
+// createLocalCacheFile returns a local path holding the blob's current content.
+//
+// Backends that store blobs as plain files (file://) return the blob's own path, so no
+// copy is made. Other backends copy the blob into dir once per blob key and content
+// version, and later calls reuse that copy. A log with segments appended by
+// WithIncrementalRefresh is merged into one file in dir, for every backend.
+//
+// Content is checked against the SHA-256 recorded in the blob's metadata, returning
+// ErrCorruptCache on a mismatch: copies as they are made, and blobs read in place once
+// per version of the file.
+func createLocalCacheFile(ctx context.Context, blobStorage *BlobStorage, blobKey, dir string) (string, error) {
+	log, err := readCachedLog(ctx, blobStorage, blobKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to create local cache file: %w", err)
+		return "", err
+	}
+	if log.segments() > 0 {
+		return createMergedLocalCacheFile(ctx, blobStorage, log, blobKey, dir)
 	}
-	defer cacheFilePath.Close()
 
-	// Read from blob storage
-	reader, err := blobStorage.Reader(ctx, blobKey)
+	want := log.parts[0].sha256
+	if path, err := blobStorage.LocalPath(ctx, blobKey); err == nil && path != "" {
+		if err := verifyLocalFile(blobKey, path, want); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+
+	version, err := blobStorage.ContentVersion(ctx, blobKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to read from blob storage: %w", err)
+		return "", err
+	}
+
+	return writeLocalCacheFile(dir, localCachePath(dir, blobKey, version), func(w io.Writer) error {
+		reader, err := blobStorage.Reader(ctx, blobKey)
+		if err != nil {
+			return fmt.Errorf("failed to read from blob storage: %w", err)
+		}
+		defer reader.Close()
+
+		h := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(w, h), reader); err != nil {
+			return fmt.Errorf("failed to write local cache file: %w", err)
+		}
+		return checkContentSHA256(blobKey, want, hex.EncodeToString(h.Sum(nil)))
+	})
+}
+
+// writeLocalCacheFile creates the local cache file at cacheFilePath in dir with the
+// content write produces, unless it already exists.
+func writeLocalCacheFile(dir, cacheFilePath string, write func(w io.Writer) error) (string, error) {
+	if _, err := os.Stat(cacheFilePath); err == nil {
+		return cacheFilePath, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create local cache directory: %w", err)
 	}
-	defer reader.Close()
+	removeStaleLocalTempFiles(dir, staleLocalTempAge)
 
-	// Write to local cache file
-	_, err = io.Copy(cacheFilePath, reader)
+	// Copy to a temp file and rename it into place so concurrent readers never see a
+	// partially written copy.
+	tmpFile, err := os.CreateTemp(dir, "bklog-*.tmp")
 	if err != nil {
+		return "", fmt.Errorf("failed to create local cache file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	if err := write(tmpFile); err != nil {
+		return "", err
+	}
+	// Flush before the rename, so a crash can't leave a complete name over partial content
+	if err := tmpFile.Sync(); err != nil {
 		return "", fmt.Errorf("failed to write local cache file: %w", err)
 	}
+	if err := tmpFile.Close(); err != nil {
+		return "", fmt.Errorf("failed to write local cache file: %w", err)
+	}
+	if err := os.Rename(tmpFile.Name(), cacheFilePath); err != nil {
+		return "", fmt.Errorf("failed to move local cache file into place: %w", err)
+	}
+
+	return cacheFilePath, nil
+}
 
-	return cacheFilePath.Name(), nil
+// removeStaleLocalTempFiles removes temp files older than maxAge from dir: copies left
+// behind by processes that were killed before they could clean up. Younger temp files
+// may belong to copies in progress and are kept. Errors are ignored; a failed sweep only
+// leaves files for the next one.
+func removeStaleLocalTempFiles(dir string, maxAge time.Duration) {
+	matches, err := filepath.Glob(filepath.Join(dir, "bklog-*.tmp"))
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-maxAge)
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.ModTime().Before(cutoff) {
+			_ = os.Remove(match)
+		}
+	}
+}
+
+// localCachePath returns the deterministic local copy path for a blob key and content version.
+func localCachePath(dir, blobKey, version string) string {
+	sum := sha256.Sum256([]byte(version))
+	return filepath.Join(dir, localCachePrefix(blobKey)+hex.EncodeToString(sum[:8])+".parquet")
+}
+
+// localCachePrefix returns the file name prefix shared by every local copy of a blob key.
+func localCachePrefix(blobKey string) string {
+	return strings.TrimSuffix(blobKey, ".parquet") + "-"
+}
+
+// removeLocalCacheFiles removes every local copy of a blob key from dir.
+func removeLocalCacheFiles(dir, blobKey string) error {
+	pattern := localCachePrefix(blobKey) + strings.Repeat("[0-9a-f]", 16) + ".parquet"
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		if err := os.Remove(match); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,95 @@
+package buildkitelogs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// ErrCorruptCache is returned when a cached blob's content doesn't match the SHA-256
+// recorded in its metadata when it was written, such as a file truncated by an
+// interrupted write. Client reads that find the cached log corrupt download it again
+// instead of returning the error.
+var ErrCorruptCache = errors.New("cached log is corrupt")
+
+// contentSHA256 returns the hex encoded SHA-256 of everything read from r.
+func contentSHA256(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fileSHA256 returns the hex encoded SHA-256 of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path) //nolint:gosec // path from the blob storage backend or a temp file
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return contentSHA256(f)
+}
+
+// checkContentSHA256 returns ErrCorruptCache if the content of the blob at key hashed to
+// got rather than want. Blobs whose metadata records no hash are not checked.
+func checkContentSHA256(key, want, got string) error {
+	if want == "" || got == want {
+		return nil
+	}
+	return fmt.Errorf("%w: %s has SHA-256 %s, but %s was recorded when it was written", ErrCorruptCache, key, got, want)
+}
+
+// verifiedFiles holds the version of each local file last found to match its recorded
+// SHA-256, so a blob read in place is only hashed again once it changes.
+var verifiedFiles sync.Map // path -> "sha256:size:modtime"
+
+// verifyLocalFile checks the file at path, holding the blob at key, against want, the
+// SHA-256 recorded in its metadata.
+func verifyLocalFile(key, path, want string) error {
+	if want == "" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read cached file: %w", err)
+	}
+	version := fmt.Sprintf("%s:%d:%d", want, info.Size(), info.ModTime().UnixNano())
+	if verified, ok := verifiedFiles.Load(path); ok && verified == version {
+		return nil
+	}
+
+	got, err := fileSHA256(path)
+	if err != nil {
+		return fmt.Errorf("failed to read cached file: %w", err)
+	}
+	if err := checkContentSHA256(key, want, got); err != nil {
+		return err
+	}
+	verifiedFiles.Store(path, version)
+	return nil
+}
+
+// verifyBlobContent reads the blob at key in full and checks it against want, the
+// SHA-256 recorded in its metadata.
+func (bs *BlobStorage) verifyBlobContent(ctx context.Context, key, want string) error {
+	if want == "" {
+		return nil
+	}
+	reader, err := bs.Reader(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to read from blob storage: %w", err)
+	}
+	defer reader.Close()
+
+	got, err := contentSHA256(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read from blob storage: %w", err)
+	}
+	return checkContentSHA256(key, want, got)
+}
@@ -0,0 +1,69 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCreateLocalCacheFile_CorruptCopy(t *testing.T) {
+	storage, err := NewBlobStorage(t.Context(), "mem://", nil)
+	if err != nil {
+		t.Fatalf("NewBlobStorage failed: %v", err)
+	}
+	defer storage.Close()
+
+	const key = "org-pipeline-1-job.parquet"
+	dir := t.TempDir()
+
+	// The metadata records the hash of content that was never fully written
+	want, err := contentSHA256(strings.NewReader("complete content"))
+	if err != nil {
+		t.Fatalf("contentSHA256 failed: %v", err)
+	}
+	if err := storage.WriteWithMetadata(t.Context(), key, []byte("complete"), &BlobMetadata{JobID: "job", ContentSHA256: want}); err != nil {
+		t.Fatalf("WriteWithMetadata failed: %v", err)
+	}
+
+	if _, err := createLocalCacheFile(t.Context(), storage, key, dir); !errors.Is(err, ErrCorruptCache) {
+		t.Fatalf("createLocalCacheFile error = %v, want ErrCorruptCache", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("corrupt copy left in the local cache: %v", entries)
+	}
+}
+
+func TestClient_CorruptCacheDownloadsAgain(t *testing.T) {
+	api := newTerminalMock()
+	client := newTestClient(t, api)
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"}
+	blobKey := GenerateBlobKey("org", "pipeline", "1", "job")
+
+	if _, err := client.DownloadAndCache(t.Context(), location, 0, false); err != nil {
+		t.Fatalf("DownloadAndCache failed: %v", err)
+	}
+	metadata, err := client.blobStorage.ReadWithMetadata(t.Context(), blobKey)
+	if err != nil || metadata == nil || metadata.ContentSHA256 == "" {
+		t.Fatalf("cached log metadata = %+v, %v, want a content SHA-256", metadata, err)
+	}
+
+	// Truncate the cached file, as an interrupted write would, keeping its metadata
+	if err := client.blobStorage.WriteWithMetadata(t.Context(), blobKey, []byte("PAR1"), metadata); err != nil {
+		t.Fatalf("WriteWithMetadata failed: %v", err)
+	}
+
+	result, err := client.DownloadAndCache(t.Context(), location, 0, false)
+	if err != nil {
+		t.Fatalf("DownloadAndCache failed: %v", err)
+	}
+	if result.Outcome != CacheRefreshed {
+		t.Errorf("Outcome = %q, want %q", result.Outcome, CacheRefreshed)
+	}
+	if logCalls, _ := api.calls(); logCalls != 2 {
+		t.Errorf("log downloads = %d, want 2", logCalls)
+	}
+	if got := readContents(t, NewParquetReader(result.Path)); len(got) != 1 {
+		t.Errorf("entries = %q, want 1", got)
+	}
+}
@@ -0,0 +1,32 @@
+package buildkitelogs
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// ListCached returns the job logs in the client's cache, sorted by blob key (so by
+// organization, pipeline and build), with the metadata recorded when each was cached:
+// job state, cache time, TTL and sizes. Errors views and other derived blobs are left
+// out, see BlobStorage.Catalog.
+//
+// Listing reads storage directly, without the per-job access checks of NewReader, so it
+// shows every job cached there; only expose it to callers trusted with the whole cache.
+func (c *Client) ListCached(ctx context.Context) ([]CatalogEntry, error) {
+	done, err := c.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	var entries []CatalogEntry
+	for entry, err := range c.blobStorage.Catalog(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b CatalogEntry) int { return strings.Compare(a.Key, b.Key) })
+	return entries, nil
+}
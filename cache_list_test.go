@@ -0,0 +1,42 @@
+package buildkitelogs
+
+import (
+	"testing"
+)
+
+func TestClient_ListCached(t *testing.T) {
+	client := newTestClient(t, newTerminalMock())
+
+	entries, err := client.ListCached(t.Context())
+	if err != nil {
+		t.Fatalf("ListCached failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("empty cache lists %d entries", len(entries))
+	}
+
+	for _, job := range []string{"job-b", "job-a"} {
+		location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: job}
+		if _, err := client.DownloadAndCache(t.Context(), location, 0, false); err != nil {
+			t.Fatalf("DownloadAndCache failed: %v", err)
+		}
+	}
+
+	entries, err = client.ListCached(t.Context())
+	if err != nil {
+		t.Fatalf("ListCached failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("listed %d entries, want 2", len(entries))
+	}
+	first := entries[0]
+	if first.Key != GenerateBlobKey("org", "pipeline", "1", "job-a") {
+		t.Errorf("first key = %q, want job-a's", first.Key)
+	}
+	if first.Size <= 0 || first.Metadata == nil {
+		t.Fatalf("entry = %+v, want a size and metadata", first)
+	}
+	if m := first.Metadata; m.Organization != "org" || m.Pipeline != "pipeline" || m.Build != "1" || m.JobID != "job-a" || !m.IsTerminal || m.CachedAt.IsZero() {
+		t.Errorf("metadata = %+v", m)
+	}
+}
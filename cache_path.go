@@ -0,0 +1,84 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// CacheLocation describes where a job's cached log lives, or would live once cached.
+type CacheLocation struct {
+	BlobKey       string `json:"blob_key"`
+	StorageURL    string `json:"storage_url"`          // Storage URL the client resolved
+	BlobURL       string `json:"blob_url"`             // Storage URL of the blob, e.g. s3://bucket/key
+	Cached        bool   `json:"cached"`               // The blob exists
+	LocalPath     string `json:"local_path,omitempty"` // File readers read, empty if it depends on content not yet cached
+	LocalExists   bool   `json:"local_exists"`         // LocalPath exists
+	LocalCacheDir string `json:"local_cache_dir"`      // Directory local copies of remote blobs are kept in
+}
+
+// CachePath reports where a job's cached log and the local file readers use live, without
+// downloading anything or calling the Buildkite API. For file:// storage the local file
+// is the blob itself, so its path is known before the log is cached. Other backends copy
+// the blob into the local cache directory under a name derived from its content, so
+// LocalPath is only known once the log is cached, and the copy may not exist yet.
+func (c *Client) CachePath(ctx context.Context, location JobLocation) (*CacheLocation, error) {
+	if err := ValidateAPIParams(location.Org, location.Pipeline, location.Build, location.Job); err != nil {
+		return nil, err
+	}
+
+	done, err := c.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	blobKey := GenerateBlobKey(location.Org, location.Pipeline, location.Build, location.Job)
+	storageURL, err := url.Parse(c.blobStorage.URL())
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage URL: %w", err)
+	}
+	blobURL := *storageURL
+	blobURL.Path = path.Join(storageURL.Path, blobKey)
+	blobURL.RawQuery = ""
+
+	loc := &CacheLocation{
+		BlobKey:       blobKey,
+		StorageURL:    c.blobStorage.URL(),
+		BlobURL:       blobURL.String(),
+		LocalCacheDir: c.localCacheDir,
+	}
+
+	if loc.Cached, err = c.blobStorage.Exists(ctx, blobKey); err != nil {
+		return nil, fmt.Errorf("failed to check blob existence: %w", err)
+	}
+
+	switch {
+	case storageURL.Scheme == "file":
+		loc.LocalPath = filepath.Join(filepath.FromSlash(storageURL.Path), blobKey)
+		if loc.Cached {
+			if blobPath, err := c.blobStorage.LocalPath(ctx, blobKey); err == nil && blobPath != "" {
+				loc.LocalPath = blobPath
+			}
+		}
+	case c.inMemory:
+		// Readers read the blob itself, so there is no local copy
+	case loc.Cached:
+		version, err := readExistingBlob(ctx, c.consistencyRetry, blobKey, func() (string, error) {
+			return c.blobStorage.ContentVersion(ctx, blobKey)
+		})
+		if err != nil {
+			return nil, err
+		}
+		loc.LocalPath = localCachePath(c.localCacheDir, blobKey, version)
+	}
+
+	if loc.LocalPath != "" {
+		_, statErr := os.Stat(loc.LocalPath)
+		loc.LocalExists = statErr == nil
+	}
+	return loc, nil
+}
@@ -0,0 +1,48 @@
+package buildkitelogs
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_CachePath(t *testing.T) {
+	api := newTerminalMock()
+	client := newTestClient(t, api)
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "test-job"}
+
+	storageDir := strings.TrimPrefix(client.blobStorage.URL(), "file://")
+	wantPath := filepath.Join(storageDir, "org-pipeline-1-test-job.parquet")
+
+	loc, err := client.CachePath(t.Context(), location)
+	if err != nil {
+		t.Fatalf("CachePath failed: %v", err)
+	}
+	if loc.Cached || loc.LocalExists {
+		t.Errorf("expected nothing cached before the first read: %+v", loc)
+	}
+	if loc.LocalPath != wantPath {
+		t.Errorf("LocalPath = %s, want %s", loc.LocalPath, wantPath)
+	}
+	if loc.BlobURL != "file://"+wantPath {
+		t.Errorf("BlobURL = %s, want file://%s", loc.BlobURL, wantPath)
+	}
+	if logCalls, statusCalls := api.calls(); logCalls != 0 || statusCalls != 0 {
+		t.Errorf("CachePath made %d log and %d status calls, want none", logCalls, statusCalls)
+	}
+
+	reader, err := client.NewReader(t.Context(), "org", "pipeline", "1", "test-job", time.Minute, false)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	reader.Close()
+
+	loc, err = client.CachePath(t.Context(), location)
+	if err != nil {
+		t.Fatalf("CachePath failed: %v", err)
+	}
+	if !loc.Cached || !loc.LocalExists || loc.LocalPath != wantPath {
+		t.Errorf("after caching: %+v, want the blob at %s", loc, wantPath)
+	}
+}
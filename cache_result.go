@@ -0,0 +1,61 @@
+package buildkitelogs
+
+import (
+	"context"
+	"time"
+)
+
+// CacheOutcome is how DownloadAndCache served a job log.
+type CacheOutcome string
+
+const (
+	CacheHit       CacheOutcome = "hit"       // The cached log was current and used as is
+	CacheRefreshed CacheOutcome = "refreshed" // The log was downloaded and cached by this call
+	CacheJoined    CacheOutcome = "joined"    // A concurrent call's refresh cached the log, and this call waited for it
+	CacheExtended  CacheOutcome = "extended"  // Only the new output of a running job was downloaded, parsed and cached by this call, see WithIncrementalRefresh
+)
+
+// CacheResult describes a job log made available by DownloadAndCache.
+type CacheResult struct {
+	Path      string         `json:"path"`     // Local Parquet file; shared with readers and must not be removed. Empty for clients created WithInMemory
+	BlobKey   string         `json:"blob_key"` // Key of the log in blob storage
+	Outcome   CacheOutcome   `json:"outcome"`
+	JobStatus *JobStatus     `json:"job_status,omitempty"` // Nil when a cached log of a finished job was used without looking it up
+	Sizes     CacheSizes     `json:"sizes"`
+	Durations CacheDurations `json:"durations"`
+}
+
+// CacheSizes are the sizes recorded when a job log was cached. They are zero for logs
+// cached without metadata.
+type CacheSizes struct {
+	LogBytes     int64 `json:"log_bytes"`     // Raw log size
+	ParquetBytes int64 `json:"parquet_bytes"` // Cached Parquet file size
+	Rows         int   `json:"rows"`          // Log entries
+}
+
+// CacheDurations are the time DownloadAndCache spent in each stage, as reported to
+// Hooks. Download, Parse and Store are zero for cache hits; for CacheJoined they are
+// those of the refresh the call waited for.
+type CacheDurations struct {
+	CacheCheck  time.Duration `json:"cache_check"`
+	Download    time.Duration `json:"download"` // Until the log started downloading
+	Parse       time.Duration `json:"parse"`    // Streaming the log and converting it to Parquet
+	Store       time.Duration `json:"store"`    // Writing the Parquet file to blob storage
+	Materialize time.Duration `json:"materialize"`
+	Total       time.Duration `json:"total"`
+}
+
+// DownloadAndCache makes a job's log available as a local Parquet file, downloading it
+// when the cache has no current copy, like NewReader, and describes how: the file, its
+// blob key, whether the cache was used, the job status looked up on the way, and the
+// sizes and durations of each stage. ttl and forceRefresh are as for NewReader.
+func (c *Client) DownloadAndCache(ctx context.Context, location JobLocation, ttl time.Duration, forceRefresh bool) (*CacheResult, error) {
+	return c.downloadAndCacheResult(ctx, c.api, location.Org, location.Pipeline, location.Build, location.Job, ttl, forceRefresh)
+}
+
+func cacheSizesFromMetadata(metadata *BlobMetadata) CacheSizes {
+	if metadata == nil {
+		return CacheSizes{}
+	}
+	return CacheSizes{LogBytes: metadata.LogSize, ParquetBytes: metadata.ParquetSize, Rows: metadata.RowCount}
+}
@@ -0,0 +1,50 @@
+package buildkitelogs
+
+import (
+	"os"
+	"testing"
+)
+
+func TestClient_DownloadAndCache(t *testing.T) {
+	api := newTerminalMock()
+	client := newTestClient(t, api)
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"}
+
+	first, err := client.DownloadAndCache(t.Context(), location, 0, false)
+	if err != nil {
+		t.Fatalf("DownloadAndCache failed: %v", err)
+	}
+	if first.Outcome != CacheRefreshed || first.JobStatus == nil || first.JobStatus.State != JobStatePassed {
+		t.Errorf("first result = %+v, want a refresh with the job status", first)
+	}
+	if first.BlobKey != GenerateBlobKey("org", "pipeline", "1", "job") {
+		t.Errorf("BlobKey = %q", first.BlobKey)
+	}
+	if first.Sizes.Rows != 1 || first.Sizes.ParquetBytes == 0 || first.Sizes.LogBytes == 0 {
+		t.Errorf("Sizes = %+v", first.Sizes)
+	}
+	if first.Durations.Total < first.Durations.Parse {
+		t.Errorf("Durations = %+v", first.Durations)
+	}
+	if _, err := os.Stat(first.Path); err != nil {
+		t.Errorf("cached file: %v", err)
+	}
+
+	second, err := client.DownloadAndCache(t.Context(), location, 0, false)
+	if err != nil {
+		t.Fatalf("DownloadAndCache failed: %v", err)
+	}
+	if second.Outcome != CacheHit || second.Path != first.Path || second.Sizes != first.Sizes {
+		t.Errorf("second result = %+v, want a hit on %s", second, first.Path)
+	}
+	if second.Durations.Parse != 0 {
+		t.Errorf("cache hit spent %s parsing", second.Durations.Parse)
+	}
+	if logCalls, _ := api.calls(); logCalls != 1 {
+		t.Errorf("downloaded %d logs, want 1", logCalls)
+	}
+
+	if _, err := client.DownloadAndCache(t.Context(), JobLocation{Org: "org"}, 0, false); err == nil {
+		t.Error("expected error for incomplete location")
+	}
+}
@@ -0,0 +1,246 @@
+package buildkitelogs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+	"gocloud.dev/blob"
+)
+
+// DefaultMaxCacheSegments is how many segments WithIncrementalRefresh appends to the
+// cached log of a running job before compacting them into a single file again.
+const DefaultMaxCacheSegments = 32
+
+// segmentKeyPrefix returns the prefix of the keys of the segments of a generation of the
+// log at blobKey. Segment keys don't end in ".parquet", so they are not listed as logs.
+func segmentKeyPrefix(blobKey, generation string) string {
+	return blobKey + ".seg-" + generation + "-"
+}
+
+// segmentKey returns the key of the nth segment of a generation, numbered from 1 so keys
+// sort in the order the segments were appended.
+func segmentKey(blobKey, generation string, n int) string {
+	return fmt.Sprintf("%s%06d", segmentKeyPrefix(blobKey, generation), n)
+}
+
+// newSegmentGeneration returns a random segment generation, so segments left by an
+// earlier version of a log can't be mistaken for segments of the current one.
+func newSegmentGeneration() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// listKeys returns the keys of the blobs starting with prefix, in lexical order.
+func (bs *BlobStorage) listKeys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	it := bs.bucket.List(&blob.ListOptions{Prefix: prefix})
+	for {
+		obj, err := it.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list blobs: %w", err)
+		}
+		if !obj.IsDir {
+			keys = append(keys, obj.Key)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// deleteSegments removes the segments of every generation of the log at blobKey except
+// keep. Segments that are already gone are ignored.
+func (bs *BlobStorage) deleteSegments(ctx context.Context, blobKey, keep string) error {
+	keys, err := bs.listKeys(ctx, blobKey+".seg-")
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if keep != "" && strings.HasPrefix(key, segmentKeyPrefix(blobKey, keep)) {
+			continue
+		}
+		if err := bs.Delete(ctx, key); err != nil && !IsBlobNotFound(err) {
+			return fmt.Errorf("failed to delete segment %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// segmentKeys returns the keys of the segments of a generation of the log at blobKey, in
+// order. Segments are numbered from 1; any after a gap in the numbering were appended to
+// a version of the log that no longer exists, and are left out.
+func (bs *BlobStorage) segmentKeys(ctx context.Context, blobKey, generation string) ([]string, error) {
+	prefix := segmentKeyPrefix(blobKey, generation)
+	keys, err := bs.listKeys(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		if n, err := strconv.Atoi(strings.TrimPrefix(key, prefix)); err != nil || n != i+1 {
+			return keys[:i], nil
+		}
+	}
+	return keys, nil
+}
+
+// cachedLogMetadata returns the metadata describing the whole cached log at blobKey: the
+// metadata of its last segment, if segments were appended to it.
+func (bs *BlobStorage) cachedLogMetadata(ctx context.Context, blobKey string) (*BlobMetadata, error) {
+	metadata, err := bs.ReadWithMetadata(ctx, blobKey)
+	if err != nil || metadata == nil || metadata.SegmentGeneration == "" {
+		return metadata, err
+	}
+	keys, err := bs.segmentKeys(ctx, blobKey, metadata.SegmentGeneration)
+	if err != nil || len(keys) == 0 {
+		return metadata, err
+	}
+	return bs.ReadWithMetadata(ctx, keys[len(keys)-1])
+}
+
+// cachePart is a blob holding part of a cached log: the log's own blob or a segment.
+type cachePart struct {
+	key    string
+	kept   int    // Rows of the part kept when the log is continued after it
+	sha256 string // SHA-256 recorded in the part's metadata, see BlobMetadata.ContentSHA256
+}
+
+// cachedLog is a cached log: its blob followed by the segments appended to it.
+type cachedLog struct {
+	parts    []cachePart
+	metadata *BlobMetadata // Metadata of the last part, which describes the whole log
+}
+
+// segments returns the number of segments appended to the log's blob.
+func (l *cachedLog) segments() int {
+	return len(l.parts) - 1
+}
+
+// readCachedLog reads the metadata of the log at blobKey and of its segments.
+func readCachedLog(ctx context.Context, bs *BlobStorage, blobKey string) (*cachedLog, error) {
+	metadata, err := bs.ReadWithMetadata(ctx, blobKey)
+	if err != nil {
+		return nil, err
+	}
+	log := &cachedLog{parts: []cachePart{{key: blobKey}}, metadata: metadata}
+	if metadata == nil {
+		return log, nil
+	}
+	log.parts[0].kept = metadata.LogOffsetRows
+	log.parts[0].sha256 = metadata.ContentSHA256
+	if metadata.SegmentGeneration == "" {
+		return log, nil
+	}
+
+	keys, err := bs.segmentKeys(ctx, blobKey, metadata.SegmentGeneration)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		segment, err := bs.ReadWithMetadata(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if segment == nil {
+			return nil, fmt.Errorf("segment %s has no metadata", key)
+		}
+		log.parts = append(log.parts, cachePart{
+			key:    key,
+			kept:   segment.LogOffsetRows - log.metadata.LogOffsetRows,
+			sha256: segment.ContentSHA256,
+		})
+		log.metadata = segment
+	}
+	return log, nil
+}
+
+// entries returns the kept rows of every part of the log, and with all also the rows
+// after them in the last part: the whole log as it is cached.
+func (l *cachedLog) entries(ctx context.Context, open func(key string) *ParquetReader, all bool) iter.Seq2[*logparser.Entry, error] {
+	return func(yield func(*logparser.Entry, error) bool) {
+		for i, part := range l.parts {
+			limit := part.kept
+			if all && i == len(l.parts)-1 {
+				limit = -1
+			}
+			if limit == 0 {
+				continue
+			}
+
+			read := 0
+			for entry, err := range logEntriesFromParquet(open(part.key).ReadEntriesIter(ctx)) {
+				if !yield(entry, err) || err != nil {
+					return
+				}
+				read++
+				if read == limit {
+					break
+				}
+			}
+			if read < limit {
+				yield(nil, fmt.Errorf("cached log part %s has %d rows, want %d", part.key, read, limit))
+				return
+			}
+		}
+	}
+}
+
+// lastKeptGroup returns the group of the last kept row of the log and the group it is
+// nested in, which output parsed after it continues.
+func (l *cachedLog) lastKeptGroup(ctx context.Context, open func(key string) *ParquetReader) (group, parent string, err error) {
+	for _, part := range slices.Backward(l.parts) {
+		if part.kept <= 0 {
+			continue
+		}
+		for entry, err := range open(part.key).SeekToRow(ctx, int64(part.kept-1)) {
+			if err != nil {
+				return "", "", err
+			}
+			return entry.Group, entry.ParentGroup, nil
+		}
+		return "", "", fmt.Errorf("cached log part %s has fewer than %d rows", part.key, part.kept)
+	}
+	return "", "", nil
+}
+
+// createMergedLocalCacheFile writes the parts of a log with segments into one local
+// file in dir, reused by later calls until another segment is appended.
+func createMergedLocalCacheFile(ctx context.Context, blobStorage *BlobStorage, log *cachedLog, blobKey, dir string) (string, error) {
+	last := log.parts[len(log.parts)-1].key
+	version, err := blobStorage.ContentVersion(ctx, last)
+	if err != nil {
+		return "", err
+	}
+
+	return writeLocalCacheFile(dir, localCachePath(dir, blobKey, last+"@"+version), func(w io.Writer) error {
+		return writeMergedLog(ctx, blobStorage, log, w)
+	})
+}
+
+// writeMergedLog writes the parts of a log with segments to w as one Parquet file. Each
+// part is checked against its recorded SHA-256 first.
+func writeMergedLog(ctx context.Context, blobStorage *BlobStorage, log *cachedLog, w io.Writer) error {
+	for _, part := range log.parts {
+		if err := blobStorage.verifyBlobContent(ctx, part.key, part.sha256); err != nil {
+			return err
+		}
+	}
+	open := func(key string) *ParquetReader {
+		return NewParquetReaderFromBlob(ctx, blobStorage, key)
+	}
+	if _, err := ExportSeq2ToParquetWriter(log.entries(ctx, open, true), w); err != nil {
+		return fmt.Errorf("failed to merge cached log segments: %w", err)
+	}
+	return nil
+}
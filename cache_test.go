@@ -0,0 +1,125 @@
+package buildkitelogs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateLocalCacheFile_FileBackendUsesBlobPath(t *testing.T) {
+	storageDir := t.TempDir()
+	storage, err := NewBlobStorage(t.Context(), "file://"+storageDir, nil)
+	if err != nil {
+		t.Fatalf("NewBlobStorage failed: %v", err)
+	}
+	defer storage.Close()
+
+	if err := storage.WriteWithMetadata(t.Context(), "org-pipeline-1-job.parquet", []byte("data"), nil); err != nil {
+		t.Fatalf("WriteWithMetadata failed: %v", err)
+	}
+
+	path, err := createLocalCacheFile(t.Context(), storage, "org-pipeline-1-job.parquet", t.TempDir())
+	if err != nil {
+		t.Fatalf("createLocalCacheFile failed: %v", err)
+	}
+	if want := filepath.Join(storageDir, "org-pipeline-1-job.parquet"); path != want {
+		t.Errorf("path = %q, want the blob's own path %q", path, want)
+	}
+}
+
+func TestCreateLocalCacheFile_ReusesCopyPerContent(t *testing.T) {
+	storage, err := NewBlobStorage(t.Context(), "mem://", nil)
+	if err != nil {
+		t.Fatalf("NewBlobStorage failed: %v", err)
+	}
+	defer storage.Close()
+
+	const key = "org-pipeline-1-job.parquet"
+	dir := t.TempDir()
+
+	if err := storage.WriteWithMetadata(t.Context(), key, []byte("first"), nil); err != nil {
+		t.Fatalf("WriteWithMetadata failed: %v", err)
+	}
+	first, err := createLocalCacheFile(t.Context(), storage, key, dir)
+	if err != nil {
+		t.Fatalf("createLocalCacheFile failed: %v", err)
+	}
+	if !strings.HasPrefix(first, dir) {
+		t.Fatalf("path %q is not in the local cache directory %q", first, dir)
+	}
+	again, err := createLocalCacheFile(t.Context(), storage, key, dir)
+	if err != nil {
+		t.Fatalf("createLocalCacheFile failed: %v", err)
+	}
+	if again != first {
+		t.Errorf("unchanged blob materialized to %q, then %q", first, again)
+	}
+
+	if err := storage.WriteWithMetadata(t.Context(), key, []byte("second"), nil); err != nil {
+		t.Fatalf("WriteWithMetadata failed: %v", err)
+	}
+	second, err := createLocalCacheFile(t.Context(), storage, key, dir)
+	if err != nil {
+		t.Fatalf("createLocalCacheFile failed: %v", err)
+	}
+	if second == first {
+		t.Fatal("changed blob reused the stale local copy")
+	}
+	data, err := os.ReadFile(second)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != "second" {
+		t.Errorf("local copy contains %q, want %q", data, "second")
+	}
+
+	if err := removeLocalCacheFiles(dir, key); err != nil {
+		t.Fatalf("removeLocalCacheFiles failed: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected local cache directory to be empty, found %d files", len(entries))
+	}
+}
+
+func TestCreateLocalCacheFile_RemovesStaleTempFiles(t *testing.T) {
+	storage, err := NewBlobStorage(t.Context(), "mem://", nil)
+	if err != nil {
+		t.Fatalf("NewBlobStorage failed: %v", err)
+	}
+	defer storage.Close()
+
+	const key = "org-pipeline-1-job.parquet"
+	if err := storage.WriteWithMetadata(t.Context(), key, []byte("data"), nil); err != nil {
+		t.Fatalf("WriteWithMetadata failed: %v", err)
+	}
+
+	// A copy stranded by a killed process, and one still in progress
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "bklog-stale.tmp")
+	inProgress := filepath.Join(dir, "bklog-copying.tmp")
+	for _, path := range []string{stale, inProgress} {
+		if err := os.WriteFile(path, []byte("partial"), 0o600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	old := time.Now().Add(-2 * staleLocalTempAge)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+
+	if _, err := createLocalCacheFile(t.Context(), storage, key, dir); err != nil {
+		t.Fatalf("createLocalCacheFile failed: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale temp file was kept: %v", err)
+	}
+	if _, err := os.Stat(inProgress); err != nil {
+		t.Errorf("in-progress temp file was removed: %v", err)
+	}
+}
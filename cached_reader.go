@@ -0,0 +1,145 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gocloud.dev/blob"
+)
+
+// ErrNotCached is returned by Client.OpenCached when a job's log is not in the cache.
+var ErrNotCached = errors.New("job log is not cached")
+
+// ReaderAtCloser provides random access to a cached Parquet log without going through
+// a local file path.
+type ReaderAtCloser interface {
+	io.ReaderAt
+	io.Closer
+	Size() int64
+}
+
+// OpenCached returns a handle over a job's cached Parquet log. file:// caches are opened
+// in place; other backends are read with ranged requests against blob storage, so no
+// local filesystem writes are needed. Use NewParquetReaderFromReaderAt to query it.
+//
+// OpenCached never downloads the log; it returns ErrNotCached when the job has no cache
+// entry. Access is checked with the current API identity, as for NewReader. The cached
+// log of a running job can be replaced by a later refresh, so reopen the handle after
+// refreshing rather than holding it open. A log with segments appended by
+// WithIncrementalRefresh is the exception to reading in place: its parts are merged into
+// a local file first, or into memory for clients created WithInMemory.
+func (c *Client) OpenCached(ctx context.Context, location JobLocation) (ReaderAtCloser, error) {
+	org, pipeline, build, job := location.Org, location.Pipeline, location.Build, location.Job
+	if err := ValidateAPIParams(org, pipeline, build, job); err != nil {
+		return nil, err
+	}
+
+	done, err := c.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	if err := validateJobLogAccess(ctx, c.api, org, pipeline, build, job); err != nil {
+		return nil, fmt.Errorf("failed to validate job log access: %w", err)
+	}
+
+	blobKey := GenerateBlobKey(org, pipeline, build, job)
+	exists, _, err := c.checkCache(ctx, org, pipeline, build, job, blobKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check blob existence: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrNotCached, blobKey)
+	}
+
+	return readExistingBlob(ctx, c.consistencyRetry, blobKey, func() (ReaderAtCloser, error) {
+		log, err := readCachedLog(ctx, c.blobStorage, blobKey)
+		if err != nil {
+			return nil, err
+		}
+		if log.segments() == 0 {
+			return c.blobStorage.OpenReaderAt(ctx, blobKey)
+		}
+		return c.openMergedLog(ctx, log, blobKey)
+	})
+}
+
+// OpenReaderAt returns random access to a blob. Blobs stored as plain files are opened
+// directly; other backends issue a ranged read per ReadAt call using ctx.
+func (bs *BlobStorage) OpenReaderAt(ctx context.Context, key string) (ReaderAtCloser, error) {
+	if path, err := bs.LocalPath(ctx, key); err == nil && path != "" {
+		return openFileReaderAt(path)
+	}
+
+	attrs, err := bs.bucket.Attributes(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob attributes: %w", err)
+	}
+	return &blobReaderAt{ctx: ctx, bucket: bs.bucket, key: key, size: attrs.Size}, nil
+}
+
+// openFileReaderAt opens the local file at path as a ReaderAtCloser.
+func openFileReaderAt(path string) (ReaderAtCloser, error) {
+	f, err := os.Open(path) //nolint:gosec // path from the blob storage backend or local cache
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cached file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+	return &fileReaderAt{File: f, size: info.Size()}, nil
+}
+
+// fileReaderAt is a ReaderAtCloser over a local file.
+type fileReaderAt struct {
+	*os.File
+	size int64
+}
+
+func (f *fileReaderAt) Size() int64 {
+	return f.size
+}
+
+// blobReaderAt is a ReaderAtCloser issuing ranged reads against blob storage.
+type blobReaderAt struct {
+	ctx    context.Context
+	bucket *blob.Bucket
+	key    string
+	size   int64
+}
+
+func (r *blobReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset %d", off)
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+
+	length := min(int64(len(p)), r.size-off)
+	reader, err := r.bucket.NewRangeReader(r.ctx, r.key, off, length, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read blob range: %w", err)
+	}
+	defer reader.Close()
+
+	n, err := io.ReadFull(reader, p[:length])
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (r *blobReaderAt) Close() error {
+	return nil
+}
+
+func (r *blobReaderAt) Size() int64 {
+	return r.size
+}
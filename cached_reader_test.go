@@ -0,0 +1,79 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestBlobStorage_OpenReaderAtRangeReads(t *testing.T) {
+	storage, err := NewBlobStorage(t.Context(), "mem://", nil)
+	if err != nil {
+		t.Fatalf("NewBlobStorage failed: %v", err)
+	}
+	defer storage.Close()
+
+	const key = "org-pipeline-1-job.parquet"
+	if err := storage.WriteWithMetadata(t.Context(), key, []byte("0123456789"), nil); err != nil {
+		t.Fatalf("WriteWithMetadata failed: %v", err)
+	}
+
+	r, err := storage.OpenReaderAt(t.Context(), key)
+	if err != nil {
+		t.Fatalf("OpenReaderAt failed: %v", err)
+	}
+	defer r.Close()
+
+	if r.Size() != 10 {
+		t.Errorf("Size() = %d, want 10", r.Size())
+	}
+
+	buf := make([]byte, 4)
+	if n, err := r.ReadAt(buf, 3); err != nil || string(buf[:n]) != "3456" {
+		t.Errorf("ReadAt(3) = %q, %v", buf[:n], err)
+	}
+	if n, err := r.ReadAt(buf, 8); err != io.EOF || string(buf[:n]) != "89" {
+		t.Errorf("ReadAt(8) = %q, %v; want \"89\", EOF", buf[:n], err)
+	}
+	if _, err := r.ReadAt(buf, 10); err != io.EOF {
+		t.Errorf("ReadAt past end error = %v, want EOF", err)
+	}
+}
+
+func TestClient_OpenCached(t *testing.T) {
+	api := newTerminalMock()
+	client := newTestClient(t, api)
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "test-job"}
+
+	if _, err := client.OpenCached(t.Context(), location); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("OpenCached before download error = %v, want ErrNotCached", err)
+	}
+
+	reader, err := client.NewReader(t.Context(), "org", "pipeline", "1", "test-job", time.Minute, false)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	reader.Close()
+
+	cached, err := client.OpenCached(t.Context(), location)
+	if err != nil {
+		t.Fatalf("OpenCached failed: %v", err)
+	}
+	defer cached.Close()
+
+	var entries []ParquetLogEntry
+	for entry, err := range NewParquetReaderFromReaderAt(cached, cached.Size()).ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 1 || entries[0].CleanContent(true) != "Test log entry" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+
+	if logCalls, _ := api.calls(); logCalls != 1 {
+		t.Errorf("log downloaded %d times, want 1", logCalls)
+	}
+}
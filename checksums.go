@@ -0,0 +1,191 @@
+package buildkitelogs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/metadata"
+)
+
+// ChecksumsMetadataKey is the Parquet key/value metadata key holding the data checksums.
+const ChecksumsMetadataKey = "buildkite.checksums"
+
+// ErrChecksumMismatch is returned by readers with checksum validation enabled when the
+// file's data does not match the checksums recorded when it was written.
+var ErrChecksumMismatch = errors.New("parquet data checksum mismatch")
+
+// ParquetWriterOption configures a ParquetWriter.
+type ParquetWriterOption func(*ParquetWriter)
+
+// WithWriteChecksums sets whether the writer records a CRC-32 checksum of the bytes of
+// every batch's row groups under ChecksumsMetadataKey. The Parquet library does not write
+// page CRCs, so these cover each WriteBatch call instead of each page. Default is on.
+func WithWriteChecksums(enabled bool) ParquetWriterOption {
+	return func(pw *ParquetWriter) {
+		pw.checksumsEnabled = enabled
+	}
+}
+
+// WithChecksumValidation makes readers returned by the client validate checksums (see
+// ParquetReader.WithChecksumValidation), so a cached log corrupted in storage or in
+// transfer fails to read instead of returning wrong results. Default is off.
+func WithChecksumValidation(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.checksums = enabled
+	}
+}
+
+// checksumRange is the CRC-32 (IEEE) checksum of a byte range of a Parquet file.
+type checksumRange struct {
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+	CRC32  uint32 `json:"crc32"`
+}
+
+// checksumWriter checksums the bytes passed through to w, one range per mark.
+type checksumWriter struct {
+	w       io.Writer
+	crc     hash.Hash32
+	written int64
+	start   int64
+	ranges  []checksumRange
+}
+
+func newChecksumWriter(w io.Writer) *checksumWriter {
+	return &checksumWriter{w: w, crc: crc32.NewIEEE()}
+}
+
+func (c *checksumWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	_, _ = c.crc.Write(p[:n])
+	c.written += int64(n)
+	return n, err
+}
+
+// Close closes w if it is closable, as the Parquet writer would have done without the
+// checksumWriter in between.
+func (c *checksumWriter) Close() error {
+	if closer, ok := c.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// mark ends the current range at the bytes written so far.
+func (c *checksumWriter) mark() {
+	if c.written == c.start {
+		return
+	}
+	c.ranges = append(c.ranges, checksumRange{
+		Offset: c.start,
+		Length: c.written - c.start,
+		CRC32:  c.crc.Sum32(),
+	})
+	c.crc.Reset()
+	c.start = c.written
+}
+
+func (c *checksumWriter) marshal() (string, error) {
+	ranges := c.ranges
+	if ranges == nil {
+		ranges = []checksumRange{}
+	}
+	data, err := json.Marshal(ranges)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func checksumsFromMetadata(md *metadata.FileMetaData) ([]checksumRange, bool, error) {
+	value := md.KeyValueMetadata().FindValue(ChecksumsMetadataKey)
+	if value == nil {
+		return nil, false, nil
+	}
+
+	var ranges []checksumRange
+	if err := json.Unmarshal([]byte(*value), &ranges); err != nil {
+		return nil, false, fmt.Errorf("failed to decode checksums: %w", err)
+	}
+	return ranges, true, nil
+}
+
+// WithChecksumValidation makes every read verify the file's data against its recorded
+// checksums before decoding it, failing with ErrChecksumMismatch if a range differs, so a
+// corrupted copy is reported instead of returning wrong results. This reads the file's
+// data once more per read. Files written without checksums are read unverified. It
+// returns the reader.
+func (pr *ParquetReader) WithChecksumValidation() *ParquetReader {
+	if !pr.validateChecksums {
+		pr.validateChecksums = true
+		pr.source = checksummedSource(pr.source)
+	}
+	return pr
+}
+
+// newParquetReader opens a cached log, validating checksums if the client is configured to.
+func (c *Client) newParquetReader(filePath string) *ParquetReader {
+	reader := NewParquetReader(filePath).WithLogger(c.logger)
+	if c.checksums {
+		reader.WithChecksumValidation()
+	}
+	return reader
+}
+
+// newParquetReaderFromBlob opens a cached blob, validating checksums if the client is
+// configured to.
+func (c *Client) newParquetReaderFromBlob(ctx context.Context, key string) *ParquetReader {
+	reader := NewParquetReaderFromBlob(ctx, c.blobStorage, key).WithLogger(c.logger)
+	if c.checksums {
+		reader.WithChecksumValidation()
+	}
+	return reader
+}
+
+// checksummedSource returns a parquetSource that verifies the checksums of src's bytes
+// each time it is opened.
+func checksummedSource(src parquetSource) parquetSource {
+	return func() (*openedParquet, error) {
+		opened, err := src()
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyChecksums(opened); err != nil {
+			_ = opened.Close()
+			return nil, err
+		}
+		return opened, nil
+	}
+}
+
+func verifyChecksums(opened *openedParquet) error {
+	// The section reader keeps pf.Close from closing opened, which the read still needs.
+	pf, err := file.NewParquetReader(io.NewSectionReader(opened.r, 0, opened.size))
+	if err != nil {
+		return fmt.Errorf("failed to open parquet file: %w", err)
+	}
+	defer pf.Close()
+
+	ranges, ok, err := checksumsFromMetadata(pf.MetaData())
+	if err != nil || !ok {
+		return err
+	}
+
+	crc := crc32.NewIEEE()
+	for _, r := range ranges {
+		crc.Reset()
+		if _, err := io.Copy(crc, io.NewSectionReader(opened.r, r.Offset, r.Length)); err != nil {
+			return fmt.Errorf("failed to read bytes %d-%d: %w", r.Offset, r.Offset+r.Length-1, err)
+		}
+		if crc.Sum32() != r.CRC32 {
+			return fmt.Errorf("%w: bytes %d-%d", ErrChecksumMismatch, r.Offset, r.Offset+r.Length-1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestChecksumValidation(t *testing.T) {
+	filename := writeGroupIndexTestFile(t, groupIndexTestEntries())
+
+	info, err := NewParquetReader(filename).GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	if !info.Checksummed {
+		t.Fatal("expected file to record checksums")
+	}
+
+	rows := 0
+	for _, err := range NewParquetReader(filename).WithChecksumValidation().ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("read of intact file failed: %v", err)
+		}
+		rows++
+	}
+	if rows != 3413 {
+		t.Errorf("read %d rows, want 3413", rows)
+	}
+
+	// Flip a byte inside the first row group's data
+	data, err := os.ReadFile(filename) //nolint:gosec // test file
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[100] ^= 0xff
+	if err := os.WriteFile(filename, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := NewParquetReader(filename).WithChecksumValidation()
+	for _, err := range reader.ReadEntriesIter(t.Context()) {
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("ReadEntriesIter error = %v, want ErrChecksumMismatch", err)
+		}
+		break
+	}
+	if _, err := reader.GetFileInfo(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("GetFileInfo error = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestChecksumsDisabled(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "unchecked.parquet")
+	file, err := os.Create(filename) //nolint:gosec // test file
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, err := NewParquetWriter(file, WithWriteChecksums(false))
+	if err != nil {
+		t.Fatalf("NewParquetWriter failed: %v", err)
+	}
+	if err := writer.WriteBatch([]*logparser.Entry{{Content: "hello"}}); err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reader := NewParquetReader(filename).WithChecksumValidation()
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	if info.Checksummed {
+		t.Error("expected file without checksums")
+	}
+	if info.RowCount != 1 {
+		t.Errorf("RowCount = %d, want 1", info.RowCount)
+	}
+}
@@ -0,0 +1,266 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// DefaultClickHouseTable is the table a ClickHouseSink inserts into when
+// ClickHouseOptions.Table is empty.
+const DefaultClickHouseTable = "buildkite_logs"
+
+// ClickHouseTableSchema creates the table a ClickHouseSink inserts into; replace
+// buildkite_logs with ClickHouseOptions.Table. The ReplacingMergeTree collapses rows
+// inserted twice, by a retried insert or a re-exported job, on their sorting key.
+const ClickHouseTableSchema = `CREATE TABLE IF NOT EXISTS buildkite_logs (
+    org        LowCardinality(String),
+    pipeline   LowCardinality(String),
+    build      String,
+    job        String,
+    row        UInt64,
+    timestamp  Nullable(DateTime64(3, 'UTC')),
+    group_name LowCardinality(String),
+    content    String,
+    is_group   Bool
+) ENGINE = ReplacingMergeTree
+ORDER BY (org, pipeline, build, job, row)`
+
+// DefaultClickHouseBatchSize is the number of rows a ClickHouseSink sends per insert.
+// ClickHouse prefers few large inserts over many small ones.
+const DefaultClickHouseBatchSize = 10000
+
+// DefaultClickHouseRetry retries a failed insert four times over about 7s, covering
+// ClickHouse overload (429, 503) and restarts.
+var DefaultClickHouseRetry = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     4 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// ClickHouseOptions configures a ClickHouseSink.
+type ClickHouseOptions struct {
+	URL        string       // ClickHouse HTTP interface, e.g. http://localhost:8123
+	Table      string       // Table or database.table to insert into (default: DefaultClickHouseTable)
+	Username   string       // User to authenticate as (optional)
+	Password   string       // Password of Username (optional)
+	Org        string       // Value of the org column
+	Pipeline   string       // Value of the pipeline column
+	Build      string       // Value of the build column
+	Job        string       // Value of the job column
+	BatchSize  int          // Rows per insert (default: DefaultClickHouseBatchSize)
+	Retry      RetryPolicy  // Retries of failed inserts (default: DefaultClickHouseRetry when MaxAttempts is 0)
+	HTTPClient *http.Client // Client for inserts (default: http.DefaultClient)
+}
+
+// ClickHouseInsertError is returned when ClickHouse rejects an insert.
+type ClickHouseInsertError struct {
+	StatusCode int
+	Message    string // Start of the response body, ClickHouse's exception text
+}
+
+func (e *ClickHouseInsertError) Error() string {
+	return fmt.Sprintf("clickhouse insert failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// isRetryableClickHouseError reports whether an insert failure looks transient:
+// ClickHouse overload or an unavailable server, or a network error. Other 5xx responses
+// usually report a bad query or schema and are not retried.
+func isRetryableClickHouseError(err error) bool {
+	var insertErr *ClickHouseInsertError
+	if errors.As(err, &insertErr) {
+		switch insertErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return IsRetryableError(err)
+}
+
+var clickHouseIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// ClickHouseSink is a LogWriter that inserts entries into a ClickHouse table with the
+// ClickHouseTableSchema columns, through ClickHouse's HTTP interface in the JSONEachRow
+// format. Entries are buffered and inserted in batches, retrying failed inserts with
+// backoff; Close inserts the rest. Rows are numbered in the order they are written.
+// A ClickHouseSink is not safe for concurrent use.
+type ClickHouseSink struct {
+	ctx       context.Context
+	insertURL string
+	opts      ClickHouseOptions
+	pending   bytes.Buffer
+	buffered  int
+	rows      uint64
+	inserted  int
+	closed    bool
+}
+
+type clickHouseRow struct {
+	Org       string  `json:"org"`
+	Pipeline  string  `json:"pipeline"`
+	Build     string  `json:"build"`
+	Job       string  `json:"job"`
+	Row       uint64  `json:"row"`
+	Timestamp *string `json:"timestamp"`
+	Group     string  `json:"group_name"`
+	Content   string  `json:"content"`
+	IsGroup   bool    `json:"is_group"`
+}
+
+// clickHouseTimeFormat is the DateTime64(3) text format ClickHouse parses by default
+const clickHouseTimeFormat = "2006-01-02 15:04:05.000"
+
+var _ LogWriter = (*ClickHouseSink)(nil)
+
+// NewClickHouseSink creates a sink inserting into the ClickHouse at opts.URL. Inserts
+// use ctx, so canceling it stops the sink.
+func NewClickHouseSink(ctx context.Context, opts ClickHouseOptions) (*ClickHouseSink, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid ClickHouse URL %q: want http(s)://host[:port]", opts.URL)
+	}
+	if opts.Table == "" {
+		opts.Table = DefaultClickHouseTable
+	}
+	if !clickHouseIdentifier.MatchString(opts.Table) {
+		return nil, fmt.Errorf("invalid ClickHouse table %q: want table or database.table", opts.Table)
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultClickHouseBatchSize
+	}
+	if opts.Retry.MaxAttempts == 0 {
+		opts.Retry = DefaultClickHouseRetry
+	}
+	if opts.Retry.Retryable == nil {
+		opts.Retry.Retryable = isRetryableClickHouseError
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+
+	query := u.Query()
+	query.Set("query", fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", opts.Table))
+	u.RawQuery = query.Encode()
+
+	return &ClickHouseSink{
+		ctx:       ctx,
+		insertURL: u.String(),
+		opts:      opts,
+	}, nil
+}
+
+// WriteBatch buffers entries, inserting every full batch into ClickHouse.
+func (s *ClickHouseSink) WriteBatch(entries []*logparser.Entry) error {
+	if s.closed {
+		return errors.New("clickhouse sink is closed")
+	}
+
+	encoder := json.NewEncoder(&s.pending)
+	encoder.SetEscapeHTML(false)
+	for _, entry := range entries {
+		row := clickHouseRow{
+			Org:      s.opts.Org,
+			Pipeline: s.opts.Pipeline,
+			Build:    s.opts.Build,
+			Job:      s.opts.Job,
+			Row:      s.rows,
+			Group:    strings.TrimSpace(StripANSI(entry.Group)),
+			Content:  StripANSI(entry.Content),
+			IsGroup:  entry.IsGroup(),
+		}
+		if entry.HasTimestamp() {
+			timestamp := entry.Timestamp.UTC().Format(clickHouseTimeFormat)
+			row.Timestamp = &timestamp
+		}
+		if err := encoder.Encode(row); err != nil {
+			return fmt.Errorf("failed to encode clickhouse row: %w", err)
+		}
+		s.rows++
+		s.buffered++
+
+		if s.buffered >= s.opts.BatchSize {
+			if err := s.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Flush inserts the buffered rows.
+func (s *ClickHouseSink) Flush() error {
+	if s.buffered == 0 {
+		return nil
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = s.insert(s.pending.Bytes())
+		if err == nil || attempt >= s.opts.Retry.attempts() || !s.opts.Retry.retryable(err) {
+			break
+		}
+		if sleepErr := sleepContext(s.ctx, s.opts.Retry.backoff(attempt+1)); sleepErr != nil {
+			return sleepErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	s.inserted += s.buffered
+	s.buffered = 0
+	s.pending.Reset()
+	return nil
+}
+
+// Inserted returns the number of rows ClickHouse accepted.
+func (s *ClickHouseSink) Inserted() int {
+	return s.inserted
+}
+
+// Close inserts the buffered rows. Later writes fail.
+func (s *ClickHouseSink) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.Flush()
+}
+
+func (s *ClickHouseSink) insert(body []byte) error {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.insertURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create clickhouse insert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.opts.Username != "" {
+		req.SetBasicAuth(s.opts.Username, s.opts.Password)
+	}
+
+	resp, err := s.opts.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to insert into clickhouse: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &ClickHouseInsertError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
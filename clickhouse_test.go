@@ -0,0 +1,138 @@
+package buildkitelogs
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+type clickHouseTestServer struct {
+	mu       sync.Mutex
+	inserts  [][]clickHouseRow
+	queries  []string
+	users    []string
+	statuses []int // Statuses of the next responses, then 200
+}
+
+func (s *clickHouseTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, _, _ := r.BasicAuth()
+	s.users = append(s.users, user)
+	s.queries = append(s.queries, r.URL.Query().Get("query"))
+	if len(s.statuses) > 0 {
+		status := s.statuses[0]
+		s.statuses = s.statuses[1:]
+		http.Error(w, "Code: 202. DB::Exception: Too many simultaneous queries", status)
+		return
+	}
+	var rows []clickHouseRow
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		var row clickHouseRow
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rows = append(rows, row)
+	}
+	s.inserts = append(s.inserts, rows)
+}
+
+func TestClickHouseSink(t *testing.T) {
+	clickhouse := &clickHouseTestServer{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(clickhouse)
+	defer server.Close()
+
+	sink, err := NewClickHouseSink(t.Context(), ClickHouseOptions{
+		URL:       server.URL,
+		Table:     "ci.logs",
+		Username:  "writer",
+		Org:       "org",
+		Pipeline:  "pipeline",
+		Build:     "12",
+		Job:       "job",
+		BatchSize: 2,
+		Retry:     RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewClickHouseSink failed: %v", err)
+	}
+
+	log := "\x1b_bk;t=1745322209921\x07~~~ Setup\n\x1b_bk;t=1745322209922\x07\x1b[32minstalling\x1b[0m\nno timestamp\n"
+	rows, err := ExportSeq2ToLogWriter(logparser.New().All(strings.NewReader(log)), sink, nil)
+	if err != nil {
+		t.Fatalf("ExportSeq2ToLogWriter failed: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if rows != 3 || sink.Inserted() != 3 {
+		t.Errorf("rows = %d, inserted = %d, want 3 and 3", rows, sink.Inserted())
+	}
+
+	// The first insert was retried after the 503, and the last row was inserted on Close
+	if len(clickhouse.inserts) != 2 || len(clickhouse.queries) != 3 {
+		t.Fatalf("inserts = %d, requests = %d, want 2 and 3", len(clickhouse.inserts), len(clickhouse.queries))
+	}
+	if clickhouse.queries[0] != "INSERT INTO ci.logs FORMAT JSONEachRow" || clickhouse.users[0] != "writer" {
+		t.Errorf("query = %q as %q", clickhouse.queries[0], clickhouse.users[0])
+	}
+
+	first := clickhouse.inserts[0]
+	if len(first) != 2 || first[0].Org != "org" || first[0].Job != "job" || !first[0].IsGroup || first[0].Group != "~~~ Setup" {
+		t.Fatalf("first insert = %+v", first)
+	}
+	if first[0].Timestamp == nil || *first[0].Timestamp != "2025-04-22 11:43:29.921" {
+		t.Errorf("timestamp = %v, want 2025-04-22 11:43:29.921", first[0].Timestamp)
+	}
+	if first[1].Row != 1 || first[1].Content != "installing" {
+		t.Errorf("second row = %+v, want row 1 without ANSI codes", first[1])
+	}
+	if last := clickhouse.inserts[1][0]; last.Row != 2 || last.Timestamp != nil {
+		t.Errorf("untimestamped row = %+v, want row 2 with a null timestamp", last)
+	}
+
+	if err := sink.WriteBatch([]*logparser.Entry{{Content: "late"}}); err == nil {
+		t.Error("WriteBatch after Close succeeded")
+	}
+}
+
+func TestClickHouseSink_Rejected(t *testing.T) {
+	clickhouse := &clickHouseTestServer{statuses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(clickhouse)
+	defer server.Close()
+
+	sink, err := NewClickHouseSink(t.Context(), ClickHouseOptions{URL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClickHouseSink failed: %v", err)
+	}
+	if err := sink.WriteBatch([]*logparser.Entry{{Content: "hello"}}); err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+
+	var insertErr *ClickHouseInsertError
+	if err := sink.Close(); !errors.As(err, &insertErr) || insertErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Close error = %v, want a 500 ClickHouseInsertError", err)
+	}
+	if len(clickhouse.queries) != 1 || clickhouse.queries[0] != "INSERT INTO buildkite_logs FORMAT JSONEachRow" {
+		t.Errorf("rejected insert was sent as %q, want once into buildkite_logs", clickhouse.queries)
+	}
+}
+
+func TestNewClickHouseSink_InvalidOptions(t *testing.T) {
+	if _, err := NewClickHouseSink(t.Context(), ClickHouseOptions{URL: "localhost:8123"}); err == nil {
+		t.Error("NewClickHouseSink accepted a URL without a scheme")
+	}
+	if _, err := NewClickHouseSink(t.Context(), ClickHouseOptions{URL: "http://localhost:8123", Table: "logs; DROP TABLE logs"}); err == nil {
+		t.Error("NewClickHouseSink accepted an invalid table name")
+	}
+}
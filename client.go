@@ -1,11 +1,17 @@
 package buildkitelogs
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"iter"
+	"log/slog"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/buildkite/buildkite-logs/logparser"
@@ -23,6 +29,10 @@ var ErrJobLogUnavailable = errors.New("job log does not exist or is not accessib
 // DefaultMaxLogBytes is the default maximum log size (10MB).
 const DefaultMaxLogBytes int64 = 10 * 1024 * 1024
 
+// TruncatedLogMarker starts the content of the entry that ends a log truncated at the
+// maximum log size (see WithTruncateLargeLogs).
+const TruncatedLogMarker = "[bklog] log truncated"
+
 // ClientOption configures a Client.
 type ClientOption func(*Client)
 
@@ -35,6 +45,16 @@ func WithMaxLogBytes(n int64) ClientOption {
 	}
 }
 
+// WithTruncateLargeLogs sets whether logs exceeding the WithMaxLogBytes limit are cut
+// at the limit instead of failing with ErrLogTooLarge. The rest of the log is not
+// downloaded, and the cached log ends with an entry starting with TruncatedLogMarker and
+// records the limit in ProducerInfo.InputTruncatedAt. Default is off.
+func WithTruncateLargeLogs(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.truncateLargeLogs = enabled
+	}
+}
+
 // WithParserOptions sets parser options used when converting downloaded logs to Parquet.
 func WithParserOptions(options ...logparser.Option) ClientOption {
 	return func(c *Client) {
@@ -42,6 +62,15 @@ func WithParserOptions(options ...logparser.Option) ClientOption {
 	}
 }
 
+// WithLocalCacheDir sets the directory that cached logs from remote blob storage backends
+// are copied to for reading. Copies are keyed by blob key and content, so repeated reads
+// of an unchanged log reuse the same file. Default is DefaultLocalCacheDir().
+func WithLocalCacheDir(dir string) ClientOption {
+	return func(c *Client) {
+		c.localCacheDir = dir
+	}
+}
+
 // Hook function types for different stages of downloadAndCacheWithBlobStorage
 type AfterCacheCheckFunc func(ctx context.Context, result *CacheCheckResult)
 type AfterJobStatusFunc func(ctx context.Context, result *JobStatusResult)
@@ -70,6 +99,9 @@ type Hooks struct {
 	OnAfterLogParsing  []AfterLogParsingFunc
 	OnAfterBlobStorage []AfterBlobStorageFunc
 	OnAfterLocalCache  []AfterLocalCacheFunc
+	OnBeforeStage      []BeforeStageFunc
+	OnError            []OnErrorFunc
+	OnRetry            []OnRetryFunc
 }
 
 // BaseResult contains common fields for all hook results
@@ -79,6 +111,7 @@ type BaseResult struct {
 	Stage                     Stage
 	Success                   bool
 	Err                       error
+	RequestID                 string // Buildkite request ID of a failed API call, if known
 }
 
 // CacheCheckResult contains the result of checking blob storage cache
@@ -88,10 +121,12 @@ type CacheCheckResult struct {
 	Exists  bool
 }
 
-// JobStatusResult contains the result of fetching job status
+// JobStatusResult contains the result of one attempt at fetching job status
 type JobStatusResult struct {
 	BaseResult
 	JobStatus *JobStatus
+	Attempt   int  // 1 for the first attempt
+	WillRetry bool // The attempt failed and another will follow
 }
 
 // LogDownloadResult contains the result of downloading logs from API
@@ -148,15 +183,43 @@ func (h *Hooks) AddAfterLocalCache(hook AfterLocalCacheFunc) {
 	h.OnAfterLocalCache = append(h.OnAfterLocalCache, hook)
 }
 
-// Client provides a high-level convenience API for common buildkite-logs-parquet operations
+// Client provides a high-level convenience API for common buildkite-logs operations
 type Client struct {
-	api           BuildkiteAPI
-	storageURL    string
-	blobStorage   *BlobStorage
-	hooks         *Hooks
-	maxLogBytes   int64 // 0 means no limit
-	refreshGroup  singleflight.Group
-	parserOptions []logparser.Option
+	api               BuildkiteAPI
+	storageURL        string
+	storageMode       StorageMode // Cache location when storageURL is empty
+	blobStorage       *BlobStorage
+	hooks             *Hooks
+	maxLogBytes       int64 // 0 means no limit
+	truncateLargeLogs bool  // Cut logs at maxLogBytes instead of failing
+	refreshGroup      singleflight.Group
+	parserOptions     []logparser.Option
+	localCacheDir     string
+	idleTimeout       time.Duration
+	jobStatusRetry    RetryPolicy
+	annotations       *AnnotationStore
+	errorsView        bool
+	checksums         bool // Validate checksums when reading cached logs
+	objectTags        bool // Tag cached S3 objects with their metadata
+	consistencyRetry  RetryPolicy
+	followInterval    time.Duration      // Poll interval of FollowLogs (0 = DefaultFollowInterval)
+	cacheCompression  ParquetCompression // Codec of cached Parquet files ("" = DefaultParquetCompression)
+	refreshLock       time.Duration      // Staleness of refresh lock files, 0 = no lock, see WithRefreshLock
+	incremental       bool               // Download only new output of running jobs, see WithIncrementalRefresh
+	inMemory          bool               // Write nothing to the local filesystem, see WithInMemory
+	logger            *slog.Logger       // See WithLogger
+
+	closeCtx    context.Context // Canceled by Close to stop background work
+	closeCancel context.CancelFunc
+	cleanupDone chan struct{}
+
+	mu         sync.Mutex
+	closed     bool
+	active     sync.WaitGroup
+	activeOps  int
+	localFiles map[string]*localFile // The client's links to local copies, see leaseLocalFile
+	leaseID    string                // Names the client's links, unique like refresh lock tokens
+	statuses   map[string]*JobStatus // Terminal job statuses by blob key, see JobStatus
 }
 
 // NewClient creates a new Client using the provided go-buildkite client
@@ -167,35 +230,50 @@ func NewClient(ctx context.Context, client *buildkite.Client, storageURL string,
 
 // NewClientWithAPI creates a new Client using a custom BuildkiteAPI implementation
 func NewClientWithAPI(ctx context.Context, api BuildkiteAPI, storageURL string, opts ...ClientOption) (*Client, error) {
-	// Initialize blob storage once during client creation
-	blobStorage, err := NewBlobStorage(ctx, storageURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize blob storage: %w", err)
-	}
-
 	c := &Client{
-		api:         api,
-		storageURL:  storageURL,
-		blobStorage: blobStorage,
-		hooks:       &Hooks{},
-		maxLogBytes: DefaultMaxLogBytes,
+		api:              api,
+		storageURL:       storageURL,
+		hooks:            &Hooks{},
+		maxLogBytes:      DefaultMaxLogBytes,
+		localCacheDir:    DefaultLocalCacheDir(),
+		consistencyRetry: DefaultConsistencyRetry,
+		logger:           discardLogger,
+		leaseID:          newRefreshLockToken(),
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
+	if c.inMemory && storageURL == "" {
+		storageURL = MemoryStorageURL
+		c.storageURL = storageURL
+	}
+
+	// Initialize blob storage once during client creation
+	blobStorage, err := NewBlobStorage(ctx, storageURL, &BlobStorageOptions{StorageMode: c.storageMode, ObjectTags: c.objectTags})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize blob storage: %w", err)
+	}
+	c.blobStorage = blobStorage
+	c.annotations = NewAnnotationStore(blobStorage)
+
+	c.closeCtx, c.closeCancel = context.WithCancel(context.Background())
+	if c.idleTimeout > 0 {
+		c.startIdleCleanup()
+	}
 
 	return c, nil
 }
 
-func (c *Client) newDefaultClientParser() *logparser.Parser {
-	return logparser.New(append([]logparser.Option{
-		logparser.WithTruncateLongLines(true),
-	}, c.parserOptions...)...)
+func (c *Client) newDefaultClientParser(extra ...logparser.Option) *logparser.Parser {
+	options := append([]logparser.Option{logparser.WithTruncateLongLines(true)}, c.parserOptions...)
+	return logparser.New(append(options, extra...)...)
 }
 
 // NewReader downloads and caches job logs (if needed) and returns a ParquetReader for querying.
-// The returned reader owns the underlying temp file; callers must call Close() when done.
+// The reader reads the cached file in place (file:// storage) or a local copy shared by
+// readers of the same content, so Close() does not remove it. Readers of clients created
+// WithInMemory read the cached blob using ctx.
 //
 // Parameters:
 //   - org: Buildkite organization slug
@@ -205,12 +283,12 @@ func (c *Client) newDefaultClientParser() *logparser.Parser {
 //   - ttl: Time-to-live for cache (use 0 for default 30s)
 //   - forceRefresh: If true, forces re-download even if cache exists
 func (c *Client) NewReader(ctx context.Context, org, pipeline, build, job string, ttl time.Duration, forceRefresh bool) (*ParquetReader, error) {
-	filePath, err := c.downloadAndCache(ctx, c.api, org, pipeline, build, job, ttl, forceRefresh)
+	result, err := c.downloadAndCacheResult(ctx, c.api, org, pipeline, build, job, ttl, forceRefresh)
 	if err != nil {
 		return nil, err
 	}
 
-	return newParquetReaderOwned(filePath), nil
+	return c.newAnnotatedReader(ctx, result, JobLocation{Org: org, Pipeline: pipeline, Build: build, Job: job})
 }
 
 // NewReaderByJobID downloads and caches job logs using only an organization slug and job UUID.
@@ -232,20 +310,147 @@ func (c *Client) NewReaderByJobID(ctx context.Context, org, job string, ttl time
 		location: location,
 	}
 
-	filePath, err := c.downloadAndCache(ctx, adapter, location.Org, location.Pipeline, location.Build, location.Job, ttl, forceRefresh)
+	result, err := c.downloadAndCacheResult(ctx, adapter, location.Org, location.Pipeline, location.Build, location.Job, ttl, forceRefresh)
 	if err != nil {
 		return nil, err
 	}
 
-	return newParquetReaderOwned(filePath), nil
+	return c.newAnnotatedReader(ctx, result, location)
+}
+
+// NewReaderByStep downloads and caches the log of the job in a build whose step key or
+// label matches step, instead of requiring a job UUID. See ResolveJobByStep for the
+// matching rules; ambiguous or missing steps return ErrAmbiguousStep or ErrStepNotFound.
+func (c *Client) NewReaderByStep(ctx context.Context, org, pipeline, build, step string, ttl time.Duration, forceRefresh bool) (*ParquetReader, error) {
+	location, err := c.ResolveStep(ctx, org, pipeline, build, step)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.NewReader(ctx, location.Org, location.Pipeline, location.Build, location.Job, ttl, forceRefresh)
+}
+
+// Annotations returns the store for triage annotations on the client's cached logs.
+// Readers returned by the client include the job's annotations.
+func (c *Client) Annotations() *AnnotationStore {
+	return c.annotations
+}
+
+// newAnnotatedReader opens a cached log with the job's stored annotations attached.
+func (c *Client) newAnnotatedReader(ctx context.Context, result *CacheResult, location JobLocation) (*ParquetReader, error) {
+	annotations, err := c.annotations.List(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := c.readerForResult(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	return reader.WithAnnotations(annotations), nil
+}
+
+// ResolveStep resolves a step key or label within a build to a job location.
+func (c *Client) ResolveStep(ctx context.Context, org, pipeline, build, step string) (JobLocation, error) {
+	provider, ok := c.api.(BuildProvider)
+	if !ok {
+		return JobLocation{}, fmt.Errorf("API client does not support listing build jobs")
+	}
+
+	return ResolveJobByStep(ctx, provider, org, pipeline, build, step)
+}
+
+// ResolveBuild resolves LatestBuild to the number of the pipeline's most recent build on
+// branch (or any branch, if empty). Other build identifiers are returned unchanged.
+func (c *Client) ResolveBuild(ctx context.Context, org, pipeline, build, branch string) (string, error) {
+	if build != LatestBuild {
+		return ResolveBuild(ctx, nil, org, pipeline, build, branch)
+	}
+
+	provider, ok := c.api.(LatestBuildProvider)
+	if !ok {
+		return "", fmt.Errorf("API client does not support listing builds")
+	}
+
+	return ResolveBuild(ctx, provider, org, pipeline, build, branch)
+}
+
+// Invalidate removes the cached log for a job from blob storage, along with its errors
+// view and local copies, so the next read downloads it again. It reports whether a cache
+// entry existed.
+func (c *Client) Invalidate(ctx context.Context, location JobLocation) (bool, error) {
+	if err := ValidateAPIParams(location.Org, location.Pipeline, location.Build, location.Job); err != nil {
+		return false, err
+	}
+
+	done, err := c.begin()
+	if err != nil {
+		return false, err
+	}
+	defer done()
+
+	blobKey := GenerateBlobKey(location.Org, location.Pipeline, location.Build, location.Job)
+
+	// Don't let later readers join a refresh that started before the invalidation.
+	c.refreshGroup.Forget(blobKey)
+
+	if err := removeLocalCacheFiles(c.localCacheDir, blobKey); err != nil {
+		return false, fmt.Errorf("failed to remove local cache files: %w", err)
+	}
+	viewKey := ErrorsViewKey(location.Org, location.Pipeline, location.Build, location.Job)
+	if err := removeLocalCacheFiles(c.localCacheDir, viewKey); err != nil {
+		return false, fmt.Errorf("failed to remove local cache files: %w", err)
+	}
+	if err := c.releaseLocalFiles(blobKey); err != nil {
+		return false, fmt.Errorf("failed to remove local cache files: %w", err)
+	}
+
+	exists, err := c.blobStorage.Exists(ctx, blobKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to check blob existence: %w", err)
+	}
+	if !exists {
+		return false, nil
+	}
+
+	if err := c.blobStorage.Delete(ctx, blobKey); err != nil {
+		return false, fmt.Errorf("failed to delete cached log: %w", err)
+	}
+	if err := c.blobStorage.deleteSegments(ctx, blobKey, ""); err != nil {
+		return true, err
+	}
+	viewExists, err := c.blobStorage.Exists(ctx, viewKey)
+	if err != nil {
+		return true, fmt.Errorf("failed to check errors view existence: %w", err)
+	}
+	if viewExists {
+		if err := c.blobStorage.Delete(ctx, viewKey); err != nil {
+			return true, fmt.Errorf("failed to delete errors view: %w", err)
+		}
+	}
+	return true, nil
 }
 
 // downloadAndCache downloads and caches job logs as Parquet format, returning the local file path.
-// Callers are responsible for removing the returned temp file.
+// The path is shared with other readers and must not be removed by callers.
 func (c *Client) downloadAndCache(ctx context.Context, api BuildkiteAPI, org, pipeline, build, job string, ttl time.Duration, forceRefresh bool) (string, error) {
-	if err := ValidateAPIParams(org, pipeline, build, job); err != nil {
+	result, err := c.downloadAndCacheResult(ctx, api, org, pipeline, build, job, ttl, forceRefresh)
+	if err != nil {
 		return "", err
 	}
+	return result.Path, nil
+}
+
+// downloadAndCacheResult is downloadAndCache, describing how the log was served.
+func (c *Client) downloadAndCacheResult(ctx context.Context, api BuildkiteAPI, org, pipeline, build, job string, ttl time.Duration, forceRefresh bool) (*CacheResult, error) {
+	if err := ValidateAPIParams(org, pipeline, build, job); err != nil {
+		return nil, err
+	}
+
+	done, err := c.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 
 	return c.downloadAndCacheWithBlobStorage(ctx, api, org, pipeline, build, job, ttl, forceRefresh)
 }
@@ -255,94 +460,199 @@ func (c *Client) Hooks() *Hooks {
 	return c.hooks
 }
 
+// checkCache reports whether the log at blobKey is cached and how long checking took,
+// running the hooks of StageCacheCheck.
+func (c *Client) checkCache(ctx context.Context, org, pipeline, build, job, blobKey string) (bool, time.Duration, error) {
+	checkCtx, cancel, err := c.beforeStage(ctx, org, pipeline, build, job, StageCacheCheck, blobKey)
+	if err != nil {
+		return false, 0, err
+	}
+	defer cancel()
+
+	cacheCheckStart := time.Now()
+	exists, err := c.blobStorage.Exists(checkCtx, blobKey)
+	cacheCheckDuration := time.Since(cacheCheckStart)
+	c.fireCacheCheckHook(ctx, org, pipeline, build, job, cacheCheckDuration, blobKey, exists, err)
+	return exists, cacheCheckDuration, err
+}
+
 // downloadAndCacheWithBlobStorage downloads logs using the client's blob storage backend
-func (c *Client) downloadAndCacheWithBlobStorage(ctx context.Context, api BuildkiteAPI, org, pipeline, build, job string, ttl time.Duration, forceRefresh bool) (string, error) {
+func (c *Client) downloadAndCacheWithBlobStorage(ctx context.Context, api BuildkiteAPI, org, pipeline, build, job string, ttl time.Duration, forceRefresh bool) (*CacheResult, error) {
+	start := time.Now()
 	if ttl == 0 {
 		ttl = 30 * time.Second // Default TTL
 	}
 
 	// Authorize every caller before it can use or join shared cache work.
 	if err := validateJobLogAccess(ctx, api, org, pipeline, build, job); err != nil {
-		return "", fmt.Errorf("failed to validate job log access: %w", err)
+		return nil, fmt.Errorf("failed to validate job log access: %w", err)
 	}
 
 	blobKey := GenerateBlobKey(org, pipeline, build, job)
+	result := &CacheResult{BlobKey: blobKey}
 
-	cacheCheckStart := time.Now()
-	exists, err := c.blobStorage.Exists(ctx, blobKey)
-	cacheCheckDuration := time.Since(cacheCheckStart)
-	c.fireCacheCheckHook(ctx, org, pipeline, build, job, cacheCheckDuration, blobKey, exists, err)
+	exists, cacheCheckDuration, err := c.checkCache(ctx, org, pipeline, build, job, blobKey)
+	result.Durations.CacheCheck = cacheCheckDuration
 	if err != nil {
-		return "", fmt.Errorf("failed to check blob existence: %w", err)
+		return nil, fmt.Errorf("failed to check blob existence: %w", err)
 	}
 
 	var jobStatus *JobStatus
 	if exists && !forceRefresh {
 		var usable bool
-		jobStatus, usable, err = c.checkCachedJobLog(ctx, api, org, pipeline, build, job, blobKey, ttl, nil)
+		var metadata *BlobMetadata
+		jobStatus, metadata, usable, err = c.checkCachedJobLog(ctx, api, org, pipeline, build, job, blobKey, ttl, nil)
 		if err != nil {
-			return "", fmt.Errorf("failed to check cached job log: %w", err)
+			return nil, fmt.Errorf("failed to check cached job log: %w", err)
 		}
 		if usable {
-			return c.createLocalCacheFileWithHooks(ctx, org, pipeline, build, job, blobKey)
+			result.Outcome = CacheHit
+			result.JobStatus = jobStatus
+			result.Sizes = cacheSizesFromMetadata(metadata)
+			hit, err := c.materializeCacheResult(ctx, org, pipeline, build, job, result, start)
+			if !errors.Is(err, ErrCorruptCache) {
+				return hit, err
+			}
+			// The cached blob is damaged: remove it, so the log is downloaded again in full
+			c.logger.WarnContext(ctx, "cached job log is corrupt, downloading it again", jobAttrs(org, pipeline, build, job), "blob_key", blobKey, "error", err)
+			if err := c.blobStorage.Delete(ctx, blobKey); err != nil && !IsBlobNotFound(err) {
+				return nil, fmt.Errorf("failed to delete corrupt cached log: %w", err)
+			}
+			forceRefresh = true
 		}
 	}
 
-	// Decouple shared refresh work from the single caller that wins the
-	// singleflight race. Waiters can still abandon their own wait below.
-	refreshCtx := context.WithoutCancel(ctx)
 	inflightKey := blobKey
+	ran := false
 	ch := c.refreshGroup.DoChan(inflightKey, func() (any, error) {
-		if !forceRefresh {
+		ran = true
+
+		done, err := c.begin()
+		if err != nil {
+			return nil, err
+		}
+		defer done()
+
+		// Decouple shared refresh work from the single caller that wins the
+		// singleflight race. Waiters can still abandon their own wait below, and
+		// Close cancels the refresh.
+		refreshCtx, cancel := c.detach(ctx)
+		defer cancel()
+
+		// recheck returns the cached log if a refresh that finished meanwhile made it usable
+		recheck := func() (*CacheResult, error) {
 			exists, err := c.blobStorage.Exists(refreshCtx, blobKey)
 			if err != nil {
 				return nil, fmt.Errorf("failed to recheck blob existence: %w", err)
 			}
-			if exists {
-				var usable bool
-				jobStatus, usable, err = c.checkCachedJobLog(refreshCtx, api, org, pipeline, build, job, blobKey, ttl, jobStatus)
-				if err != nil {
-					return nil, fmt.Errorf("failed to recheck cached job log: %w", err)
-				}
-				if usable {
-					return nil, nil
-				}
+			if !exists {
+				return nil, nil
+			}
+			var usable bool
+			var metadata *BlobMetadata
+			jobStatus, metadata, usable, err = c.checkCachedJobLog(refreshCtx, api, org, pipeline, build, job, blobKey, ttl, jobStatus)
+			if err != nil {
+				return nil, fmt.Errorf("failed to recheck cached job log: %w", err)
+			}
+			if !usable {
+				return nil, nil
+			}
+			return &CacheResult{Outcome: CacheHit, JobStatus: jobStatus, Sizes: cacheSizesFromMetadata(metadata)}, nil
+		}
+		if !forceRefresh {
+			if hit, err := recheck(); hit != nil || err != nil {
+				return hit, err
+			}
+		}
+
+		release, waited, err := c.acquireRefreshLock(refreshCtx, blobKey)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		if waited && !forceRefresh {
+			// The process holding the lock has most likely cached the log
+			if hit, err := recheck(); hit != nil || err != nil {
+				return hit, err
 			}
 		}
-		return nil, c.refreshBlobCache(refreshCtx, api, org, pipeline, build, job, ttl, blobKey, jobStatus)
+
+		refreshed := &CacheResult{Outcome: CacheRefreshed}
+		if err := c.refreshBlobCache(refreshCtx, api, org, pipeline, build, job, ttl, blobKey, jobStatus, refreshed); err != nil {
+			return nil, err
+		}
+		return refreshed, nil
 	})
 
 	select {
 	case <-ctx.Done():
-		return "", ctx.Err()
-	case result := <-ch:
-		if result.Err != nil {
-			return "", result.Err
+		return nil, ctx.Err()
+	case <-c.closeCtx.Done():
+		return nil, ErrClientClosed
+	case shared := <-ch:
+		if shared.Err != nil {
+			return nil, shared.Err
 		}
+		// The refresh may have been run by a concurrent caller, whose work this call joined
+		refresh := shared.Val.(*CacheResult)
+		result.Outcome = refresh.Outcome
+		if !ran && (refresh.Outcome == CacheRefreshed || refresh.Outcome == CacheExtended) {
+			result.Outcome = CacheJoined
+		}
+		result.JobStatus = refresh.JobStatus
+		result.Sizes = refresh.Sizes
+		result.Durations.Download = refresh.Durations.Download
+		result.Durations.Parse = refresh.Durations.Parse
+		result.Durations.Store = refresh.Durations.Store
 	}
 
-	return c.createLocalCacheFileWithHooks(ctx, org, pipeline, build, job, blobKey)
+	return c.materializeCacheResult(ctx, org, pipeline, build, job, result, start)
 }
 
-func (c *Client) checkCachedJobLog(ctx context.Context, api BuildkiteAPI, org, pipeline, build, job, blobKey string, ttl time.Duration, status *JobStatus) (*JobStatus, bool, error) {
-	metadata, err := c.blobStorage.ReadWithMetadata(ctx, blobKey)
+// materializeCacheResult creates the local file of a cached log and completes result.
+// Clients created WithInMemory read the blob instead, so no file is created.
+func (c *Client) materializeCacheResult(ctx context.Context, org, pipeline, build, job string, result *CacheResult, start time.Time) (*CacheResult, error) {
+	if !c.inMemory {
+		materializeStart := time.Now()
+		path, err := c.createLocalCacheFileWithHooks(ctx, org, pipeline, build, job, result.BlobKey)
+		if err != nil {
+			return nil, err
+		}
+		result.Path = path
+		result.Durations.Materialize = time.Since(materializeStart)
+	}
+	result.Durations.Total = time.Since(start)
+	c.logger.DebugContext(ctx, "served job log", jobAttrs(org, pipeline, build, job),
+		"outcome", result.Outcome, "blob_key", result.BlobKey, "rows", result.Sizes.Rows, "duration", result.Durations.Total)
+	return result, nil
+}
+
+func (c *Client) checkCachedJobLog(ctx context.Context, api BuildkiteAPI, org, pipeline, build, job, blobKey string, ttl time.Duration, status *JobStatus) (*JobStatus, *BlobMetadata, bool, error) {
+	metadata, err := readExistingBlob(c.stageContext(ctx, StageCacheCheck), c.consistencyRetry, blobKey, func() (*BlobMetadata, error) {
+		return c.blobStorage.cachedLogMetadata(ctx, blobKey)
+	})
+	var consistencyErr *BlobConsistencyError
+	if errors.As(err, &consistencyErr) {
+		// The blob went away after the existence check, so download it again
+		return status, nil, false, nil
+	}
 	if err != nil || metadata == nil {
-		return status, false, err
+		return status, nil, false, err
 	}
 	if metadata.IsTerminal {
-		return status, true, nil
+		return status, metadata, true, nil
 	}
 	if time.Since(metadata.CachedAt) > ttl {
-		return status, false, nil
+		return status, metadata, false, nil
 	}
 
 	if status == nil {
 		status, err = c.getJobStatus(ctx, api, org, pipeline, build, job)
 		if err != nil {
-			return nil, false, err
+			return nil, nil, false, err
 		}
 	}
-	return status, !status.IsTerminal, nil
+	return status, metadata, !status.IsTerminal, nil
 }
 
 func validateJobLogAccess(ctx context.Context, api BuildkiteAPI, org, pipeline, build, job string) error {
@@ -357,17 +667,47 @@ func validateJobLogAccess(ctx context.Context, api BuildkiteAPI, org, pipeline,
 }
 
 func (c *Client) getJobStatus(ctx context.Context, api BuildkiteAPI, org, pipeline, build, job string) (*JobStatus, error) {
-	jobStatusStart := time.Now()
-	jobStatus, err := api.GetJobStatus(ctx, org, pipeline, build, job)
-	if err == nil && jobStatus == nil {
-		err = errors.New("API returned nil job status")
+	policy := c.jobStatusRetry
+	if retrying, ok := api.(retryingAPI); ok && retrying.retriesCalls() {
+		// The API client retries the lookup itself
+		policy = RetryPolicy{}
+	}
+	statusCtx, cancel, err := c.beforeStage(ctx, org, pipeline, build, job, StageJobStatus, "")
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		jobStatusStart := time.Now()
+		jobStatus, err := api.GetJobStatus(statusCtx, org, pipeline, build, job)
+		if err == nil && jobStatus == nil {
+			err = errors.New("API returned nil job status")
+		}
+		jobStatusDuration := time.Since(jobStatusStart)
+
+		willRetry := err != nil && attempt < policy.attempts() && policy.retryable(err)
+		var wait time.Duration
+		if willRetry {
+			wait, willRetry = policy.delay(attempt+1, err)
+		}
+		c.fireJobStatusHook(ctx, org, pipeline, build, job, jobStatusDuration, jobStatus, attempt, willRetry, err)
+		if !willRetry {
+			return jobStatus, err
+		}
+		c.logger.DebugContext(ctx, "retrying job status lookup", jobAttrs(org, pipeline, build, job), "attempt", attempt, "wait", wait, "error", err)
+
+		if sleepErr := sleepContext(statusCtx, wait); sleepErr != nil {
+			return nil, err
+		}
 	}
-	jobStatusDuration := time.Since(jobStatusStart)
-	c.fireJobStatusHook(ctx, org, pipeline, build, job, jobStatusDuration, jobStatus, err)
-	return jobStatus, err
 }
 
-func (c *Client) refreshBlobCache(ctx context.Context, api BuildkiteAPI, org, pipeline, build, job string, ttl time.Duration, blobKey string, jobStatus *JobStatus) error {
+// refreshBlobCache downloads, parses and stores a job log, filling in the job status,
+// sizes and durations of result. With WithIncrementalRefresh, only the output past the
+// cached part of a running job's log is downloaded and parsed, and stored as a segment
+// of the cached log while the job runs.
+func (c *Client) refreshBlobCache(ctx context.Context, api BuildkiteAPI, org, pipeline, build, job string, ttl time.Duration, blobKey string, jobStatus *JobStatus, result *CacheResult) error {
 	if jobStatus == nil {
 		var err error
 		jobStatus, err = c.getJobStatus(ctx, api, org, pipeline, build, job)
@@ -376,8 +716,35 @@ func (c *Client) refreshBlobCache(ctx context.Context, api BuildkiteAPI, org, pi
 		}
 	}
 
+	var base *appendBase
+	rangeAPI, canRange := api.(RangeLogProvider)
+	if c.incremental && canRange {
+		base = c.loadAppendBase(ctx, blobKey)
+	}
+	var offset int64 // Bytes of the log already cached and kept
+	var keptRows int // Rows of the log already cached and kept, when storing a segment
+	segment := false
+	if base != nil {
+		offset = base.offset
+		if segment = base.canAppendSegment(jobStatus); segment {
+			keptRows = base.rows
+		}
+	}
+
+	// The log is parsed as it downloads, so the download's context covers parsing too
+	downloadCtx, cancelDownload, err := c.beforeStage(ctx, org, pipeline, build, job, StageLogDownload, blobKey)
+	if err != nil {
+		return err
+	}
+	defer cancelDownload()
+
 	logDownloadStart := time.Now()
-	logReader, err := api.GetJobLog(ctx, org, pipeline, build, job)
+	var logReader io.ReadCloser
+	if base != nil {
+		logReader, err = rangeAPI.GetJobLogFrom(downloadCtx, org, pipeline, build, job, offset)
+	} else {
+		logReader, err = api.GetJobLog(downloadCtx, org, pipeline, build, job)
+	}
 	logDownloadDuration := time.Since(logDownloadStart)
 	if err != nil {
 		c.fireLogDownloadHook(ctx, org, pipeline, build, job, logDownloadDuration, 0, err)
@@ -397,39 +764,53 @@ func (c *Client) refreshBlobCache(ctx context.Context, api BuildkiteAPI, org, pi
 
 	countingReader := &countingReadCloser{rc: logReader}
 	logReader = countingReader
-	if c.maxLogBytes > 0 {
-		if logSize > c.maxLogBytes {
-			err := fmt.Errorf("%w: %d bytes exceeds limit of %d bytes", ErrLogTooLarge, logSize, c.maxLogBytes)
+	var writerOpts []ParquetWriterOption
+	var truncatingReader *truncatingReadCloser
+	if c.maxLogBytes > 0 && c.truncateLargeLogs {
+		truncatingReader = &truncatingReadCloser{rc: logReader, start: offset, limit: c.maxLogBytes}
+		logReader = truncatingReader
+		writerOpts = append(writerOpts, withInputTruncation(truncatingReader.truncatedAt))
+	} else if c.maxLogBytes > 0 {
+		if offset+logSize > c.maxLogBytes {
+			err := fmt.Errorf("%w: %d bytes exceeds limit of %d bytes", ErrLogTooLarge, offset+logSize, c.maxLogBytes)
 			c.fireLogDownloadHook(ctx, org, pipeline, build, job, logDownloadDuration, logSize, err)
 			return err
 		}
 		limitedReader := &limitedReadCloser{
 			rc:    logReader,
-			r:     io.LimitReader(logReader, c.maxLogBytes+1),
+			r:     io.LimitReader(logReader, c.maxLogBytes-offset+1),
+			start: offset,
 			limit: c.maxLogBytes,
 		}
 		logReader = limitedReader
 	}
 
 	logParsingStart := time.Now()
-	tempFile, err := os.CreateTemp("", "bklog-*.parquet")
+	spool, err := c.newParquetSpool("bklog-*.parquet")
 	if err != nil {
 		logParsingDuration := time.Since(logParsingStart)
 		c.fireLogParsingHook(ctx, org, pipeline, build, job, logParsingDuration, 0, 0, err)
-		return fmt.Errorf("failed to create temp file: %w", err)
+		return err
 	}
-	tempPath := tempFile.Name()
-	if err := tempFile.Close(); err != nil {
-		logParsingDuration := time.Since(logParsingStart)
-		c.fireLogParsingHook(ctx, org, pipeline, build, job, logParsingDuration, 0, 0, err)
-		return fmt.Errorf("failed to close temp file before export: %w", err)
+	defer spool.remove()
+
+	var parser *logparser.Parser
+	var entries iter.Seq2[*logparser.Entry, error]
+	if base != nil {
+		parser = c.newDefaultClientParser(logparser.WithInitialGroup(base.group), logparser.WithInitialParentGroup(base.parent))
+		entries = parser.All(logReader)
+		if !segment {
+			entries = base.entries(downloadCtx, c, entries)
+		}
+	} else {
+		parser = c.newDefaultClientParser()
+		entries = parser.All(logReader)
 	}
-	defer func() {
-		_ = os.Remove(tempPath)
-	}()
-
-	parser := c.newDefaultClientParser()
-	logEntries, err := ExportSeq2ToParquetWithFilterAndStats(parser.All(logReader), tempPath, nil)
+	writerOpts = append(writerOpts, WithSourceParser(parser))
+	if c.cacheCompression != "" {
+		writerOpts = append(writerOpts, WithCompression(c.cacheCompression))
+	}
+	logEntries, err := spool.export(entries, nil, writerOpts...)
 	logParsingDuration := time.Since(logParsingStart)
 	if err != nil {
 		if isLogDownloadError(err) {
@@ -442,18 +823,50 @@ func (c *Client) refreshBlobCache(ctx context.Context, api BuildkiteAPI, org, pi
 		c.fireLogParsingHook(ctx, org, pipeline, build, job, logParsingDuration, 0, logEntries, err)
 		return fmt.Errorf("failed to export logs to parquet: %w", err)
 	}
-	fileInfo, err := os.Stat(tempPath) //nolint:gosec // path from os.CreateTemp, not user input
+	parquetSize, err := spool.size()
 	if err != nil {
 		c.fireLogParsingHook(ctx, org, pipeline, build, job, logParsingDuration, 0, logEntries, err)
 		return fmt.Errorf("failed to measure parquet data: %w", err)
 	}
-	parquetSize := fileInfo.Size()
+	contentSHA256, err := spool.sha256()
+	if err != nil {
+		c.fireLogParsingHook(ctx, org, pipeline, build, job, logParsingDuration, 0, logEntries, err)
+		return fmt.Errorf("failed to hash parquet data: %w", err)
+	}
 	if logSize == 0 {
 		logSize = countingReader.consumed
 	}
 	c.fireLogDownloadHook(ctx, org, pipeline, build, job, time.Since(logDownloadStart), logSize, nil)
 	c.fireLogParsingHook(ctx, org, pipeline, build, job, logParsingDuration, parquetSize, logEntries, nil)
 
+	// A later incremental refresh continues after the last complete line; the partial
+	// line after it, if any, is parsed again then
+	var logOffset int64
+	var logOffsetRows int
+	if truncatingReader == nil || truncatingReader.truncatedAt() == 0 {
+		logOffset = offset + countingReader.lineEnd
+		logOffsetRows = keptRows + logEntries
+		if countingReader.lineEnd < countingReader.consumed {
+			logOffsetRows--
+		}
+	}
+
+	// Store a segment of the cached log, or the whole log under a new segment generation
+	key := blobKey
+	var generation string
+	if segment {
+		generation = base.log.metadata.SegmentGeneration
+		key = segmentKey(blobKey, generation, base.log.segments()+1)
+	} else if c.incremental && !jobStatus.IsTerminal {
+		generation = newSegmentGeneration()
+	}
+
+	storeCtx, cancelStore, err := c.beforeStage(ctx, org, pipeline, build, job, StageBlobStorage, key)
+	if err != nil {
+		return err
+	}
+	defer cancelStore()
+
 	blobStorageStart := time.Now()
 	metadata := &BlobMetadata{
 		JobID:        job,
@@ -464,12 +877,24 @@ func (c *Client) refreshBlobCache(ctx context.Context, api BuildkiteAPI, org, pi
 		Organization: org,
 		Pipeline:     pipeline,
 		Build:        build,
-		LogSize:      logSize,
+		LogSize:      offset + logSize,
 		ParquetSize:  parquetSize,
-		RowCount:     logEntries,
+		RowCount:     keptRows + logEntries,
 		ProcessedAt:  time.Now(),
+
+		RetryOfJobID:   jobStatus.RetryOfJobID,
+		RetriedInJobID: jobStatus.RetriedInJobID,
+
+		LogOffset:     logOffset,
+		LogOffsetRows: logOffsetRows,
+
+		ContentSHA256:     contentSHA256,
+		SegmentGeneration: generation,
 	}
-	parquetReader, err := os.Open(tempPath) //nolint:gosec // path from os.CreateTemp, not user input
+	if segment {
+		metadata.ParquetSize += base.log.metadata.ParquetSize
+	}
+	parquetReader, err := spool.open()
 	if err != nil {
 		blobStorageDuration := time.Since(blobStorageStart)
 		c.fireBlobStorageHook(ctx, org, pipeline, build, job, blobStorageDuration, blobKey, parquetSize, jobStatus.IsTerminal, ttl, err)
@@ -477,19 +902,50 @@ func (c *Client) refreshBlobCache(ctx context.Context, api BuildkiteAPI, org, pi
 	}
 	defer parquetReader.Close()
 
-	err = c.blobStorage.WriteWithMetadataFrom(ctx, blobKey, parquetReader, metadata)
+	err = c.blobStorage.WriteWithMetadataFrom(storeCtx, key, parquetReader, metadata)
+	if err == nil && !segment && c.incremental {
+		// Segments of the log this one replaces are no longer read
+		err = c.blobStorage.deleteSegments(storeCtx, blobKey, generation)
+	}
 	blobStorageDuration := time.Since(blobStorageStart)
-	c.fireBlobStorageHook(ctx, org, pipeline, build, job, blobStorageDuration, blobKey, parquetSize, jobStatus.IsTerminal, ttl, err)
+	c.fireBlobStorageHook(ctx, org, pipeline, build, job, blobStorageDuration, key, parquetSize, jobStatus.IsTerminal, ttl, err)
 	if err != nil {
 		return fmt.Errorf("failed to write to blob storage: %w", err)
 	}
 
+	if base != nil {
+		result.Outcome = CacheExtended
+	}
+	result.JobStatus = jobStatus
+	result.Sizes = cacheSizesFromMetadata(metadata)
+	result.Durations.Download = logDownloadDuration
+	result.Durations.Parse = logParsingDuration
+	result.Durations.Store = blobStorageDuration
+
+	if c.errorsView {
+		source := spool.reader()
+		if segment {
+			// The segment holds only the new entries; the view is built from the whole log
+			if source, err = c.openCachedLog(ctx, blobKey); err != nil {
+				return fmt.Errorf("failed to merge cached log for errors view: %w", err)
+			}
+		}
+		return c.writeErrorsView(ctx, source, ErrorsViewKey(org, pipeline, build, job), metadata)
+	}
 	return nil
 }
 
 func (c *Client) createLocalCacheFileWithHooks(ctx context.Context, org, pipeline, build, job, blobKey string) (string, error) {
+	cacheCtx, cancel, err := c.beforeStage(ctx, org, pipeline, build, job, StageLocalCache, blobKey)
+	if err != nil {
+		return "", err
+	}
+	defer cancel()
+
 	localCacheStart := time.Now()
-	localPath, err := createLocalCacheFile(ctx, c.blobStorage, blobKey)
+	localPath, err := readExistingBlob(cacheCtx, c.consistencyRetry, blobKey, func() (string, error) {
+		return c.leasedLocalCacheFile(cacheCtx, blobKey)
+	})
 	localCacheDuration := time.Since(localCacheStart)
 
 	var fileSize int64
@@ -507,92 +963,124 @@ func (c *Client) createLocalCacheFileWithHooks(ctx context.Context, org, pipelin
 	return localPath, nil
 }
 
+// leasedLocalCacheFile returns the client's link to a local copy of blobKey (see
+// leaseLocalFile), copying the blob again if another client removed the copy before it
+// could be linked.
+func (c *Client) leasedLocalCacheFile(ctx context.Context, blobKey string) (string, error) {
+	for attempt := 1; ; attempt++ {
+		path, err := createLocalCacheFile(ctx, c.blobStorage, blobKey, c.localCacheDir)
+		if err != nil {
+			return "", err
+		}
+		link, err := c.leaseLocalFile(path)
+		if errors.Is(err, fs.ErrNotExist) && attempt < 3 {
+			continue
+		}
+		return link, err
+	}
+}
+
 func (c *Client) fireCacheCheckHook(ctx context.Context, org, pipeline, build, job string, duration time.Duration, blobKey string, exists bool, err error) {
 	for _, hook := range c.hooks.OnAfterCacheCheck {
 		hook(ctx, &CacheCheckResult{
 			BaseResult: BaseResult{
-				Org:      org,
-				Pipeline: pipeline,
-				Build:    build,
-				Job:      job,
-				Duration: duration,
-				Stage:    StageCacheCheck,
-				Success:  err == nil,
-				Err:      err,
+				Org:       org,
+				Pipeline:  pipeline,
+				Build:     build,
+				Job:       job,
+				Duration:  duration,
+				Stage:     StageCacheCheck,
+				Success:   err == nil,
+				Err:       err,
+				RequestID: RequestIDFromError(err),
 			},
 			BlobKey: blobKey,
 			Exists:  exists,
 		})
 	}
+	c.fireErrorHook(ctx, StageCacheCheck, err)
 }
 
-func (c *Client) fireJobStatusHook(ctx context.Context, org, pipeline, build, job string, duration time.Duration, jobStatus *JobStatus, err error) {
+func (c *Client) fireJobStatusHook(ctx context.Context, org, pipeline, build, job string, duration time.Duration, jobStatus *JobStatus, attempt int, willRetry bool, err error) {
 	for _, hook := range c.hooks.OnAfterJobStatus {
 		hook(ctx, &JobStatusResult{
 			BaseResult: BaseResult{
-				Org:      org,
-				Pipeline: pipeline,
-				Build:    build,
-				Job:      job,
-				Duration: duration,
-				Stage:    StageJobStatus,
-				Success:  err == nil,
-				Err:      err,
+				Org:       org,
+				Pipeline:  pipeline,
+				Build:     build,
+				Job:       job,
+				Duration:  duration,
+				Stage:     StageJobStatus,
+				Success:   err == nil,
+				Err:       err,
+				RequestID: RequestIDFromError(err),
 			},
 			JobStatus: jobStatus,
+			Attempt:   attempt,
+			WillRetry: willRetry,
 		})
 	}
+	if willRetry {
+		c.fireRetryHook(ctx, StageJobStatus, attempt, err)
+	} else {
+		c.fireErrorHook(ctx, StageJobStatus, err)
+	}
 }
 
 func (c *Client) fireLogDownloadHook(ctx context.Context, org, pipeline, build, job string, duration time.Duration, logSize int64, err error) {
 	for _, hook := range c.hooks.OnAfterLogDownload {
 		hook(ctx, &LogDownloadResult{
 			BaseResult: BaseResult{
-				Org:      org,
-				Pipeline: pipeline,
-				Build:    build,
-				Job:      job,
-				Duration: duration,
-				Stage:    StageLogDownload,
-				Success:  err == nil,
-				Err:      err,
+				Org:       org,
+				Pipeline:  pipeline,
+				Build:     build,
+				Job:       job,
+				Duration:  duration,
+				Stage:     StageLogDownload,
+				Success:   err == nil,
+				Err:       err,
+				RequestID: RequestIDFromError(err),
 			},
 			LogSize: logSize,
 		})
 	}
+	c.fireErrorHook(ctx, StageLogDownload, err)
 }
 
 func (c *Client) fireLogParsingHook(ctx context.Context, org, pipeline, build, job string, duration time.Duration, parquetSize int64, logEntries int, err error) {
 	for _, hook := range c.hooks.OnAfterLogParsing {
 		hook(ctx, &LogParsingResult{
 			BaseResult: BaseResult{
-				Org:      org,
-				Pipeline: pipeline,
-				Build:    build,
-				Job:      job,
-				Duration: duration,
-				Stage:    StageLogParsing,
-				Success:  err == nil,
-				Err:      err,
+				Org:       org,
+				Pipeline:  pipeline,
+				Build:     build,
+				Job:       job,
+				Duration:  duration,
+				Stage:     StageLogParsing,
+				Success:   err == nil,
+				Err:       err,
+				RequestID: RequestIDFromError(err),
 			},
 			ParquetSize: parquetSize,
 			LogEntries:  logEntries,
 		})
 	}
+	c.fireErrorHook(ctx, StageLogParsing, err)
 }
 
 func (c *Client) fireBlobStorageHook(ctx context.Context, org, pipeline, build, job string, duration time.Duration, blobKey string, dataSize int64, isTerminal bool, ttl time.Duration, err error) {
 	for _, hook := range c.hooks.OnAfterBlobStorage {
 		hook(ctx, &BlobStorageResult{
 			BaseResult: BaseResult{
-				Org:      org,
-				Pipeline: pipeline,
-				Build:    build,
-				Job:      job,
-				Duration: duration,
-				Stage:    StageBlobStorage,
-				Success:  err == nil,
-				Err:      err,
+				Org:       org,
+				Pipeline:  pipeline,
+				Build:     build,
+				Job:       job,
+				Duration:  duration,
+				Stage:     StageBlobStorage,
+				Success:   err == nil,
+				Err:       err,
+				RequestID: RequestIDFromError(err),
 			},
 			BlobKey:    blobKey,
 			DataSize:   dataSize,
@@ -600,25 +1088,28 @@ func (c *Client) fireBlobStorageHook(ctx context.Context, org, pipeline, build,
 			TTL:        ttl,
 		})
 	}
+	c.fireErrorHook(ctx, StageBlobStorage, err)
 }
 
 func (c *Client) fireLocalCacheHook(ctx context.Context, org, pipeline, build, job string, duration time.Duration, localPath string, fileSize int64, err error) {
 	for _, hook := range c.hooks.OnAfterLocalCache {
 		hook(ctx, &LocalCacheResult{
 			BaseResult: BaseResult{
-				Org:      org,
-				Pipeline: pipeline,
-				Build:    build,
-				Job:      job,
-				Duration: duration,
-				Stage:    StageLocalCache,
-				Success:  err == nil,
-				Err:      err,
+				Org:       org,
+				Pipeline:  pipeline,
+				Build:     build,
+				Job:       job,
+				Duration:  duration,
+				Stage:     StageLocalCache,
+				Success:   err == nil,
+				Err:       err,
+				RequestID: RequestIDFromError(err),
 			},
 			LocalPath: localPath,
 			FileSize:  fileSize,
 		})
 	}
+	c.fireErrorHook(ctx, StageLocalCache, err)
 }
 
 type logDownloadError struct {
@@ -642,10 +1133,14 @@ func isLogDownloadError(err error) bool {
 type countingReadCloser struct {
 	rc       io.ReadCloser
 	consumed int64
+	lineEnd  int64 // Bytes consumed up to and including the last newline
 }
 
 func (c *countingReadCloser) Read(p []byte) (int, error) {
 	n, err := c.rc.Read(p)
+	if i := bytes.LastIndexByte(p[:n], '\n'); i >= 0 {
+		c.lineEnd = c.consumed + int64(i) + 1
+	}
 	c.consumed += int64(n)
 	return n, err
 }
@@ -658,7 +1153,8 @@ func (c *countingReadCloser) Close() error {
 // if the limit is exceeded during reading.
 type limitedReadCloser struct {
 	rc       io.ReadCloser
-	r        io.Reader // LimitReader set to limit+1
+	r        io.Reader // LimitReader set to limit-start+1
+	start    int64     // Bytes of the log before this reader, see WithIncrementalRefresh
 	limit    int64
 	consumed int64
 }
@@ -666,7 +1162,7 @@ type limitedReadCloser struct {
 func (l *limitedReadCloser) Read(p []byte) (int, error) {
 	n, err := l.r.Read(p)
 	l.consumed += int64(n)
-	if l.consumed > l.limit {
+	if l.start+l.consumed > l.limit {
 		return n, fmt.Errorf("%w: exceeded limit of %d bytes", ErrLogTooLarge, l.limit)
 	}
 	return n, err
@@ -676,10 +1172,55 @@ func (l *limitedReadCloser) Close() error {
 	return l.rc.Close()
 }
 
-// Close closes the underlying blob storage connection
-func (c *Client) Close() error {
-	if c.blobStorage != nil {
-		return c.blobStorage.Close()
+// truncatingReadCloser reads at most limit bytes, then ends with a TruncatedLogMarker
+// line if there was more to read.
+type truncatingReadCloser struct {
+	rc       io.ReadCloser
+	start    int64 // Bytes of the log before this reader, see WithIncrementalRefresh
+	limit    int64
+	consumed int64
+	lastByte byte
+	marker   *strings.Reader // Set once the log turned out to exceed the limit
+}
+
+func (t *truncatingReadCloser) Read(p []byte) (int, error) {
+	if t.marker != nil {
+		return t.marker.Read(p)
 	}
-	return nil
+	if remaining := t.limit - t.start - t.consumed; remaining > 0 {
+		if int64(len(p)) > remaining {
+			p = p[:remaining]
+		}
+		n, err := t.rc.Read(p)
+		t.consumed += int64(n)
+		if n > 0 {
+			t.lastByte = p[n-1]
+		}
+		return n, err
+	}
+
+	// At the limit, a single byte more decides whether the log was truncated
+	var probe [1]byte
+	if n, err := io.ReadFull(t.rc, probe[:]); n == 0 {
+		return 0, err
+	}
+	marker := fmt.Sprintf("%s: exceeded limit of %d bytes, the rest of the log was not downloaded\n", TruncatedLogMarker, t.limit)
+	if t.lastByte != '\n' {
+		// End the partial last line so the marker is an entry of its own
+		marker = "\n" + marker
+	}
+	t.marker = strings.NewReader(marker)
+	return t.marker.Read(p)
+}
+
+// truncatedAt returns the limit if the log was truncated, otherwise 0.
+func (t *truncatingReadCloser) truncatedAt() int64 {
+	if t.marker == nil {
+		return 0
+	}
+	return t.limit
+}
+
+func (t *truncatingReadCloser) Close() error {
+	return t.rc.Close()
 }
@@ -1044,6 +1044,64 @@ func TestClient_NewReader_LogTooLarge(t *testing.T) {
 	}
 }
 
+func TestClient_NewReader_TruncateLargeLogs(t *testing.T) {
+	mock := &mockBuildkiteAPI{
+		logContent: strings.Repeat("line of log output\n", 100), // 1900 bytes
+		jobStatus: &JobStatus{
+			ID:         "test-job",
+			State:      JobStatePassed,
+			IsTerminal: true,
+		},
+	}
+	client := newTestClient(t, mock, WithMaxLogBytes(190), WithTruncateLargeLogs(true))
+
+	reader, err := client.NewReader(t.Context(), "org", "pipeline", "123", "job-1", time.Minute, false)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	defer reader.Close()
+
+	var entries []ParquetLogEntry
+	for entry, err := range reader.ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 11 {
+		t.Fatalf("got %d entries, want 10 log lines and the marker", len(entries))
+	}
+	if entries[9].Content != "line of log output" {
+		t.Errorf("last kept line = %q", entries[9].Content)
+	}
+	if !strings.HasPrefix(entries[10].Content, TruncatedLogMarker) {
+		t.Errorf("last entry = %q, want the truncation marker", entries[10].Content)
+	}
+
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo: %v", err)
+	}
+	if info.Producer == nil || info.Producer.InputTruncatedAt != 190 {
+		t.Errorf("Producer = %+v, want InputTruncatedAt 190", info.Producer)
+	}
+}
+
+func TestTruncatingReadCloser_AtLimit(t *testing.T) {
+	reader := &truncatingReadCloser{rc: io.NopCloser(strings.NewReader("exactly10\n")), limit: 10}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(data) != "exactly10\n" {
+		t.Errorf("read %q, want the log unchanged", data)
+	}
+	if reader.truncatedAt() != 0 {
+		t.Errorf("truncatedAt() = %d, want 0 for a log at the limit", reader.truncatedAt())
+	}
+}
+
 func TestClient_NewReader_LogWithinLimit(t *testing.T) {
 	mock := newTerminalMock()                                    // small log content
 	client := newTestClient(t, mock, WithMaxLogBytes(1024*1024)) // 1MB limit
@@ -1129,3 +1187,45 @@ func TestClient_NewReader_RetriedJob_OriginalJobNotFound(t *testing.T) {
 	}
 	defer reader.Close()
 }
+
+func TestClient_Invalidate(t *testing.T) {
+	api := newTerminalMock()
+	client := newTestClient(t, api)
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "test-job"}
+
+	removed, err := client.Invalidate(t.Context(), location)
+	if err != nil {
+		t.Fatalf("Invalidate failed: %v", err)
+	}
+	if removed {
+		t.Error("expected nothing to remove before the first read")
+	}
+
+	reader, err := client.NewReader(t.Context(), "org", "pipeline", "1", "test-job", time.Minute, false)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	reader.Close()
+
+	removed, err = client.Invalidate(t.Context(), location)
+	if err != nil {
+		t.Fatalf("Invalidate failed: %v", err)
+	}
+	if !removed {
+		t.Error("expected cached log to be removed")
+	}
+
+	reader, err = client.NewReader(t.Context(), "org", "pipeline", "1", "test-job", time.Minute, false)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	reader.Close()
+
+	if logCalls, _ := api.calls(); logCalls != 2 {
+		t.Errorf("log downloaded %d times, want 2 (once before and once after invalidation)", logCalls)
+	}
+
+	if _, err := client.Invalidate(t.Context(), JobLocation{Org: "org"}); err == nil {
+		t.Error("expected error for incomplete job location")
+	}
+}
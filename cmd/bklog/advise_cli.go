@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+)
+
+// AdviseConfig holds configuration for the advise command
+type AdviseConfig struct {
+	ParquetFile string
+	TopN        int    // Groups and repeated lines to report
+	MinRepeats  int    // Occurrences for a line to count as repeated
+	Format      string // Output format: text, json
+	// Buildkite API parameters
+	Organization string
+	Pipeline     string
+	Build        string
+	Branch       string
+	Job          string
+	Step         string
+	CacheURL     string
+}
+
+func handleAdviseCommand() {
+	var config AdviseConfig
+
+	adviseFlags := flag.NewFlagSet("advise", flag.ExitOnError)
+	adviseFlags.StringVar(&config.ParquetFile, "file", "", "Path to Parquet log file (use this OR API parameters)")
+	adviseFlags.IntVar(&config.TopN, "top", 10, "Number of groups and repeated lines to report")
+	adviseFlags.IntVar(&config.MinRepeats, "min-repeats", 10, "Occurrences for a line to count as repeated")
+	adviseFlags.StringVar(&config.Format, "format", "text", "Output format: text, json")
+	// Buildkite API parameters
+	adviseFlags.StringVar(&config.Organization, "org", "", "Buildkite organization slug (for API)")
+	adviseFlags.StringVar(&config.Pipeline, "pipeline", "", "Buildkite pipeline slug (for API)")
+	adviseFlags.StringVar(&config.Build, "build", "", "Buildkite build number, UUID or \"latest\" (for API)")
+	adviseFlags.StringVar(&config.Branch, "branch", "", "Branch to select the most recent build from (with -build latest)")
+	adviseFlags.StringVar(&config.Job, "job", "", "Buildkite job ID (for API)")
+	adviseFlags.StringVar(&config.Step, "step", "", "Step key or label to resolve within the build (for API, instead of -job)")
+	adviseFlags.StringVar(&config.CacheURL, "cache-url", "", "Cache storage URL (file://path, s3://bucket, etc)")
+
+	adviseFlags.Usage = func() {
+		fmt.Printf("Usage: %s advise [options]\n\n", os.Args[0])
+		fmt.Println("Report what makes a job log large (groups, repeated lines, ANSI codes, progress")
+		fmt.Println("redraws and heartbeats) and recommend how the pipeline could trim its output.")
+		fmt.Println("\nYou must provide either:")
+		fmt.Println("  -file <path>     Local parquet file")
+		fmt.Println("  OR API params:   -org -pipeline -build -job")
+		fmt.Println("\nOptions:")
+		adviseFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s advise -file logs.parquet\n", os.Args[0])
+		fmt.Printf("  %s advise -file logs.parquet -top 5 -format json\n", os.Args[0])
+		fmt.Printf("  %s advise -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab\n", os.Args[0])
+	}
+
+	if err := adviseFlags.Parse(os.Args[2:]); err != nil {
+		os.Exit(1)
+	}
+
+	hasFile := config.ParquetFile != ""
+	hasAPIParams := config.Organization != "" || config.Pipeline != "" || config.Build != "" || config.Job != "" || config.Step != ""
+
+	if hasFile == hasAPIParams {
+		fmt.Fprintf(os.Stderr, "Error: Must provide either -file or API parameters (-org, -pipeline, -build, -job)\n\n")
+		adviseFlags.Usage()
+		os.Exit(1)
+	}
+	if config.Format != "text" && config.Format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid format %q (must be text or json)\n\n", config.Format)
+		adviseFlags.Usage()
+		os.Exit(1)
+	}
+	if config.TopN < 1 || config.MinRepeats < 2 {
+		fmt.Fprintf(os.Stderr, "Error: -top must be at least 1 and -min-repeats at least 2\n\n")
+		adviseFlags.Usage()
+		os.Exit(1)
+	}
+
+	if hasAPIParams {
+		if err := validateAPIFlags(config.Organization, config.Pipeline, &config.Build, config.Branch, &config.Job, config.Step); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+			adviseFlags.Usage()
+			os.Exit(1)
+		}
+	}
+
+	ctx := context.Background()
+
+	if err := runAdvise(ctx, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runAdvise(ctx context.Context, config *AdviseConfig) error {
+	reader, err := resolveReader(ctx, &QueryConfig{
+		ParquetFile:  config.ParquetFile,
+		Organization: config.Organization,
+		Pipeline:     config.Pipeline,
+		Build:        config.Build,
+		Job:          config.Job,
+		Branch:       config.Branch,
+		Step:         config.Step,
+		CacheURL:     config.CacheURL,
+	})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	report, err := reader.Advise(ctx, buildkitelogs.AdviseOptions{TopN: config.TopN, MinRepeats: config.MinRepeats})
+	if err != nil {
+		return fmt.Errorf("failed to analyze log: %w", err)
+	}
+
+	if config.Format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	writeSizeReport(os.Stdout, report)
+	return nil
+}
+
+// writeSizeReport writes report as text, largest contributors first
+func writeSizeReport(w io.Writer, report *buildkitelogs.SizeReport) {
+	percent := func(n int64) float64 {
+		if report.Bytes == 0 {
+			return 0
+		}
+		return 100 * float64(n) / float64(report.Bytes)
+	}
+
+	fmt.Fprintf(w, "Log size: %d bytes in %d entries\n", report.Bytes, report.Entries)
+
+	fmt.Fprintf(w, "\nLargest groups (%d of %d):\n", len(report.Groups), report.GroupCount)
+	for _, group := range report.Groups {
+		name := group.Name
+		if name == "" {
+			name = "<no group>"
+		}
+		fmt.Fprintf(w, "  %10d bytes  %5.1f%%  %8d entries  %s\n", group.Bytes, percent(group.Bytes), group.Entries, name)
+	}
+
+	fmt.Fprintf(w, "\nRepeated lines (%d bytes of repeats, %.1f%%):\n", report.RepeatedBytes, percent(report.RepeatedBytes))
+	if len(report.RepeatedLines) == 0 {
+		fmt.Fprintf(w, "  none\n")
+	}
+	for _, line := range report.RepeatedLines {
+		fmt.Fprintf(w, "  %10d bytes  %8dx  %s\n", line.Bytes, line.Count, truncateString(line.Content, 100))
+	}
+
+	fmt.Fprintf(w, "\nOverhead:\n")
+	fmt.Fprintf(w, "  ANSI escape codes: %10d bytes  %5.1f%%\n", report.ANSIBytes, percent(report.ANSIBytes))
+	fmt.Fprintf(w, "  Progress redraws:  %10d bytes  %5.1f%%  in %d entries\n", report.ProgressBytes, percent(report.ProgressBytes), report.ProgressEntries)
+	fmt.Fprintf(w, "  Heartbeats:        %10d bytes  %5.1f%%\n", report.HeartbeatBytes, percent(report.HeartbeatBytes))
+
+	fmt.Fprintf(w, "\nRecommendations (estimated savings overlap):\n")
+	if len(report.Recommendations) == 0 {
+		fmt.Fprintf(w, "  none, no single mitigation would save more than 1%% of the log\n")
+	}
+	for i, recommendation := range report.Recommendations {
+		fmt.Fprintf(w, "  %d. %s\n     saves ~%d bytes (%.1f%%) [%s]\n", i+1, recommendation.Message, recommendation.Savings, percent(recommendation.Savings), recommendation.Mitigation)
+	}
+}
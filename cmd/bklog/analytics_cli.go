@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+)
+
+// AnalyticsConfig holds configuration for the analytics command
+type AnalyticsConfig struct {
+	CacheURL     string
+	Since        string // Duration such as 24h or 30d
+	Top          int    // Error signatures per pipeline
+	MetadataOnly bool   // Only read blob metadata
+	Format       string // Output format: text, json
+}
+
+func handleAnalyticsCommand() {
+	var config AnalyticsConfig
+
+	analyticsFlags := flag.NewFlagSet("analytics", flag.ExitOnError)
+	analyticsFlags.StringVar(&config.CacheURL, "cache-url", "", "Cache storage URL (file://path, s3://bucket, etc)")
+	analyticsFlags.StringVar(&config.Since, "since", "30d", "Only include logs cached within this duration (e.g. 24h, 30d, 0 = all)")
+	analyticsFlags.IntVar(&config.Top, "top", 5, "Number of error signatures to report per pipeline")
+	analyticsFlags.BoolVar(&config.MetadataOnly, "metadata-only", false, "Only read blob metadata (faster, but no durations or error signatures)")
+	analyticsFlags.StringVar(&config.Format, "format", "text", "Output format: text, json")
+
+	analyticsFlags.Usage = func() {
+		fmt.Printf("Usage: %s analytics [options]\n\n", os.Args[0])
+		fmt.Println("Summarize the cached logs of every pipeline in a cache: log volume, average job")
+		fmt.Println("duration and the most frequent error signatures.")
+		fmt.Println("\nAnalytics reads the cache directly, so it needs credentials for the whole bucket and")
+		fmt.Println("reports on every pipeline cached there. Cache hit rates are not recorded in the cache.")
+		fmt.Println("\nOptions:")
+		analyticsFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s analytics -cache-url s3://my-log-cache -since 30d\n", os.Args[0])
+		fmt.Printf("  %s analytics -cache-url file:///var/cache/bklog -since 24h -top 10 -format json\n", os.Args[0])
+		fmt.Printf("  %s analytics -cache-url s3://my-log-cache -since 0 -metadata-only\n", os.Args[0])
+	}
+
+	if err := analyticsFlags.Parse(os.Args[2:]); err != nil {
+		os.Exit(1)
+	}
+
+	if config.Format != "text" && config.Format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid format %q (must be text or json)\n\n", config.Format)
+		analyticsFlags.Usage()
+		os.Exit(1)
+	}
+	if config.Top < 1 {
+		fmt.Fprintf(os.Stderr, "Error: -top must be at least 1\n\n")
+		analyticsFlags.Usage()
+		os.Exit(1)
+	}
+	if _, err := parseSinceDuration(config.Since); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+		analyticsFlags.Usage()
+		os.Exit(1)
+	}
+
+	if err := runAnalytics(context.Background(), &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runAnalytics(ctx context.Context, config *AnalyticsConfig) error {
+	since, err := parseSinceDuration(config.Since)
+	if err != nil {
+		return err
+	}
+
+	storage, err := buildkitelogs.NewBlobStorage(ctx, config.CacheURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open cache: %w", err)
+	}
+	defer storage.Close()
+
+	opts := buildkitelogs.AnalyticsOptions{TopErrors: config.Top, MetadataOnly: config.MetadataOnly}
+	if since > 0 {
+		opts.Since = time.Now().Add(-since)
+	}
+
+	start := time.Now()
+	report, err := buildkitelogs.Analytics(ctx, storage, opts)
+	if err != nil {
+		return fmt.Errorf("failed to compute analytics: %w", err)
+	}
+
+	if config.Format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	printAnalyticsReport(report, config)
+	fmt.Fprintf(os.Stderr, "\nAnalyzed %d cached jobs in %s\n", report.Jobs, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func printAnalyticsReport(report *buildkitelogs.AnalyticsReport, config *AnalyticsConfig) {
+	if report.Jobs == 0 {
+		fmt.Println("No cached logs found")
+		return
+	}
+
+	for i, p := range report.Pipelines {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s/%s\n", p.Org, p.Pipeline)
+		fmt.Printf("  Jobs:         %d (%d builds)\n", p.Jobs, p.Builds)
+		fmt.Printf("  Log volume:   %.1f MB raw, %.1f MB cached, %d entries\n",
+			float64(p.LogBytes)/(1024*1024), float64(p.ParquetBytes)/(1024*1024), p.Entries)
+		if config.MetadataOnly {
+			continue
+		}
+		if p.TimedJobs > 0 {
+			fmt.Printf("  Avg duration: %s (%d timed jobs)\n", p.AverageDuration.Round(time.Second), p.TimedJobs)
+		}
+		if p.UnreadableJobs > 0 {
+			fmt.Printf("  Unreadable:   %d jobs\n", p.UnreadableJobs)
+		}
+		fmt.Printf("  Error lines:  %d\n", p.ErrorLines)
+		for _, sig := range p.TopErrors {
+			fmt.Printf("    %6d  in %d jobs  %s\n", sig.Count, sig.Jobs, truncateString(sig.Signature, 100))
+		}
+	}
+
+	if report.Unattributed > 0 {
+		fmt.Printf("\n%d cached logs have no pipeline metadata and are not included above\n", report.Unattributed)
+	}
+}
+
+// parseSinceDuration parses a duration, also accepting whole days such as "30d"
+func parseSinceDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid -since %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	since, err := time.ParseDuration(value)
+	if err != nil || since < 0 {
+		return 0, fmt.Errorf("invalid -since %q", value)
+	}
+	return since, nil
+}
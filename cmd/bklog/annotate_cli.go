@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+)
+
+// AnnotateConfig holds configuration for the annotate command
+type AnnotateConfig struct {
+	Organization string
+	Pipeline     string
+	Build        string
+	Job          string
+	Row          int64
+	Label        string
+	Comment      string
+	Author       string
+	Remove       bool
+	List         bool
+	Format       string // "text", "json"
+	CacheURL     string
+}
+
+func handleAnnotateCommand() {
+	var config AnnotateConfig
+
+	annotateFlags := flag.NewFlagSet("annotate", flag.ExitOnError)
+	annotateFlags.StringVar(&config.Organization, "org", "", "Buildkite organization slug")
+	annotateFlags.StringVar(&config.Pipeline, "pipeline", "", "Buildkite pipeline slug")
+	annotateFlags.StringVar(&config.Build, "build", "", "Buildkite build number or UUID")
+	annotateFlags.StringVar(&config.Job, "job", "", "Buildkite job ID")
+	annotateFlags.Int64Var(&config.Row, "row", -1, "Row number of the log entry to annotate")
+	annotateFlags.StringVar(&config.Label, "label", "", "Label for the entry, e.g. known-flaky or root-cause")
+	annotateFlags.StringVar(&config.Comment, "comment", "", "Free-form comment for the entry")
+	annotateFlags.StringVar(&config.Author, "author", os.Getenv("USER"), "Author recorded with the annotation")
+	annotateFlags.BoolVar(&config.Remove, "remove", false, "Remove the row's annotations (only those with -label, if given)")
+	annotateFlags.BoolVar(&config.List, "list", false, "List the job's annotations")
+	annotateFlags.StringVar(&config.Format, "format", "text", "Output format for -list: text, json")
+	annotateFlags.StringVar(&config.CacheURL, "cache-url", "", "Cache storage URL the annotations are kept in (file://path, s3://bucket, etc)")
+
+	annotateFlags.Usage = func() {
+		fmt.Printf("Usage: %s annotate [options]\n\n", os.Args[0])
+		fmt.Println("Add, remove or list triage annotations on a job's log entries.")
+		fmt.Println("Annotated entries are shown by 'query -op annotations'.")
+		fmt.Println("\nOptions:")
+		annotateFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s annotate -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -row 42 -label known-flaky\n", os.Args[0])
+		fmt.Printf("  %s annotate -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -row 97 -label root-cause -comment \"DNS timeout\"\n", os.Args[0])
+		fmt.Printf("  %s annotate -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -list\n", os.Args[0])
+	}
+
+	if err := annotateFlags.Parse(os.Args[2:]); err != nil {
+		os.Exit(1)
+	}
+
+	if err := validateAPIFlags(config.Organization, config.Pipeline, &config.Build, "", &config.Job, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+		annotateFlags.Usage()
+		os.Exit(1)
+	}
+	if !config.List && config.Row < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -row is required unless -list is given\n\n")
+		annotateFlags.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	if err := runAnnotate(ctx, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runAnnotate(ctx context.Context, config *AnnotateConfig) error {
+	storage, err := buildkitelogs.NewBlobStorage(ctx, config.CacheURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open cache storage: %w", err)
+	}
+	defer storage.Close()
+
+	store := buildkitelogs.NewAnnotationStore(storage)
+	location := buildkitelogs.JobLocation{
+		Org:      config.Organization,
+		Pipeline: config.Pipeline,
+		Build:    config.Build,
+		Job:      config.Job,
+	}
+
+	switch {
+	case config.List:
+		annotations, err := store.List(ctx, location)
+		if err != nil {
+			return err
+		}
+		if config.Format == "json" {
+			return writeJSONLines(annotations, os.Stdout)
+		}
+		for _, a := range annotations {
+			fmt.Printf("row %d: %s\n", a.RowNumber, formatAnnotation(a))
+		}
+		fmt.Fprintf(os.Stderr, "\nAnnotations: %d\n", len(annotations))
+		return nil
+
+	case config.Remove:
+		removed, err := store.Remove(ctx, location, config.Row, config.Label)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Removed %d annotations from row %d\n", removed, config.Row)
+		return nil
+
+	default:
+		err := store.Add(ctx, location, buildkitelogs.Annotation{
+			RowNumber: config.Row,
+			Label:     config.Label,
+			Comment:   config.Comment,
+			Author:    config.Author,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Annotated row %d\n", config.Row)
+		return nil
+	}
+}
+
+// formatAnnotation renders an annotation as "[label] comment (author)".
+func formatAnnotation(a buildkitelogs.Annotation) string {
+	s := ""
+	if a.Label != "" {
+		s = "[" + a.Label + "]"
+	}
+	if a.Comment != "" {
+		if s != "" {
+			s += " "
+		}
+		s += a.Comment
+	}
+	if a.Author != "" {
+		s += " (" + a.Author + ")"
+	}
+	return s
+}
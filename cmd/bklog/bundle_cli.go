@@ -0,0 +1,256 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+)
+
+// defaultBundleErrorPattern matches the lines most often relevant when triaging a failed job
+const defaultBundleErrorPattern = `\b(error|fail(ed|ure)?|fatal|panic|exception)\b`
+
+// BundleConfig holds configuration for the bundle command
+type BundleConfig struct {
+	ParquetFile  string
+	OutputFile   string
+	ErrorPattern string // Regex pattern for error matches (case-insensitive)
+	Context      int    // Lines of context around each error match
+	TailLines    int    // Number of lines from the end of the log to include
+	StripANSI    bool   // Strip ANSI escape codes from text output
+	// Buildkite API parameters
+	Organization string
+	Pipeline     string
+	Build        string
+	Branch       string
+	Job          string
+	Step         string
+	CacheURL     string
+}
+
+// BundleManifest describes the contents of a bundle
+type BundleManifest struct {
+	Source       string    `json:"source"`
+	GeneratedAt  time.Time `json:"generated_at"`
+	Version      string    `json:"version"`
+	ErrorPattern string    `json:"error_pattern"`
+	ErrorMatches int       `json:"error_matches"`
+	Context      int       `json:"context"`
+	TailLines    int       `json:"tail_lines"`
+	Files        []string  `json:"files"`
+}
+
+func handleBundleCommand() {
+	var config BundleConfig
+
+	bundleFlags := flag.NewFlagSet("bundle", flag.ExitOnError)
+	bundleFlags.StringVar(&config.ParquetFile, "file", "", "Path to Parquet log file (use this OR API parameters)")
+	bundleFlags.StringVar(&config.OutputFile, "o", "bundle.zip", "Output zip file")
+	bundleFlags.StringVar(&config.ErrorPattern, "pattern", defaultBundleErrorPattern, "Regex pattern for error matches (case-insensitive)")
+	bundleFlags.IntVar(&config.Context, "C", 5, "Lines of context around each error match")
+	bundleFlags.IntVar(&config.TailLines, "tail", 200, "Number of lines from the end of the log to include")
+	bundleFlags.BoolVar(&config.StripANSI, "strip-ansi", true, "Strip ANSI escape codes from text output")
+	// Buildkite API parameters
+	bundleFlags.StringVar(&config.Organization, "org", "", "Buildkite organization slug (for API)")
+	bundleFlags.StringVar(&config.Pipeline, "pipeline", "", "Buildkite pipeline slug (for API)")
+	bundleFlags.StringVar(&config.Build, "build", "", "Buildkite build number, UUID or \"latest\" (for API)")
+	bundleFlags.StringVar(&config.Branch, "branch", "", "Branch to select the most recent build from (with -build latest)")
+	bundleFlags.StringVar(&config.Job, "job", "", "Buildkite job ID (for API)")
+	bundleFlags.StringVar(&config.Step, "step", "", "Step key or label to resolve within the build (for API, instead of -job)")
+	bundleFlags.StringVar(&config.CacheURL, "cache-url", "", "Cache storage URL (file://path, s3://bucket, etc)")
+
+	bundleFlags.Usage = func() {
+		fmt.Printf("Usage: %s bundle [options]\n\n", os.Args[0])
+		fmt.Println("Package everything needed to triage a job log into a single zip file:")
+		fmt.Println("file info, group timeline, error matches with context and the last N lines.")
+		fmt.Println("\nYou must provide either:")
+		fmt.Println("  -file <path>     Local parquet file")
+		fmt.Println("  OR API params:   -org -pipeline -build -job")
+		fmt.Println("\nOptions:")
+		bundleFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s bundle -file logs.parquet -o bundle.zip\n", os.Args[0])
+		fmt.Printf("  %s bundle -file logs.parquet -o bundle.zip -pattern \"timeout|oom\" -C 10\n", os.Args[0])
+		fmt.Printf("  %s bundle -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -o bundle.zip\n", os.Args[0])
+	}
+
+	if err := bundleFlags.Parse(os.Args[2:]); err != nil {
+		os.Exit(1)
+	}
+
+	hasFile := config.ParquetFile != ""
+	hasAPIParams := config.Organization != "" || config.Pipeline != "" || config.Build != "" || config.Job != "" || config.Step != ""
+
+	if hasFile == hasAPIParams {
+		fmt.Fprintf(os.Stderr, "Error: Must provide either -file or API parameters (-org, -pipeline, -build, -job)\n\n")
+		bundleFlags.Usage()
+		os.Exit(1)
+	}
+
+	if config.TailLines < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -tail must not be negative\n\n")
+		bundleFlags.Usage()
+		os.Exit(1)
+	}
+
+	if hasAPIParams {
+		if err := validateAPIFlags(config.Organization, config.Pipeline, &config.Build, config.Branch, &config.Job, config.Step); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+			bundleFlags.Usage()
+			os.Exit(1)
+		}
+	}
+
+	ctx := context.Background()
+
+	if err := runBundle(ctx, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runBundle(ctx context.Context, config *BundleConfig) error {
+	reader, err := resolveReader(ctx, &QueryConfig{
+		ParquetFile:  config.ParquetFile,
+		Organization: config.Organization,
+		Pipeline:     config.Pipeline,
+		Build:        config.Build,
+		Job:          config.Job,
+		Branch:       config.Branch,
+		Step:         config.Step,
+		CacheURL:     config.CacheURL,
+	})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	out, err := os.Create(config.OutputFile)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle file: %w", err)
+	}
+	defer out.Close()
+
+	if err := writeBundle(ctx, out, reader, config); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to close bundle file: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Bundle written to %s\n", config.OutputFile)
+	return nil
+}
+
+// writeBundle writes the bundle zip archive for reader to w
+func writeBundle(ctx context.Context, w io.Writer, reader *buildkitelogs.ParquetReader, config *BundleConfig) error {
+	zw := zip.NewWriter(w)
+
+	manifest := BundleManifest{
+		Source:       config.ParquetFile,
+		GeneratedAt:  time.Now().UTC(),
+		Version:      version,
+		ErrorPattern: config.ErrorPattern,
+		Context:      config.Context,
+		TailLines:    config.TailLines,
+	}
+	if manifest.Source == "" {
+		job := config.Job
+		if job == "" {
+			job = config.Step
+		}
+		manifest.Source = fmt.Sprintf("%s/%s/%s/%s", config.Organization, config.Pipeline, config.Build, job)
+	}
+
+	addFile := func(name string, write func(io.Writer) error) error {
+		fw, err := zw.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+		}
+		if err := write(fw); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		manifest.Files = append(manifest.Files, name)
+		return nil
+	}
+
+	// File info
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+	if err := addFile("info.json", func(w io.Writer) error {
+		return writeIndentedJSON(w, info)
+	}); err != nil {
+		return err
+	}
+
+	// Group timeline
+	groups, _, err := collectGroups(reader.ReadEntriesIter(ctx))
+	if err != nil {
+		return err
+	}
+	if err := addFile("groups.jsonl", func(w io.Writer) error {
+		return writeJSONLines(groups, w)
+	}); err != nil {
+		return err
+	}
+
+	// Error matches with context
+	var matches []buildkitelogs.SearchResult
+	for result, err := range reader.SearchEntriesIter(ctx, buildkitelogs.SearchOptions{
+		Pattern: config.ErrorPattern,
+		Context: config.Context,
+	}) {
+		if err != nil {
+			return fmt.Errorf("error during search: %w", err)
+		}
+		matches = append(matches, result)
+	}
+	manifest.ErrorMatches = len(matches)
+	if err := addFile("errors.jsonl", func(w io.Writer) error {
+		return writeJSONLines(matches, w)
+	}); err != nil {
+		return err
+	}
+
+	// Last N lines; with -tail 0 or an empty log there is nothing to seek to
+	startRow := max(info.RowCount-int64(config.TailLines), 0)
+	var tail []buildkitelogs.ParquetLogEntry
+	if startRow < info.RowCount {
+		for entry, err := range reader.SeekToRow(ctx, startRow) {
+			if err != nil {
+				return fmt.Errorf("error reading entries: %w", err)
+			}
+			tail = append(tail, entry)
+		}
+	}
+	if err := addFile("tail.log", func(w io.Writer) error {
+		return writeLogEntries(w, tail, &QueryConfig{StripANSI: config.StripANSI})
+	}); err != nil {
+		return err
+	}
+
+	if err := addFile("manifest.json", func(w io.Writer) error {
+		return writeIndentedJSON(w, manifest)
+	}); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize bundle: %w", err)
+	}
+	return nil
+}
+
+func writeIndentedJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+)
+
+func TestWriteBundle(t *testing.T) {
+	reader := buildkitelogs.NewParquetReader("../../testdata/bash-example.parquet")
+	config := &BundleConfig{
+		ParquetFile:  "../../testdata/bash-example.parquet",
+		ErrorPattern: defaultBundleErrorPattern,
+		Context:      2,
+		TailLines:    5,
+		StripANSI:    true,
+	}
+
+	var buf bytes.Buffer
+	if err := writeBundle(t.Context(), &buf, reader, config); err != nil {
+		t.Fatalf("writeBundle failed: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("bundle is not a valid zip: %v", err)
+	}
+
+	contents := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", f.Name, err)
+		}
+		contents[f.Name] = data
+	}
+
+	for _, name := range []string{"info.json", "groups.jsonl", "errors.jsonl", "tail.log", "manifest.json"} {
+		if _, ok := contents[name]; !ok {
+			t.Errorf("bundle is missing %s", name)
+		}
+	}
+
+	var manifest BundleManifest
+	if err := json.Unmarshal(contents["manifest.json"], &manifest); err != nil {
+		t.Fatalf("failed to decode manifest: %v", err)
+	}
+	if manifest.Source != config.ParquetFile {
+		t.Errorf("manifest source = %q, want %q", manifest.Source, config.ParquetFile)
+	}
+	if got := bytes.Count(contents["errors.jsonl"], []byte("\n")); got != manifest.ErrorMatches {
+		t.Errorf("errors.jsonl has %d lines, manifest reports %d matches", got, manifest.ErrorMatches)
+	}
+	if got := bytes.Count(contents["tail.log"], []byte("\n")); got != 5 {
+		t.Errorf("tail.log has %d lines, want 5", got)
+	}
+}
+
+func TestWriteBundle_NoTail(t *testing.T) {
+	reader := buildkitelogs.NewParquetReader("../../testdata/bash-example.parquet")
+	config := &BundleConfig{
+		ParquetFile:  "../../testdata/bash-example.parquet",
+		ErrorPattern: defaultBundleErrorPattern,
+		TailLines:    0,
+	}
+
+	var buf bytes.Buffer
+	if err := writeBundle(t.Context(), &buf, reader, config); err != nil {
+		t.Fatalf("writeBundle failed: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("bundle is not a valid zip: %v", err)
+	}
+	f, err := zr.Open("tail.log")
+	if err != nil {
+		t.Fatalf("bundle is missing tail.log: %v", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("failed to read tail.log: %v", err)
+	}
+	if len(data) != 0 {
+		t.Errorf("tail.log = %q, want it empty with -tail 0", data)
+	}
+}
@@ -0,0 +1,486 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+)
+
+// CacheConfig holds configuration for cache subcommands
+type CacheConfig struct {
+	Format string // "text", "json"
+	// Buildkite API parameters
+	Organization string
+	Pipeline     string
+	Build        string
+	Branch       string
+	Job          string
+	Step         string
+	CacheURL     string
+	// doctor
+	File       string // Parquet file to measure instead of a cached job
+	SampleRows int    // Rows to re-encode
+	// verify
+	RawFile string // Raw log to compare -file with
+}
+
+func handleCacheCommand() {
+	if len(os.Args) < 3 {
+		printCacheUsage()
+		os.Exit(1)
+	}
+
+	switch os.Args[2] {
+	case "list":
+		handleCacheListCommand()
+	case "path":
+		handleCachePathCommand()
+	case "doctor":
+		handleCacheDoctorCommand()
+	case "verify":
+		handleCacheVerifyCommand()
+	case "help", "-h", "--help":
+		printCacheUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown cache subcommand: %s\n\n", os.Args[2]) //nolint:gosec // CLI tool, not a web context
+		printCacheUsage()
+		os.Exit(1)
+	}
+}
+
+func printCacheUsage() {
+	fmt.Printf("Usage: %s cache <subcommand> [options]\n\n", os.Args[0])
+	fmt.Println("Subcommands:")
+	fmt.Println("  list      List cached job logs with their job state, cache time, TTL and size")
+	fmt.Println("  path      Show where a job's cached log and its local copy live, without downloading")
+	fmt.Println("  doctor    Measure how a cached log compresses with each Parquet codec and recommend one")
+	fmt.Println("  verify    Check that a cached log still matches a fresh download of its raw log")
+}
+
+func handleCacheListCommand() {
+	var config CacheConfig
+
+	listFlags := flag.NewFlagSet("cache list", flag.ExitOnError)
+	listFlags.StringVar(&config.Format, "format", "text", "Output format: text, json")
+	listFlags.StringVar(&config.Organization, "org", "", "Only list jobs of this Buildkite organization")
+	listFlags.StringVar(&config.Pipeline, "pipeline", "", "Only list jobs of this pipeline")
+	listFlags.StringVar(&config.CacheURL, "cache-url", "", "Cache storage URL (file://path, s3://bucket, etc)")
+
+	listFlags.Usage = func() {
+		fmt.Printf("Usage: %s cache list [options]\n\n", os.Args[0])
+		fmt.Println("List the job logs in the cache with the metadata recorded when each was cached.")
+		fmt.Println("Storage is read directly; no BUILDKITE_API_TOKEN is needed.")
+		fmt.Println("\nOptions:")
+		listFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s cache list\n", os.Args[0])
+		fmt.Printf("  %s cache list -org myorg -pipeline mypipe -cache-url s3://my-bucket -format json\n", os.Args[0])
+	}
+
+	if err := listFlags.Parse(os.Args[3:]); err != nil {
+		os.Exit(1)
+	}
+
+	if config.Format != "text" && config.Format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid format %q (must be text or json)\n\n", config.Format)
+		listFlags.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	if err := runCacheList(ctx, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runCacheList(ctx context.Context, config *CacheConfig) error {
+	buildkiteClient := buildkitelogs.NewBuildkiteAPIClient(os.Getenv("BUILDKITE_API_TOKEN"), version)
+	client, err := buildkitelogs.NewClientWithAPI(ctx, buildkiteClient, config.CacheURL, buildkitelogs.WithLogger(logger))
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	cached, err := client.ListCached(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list cache: %w", err)
+	}
+	entries := make([]buildkitelogs.CatalogEntry, 0, len(cached))
+	for _, entry := range cached {
+		if config.Organization != "" || config.Pipeline != "" {
+			m := entry.Metadata
+			if m == nil || (config.Organization != "" && m.Organization != config.Organization) || (config.Pipeline != "" && m.Pipeline != config.Pipeline) {
+				continue
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	if config.Format == "json" {
+		return writeJSONLines(entries, os.Stdout)
+	}
+
+	fmt.Fprintf(os.Stderr, "Cached job logs: %d\n\n", len(entries))
+	if len(entries) == 0 {
+		return nil
+	}
+	fmt.Printf("%-30s %-8s %-36s %-10s %-20s %-8s %10s\n", "PIPELINE", "BUILD", "JOB", "STATE", "CACHED AT", "TTL", "SIZE")
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+		m := entry.Metadata
+		if m == nil {
+			// Written without metadata: only the key identifies the job
+			fmt.Printf("%-30s %-8s %-36s %-10s %-20s %-8s %8.1f KB\n", truncateString(entry.Key, 30), "-", "-", "-", "-", "-", float64(entry.Size)/1024)
+			continue
+		}
+		ttl := m.TTL
+		if m.IsTerminal {
+			ttl = "-" // Terminal jobs never expire
+		}
+		fmt.Printf("%-30s %-8s %-36s %-10s %-20s %-8s %8.1f KB\n",
+			truncateString(m.Organization+"/"+m.Pipeline, 30), m.Build, m.JobID, m.JobState,
+			m.CachedAt.Local().Format("2006-01-02 15:04:05"), ttl, float64(entry.Size)/1024)
+	}
+	fmt.Fprintf(os.Stderr, "\nTotal size: %.1f MB\n", float64(total)/(1024*1024))
+	return nil
+}
+
+func handleCachePathCommand() {
+	var config CacheConfig
+
+	pathFlags := flag.NewFlagSet("cache path", flag.ExitOnError)
+	pathFlags.StringVar(&config.Format, "format", "text", "Output format: text, json")
+	pathFlags.StringVar(&config.Organization, "org", "", "Buildkite organization slug")
+	pathFlags.StringVar(&config.Pipeline, "pipeline", "", "Buildkite pipeline slug")
+	pathFlags.StringVar(&config.Build, "build", "", "Buildkite build number, UUID or \"latest\"")
+	pathFlags.StringVar(&config.Branch, "branch", "", "Branch to select the most recent build from (with -build latest)")
+	pathFlags.StringVar(&config.Job, "job", "", "Buildkite job ID")
+	pathFlags.StringVar(&config.Step, "step", "", "Step key or label to resolve within the build (instead of -job)")
+	pathFlags.StringVar(&config.CacheURL, "cache-url", "", "Cache storage URL (file://path, s3://bucket, etc)")
+
+	pathFlags.Usage = func() {
+		fmt.Printf("Usage: %s cache path [options]\n\n", os.Args[0])
+		fmt.Println("Show where a job's cached log and the local file readers use live, or would live")
+		fmt.Println("once cached. Nothing is downloaded.")
+		fmt.Println("\nBUILDKITE_API_TOKEN is only needed to resolve -build latest or -step.")
+		fmt.Println("\nOptions:")
+		pathFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s cache path -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab\n", os.Args[0])
+		fmt.Printf("  %s cache path -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -format json\n", os.Args[0])
+	}
+
+	if err := pathFlags.Parse(os.Args[3:]); err != nil {
+		os.Exit(1)
+	}
+
+	if config.Format != "text" && config.Format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid format %q (must be text or json)\n\n", config.Format)
+		pathFlags.Usage()
+		os.Exit(1)
+	}
+	if err := validateAPIFlags(config.Organization, config.Pipeline, &config.Build, config.Branch, &config.Job, config.Step); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+		pathFlags.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	if err := runCachePath(ctx, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runCachePath(ctx context.Context, config *CacheConfig) error {
+	apiToken := os.Getenv("BUILDKITE_API_TOKEN")
+	if apiToken == "" && (config.Build == buildkitelogs.LatestBuild || config.Step != "") {
+		return fmt.Errorf("BUILDKITE_API_TOKEN environment variable is required to resolve -build latest or -step")
+	}
+
+	buildkiteClient := buildkitelogs.NewBuildkiteAPIClient(apiToken, version)
+	client, err := buildkitelogs.NewClientWithAPI(ctx, buildkiteClient, config.CacheURL, buildkitelogs.WithLogger(logger))
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	build, err := client.ResolveBuild(ctx, config.Organization, config.Pipeline, config.Build, config.Branch)
+	if err != nil {
+		return fmt.Errorf("failed to resolve build: %w", err)
+	}
+	location := buildkitelogs.JobLocation{Org: config.Organization, Pipeline: config.Pipeline, Build: build, Job: config.Job}
+	if config.Step != "" {
+		if location, err = client.ResolveStep(ctx, config.Organization, config.Pipeline, build, config.Step); err != nil {
+			return fmt.Errorf("failed to resolve step: %w", err)
+		}
+	}
+
+	loc, err := client.CachePath(ctx, location)
+	if err != nil {
+		return err
+	}
+
+	if config.Format == "json" {
+		return writeIndentedJSON(os.Stdout, loc)
+	}
+
+	fmt.Printf("Blob key:     %s\n", loc.BlobKey)
+	fmt.Printf("Blob URL:     %s\n", loc.BlobURL)
+	fmt.Printf("Cached:       %t\n", loc.Cached)
+	switch {
+	case loc.LocalPath == "":
+		fmt.Printf("Local file:   not known until cached (copies go in %s)\n", loc.LocalCacheDir)
+	case loc.LocalExists:
+		fmt.Printf("Local file:   %s\n", loc.LocalPath)
+	default:
+		fmt.Printf("Local file:   %s (not present)\n", loc.LocalPath)
+	}
+	return nil
+}
+
+func handleCacheDoctorCommand() {
+	var config CacheConfig
+
+	doctorFlags := flag.NewFlagSet("cache doctor", flag.ExitOnError)
+	doctorFlags.StringVar(&config.Format, "format", "text", "Output format: text, json")
+	doctorFlags.StringVar(&config.File, "file", "", "Parquet file to measure (use this OR API parameters)")
+	doctorFlags.IntVar(&config.SampleRows, "sample-rows", buildkitelogs.DefaultCompressionSampleRows, "Rows of the log to re-encode with each codec")
+	doctorFlags.StringVar(&config.Organization, "org", "", "Buildkite organization slug")
+	doctorFlags.StringVar(&config.Pipeline, "pipeline", "", "Buildkite pipeline slug")
+	doctorFlags.StringVar(&config.Build, "build", "", "Buildkite build number, UUID or \"latest\"")
+	doctorFlags.StringVar(&config.Branch, "branch", "", "Branch to select the most recent build from (with -build latest)")
+	doctorFlags.StringVar(&config.Job, "job", "", "Buildkite job ID")
+	doctorFlags.StringVar(&config.Step, "step", "", "Step key or label to resolve within the build (instead of -job)")
+	doctorFlags.StringVar(&config.CacheURL, "cache-url", "", "Cache storage URL (file://path, s3://bucket, etc)")
+
+	doctorFlags.Usage = func() {
+		fmt.Printf("Usage: %s cache doctor [options]\n\n", os.Args[0])
+		fmt.Println("Re-encode a sample of a log with each Parquet codec (zstd, snappy, none), measure")
+		fmt.Println("size and write time, and how much a further gzip pass saves, as storage-side or")
+		fmt.Println("transfer compression would. Recommends the codec to cache with (WithCacheCompression)")
+		fmt.Println("and whether compressing cached logs again is worth it.")
+		fmt.Println("\nFor API usage, set BUILDKITE_API_TOKEN environment variable.")
+		fmt.Println("\nOptions:")
+		doctorFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s cache doctor -file logs.parquet\n", os.Args[0])
+		fmt.Printf("  %s cache doctor -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -format json\n", os.Args[0])
+	}
+
+	if err := doctorFlags.Parse(os.Args[3:]); err != nil {
+		os.Exit(1)
+	}
+
+	if config.Format != "text" && config.Format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid format %q (must be text or json)\n\n", config.Format)
+		doctorFlags.Usage()
+		os.Exit(1)
+	}
+	hasAPIParams := config.Organization != "" || config.Pipeline != "" || config.Build != "" || config.Job != "" || config.Step != ""
+	if (config.File == "") == !hasAPIParams {
+		fmt.Fprintf(os.Stderr, "Error: provide either -file or API parameters (-org, -pipeline, -build, -job)\n\n")
+		doctorFlags.Usage()
+		os.Exit(1)
+	}
+	if hasAPIParams {
+		if err := validateAPIFlags(config.Organization, config.Pipeline, &config.Build, config.Branch, &config.Job, config.Step); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+			doctorFlags.Usage()
+			os.Exit(1)
+		}
+	}
+
+	ctx := context.Background()
+
+	if err := runCacheDoctor(ctx, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runCacheDoctor(ctx context.Context, config *CacheConfig) error {
+	var reader *buildkitelogs.ParquetReader
+	if config.File != "" {
+		reader = buildkitelogs.NewParquetReader(config.File).WithLogger(logger)
+	} else {
+		apiToken := os.Getenv("BUILDKITE_API_TOKEN")
+		if apiToken == "" {
+			return fmt.Errorf("BUILDKITE_API_TOKEN environment variable is required for API access")
+		}
+
+		buildkiteClient := buildkitelogs.NewBuildkiteAPIClient(apiToken, version)
+		client, err := buildkitelogs.NewClientWithAPI(ctx, buildkiteClient, config.CacheURL, buildkitelogs.WithLogger(logger))
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		build, err := client.ResolveBuild(ctx, config.Organization, config.Pipeline, config.Build, config.Branch)
+		if err != nil {
+			return fmt.Errorf("failed to resolve build: %w", err)
+		}
+		if config.Step != "" {
+			reader, err = client.NewReaderByStep(ctx, config.Organization, config.Pipeline, build, config.Step, 0, false)
+		} else {
+			reader, err = client.NewReader(ctx, config.Organization, config.Pipeline, build, config.Job, 0, false)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read cached log: %w", err)
+		}
+	}
+
+	report, err := reader.MeasureCompression(ctx, config.SampleRows)
+	if err != nil {
+		return err
+	}
+
+	if config.Format == "json" {
+		return writeIndentedJSON(os.Stdout, report)
+	}
+
+	fmt.Printf("Sample: %d rows, %.1f KB of log content\n\n", report.SampleRows, float64(report.SampleBytes)/1024)
+	fmt.Printf("%-8s %12s %12s %12s %12s\n", "Codec", "Size", "Write time", "+gzip size", "gzip saves")
+	for _, m := range report.Measurements {
+		fmt.Printf("%-8s %10.1f KB %12s %9.1f KB %11.1f%%\n",
+			m.Compression, float64(m.Bytes)/1024, m.EncodeTime.Round(time.Microsecond),
+			float64(m.GzipBytes)/1024, 100*m.GzipSavings())
+	}
+	fmt.Printf("\nRecommended codec: %s\n", report.Recommended)
+	for _, note := range report.Notes {
+		fmt.Printf("  - %s\n", note)
+	}
+	return nil
+}
+
+func handleCacheVerifyCommand() {
+	var config CacheConfig
+
+	verifyFlags := flag.NewFlagSet("cache verify", flag.ExitOnError)
+	verifyFlags.StringVar(&config.Format, "format", "text", "Output format: text, json")
+	verifyFlags.StringVar(&config.File, "file", "", "Parquet file to verify (with -raw-file, instead of API parameters)")
+	verifyFlags.StringVar(&config.RawFile, "raw-file", "", "Raw log the Parquet file was parsed from (with -file)")
+	verifyFlags.StringVar(&config.Organization, "org", "", "Buildkite organization slug")
+	verifyFlags.StringVar(&config.Pipeline, "pipeline", "", "Buildkite pipeline slug")
+	verifyFlags.StringVar(&config.Build, "build", "", "Buildkite build number, UUID or \"latest\"")
+	verifyFlags.StringVar(&config.Branch, "branch", "", "Branch to select the most recent build from (with -build latest)")
+	verifyFlags.StringVar(&config.Job, "job", "", "Buildkite job ID")
+	verifyFlags.StringVar(&config.Step, "step", "", "Step key or label to resolve within the build (instead of -job)")
+	verifyFlags.StringVar(&config.CacheURL, "cache-url", "", "Cache storage URL (file://path, s3://bucket, etc)")
+
+	verifyFlags.Usage = func() {
+		fmt.Printf("Usage: %s cache verify [options]\n\n", os.Args[0])
+		fmt.Println("Download a cached job's raw log again, parse it and compare the entries with the")
+		fmt.Println("cached log row by row, to find caches written before a log was complete. Exits")
+		fmt.Println("with status 1 when they differ; re-cache the job with 'parse -force-refresh'.")
+		fmt.Println("With -file and -raw-file, compares a Parquet file with a local raw log instead.")
+		fmt.Println("\nFor API usage, set BUILDKITE_API_TOKEN environment variable.")
+		fmt.Println("\nOptions:")
+		verifyFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s cache verify -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab\n", os.Args[0])
+		fmt.Printf("  %s cache verify -file logs.parquet -raw-file job.log -format json\n", os.Args[0])
+	}
+
+	if err := verifyFlags.Parse(os.Args[3:]); err != nil {
+		os.Exit(1)
+	}
+
+	if config.Format != "text" && config.Format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid format %q (must be text or json)\n\n", config.Format)
+		verifyFlags.Usage()
+		os.Exit(1)
+	}
+	hasAPIParams := config.Organization != "" || config.Pipeline != "" || config.Build != "" || config.Job != "" || config.Step != ""
+	if (config.File == "") == !hasAPIParams {
+		fmt.Fprintf(os.Stderr, "Error: provide either -file or API parameters (-org, -pipeline, -build, -job)\n\n")
+		verifyFlags.Usage()
+		os.Exit(1)
+	}
+	if (config.File == "") != (config.RawFile == "") {
+		fmt.Fprintf(os.Stderr, "Error: -file and -raw-file must be used together\n\n")
+		verifyFlags.Usage()
+		os.Exit(1)
+	}
+	if hasAPIParams {
+		if err := validateAPIFlags(config.Organization, config.Pipeline, &config.Build, config.Branch, &config.Job, config.Step); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+			verifyFlags.Usage()
+			os.Exit(1)
+		}
+	}
+
+	ctx := context.Background()
+
+	if err := runCacheVerify(ctx, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runCacheVerify(ctx context.Context, config *CacheConfig) error {
+	var result *buildkitelogs.LogVerification
+	if config.File != "" {
+		raw, err := os.Open(config.RawFile)
+		if err != nil {
+			return fmt.Errorf("failed to open raw log: %w", err)
+		}
+		defer raw.Close()
+
+		if result, err = buildkitelogs.VerifyRawLog(ctx, buildkitelogs.NewParquetReader(config.File).WithLogger(logger), raw); err != nil {
+			return err
+		}
+	} else {
+		apiToken := os.Getenv("BUILDKITE_API_TOKEN")
+		if apiToken == "" {
+			return fmt.Errorf("BUILDKITE_API_TOKEN environment variable is required for API access")
+		}
+
+		buildkiteClient := buildkitelogs.NewBuildkiteAPIClient(apiToken, version)
+		client, err := buildkitelogs.NewClientWithAPI(ctx, buildkiteClient, config.CacheURL, buildkitelogs.WithLogger(logger))
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		build, err := client.ResolveBuild(ctx, config.Organization, config.Pipeline, config.Build, config.Branch)
+		if err != nil {
+			return fmt.Errorf("failed to resolve build: %w", err)
+		}
+		location := buildkitelogs.JobLocation{Org: config.Organization, Pipeline: config.Pipeline, Build: build, Job: config.Job}
+		if config.Step != "" {
+			if location, err = client.ResolveStep(ctx, config.Organization, config.Pipeline, build, config.Step); err != nil {
+				return fmt.Errorf("failed to resolve step: %w", err)
+			}
+		}
+		if result, err = client.VerifyCache(ctx, location); err != nil {
+			return err
+		}
+	}
+
+	if config.Format == "json" {
+		if err := writeIndentedJSON(os.Stdout, result); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Cached rows:  %d (digest %s)\n", result.CachedRows, result.CachedDigest)
+		fmt.Printf("Raw rows:     %d (digest %s)\n", result.RawRows, result.RawDigest)
+		if result.Match {
+			fmt.Println("Result:       match")
+		} else {
+			fmt.Printf("Result:       mismatch at row %d\n", result.FirstMismatch)
+		}
+	}
+	if !result.Match {
+		return fmt.Errorf("cached log does not match the raw log")
+	}
+	return nil
+}
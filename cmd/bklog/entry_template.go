@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+)
+
+// entryTemplateData is the value a -template is executed with for each output entry
+type entryTemplateData struct {
+	RowNumber int64     // 0-based row position in the Parquet file
+	Timestamp time.Time // Zero when the entry has no timestamp
+	Content   string    // Content, ANSI-stripped with -strip-ansi
+	Group     string    // Group name, ANSI-stripped with -strip-ansi
+	IsGroup   bool      // The entry is a group header
+	Severity  string    // Inferred severity: info, warn or error
+	Match     bool      // The entry is a search match rather than context
+}
+
+// parseEntryTemplate parses a -template, rejecting references to fields that don't exist
+func parseEntryTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("entry").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -template: %w", err)
+	}
+	// Unknown fields only fail on execution, so try it once up front
+	if err := tmpl.Execute(io.Discard, entryTemplateData{}); err != nil {
+		return nil, fmt.Errorf("invalid -template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// writeTemplateEntry writes entry to w using tmpl, followed by a newline
+func writeTemplateEntry(w io.Writer, tmpl *template.Template, entry buildkitelogs.ParquetLogEntry, match, stripANSI bool) error {
+	data := entryTemplateData{
+		RowNumber: entry.RowNumber,
+		Content:   entry.CleanContent(stripANSI),
+		Group:     entry.CleanGroup(stripANSI),
+		IsGroup:   entry.IsGroup(),
+		Severity:  entry.InferredSeverity().String(),
+		Match:     match,
+	}
+	if entry.HasTime() {
+		data.Timestamp = time.UnixMilli(entry.Timestamp)
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to execute -template: %w", err)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
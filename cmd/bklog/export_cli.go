@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func handleExportCommand() {
+	var config Config
+
+	exportFlags := flag.NewFlagSet("export", flag.ExitOnError)
+	exportFlags.StringVar(&config.FilePath, "file", "", "Path to Buildkite log file, optionally gzip, zstd or bzip2 compressed (use this OR API parameters)")
+	exportFlags.StringVar(&config.OTLPEndpoint, "otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "Export entries as OpenTelemetry log records to this OTLP/HTTP endpoint (e.g., http://localhost:4318; default OTEL_EXPORTER_OTLP_ENDPOINT)")
+	exportFlags.StringVar(&config.Filter, "filter", "", "Filter entries by type: command, group")
+	exportFlags.BoolVar(&config.DropHeartbeats, "drop-heartbeats", false, "Drop agent heartbeat/keepalive entries")
+	exportFlags.BoolVar(&config.ShowSummary, "summary", false, "Show processing summary at the end")
+	exportFlags.IntVar(&config.MaxLineBytes, "max-line-bytes", logparser.DefaultMaxLineBytes, "Maximum bytes allowed in a single log line")
+	exportFlags.BoolVar(&config.TruncateLongLines, "truncate-long-lines", false, "Truncate log lines that exceed -max-line-bytes instead of returning an error")
+	// Buildkite API parameters
+	exportFlags.StringVar(&config.Organization, "org", "", "Buildkite organization slug (for API)")
+	exportFlags.StringVar(&config.Pipeline, "pipeline", "", "Buildkite pipeline slug (for API)")
+	exportFlags.StringVar(&config.Build, "build", "", "Buildkite build number, UUID or \"latest\" (for API)")
+	exportFlags.StringVar(&config.Branch, "branch", "", "Branch to select the most recent build from (with -build latest)")
+	exportFlags.StringVar(&config.Job, "job", "", "Buildkite job ID (for API)")
+	exportFlags.StringVar(&config.Step, "step", "", "Step key or label to resolve within the build (for API, instead of -job)")
+
+	exportFlags.Usage = func() {
+		fmt.Printf("Usage: %s export [options]\n\n", os.Args[0])
+		fmt.Println("Parse a Buildkite log and ship its entries to an observability backend.")
+		fmt.Println("\nYou must provide either:")
+		fmt.Println("  -file <path>     Local log file")
+		fmt.Println("  OR API params:   -org -pipeline -build -job")
+		fmt.Println("\nFor API usage, set BUILDKITE_API_TOKEN environment variable.")
+		fmt.Println("For -otlp-endpoint, OTEL_EXPORTER_OTLP_HEADERS (key1=value1,key2=value2) sets request headers, such as an API key.")
+		fmt.Println("\nOptions:")
+		exportFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s export -file buildkite.log -otlp-endpoint http://localhost:4318\n", os.Args[0])
+		fmt.Printf("  %s export -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -otlp-endpoint http://localhost:4318\n", os.Args[0])
+	}
+
+	if err := exportFlags.Parse(os.Args[2:]); err != nil {
+		os.Exit(1)
+	}
+
+	hasFile := config.FilePath != ""
+	hasAPIParams := config.Organization != "" || config.Pipeline != "" || config.Build != "" || config.Job != "" || config.Step != ""
+
+	if hasFile == hasAPIParams {
+		fmt.Fprintf(os.Stderr, "Error: Must provide either -file or API parameters (-org, -pipeline, -build, -job)\n\n")
+		exportFlags.Usage()
+		os.Exit(1)
+	}
+
+	if config.OTLPEndpoint == "" {
+		fmt.Fprintf(os.Stderr, "Error: -otlp-endpoint is required\n\n")
+		exportFlags.Usage()
+		os.Exit(1)
+	}
+
+	if hasAPIParams {
+		if err := validateAPIFlags(config.Organization, config.Pipeline, &config.Build, config.Branch, &config.Job, config.Step); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+			exportFlags.Usage()
+			os.Exit(1)
+		}
+	}
+
+	if err := runParse(context.Background(), &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func exportToOTLPSeq2(ctx context.Context, reader io.Reader, parser *logparser.Parser, config *Config, attributes map[string]string, summary *ProcessingSummary) error {
+	headers, err := parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS: %w", err)
+	}
+	sink, err := buildkitelogs.NewOTLPSink(ctx, buildkitelogs.OTLPOptions{
+		URL:                config.OTLPEndpoint,
+		ResourceAttributes: attributes,
+		Headers:            headers,
+	})
+	if err != nil {
+		return err
+	}
+	if err := exportToLogWriterSeq2(reader, parser, config, sink, summary); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d entries to %s\n", sink.Exported(), config.OTLPEndpoint)
+	return nil
+}
+
+// parseOTLPHeaders parses headers in the OTEL_EXPORTER_OTLP_HEADERS format: comma
+// separated key=value pairs with URL-encoded values.
+func parseOTLPHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for pair := range strings.SplitSeq(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("header %q is not key=value", pair)
+		}
+		decoded, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("header %q: %w", key, err)
+		}
+		headers[strings.TrimSpace(key)] = decoded
+	}
+	return headers, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseOTLPHeaders(t *testing.T) {
+	headers, err := parseOTLPHeaders("api-key=abc%3D%3D, x-tenant = team-a ,")
+	if err != nil {
+		t.Fatalf("parseOTLPHeaders: %v", err)
+	}
+	if want := map[string]string{"api-key": "abc==", "x-tenant": "team-a"}; !maps.Equal(headers, want) {
+		t.Errorf("headers = %v, want %v", headers, want)
+	}
+
+	if _, err := parseOTLPHeaders("no-value"); err == nil {
+		t.Error("parseOTLPHeaders accepted a header without a value")
+	}
+}
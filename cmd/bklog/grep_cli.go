@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+)
+
+// GrepConfig holds configuration for the grep command
+type GrepConfig struct {
+	Files         []string // Parquet files or glob patterns
+	Pattern       string
+	CaseSensitive bool
+	InvertMatch   bool
+	Context       int
+	LimitMatches  int
+	Format        string // "text", "json"
+}
+
+func handleGrepCommand() {
+	var config GrepConfig
+
+	grepFlags := flag.NewFlagSet("grep", flag.ExitOnError)
+	grepFlags.StringVar(&config.Pattern, "pattern", "", "Regex pattern to search for")
+	grepFlags.BoolVar(&config.CaseSensitive, "case-sensitive", false, "Case-sensitive search")
+	grepFlags.BoolVar(&config.InvertMatch, "invert-match", false, "Show non-matching lines")
+	grepFlags.IntVar(&config.Context, "C", 0, "Show NUM lines before and after each match")
+	grepFlags.IntVar(&config.LimitMatches, "limit", 0, "Stop after this many matches across all files (0 = no limit)")
+	grepFlags.StringVar(&config.Format, "format", "text", "Output format: text, json")
+
+	grepFlags.Usage = func() {
+		fmt.Printf("Usage: %s grep [options] <file.parquet|glob>...\n\n", os.Args[0])
+		fmt.Println("Search several Parquet log files at once, such as every cached job of a build.")
+		fmt.Println("Matches are printed as file:row: content, with the job ID of cached logs.")
+		fmt.Println("\nOptions:")
+		grepFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s grep -pattern \"FAIL\" ~/.bklog/myorg-mypipeline-123-*.parquet\n", os.Args[0])
+		fmt.Printf("  %s grep -pattern \"panic:\" -C 3 'logs/*.parquet'\n", os.Args[0])
+		fmt.Printf("  %s grep -pattern \"OOMKilled\" -format json job1.parquet job2.parquet\n", os.Args[0])
+	}
+
+	if err := grepFlags.Parse(os.Args[2:]); err != nil {
+		os.Exit(1)
+	}
+	config.Files = grepFlags.Args()
+
+	if config.Pattern == "" || len(config.Files) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: -pattern and at least one file are required\n\n")
+		grepFlags.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	if err := runGrep(ctx, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runGrep(ctx context.Context, config *GrepConfig) error {
+	// Expand patterns the shell left alone, e.g. quoted globs
+	var paths []string
+	for _, arg := range config.Files {
+		if !strings.ContainsAny(arg, "*?[") {
+			paths = append(paths, arg)
+			continue
+		}
+		reader, err := buildkitelogs.NewMultiReaderGlob(arg)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, reader.Files()...)
+	}
+	reader := buildkitelogs.NewMultiReader(paths...)
+	defer reader.Close()
+
+	start := time.Now()
+	options := buildkitelogs.SearchOptions{
+		Pattern:       config.Pattern,
+		CaseSensitive: config.CaseSensitive,
+		InvertMatch:   config.InvertMatch,
+		BeforeContext: config.Context,
+		AfterContext:  config.Context,
+	}
+
+	matches := 0
+	for result, err := range reader.SearchEntriesIter(ctx, options) {
+		if err != nil {
+			return err
+		}
+		if config.Format == "json" {
+			if err := writeJSONLines([]buildkitelogs.MultiFileSearchResult{result}, os.Stdout); err != nil {
+				return err
+			}
+		} else {
+			writeGrepResult(result, config.Context > 0)
+		}
+		matches++
+		if config.LimitMatches > 0 && matches >= config.LimitMatches {
+			break
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "\n%d matches in %d files in %s\n", matches, len(paths), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// writeGrepResult prints a match grep-style: file:row: content, with context lines as
+// file-row- content and "--" between results with context.
+func writeGrepResult(result buildkitelogs.MultiFileSearchResult, withContext bool) {
+	source := result.File
+	if result.JobID != "" {
+		source += " (" + result.JobID + ")"
+	}
+	line := func(entry buildkitelogs.ParquetLogEntry, sep string) {
+		fmt.Printf("%s%s%d%s %s\n", source, sep, entry.RowNumber, sep, entry.CleanContent(true))
+	}
+
+	for _, entry := range result.BeforeContext {
+		line(entry, "-")
+	}
+	line(result.Match, ":")
+	for _, entry := range result.AfterContext {
+		line(entry, "-")
+	}
+	if withContext {
+		fmt.Println("--")
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// historyEnv enables the history: "1" or "true" records to ~/.bklog/history.jsonl, any
+// other value is the path of the history file to record to.
+const historyEnv = "BKLOG_HISTORY"
+
+// HistoryEntry is one recorded invocation, a line of the history file
+type HistoryEntry struct {
+	Time       time.Time `json:"time"`
+	Command    string    `json:"command"`
+	Args       []string  `json:"args"`
+	Op         string    `json:"op,omitempty"`
+	File       string    `json:"file,omitempty"`
+	Org        string    `json:"org,omitempty"`
+	Pipeline   string    `json:"pipeline,omitempty"`
+	Build      string    `json:"build,omitempty"`
+	Job        string    `json:"job,omitempty"`
+	Step       string    `json:"step,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	Version    string    `json:"version"`
+}
+
+// HistoryConfig holds configuration for the history command
+type HistoryConfig struct {
+	Limit  int    // Number of most recent entries to list
+	Replay int    // Entry number to run again (0 = list)
+	Format string // Output format for listing: text, json
+}
+
+func handleHistoryCommand() {
+	var config HistoryConfig
+
+	historyFlags := flag.NewFlagSet("history", flag.ExitOnError)
+	historyFlags.IntVar(&config.Limit, "n", 20, "Number of most recent entries to list (0 = all)")
+	historyFlags.IntVar(&config.Replay, "replay", 0, "Run the entry with this number again")
+	historyFlags.StringVar(&config.Format, "format", "text", "Output format for listing: text, json")
+
+	historyFlags.Usage = func() {
+		fmt.Printf("Usage: %s history [options]\n\n", os.Args[0])
+		fmt.Println("List or replay previous bklog invocations.")
+		fmt.Printf("\nInvocations are only recorded while %s is set: \"1\" records to ~/.bklog/history.jsonl,\n", historyEnv)
+		fmt.Println("any other value is the path of the history file. Only invocations that succeed are recorded.")
+		fmt.Println("\nOptions:")
+		historyFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s history\n", os.Args[0])
+		fmt.Printf("  %s history -n 5 -format json\n", os.Args[0])
+		fmt.Printf("  %s history -replay 12\n", os.Args[0])
+	}
+
+	if err := historyFlags.Parse(os.Args[2:]); err != nil {
+		os.Exit(1)
+	}
+
+	if config.Format != "text" && config.Format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid format %q (must be text or json)\n\n", config.Format)
+		historyFlags.Usage()
+		os.Exit(1)
+	}
+	if config.Limit < 0 || config.Replay < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -n and -replay must not be negative\n\n")
+		historyFlags.Usage()
+		os.Exit(1)
+	}
+
+	if err := runHistory(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runHistory(config *HistoryConfig) error {
+	path, _, err := historyPath()
+	if err != nil {
+		return err
+	}
+	entries, err := readHistory(path)
+	if err != nil {
+		return err
+	}
+
+	if config.Replay > 0 {
+		if config.Replay > len(entries) {
+			return fmt.Errorf("no history entry %d (%d recorded)", config.Replay, len(entries))
+		}
+		return replayHistory(entries[config.Replay-1], config.Replay)
+	}
+
+	first := 0
+	if config.Limit > 0 && len(entries) > config.Limit {
+		first = len(entries) - config.Limit
+	}
+
+	if config.Format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, entry := range entries[first:] {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stderr, "No history recorded in %s (set %s=1 to record it)\n", path, historyEnv)
+		return nil
+	}
+	for i, entry := range entries[first:] {
+		fmt.Printf("%4d  %s  %8s  %s\n",
+			first+i+1,
+			entry.Time.Local().Format("2006-01-02 15:04:05"),
+			(time.Duration(entry.DurationMS) * time.Millisecond).String(),
+			historyCommandLine(entry))
+	}
+	return nil
+}
+
+// replayHistory runs a recorded invocation again, recording the replay as a new entry
+func replayHistory(entry HistoryEntry, number int) error {
+	if entry.Command == "" || entry.Command == "history" {
+		return fmt.Errorf("history entry %d cannot be replayed", number)
+	}
+
+	fmt.Fprintf(os.Stderr, "Replaying #%d: %s\n", number, historyCommandLine(entry))
+
+	os.Args = append([]string{os.Args[0], entry.Command}, entry.Args...)
+	start := time.Now()
+	runSubcommand(entry.Command)
+	recordHistory(entry.Command, entry.Args, start)
+	return nil
+}
+
+// recordHistory appends a successful invocation to the history file when BKLOG_HISTORY
+// is set. Failing to record only warns, so the history never breaks a command.
+func recordHistory(command string, args []string, start time.Time) {
+	switch command {
+	case "history", "version", "-v", "--version", "help", "-h", "--help":
+		return
+	}
+
+	path, enabled, err := historyPath()
+	if !enabled {
+		return
+	}
+	if err == nil {
+		err = appendHistory(path, newHistoryEntry(command, args, start, time.Now()))
+	}
+	if err != nil {
+		logger.Warn("failed to record history", "error", err)
+	}
+}
+
+func newHistoryEntry(command string, args []string, start, end time.Time) HistoryEntry {
+	entry := HistoryEntry{
+		Time:       start.UTC(),
+		Command:    command,
+		Args:       args,
+		DurationMS: end.Sub(start).Milliseconds(),
+		Version:    version,
+	}
+	if entry.Args == nil {
+		entry.Args = []string{}
+	}
+
+	targets := map[string]*string{
+		"op":       &entry.Op,
+		"file":     &entry.File,
+		"org":      &entry.Org,
+		"pipeline": &entry.Pipeline,
+		"build":    &entry.Build,
+		"job":      &entry.Job,
+		"step":     &entry.Step,
+	}
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		target, ok := targets[name]
+		if !ok || !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		*target = value
+	}
+	return entry
+}
+
+// historyPath returns the history file path and whether recording is enabled
+func historyPath() (string, bool, error) {
+	value := os.Getenv(historyEnv)
+	switch strings.ToLower(value) {
+	case "", "0", "false":
+		path, err := defaultHistoryPath()
+		return path, false, err
+	case "1", "true":
+		path, err := defaultHistoryPath()
+		return path, true, err
+	}
+	return value, true, nil
+}
+
+func defaultHistoryPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".bklog", "history.jsonl"), nil
+}
+
+func appendHistory(path string, entry HistoryEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) //nolint:gosec // user-controlled history path
+	if err != nil {
+		return err
+	}
+	// A single write keeps lines from concurrent invocations whole
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// readHistory reads the history file, skipping lines that fail to decode
+func readHistory(path string) ([]HistoryEntry, error) {
+	file, err := os.Open(path) //nolint:gosec // user-controlled history path
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer file.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return entries, nil
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./:=@%+,-]+$`)
+
+// historyCommandLine formats an entry as a shell command line that can be shared and pasted
+func historyCommandLine(entry HistoryEntry) string {
+	parts := []string{"bklog", entry.Command}
+	for _, arg := range entry.Args {
+		if !shellSafe.MatchString(arg) {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryEntryTargets(t *testing.T) {
+	start := time.Date(2025, 4, 22, 10, 0, 0, 0, time.UTC)
+	args := []string{"-org", "myorg", "--pipeline=mypipe", "-build", "123", "-job", "0190a7a4-5b3c-7d1e-9f00-1234567890ab", "-op", "search", "-pattern", "it's broken"}
+
+	entry := newHistoryEntry("query", args, start, start.Add(1500*time.Millisecond))
+
+	if entry.Org != "myorg" || entry.Pipeline != "mypipe" || entry.Build != "123" || entry.Op != "search" {
+		t.Errorf("unexpected targets: %+v", entry)
+	}
+	if entry.Job != "0190a7a4-5b3c-7d1e-9f00-1234567890ab" {
+		t.Errorf("Job = %q", entry.Job)
+	}
+	if entry.DurationMS != 1500 {
+		t.Errorf("DurationMS = %d, want 1500", entry.DurationMS)
+	}
+
+	want := `bklog query -org myorg --pipeline=mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -op search -pattern 'it'\''s broken'`
+	if got := historyCommandLine(entry); got != want {
+		t.Errorf("historyCommandLine() = %s, want %s", got, want)
+	}
+}
+
+func TestHistoryRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.jsonl")
+	t.Setenv(historyEnv, path)
+
+	start := time.Now()
+	recordHistory("query", []string{"-file", "logs.parquet", "-op", "info"}, start)
+	recordHistory("history", []string{"-n", "5"}, start)
+	recordHistory("parse", []string{"-file", "build.log"}, start)
+
+	entries, err := readHistory(path)
+	if err != nil {
+		t.Fatalf("readHistory: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2 (history invocations are not recorded)", len(entries))
+	}
+	if entries[0].Command != "query" || entries[0].File != "logs.parquet" || entries[0].Op != "info" {
+		t.Errorf("entry 0 = %+v", entries[0])
+	}
+	if entries[1].Command != "parse" || entries[1].File != "build.log" {
+		t.Errorf("entry 1 = %+v", entries[1])
+	}
+
+	t.Setenv(historyEnv, "")
+	recordHistory("query", nil, start)
+	if entries, _ := readHistory(path); len(entries) != 2 {
+		t.Errorf("recorded with %s unset", historyEnv)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+)
+
+// IngestConfig holds configuration for the ingest command
+type IngestConfig struct {
+	RawDir     string // Directory of raw logs laid out as <org>/<pipeline>/<build>/<job>.log
+	DatasetDir string // Dataset directory to write to
+	Force      bool   // Re-parse logs already in the dataset
+	Quiet      bool   // Only print the summary
+}
+
+func handleIngestCommand() {
+	var config IngestConfig
+
+	ingestFlags := flag.NewFlagSet("ingest", flag.ExitOnError)
+	ingestFlags.StringVar(&config.DatasetDir, "o", "", "Dataset directory to write to (required)")
+	ingestFlags.BoolVar(&config.Force, "force", false, "Re-parse logs already in the dataset")
+	ingestFlags.BoolVar(&config.Quiet, "quiet", false, "Only print the summary")
+
+	ingestFlags.Usage = func() {
+		fmt.Printf("Usage: %s ingest <rawdir> -o <dataset> [options]\n\n", os.Args[0])
+		fmt.Println("Convert a directory of raw logs into a Hive-partitioned Parquet dataset")
+		fmt.Println("(org=<org>/pipeline=<pipeline>/build=<build>/<job>.parquet) with a manifest.json,")
+		fmt.Println("ready to query with DuckDB, Spark and other data lake tools.")
+		fmt.Println("\nRaw logs must be laid out as <rawdir>/<org>/<pipeline>/<build>/<job>.log; other files")
+		fmt.Println("are ignored. Ingesting into an existing dataset adds new logs and skips ones already in it.")
+		fmt.Println("\nOptions:")
+		ingestFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s ingest rawlogs/ -o dataset/\n", os.Args[0])
+		fmt.Printf("  %s ingest rawlogs/ -o dataset/ -force\n", os.Args[0])
+		fmt.Println("  duckdb -c \"SELECT pipeline, count(*) FROM read_parquet('dataset/**/*.parquet', hive_partitioning = true) GROUP BY pipeline\"")
+	}
+
+	// Accept the raw directory before or after the options
+	args := os.Args[2:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		config.RawDir, args = args[0], args[1:]
+	}
+	if err := ingestFlags.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	if config.RawDir == "" && ingestFlags.NArg() > 0 {
+		config.RawDir = ingestFlags.Arg(0)
+	}
+
+	if config.RawDir == "" || config.DatasetDir == "" {
+		fmt.Fprintf(os.Stderr, "Error: a raw log directory and -o are required\n\n")
+		ingestFlags.Usage()
+		os.Exit(1)
+	}
+	if ingestFlags.NArg() > 1 || (ingestFlags.NArg() == 1 && config.RawDir != ingestFlags.Arg(0)) {
+		fmt.Fprintf(os.Stderr, "Error: only one raw log directory can be ingested at a time\n\n")
+		ingestFlags.Usage()
+		os.Exit(1)
+	}
+
+	if err := runIngest(context.Background(), &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runIngest(ctx context.Context, config *IngestConfig) error {
+	ingested, skipped := 0, 0
+	manifest, err := buildkitelogs.IngestRawLogs(ctx, config.RawDir, config.DatasetDir, buildkitelogs.IngestOptions{
+		Force: config.Force,
+		OnFile: func(file buildkitelogs.DatasetFile, wasSkipped bool) {
+			if wasSkipped {
+				skipped++
+				return
+			}
+			ingested++
+			if !config.Quiet {
+				fmt.Printf("%s (%d entries)\n", file.Path, file.Rows)
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Ingested %d logs, skipped %d already in the dataset; %s lists %d files\n",
+		ingested, skipped, buildkitelogs.DatasetManifestFile, len(manifest.Files))
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+)
+
+// JobConfig holds configuration for job subcommands
+type JobConfig struct {
+	Format string // "text", "json"
+	// Buildkite API parameters
+	Organization string
+	Pipeline     string
+	Build        string
+	Branch       string
+	Job          string
+	Step         string
+	CacheURL     string
+}
+
+func handleJobCommand() {
+	if len(os.Args) < 3 {
+		printJobUsage()
+		os.Exit(1)
+	}
+
+	switch os.Args[2] {
+	case "info":
+		handleJobInfoCommand()
+	case "help", "-h", "--help":
+		printJobUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown job subcommand: %s\n\n", os.Args[2]) //nolint:gosec // CLI tool, not a web context
+		printJobUsage()
+		os.Exit(1)
+	}
+}
+
+func printJobUsage() {
+	fmt.Printf("Usage: %s job <subcommand> [options]\n\n", os.Args[0])
+	fmt.Println("Subcommands:")
+	fmt.Println("  info      Show job state, exit status, agent, timings, retries and cache status")
+}
+
+func handleJobInfoCommand() {
+	var config JobConfig
+
+	infoFlags := flag.NewFlagSet("job info", flag.ExitOnError)
+	infoFlags.StringVar(&config.Format, "format", "text", "Output format: text, json")
+	infoFlags.StringVar(&config.Organization, "org", "", "Buildkite organization slug")
+	infoFlags.StringVar(&config.Pipeline, "pipeline", "", "Buildkite pipeline slug")
+	infoFlags.StringVar(&config.Build, "build", "", "Buildkite build number, UUID or \"latest\"")
+	infoFlags.StringVar(&config.Branch, "branch", "", "Branch to select the most recent build from (with -build latest)")
+	infoFlags.StringVar(&config.Job, "job", "", "Buildkite job ID")
+	infoFlags.StringVar(&config.Step, "step", "", "Step key or label to resolve within the build (instead of -job)")
+	infoFlags.StringVar(&config.CacheURL, "cache-url", "", "Cache storage URL (file://path, s3://bucket, etc)")
+
+	infoFlags.Usage = func() {
+		fmt.Printf("Usage: %s job info [options]\n\n", os.Args[0])
+		fmt.Println("Show job metadata and cache status without downloading the log.")
+		fmt.Println("\nSet BUILDKITE_API_TOKEN environment variable for API access.")
+		fmt.Println("\nOptions:")
+		infoFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s job info -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab\n", os.Args[0])
+		fmt.Printf("  %s job info -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -format json\n", os.Args[0])
+		fmt.Printf("  %s job info -org myorg -pipeline mypipe -build 123 -step \"Run integration tests\"\n", os.Args[0])
+	}
+
+	if err := infoFlags.Parse(os.Args[3:]); err != nil {
+		os.Exit(1)
+	}
+
+	if err := validateAPIFlags(config.Organization, config.Pipeline, &config.Build, config.Branch, &config.Job, config.Step); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+		infoFlags.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	if err := runJobInfo(ctx, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runJobInfo(ctx context.Context, config *JobConfig) error {
+	apiToken := os.Getenv("BUILDKITE_API_TOKEN")
+	if apiToken == "" {
+		return fmt.Errorf("BUILDKITE_API_TOKEN environment variable is required for API access")
+	}
+
+	buildkiteClient := buildkitelogs.NewBuildkiteAPIClient(apiToken, version)
+	client, err := buildkitelogs.NewClientWithAPI(ctx, buildkiteClient, config.CacheURL, buildkitelogs.WithLogger(logger))
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	build, err := client.ResolveBuild(ctx, config.Organization, config.Pipeline, config.Build, config.Branch)
+	if err != nil {
+		return fmt.Errorf("failed to resolve build: %w", err)
+	}
+	job := config.Job
+	if config.Step != "" {
+		location, err := client.ResolveStep(ctx, config.Organization, config.Pipeline, build, config.Step)
+		if err != nil {
+			return fmt.Errorf("failed to resolve step: %w", err)
+		}
+		build, job = location.Build, location.Job
+	}
+
+	info, err := client.JobInfo(ctx, config.Organization, config.Pipeline, build, job)
+	if err != nil {
+		return err
+	}
+
+	if config.Format == "json" {
+		return writeIndentedJSON(os.Stdout, info)
+	}
+
+	printJobInfo(info)
+	return nil
+}
+
+// printJobInfo writes a human-readable summary of a job
+func printJobInfo(info *buildkitelogs.JobInfo) {
+	status := info.Status
+
+	fmt.Printf("Job:          %s\n", status.ID)
+	if status.Name != "" {
+		fmt.Printf("Name:         %s\n", status.Name)
+	}
+	fmt.Printf("Build:        %s/%s/%s\n", info.Org, info.Pipeline, info.Build)
+	fmt.Printf("State:        %s (terminal: %t)\n", status.State, status.IsTerminal)
+	if status.ExitStatus != nil {
+		fmt.Printf("Exit status:  %d\n", *status.ExitStatus)
+	}
+	if status.AgentName != "" {
+		fmt.Printf("Agent:        %s", status.AgentName)
+		if status.AgentHostname != "" {
+			fmt.Printf(" (%s)", status.AgentHostname)
+		}
+		fmt.Println()
+	}
+	printJobTime("Created:", status.CreatedAt)
+	printJobTime("Scheduled:", status.ScheduledAt)
+	printJobTime("Started:", status.StartedAt)
+	printJobTime("Finished:", status.FinishedAt)
+	if d := info.Duration(); d > 0 {
+		fmt.Printf("Duration:     %s\n", d.Round(time.Second))
+	}
+	if status.RetriesCount > 0 || status.Retried {
+		fmt.Printf("Retries:      %d", status.RetriesCount)
+		if status.RetriedInJobID != "" {
+			fmt.Printf(" (retried in %s)", status.RetriedInJobID)
+		}
+		fmt.Println()
+	}
+	if status.RetryOfJobID != "" {
+		fmt.Printf("Retry of:     %s\n", status.RetryOfJobID)
+	}
+	if status.WebURL != "" {
+		fmt.Printf("URL:          %s\n", status.WebURL)
+	}
+
+	cache := info.Cache
+	if !cache.Cached {
+		fmt.Printf("Cache:        not cached (%s)\n", cache.BlobKey)
+		return
+	}
+	fmt.Printf("Cache:        cached (%s)\n", cache.BlobKey)
+	if !cache.CachedAt.IsZero() {
+		fmt.Printf("  Cached at:  %s\n", cache.CachedAt.Format(time.RFC3339))
+	}
+	if cache.IsTerminal {
+		fmt.Printf("  Expires:    never (terminal job)\n")
+	} else if cache.TTL != "" {
+		fmt.Printf("  TTL:        %s\n", cache.TTL)
+	}
+	if cache.RowCount > 0 {
+		fmt.Printf("  Rows:       %d\n", cache.RowCount)
+	}
+	if cache.ParquetSize > 0 {
+		fmt.Printf("  Size:       %d bytes\n", cache.ParquetSize)
+	}
+}
+
+func printJobTime(label string, t *time.Time) {
+	if t == nil {
+		return
+	}
+	fmt.Printf("%-13s %s\n", label, t.Format(time.RFC3339))
+}
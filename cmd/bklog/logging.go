@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// logger receives the diagnostics of bklog and of the clients and readers it creates. The
+// global -log-level and -log-format options, or BKLOG_LOG_LEVEL and BKLOG_LOG_FORMAT,
+// configure it.
+var logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+// parseGlobalOptions parses the options given before the subcommand, as in
+// "bklog -log-level debug -log-format json query ...", and returns a logger writing to w
+// as they ask, with the subcommand and its arguments.
+func parseGlobalOptions(args []string, w io.Writer) (*slog.Logger, []string, error) {
+	globalFlags := flag.NewFlagSet("bklog", flag.ContinueOnError)
+	globalFlags.SetOutput(io.Discard)
+	level := globalFlags.String("log-level", envOr("BKLOG_LOG_LEVEL", "warn"), "Diagnostics to log: debug, info, warn or error")
+	format := globalFlags.String("log-format", envOr("BKLOG_LOG_FORMAT", "text"), "Format of logged diagnostics: text or json")
+	// Only the leading options are global, so "bklog -h" and the subcommand's own options
+	// are left to the subcommand
+	n := 0
+	for n < len(args) && strings.HasPrefix(args[n], "-") {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(args[n], "-"), "=")
+		if name != "log-level" && name != "log-format" {
+			break
+		}
+		n++
+		if !hasValue {
+			n++
+		}
+	}
+	n = min(n, len(args))
+	if err := globalFlags.Parse(args[:n]); err != nil {
+		return nil, nil, err
+	}
+
+	l, err := newLogger(w, *level, *format)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, args[n:], nil
+}
+
+// newLogger returns a logger writing records at level or above to w, as text or json.
+func newLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	var minLevel slog.Level
+	if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid -log-level %q (want debug, info, warn or error)", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: minLevel}
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("invalid -log-format %q (want text or json)", format)
+}
+
+// envOr returns the value of the environment variable key, or fallback if it is empty.
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
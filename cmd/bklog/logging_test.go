@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestParseGlobalOptions(t *testing.T) {
+	var buf bytes.Buffer
+	l, args, err := parseGlobalOptions([]string{"-log-level", "debug", "--log-format=json", "query", "-log-level", "x"}, &buf)
+	if err != nil {
+		t.Fatalf("parseGlobalOptions: %v", err)
+	}
+	if want := []string{"query", "-log-level", "x"}; !slices.Equal(args, want) {
+		t.Errorf("args = %q, want %q", args, want)
+	}
+
+	l.Debug("served job log", "outcome", "hit")
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("log record %q is not JSON: %v", buf.String(), err)
+	}
+	if record["level"] != "DEBUG" || record["outcome"] != "hit" {
+		t.Errorf("record = %v", record)
+	}
+}
+
+func TestParseGlobalOptions_Defaults(t *testing.T) {
+	t.Setenv("BKLOG_LOG_LEVEL", "")
+	t.Setenv("BKLOG_LOG_FORMAT", "")
+
+	var buf bytes.Buffer
+	l, args, err := parseGlobalOptions([]string{"-h"}, &buf)
+	if err != nil {
+		t.Fatalf("parseGlobalOptions: %v", err)
+	}
+	if !slices.Equal(args, []string{"-h"}) {
+		t.Errorf("args = %q, want the help flag left to the subcommand", args)
+	}
+	l.Info("not logged")
+	l.Warn("logged")
+	if got := buf.String(); !bytes.Contains(buf.Bytes(), []byte("level=WARN msg=logged")) || bytes.Contains(buf.Bytes(), []byte("not logged")) {
+		t.Errorf("output = %q, want only the warning as text", got)
+	}
+}
+
+func TestParseGlobalOptions_Invalid(t *testing.T) {
+	for _, args := range [][]string{{"-log-level", "loud", "query"}, {"-log-format", "xml", "query"}} {
+		if _, _, err := parseGlobalOptions(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("parseGlobalOptions(%q) succeeded, want an error", args)
+		}
+	}
+}
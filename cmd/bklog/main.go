@@ -2,7 +2,12 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
+	"slices"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
 )
 
 // version can be overridden at build time using:
@@ -12,18 +17,38 @@ var version = "dev"
 type Config struct {
 	FilePath          string
 	OutputJSON        bool
+	Format            string // Output format: text, json, parquet-json, es-bulk, csv, tsv
+	Columns           string // Comma separated columns of csv and tsv output
 	Filter            string
 	ShowSummary       bool
 	ShowGroups        bool
 	ParquetFile       string
 	JSONLFile         string
+	SQLiteFile        string // SQLite database export
+	BulkFile          string // Elasticsearch/OpenSearch _bulk NDJSON export
+	BulkIndex         string // Index named in the _bulk actions
+	LokiURL           string // Grafana Loki to push entries to
+	LokiTenant        string // X-Scope-OrgID for multi-tenant Loki
+	ClickHouseURL     string // ClickHouse HTTP interface to insert entries into
+	ClickHouseTable   string // ClickHouse table to insert into
+	OTLPEndpoint      string // OTLP/HTTP endpoint to export entries to
 	MaxLineBytes      int
 	TruncateLongLines bool
+	QuarantineFile    string // File to write lines that did not parse cleanly to
+	DropHeartbeats    bool
+	SortByTime        bool   // Sort Parquet exports by timestamp
+	OmitContent       bool   // Write Parquet exports without log content
+	Compression       string // Codec of Parquet exports
+	CompressionLevel  int    // Level of the codec, 0 for its default
+	RowGroupSize      int    // Rows per Parquet row group, 0 for one per batch
+	Dictionary        bool   // Dictionary-encode Parquet columns
 	// Buildkite API parameters
 	Organization string
 	Pipeline     string
 	Build        string
+	Branch       string
 	Job          string
+	Step         string
 }
 
 type ProcessingSummary struct {
@@ -32,16 +57,38 @@ type ProcessingSummary struct {
 	BytesProcessed  int64
 	EntriesWithTime int
 	Sections        int
+	Heartbeats      int
+	// HeartbeatsDropped is true when heartbeat entries were excluded from output
+	HeartbeatsDropped bool
 }
 
 func main() {
+	l, args, err := parseGlobalOptions(os.Args[1:], os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+		printUsage()
+		os.Exit(1)
+	}
+	logger = l
+	slog.SetDefault(l)
+	// Subcommands read their options from os.Args[2:]
+	os.Args = append([]string{os.Args[0]}, args...)
 	if len(os.Args) < 2 {
 		printUsage()
 		os.Exit(1)
 	}
 
 	subcommand := os.Args[1]
+	args = slices.Clone(os.Args[2:])
+	start := time.Now()
+
+	runSubcommand(subcommand)
+	recordHistory(subcommand, args, start)
+}
 
+// runSubcommand runs the subcommand with its options in os.Args[2:]. Subcommands exit
+// the process on failure, so it only returns when the subcommand succeeded.
+func runSubcommand(subcommand string) {
 	switch subcommand {
 	case "parse":
 		handleParseCommand()
@@ -49,9 +96,34 @@ func main() {
 		handleQueryCommand()
 	case "debug":
 		handleDebugCommand()
+	case "bundle":
+		handleBundleCommand()
+	case "job":
+		handleJobCommand()
+	case "cache":
+		handleCacheCommand()
+	case "annotate":
+		handleAnnotateCommand()
+	case "scan":
+		handleScanCommand()
+	case "grep":
+		handleGrepCommand()
+	case "reparse":
+		handleReparseCommand()
+	case "advise":
+		handleAdviseCommand()
+	case "history":
+		handleHistoryCommand()
+	case "analytics":
+		handleAnalyticsCommand()
+	case "ingest":
+		handleIngestCommand()
+	case "export":
+		handleExportCommand()
+	case "serve":
+		handleServeCommand()
 	case "version", "-v", "--version":
 		fmt.Printf("bklog version %s\n", version)
-		return
 	case "help", "-h", "--help":
 		printUsage()
 	default:
@@ -62,15 +134,82 @@ func main() {
 }
 
 func printUsage() {
-	fmt.Printf("Usage: %s <subcommand> [options]\n\n", os.Args[0])
+	fmt.Printf("Usage: %s [-log-level level] [-log-format text|json] <subcommand> [options]\n\n", os.Args[0])
 	fmt.Println("Subcommands:")
 	fmt.Println("  parse     Parse Buildkite log files and export to various formats")
 	fmt.Println("  query     Query Parquet log files (supports local files and Buildkite API)")
 	fmt.Println("  debug     Debug parser issues with raw log inspection")
+	fmt.Println("  bundle    Package file info, groups, errors and the log tail into a zip for support")
+	fmt.Println("  job       Show job metadata and cache status without downloading the log")
+	fmt.Println("  cache     Show where a job's cached log lives, without downloading it")
+	fmt.Println("  scan      Search recent job logs across every pipeline in an organization")
+	fmt.Println("  grep      Search several Parquet log files at once, such as every job of a build")
+	fmt.Println("  annotate  Add, remove or list triage annotations on a job's log entries")
+	fmt.Println("  reparse   Re-parse a log with the current parser and replace its Parquet file")
+	fmt.Println("  advise    Report what makes a log large and recommend how to trim it")
+	fmt.Println("  history   List or replay previous invocations recorded with BKLOG_HISTORY set")
+	fmt.Println("  analytics Summarize log volume, durations and errors per pipeline across a cache")
+	fmt.Println("  ingest    Convert a directory of raw logs into a partitioned Parquet dataset")
+	fmt.Println("  export    Ship a parsed log to an OpenTelemetry (OTLP) endpoint")
+	fmt.Println("  serve     Serve job log searches over HTTP as Server-Sent Events")
 	fmt.Println("  version   Show version information")
 	fmt.Println("  help      Show this help message")
 	fmt.Println("")
+	fmt.Println("Global options:")
+	fmt.Println("  -log-level <level>   Diagnostics to log: debug, info, warn or error (default warn, or BKLOG_LOG_LEVEL)")
+	fmt.Println("  -log-format <format> Format of logged diagnostics: text or json (default text, or BKLOG_LOG_FORMAT)")
+	fmt.Println("")
 	fmt.Printf("Use '%s <subcommand> -h' for subcommand-specific help", os.Args[0])
 }
 
 // handleQueryCommand is now implemented in query_cli.go using the library package
+
+// validateAPIFlags validates API parameters where the job is identified either by -job
+// or by -step (a step key or label resolved within the build), and -branch narrows
+// -build latest. Build and job identifiers are normalized in place, so build URLs and
+// "#123" style numbers are accepted before any API call is made.
+func validateAPIFlags(org, pipeline string, build *string, branch string, job *string, step string) error {
+	if *job != "" && step != "" {
+		return fmt.Errorf("cannot use both -job and -step")
+	}
+	if branch != "" && *build != buildkitelogs.LatestBuild {
+		return fmt.Errorf("-branch can only be used with -build %s", buildkitelogs.LatestBuild)
+	}
+
+	jobOrStep := step
+	if step == "" {
+		jobOrStep = *job
+	}
+	if err := buildkitelogs.ValidateAPIParams(org, pipeline, *build, jobOrStep); err != nil {
+		return err
+	}
+
+	normalized, err := buildkitelogs.NormalizeBuild(*build)
+	if err != nil {
+		return err
+	}
+	*build = normalized
+	if step == "" {
+		if *job, err = buildkitelogs.NormalizeJob(*job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateBuildFlags validates API flags naming a whole build, normalizing the build.
+func validateBuildFlags(org, pipeline string, build *string, branch string) error {
+	if branch != "" && *build != buildkitelogs.LatestBuild {
+		return fmt.Errorf("-branch can only be used with -build %s", buildkitelogs.LatestBuild)
+	}
+	if org == "" || pipeline == "" || *build == "" {
+		return fmt.Errorf("-org, -pipeline and -build are required")
+	}
+
+	normalized, err := buildkitelogs.NormalizeBuild(*build)
+	if err != nil {
+		return err
+	}
+	*build = normalized
+	return nil
+}
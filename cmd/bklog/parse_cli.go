@@ -3,33 +3,59 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"iter"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	buildkitelogs "github.com/buildkite/buildkite-logs"
 	"github.com/buildkite/buildkite-logs/logparser"
+	"github.com/buildkite/buildkite-logs/sqlite"
 )
 
 func handleParseCommand() {
 	var config Config
 
 	parseFlags := flag.NewFlagSet("parse", flag.ExitOnError)
-	parseFlags.StringVar(&config.FilePath, "file", "", "Path to Buildkite log file (use this OR API parameters)")
-	parseFlags.BoolVar(&config.OutputJSON, "json", false, "Output as JSON")
+	parseFlags.StringVar(&config.FilePath, "file", "", "Path to Buildkite log file, optionally gzip, zstd or bzip2 compressed (use this OR API parameters)")
+	parseFlags.BoolVar(&config.OutputJSON, "json", false, "Output as JSON (same as -format json)")
+	parseFlags.StringVar(&config.Format, "format", "text", "Output format: text, json, parquet-json (JSON Lines in the schema of query output), es-bulk (Elasticsearch/OpenSearch _bulk NDJSON), csv, tsv")
+	parseFlags.StringVar(&config.Columns, "columns", "", "Comma separated columns of -format csv or tsv (default: "+strings.Join(buildkitelogs.DefaultCSVColumns, ",")+"; also "+strings.Join(extraCSVColumns(), ",")+")")
 	parseFlags.StringVar(&config.Filter, "filter", "", "Filter entries by type: command, group")
 	parseFlags.BoolVar(&config.ShowSummary, "summary", false, "Show processing summary at the end")
 	parseFlags.BoolVar(&config.ShowGroups, "groups", false, "Show group/section information")
 	parseFlags.StringVar(&config.ParquetFile, "parquet", "", "Export to Parquet file (e.g., output.parquet)")
 	parseFlags.StringVar(&config.JSONLFile, "jsonl", "", "Export to JSON Lines file (e.g., output.jsonl)")
+	parseFlags.StringVar(&config.SQLiteFile, "sqlite", "", "Export to a SQLite database, replacing any file there (e.g., logs.db)")
+	parseFlags.StringVar(&config.BulkFile, "bulk", "", "Export to an Elasticsearch/OpenSearch _bulk NDJSON file (e.g., output.ndjson)")
+	parseFlags.StringVar(&config.LokiURL, "loki-url", "", "Push entries to Grafana Loki at this URL (e.g., http://localhost:3100)")
+	parseFlags.StringVar(&config.LokiTenant, "loki-tenant", "", "Tenant ID for multi-tenant Loki (sent as X-Scope-OrgID)")
+	parseFlags.StringVar(&config.ClickHouseURL, "clickhouse-url", "", "Insert entries into ClickHouse through its HTTP interface at this URL (e.g., http://localhost:8123)")
+	parseFlags.StringVar(&config.ClickHouseTable, "clickhouse-table", buildkitelogs.DefaultClickHouseTable, "ClickHouse table or database.table to insert into (with -clickhouse-url)")
+	parseFlags.StringVar(&config.BulkIndex, "bulk-index", "", "Index to name in the -bulk actions (default: none, set it in the request path)")
+	parseFlags.StringVar(&config.BulkIndex, "index", "", "Index to name in the _bulk actions of -format es-bulk (same as -bulk-index)")
 	parseFlags.IntVar(&config.MaxLineBytes, "max-line-bytes", logparser.DefaultMaxLineBytes, "Maximum bytes allowed in a single log line")
 	parseFlags.BoolVar(&config.TruncateLongLines, "truncate-long-lines", false, "Truncate log lines that exceed -max-line-bytes instead of returning an error")
+	parseFlags.StringVar(&config.QuarantineFile, "quarantine", "", "Write lines that did not parse cleanly, with line numbers, to this file (e.g., output.quarantine)")
+	parseFlags.BoolVar(&config.DropHeartbeats, "drop-heartbeats", false, "Drop agent heartbeat/keepalive entries from output and exports")
+	parseFlags.BoolVar(&config.SortByTime, "sort-by-time", false, "Order the Parquet export by timestamp instead of log order (stable for equal timestamps)")
+	parseFlags.StringVar(&config.Compression, "compression", string(buildkitelogs.DefaultParquetCompression), "Codec of the Parquet export: zstd, snappy or none")
+	parseFlags.IntVar(&config.CompressionLevel, "compression-level", 0, "Level of the -compression codec, e.g. 1 (fastest) to 22 (smallest) for zstd (0 = codec default)")
+	parseFlags.IntVar(&config.RowGroupSize, "row-group-size", 0, "Rows per Parquet row group; larger groups scan faster from object storage (0 = one per 1000-entry batch)")
+	parseFlags.BoolVar(&config.Dictionary, "dictionary", true, "Dictionary-encode Parquet columns")
+	parseFlags.BoolVar(&config.OmitContent, "omit-content", false, "Write the Parquet export without log content, keeping timestamps, groups, flags, content hashes and lengths")
 	// Buildkite API parameters
 	parseFlags.StringVar(&config.Organization, "org", "", "Buildkite organization slug (for API)")
 	parseFlags.StringVar(&config.Pipeline, "pipeline", "", "Buildkite pipeline slug (for API)")
-	parseFlags.StringVar(&config.Build, "build", "", "Buildkite build number or UUID (for API)")
+	parseFlags.StringVar(&config.Build, "build", "", "Buildkite build number, UUID or \"latest\" (for API)")
+	parseFlags.StringVar(&config.Branch, "branch", "", "Branch to select the most recent build from (with -build latest)")
 	parseFlags.StringVar(&config.Job, "job", "", "Buildkite job ID (for API)")
+	parseFlags.StringVar(&config.Step, "step", "", "Step key or label to resolve within the build (for API, instead of -job)")
 
 	parseFlags.Usage = func() {
 		fmt.Printf("Usage: %s parse [options]\n\n", os.Args[0])
@@ -38,18 +64,34 @@ func handleParseCommand() {
 		fmt.Println("  -file <path>     Local log file")
 		fmt.Println("  OR API params:   -org -pipeline -build -job")
 		fmt.Println("\nFor API usage, set BUILDKITE_API_TOKEN environment variable.")
+		fmt.Println("For -clickhouse-url, set CLICKHOUSE_USER and CLICKHOUSE_PASSWORD to authenticate.")
 		fmt.Println("\nOptions:")
 		parseFlags.PrintDefaults()
 		fmt.Println("\nExamples:")
 		fmt.Printf("  # Local file:\n")
 		fmt.Printf("  %s parse -file buildkite.log\n", os.Args[0])
 		fmt.Printf("  %s parse -file buildkite.log -filter group -json\n", os.Args[0])
+		fmt.Printf("  %s parse -file job.log.gz -parquet output.parquet\n", os.Args[0])
+		fmt.Printf("  %s parse -file buildkite.log -format parquet-json\n", os.Args[0])
 		fmt.Printf("  %s parse -file buildkite.log -parquet output.parquet -summary\n", os.Args[0])
 		fmt.Printf("  %s parse -file buildkite.log -jsonl output.jsonl -summary\n", os.Args[0])
+		fmt.Printf("  %s parse -file buildkite.log -sqlite logs.db\n", os.Args[0])
+		fmt.Printf("  %s parse -file buildkite.log -bulk output.ndjson -bulk-index buildkite-logs\n", os.Args[0])
+		fmt.Printf("  %s parse -file buildkite.log -format csv -columns time,group,severity,content > output.csv\n", os.Args[0])
+		fmt.Printf("  %s parse -file buildkite.log -format es-bulk -index buildkite-logs | curl -H 'Content-Type: application/x-ndjson' -X POST localhost:9200/_bulk --data-binary @-\n", os.Args[0])
+		fmt.Printf("  %s parse -file buildkite.log -parquet output.parquet -drop-heartbeats\n", os.Args[0])
+		fmt.Printf("  %s parse -file buildkite.log -parquet output.parquet -quarantine output.quarantine\n", os.Args[0])
+		fmt.Printf("  %s parse -file buildkite.log -parquet output.parquet -sort-by-time\n", os.Args[0])
+		fmt.Printf("  %s parse -file buildkite.log -parquet output.parquet -omit-content\n", os.Args[0])
+		fmt.Printf("  %s parse -file buildkite.log -parquet output.parquet -compression none\n", os.Args[0])
+		fmt.Printf("  %s parse -file buildkite.log -parquet output.parquet -compression snappy -row-group-size 100000\n", os.Args[0])
 		fmt.Printf("\n  # API:\n")
-		fmt.Printf("  %s parse -org myorg -pipeline mypipe -build 123 -job abc-def -json\n", os.Args[0])
-		fmt.Printf("  %s parse -org myorg -pipeline mypipe -build 123 -job abc-def -parquet logs.parquet\n", os.Args[0])
-		fmt.Printf("  %s parse -org myorg -pipeline mypipe -build 123 -job abc-def -jsonl logs.jsonl\n", os.Args[0])
+		fmt.Printf("  %s parse -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -json\n", os.Args[0])
+		fmt.Printf("  %s parse -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -parquet logs.parquet\n", os.Args[0])
+		fmt.Printf("  %s parse -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -jsonl logs.jsonl\n", os.Args[0])
+		fmt.Printf("  %s parse -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -loki-url http://localhost:3100\n", os.Args[0])
+		fmt.Printf("  %s parse -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -clickhouse-url http://localhost:8123\n", os.Args[0])
+		fmt.Printf("  %s parse -org myorg -pipeline mypipe -build 123 -step \"Run integration tests\" -json\n", os.Args[0])
 	}
 
 	if err := parseFlags.Parse(os.Args[2:]); err != nil {
@@ -58,7 +100,7 @@ func handleParseCommand() {
 
 	// Validate that either file or API parameters are provided
 	hasFile := config.FilePath != ""
-	hasAPIParams := config.Organization != "" || config.Pipeline != "" || config.Build != "" || config.Job != ""
+	hasAPIParams := config.Organization != "" || config.Pipeline != "" || config.Build != "" || config.Job != "" || config.Step != ""
 
 	if !hasFile && !hasAPIParams {
 		fmt.Fprintf(os.Stderr, "Error: Must provide either -file or API parameters (-org, -pipeline, -build, -job)\n\n")
@@ -72,9 +114,66 @@ func handleParseCommand() {
 		os.Exit(1)
 	}
 
+	if config.BulkIndex != "" && config.BulkFile == "" && config.Format != "es-bulk" {
+		fmt.Fprintf(os.Stderr, "Error: -bulk-index and -index require -bulk or -format es-bulk\n\n")
+		parseFlags.Usage()
+		os.Exit(1)
+	}
+
+	if config.LokiTenant != "" && config.LokiURL == "" {
+		fmt.Fprintf(os.Stderr, "Error: -loki-tenant requires -loki-url\n\n")
+		parseFlags.Usage()
+		os.Exit(1)
+	}
+
+	switch config.Format {
+	case "text":
+	case "json":
+		config.OutputJSON = true
+	case "parquet-json", "es-bulk", "csv", "tsv":
+		if config.OutputJSON {
+			fmt.Fprintf(os.Stderr, "Error: -json and -format %s are mutually exclusive\n\n", config.Format)
+			parseFlags.Usage()
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid -format %q: want text, json, parquet-json, es-bulk, csv or tsv\n\n", config.Format)
+		parseFlags.Usage()
+		os.Exit(1)
+	}
+
+	if config.Columns != "" && config.Format != "csv" && config.Format != "tsv" {
+		fmt.Fprintf(os.Stderr, "Error: -columns requires -format csv or tsv\n\n")
+		parseFlags.Usage()
+		os.Exit(1)
+	}
+	if _, err := csvOptions(config.Format, config.Columns); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid -columns: %v\n\n", err)
+		parseFlags.Usage()
+		os.Exit(1)
+	}
+
+	if config.OmitContent && config.ParquetFile == "" {
+		fmt.Fprintf(os.Stderr, "Error: -omit-content requires -parquet\n\n")
+		parseFlags.Usage()
+		os.Exit(1)
+	}
+
+	if _, err := buildkitelogs.ParseParquetCompression(config.Compression); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid -compression: %v\n\n", err)
+		parseFlags.Usage()
+		os.Exit(1)
+	}
+
+	if config.CompressionLevel < 0 || config.RowGroupSize < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -compression-level and -row-group-size must not be negative\n\n")
+		parseFlags.Usage()
+		os.Exit(1)
+	}
+
 	// If using API, validate all required parameters are present
 	if hasAPIParams {
-		if err := buildkitelogs.ValidateAPIParams(config.Organization, config.Pipeline, config.Build, config.Job); err != nil {
+		if err := validateAPIFlags(config.Organization, config.Pipeline, &config.Build, config.Branch, &config.Job, config.Step); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
 			parseFlags.Usage()
 			os.Exit(1)
@@ -92,6 +191,10 @@ func handleParseCommand() {
 func runParse(ctx context.Context, config *Config) error {
 	var reader io.ReadCloser
 	var bytesProcessed int64
+	var lokiLabels map[string]string
+	var clickHouseJob buildkitelogs.ClickHouseOptions // Job columns of ClickHouse rows
+	var bulkOpts []buildkitelogs.BulkOption           // Job fields of bulk documents, for API logs
+	var otlpAttributes map[string]string              // Resource attributes of OTLP log records
 
 	// Determine data source: file or API
 	if config.FilePath != "" {
@@ -100,7 +203,6 @@ func runParse(ctx context.Context, config *Config) error {
 		if err != nil {
 			return fmt.Errorf("failed to open file: %w", err)
 		}
-		reader = file
 
 		// Get file size for bytes processed calculation
 		fileInfo, err := file.Stat()
@@ -109,6 +211,17 @@ func runParse(ctx context.Context, config *Config) error {
 			return fmt.Errorf("failed to get file info: %w", err)
 		}
 		bytesProcessed = fileInfo.Size()
+
+		// Compressed archives (.gz, .zst, .bz2) are decompressed transparently
+		decompressed, err := logparser.NewDecompressingReader(file)
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		reader = &decompressingFile{ReadCloser: decompressed, file: file}
+		lokiLabels = map[string]string{"file": filepath.Base(config.FilePath)}
+		clickHouseJob.Job = filepath.Base(config.FilePath)
+		otlpAttributes = map[string]string{"log.file.name": filepath.Base(config.FilePath)}
 	} else {
 		// Buildkite API
 		apiToken := os.Getenv("BUILDKITE_API_TOKEN")
@@ -117,44 +230,125 @@ func runParse(ctx context.Context, config *Config) error {
 		}
 
 		client := buildkitelogs.NewBuildkiteAPIClient(apiToken, version)
-		logReader, err := client.GetJobLog(ctx, config.Organization, config.Pipeline, config.Build, config.Job)
+		build, err := buildkitelogs.ResolveBuild(ctx, client, config.Organization, config.Pipeline, config.Build, config.Branch)
+		if err != nil {
+			return fmt.Errorf("failed to resolve build: %w", err)
+		}
+		job := config.Job
+		if config.Step != "" {
+			location, err := buildkitelogs.ResolveJobByStep(ctx, client, config.Organization, config.Pipeline, build, config.Step)
+			if err != nil {
+				return fmt.Errorf("failed to resolve step: %w", err)
+			}
+			build, job = location.Build, location.Job
+		}
+		logReader, err := client.GetJobLog(ctx, config.Organization, config.Pipeline, build, job)
 		if err != nil {
 			return fmt.Errorf("failed to fetch logs from API: %w", err)
 		}
 		reader = logReader
 		bytesProcessed = -1 // Unknown for API
+		lokiLabels = buildkitelogs.LokiJobLabels(config.Organization, config.Pipeline, build, job)
+		clickHouseJob = buildkitelogs.ClickHouseOptions{Org: config.Organization, Pipeline: config.Pipeline, Build: build, Job: job}
+		otlpAttributes = buildkitelogs.OTLPJobAttributes(config.Organization, config.Pipeline, build, job)
+		bulkOpts = append(bulkOpts, buildkitelogs.WithBulkJob(buildkitelogs.JobLocation{Org: config.Organization, Pipeline: config.Pipeline, Build: build, Job: job}))
 	}
 
 	defer func() {
 		if closeErr := reader.Close(); closeErr != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to close reader: %v\n", closeErr)
+			logger.Warn("failed to close reader", "error", closeErr)
 		}
 	}()
 
 	summary := &ProcessingSummary{
-		BytesProcessed: bytesProcessed,
+		BytesProcessed:    bytesProcessed,
+		HeartbeatsDropped: config.DropHeartbeats,
 	}
 
-	parser := logparser.New(
+	parserOpts := []logparser.Option{
 		logparser.WithMaxLineBytes(config.MaxLineBytes),
 		logparser.WithTruncateLongLines(config.TruncateLongLines),
-	)
+	}
+	if config.QuarantineFile != "" {
+		quarantineFile, err := os.Create(config.QuarantineFile)
+		if err != nil {
+			return fmt.Errorf("failed to create quarantine file: %w", err)
+		}
+		defer func() {
+			if closeErr := quarantineFile.Close(); closeErr != nil {
+				logger.Warn("failed to close quarantine file", "error", closeErr)
+			}
+		}()
+		parserOpts = append(parserOpts, logparser.WithQuarantine(quarantineFile, 0))
+	}
+	parser := logparser.New(parserOpts...)
 
 	// Handle export options
 	switch {
 	case config.ParquetFile != "":
-		err := exportToParquetSeq2(reader, parser, config.ParquetFile, config.Filter, summary)
+		writerOpts := []buildkitelogs.ParquetWriterOption{
+			buildkitelogs.WithSourceParser(parser),
+			buildkitelogs.WithOmitContent(config.OmitContent),
+			buildkitelogs.WithCompression(buildkitelogs.ParquetCompression(config.Compression)),
+			buildkitelogs.WithCompressionLevel(config.CompressionLevel),
+			buildkitelogs.WithRowGroupSize(config.RowGroupSize),
+			buildkitelogs.WithDictionary(config.Dictionary),
+		}
+		err := exportToParquetSeq2(reader, parser, config.ParquetFile, config.Filter, config.DropHeartbeats, config.SortByTime, summary, writerOpts...)
 		if err != nil {
 			return fmt.Errorf("failed to export to Parquet: %w", err)
 		}
 	case config.JSONLFile != "":
-		err := exportToJSONLSeq2(reader, parser, config.JSONLFile, config.Filter, summary)
+		err := exportToJSONLSeq2(reader, parser, config.JSONLFile, config.Filter, config.DropHeartbeats, summary)
 		if err != nil {
 			return fmt.Errorf("failed to export to JSON Lines: %w", err)
 		}
+	case config.SQLiteFile != "":
+		err := exportToSQLiteSeq2(reader, parser, config, summary)
+		if err != nil {
+			return fmt.Errorf("failed to export to SQLite: %w", err)
+		}
+	case config.LokiURL != "":
+		err := exportToLokiSeq2(ctx, reader, parser, config, lokiLabels, summary)
+		if err != nil {
+			return fmt.Errorf("failed to push to Loki: %w", err)
+		}
+	case config.OTLPEndpoint != "":
+		err := exportToOTLPSeq2(ctx, reader, parser, config, otlpAttributes, summary)
+		if err != nil {
+			return fmt.Errorf("failed to export to OTLP: %w", err)
+		}
+	case config.ClickHouseURL != "":
+		err := exportToClickHouseSeq2(ctx, reader, parser, config, clickHouseJob, summary)
+		if err != nil {
+			return fmt.Errorf("failed to insert into ClickHouse: %w", err)
+		}
+	case config.BulkFile != "":
+		err := exportToBulkFileSeq2(reader, parser, config.BulkFile, config.BulkIndex, config.Filter, config.DropHeartbeats, summary, bulkOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to export to bulk NDJSON: %w", err)
+		}
+	case config.Format == "es-bulk":
+		err := exportToBulkSeq2(reader, parser, os.Stdout, config.BulkIndex, config.Filter, config.DropHeartbeats, summary, bulkOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to export to bulk NDJSON: %w", err)
+		}
+	case config.Format == "csv" || config.Format == "tsv":
+		opts, err := csvOptions(config.Format, config.Columns)
+		if err != nil {
+			return err
+		}
+		if err := outputCSVSeq2(reader, parser, config.Filter, config.DropHeartbeats, opts, summary); err != nil {
+			return fmt.Errorf("failed to export to CSV: %w", err)
+		}
+	case config.Format == "parquet-json":
+		err := outputParquetJSONSeq2(reader, parser, config.Filter, config.DropHeartbeats, summary)
+		if err != nil {
+			return fmt.Errorf("failed to process data: %w", err)
+		}
 	default:
 		// Regular output processing
-		err := outputSeq2(reader, parser, config.OutputJSON, config.Filter, config.ShowGroups, summary)
+		err := outputSeq2(reader, parser, config.OutputJSON, config.Filter, config.DropHeartbeats, config.ShowGroups, summary)
 		if err != nil {
 			return fmt.Errorf("failed to process data: %w", err)
 		}
@@ -167,15 +361,15 @@ func runParse(ctx context.Context, config *Config) error {
 	return nil
 }
 
-func outputSeq2(reader io.Reader, parser *logparser.Parser, outputJSON bool, filter string, showGroups bool, summary *ProcessingSummary) error {
+func outputSeq2(reader io.Reader, parser *logparser.Parser, outputJSON bool, filter string, dropHeartbeats, showGroups bool, summary *ProcessingSummary) error {
 
 	if outputJSON {
-		return outputJSONSeq2(reader, parser, filter, showGroups, summary)
+		return outputJSONSeq2(reader, parser, filter, dropHeartbeats, showGroups, summary)
 	}
-	return outputTextSeq2(reader, parser, filter, showGroups, summary)
+	return outputTextSeq2(reader, parser, filter, dropHeartbeats, showGroups, summary)
 }
 
-func outputJSONSeq2(reader io.Reader, parser *logparser.Parser, filter string, showGroups bool, summary *ProcessingSummary) error {
+func outputJSONSeq2(reader io.Reader, parser *logparser.Parser, filter string, dropHeartbeats, showGroups bool, summary *ProcessingSummary) error {
 	type JSONEntry struct {
 		Timestamp string `json:"timestamp,omitempty"`
 		Content   string `json:"content"`
@@ -201,7 +395,11 @@ func outputJSONSeq2(reader io.Reader, parser *logparser.Parser, filter string, s
 			summary.Sections++
 		}
 
-		if !shouldIncludeEntry(entry, filter) {
+		if entry.IsHeartbeat() {
+			summary.Heartbeats++
+		}
+
+		if !shouldIncludeEntry(entry, filter, dropHeartbeats) {
 			continue
 		}
 
@@ -230,7 +428,81 @@ func outputJSONSeq2(reader io.Reader, parser *logparser.Parser, filter string, s
 	return encoder.Encode(jsonEntries)
 }
 
-func outputTextSeq2(reader io.Reader, parser *logparser.Parser, filter string, showGroups bool, summary *ProcessingSummary) error {
+// outputParquetJSONSeq2 writes entries as JSON Lines in the schema of query output
+func outputParquetJSONSeq2(reader io.Reader, parser *logparser.Parser, filter string, dropHeartbeats bool, summary *ProcessingSummary) error {
+	_, err := buildkitelogs.ExportSeq2ToJSONL(filteredEntriesSeq2(reader, parser, filter, dropHeartbeats, summary), os.Stdout, nil)
+	return err
+}
+
+func outputCSVSeq2(reader io.Reader, parser *logparser.Parser, filter string, dropHeartbeats bool, opts buildkitelogs.CSVOptions, summary *ProcessingSummary) error {
+	_, err := buildkitelogs.ExportSeq2ToCSV(filteredEntriesSeq2(reader, parser, filter, dropHeartbeats, summary), os.Stdout, opts)
+	return err
+}
+
+// filteredEntriesSeq2 parses entries, counting them in summary, and yields those passing
+// the filter. It stops at the first parse error.
+func filteredEntriesSeq2(reader io.Reader, parser *logparser.Parser, filter string, dropHeartbeats bool, summary *ProcessingSummary) iter.Seq2[*logparser.Entry, error] {
+	return func(yield func(*logparser.Entry, error) bool) {
+		for entry, err := range parser.All(reader) {
+			if err != nil {
+				yield(nil, fmt.Errorf("parse error: %w", err))
+				return
+			}
+
+			summary.TotalEntries++
+			if entry.HasTimestamp() {
+				summary.EntriesWithTime++
+			}
+			if entry.IsGroup() {
+				summary.Sections++
+			}
+			if entry.IsHeartbeat() {
+				summary.Heartbeats++
+			}
+
+			if !shouldIncludeEntry(entry, filter, dropHeartbeats) {
+				continue
+			}
+			summary.FilteredEntries++
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+}
+
+// csvOptions returns the options of -format csv or tsv with the comma separated -columns
+// list, failing for columns not in buildkitelogs.CSVColumns.
+func csvOptions(format, columns string) (buildkitelogs.CSVOptions, error) {
+	var opts buildkitelogs.CSVOptions
+	if format == "tsv" {
+		opts.Comma = '\t'
+	}
+	for name := range strings.SplitSeq(columns, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(buildkitelogs.CSVColumns, name) {
+			return opts, fmt.Errorf("unknown column %q (want one of %s)", name, strings.Join(buildkitelogs.CSVColumns, ", "))
+		}
+		opts.Columns = append(opts.Columns, name)
+	}
+	return opts, nil
+}
+
+// extraCSVColumns returns the CSV columns not written by default, for flag help
+func extraCSVColumns() []string {
+	var extra []string
+	for _, name := range buildkitelogs.CSVColumns {
+		if !slices.Contains(buildkitelogs.DefaultCSVColumns, name) {
+			extra = append(extra, name)
+		}
+	}
+	return extra
+}
+
+func outputTextSeq2(reader io.Reader, parser *logparser.Parser, filter string, dropHeartbeats, showGroups bool, summary *ProcessingSummary) error {
 	for entry, err := range parser.All(reader) {
 		if err != nil {
 			return fmt.Errorf("parse error: %w", err)
@@ -247,7 +519,11 @@ func outputTextSeq2(reader io.Reader, parser *logparser.Parser, filter string, s
 			summary.Sections++
 		}
 
-		if !shouldIncludeEntry(entry, filter) {
+		if entry.IsHeartbeat() {
+			summary.Heartbeats++
+		}
+
+		if !shouldIncludeEntry(entry, filter, dropHeartbeats) {
 			continue
 		}
 
@@ -273,7 +549,11 @@ func outputTextSeq2(reader io.Reader, parser *logparser.Parser, filter string, s
 	return nil
 }
 
-func shouldIncludeEntry(entry *logparser.Entry, filter string) bool {
+func shouldIncludeEntry(entry *logparser.Entry, filter string, dropHeartbeats bool) bool {
+	if dropHeartbeats && entry.IsHeartbeat() {
+		return false
+	}
+
 	switch filter {
 	case "command":
 		return false // Commands filter no longer supported
@@ -284,12 +564,12 @@ func shouldIncludeEntry(entry *logparser.Entry, filter string) bool {
 	}
 }
 
-func exportToParquetSeq2(reader io.Reader, parser *logparser.Parser, filename string, filter string, summary *ProcessingSummary) error {
+func exportToParquetSeq2(reader io.Reader, parser *logparser.Parser, filename string, filter string, dropHeartbeats, sortByTime bool, summary *ProcessingSummary, writerOpts ...buildkitelogs.ParquetWriterOption) error {
 	// Create filter function based on filter string
 	var filterFunc func(*logparser.Entry) bool
-	if filter != "" {
+	if filter != "" || dropHeartbeats {
 		filterFunc = func(entry *logparser.Entry) bool {
-			return shouldIncludeEntry(entry, filter)
+			return shouldIncludeEntry(entry, filter, dropHeartbeats)
 		}
 	}
 
@@ -301,7 +581,7 @@ func exportToParquetSeq2(reader io.Reader, parser *logparser.Parser, filename st
 
 			// Handle parse errors - still count them but log warnings
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Error parsing line %d: %v\n", lineNum, err)
+				logger.Warn("failed to parse line", "line", lineNum, "error", err)
 				if !yield(nil, err) {
 					return
 				}
@@ -319,6 +599,10 @@ func exportToParquetSeq2(reader io.Reader, parser *logparser.Parser, filename st
 				summary.Sections++
 			}
 
+			if entry.IsHeartbeat() {
+				summary.Heartbeats++
+			}
+
 			// Apply filter if specified
 			if filterFunc == nil || filterFunc(entry) {
 				summary.FilteredEntries++
@@ -331,16 +615,21 @@ func exportToParquetSeq2(reader io.Reader, parser *logparser.Parser, filename st
 		}
 	}
 
+	if sortByTime {
+		_, err := buildkitelogs.ExportSeq2ToParquetSorted(countingSeq, filename, filterFunc, buildkitelogs.SortOptions{}, writerOpts...)
+		return err
+	}
+
 	// Export using the Seq2 iterator with filtering
-	return buildkitelogs.ExportSeq2ToParquetWithFilter(countingSeq, filename, filterFunc)
+	return buildkitelogs.ExportSeq2ToParquetWithFilter(countingSeq, filename, filterFunc, writerOpts...)
 }
 
-func exportToJSONLSeq2(reader io.Reader, parser *logparser.Parser, filename string, filter string, summary *ProcessingSummary) error {
+func exportToJSONLSeq2(reader io.Reader, parser *logparser.Parser, filename string, filter string, dropHeartbeats bool, summary *ProcessingSummary) error {
 	// Create filter function based on filter string
 	var filterFunc func(*logparser.Entry) bool
-	if filter != "" {
+	if filter != "" || dropHeartbeats {
 		filterFunc = func(entry *logparser.Entry) bool {
-			return shouldIncludeEntry(entry, filter)
+			return shouldIncludeEntry(entry, filter, dropHeartbeats)
 		}
 	}
 
@@ -360,7 +649,7 @@ func exportToJSONLSeq2(reader io.Reader, parser *logparser.Parser, filename stri
 
 		// Handle parse errors - still count them but log warnings
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Error parsing line %d: %v\n", lineNum, err)
+			logger.Warn("failed to parse line", "line", lineNum, "error", err)
 			continue
 		}
 
@@ -375,6 +664,10 @@ func exportToJSONLSeq2(reader io.Reader, parser *logparser.Parser, filename stri
 			summary.Sections++
 		}
 
+		if entry.IsHeartbeat() {
+			summary.Heartbeats++
+		}
+
 		// Apply filter if specified
 		if filterFunc == nil || filterFunc(entry) {
 			summary.FilteredEntries++
@@ -396,6 +689,144 @@ func exportToJSONLSeq2(reader io.Reader, parser *logparser.Parser, filename stri
 	return nil
 }
 
+func exportToLokiSeq2(ctx context.Context, reader io.Reader, parser *logparser.Parser, config *Config, labels map[string]string, summary *ProcessingSummary) error {
+	sink, err := buildkitelogs.NewLokiSink(ctx, buildkitelogs.LokiOptions{
+		URL:      config.LokiURL,
+		Labels:   labels,
+		TenantID: config.LokiTenant,
+	})
+	if err != nil {
+		return err
+	}
+	if err := exportToLogWriterSeq2(reader, parser, config, sink, summary); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Pushed %d entries to Loki\n", sink.Pushed())
+	return nil
+}
+
+func exportToClickHouseSeq2(ctx context.Context, reader io.Reader, parser *logparser.Parser, config *Config, job buildkitelogs.ClickHouseOptions, summary *ProcessingSummary) error {
+	opts := job
+	opts.URL = config.ClickHouseURL
+	opts.Table = config.ClickHouseTable
+	opts.Username = os.Getenv("CLICKHOUSE_USER")
+	opts.Password = os.Getenv("CLICKHOUSE_PASSWORD")
+	sink, err := buildkitelogs.NewClickHouseSink(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if err := exportToLogWriterSeq2(reader, parser, config, sink, summary); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Inserted %d entries into ClickHouse table %s\n", sink.Inserted(), opts.Table)
+	return nil
+}
+
+// exportToLogWriterSeq2 writes the parsed entries passing the filter to sink and closes it
+func exportToLogWriterSeq2(reader io.Reader, parser *logparser.Parser, config *Config, sink buildkitelogs.LogWriter, summary *ProcessingSummary) error {
+	var filterFunc func(*logparser.Entry) bool
+	if config.Filter != "" || config.DropHeartbeats {
+		filterFunc = func(entry *logparser.Entry) bool {
+			return shouldIncludeEntry(entry, config.Filter, config.DropHeartbeats)
+		}
+	}
+
+	seq := func(yield func(*logparser.Entry, error) bool) {
+		lineNum := 0
+		for entry, err := range parser.All(reader) {
+			lineNum++
+
+			// Handle parse errors - still count them but log warnings
+			if err != nil {
+				logger.Warn("failed to parse line", "line", lineNum, "error", err)
+				continue
+			}
+
+			summary.TotalEntries++
+			if entry.HasTimestamp() {
+				summary.EntriesWithTime++
+			}
+			if entry.IsGroup() {
+				summary.Sections++
+			}
+			if entry.IsHeartbeat() {
+				summary.Heartbeats++
+			}
+			if filterFunc == nil || filterFunc(entry) {
+				summary.FilteredEntries++
+			}
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+
+	if _, err := buildkitelogs.ExportSeq2ToLogWriter(seq, sink, filterFunc); err != nil {
+		_ = sink.Close()
+		return err
+	}
+	return sink.Close()
+}
+
+func exportToSQLiteSeq2(reader io.Reader, parser *logparser.Parser, config *Config, summary *ProcessingSummary) error {
+	w, err := sqlite.NewWriter(config.SQLiteFile)
+	if err != nil {
+		return err
+	}
+	return exportToLogWriterSeq2(reader, parser, config, w, summary)
+}
+
+func exportToBulkFileSeq2(reader io.Reader, parser *logparser.Parser, filename, index string, filter string, dropHeartbeats bool, summary *ProcessingSummary, opts ...buildkitelogs.BulkOption) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create bulk NDJSON file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if err := exportToBulkSeq2(reader, parser, file, index, filter, dropHeartbeats, summary, opts...); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func exportToBulkSeq2(reader io.Reader, parser *logparser.Parser, w io.Writer, index string, filter string, dropHeartbeats bool, summary *ProcessingSummary, opts ...buildkitelogs.BulkOption) error {
+	// Count entries for the summary and export those passing the filter
+	seq := func(yield func(*logparser.Entry, error) bool) {
+		lineNum := 0
+		for entry, err := range parser.All(reader) {
+			lineNum++
+
+			// Handle parse errors - still count them but log warnings
+			if err != nil {
+				logger.Warn("failed to parse line", "line", lineNum, "error", err)
+				continue
+			}
+
+			summary.TotalEntries++
+			if entry.HasTimestamp() {
+				summary.EntriesWithTime++
+			}
+			if entry.IsGroup() {
+				summary.Sections++
+			}
+			if entry.IsHeartbeat() {
+				summary.Heartbeats++
+			}
+
+			if (filter != "" || dropHeartbeats) && !shouldIncludeEntry(entry, filter, dropHeartbeats) {
+				continue
+			}
+			summary.FilteredEntries++
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+
+	_, err := buildkitelogs.ExportSeq2ToESBulk(seq, w, index, opts...)
+	return err
+}
+
 func printSummary(summary *ProcessingSummary) {
 	fmt.Printf("\n--- Processing Summary ---\n")
 	if summary.BytesProcessed >= 0 {
@@ -406,9 +837,24 @@ func printSummary(summary *ProcessingSummary) {
 	fmt.Printf("Total entries: %d\n", summary.TotalEntries)
 	fmt.Printf("Entries with timestamps: %d\n", summary.EntriesWithTime)
 	fmt.Printf("Sections: %d\n", summary.Sections)
-	fmt.Printf("Regular output: %d\n", summary.TotalEntries-summary.Sections)
+	if summary.HeartbeatsDropped {
+		fmt.Printf("Heartbeats: %d (dropped)\n", summary.Heartbeats)
+	} else {
+		fmt.Printf("Heartbeats: %d\n", summary.Heartbeats)
+	}
+	fmt.Printf("Regular output: %d\n", summary.TotalEntries-summary.Sections-summary.Heartbeats)
 
 	if summary.FilteredEntries > 0 {
 		fmt.Printf("Exported %d entries to %s\n", summary.FilteredEntries, "Parquet file")
 	}
 }
+
+// decompressingFile reads a log file through its decompressor, closing both.
+type decompressingFile struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (f *decompressingFile) Close() error {
+	return errors.Join(f.ReadCloser.Close(), f.file.Close())
+}
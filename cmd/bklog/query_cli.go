@@ -1,16 +1,25 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"iter"
+	"maps"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/buildkite-logs/logparser"
 )
 
 func handleQueryCommand() {
@@ -18,14 +27,25 @@ func handleQueryCommand() {
 
 	queryFlags := flag.NewFlagSet("query", flag.ExitOnError)
 	queryFlags.StringVar(&config.ParquetFile, "file", "", "Path to Parquet log file (use this OR API parameters)")
-	queryFlags.StringVar(&config.Operation, "op", "list-groups", "Query operation: list-groups, by-group, info, tail, seek, dump, search")
+	queryFlags.StringVar(&config.Operation, "op", "list-groups", "Query operation: list-groups, by-group, info, tail, seek, dump, search, group-tails, timings, summary, docker-steps, env, annotations, follow")
 	queryFlags.StringVar(&config.GroupName, "group", "", "Group name to filter by (for by-group operation)")
-	queryFlags.StringVar(&config.Format, "format", "text", "Output format: text, json")
+	queryFlags.BoolVar(&config.GroupExact, "group-exact", false, "Match -group exactly instead of as a case-insensitive substring (for by-group)")
+	queryFlags.IntVar(&config.PickGroup, "pick", 0, "Show the Nth group matching -group when it matches several (for by-group, 1-based)")
+	queryFlags.StringVar(&config.AnnotationLabel, "annotation", "", "Only show entries with this annotation label (for annotations operation)")
+	queryFlags.StringVar(&config.Format, "format", "text", "Output format: text, json, or csv and tsv (for dump)")
+	queryFlags.StringVar(&config.Columns, "columns", "", "Comma separated columns of -format csv or tsv (default: "+strings.Join(buildkitelogs.DefaultCSVColumns, ",")+"; also "+strings.Join(extraCSVColumns(), ",")+")")
 	queryFlags.BoolVar(&config.ShowStats, "stats", true, "Show query statistics")
 	queryFlags.IntVar(&config.LimitEntries, "limit", 0, "Limit number of entries returned (0 = no limit, enables early termination)")
-	queryFlags.IntVar(&config.TailLines, "tail", 10, "Number of lines to show from end (for tail operation)")
+	queryFlags.IntVar(&config.TailLines, "tail", 10, "Number of lines to show from end (for tail and group-tails operations), or of error lines for summary")
+	queryFlags.IntVar(&config.TopGroups, "top", 0, "Show only the first N groups after sorting (for list-groups, 0 = all)")
+	queryFlags.StringVar(&config.SortGroupsBy, "sort-by", "first-seen", "Group order: first-seen, entries, duration (for list-groups)")
+	queryFlags.BoolVar(&config.GroupTree, "tree", false, "Show groups nested under their parent groups (for list-groups)")
 	queryFlags.Int64Var(&config.SeekToRow, "seek", 0, "Row number to seek to (0-based, for seek operation)")
 	queryFlags.BoolVar(&config.RawOutput, "raw", false, "Output raw log content without timestamps, groups, or other prefixes")
+	queryFlags.StringVar(&config.Template, "template", "", "Go template for each output entry, e.g. '{{.Timestamp}} {{.Group}} {{.Content}}' (fields: RowNumber, Timestamp, Content, Group, IsGroup, Severity, Match)")
+	queryFlags.StringVar(&config.GroupAliasesFile, "group-aliases", "", "YAML file mapping renamed group names to canonical ones (alias: canonical name)")
+	queryFlags.BoolVar(&config.VerifyChecksums, "verify-checksums", false, "Verify the file's data checksums before reading and fail if it is corrupted")
+	queryFlags.BoolVar(&config.Explain, "explain", false, "Print how the operation would read the file (row groups, index use, scan bytes) instead of running it")
 	// Search operation parameters
 	queryFlags.StringVar(&config.SearchPattern, "pattern", "", "Regex pattern to search for (for search operation)")
 	queryFlags.IntVar(&config.AfterContext, "A", 0, "Show NUM lines after each match")
@@ -35,17 +55,31 @@ func handleQueryCommand() {
 	queryFlags.BoolVar(&config.InvertMatch, "invert-match", false, "Show non-matching lines")
 	queryFlags.BoolVar(&config.Reverse, "reverse", false, "Search backwards from end/seek position")
 	queryFlags.Int64Var(&config.SearchSeek, "search-seek", 0, "Start search from this row (useful with --reverse)")
+	queryFlags.StringVar(&config.SearchGroupBy, "group-by", "", "Cluster matches under a heading with counts: group (for search operation)")
+	queryFlags.BoolVar(&config.GroupContext, "group-context", false, "Show the header of each match's group (for search operation)")
+	queryFlags.BoolVar(&config.Links, "links", false, "Show a Buildkite UI link to each match's line (for search operation, API only)")
+	var searchFrom, searchTo string
+	queryFlags.StringVar(&searchFrom, "from", "", "Only search entries logged at or after this RFC 3339 time, e.g. 2025-04-22T21:00:00Z (for search operation)")
+	queryFlags.StringVar(&searchTo, "to", "", "Only search entries logged at or before this RFC 3339 time (for search operation)")
+	queryFlags.DurationVar(&config.SearchTimeout, "timeout", 0, "Stop searching after this long and show partial results, e.g. 5s (0 = no limit)")
 	// Buildkite API parameters
 	// ANSI processing flag
 	queryFlags.BoolVar(&config.StripANSI, "strip-ansi", false, "Strip ANSI escape codes from log content")
+	queryFlags.BoolVar(&config.DropHeartbeats, "drop-heartbeats", false, "Drop agent heartbeat/keepalive entries from results")
+	queryFlags.StringVar(&config.MinSeverity, "min-severity", "", "Only show entries of at least this inferred severity: info, warn, error")
+	queryFlags.StringVar(&config.Level, "level", "", "Only show entries of this inferred severity: info, warn, error")
 	// Buildkite API parameters
 	queryFlags.StringVar(&config.Organization, "org", "", "Buildkite organization slug (for API)")
 	queryFlags.StringVar(&config.Pipeline, "pipeline", "", "Buildkite pipeline slug (for API)")
-	queryFlags.StringVar(&config.Build, "build", "", "Buildkite build number or UUID (for API)")
+	queryFlags.StringVar(&config.Build, "build", "", "Buildkite build number, UUID or \"latest\" (for API)")
+	queryFlags.StringVar(&config.Branch, "branch", "", "Branch to select the most recent build from (with -build latest)")
 	queryFlags.StringVar(&config.Job, "job", "", "Buildkite job ID (for API)")
+	queryFlags.StringVar(&config.Step, "step", "", "Step key or label to resolve within the build (for API, instead of -job)")
+	queryFlags.BoolVar(&config.AllJobs, "all-jobs", false, "Download every job in the build and run the operation on each (for API, instead of -job)")
 	// Smart caching parameters
 	queryFlags.DurationVar(&config.CacheTTL, "cache-ttl", 30*time.Second, "Cache TTL for non-terminal jobs")
 	queryFlags.BoolVar(&config.ForceRefresh, "cache-force-refresh", false, "Force refresh cached entry")
+	queryFlags.BoolVar(&config.Invalidate, "cache-invalidate", false, "Delete the cached entry for the job and exit (API only)")
 	queryFlags.StringVar(&config.CacheURL, "cache-url", "", "Cache storage URL (file://path, s3://bucket, etc)")
 
 	queryFlags.Usage = func() {
@@ -53,7 +87,7 @@ func handleQueryCommand() {
 		fmt.Println("Query Parquet log files from local files or Buildkite API.")
 		fmt.Println("\nYou must provide either:")
 		fmt.Println("  -file <path>     Local parquet file")
-		fmt.Println("  OR API params:   -org -pipeline -build -job")
+		fmt.Println("  OR API params:   -org -pipeline -build -job (or -step, or -all-jobs)")
 		fmt.Println("\nFor API usage, set BUILDKITE_API_TOKEN environment variable.")
 		fmt.Println("API logs are automatically downloaded and cached using the high-level client.")
 		fmt.Println("Smart caching: Terminal jobs are cached permanently, non-terminal jobs use TTL.")
@@ -67,27 +101,59 @@ func handleQueryCommand() {
 		fmt.Println("  tail           Show last N entries from the file")
 		fmt.Println("  seek           Start reading from a specific row number")
 		fmt.Println("  dump           Output all entries from the file")
+		fmt.Println("  group-tails    Show the last N entries of every group")
+		fmt.Println("  timings        Show how long each group took and its share of the job's time")
+		fmt.Println("  summary        Summarize a failed job as Markdown for buildkite-agent annotate")
+		fmt.Println("  docker-steps   Show per-step timing of docker build / BuildKit output")
+		fmt.Println("  env            Show the environment variables printed in environment groups, secrets redacted")
+		fmt.Println("  annotations    Show entries annotated with 'bklog annotate' (API only)")
+		fmt.Println("  follow         Stream entries of a running job as they are logged (API only)")
 		fmt.Println("\nExamples:")
 		fmt.Printf("  # Local file:\n")
 		fmt.Printf("  %s query -file logs.parquet -op list-groups\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op list-groups -tree\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op timings\n", os.Args[0])
+		fmt.Printf("  %s query -org myorg -pipeline mypipeline -build 123 -job <job-id> -op summary | buildkite-agent annotate --style error\n", os.Args[0])
 
 		fmt.Printf("  %s query -file logs.parquet -op by-group -group \"Running tests\"\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op by-group -group \"tests\" -pick 2\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op list-groups -group-aliases group-aliases.yaml\n", os.Args[0])
 		fmt.Printf("  %s query -file logs.parquet -op search -pattern \"error|failed\" -C 3\n", os.Args[0])
 		fmt.Printf("  %s query -file logs.parquet -op search -pattern \"test.*failed\" -reverse -C 2\n", os.Args[0])
 		fmt.Printf("  %s query -file logs.parquet -op search -pattern \"setup\" -reverse -search-seek 1000\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op search -pattern \"panic\" -timeout 5s\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op search -pattern \"timeout\" -from 2025-04-22T21:00:00Z -to 2025-04-22T21:30:00Z\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op search -pattern \"FAIL\" -group-context\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op search -pattern \"ERROR\" -group-by group\n", os.Args[0])
 		fmt.Printf("  %s query -file logs.parquet -op info\n", os.Args[0])
 		fmt.Printf("  %s query -file logs.parquet -op tail -tail 20\n", os.Args[0])
 		fmt.Printf("  %s query -file logs.parquet -op seek -seek 1000 -limit 50\n", os.Args[0])
 		fmt.Printf("  %s query -file logs.parquet -op dump -limit 100\n", os.Args[0])
 		fmt.Printf("  %s query -file logs.parquet -op dump -raw\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op dump -template '{{.Timestamp.Format \"15:04:05\"}} {{.Group}} {{.Content}}'\n", os.Args[0])
 		fmt.Printf("  %s query -file logs.parquet -op dump -strip-ansi\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op dump -format csv -strip-ansi > logs.csv\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op dump -drop-heartbeats\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op by-group -group \"tests\" -min-severity warn\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op dump -level error\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op group-tails -tail 5\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op docker-steps\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op env -format json\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op by-group -group \"Running tests\" -explain\n", os.Args[0])
+		fmt.Printf("  %s query -file logs.parquet -op dump -verify-checksums\n", os.Args[0])
 		fmt.Printf("\n  # API:\n")
-		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -job abc-def -op list-groups\n", os.Args[0])
-		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -job abc-def -op by-group -group \"Running tests\"\n", os.Args[0])
-		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -job abc-def -op info\n", os.Args[0])
-		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -job abc-def -op info -cache-force-refresh\n", os.Args[0])
-		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -job abc-def -op list-groups -cache-ttl=60s\n", os.Args[0])
-		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -job abc-def -op info -cache-url=file:///tmp/cache\n", os.Args[0])
+		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -op list-groups\n", os.Args[0])
+		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -op by-group -group \"Running tests\"\n", os.Args[0])
+		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -step \"Run integration tests\" -op tail\n", os.Args[0])
+		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -op search -pattern \"FAIL\" -links\n", os.Args[0])
+		fmt.Printf("  %s query -org myorg -pipeline mypipe -build latest -branch main -step tests -op info\n", os.Args[0])
+		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -all-jobs -op search -pattern \"FAIL\"\n", os.Args[0])
+		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -step tests -op follow\n", os.Args[0])
+		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -op info\n", os.Args[0])
+		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -op info -cache-force-refresh\n", os.Args[0])
+		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -cache-invalidate\n", os.Args[0])
+		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -op list-groups -cache-ttl=60s\n", os.Args[0])
+		fmt.Printf("  %s query -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -op info -cache-url=file:///tmp/cache\n", os.Args[0])
 	}
 
 	if err := queryFlags.Parse(os.Args[2:]); err != nil {
@@ -96,7 +162,7 @@ func handleQueryCommand() {
 
 	// Validate that either file or API parameters are provided
 	hasFile := config.ParquetFile != ""
-	hasAPIParams := config.Organization != "" || config.Pipeline != "" || config.Build != "" || config.Job != ""
+	hasAPIParams := config.Organization != "" || config.Pipeline != "" || config.Build != "" || config.Job != "" || config.Step != ""
 
 	if !hasFile && !hasAPIParams {
 		fmt.Fprintf(os.Stderr, "Error: Must provide either -file or API parameters (-org, -pipeline, -build, -job)\n\n")
@@ -110,9 +176,131 @@ func handleQueryCommand() {
 		os.Exit(1)
 	}
 
+	switch config.Format {
+	case "text", "json":
+	case "csv", "tsv":
+		if config.Operation != "dump" {
+			fmt.Fprintf(os.Stderr, "Error: -format %s requires -op dump\n\n", config.Format)
+			queryFlags.Usage()
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid -format %q: want text, json, csv or tsv\n\n", config.Format)
+		queryFlags.Usage()
+		os.Exit(1)
+	}
+
+	if config.Columns != "" && config.Format != "csv" && config.Format != "tsv" {
+		fmt.Fprintf(os.Stderr, "Error: -columns requires -format csv or tsv\n\n")
+		queryFlags.Usage()
+		os.Exit(1)
+	}
+	if _, err := csvOptions(config.Format, config.Columns); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid -columns: %v\n\n", err)
+		queryFlags.Usage()
+		os.Exit(1)
+	}
+
+	if config.Template != "" {
+		if config.Format != "text" {
+			fmt.Fprintf(os.Stderr, "Error: -template cannot be used with -format %s\n\n", config.Format)
+			queryFlags.Usage()
+			os.Exit(1)
+		}
+		tmpl, err := parseEntryTemplate(config.Template)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+			os.Exit(1)
+		}
+		config.entryTemplate = tmpl
+	}
+
+	for _, bound := range []struct {
+		flag, value string
+		t           *time.Time
+	}{{"-from", searchFrom, &config.SearchFrom}, {"-to", searchTo, &config.SearchTo}} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid %s %q (want an RFC 3339 time such as 2025-04-22T21:00:00Z)\n\n", bound.flag, bound.value)
+			queryFlags.Usage()
+			os.Exit(1)
+		}
+		*bound.t = t
+	}
+
+	if config.MinSeverity != "" {
+		severity, err := logparser.ParseSeverity(config.MinSeverity)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -min-severity: %v\n\n", err)
+			queryFlags.Usage()
+			os.Exit(1)
+		}
+		config.minSeverity = severity
+	}
+	if config.Level != "" {
+		level, err := logparser.ParseSeverity(config.Level)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -level: %v\n\n", err)
+			queryFlags.Usage()
+			os.Exit(1)
+		}
+		config.level = level
+	}
+
+	if config.SearchGroupBy != "" && config.SearchGroupBy != "group" {
+		fmt.Fprintf(os.Stderr, "Error: invalid -group-by %q (want group)\n\n", config.SearchGroupBy)
+		queryFlags.Usage()
+		os.Exit(1)
+	}
+
+	if hasFile && config.Invalidate {
+		fmt.Fprintf(os.Stderr, "Error: -cache-invalidate requires API parameters\n\n")
+		queryFlags.Usage()
+		os.Exit(1)
+	}
+
+	if config.PickGroup < 0 || (config.PickGroup > 0 && config.GroupExact) {
+		fmt.Fprintf(os.Stderr, "Error: -pick must be positive and cannot be used with -group-exact\n\n")
+		queryFlags.Usage()
+		os.Exit(1)
+	}
+
+	if hasFile && config.Operation == "follow" {
+		fmt.Fprintf(os.Stderr, "Error: -op follow requires API parameters\n\n")
+		queryFlags.Usage()
+		os.Exit(1)
+	}
+
+	if hasFile && config.Links {
+		fmt.Fprintf(os.Stderr, "Error: -links requires API parameters\n\n")
+		queryFlags.Usage()
+		os.Exit(1)
+	}
+
+	if config.AllJobs {
+		if hasFile || config.Job != "" || config.Step != "" {
+			fmt.Fprintf(os.Stderr, "Error: -all-jobs cannot be used with -file, -job or -step\n\n")
+			queryFlags.Usage()
+			os.Exit(1)
+		}
+		if config.Operation == "follow" || config.Invalidate {
+			fmt.Fprintf(os.Stderr, "Error: -all-jobs cannot be used with -op follow or -cache-invalidate\n\n")
+			queryFlags.Usage()
+			os.Exit(1)
+		}
+		if err := validateBuildFlags(config.Organization, config.Pipeline, &config.Build, config.Branch); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+			queryFlags.Usage()
+			os.Exit(1)
+		}
+	}
+
 	// If using API, validate all required parameters are present
-	if hasAPIParams {
-		if err := buildkitelogs.ValidateAPIParams(config.Organization, config.Pipeline, config.Build, config.Job); err != nil {
+	if hasAPIParams && !config.AllJobs {
+		if err := validateAPIFlags(config.Organization, config.Pipeline, &config.Build, config.Branch, &config.Job, config.Step); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
 			queryFlags.Usage()
 			os.Exit(1)
@@ -129,14 +317,30 @@ func handleQueryCommand() {
 
 // formatLogEntries formats a slice of log entries consistently across all operations
 func formatLogEntries(entries []buildkitelogs.ParquetLogEntry, config *QueryConfig) {
-	if config.RawOutput {
-		// Raw mode: just print content to stdout
+	if err := writeLogEntries(os.Stdout, entries, config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+}
+
+// writeLogEntries writes log entries to w using the same layout as formatLogEntries,
+// stopping at the first write error
+func writeLogEntries(w io.Writer, entries []buildkitelogs.ParquetLogEntry, config *QueryConfig) error {
+	if config.entryTemplate != nil {
+		for _, entry := range entries {
+			if err := writeTemplateEntry(w, config.entryTemplate, entry, false, config.StripANSI); err != nil {
+				return err
+			}
+		}
+	} else if config.RawOutput {
+		// Raw mode: just print content
 		for _, entry := range entries {
 			content := entry.CleanContent(config.StripANSI)
-			fmt.Println(content)
+			if _, err := fmt.Fprintln(w, content); err != nil {
+				return err
+			}
 		}
 	} else {
-		// Formatted mode: print with timestamps and markers to stdout
+		// Formatted mode: print with timestamps and markers
 		for _, entry := range entries {
 			timestamp := time.Unix(0, entry.Timestamp*int64(time.Millisecond))
 
@@ -154,27 +358,39 @@ func formatLogEntries(entries []buildkitelogs.ParquetLogEntry, config *QueryConf
 			group := entry.CleanGroup(config.StripANSI)
 
 			// For group entries where group name == content, don't show duplicate
+			var err error
 			if group != "" && group != content {
-				fmt.Printf("[%s] [%s]%s %s\n",
+				_, err = fmt.Fprintf(w, "[%s] [%s]%s %s\n",
 					timestamp.Format("2006-01-02 15:04:05.000"),
 					group,
 					markerStr,
 					content)
 			} else {
-				fmt.Printf("[%s]%s %s\n",
+				_, err = fmt.Fprintf(w, "[%s]%s %s\n",
 					timestamp.Format("2006-01-02 15:04:05.000"),
 					markerStr,
 					content)
 			}
+			if err != nil {
+				return err
+			}
 		}
 	}
+	return nil
 }
 
 // formatSearchResults formats search results consistently
 func formatSearchResults(results []buildkitelogs.SearchResult, config *QueryConfig) {
-	if config.RawOutput {
+	if config.entryTemplate != nil {
+		if err := writeTemplateSearchResults(os.Stdout, results, config); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	} else if config.RawOutput {
 		// Raw mode: just print content to stdout
 		for _, result := range results {
+			if header := separateGroupHeader(result); header != nil {
+				fmt.Println(header.CleanContent(config.StripANSI))
+			}
 			// Print before context
 			for _, entry := range result.BeforeContext {
 				content := entry.CleanContent(config.StripANSI)
@@ -196,6 +412,13 @@ func formatSearchResults(results []buildkitelogs.SearchResult, config *QueryConf
 				fmt.Println("--")
 			}
 
+			if header := separateGroupHeader(result); header != nil {
+				timestamp := time.Unix(0, header.Timestamp*int64(time.Millisecond))
+				fmt.Printf("[%s] GROUP: %s\n",
+					timestamp.Format("2006-01-02 15:04:05.000"),
+					header.CleanContent(config.StripANSI))
+			}
+
 			// Print before context
 			for _, entry := range result.BeforeContext {
 				timestamp := time.Unix(0, entry.Timestamp*int64(time.Millisecond))
@@ -227,6 +450,9 @@ func formatSearchResults(results []buildkitelogs.SearchResult, config *QueryConf
 					timestamp.Format("2006-01-02 15:04:05.000"),
 					content)
 			}
+			if result.URL != "" {
+				fmt.Printf("  -> %s\n", result.URL)
+			}
 
 			// Print after context
 			for _, entry := range result.AfterContext {
@@ -248,59 +474,309 @@ func formatSearchResults(results []buildkitelogs.SearchResult, config *QueryConf
 	}
 }
 
+// writeTemplateSearchResults writes each result's lines in log order using -template
+func writeTemplateSearchResults(w io.Writer, results []buildkitelogs.SearchResult, config *QueryConfig) error {
+	write := func(entry buildkitelogs.ParquetLogEntry, match bool) error {
+		return writeTemplateEntry(w, config.entryTemplate, entry, match, config.StripANSI)
+	}
+	for _, result := range results {
+		if header := separateGroupHeader(result); header != nil {
+			if err := write(*header, false); err != nil {
+				return err
+			}
+		}
+		for _, entry := range result.BeforeContext {
+			if err := write(entry, false); err != nil {
+				return err
+			}
+		}
+		if err := write(result.Match, true); err != nil {
+			return err
+		}
+		for _, entry := range result.AfterContext {
+			if err := write(entry, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// separateGroupHeader returns the result's group header unless it is already printed as
+// the match or one of its before-context lines.
+func separateGroupHeader(result buildkitelogs.SearchResult) *buildkitelogs.ParquetLogEntry {
+	header := result.GroupHeader
+	if header == nil || header.RowNumber == result.Match.RowNumber {
+		return nil
+	}
+	for _, entry := range result.BeforeContext {
+		if entry.RowNumber == header.RowNumber {
+			return nil
+		}
+	}
+	return header
+}
+
 // QueryConfig holds configuration for CLI query operations
 type QueryConfig struct {
-	ParquetFile  string
-	Operation    string // "list-groups", "by-group", "info", "tail"
-	GroupName    string
-	Format       string // "text", "json"
-	ShowStats    bool
-	LimitEntries int   // Limit output entries (0 = no limit)
-	TailLines    int   // Number of lines to show from end (for tail operation)
-	SeekToRow    int64 // Row number to seek to (0-based)
-	RawOutput    bool  // Output raw log content without timestamps, groups, or other prefixes
+	ParquetFile     string
+	Operation       string // "list-groups", "by-group", "info", "tail"
+	GroupName       string
+	GroupExact      bool   // Match GroupName exactly instead of as a substring
+	PickGroup       int    // Nth of several groups matching GroupName (1-based, 0 = ask or fail)
+	AnnotationLabel string // Annotation label to filter by (for annotations operation)
+	Format          string // "text", "json", or "csv" and "tsv" for dump
+	Columns         string // Comma separated columns of csv and tsv output
+	ShowStats       bool
+	LimitEntries    int    // Limit output entries (0 = no limit)
+	TailLines       int    // Number of lines to show from end (for tail operation)
+	TopGroups       int    // Show only the first N groups (for list-groups, 0 = all)
+	SortGroupsBy    string // Group order for list-groups: first-seen, entries, duration
+	GroupTree       bool   // Nest groups under their parents in list-groups output
+	SeekToRow       int64  // Row number to seek to (0-based)
+	RawOutput       bool   // Output raw log content without timestamps, groups, or other prefixes
+	Template        string // Go text/template applied to each output entry
+	Explain         bool   // Print the query plan instead of running the operation
+	VerifyChecksums bool   // Fail reads of files whose data checksums do not match
+	// GroupAliasesFile maps drifting group names to canonical ones
+	GroupAliasesFile string
 	// Search operation parameters
-	SearchPattern string // Regex pattern to search for
-	AfterContext  int    // Lines to show after match
-	BeforeContext int    // Lines to show before match
-	Context       int    // Lines to show before and after match
-	CaseSensitive bool   // Case-sensitive search
-	InvertMatch   bool   // Show non-matching lines
-	Reverse       bool   // Search backwards from end/seek position
-	SearchSeek    int64  // Start search from this row (useful with Reverse)
+	SearchPattern string        // Regex pattern to search for
+	AfterContext  int           // Lines to show after match
+	BeforeContext int           // Lines to show before match
+	Context       int           // Lines to show before and after match
+	CaseSensitive bool          // Case-sensitive search
+	InvertMatch   bool          // Show non-matching lines
+	Reverse       bool          // Search backwards from end/seek position
+	SearchSeek    int64         // Start search from this row (useful with Reverse)
+	SearchTimeout time.Duration // Stop searching after this long (0 = no limit)
+	SearchFrom    time.Time     // Only search entries logged at or after this time (zero = no limit)
+	SearchTo      time.Time     // Only search entries logged at or before this time (zero = no limit)
+	GroupContext  bool          // Show the group header of each match
+	Links         bool          // Link each match to its line in the Buildkite UI
+	SearchGroupBy string        // Cluster matches by this key: "" (flat stream) or "group"
 	// ANSI processing
 	StripANSI bool // Strip ANSI escape codes from log content
+	// Noise filtering
+	DropHeartbeats bool   // Drop heartbeat/keepalive entries
+	MinSeverity    string // Drop entries below this inferred severity: info, warn, error
+	Level          string // Drop entries of any other inferred severity: info, warn, error
 	// Buildkite API parameters
 	Organization string
 	Pipeline     string
 	Build        string
+	Branch       string // Branch for -build latest
 	Job          string
+	Step         string // Step key or label, resolved to a job within the build
+	AllJobs      bool   // Run the operation on every job in the build
 	// Smart caching parameters
 	CacheTTL     time.Duration // Cache TTL for non-terminal jobs
 	ForceRefresh bool          // Force refresh cached entry
+	Invalidate   bool          // Delete the cached entry instead of querying
 	CacheURL     string        // Cache storage URL
+
+	entryTemplate *template.Template // Parsed Template, nil without -template
+	minSeverity   logparser.Severity // Parsed MinSeverity, SeverityUnknown without -min-severity
+	level         logparser.Severity // Parsed Level, SeverityUnknown without -level
+	jobWebURL     string             // Web URL of the job, set by resolveReader with -links
+
+	// Status of the job, set by resolveReader for the summary operation
+	jobStatus *buildkitelogs.JobStatus
 }
 
 // runQuery executes a query using streaming iterators
 func runQuery(ctx context.Context, config *QueryConfig) error {
+	if config.Invalidate {
+		return runCacheInvalidate(ctx, config)
+	}
+	if config.Operation == "follow" {
+		return runFollow(ctx, config)
+	}
+
+	var aliases *buildkitelogs.GroupAliases
+	if config.GroupAliasesFile != "" {
+		var err error
+		if aliases, err = buildkitelogs.LoadGroupAliases(config.GroupAliasesFile); err != nil {
+			return err
+		}
+	}
+	if config.AllJobs {
+		return runBuildQuery(ctx, config, aliases)
+	}
+
 	reader, err := resolveReader(ctx, config)
 	if err != nil {
 		return err
 	}
 	defer reader.Close()
+	configureReader(reader, config, aliases)
 
 	return runStreamingQuery(ctx, reader, config)
 }
 
+// configureReader applies the reader options of the query flags.
+func configureReader(reader *buildkitelogs.ParquetReader, config *QueryConfig, aliases *buildkitelogs.GroupAliases) {
+	reader.WithGroupAliases(aliases)
+	reader.WithMinSeverity(config.minSeverity)
+	reader.WithLevel(config.level)
+	if config.VerifyChecksums {
+		reader.WithChecksumValidation()
+	}
+	if columns := queryColumns(config); columns != nil {
+		reader.WithColumns(columns...)
+	}
+}
+
+// runBuildQuery downloads every job in the build and runs the operation on each in turn,
+// under a heading naming the job. Jobs whose logs could not be downloaded are reported
+// after the others have been queried.
+func runBuildQuery(ctx context.Context, config *QueryConfig, aliases *buildkitelogs.GroupAliases) error {
+	apiToken := os.Getenv("BUILDKITE_API_TOKEN")
+	if apiToken == "" {
+		return fmt.Errorf("BUILDKITE_API_TOKEN environment variable is required for API access")
+	}
+
+	buildkiteClient := buildkitelogs.NewBuildkiteAPIClient(apiToken, version)
+	client, err := buildkitelogs.NewClientWithAPI(ctx, buildkiteClient, config.CacheURL, buildkitelogs.WithLogger(logger))
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	build, err := client.ResolveBuild(ctx, config.Organization, config.Pipeline, config.Build, config.Branch)
+	if err != nil {
+		return fmt.Errorf("failed to resolve build: %w", err)
+	}
+	paths, downloadErr := client.DownloadBuild(ctx, config.Organization, config.Pipeline, build)
+	if downloadErr != nil && len(paths) == 0 {
+		return fmt.Errorf("failed to download build: %w", downloadErr)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("build %s has no job logs", build)
+	}
+
+	// JSON and CSV output stay parseable with the headings on stderr
+	headings := os.Stdout
+	if config.Format != "text" {
+		headings = os.Stderr
+	}
+	for i, job := range slices.Sorted(maps.Keys(paths)) {
+		if i > 0 {
+			fmt.Fprintln(headings)
+		}
+		fmt.Fprintf(headings, "=== Job %s\n", job)
+
+		location := buildkitelogs.JobLocation{Org: config.Organization, Pipeline: config.Pipeline, Build: build, Job: job}
+		reader := buildkitelogs.NewParquetReader(paths[job]).WithLogger(logger)
+		configureReader(reader, config, aliases)
+		if config.Operation == "annotations" {
+			annotations, err := client.Annotations().List(ctx, location)
+			if err != nil {
+				reader.Close()
+				return fmt.Errorf("job %s: %w", job, err)
+			}
+			reader.WithAnnotations(annotations)
+		}
+		if config.Operation == "summary" {
+			status, err := client.JobStatus(ctx, location)
+			if err != nil {
+				reader.Close()
+				return fmt.Errorf("job %s: %w", job, err)
+			}
+			config.jobStatus = status
+		}
+		if config.Links {
+			config.jobWebURL = buildkitelogs.JobWebURL(location.Org, location.Pipeline, location.Build, location.Job)
+		}
+
+		err := runStreamingQuery(ctx, reader, config)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("job %s: %w", job, err)
+		}
+	}
+
+	if downloadErr != nil {
+		return fmt.Errorf("failed to download some jobs: %w", downloadErr)
+	}
+	return nil
+}
+
+// queryColumns returns the columns the operation needs, or nil if it needs every column.
+// Raw dumps print only content, so they skip decoding the rest of the file.
+func queryColumns(config *QueryConfig) []string {
+	if config.Operation != "dump" || !config.RawOutput || config.entryTemplate != nil || config.Format != "text" {
+		return nil
+	}
+	if config.DropHeartbeats {
+		return []string{"content", "flags"}
+	}
+	return []string{"content"}
+}
+
+// runFollow prints a job's entries as they are logged until the job finishes.
+func runFollow(ctx context.Context, config *QueryConfig) error {
+	apiToken := os.Getenv("BUILDKITE_API_TOKEN")
+	if apiToken == "" {
+		return fmt.Errorf("BUILDKITE_API_TOKEN environment variable is required for API access")
+	}
+
+	buildkiteClient := buildkitelogs.NewBuildkiteAPIClient(apiToken, version)
+	client, err := buildkitelogs.NewClientWithAPI(ctx, buildkiteClient, config.CacheURL, buildkitelogs.WithLogger(logger))
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	location, err := resolveJobLocation(ctx, client, config)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	shown := 0
+	heartbeats := 0
+	entries := client.FollowLogs(ctx, location.Org, location.Pipeline, location.Build, location.Job)
+	for entry, err := range dropHeartbeats(entries, config, &heartbeats) {
+		if errors.Is(err, context.Canceled) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error following log: %w", err)
+		}
+		if severity := entry.InferredSeverity(); severity < config.minSeverity || (config.level != logparser.SeverityUnknown && severity != config.level) {
+			continue
+		}
+
+		batch := []buildkitelogs.ParquetLogEntry{entry}
+		if config.Format == "json" {
+			if err := writeJSONLines(batch, os.Stdout); err != nil {
+				return err
+			}
+		} else if err := writeLogEntries(os.Stdout, batch, config); err != nil {
+			return err
+		}
+
+		shown++
+		if config.LimitEntries > 0 && shown >= config.LimitEntries {
+			break
+		}
+	}
+	printHeartbeatStats(heartbeats, config)
+	return nil
+}
+
 // resolveReader creates a ParquetReader from either a local file or the Buildkite API.
 func resolveReader(ctx context.Context, config *QueryConfig) (*buildkitelogs.ParquetReader, error) {
 	// If file path is provided directly, use it (non-owned reader)
 	if config.ParquetFile != "" {
-		return buildkitelogs.NewParquetReader(config.ParquetFile), nil
+		return buildkitelogs.NewParquetReader(config.ParquetFile).WithLogger(logger), nil
 	}
 
 	// If API parameters are provided, download and cache using high-level client
-	if config.Organization != "" && config.Pipeline != "" && config.Build != "" && config.Job != "" {
+	if config.Organization != "" && config.Pipeline != "" && config.Build != "" && (config.Job != "" || config.Step != "") {
 		apiToken := os.Getenv("BUILDKITE_API_TOKEN")
 		if apiToken == "" {
 			return nil, fmt.Errorf("BUILDKITE_API_TOKEN environment variable is required for API access")
@@ -308,25 +784,109 @@ func resolveReader(ctx context.Context, config *QueryConfig) (*buildkitelogs.Par
 
 		// Create buildkite client and high-level client
 		buildkiteClient := buildkitelogs.NewBuildkiteAPIClient(apiToken, version)
-		client, err := buildkitelogs.NewClientWithAPI(ctx, buildkiteClient, config.CacheURL)
+		client, err := buildkitelogs.NewClientWithAPI(ctx, buildkiteClient, config.CacheURL, buildkitelogs.WithLogger(logger))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create client: %w", err)
 		}
 		defer client.Close()
 
-		reader, err := client.NewReader(ctx, config.Organization, config.Pipeline, config.Build, config.Job, config.CacheTTL, config.ForceRefresh)
+		location, err := resolveJobLocation(ctx, client, config)
+		if err != nil {
+			return nil, err
+		}
+
+		reader, err := client.NewReader(ctx, location.Org, location.Pipeline, location.Build, location.Job, config.CacheTTL, config.ForceRefresh)
 		if err != nil {
 			return nil, fmt.Errorf("failed to download and cache logs: %w", err)
 		}
 
+		if config.Operation == "summary" {
+			if config.jobStatus, err = client.JobStatus(ctx, location); err != nil {
+				reader.Close()
+				return nil, fmt.Errorf("failed to get job status: %w", err)
+			}
+		}
+
+		if config.Links {
+			config.jobWebURL = buildkitelogs.JobWebURL(location.Org, location.Pipeline, location.Build, location.Job)
+			info, err := client.JobInfo(ctx, location.Org, location.Pipeline, location.Build, location.Job)
+			if err != nil {
+				reader.Close()
+				return nil, fmt.Errorf("failed to get job URL: %w", err)
+			}
+			if info.Status.WebURL != "" {
+				config.jobWebURL = info.Status.WebURL
+			}
+		}
+
 		return reader, nil
 	}
 
 	return nil, fmt.Errorf("either -file or API parameters must be provided")
 }
 
+// resolveJobLocation resolves -build latest and -step to the job the API parameters refer to.
+func resolveJobLocation(ctx context.Context, client *buildkitelogs.Client, config *QueryConfig) (buildkitelogs.JobLocation, error) {
+	build, err := client.ResolveBuild(ctx, config.Organization, config.Pipeline, config.Build, config.Branch)
+	if err != nil {
+		return buildkitelogs.JobLocation{}, fmt.Errorf("failed to resolve build: %w", err)
+	}
+
+	if config.Step != "" {
+		location, err := client.ResolveStep(ctx, config.Organization, config.Pipeline, build, config.Step)
+		if err != nil {
+			return buildkitelogs.JobLocation{}, fmt.Errorf("failed to resolve step: %w", err)
+		}
+		return location, nil
+	}
+
+	return buildkitelogs.JobLocation{
+		Org:      config.Organization,
+		Pipeline: config.Pipeline,
+		Build:    build,
+		Job:      config.Job,
+	}, nil
+}
+
+// runCacheInvalidate deletes the cached log for the job the API parameters refer to.
+func runCacheInvalidate(ctx context.Context, config *QueryConfig) error {
+	apiToken := os.Getenv("BUILDKITE_API_TOKEN")
+	if apiToken == "" {
+		return fmt.Errorf("BUILDKITE_API_TOKEN environment variable is required for API access")
+	}
+
+	buildkiteClient := buildkitelogs.NewBuildkiteAPIClient(apiToken, version)
+	client, err := buildkitelogs.NewClientWithAPI(ctx, buildkiteClient, config.CacheURL, buildkitelogs.WithLogger(logger))
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	location, err := resolveJobLocation(ctx, client, config)
+	if err != nil {
+		return err
+	}
+
+	removed, err := client.Invalidate(ctx, location)
+	if err != nil {
+		return err
+	}
+
+	key := buildkitelogs.GenerateBlobKey(location.Org, location.Pipeline, location.Build, location.Job)
+	if removed {
+		fmt.Fprintf(os.Stderr, "Invalidated cache entry %s\n", key)
+	} else {
+		fmt.Fprintf(os.Stderr, "No cache entry found for %s\n", key)
+	}
+	return nil
+}
+
 // runStreamingQuery executes streaming queries for memory efficiency
 func runStreamingQuery(ctx context.Context, reader *buildkitelogs.ParquetReader, config *QueryConfig) error {
+	if config.Explain {
+		return explainQuery(reader, config)
+	}
+
 	start := time.Now()
 
 	switch config.Operation {
@@ -339,6 +899,9 @@ func runStreamingQuery(ctx context.Context, reader *buildkitelogs.ParquetReader,
 		if config.GroupName == "" {
 			return fmt.Errorf("group pattern is required for by-group operation")
 		}
+		if err := resolveGroupName(ctx, reader, config, os.Stdin, os.Stderr); err != nil {
+			return err
+		}
 		return streamByGroup(ctx, reader, config, start)
 	case "search":
 		if config.SearchPattern == "" {
@@ -351,90 +914,131 @@ func runStreamingQuery(ctx context.Context, reader *buildkitelogs.ParquetReader,
 		return seekToRow(ctx, reader, config, start)
 	case "dump":
 		return streamDump(ctx, reader, config, start)
+	case "group-tails":
+		return showGroupTails(ctx, reader, config, start)
+	case "timings":
+		return showGroupTimings(ctx, reader, config, start)
+	case "summary":
+		return showFailureSummary(ctx, reader, config, start)
+	case "docker-steps":
+		return showDockerSteps(ctx, reader, config, start)
+	case "env":
+		return showEnvironment(ctx, reader, config, start)
+	case "annotations":
+		return showAnnotations(ctx, reader, config, start)
 	default:
 		return fmt.Errorf("unknown operation: %s", config.Operation)
 	}
 }
 
-// streamListGroups handles list-groups operation using streaming
-func streamListGroups(ctx context.Context, reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
-	// Use streaming iterator to build group statistics
-	groupMap := make(map[string]*buildkitelogs.GroupInfo)
-	totalEntries := 0
+// explainQuery prints the plan of the read the operation would perform
+func explainQuery(reader *buildkitelogs.ParquetReader, config *QueryConfig) error {
+	var plan *buildkitelogs.QueryPlan
+	var err error
 
-	for entry, err := range reader.ReadEntriesIter(ctx) {
-		if err != nil {
-			return fmt.Errorf("error reading entries: %w", err)
+	switch config.Operation {
+	case "list-groups", "dump", "group-tails", "timings", "summary", "docker-steps":
+		plan, err = reader.ExplainRead()
+	case "info":
+		plan, err = reader.ExplainMetadata()
+	case "by-group":
+		plan, err = reader.ExplainFilterByGroup(config.GroupName)
+	case "env":
+		plan, err = reader.ExplainFilterByGroup(environmentGroup(config))
+	case "search":
+		plan, err = reader.ExplainSearch(searchOptions(config, time.Now()))
+	case "tail":
+		tailLines := int64(config.TailLines)
+		if tailLines <= 0 {
+			tailLines = 10
 		}
-
-		totalEntries++
-
-		groupName := entry.Group
-		if groupName == "" {
-			groupName = "<no group>"
+		plan, err = reader.ExplainMetadata()
+		if err == nil {
+			plan, err = reader.ExplainSeek(max(plan.TotalRows-tailLines, 0))
 		}
+	case "seek":
+		plan, err = reader.ExplainSeek(config.SeekToRow)
+	case "annotations":
+		plan, err = reader.ExplainAnnotatedEntries(config.AnnotationLabel)
+	default:
+		return fmt.Errorf("unknown operation: %s", config.Operation)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to explain query: %w", err)
+	}
 
-		info, exists := groupMap[groupName]
-		if !exists {
-			entryTime := time.Unix(0, entry.Timestamp*int64(time.Millisecond))
-			info = &buildkitelogs.GroupInfo{
-				Name:      groupName,
-				FirstSeen: entryTime,
-				LastSeen:  entryTime,
-			}
-			groupMap[groupName] = info
-		}
+	if config.Format == "json" {
+		return writeJSONLines([]*buildkitelogs.QueryPlan{plan}, os.Stdout)
+	}
 
-		info.EntryCount++
+	fmt.Printf("Query Plan (%s):\n", config.Operation)
+	fmt.Printf("  Strategy:     %s\n", plan.Strategy)
+	fmt.Printf("  Columns:      %s\n", strings.Join(plan.Columns, ", "))
+	fmt.Printf("  Group Index:  %t\n", plan.GroupIndex)
+	fmt.Printf("  Row Groups:   %d of %d %v\n", len(plan.RowGroupsRead), plan.RowGroups, plan.RowGroupsRead)
+	fmt.Printf("  Rows Scanned: %d of %d\n", plan.RowsScanned, plan.TotalRows)
+	fmt.Printf("  Scan Bytes:   %d of %d (%.2f MB)\n", plan.ScanBytes, plan.FileSize, float64(plan.ScanBytes)/(1024*1024))
+	for _, note := range plan.Notes {
+		fmt.Printf("  Note:         %s\n", note)
+	}
+	if config.LimitEntries > 0 {
+		fmt.Printf("  Note:         -limit %d may stop the read early\n", config.LimitEntries)
+	}
+	return nil
+}
 
-		entryTime := time.Unix(0, entry.Timestamp*int64(time.Millisecond))
-		if entryTime.Before(info.FirstSeen) {
-			info.FirstSeen = entryTime
-		}
-		if entryTime.After(info.LastSeen) {
-			info.LastSeen = entryTime
-		}
+// streamListGroups handles list-groups operation using streaming
+func streamListGroups(ctx context.Context, reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	heartbeats := 0
+	groups, totalEntries, err := collectGroups(dropHeartbeats(reader.ReadEntriesIter(ctx), config, &heartbeats))
+	if err != nil {
+		return err
+	}
+	if err := buildkitelogs.SortGroups(groups, buildkitelogs.GroupSort(config.SortGroupsBy)); err != nil {
+		return err
+	}
+	totalGroups := len(groups)
+	if config.TopGroups > 0 && len(groups) > config.TopGroups {
+		groups = groups[:config.TopGroups]
+	}
 
+	// Format output
+	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
+	if err := formatStreamingGroupsResult(ctx, groups, totalGroups, totalEntries, queryTime, config); err != nil {
+		return err
 	}
+	printHeartbeatStats(heartbeats, config)
+	return nil
+}
 
-	// Convert to slice and sort
-	groups := make([]buildkitelogs.GroupInfo, 0, len(groupMap))
-	for _, info := range groupMap {
-		groups = append(groups, *info)
+// collectGroups builds per-group statistics from a stream of entries, ordered by first appearance
+func collectGroups(entries iter.Seq2[buildkitelogs.ParquetLogEntry, error]) ([]buildkitelogs.GroupInfo, int, error) {
+	groups, totalEntries, err := buildkitelogs.ListGroupsFromEntries(entries)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	// Sort by first seen time (simple sorting)
-	for i := 0; i < len(groups)-1; i++ {
-		for j := i + 1; j < len(groups); j++ {
-			if groups[j].FirstSeen.Before(groups[i].FirstSeen) {
-				groups[i], groups[j] = groups[j], groups[i]
-			}
+	for i := range groups {
+		if groups[i].Name == "" {
+			groups[i].Name = "<no group>"
 		}
 	}
-
-	// Format output
-	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
-	return formatStreamingGroupsResult(ctx, groups, totalEntries, queryTime, config)
+	return groups, totalEntries, nil
 }
 
 // streamSearch handles search operation using streaming with regex pattern matching and context lines
 func streamSearch(ctx context.Context, reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
-	// Create search options
-	options := buildkitelogs.SearchOptions{
-		Pattern:       config.SearchPattern,
-		CaseSensitive: config.CaseSensitive,
-		InvertMatch:   config.InvertMatch,
-		BeforeContext: config.BeforeContext,
-		AfterContext:  config.AfterContext,
-		Context:       config.Context,
-		Reverse:       config.Reverse,
-		SeekStart:     config.SearchSeek,
-	}
+	options := searchOptions(config, start)
 
 	var results []buildkitelogs.SearchResult
 	matchesFound := 0
+	timedOut := false
 
 	for result, err := range reader.SearchEntriesIter(ctx, options) {
+		if errors.Is(err, buildkitelogs.ErrSearchTimeout) {
+			timedOut = true
+			break
+		}
 		if err != nil {
 			return fmt.Errorf("error during search: %w", err)
 		}
@@ -450,7 +1054,31 @@ func streamSearch(ctx context.Context, reader *buildkitelogs.ParquetReader, conf
 
 	// Format output
 	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
-	return formatSearchResultsLibrary(results, matchesFound, queryTime, config)
+	return formatSearchResultsLibrary(results, matchesFound, timedOut, queryTime, config)
+}
+
+// searchOptions returns the library search options of the query, for a search started at start
+func searchOptions(config *QueryConfig, start time.Time) buildkitelogs.SearchOptions {
+	options := buildkitelogs.SearchOptions{
+		Pattern:        config.SearchPattern,
+		CaseSensitive:  config.CaseSensitive,
+		InvertMatch:    config.InvertMatch,
+		BeforeContext:  config.BeforeContext,
+		AfterContext:   config.AfterContext,
+		Context:        config.Context,
+		Reverse:        config.Reverse,
+		SeekStart:      config.SearchSeek,
+		DropHeartbeats: config.DropHeartbeats,
+		GroupContext:   config.GroupContext,
+		JobURL:         config.jobWebURL,
+		MinTimestamp:   config.SearchFrom,
+		MaxTimestamp:   config.SearchTo,
+		Level:          config.level,
+	}
+	if config.SearchTimeout > 0 {
+		options.Deadline = start.Add(config.SearchTimeout)
+	}
+	return options
 }
 
 // streamByGroup handles by-group operation using streaming with optional limiting
@@ -458,8 +1086,13 @@ func streamByGroup(ctx context.Context, reader *buildkitelogs.ParquetReader, con
 	var entries []buildkitelogs.ParquetLogEntry
 	totalEntries := 0
 	matchedEntries := 0
+	heartbeats := 0
 
-	for entry, err := range reader.FilterByGroupIter(ctx, config.GroupName) {
+	groupEntries := reader.FilterByGroupIter(ctx, config.GroupName)
+	if config.GroupExact {
+		groupEntries = reader.FilterByGroupNameIter(ctx, config.GroupName)
+	}
+	for entry, err := range dropHeartbeats(groupEntries, config, &heartbeats) {
 		if err != nil {
 			return fmt.Errorf("error filtering entries: %w", err)
 		}
@@ -483,7 +1116,73 @@ func streamByGroup(ctx context.Context, reader *buildkitelogs.ParquetReader, con
 
 	// Format output
 	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
-	return formatStreamingEntriesResult(entries, totalEntries, matchedEntries, queryTime, config)
+	if err := formatStreamingEntriesResult(entries, totalEntries, matchedEntries, queryTime, config); err != nil {
+		return err
+	}
+	printHeartbeatStats(heartbeats, config)
+	return nil
+}
+
+// resolveGroupName narrows a -group pattern that matches several distinct groups to one
+// group, chosen with -pick or, on a terminal, from a list. Otherwise it fails with the
+// list rather than merging the groups' entries.
+func resolveGroupName(ctx context.Context, reader *buildkitelogs.ParquetReader, config *QueryConfig, in *os.File, out *os.File) error {
+	if config.GroupExact {
+		return nil
+	}
+	names, err := reader.MatchingGroups(ctx, config.GroupName)
+	if err != nil {
+		return fmt.Errorf("failed to list matching groups: %w", err)
+	}
+
+	var name string
+	switch {
+	case config.PickGroup > len(names):
+		return fmt.Errorf("-pick %d is out of range: %q matches %d groups", config.PickGroup, config.GroupName, len(names))
+	case config.PickGroup > 0:
+		name = names[config.PickGroup-1]
+	case len(names) <= 1:
+		return nil
+	case config.Format != "json" && isTerminal(in) && isTerminal(out):
+		if name, err = promptGroupChoice(names, config.GroupName, in, out); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%s\nChoose one with -pick N or match it exactly with -group-exact", groupChoiceList(names, config.GroupName))
+	}
+
+	config.GroupName, config.GroupExact = name, true
+	return nil
+}
+
+// groupChoiceList lists the groups matching pattern, numbered for -pick
+func groupChoiceList(names []string, pattern string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "group pattern %q matches %d groups:", pattern, len(names))
+	for i, name := range names {
+		fmt.Fprintf(&b, "\n  %d) %s", i+1, name)
+	}
+	return b.String()
+}
+
+// promptGroupChoice asks which of the groups matching pattern to show
+func promptGroupChoice(names []string, pattern string, in io.Reader, out io.Writer) (string, error) {
+	fmt.Fprintf(out, "%s\nPick a group [1-%d]: ", groupChoiceList(names, pattern), len(names))
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("no group picked: %w", err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || n < 1 || n > len(names) {
+		return "", fmt.Errorf("invalid choice %q (want 1-%d)", strings.TrimSpace(line), len(names))
+	}
+	return names[n-1], nil
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
 
 func writeJSONLines[T any](entries []T, writer io.Writer) error {
@@ -496,14 +1195,31 @@ func writeJSONLines[T any](entries []T, writer io.Writer) error {
 	return nil
 }
 
-// formatStreamingGroupsResult formats groups output from streaming query
-func formatStreamingGroupsResult(ctx context.Context, groups []buildkitelogs.GroupInfo, totalEntries int, queryTime float64, config *QueryConfig) error {
+// formatStreamingGroupsResult formats groups output from streaming query. groups may be
+// the top of totalGroups groups.
+func formatStreamingGroupsResult(ctx context.Context, groups []buildkitelogs.GroupInfo, totalGroups, totalEntries int, queryTime float64, config *QueryConfig) error {
+	// Buffer stdout so jobs with tens of thousands of groups aren't written a line at a time
+	out := bufio.NewWriter(os.Stdout)
+
 	if config.Format == "json" {
-		return writeJSONLines(groups, io.Writer(os.Stdout))
+		var err error
+		if config.GroupTree {
+			err = writeJSONLines(buildkitelogs.GroupTree(groups), out)
+		} else {
+			err = writeJSONLines(groups, out)
+		}
+		if err != nil {
+			return err
+		}
+		return out.Flush()
 	}
 
 	// Text format
-	fmt.Fprintf(os.Stderr, "Groups found: %d\n\n", len(groups))
+	if len(groups) < totalGroups {
+		fmt.Fprintf(os.Stderr, "Groups found: %d (showing top %d by %s)\n\n", totalGroups, len(groups), config.SortGroupsBy)
+	} else {
+		fmt.Fprintf(os.Stderr, "Groups found: %d\n\n", totalGroups)
+	}
 
 	if len(groups) == 0 {
 		fmt.Fprintln(os.Stderr, "No groups found.")
@@ -511,32 +1227,172 @@ func formatStreamingGroupsResult(ctx context.Context, groups []buildkitelogs.Gro
 	}
 
 	// Print table header
-	fmt.Printf("%-40s %8s %19s %19s\n",
-		"GROUP NAME", "ENTRIES", "FIRST SEEN", "LAST SEEN")
-	fmt.Println(strings.Repeat("-", 89))
+	fmt.Fprintf(out, "%-40s %8s %19s %19s %10s\n",
+		"GROUP NAME", "ENTRIES", "FIRST SEEN", "LAST SEEN", "DURATION")
+	fmt.Fprintln(out, strings.Repeat("-", 100))
 
-	for _, group := range groups {
-		fmt.Printf("%-40s %8d %19s %19s\n",
-			truncateString(group.Name, 40),
-			group.EntryCount,
-			group.FirstSeen.Format("2006-01-02 15:04:05"),
-			group.LastSeen.Format("2006-01-02 15:04:05"))
+	if config.GroupTree {
+		writeGroupTree(out, buildkitelogs.GroupTree(groups))
+	} else {
+		for _, group := range groups {
+			writeGroupRow(out, group, group.Name)
+		}
+	}
+	if err := out.Flush(); err != nil {
+		return err
 	}
 
 	if config.ShowStats {
 		fmt.Fprintf(os.Stderr, "\n--- Query Statistics (Streaming) ---\n")
 		fmt.Fprintf(os.Stderr, "Total entries: %d\n", totalEntries)
-		fmt.Fprintf(os.Stderr, "Total groups: %d\n", len(groups))
+		fmt.Fprintf(os.Stderr, "Total groups: %d\n", totalGroups)
 		fmt.Fprintf(os.Stderr, "Query time: %.2f ms\n", queryTime)
 	}
 
 	return nil
 }
 
+// showGroupTimings handles the timings operation: the time each group took
+func showGroupTimings(ctx context.Context, reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	report, err := reader.GroupTimings(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to time groups: %w", err)
+	}
+	for i := range report.Groups {
+		if report.Groups[i].Name == "" {
+			report.Groups[i].Name = "<no group>"
+		}
+	}
+
+	if config.Format == "json" {
+		return writeJSONLines([]*buildkitelogs.TimingReport{report}, os.Stdout)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	writeTimingReport(out, report, config.StripANSI)
+	if err := out.Flush(); err != nil {
+		return err
+	}
+
+	if config.ShowStats {
+		fmt.Fprintf(os.Stderr, "\n--- Query Statistics (Streaming) ---\n")
+		fmt.Fprintf(os.Stderr, "Total entries: %d\n", report.Entries)
+		fmt.Fprintf(os.Stderr, "Total groups: %d\n", len(report.Groups))
+		fmt.Fprintf(os.Stderr, "Query time: %.2f ms\n", float64(time.Since(start).Nanoseconds())/1e6)
+	}
+	return nil
+}
+
+// writeTimingReport writes the timings table, nested groups indented under their
+// parents, followed by the job's totals
+func writeTimingReport(out io.Writer, report *buildkitelogs.TimingReport, stripANSI bool) {
+	fmt.Fprintf(out, "%-40s %8s %8s %10s %7s %10s\n", "GROUP NAME", "ENTRIES", "START", "DURATION", "% JOB", "GAP BEFORE")
+	fmt.Fprintln(out, strings.Repeat("-", 88))
+	for _, group := range report.Groups {
+		name := group.Name
+		if stripANSI {
+			name = buildkitelogs.StripANSI(name)
+		}
+		name = strings.Repeat("  ", group.Depth) + name
+		if !group.Timed {
+			fmt.Fprintf(out, "%-40s %8d %8s %10s %7s %10s\n", truncateString(name, 40), group.Entries, "-", "-", "-", "-")
+			continue
+		}
+		fmt.Fprintf(out, "%-40s %8d %8s %10s %6.1f%% %10s\n",
+			truncateString(name, 40),
+			group.Entries,
+			group.Start.Format("15:04:05"),
+			group.Duration.Round(time.Second),
+			group.Percent,
+			group.Gap.Round(time.Second))
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Job time:            %s\n", report.Duration.Round(time.Second))
+	fmt.Fprintf(out, "Time between groups: %s (%.1f%%)\n", report.Gap.Round(time.Second), report.GapPercent)
+}
+
+// showFailureSummary handles the summary operation: a Markdown failure summary of the job
+func showFailureSummary(ctx context.Context, reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	summary, err := reader.FailureSummary(ctx, config.TailLines)
+	if err != nil {
+		return fmt.Errorf("failed to summarize log: %w", err)
+	}
+	summary.WithJobStatus(config.jobStatus)
+	if config.StripANSI {
+		for i := range summary.Errors {
+			summary.Errors[i].Content = buildkitelogs.StripANSI(summary.Errors[i].Content)
+		}
+	}
+
+	if config.Format == "json" {
+		return writeJSONLines([]*buildkitelogs.FailureSummary{summary}, os.Stdout)
+	}
+	if err := summary.WriteMarkdown(os.Stdout); err != nil {
+		return err
+	}
+
+	if config.ShowStats {
+		fmt.Fprintf(os.Stderr, "\n--- Query Statistics (Streaming) ---\n")
+		fmt.Fprintf(os.Stderr, "Total entries: %d\n", summary.Entries)
+		fmt.Fprintf(os.Stderr, "Error lines: %d\n", summary.ErrorCount)
+		fmt.Fprintf(os.Stderr, "Query time: %.2f ms\n", float64(time.Since(start).Nanoseconds())/1e6)
+	}
+	return nil
+}
+
+// writeGroupRow writes a row of the list-groups table, naming the group label
+func writeGroupRow(out io.Writer, group buildkitelogs.GroupInfo, label string) {
+	fmt.Fprintf(out, "%-40s %8d %19s %19s %10s\n",
+		truncateString(label, 40),
+		group.EntryCount,
+		group.FirstSeen.Format("2006-01-02 15:04:05"),
+		group.LastSeen.Format("2006-01-02 15:04:05"),
+		group.Duration().Round(time.Second))
+}
+
+// writeGroupTree writes the rows of the top-level nodes, each followed by its children.
+func writeGroupTree(out io.Writer, roots []*buildkitelogs.GroupNode) {
+	for _, root := range roots {
+		writeGroupRow(out, root.GroupInfo, root.Name)
+		writeGroupChildren(out, root.Children, "")
+	}
+}
+
+// writeGroupChildren writes the rows of nested nodes and their children, drawing the tree
+// before each name. prefix continues the lines of the nodes' ancestors.
+func writeGroupChildren(out io.Writer, nodes []*buildkitelogs.GroupNode, prefix string) {
+	for i, node := range nodes {
+		branch, continuation := "|-- ", "|   "
+		if i == len(nodes)-1 {
+			branch, continuation = "`-- ", "    "
+		}
+		writeGroupRow(out, node.GroupInfo, prefix+branch+node.Name)
+		writeGroupChildren(out, node.Children, prefix+continuation)
+	}
+}
+
 // formatSearchResultsLibrary formats search results with context lines using library types
-func formatSearchResultsLibrary(results []buildkitelogs.SearchResult, matchesFound int, queryTime float64, config *QueryConfig) error {
+func formatSearchResultsLibrary(results []buildkitelogs.SearchResult, matchesFound int, timedOut bool, queryTime float64, config *QueryConfig) error {
+	var clusters []searchResultGroup
+	if config.SearchGroupBy == "group" {
+		clusters = groupSearchResults(results, config.StripANSI)
+	}
+
 	if config.Format == "json" {
-		return writeJSONLines(results, os.Stdout)
+		var err error
+		if clusters != nil {
+			err = writeJSONLines(clusters, os.Stdout)
+		} else {
+			err = writeJSONLines(results, os.Stdout)
+		}
+		if err != nil {
+			return err
+		}
+		if timedOut {
+			// Keep stdout to results only; report the truncation machine-readably on stderr
+			return writeJSONLines([]map[string]any{{"truncated_by_timeout": true, "matches": matchesFound}}, os.Stderr)
+		}
+		return nil
 	}
 
 	// Output search results using consistent formatting
@@ -545,6 +1401,9 @@ func formatSearchResultsLibrary(results []buildkitelogs.SearchResult, matchesFou
 		if config.LimitEntries > 0 && matchesFound >= config.LimitEntries {
 			limitText = fmt.Sprintf(" (limited to %d)", config.LimitEntries)
 		}
+		if timedOut {
+			limitText += fmt.Sprintf(" (partial, stopped after %s)", config.SearchTimeout)
+		}
 		fmt.Fprintf(os.Stderr, "Matches found: %d%s\n\n", matchesFound, limitText)
 
 		if len(results) == 0 {
@@ -552,19 +1411,60 @@ func formatSearchResultsLibrary(results []buildkitelogs.SearchResult, matchesFou
 		}
 	}
 
-	if len(results) > 0 {
+	switch {
+	case clusters != nil:
+		for i, cluster := range clusters {
+			if !config.RawOutput {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("=== %s (%d matches)\n", cluster.Group, cluster.Matches)
+			}
+			formatSearchResults(cluster.Results, config)
+		}
+	case len(results) > 0:
 		formatSearchResults(results, config)
 	}
 
 	if config.ShowStats {
 		fmt.Fprintf(os.Stderr, "\n--- Search Statistics (Streaming) ---\n")
 		fmt.Fprintf(os.Stderr, "Matches found: %d\n", matchesFound)
+		fmt.Fprintf(os.Stderr, "Truncated by timeout: %t\n", timedOut)
 		fmt.Fprintf(os.Stderr, "Query time: %.2f ms\n", queryTime)
 	}
 
 	return nil
 }
 
+// searchResultGroup is the search results whose match is in one group
+type searchResultGroup struct {
+	Group   string                       `json:"group"`
+	Matches int                          `json:"matches"`
+	Results []buildkitelogs.SearchResult `json:"results"`
+}
+
+// groupSearchResults clusters results by their match's group, in order of each group's
+// first match. Entries without a group are clustered under "<no group>".
+func groupSearchResults(results []buildkitelogs.SearchResult, stripANSI bool) []searchResultGroup {
+	clusters := []searchResultGroup{}
+	index := make(map[string]int)
+	for _, result := range results {
+		group := result.Match.CleanGroup(stripANSI)
+		if group == "" {
+			group = "<no group>"
+		}
+		i, ok := index[group]
+		if !ok {
+			i = len(clusters)
+			index[group] = i
+			clusters = append(clusters, searchResultGroup{Group: group})
+		}
+		clusters[i].Matches++
+		clusters[i].Results = append(clusters[i].Results, result)
+	}
+	return clusters
+}
+
 // formatStreamingEntriesResult formats entries output from streaming query
 func formatStreamingEntriesResult(entries []buildkitelogs.ParquetLogEntry, totalEntries, matchedEntries int, queryTime float64, config *QueryConfig) error {
 	if config.Format == "json" {
@@ -618,6 +1518,200 @@ func showFileInfo(reader *buildkitelogs.ParquetReader, config *QueryConfig) erro
 	fmt.Fprintf(os.Stderr, "  Columns:      %d\n", info.ColumnCount)
 	fmt.Fprintf(os.Stderr, "  File Size:    %d bytes (%.2f MB)\n", info.FileSize, float64(info.FileSize)/(1024*1024))
 	fmt.Fprintf(os.Stderr, "  Row Groups:   %d\n", info.NumRowGroups)
+	if info.SortedBy != "" {
+		fmt.Fprintf(os.Stderr, "  Sorted By:    %s\n", info.SortedBy)
+	}
+	fmt.Fprintf(os.Stderr, "  Checksums:    %t\n", info.Checksummed)
+	if info.ContentOmitted {
+		fmt.Fprintf(os.Stderr, "  Content:      omitted (hashes and lengths only)\n")
+	}
+	if info.ParserVersion > 0 {
+		fmt.Fprintf(os.Stderr, "  Parser:       version %d\n", info.ParserVersion)
+	} else {
+		fmt.Fprintf(os.Stderr, "  Parser:       version not recorded\n")
+	}
+	if producer := info.Producer; producer != nil {
+		fmt.Fprintf(os.Stderr, "  Written By:   %s (buildkite-logs %s)\n", producer.Binary, producer.PackageVersion)
+		if options := producer.ParserOptions; options != nil {
+			fmt.Fprintf(os.Stderr, "  Parser Opts:  max line %d bytes, truncate long lines: %t\n", options.MaxLineBytes, options.TruncateLongLines)
+		}
+		if producer.Filtered {
+			fmt.Fprintf(os.Stderr, "  Filtered:     true\n")
+		}
+		if producer.InputTruncatedAt > 0 {
+			fmt.Fprintf(os.Stderr, "  Truncated:    log cut at %d bytes\n", producer.InputTruncatedAt)
+		}
+	}
+
+	return nil
+}
+
+// showGroupTails shows the last N entries of every group, where exit messages usually are
+func showGroupTails(ctx context.Context, reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	tailLines := config.TailLines
+	if tailLines <= 0 {
+		tailLines = 10 // Default to 10 lines
+	}
+
+	tails, err := reader.GroupTails(ctx, tailLines)
+	if err != nil {
+		return fmt.Errorf("error reading group tails: %w", err)
+	}
+
+	if config.Format == "json" {
+		return writeJSONLines(tails, os.Stdout)
+	}
+
+	if !config.RawOutput {
+		fmt.Fprintf(os.Stderr, "Groups found: %d (last %d entries each)\n\n", len(tails), tailLines)
+	}
+
+	for i, tail := range tails {
+		if i > 0 {
+			fmt.Println()
+		}
+		group := tail.Group
+		if group == "" {
+			group = "<no group>"
+		}
+		fmt.Printf("=== %s (rows %d-%d) ===\n", buildkitelogs.StripANSI(group), tail.FirstRow, tail.LastRow)
+		if err := writeLogEntries(os.Stdout, tail.Entries, config); err != nil {
+			return err
+		}
+	}
+
+	if config.ShowStats {
+		queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
+		fmt.Fprintf(os.Stderr, "\n--- Query Statistics ---\n")
+		fmt.Fprintf(os.Stderr, "Groups: %d\n", len(tails))
+		fmt.Fprintf(os.Stderr, "Query time: %.2f ms\n", queryTime)
+	}
+
+	return nil
+}
+
+// showDockerSteps shows the docker build steps found in the file with their durations
+func showDockerSteps(ctx context.Context, reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	steps, err := reader.DockerBuildSteps(ctx)
+	if err != nil {
+		return fmt.Errorf("error analyzing docker build: %w", err)
+	}
+
+	if config.Format == "json" {
+		return writeJSONLines(steps, os.Stdout)
+	}
+
+	fmt.Fprintf(os.Stderr, "Docker build steps found: %d\n\n", len(steps))
+
+	if len(steps) == 0 {
+		fmt.Fprintln(os.Stderr, "No docker build output found.")
+		return nil
+	}
+
+	fmt.Printf("%-9s %-50s %10s %-8s\n", "STEP", "NAME", "DURATION", "STATUS")
+	fmt.Println(strings.Repeat("-", 80))
+
+	var total time.Duration
+	for _, step := range steps {
+		status := "running"
+		switch {
+		case step.Error != "":
+			status = "error"
+		case step.Cached:
+			status = "cached"
+		case step.Done:
+			status = "done"
+		}
+		total += step.Duration
+		fmt.Printf("%-9s %-50s %10s %-8s\n",
+			truncateString(step.ID, 9),
+			truncateString(step.Name, 50),
+			step.Duration.Round(100*time.Millisecond),
+			status)
+	}
+
+	if config.ShowStats {
+		queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
+		fmt.Fprintf(os.Stderr, "\n--- Docker Build Statistics ---\n")
+		fmt.Fprintf(os.Stderr, "Steps: %d\n", len(steps))
+		fmt.Fprintf(os.Stderr, "Total step time: %s\n", total.Round(100*time.Millisecond))
+		fmt.Fprintf(os.Stderr, "Query time: %.2f ms\n", queryTime)
+	}
+
+	return nil
+}
+
+// environmentGroup returns the group pattern of the env operation: -group, or the
+// library default.
+func environmentGroup(config *QueryConfig) string {
+	if config.GroupName != "" {
+		return config.GroupName
+	}
+	return buildkitelogs.DefaultEnvironmentGroup
+}
+
+// showEnvironment shows the environment variables printed in the job's environment groups
+func showEnvironment(ctx context.Context, reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	env, err := reader.Environment(ctx, buildkitelogs.EnvironmentOptions{GroupPattern: environmentGroup(config)})
+	if err != nil {
+		return fmt.Errorf("error extracting environment: %w", err)
+	}
+
+	if config.Format == "json" {
+		return writeJSONLines([]*buildkitelogs.Environment{env}, os.Stdout)
+	}
+
+	if !config.RawOutput {
+		fmt.Fprintf(os.Stderr, "Environment variables: %d from %d groups matching %q\n\n", len(env.Vars), len(env.Groups), environmentGroup(config))
+	}
+	for _, name := range slices.Sorted(maps.Keys(env.Vars)) {
+		fmt.Printf("%s=%s\n", name, env.Vars[name])
+	}
+
+	if config.ShowStats {
+		queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
+		fmt.Fprintf(os.Stderr, "\n--- Environment Statistics ---\n")
+		fmt.Fprintf(os.Stderr, "Groups: %s\n", strings.Join(env.Groups, ", "))
+		fmt.Fprintf(os.Stderr, "Redacted: %d\n", len(env.Redacted))
+		fmt.Fprintf(os.Stderr, "Query time: %.2f ms\n", queryTime)
+	}
+
+	return nil
+}
+
+// showAnnotations shows the annotated entries of the job with their annotations
+func showAnnotations(ctx context.Context, reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
+	var entries []buildkitelogs.AnnotatedEntry
+	for entry, err := range reader.AnnotatedEntriesIter(ctx, config.AnnotationLabel) {
+		if err != nil {
+			return fmt.Errorf("error reading annotated entries: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if config.Format == "json" {
+		return writeJSONLines(entries, os.Stdout)
+	}
+
+	if !config.RawOutput {
+		fmt.Fprintf(os.Stderr, "Annotated entries: %d\n\n", len(entries))
+	}
+
+	for _, entry := range entries {
+		if err := writeLogEntries(os.Stdout, []buildkitelogs.ParquetLogEntry{entry.ParquetLogEntry}, config); err != nil {
+			return err
+		}
+		for _, a := range entry.Annotations {
+			fmt.Printf("    ^ %s\n", formatAnnotation(a))
+		}
+	}
+
+	if config.ShowStats {
+		queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
+		fmt.Fprintf(os.Stderr, "\n--- Query Statistics ---\n")
+		fmt.Fprintf(os.Stderr, "Annotations: %d\n", len(reader.Annotations()))
+		fmt.Fprintf(os.Stderr, "Query time: %.2f ms\n", queryTime)
+	}
 
 	return nil
 }
@@ -643,8 +1737,9 @@ func tailFile(ctx context.Context, reader *buildkitelogs.ParquetReader, config *
 
 	var entries []buildkitelogs.ParquetLogEntry
 	entriesRead := 0
+	heartbeats := 0
 
-	for entry, err := range reader.SeekToRow(ctx, startRow) {
+	for entry, err := range dropHeartbeats(reader.SeekToRow(ctx, startRow), config, &heartbeats) {
 		if err != nil {
 			return fmt.Errorf("error reading entries: %w", err)
 		}
@@ -660,15 +1755,20 @@ func tailFile(ctx context.Context, reader *buildkitelogs.ParquetReader, config *
 
 	// Format output
 	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
-	return formatTailResult(entries, info.RowCount, int64(entriesRead), queryTime, config)
+	if err := formatTailResult(entries, info.RowCount, int64(entriesRead), queryTime, config); err != nil {
+		return err
+	}
+	printHeartbeatStats(heartbeats, config)
+	return nil
 }
 
 // seekToRow starts reading from a specific row
 func seekToRow(ctx context.Context, reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
 	var entries []buildkitelogs.ParquetLogEntry
 	entriesRead := 0
+	heartbeats := 0
 
-	for entry, err := range reader.SeekToRow(ctx, config.SeekToRow) {
+	for entry, err := range dropHeartbeats(reader.SeekToRow(ctx, config.SeekToRow), config, &heartbeats) {
 		if err != nil {
 			return fmt.Errorf("error reading entries: %w", err)
 		}
@@ -684,7 +1784,11 @@ func seekToRow(ctx context.Context, reader *buildkitelogs.ParquetReader, config
 
 	// Format output
 	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
-	return formatSeekResult(entries, config.SeekToRow, int64(entriesRead), queryTime, config)
+	if err := formatSeekResult(entries, config.SeekToRow, int64(entriesRead), queryTime, config); err != nil {
+		return err
+	}
+	printHeartbeatStats(heartbeats, config)
+	return nil
 }
 
 // formatTailResult formats tail command output
@@ -741,8 +1845,9 @@ func formatSeekResult(entries []buildkitelogs.ParquetLogEntry, startRow, entries
 func streamDump(ctx context.Context, reader *buildkitelogs.ParquetReader, config *QueryConfig, start time.Time) error {
 	var entries []buildkitelogs.ParquetLogEntry
 	totalEntries := 0
+	heartbeats := 0
 
-	for entry, err := range reader.ReadEntriesIter(ctx) {
+	for entry, err := range dropHeartbeats(reader.ReadEntriesIter(ctx), config, &heartbeats) {
 		if err != nil {
 			return fmt.Errorf("error reading entries: %w", err)
 		}
@@ -758,13 +1863,47 @@ func streamDump(ctx context.Context, reader *buildkitelogs.ParquetReader, config
 
 	// Format output
 	queryTime := float64(time.Since(start).Nanoseconds()) / 1e6
-	return formatDumpResult(entries, totalEntries, queryTime, config)
+	if err := formatDumpResult(entries, totalEntries, queryTime, config); err != nil {
+		return err
+	}
+	printHeartbeatStats(heartbeats, config)
+	return nil
+}
+
+// dropHeartbeats removes heartbeat/keepalive entries when -drop-heartbeats is set,
+// counting the dropped entries in dropped.
+func dropHeartbeats(entries iter.Seq2[buildkitelogs.ParquetLogEntry, error], config *QueryConfig, dropped *int) iter.Seq2[buildkitelogs.ParquetLogEntry, error] {
+	if !config.DropHeartbeats {
+		return entries
+	}
+	return func(yield func(buildkitelogs.ParquetLogEntry, error) bool) {
+		for entry, err := range entries {
+			if err == nil && entry.IsHeartbeat() {
+				*dropped++
+				continue
+			}
+			if !yield(entry, err) {
+				return
+			}
+		}
+	}
+}
+
+// printHeartbeatStats reports how many heartbeat entries were dropped
+func printHeartbeatStats(dropped int, config *QueryConfig) {
+	if !config.DropHeartbeats || !config.ShowStats || config.Format == "json" {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Heartbeats dropped: %d\n", dropped)
 }
 
 // formatDumpResult formats dump command output
 func formatDumpResult(entries []buildkitelogs.ParquetLogEntry, totalEntries int, queryTime float64, config *QueryConfig) error {
-	if config.Format == "json" {
+	switch config.Format {
+	case "json":
 		return writeJSONLines(entries, os.Stdout)
+	case "csv", "tsv":
+		return writeCSVEntries(entries, os.Stdout, config)
 	}
 
 	// Output entries using consistent formatting
@@ -788,6 +1927,28 @@ func formatDumpResult(entries []buildkitelogs.ParquetLogEntry, totalEntries int,
 	return nil
 }
 
+// writeCSVEntries writes entries as CSV, or TSV for -format tsv, with the -columns
+// columns. -strip-ansi applies to their content.
+func writeCSVEntries(entries []buildkitelogs.ParquetLogEntry, w io.Writer, config *QueryConfig) error {
+	opts, err := csvOptions(config.Format, config.Columns)
+	if err != nil {
+		return err
+	}
+	cw, err := buildkitelogs.NewCSVWriter(w, opts)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if config.StripANSI {
+			entry.Content = buildkitelogs.StripANSI(entry.Content)
+		}
+		if err := cw.Write(&entry); err != nil {
+			return err
+		}
+	}
+	return cw.Flush()
+}
+
 // truncateString truncates a string to the specified length
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
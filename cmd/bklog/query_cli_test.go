@@ -1,6 +1,8 @@
 package main
 
 import (
+	"io"
+	"slices"
 	"strings"
 	"testing"
 
@@ -94,3 +96,117 @@ func TestStripANSIIntegration(t *testing.T) {
 		t.Errorf("StripANSI() = %q, want %q", actualContent, expectedContent)
 	}
 }
+
+func TestGroupSearchResults(t *testing.T) {
+	match := func(row int64, group string) buildkitelogs.SearchResult {
+		return buildkitelogs.SearchResult{Match: buildkitelogs.ParquetLogEntry{RowNumber: row, Group: group}}
+	}
+	results := []buildkitelogs.SearchResult{
+		match(1, "~~~ Build"),
+		match(2, ""),
+		match(3, "--- Test"),
+		match(4, "~~~ Build"),
+		match(5, "--- Test"),
+		match(6, "~~~ Build"),
+	}
+
+	clusters := groupSearchResults(results, false)
+
+	want := []struct {
+		group string
+		rows  []int64
+	}{
+		{"~~~ Build", []int64{1, 4, 6}},
+		{"<no group>", []int64{2}},
+		{"--- Test", []int64{3, 5}},
+	}
+	if len(clusters) != len(want) {
+		t.Fatalf("got %d clusters, want %d", len(clusters), len(want))
+	}
+	for i, w := range want {
+		cluster := clusters[i]
+		if cluster.Group != w.group || cluster.Matches != len(w.rows) {
+			t.Errorf("cluster %d = %q with %d matches, want %q with %d", i, cluster.Group, cluster.Matches, w.group, len(w.rows))
+			continue
+		}
+		for j, result := range cluster.Results {
+			if result.Match.RowNumber != w.rows[j] {
+				t.Errorf("cluster %q result %d is row %d, want %d", w.group, j, result.Match.RowNumber, w.rows[j])
+			}
+		}
+	}
+}
+
+func TestEntryTemplate(t *testing.T) {
+	tmpl, err := parseEntryTemplate("{{.RowNumber}} {{.Timestamp.UTC.Format \"15:04:05\"}} [{{.Group}}] {{.Content}}{{if .Match}} <{{end}}")
+	if err != nil {
+		t.Fatalf("parseEntryTemplate: %v", err)
+	}
+
+	config := &QueryConfig{StripANSI: true, entryTemplate: tmpl}
+	results := []buildkitelogs.SearchResult{{
+		BeforeContext: []buildkitelogs.ParquetLogEntry{{RowNumber: 4, Timestamp: 1640995200000, Content: "before", Group: "Build"}},
+		Match:         buildkitelogs.ParquetLogEntry{RowNumber: 5, Timestamp: 1640995201000, Content: "\x1b[31mfailed\x1b[0m", Group: "Build"},
+	}}
+
+	var out strings.Builder
+	if err := writeTemplateSearchResults(&out, results, config); err != nil {
+		t.Fatalf("writeTemplateSearchResults: %v", err)
+	}
+	want := "4 00:00:00 [Build] before\n5 00:00:01 [Build] failed <\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	if _, err := parseEntryTemplate("{{.Nope}}"); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+	if _, err := parseEntryTemplate("{{.Content"); err == nil {
+		t.Error("expected an error for an unterminated action")
+	}
+}
+
+func TestPromptGroupChoice(t *testing.T) {
+	names := []string{"~~~ Unit tests", "~~~ Integration tests"}
+
+	var out strings.Builder
+	name, err := promptGroupChoice(names, "tests", strings.NewReader("2\n"), &out)
+	if err != nil {
+		t.Fatalf("promptGroupChoice failed: %v", err)
+	}
+	if name != "~~~ Integration tests" {
+		t.Errorf("picked %q, want the second group", name)
+	}
+	for _, want := range []string{`"tests" matches 2 groups`, "1) ~~~ Unit tests", "2) ~~~ Integration tests", "[1-2]"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("prompt %q does not contain %q", out.String(), want)
+		}
+	}
+
+	for _, input := range []string{"3\n", "x\n", ""} {
+		if _, err := promptGroupChoice(names, "tests", strings.NewReader(input), io.Discard); err == nil {
+			t.Errorf("promptGroupChoice(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestWriteGroupTree(t *testing.T) {
+	groups := []buildkitelogs.GroupInfo{
+		{Name: "~~~ Running commands", EntryCount: 1},
+		{Name: "--- Build", ParentGroup: "~~~ Running commands", Depth: 1, EntryCount: 2},
+		{Name: "--- Test", ParentGroup: "~~~ Running commands", Depth: 1, EntryCount: 3},
+		{Name: "~~~ Cleanup", EntryCount: 1},
+	}
+
+	var out strings.Builder
+	writeGroupTree(&out, buildkitelogs.GroupTree(groups))
+
+	var names []string
+	for line := range strings.Lines(out.String()) {
+		names = append(names, strings.TrimSpace(line[:40]))
+	}
+	want := []string{"~~~ Running commands", "|-- --- Build", "`-- --- Test", "~~~ Cleanup"}
+	if !slices.Equal(names, want) {
+		t.Errorf("tree = %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// ReparseConfig holds configuration for the reparse command
+type ReparseConfig struct {
+	RawFile     string // Raw log to re-parse (local mode)
+	ParquetFile string // Parquet file to replace (local mode)
+	Force       bool   // Re-parse even if the file was written by the current parser
+	// Buildkite API parameters
+	Organization string
+	Pipeline     string
+	Build        string
+	Branch       string
+	Job          string
+	Step         string
+	CacheURL     string
+}
+
+func handleReparseCommand() {
+	var config ReparseConfig
+
+	reparseFlags := flag.NewFlagSet("reparse", flag.ExitOnError)
+	reparseFlags.StringVar(&config.RawFile, "file", "", "Raw log file to re-parse (with -out)")
+	reparseFlags.StringVar(&config.ParquetFile, "out", "", "Parquet file to replace with the re-parsed log (with -file)")
+	reparseFlags.BoolVar(&config.Force, "force", false, "Re-parse even if the file was written by the current parser")
+	reparseFlags.StringVar(&config.Organization, "org", "", "Buildkite organization slug (for API)")
+	reparseFlags.StringVar(&config.Pipeline, "pipeline", "", "Buildkite pipeline slug (for API)")
+	reparseFlags.StringVar(&config.Build, "build", "", "Buildkite build number, UUID or \"latest\" (for API)")
+	reparseFlags.StringVar(&config.Branch, "branch", "", "Branch to select the most recent build from (with -build latest)")
+	reparseFlags.StringVar(&config.Job, "job", "", "Buildkite job ID (for API)")
+	reparseFlags.StringVar(&config.Step, "step", "", "Step key or label to resolve within the build (for API, instead of -job)")
+	reparseFlags.StringVar(&config.CacheURL, "cache-url", "", "Cache storage URL (file://path, s3://bucket, etc)")
+
+	reparseFlags.Usage = func() {
+		fmt.Printf("Usage: %s reparse [options]\n\n", os.Args[0])
+		fmt.Println("Re-parse a log with the current parser and atomically replace its Parquet file.")
+		fmt.Println("Files already written by the current parser version are left alone unless -force is set.")
+		fmt.Println("\nYou must provide either:")
+		fmt.Println("  -file <raw.log> -out <logs.parquet>   Local raw log and the Parquet file to replace")
+		fmt.Println("  OR API params:   -org -pipeline -build -job   Re-download and replace a cached log")
+		fmt.Println("\nFor API usage, set BUILDKITE_API_TOKEN environment variable.")
+		fmt.Println("\nOptions:")
+		reparseFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s reparse -file raw.log -out logs.parquet\n", os.Args[0])
+		fmt.Printf("  %s reparse -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab\n", os.Args[0])
+		fmt.Printf("  %s reparse -org myorg -pipeline mypipe -build 123 -job 0190a7a4-5b3c-7d1e-9f00-1234567890ab -force\n", os.Args[0])
+	}
+
+	if err := reparseFlags.Parse(os.Args[2:]); err != nil {
+		os.Exit(1)
+	}
+
+	if config.RawFile != "" || config.ParquetFile != "" {
+		if config.RawFile == "" || config.ParquetFile == "" {
+			fmt.Fprintf(os.Stderr, "Error: -file and -out must be used together\n\n")
+			reparseFlags.Usage()
+			os.Exit(1)
+		}
+	} else if err := validateAPIFlags(config.Organization, config.Pipeline, &config.Build, config.Branch, &config.Job, config.Step); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+		reparseFlags.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	if err := runReparse(ctx, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runReparse(ctx context.Context, config *ReparseConfig) error {
+	if config.RawFile != "" {
+		return runReparseFile(config)
+	}
+
+	apiToken := os.Getenv("BUILDKITE_API_TOKEN")
+	if apiToken == "" {
+		return fmt.Errorf("BUILDKITE_API_TOKEN environment variable is required for API access")
+	}
+
+	buildkiteClient := buildkitelogs.NewBuildkiteAPIClient(apiToken, version)
+	client, err := buildkitelogs.NewClientWithAPI(ctx, buildkiteClient, config.CacheURL, buildkitelogs.WithLogger(logger))
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	build, err := client.ResolveBuild(ctx, config.Organization, config.Pipeline, config.Build, config.Branch)
+	if err != nil {
+		return fmt.Errorf("failed to resolve build: %w", err)
+	}
+	location := buildkitelogs.JobLocation{Org: config.Organization, Pipeline: config.Pipeline, Build: build, Job: config.Job}
+	if config.Step != "" {
+		if location, err = client.ResolveStep(ctx, config.Organization, config.Pipeline, build, config.Step); err != nil {
+			return fmt.Errorf("failed to resolve step: %w", err)
+		}
+	}
+
+	reparsed, err := client.Reparse(ctx, location, config.Force)
+	if errors.Is(err, buildkitelogs.ErrNotCached) {
+		return fmt.Errorf("job %s is not cached; query it to cache it", location.Job)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to re-parse cached log: %w", err)
+	}
+
+	if reparsed {
+		fmt.Printf("Re-parsed cached log of job %s with parser version %d\n", location.Job, logparser.Version)
+	} else {
+		fmt.Printf("Cached log of job %s is up to date (parser version %d)\n", location.Job, logparser.Version)
+	}
+	return nil
+}
+
+// runReparseFile replaces config.ParquetFile with a fresh parse of config.RawFile
+func runReparseFile(config *ReparseConfig) error {
+	if !config.Force {
+		info, err := buildkitelogs.NewParquetReader(config.ParquetFile).WithLogger(logger).GetFileInfo()
+		if err == nil && info.ParserVersion >= logparser.Version {
+			fmt.Printf("%s is up to date (parser version %d)\n", config.ParquetFile, info.ParserVersion)
+			return nil
+		}
+	}
+
+	rows, err := buildkitelogs.ReparseFile(config.RawFile, config.ParquetFile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Re-parsed %s into %s: %d entries, parser version %d\n", config.RawFile, config.ParquetFile, rows, logparser.Version)
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+)
+
+// ScanConfig holds configuration for the scan command
+type ScanConfig struct {
+	Organization  string
+	Since         time.Duration
+	State         string
+	Pattern       string
+	CaseSensitive bool
+	Concurrency   int
+	Format        string // "text", "json"
+	CacheURL      string
+	CacheTTL      time.Duration
+}
+
+func handleScanCommand() {
+	var config ScanConfig
+
+	scanFlags := flag.NewFlagSet("scan", flag.ExitOnError)
+	scanFlags.StringVar(&config.Organization, "org", "", "Buildkite organization slug")
+	scanFlags.DurationVar(&config.Since, "since", 24*time.Hour, "Only scan builds created within this duration")
+	scanFlags.StringVar(&config.State, "state", "failed", "Job state to scan")
+	scanFlags.StringVar(&config.Pattern, "pattern", "", "Regex pattern to search job logs for")
+	scanFlags.BoolVar(&config.CaseSensitive, "case-sensitive", false, "Enable case-sensitive search")
+	scanFlags.IntVar(&config.Concurrency, "concurrency", buildkitelogs.DefaultScanConcurrency, "Number of job logs to download and search at once")
+	scanFlags.StringVar(&config.Format, "format", "text", "Output format: text, json")
+	scanFlags.StringVar(&config.CacheURL, "cache-url", "", "Cache storage URL (file://path, s3://bucket, etc)")
+	scanFlags.DurationVar(&config.CacheTTL, "cache-ttl", 30*time.Second, "Cache TTL for non-terminal jobs")
+
+	scanFlags.Usage = func() {
+		fmt.Printf("Usage: %s scan [options]\n\n", os.Args[0])
+		fmt.Println("Search the logs of recent jobs across every pipeline in an organization.")
+		fmt.Println("\nSet BUILDKITE_API_TOKEN environment variable for API access.")
+		fmt.Println("\nOptions:")
+		scanFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s scan -org myorg -since 24h -state failed -pattern \"OOMKilled\"\n", os.Args[0])
+		fmt.Printf("  %s scan -org myorg -since 2h -pattern \"connection refused\" -format json\n", os.Args[0])
+	}
+
+	if err := scanFlags.Parse(os.Args[2:]); err != nil {
+		os.Exit(1)
+	}
+
+	if config.Organization == "" || config.Pattern == "" {
+		fmt.Fprintf(os.Stderr, "Error: -org and -pattern are required\n\n")
+		scanFlags.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	if err := runScan(ctx, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runScan(ctx context.Context, config *ScanConfig) error {
+	apiToken := os.Getenv("BUILDKITE_API_TOKEN")
+	if apiToken == "" {
+		return fmt.Errorf("BUILDKITE_API_TOKEN environment variable is required for API access")
+	}
+
+	buildkiteClient := buildkitelogs.NewBuildkiteAPIClient(apiToken, version)
+	client, err := buildkitelogs.NewClientWithAPI(ctx, buildkiteClient, config.CacheURL, buildkitelogs.WithLogger(logger))
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	start := time.Now()
+	results, err := client.Scan(ctx, config.Organization, buildkitelogs.ScanOptions{
+		Since:         start.Add(-config.Since),
+		State:         config.State,
+		Pattern:       config.Pattern,
+		CaseSensitive: config.CaseSensitive,
+		Concurrency:   config.Concurrency,
+		TTL:           config.CacheTTL,
+	})
+	if err != nil {
+		return err
+	}
+
+	if config.Format == "json" {
+		return writeIndentedJSON(os.Stdout, results)
+	}
+
+	matched := 0
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Printf("%s/%s #%s %s (%s): error: %s\n", result.Org, result.Pipeline, result.Build, result.Label, result.Job, result.Error)
+			continue
+		}
+		matched++
+		fmt.Printf("%s/%s #%s %s (%s): %d matches\n", result.Org, result.Pipeline, result.Build, result.Label, result.Job, result.Matches)
+		fmt.Printf("  %s\n", result.FirstMatch)
+		if result.WebURL != "" {
+			fmt.Printf("  %s\n", result.WebURL)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "\nScan completed in %s: %d matching jobs\n", time.Since(start).Round(time.Millisecond), matched)
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+)
+
+// ServeConfig holds configuration for the serve command
+type ServeConfig struct {
+	Addr         string
+	Organization string
+	CacheURL     string
+	CacheTTL     time.Duration
+}
+
+func handleServeCommand() {
+	var config ServeConfig
+
+	serveFlags := flag.NewFlagSet("serve", flag.ExitOnError)
+	serveFlags.StringVar(&config.Addr, "addr", "localhost:8080", "Address to listen on")
+	serveFlags.StringVar(&config.Organization, "org", "", "Buildkite organization slug of job UUID refs")
+	serveFlags.StringVar(&config.CacheURL, "cache-url", "", "Cache storage URL (file://path, s3://bucket, etc)")
+	serveFlags.DurationVar(&config.CacheTTL, "cache-ttl", 30*time.Second, "Cache TTL for non-terminal jobs")
+
+	serveFlags.Usage = func() {
+		fmt.Printf("Usage: %s serve [options]\n\n", os.Args[0])
+		fmt.Println("Serve job log searches over HTTP, streaming matches as Server-Sent Events from")
+		fmt.Println("GET /jobs/{ref}/search/stream?pattern=..., where ref is a path-escaped job URL,")
+		fmt.Println("or a job UUID with -org.")
+		fmt.Println("\nSet BUILDKITE_API_TOKEN environment variable for API access.")
+		fmt.Println("\nOptions:")
+		serveFlags.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Printf("  %s serve -addr :8080 -org myorg\n", os.Args[0])
+		fmt.Println("  curl -N 'http://localhost:8080/jobs/0190a7a4-5b3c-7d1e-9f00-1234567890ab/search/stream?pattern=FAIL&context=2'")
+	}
+
+	if err := serveFlags.Parse(os.Args[2:]); err != nil {
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := runServe(ctx, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runServe(ctx context.Context, config *ServeConfig) error {
+	apiToken := os.Getenv("BUILDKITE_API_TOKEN")
+	if apiToken == "" {
+		return fmt.Errorf("BUILDKITE_API_TOKEN environment variable is required for API access")
+	}
+
+	buildkiteClient := buildkitelogs.NewBuildkiteAPIClient(apiToken, version)
+	client, err := buildkitelogs.NewClientWithAPI(ctx, buildkiteClient, config.CacheURL, buildkitelogs.WithLogger(logger))
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle(buildkitelogs.SearchStreamPattern, &buildkitelogs.SearchStreamHandler{
+		Client: client,
+		Org:    config.Organization,
+		TTL:    config.CacheTTL,
+	})
+	server := &http.Server{
+		Addr:              config.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", config.Addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/apache/arrow-go/v18/parquet/metadata"
+)
+
+// LogColumns lists the columns of a log Parquet file that WithColumns accepts. The
+// "hash", "severity", "parent_group" and "depth" columns are missing from older files
+// and "length" is only written by WithOmitContent.
+var LogColumns = []string{"timestamp", "content", "group", "flags", "hash", "severity", "parent_group", "depth", "length"}
+
+// ErrUnknownColumn is returned when a column projection names a column that is not in
+// LogColumns.
+var ErrUnknownColumn = errors.New("unknown log column")
+
+// WithColumns limits the reader to decoding the named columns (see LogColumns), leaving
+// the fields of the other columns zero in the entries it returns. Queries that only need
+// part of each entry, such as a raw dump needing just "content", then skip reading and
+// decoding the rest of the file. The projection applies to ReadEntriesIter, SeekToRow,
+// the group filters, SearchEntriesIter, GroupTails and AnnotatedEntriesIter, which add
+// the columns they depend on themselves, so FilterByGroupIter always reads "group". An
+// entry's IsGroup needs "flags". Analyses such as ListGroups and Advise read every
+// column. Calling WithColumns with no columns reads every column again.
+func (pr *ParquetReader) WithColumns(columns ...string) *ParquetReader {
+	pr.columns = slices.Clone(columns)
+	return pr
+}
+
+// readColumns returns the reader's projection plus the required columns, or nil to read
+// every column
+func (pr *ParquetReader) readColumns(required ...string) []string {
+	if len(pr.columns) == 0 {
+		return nil
+	}
+	columns := append(slices.Clone(pr.columns), required...)
+	if pr.filtersSeverity() {
+		// Older files infer severities from content and flags
+		columns = append(columns, "severity", "content", "flags")
+	}
+	slices.Sort(columns)
+	return slices.Compact(columns)
+}
+
+// projectColumns returns the leaf indices of the named columns in a file, for
+// GetRecordReader, or nil to read every column. Known columns missing from the file
+// are skipped.
+func projectColumns(md *metadata.FileMetaData, columns []string) ([]int, error) {
+	if len(columns) == 0 {
+		return nil, nil
+	}
+
+	indices := make([]int, 0, len(columns))
+	for _, name := range columns {
+		if !slices.Contains(LogColumns, name) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, name)
+		}
+		if idx := md.Schema.ColumnIndexByName(name); idx >= 0 {
+			indices = append(indices, idx)
+		}
+	}
+	if len(indices) == 0 {
+		// Rows are still counted from a column, so read the cheapest one
+		indices = append(indices, md.Schema.ColumnIndexByName("timestamp"))
+	}
+	slices.Sort(indices)
+	return slices.Compact(indices), nil
+}
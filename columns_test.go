@@ -0,0 +1,84 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParquetReader_WithColumns(t *testing.T) {
+	filename := writeGroupIndexTestFile(t, groupIndexTestEntries())
+	reader := NewParquetReader(filename).WithColumns("content")
+
+	rows := 0
+	for entry, err := range reader.ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		if entry.RowNumber != int64(rows) {
+			t.Fatalf("RowNumber = %d, want %d", entry.RowNumber, rows)
+		}
+		if entry.Content == "" {
+			t.Fatalf("row %d has no content", rows)
+		}
+		if entry.Timestamp != 0 || entry.Group != "" || entry.Hash != 0 {
+			t.Fatalf("row %d decoded columns outside the projection: %+v", rows, entry)
+		}
+		rows++
+	}
+	if rows != 3413 {
+		t.Errorf("read %d rows, want 3413", rows)
+	}
+
+	// Group filters read the group column themselves
+	matched := 0
+	for entry, err := range reader.FilterByGroupIter(t.Context(), "Cleanup") {
+		if err != nil {
+			t.Fatalf("FilterByGroupIter failed: %v", err)
+		}
+		if entry.Group != "+++ Cleanup" || entry.Timestamp != 0 {
+			t.Fatalf("unexpected entry %+v", entry)
+		}
+		matched++
+	}
+	if matched != 700 {
+		t.Errorf("FilterByGroupIter matched %d entries, want 700", matched)
+	}
+
+	plan, err := reader.ExplainRead()
+	if err != nil {
+		t.Fatalf("ExplainRead failed: %v", err)
+	}
+	if !slices.Equal(plan.Columns, []string{"content"}) {
+		t.Errorf("plan columns = %v, want [content]", plan.Columns)
+	}
+	full, err := NewParquetReader(filename).ExplainRead()
+	if err != nil {
+		t.Fatalf("ExplainRead failed: %v", err)
+	}
+	if plan.ScanBytes >= full.ScanBytes {
+		t.Errorf("projected scan bytes = %d, want fewer than %d", plan.ScanBytes, full.ScanBytes)
+	}
+
+	// No columns reads every column again
+	for entry, err := range reader.WithColumns().SeekToRow(t.Context(), 5) {
+		if err != nil {
+			t.Fatalf("SeekToRow failed: %v", err)
+		}
+		if entry.Timestamp == 0 || entry.Group != "~~~ Setup" {
+			t.Errorf("entry = %+v, want all columns", entry)
+		}
+		break
+	}
+}
+
+func TestParquetReader_WithUnknownColumn(t *testing.T) {
+	filename := writeGroupIndexTestFile(t, groupIndexTestEntries())
+	for _, err := range NewParquetReader(filename).WithColumns("contents").ReadEntriesIter(t.Context()) {
+		if !errors.Is(err, ErrUnknownColumn) {
+			t.Fatalf("error = %v, want ErrUnknownColumn", err)
+		}
+		return
+	}
+	t.Fatal("ReadEntriesIter yielded nothing")
+}
@@ -0,0 +1,255 @@
+package buildkitelogs
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// ParquetCompression is the codec a ParquetWriter compresses column chunks with.
+type ParquetCompression string
+
+const (
+	CompressionZstd   ParquetCompression = "zstd"   // Smallest files; the default
+	CompressionSnappy ParquetCompression = "snappy" // Faster to write and read, larger files
+	CompressionNone   ParquetCompression = "none"   // Uncompressed, leaving compression to storage or transfer
+)
+
+// DefaultParquetCompression is the codec of files written without WithCompression.
+const DefaultParquetCompression = CompressionZstd
+
+// ParquetCompressions lists the supported codecs.
+var ParquetCompressions = []ParquetCompression{CompressionZstd, CompressionSnappy, CompressionNone}
+
+// ErrUnknownCompression is returned for a codec name not in ParquetCompressions.
+var ErrUnknownCompression = errors.New("unknown parquet compression")
+
+// ParseParquetCompression returns the codec with the given name.
+func ParseParquetCompression(name string) (ParquetCompression, error) {
+	c := ParquetCompression(name)
+	if !slices.Contains(ParquetCompressions, c) {
+		return "", fmt.Errorf("%w: %q (want zstd, snappy or none)", ErrUnknownCompression, name)
+	}
+	return c, nil
+}
+
+func (c ParquetCompression) codec() compress.Compression {
+	switch c {
+	case CompressionSnappy:
+		return compress.Codecs.Snappy
+	case CompressionNone:
+		return compress.Codecs.Uncompressed
+	default:
+		return compress.Codecs.Zstd
+	}
+}
+
+// WithCompression sets the codec the writer compresses column chunks with. Default is
+// DefaultParquetCompression.
+func WithCompression(c ParquetCompression) ParquetWriterOption {
+	return func(pw *ParquetWriter) {
+		pw.compression = c
+	}
+}
+
+// WithCompressionLevel sets the level of the writer's codec, such as 1 (fastest) to 22
+// (smallest) for zstd; snappy and none have no levels and ignore it. Default is 0, the
+// codec's default level.
+func WithCompressionLevel(level int) ParquetWriterOption {
+	return func(pw *ParquetWriter) {
+		pw.compressionLevel = level
+	}
+}
+
+// WithCacheCompression sets the codec of the Parquet files the client caches. Cached
+// files are uploaded as written, so storage that compresses objects itself, or a
+// transfer compressing them, would compress them a second time; use
+// ParquetReader.MeasureCompression (bklog cache doctor) to see whether that pays off
+// for your logs. Default is DefaultParquetCompression.
+func WithCacheCompression(c ParquetCompression) ClientOption {
+	return func(cl *Client) {
+		cl.cacheCompression = c
+	}
+}
+
+// DefaultCompressionSampleRows is the number of rows MeasureCompression re-encodes
+// when given no sample size.
+const DefaultCompressionSampleRows = 100000
+
+// CompressionMeasurement is the result of encoding a sample of a log with one codec.
+type CompressionMeasurement struct {
+	Compression ParquetCompression `json:"compression"`
+	Bytes       int64              `json:"bytes"`      // Size of the encoded sample
+	EncodeTime  time.Duration      `json:"encode_ns"`  // Time to write the sample
+	GzipBytes   int64              `json:"gzip_bytes"` // Size after a further gzip pass, as storage or transfer compression would apply
+	GzipTime    time.Duration      `json:"gzip_ns"`    // Time of the gzip pass
+}
+
+// GzipSavings returns the fraction of the encoded size a further gzip pass removes.
+func (m CompressionMeasurement) GzipSavings() float64 {
+	if m.Bytes == 0 {
+		return 0
+	}
+	return 1 - float64(m.GzipBytes)/float64(m.Bytes)
+}
+
+// CompressionReport compares the codecs on a sample of a log.
+type CompressionReport struct {
+	SampleRows     int                      `json:"sample_rows"`
+	SampleBytes    int64                    `json:"sample_content_bytes"` // Log content in the sample
+	Measurements   []CompressionMeasurement `json:"measurements"`         // One per ParquetCompressions codec, in order
+	Recommended    ParquetCompression       `json:"recommended"`
+	DoubleCompress bool                     `json:"double_compress"` // Compressing the recommended encoding again pays off
+	Notes          []string                 `json:"notes"`
+}
+
+// Measurement returns the measurement of codec c.
+func (r *CompressionReport) Measurement(c ParquetCompression) CompressionMeasurement {
+	for _, m := range r.Measurements {
+		if m.Compression == c {
+			return m
+		}
+	}
+	return CompressionMeasurement{Compression: c}
+}
+
+// Thresholds of the recommendation: a codec has to save this fraction of the size to be
+// worth its CPU.
+const (
+	compressionWorthwhileSavings = 0.10
+	doubleCompressionSavings     = 0.05
+)
+
+// MeasureCompression re-encodes up to sampleRows rows of the file (0 =
+// DefaultCompressionSampleRows) with each codec, measures the size and time of each,
+// and of a further gzip pass over it, and recommends a codec. Timings come from a
+// single run, so compare them relative to each other.
+func (pr *ParquetReader) MeasureCompression(ctx context.Context, sampleRows int) (*CompressionReport, error) {
+	if sampleRows <= 0 {
+		sampleRows = DefaultCompressionSampleRows
+	}
+
+	report := &CompressionReport{}
+	var sample []*logparser.Entry
+	for entry, err := range readParquetFileIter(ctx, pr.source, nil) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sample: %w", err)
+		}
+		e := &logparser.Entry{Content: entry.Content, Group: entry.Group}
+		if entry.HasTime() {
+			e.Timestamp = time.UnixMilli(entry.Timestamp)
+		}
+		sample = append(sample, e)
+		report.SampleBytes += int64(len(entry.Content))
+		if len(sample) >= sampleRows {
+			break
+		}
+	}
+	report.SampleRows = len(sample)
+
+	for _, c := range ParquetCompressions {
+		m, err := measureCompression(sample, c)
+		if err != nil {
+			return nil, err
+		}
+		report.Measurements = append(report.Measurements, m)
+	}
+	report.recommend()
+	return report, nil
+}
+
+// measureCompression writes sample with codec c, passing the output through gzip
+func measureCompression(sample []*logparser.Entry, c ParquetCompression) (CompressionMeasurement, error) {
+	m := CompressionMeasurement{Compression: c}
+	gzipped := &countingWriter{w: io.Discard}
+	gz := &timedWriter{w: gzip.NewWriter(gzipped)}
+	encoded := &countingWriter{w: gz}
+
+	start := time.Now()
+	pw, err := NewParquetWriterForWriter(encoded, WithCompression(c), WithWriteChecksums(false))
+	if err != nil {
+		return m, err
+	}
+	if err := pw.WriteBatch(sample); err != nil {
+		_ = pw.Close()
+		return m, fmt.Errorf("failed to encode sample with %s: %w", c, err)
+	}
+	if err := pw.Close(); err != nil {
+		return m, fmt.Errorf("failed to encode sample with %s: %w", c, err)
+	}
+	gzipStart := time.Now()
+	if err := gz.w.(*gzip.Writer).Close(); err != nil {
+		return m, fmt.Errorf("failed to gzip sample: %w", err)
+	}
+	gz.elapsed += time.Since(gzipStart)
+
+	m.Bytes = encoded.n
+	m.GzipBytes = gzipped.n
+	m.GzipTime = gz.elapsed
+	m.EncodeTime = time.Since(start) - gz.elapsed
+	return m, nil
+}
+
+// recommend picks the cheapest codec that compresses well, and reports whether
+// compressing its output again is worthwhile
+func (r *CompressionReport) recommend() {
+	zstd, snappy, none := r.Measurement(CompressionZstd), r.Measurement(CompressionSnappy), r.Measurement(CompressionNone)
+	savings := func(m CompressionMeasurement) float64 {
+		if none.Bytes == 0 {
+			return 0
+		}
+		return 1 - float64(m.Bytes)/float64(none.Bytes)
+	}
+
+	switch {
+	case savings(zstd) < compressionWorthwhileSavings:
+		r.Recommended = CompressionNone
+		r.Notes = append(r.Notes, fmt.Sprintf("zstd saves only %.0f%% on this log; writing it uncompressed saves the CPU", 100*savings(zstd)))
+	case snappy.EncodeTime < zstd.EncodeTime && savings(zstd)-savings(snappy) < compressionWorthwhileSavings:
+		r.Recommended = CompressionSnappy
+		r.Notes = append(r.Notes, fmt.Sprintf("snappy is within %.0f%% of zstd's size and faster to write", 100*(savings(zstd)-savings(snappy))))
+	default:
+		r.Recommended = CompressionZstd
+		r.Notes = append(r.Notes, fmt.Sprintf("zstd saves %.0f%% against uncompressed Parquet (snappy %.0f%%)", 100*savings(zstd), 100*savings(snappy)))
+	}
+
+	recommended := r.Measurement(r.Recommended)
+	r.DoubleCompress = recommended.GzipSavings() >= doubleCompressionSavings
+	if r.DoubleCompress {
+		r.Notes = append(r.Notes, fmt.Sprintf("gzip saves a further %.0f%% on %s Parquet; storage or transfer compression pays off", 100*recommended.GzipSavings(), r.Recommended))
+	} else {
+		r.Notes = append(r.Notes, fmt.Sprintf("gzip saves only %.1f%% more on %s Parquet; leave storage and transfer compression of cached logs off", 100*recommended.GzipSavings(), r.Recommended))
+	}
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// timedWriter accumulates the time spent writing to w.
+type timedWriter struct {
+	w       io.Writer
+	elapsed time.Duration
+}
+
+func (w *timedWriter) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := w.w.Write(p)
+	w.elapsed += time.Since(start)
+	return n, err
+}
@@ -0,0 +1,152 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"io"
+	"iter"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func entrySeq(entries []*logparser.Entry) iter.Seq2[*logparser.Entry, error] {
+	return func(yield func(*logparser.Entry, error) bool) {
+		for _, entry := range entries {
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestWithCompression(t *testing.T) {
+	entries := groupIndexTestEntries()
+	for _, c := range ParquetCompressions {
+		filename := filepath.Join(t.TempDir(), string(c)+".parquet")
+		if _, err := ExportSeq2ToParquetWithFilterAndStats(entrySeq(entries), filename, nil, WithCompression(c)); err != nil {
+			t.Fatalf("export with %s failed: %v", c, err)
+		}
+
+		pf, err := file.OpenParquetFile(filename, false)
+		if err != nil {
+			t.Fatalf("OpenParquetFile failed: %v", err)
+		}
+		chunk, err := pf.MetaData().RowGroup(0).ColumnChunk(1)
+		if err != nil {
+			t.Fatalf("ColumnChunk failed: %v", err)
+		}
+		if chunk.Compression() != c.codec() {
+			t.Errorf("%s file uses codec %v", c, chunk.Compression())
+		}
+		_ = pf.Close()
+
+		rows := 0
+		for _, err := range NewParquetReader(filename).ReadEntriesIter(t.Context()) {
+			if err != nil {
+				t.Fatalf("reading %s file failed: %v", c, err)
+			}
+			rows++
+		}
+		if rows != len(entries) {
+			t.Errorf("%s file has %d rows, want %d", c, rows, len(entries))
+		}
+	}
+	if compress.Codecs.Zstd != DefaultParquetCompression.codec() {
+		t.Error("default codec is not zstd")
+	}
+}
+
+func TestParseParquetCompression(t *testing.T) {
+	if c, err := ParseParquetCompression("snappy"); err != nil || c != CompressionSnappy {
+		t.Errorf("ParseParquetCompression(snappy) = %q, %v", c, err)
+	}
+	if _, err := ParseParquetCompression("gzip"); !errors.Is(err, ErrUnknownCompression) {
+		t.Errorf("ParseParquetCompression(gzip) error = %v, want ErrUnknownCompression", err)
+	}
+}
+
+func TestParquetReader_MeasureCompression(t *testing.T) {
+	reader := NewParquetReader(writeGroupIndexTestFile(t, groupIndexTestEntries())).WithColumns("content")
+
+	report, err := reader.MeasureCompression(t.Context(), 2000)
+	if err != nil {
+		t.Fatalf("MeasureCompression failed: %v", err)
+	}
+	if report.SampleRows != 2000 || report.SampleBytes == 0 {
+		t.Errorf("sample = %d rows, %d bytes, want 2000 rows", report.SampleRows, report.SampleBytes)
+	}
+	if len(report.Measurements) != len(ParquetCompressions) {
+		t.Fatalf("measured %d codecs, want %d", len(report.Measurements), len(ParquetCompressions))
+	}
+	zstd, none := report.Measurement(CompressionZstd), report.Measurement(CompressionNone)
+	if zstd.Bytes == 0 || zstd.Bytes >= none.Bytes {
+		t.Errorf("zstd sample = %d bytes, uncompressed %d", zstd.Bytes, none.Bytes)
+	}
+	// Compressing zstd output again gains far less than compressing uncompressed Parquet
+	if zstd.GzipSavings() >= none.GzipSavings() {
+		t.Errorf("gzip saves %.2f on zstd, %.2f uncompressed", zstd.GzipSavings(), none.GzipSavings())
+	}
+	if !slices.Contains(ParquetCompressions, report.Recommended) || len(report.Notes) != 2 {
+		t.Errorf("recommended %q with notes %v", report.Recommended, report.Notes)
+	}
+
+	// The reader's projection is untouched
+	for entry, err := range reader.ReadEntriesIter(t.Context()) {
+		if err != nil || entry.Group != "" {
+			t.Errorf("projected entry = %+v, %v", entry, err)
+		}
+		break
+	}
+}
+
+func TestCompressionReport_Recommend(t *testing.T) {
+	incompressible := &CompressionReport{Measurements: []CompressionMeasurement{
+		{Compression: CompressionZstd, Bytes: 970, GzipBytes: 960},
+		{Compression: CompressionSnappy, Bytes: 990, GzipBytes: 980},
+		{Compression: CompressionNone, Bytes: 1000, GzipBytes: 990},
+	}}
+	incompressible.recommend()
+	if incompressible.Recommended != CompressionNone || incompressible.DoubleCompress {
+		t.Errorf("incompressible log: recommended %q, double compress %v", incompressible.Recommended, incompressible.DoubleCompress)
+	}
+
+	text := &CompressionReport{Measurements: []CompressionMeasurement{
+		{Compression: CompressionZstd, Bytes: 200, GzipBytes: 198},
+		{Compression: CompressionSnappy, Bytes: 400, GzipBytes: 250},
+		{Compression: CompressionNone, Bytes: 1000, GzipBytes: 210},
+	}}
+	text.recommend()
+	if text.Recommended != CompressionZstd || text.DoubleCompress {
+		t.Errorf("text log: recommended %q, double compress %v", text.Recommended, text.DoubleCompress)
+	}
+}
+
+func BenchmarkParquetCompression(b *testing.B) {
+	data := generateTestData(50000)
+	var entries []*logparser.Entry
+	for entry, err := range logparser.New().All(strings.NewReader(data)) {
+		if err != nil {
+			b.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, c := range ParquetCompressions {
+		b.Run(string(c), func(b *testing.B) {
+			var size int64
+			for b.Loop() {
+				w := &countingWriter{w: io.Discard}
+				if _, err := ExportSeq2ToParquetWriter(entrySeq(entries), w, WithCompression(c)); err != nil {
+					b.Fatal(err)
+				}
+				size = w.n
+			}
+			b.ReportMetric(float64(size), "bytes/file")
+		})
+	}
+}
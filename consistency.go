@@ -0,0 +1,79 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gocloud.dev/gcerrors"
+)
+
+// DefaultConsistencyRetry retries reads of a blob that storage reported as existing three
+// times over about 350ms, covering the short windows in which eventually consistent
+// backends list or report a blob before it can be read. Clients use it unless configured
+// with WithConsistencyRetry.
+var DefaultConsistencyRetry = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 50 * time.Millisecond,
+	MaxBackoff:     500 * time.Millisecond,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// WithConsistencyRetry sets the retry policy for reading a cached blob after its
+// existence check succeeded, or after it was written. Retryable defaults to
+// IsBlobNotFound; RetryPolicy{} disables the retries.
+func WithConsistencyRetry(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.consistencyRetry = policy
+	}
+}
+
+// BlobConsistencyError is returned when a blob that storage reported as existing still
+// could not be read after the consistency retries, typically because it was deleted or
+// replaced meanwhile, or the backend is slower to converge than the retries allow.
+// Err is the last read error.
+type BlobConsistencyError struct {
+	Key      string
+	Attempts int
+	Err      error
+}
+
+func (e *BlobConsistencyError) Error() string {
+	return fmt.Sprintf("blob %s exists but could not be read after %d attempts: %v", e.Key, e.Attempts, e.Err)
+}
+
+func (e *BlobConsistencyError) Unwrap() error {
+	return e.Err
+}
+
+// IsBlobNotFound reports whether err is a blob storage error for a missing blob.
+func IsBlobNotFound(err error) bool {
+	return err != nil && gcerrors.Code(err) == gcerrors.NotFound
+}
+
+// readExistingBlob runs read, which reads the blob at key that storage reported as
+// existing, retrying as the policy allows while the blob is reported missing. Retries
+// are reported to the OnRetryFunc hooks of the Client stage running with ctx, if any.
+func readExistingBlob[T any](ctx context.Context, policy RetryPolicy, key string, read func() (T, error)) (T, error) {
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsBlobNotFound
+	}
+
+	for attempt := 1; ; attempt++ {
+		value, err := read()
+		if err == nil || !retryable(err) {
+			return value, err
+		}
+		if attempt >= policy.attempts() {
+			var zero T
+			return zero, &BlobConsistencyError{Key: key, Attempts: attempt, Err: err}
+		}
+		notifyRetry(ctx, attempt, err)
+		if err := sleepContext(ctx, policy.backoff(attempt+1)); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+}
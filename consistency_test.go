@@ -0,0 +1,68 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReadExistingBlob(t *testing.T) {
+	storage, err := NewBlobStorage(t.Context(), "mem://", nil)
+	if err != nil {
+		t.Fatalf("NewBlobStorage failed: %v", err)
+	}
+	defer storage.Close()
+
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	const key = "org-pipeline-1-job.parquet"
+
+	t.Run("appears after retry", func(t *testing.T) {
+		calls := 0
+		metadata, err := readExistingBlob(t.Context(), policy, key, func() (*BlobMetadata, error) {
+			calls++
+			if calls == 2 {
+				// Storage converges between attempts
+				if err := storage.WriteWithMetadata(t.Context(), key, []byte("data"), &BlobMetadata{JobID: "job"}); err != nil {
+					t.Fatalf("WriteWithMetadata failed: %v", err)
+				}
+			}
+			return storage.ReadWithMetadata(t.Context(), key)
+		})
+		if err != nil {
+			t.Fatalf("readExistingBlob failed: %v", err)
+		}
+		if calls != 3 || metadata == nil || metadata.JobID != "job" {
+			t.Errorf("got %+v after %d calls, want the metadata after 3", metadata, calls)
+		}
+	})
+
+	t.Run("stays missing", func(t *testing.T) {
+		calls := 0
+		_, err := readExistingBlob(t.Context(), policy, "missing.parquet", func() (*BlobMetadata, error) {
+			calls++
+			return storage.ReadWithMetadata(t.Context(), "missing.parquet")
+		})
+		var consistencyErr *BlobConsistencyError
+		if !errors.As(err, &consistencyErr) {
+			t.Fatalf("error = %v, want *BlobConsistencyError", err)
+		}
+		if consistencyErr.Key != "missing.parquet" || consistencyErr.Attempts != 3 || calls != 3 {
+			t.Errorf("error = %+v after %d calls", consistencyErr, calls)
+		}
+		if !IsBlobNotFound(err) {
+			t.Error("BlobConsistencyError should unwrap to the not-found error")
+		}
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		calls := 0
+		failure := errors.New("access denied")
+		_, err := readExistingBlob(t.Context(), policy, key, func() (string, error) {
+			calls++
+			return "", failure
+		})
+		if !errors.Is(err, failure) || calls != 1 {
+			t.Errorf("error = %v after %d calls, want the failure after 1", err, calls)
+		}
+	})
+}
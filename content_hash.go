@@ -0,0 +1,38 @@
+package buildkitelogs
+
+// FNV-1a, chosen because hashes are stored in files and compared across builds, so they
+// must not change between processes or releases
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// ContentHash returns a stable 64-bit hash of a log line for duplicate detection and
+// diffing. ANSI escape sequences are removed and runs of ASCII whitespace are treated as
+// a single space, ignoring leading and trailing whitespace, so lines that differ only in
+// color or spacing hash the same. Hashes are FNV-1a and won't change between releases.
+//
+// Parquet files store the hash of every entry in the "hash" column (ParquetLogEntry.Hash).
+func ContentHash(content string) uint64 {
+	content = StripANSI(content)
+
+	hash := uint64(fnvOffset64)
+	started, pendingSpace := false, false
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch c {
+		case ' ', '\t', '\n', '\r', '\v', '\f':
+			pendingSpace = started
+			continue
+		}
+		if pendingSpace {
+			hash ^= ' '
+			hash *= fnvPrime64
+			pendingSpace = false
+		}
+		hash ^= uint64(c)
+		hash *= fnvPrime64
+		started = true
+	}
+	return hash
+}
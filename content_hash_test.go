@@ -0,0 +1,70 @@
+package buildkitelogs
+
+import (
+	"testing"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestContentHash(t *testing.T) {
+	same := []string{
+		"go test ./...",
+		"  go test ./...\r\n",
+		"go\ttest   ./...",
+		"\x1b[32mgo test\x1b[0m ./...",
+	}
+	for _, content := range same[1:] {
+		if ContentHash(content) != ContentHash(same[0]) {
+			t.Errorf("ContentHash(%q) differs from ContentHash(%q)", content, same[0])
+		}
+	}
+
+	different := []string{"go test ./", "gotest ./...", "GO TEST ./...", ""}
+	for _, content := range different {
+		if ContentHash(content) == ContentHash(same[0]) {
+			t.Errorf("ContentHash(%q) equals ContentHash(%q)", content, same[0])
+		}
+	}
+
+	// Hashes are stored in files, so they must never change
+	if got, want := ContentHash("hello world"), uint64(0x779a65e7023cd2e7); got != want {
+		t.Errorf("ContentHash(\"hello world\") = %#x, want %#x", got, want)
+	}
+}
+
+func TestParquetHashColumn(t *testing.T) {
+	entries := []*logparser.Entry{
+		{Content: "\x1b[31mFAIL\x1b[0m  pkg/a"},
+		{Content: "ok  pkg/b"},
+		{Content: "FAIL pkg/a"},
+	}
+	filename := writeGroupIndexTestFile(t, entries)
+
+	var hashes []uint64
+	for entry, err := range NewParquetReader(filename).ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if entry.Hash != ContentHash(entry.Content) {
+			t.Errorf("row %d hash = %#x, want %#x", entry.RowNumber, entry.Hash, ContentHash(entry.Content))
+		}
+		hashes = append(hashes, entry.Hash)
+	}
+	if len(hashes) != 3 || hashes[0] != hashes[2] || hashes[0] == hashes[1] {
+		t.Errorf("hashes = %#x, want rows 0 and 2 equal and row 1 different", hashes)
+	}
+}
+
+func TestParquetLogEntry_ContentHashWithoutColumn(t *testing.T) {
+	for entry, err := range NewParquetReader("testdata/bash-example.parquet").ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if entry.Hash != 0 {
+			t.Fatalf("testdata file unexpectedly has a hash column")
+		}
+		if entry.ContentHash() != ContentHash(entry.Content) {
+			t.Errorf("row %d ContentHash() = %#x, want %#x", entry.RowNumber, entry.ContentHash(), ContentHash(entry.Content))
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package buildkitelogs
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// CSVColumns lists the columns a CSVWriter accepts. They are the fields of
+// ParquetLogEntry under their JSON names, plus "time", the timestamp in RFC 3339 UTC
+// (empty for entries without one), and "is_group", true for group headers.
+var CSVColumns = []string{"row_number", "timestamp", "time", "content", "group", "parent_group", "depth", "flags", "is_group", "hash", "severity", "length"}
+
+// DefaultCSVColumns are the columns a CSVWriter writes unless CSVOptions.Columns names
+// others.
+var DefaultCSVColumns = []string{"row_number", "timestamp", "time", "group", "parent_group", "depth", "is_group", "severity", "content"}
+
+// CSVOptions configures a CSVWriter.
+type CSVOptions struct {
+	Columns  []string // Columns to write, in order, from CSVColumns (default: DefaultCSVColumns)
+	Comma    rune     // Field delimiter, '\t' for TSV (default: ',')
+	NoHeader bool     // Omit the header row of column names
+}
+
+// CSVWriter writes log entries as CSV (RFC 4180), one row per entry, so log slices open
+// in spreadsheets and load into DuckDB or BigQuery. Fields holding the delimiter, quotes
+// or newlines are quoted; content is written as logged, ANSI codes included.
+type CSVWriter struct {
+	w       *csv.Writer
+	columns []string
+	record  []string
+}
+
+// NewCSVWriter creates a writer of the columns in opts to w, writing the header row
+// first unless opts.NoHeader is set. It fails with ErrUnknownColumn for a column not in
+// CSVColumns.
+func NewCSVWriter(w io.Writer, opts CSVOptions) (*CSVWriter, error) {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns
+	}
+	for _, name := range columns {
+		if !slices.Contains(CSVColumns, name) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, name)
+		}
+	}
+
+	cw := &CSVWriter{
+		w:       csv.NewWriter(w),
+		columns: slices.Clone(columns),
+		record:  make([]string, len(columns)),
+	}
+	if opts.Comma != 0 {
+		cw.w.Comma = opts.Comma
+	}
+	if !opts.NoHeader {
+		if err := cw.w.Write(cw.columns); err != nil {
+			return nil, fmt.Errorf("failed to write CSV header: %w", err)
+		}
+	}
+	return cw, nil
+}
+
+// Write writes an entry as a row.
+func (cw *CSVWriter) Write(entry *ParquetLogEntry) error {
+	for i, name := range cw.columns {
+		cw.record[i] = csvField(entry, name)
+	}
+	if err := cw.w.Write(cw.record); err != nil {
+		return fmt.Errorf("failed to write CSV row: %w", err)
+	}
+	return nil
+}
+
+// Flush writes any buffered rows to the underlying writer.
+func (cw *CSVWriter) Flush() error {
+	cw.w.Flush()
+	if err := cw.w.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV export: %w", err)
+	}
+	return nil
+}
+
+// csvField formats the named column of an entry
+func csvField(entry *ParquetLogEntry, name string) string {
+	switch name {
+	case "row_number":
+		return strconv.FormatInt(entry.RowNumber, 10)
+	case "timestamp":
+		return strconv.FormatInt(entry.Timestamp, 10)
+	case "time":
+		if !entry.HasTime() {
+			return ""
+		}
+		return time.UnixMilli(entry.Timestamp).UTC().Format("2006-01-02T15:04:05.000Z")
+	case "content":
+		return entry.Content
+	case "group":
+		return entry.Group
+	case "parent_group":
+		return entry.ParentGroup
+	case "depth":
+		return strconv.Itoa(entry.Depth)
+	case "flags":
+		return strconv.FormatInt(int64(entry.Flags), 10)
+	case "is_group":
+		return strconv.FormatBool(entry.IsGroup())
+	case "hash":
+		return strconv.FormatUint(entry.Hash, 10)
+	case "severity":
+		return entry.Severity.String()
+	case "length":
+		return strconv.FormatInt(entry.Length, 10)
+	}
+	return ""
+}
+
+// ExportSeq2ToCSV writes log entries to w as CSV, with the columns and delimiter in opts
+// (see CSVWriter). Row numbers count the entries written, like ExportSeq2ToJSONL. It
+// returns the number of entries written.
+func ExportSeq2ToCSV(seq iter.Seq2[*logparser.Entry, error], w io.Writer, opts CSVOptions) (int, error) {
+	cw, err := NewCSVWriter(w, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	for entry, err := range seq {
+		if err != nil {
+			return rows, fmt.Errorf("error during iteration: %w", err)
+		}
+
+		record := ParquetLogEntry{
+			RowNumber:   int64(rows),
+			Timestamp:   entry.Timestamp.UnixMilli(),
+			Content:     entry.Content,
+			Group:       entry.Group,
+			ParentGroup: entry.ParentGroup,
+			Depth:       entry.Depth,
+			Flags:       entry.ComputeFlags(),
+			Hash:        ContentHash(entry.Content),
+			Severity:    entry.Severity(),
+		}
+		if err := cw.Write(&record); err != nil {
+			return rows, err
+		}
+		rows++
+	}
+
+	if err := cw.Flush(); err != nil {
+		return rows, err
+	}
+	return rows, nil
+}
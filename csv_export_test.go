@@ -0,0 +1,70 @@
+package buildkitelogs
+
+import (
+	"encoding/csv"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestExportSeq2ToCSV(t *testing.T) {
+	log := "\x1b_bk;t=1745322209921\x07~~~ Setup, \"quoted\"\n\x1b_bk;t=1745322209922\x07ERROR: a, b \"c\"\nno timestamp\n"
+
+	var out strings.Builder
+	rows, err := ExportSeq2ToCSV(logparser.New().All(strings.NewReader(log)), &out, CSVOptions{})
+	if err != nil {
+		t.Fatalf("ExportSeq2ToCSV failed: %v", err)
+	}
+	if rows != 3 {
+		t.Errorf("rows = %d, want 3", rows)
+	}
+	if !strings.Contains(out.String(), `"ERROR: a, b ""c"""`) {
+		t.Errorf("content not quoted:\n%s", out.String())
+	}
+
+	records, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	want := [][]string{
+		DefaultCSVColumns,
+		{"0", "1745322209921", "2025-04-22T11:43:29.921Z", "~~~ Setup, \"quoted\"", "", "0", "true", "info", "~~~ Setup, \"quoted\""},
+		{"1", "1745322209922", "2025-04-22T11:43:29.922Z", "~~~ Setup, \"quoted\"", "", "0", "false", "error", "ERROR: a, b \"c\""},
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want a header and 3 rows", len(records))
+	}
+	for i, record := range want {
+		if !slices.Equal(records[i], record) {
+			t.Errorf("record %d = %q, want %q", i, records[i], record)
+		}
+	}
+	if untimed := records[3]; untimed[2] != "" || untimed[8] != "no timestamp" {
+		t.Errorf("untimed record = %q, want an empty time", untimed)
+	}
+}
+
+func TestNewCSVWriter_ColumnsAndTSV(t *testing.T) {
+	var out strings.Builder
+	cw, err := NewCSVWriter(&out, CSVOptions{Columns: []string{"severity", "content", "hash"}, Comma: '\t', NoHeader: true})
+	if err != nil {
+		t.Fatalf("NewCSVWriter failed: %v", err)
+	}
+	entry := &ParquetLogEntry{Content: "a\tb", Hash: 42, Severity: logparser.SeverityWarn}
+	if err := cw.Write(entry); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := cw.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if got, want := out.String(), "warn\t\"a\tb\"\t42\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	if _, err := NewCSVWriter(&out, CSVOptions{Columns: []string{"content", "bogus"}}); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("NewCSVWriter with an unknown column = %v, want ErrUnknownColumn", err)
+	}
+}
@@ -0,0 +1,199 @@
+package buildkitelogs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// DatasetManifestFile is the name of the manifest IngestRawLogs writes at the root of a
+// dataset.
+const DatasetManifestFile = "manifest.json"
+
+// DatasetFile is a job log in a dataset.
+type DatasetFile struct {
+	Path          string    `json:"path"` // Relative to the dataset root, with forward slashes
+	Org           string    `json:"organization"`
+	Pipeline      string    `json:"pipeline"`
+	Build         string    `json:"build"`
+	Job           string    `json:"job"`
+	Source        string    `json:"source"` // Raw log the file was parsed from
+	Rows          int       `json:"rows"`
+	Bytes         int64     `json:"bytes"`
+	ParserVersion int       `json:"parser_version"`
+	IngestedAt    time.Time `json:"ingested_at"`
+}
+
+// DatasetManifest lists the files of a dataset written by IngestRawLogs.
+type DatasetManifest struct {
+	UpdatedAt time.Time     `json:"updated_at"`
+	Files     []DatasetFile `json:"files"` // Sorted by Path
+}
+
+// IngestOptions configures IngestRawLogs.
+type IngestOptions struct {
+	// Force re-parses logs already in the dataset. By default they are skipped, so
+	// ingesting a directory again only adds new logs.
+	Force bool
+	// ParserOptions configure the parser; the default truncates long lines as the
+	// Client does.
+	ParserOptions []logparser.Option
+	// OnFile, if set, is called after each raw log is ingested or skipped.
+	OnFile func(file DatasetFile, skipped bool)
+}
+
+// DatasetPartitionPath returns the path of a job's file in a dataset:
+// org=<org>/pipeline=<pipeline>/build=<build>/<job>.parquet, with values escaped as
+// needed.
+func DatasetPartitionPath(org, pipeline, build, job string) string {
+	return fmt.Sprintf("org=%s/pipeline=%s/build=%s/%s.parquet",
+		url.PathEscape(org), url.PathEscape(pipeline), url.PathEscape(build), url.PathEscape(job))
+}
+
+// IngestRawLogs converts the raw logs in rawDir into a Hive-partitioned Parquet dataset
+// in datasetDir, ready for tools like DuckDB and Spark to query as one table:
+//
+//	SELECT * FROM read_parquet('dataset/**/*.parquet', hive_partitioning = true)
+//
+// Raw logs are found by their path, rawDir/<org>/<pipeline>/<build>/<job>.log; other
+// files are ignored. Each is written to DatasetPartitionPath and recorded in the
+// dataset's manifest (DatasetManifestFile), which is updated rather than replaced, so a
+// dataset can be extended by ingesting more logs later. Files are renamed into place
+// once complete, so readers of the dataset never see partial files.
+func IngestRawLogs(ctx context.Context, rawDir, datasetDir string, opts IngestOptions) (*DatasetManifest, error) {
+	manifest, err := ReadDatasetManifest(datasetDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		manifest = &DatasetManifest{}
+	} else if err != nil {
+		return nil, err
+	}
+	files := make(map[string]DatasetFile, len(manifest.Files))
+	for _, file := range manifest.Files {
+		files[file.Path] = file
+	}
+
+	parserOptions := opts.ParserOptions
+	if parserOptions == nil {
+		parserOptions = []logparser.Option{logparser.WithTruncateLongLines(true)}
+	}
+
+	walkErr := filepath.WalkDir(rawDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".log" {
+			return nil
+		}
+		rel, err := filepath.Rel(rawDir, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 4 {
+			return nil
+		}
+
+		file := DatasetFile{
+			Org:      parts[0],
+			Pipeline: parts[1],
+			Build:    parts[2],
+			Job:      strings.TrimSuffix(parts[3], ".log"),
+			Source:   path,
+		}
+		file.Path = DatasetPartitionPath(file.Org, file.Pipeline, file.Build, file.Job)
+		target := filepath.Join(datasetDir, filepath.FromSlash(file.Path))
+
+		if existing, ok := files[file.Path]; ok && !opts.Force {
+			if _, err := os.Stat(target); err == nil {
+				if opts.OnFile != nil {
+					opts.OnFile(existing, true)
+				}
+				return nil
+			}
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("failed to create partition directory: %w", err)
+		}
+		rows, err := ReparseFile(path, target, parserOptions...)
+		if err != nil {
+			return fmt.Errorf("failed to ingest %s: %w", path, err)
+		}
+		info, err := os.Stat(target)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", target, err)
+		}
+		file.Rows = rows
+		file.Bytes = info.Size()
+		file.ParserVersion = logparser.Version
+		file.IngestedAt = time.Now().UTC()
+		files[file.Path] = file
+		if opts.OnFile != nil {
+			opts.OnFile(file, false)
+		}
+		return nil
+	})
+
+	// Record what was ingested even when a later log failed, so the next run skips it
+	manifest.Files = manifest.Files[:0]
+	for _, file := range files {
+		manifest.Files = append(manifest.Files, file)
+	}
+	slices.SortFunc(manifest.Files, func(a, b DatasetFile) int { return strings.Compare(a.Path, b.Path) })
+	manifest.UpdatedAt = time.Now().UTC()
+	if err := writeDatasetManifest(datasetDir, manifest); err != nil {
+		return nil, err
+	}
+	if walkErr != nil {
+		return nil, walkErr
+	}
+	return manifest, nil
+}
+
+// ReadDatasetManifest reads the manifest of a dataset written by IngestRawLogs. The
+// error wraps fs.ErrNotExist when the directory has no manifest.
+func ReadDatasetManifest(datasetDir string) (*DatasetManifest, error) {
+	data, err := os.ReadFile(filepath.Join(datasetDir, DatasetManifestFile)) //nolint:gosec // caller-controlled path
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset manifest: %w", err)
+	}
+	var manifest DatasetManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse dataset manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+func writeDatasetManifest(datasetDir string, manifest *DatasetManifest) error {
+	if err := os.MkdirAll(datasetDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create dataset directory: %w", err)
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode dataset manifest: %w", err)
+	}
+
+	path := filepath.Join(datasetDir, DatasetManifestFile)
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, append(data, '\n'), 0o644); err != nil { //nolint:gosec // dataset files are meant to be shared
+		return fmt.Errorf("failed to write dataset manifest: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to write dataset manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeRawTestLog(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestIngestRawLogs(t *testing.T) {
+	rawDir := t.TempDir()
+	datasetDir := filepath.Join(t.TempDir(), "dataset")
+	writeRawTestLog(t, filepath.Join(rawDir, "org", "api", "1", "job-a.log"),
+		"\x1b_bk;t=1745322209921\x07~~~ Setup\n\x1b_bk;t=1745322209922\x07hello\n")
+	writeRawTestLog(t, filepath.Join(rawDir, "org", "api", "2", "job-b.log"),
+		"\x1b_bk;t=1745322209921\x07one line\n")
+	writeRawTestLog(t, filepath.Join(rawDir, "org", "README.log"), "not a job log\n")
+	writeRawTestLog(t, filepath.Join(rawDir, "org", "api", "1", "notes.txt"), "not a log\n")
+
+	if _, err := ReadDatasetManifest(datasetDir); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("ReadDatasetManifest before ingesting error = %v, want fs.ErrNotExist", err)
+	}
+
+	manifest, err := IngestRawLogs(t.Context(), rawDir, datasetDir, IngestOptions{})
+	if err != nil {
+		t.Fatalf("IngestRawLogs failed: %v", err)
+	}
+	if len(manifest.Files) != 2 {
+		t.Fatalf("manifest lists %d files, want 2: %+v", len(manifest.Files), manifest.Files)
+	}
+	first := manifest.Files[0]
+	if first.Path != "org=org/pipeline=api/build=1/job-a.parquet" || first.Org != "org" || first.Pipeline != "api" ||
+		first.Build != "1" || first.Job != "job-a" || first.Rows != 2 {
+		t.Errorf("first file = %+v", first)
+	}
+
+	reader := NewParquetReader(filepath.Join(datasetDir, filepath.FromSlash(first.Path)))
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	if info.RowCount != 2 {
+		t.Errorf("RowCount = %d, want 2", info.RowCount)
+	}
+
+	// Ingesting again skips logs already in the dataset and adds new ones
+	writeRawTestLog(t, filepath.Join(rawDir, "org", "web", "7", "job-c.log"), "\x1b_bk;t=1745322209921\x07web\n")
+	var ingested, skipped int
+	manifest, err = IngestRawLogs(t.Context(), rawDir, datasetDir, IngestOptions{
+		OnFile: func(file DatasetFile, wasSkipped bool) {
+			if wasSkipped {
+				skipped++
+			} else {
+				ingested++
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("IngestRawLogs failed: %v", err)
+	}
+	if ingested != 1 || skipped != 2 || len(manifest.Files) != 3 {
+		t.Errorf("second ingest: %d ingested, %d skipped, %d files; want 1, 2, 3", ingested, skipped, len(manifest.Files))
+	}
+
+	read, err := ReadDatasetManifest(datasetDir)
+	if err != nil {
+		t.Fatalf("ReadDatasetManifest failed: %v", err)
+	}
+	if len(read.Files) != 3 || read.Files[2].Path != "org=org/pipeline=web/build=7/job-c.parquet" {
+		t.Errorf("manifest on disk = %+v", read.Files)
+	}
+}
+
+func TestDatasetPartitionPath(t *testing.T) {
+	if got, want := DatasetPartitionPath("org", "my pipeline", "12", "job"), "org=org/pipeline=my%20pipeline/build=12/job.parquet"; got != want {
+		t.Errorf("DatasetPartitionPath = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Environment variables configuring the default Client
+const (
+	// APITokenEnv names the variable holding the Buildkite API token.
+	APITokenEnv = "BUILDKITE_API_TOKEN"
+	// CacheURLEnv names the variable holding the cache storage URL. When unset, the cache
+	// location follows StorageModeEnv.
+	CacheURLEnv = "BKLOG_CACHE_URL"
+)
+
+// ErrNoAPIToken is returned by DefaultClient when APITokenEnv is not set.
+var ErrNoAPIToken = errors.New("BUILDKITE_API_TOKEN environment variable is required for the default client")
+
+// defaultClient is the Client used by the package-level helpers
+var defaultClient struct {
+	mu     sync.Mutex
+	client *Client
+}
+
+// DefaultClient returns the package-level Client used by Open and Search, creating it
+// on first use from APITokenEnv and CacheURLEnv. It is safe for concurrent use. If
+// creating it fails, the next call tries again, so scripts can fix their environment
+// and retry.
+//
+// The default client is for scripts; services should create their own Client with
+// NewClient, so configuration is explicit and the client can be closed.
+func DefaultClient(ctx context.Context) (*Client, error) {
+	defaultClient.mu.Lock()
+	defer defaultClient.mu.Unlock()
+
+	if defaultClient.client != nil {
+		return defaultClient.client, nil
+	}
+
+	token := os.Getenv(APITokenEnv)
+	if token == "" {
+		return nil, ErrNoAPIToken
+	}
+	client, err := NewClientWithAPI(ctx, NewBuildkiteAPIClient(token, "dev"), os.Getenv(CacheURLEnv))
+	if err != nil {
+		return nil, err
+	}
+	defaultClient.client = client
+	return client, nil
+}
+
+// SetDefaultClient replaces the Client used by Open and Search and returns the previous
+// one, which may be nil, so the caller can close it. Passing nil makes the next use
+// create a client from the environment again.
+func SetDefaultClient(client *Client) *Client {
+	defaultClient.mu.Lock()
+	defer defaultClient.mu.Unlock()
+
+	previous := defaultClient.client
+	defaultClient.client = client
+	return previous
+}
+
+// ParseJobRef parses a job reference: a Buildkite job URL (see ParseBuildkiteURL) or
+// "org/pipeline/build/job".
+func ParseJobRef(ref string) (JobLocation, error) {
+	ref = strings.TrimSpace(ref)
+	if strings.Contains(ref, "://") {
+		location, err := ParseBuildkiteURL(ref)
+		if err != nil {
+			return JobLocation{}, err
+		}
+		if location.Job == "" {
+			return JobLocation{}, fmt.Errorf("URL %q does not identify a job", ref)
+		}
+		return location, nil
+	}
+
+	parts := strings.Split(ref, "/")
+	if len(parts) != 4 {
+		return JobLocation{}, fmt.Errorf("job reference must be a job URL or org/pipeline/build/job, got %q", ref)
+	}
+	if err := ValidateAPIParams(parts[0], parts[1], parts[2], parts[3]); err != nil {
+		return JobLocation{}, err
+	}
+	build, err := NormalizeBuild(parts[2])
+	if err != nil {
+		return JobLocation{}, err
+	}
+	job, err := NormalizeJob(parts[3])
+	if err != nil {
+		return JobLocation{}, err
+	}
+	return JobLocation{Org: parts[0], Pipeline: parts[1], Build: build, Job: job}, nil
+}
+
+// Open returns a reader for the log of the job jobRef refers to (see ParseJobRef),
+// downloaded and cached by the DefaultClient.
+func Open(ctx context.Context, jobRef string) (*ParquetReader, error) {
+	location, err := ParseJobRef(jobRef)
+	if err != nil {
+		return nil, err
+	}
+	reader, _, err := openDefault(ctx, location)
+	return reader, err
+}
+
+// openDefault opens a job log with the DefaultClient, returning the location with a
+// LatestBuild resolved
+func openDefault(ctx context.Context, location JobLocation) (*ParquetReader, JobLocation, error) {
+	client, err := DefaultClient(ctx)
+	if err != nil {
+		return nil, location, err
+	}
+	if location.Build == LatestBuild {
+		if location.Build, err = client.ResolveBuild(ctx, location.Org, location.Pipeline, location.Build, ""); err != nil {
+			return nil, location, err
+		}
+	}
+	reader, err := client.NewReader(ctx, location.Org, location.Pipeline, location.Build, location.Job, 0, false)
+	return reader, location, err
+}
+
+// Search searches the log of the job jobRef refers to (see ParseJobRef) using the
+// DefaultClient. Results link to their line in the Buildkite UI unless opts.JobURL is
+// set.
+func Search(ctx context.Context, jobRef string, opts SearchOptions) iter.Seq2[SearchResult, error] {
+	return func(yield func(SearchResult, error) bool) {
+		location, err := ParseJobRef(jobRef)
+		if err != nil {
+			yield(SearchResult{}, err)
+			return
+		}
+		reader, location, err := openDefault(ctx, location)
+		if err != nil {
+			yield(SearchResult{}, err)
+			return
+		}
+		defer reader.Close()
+
+		if opts.JobURL == "" {
+			opts.JobURL = JobWebURL(location.Org, location.Pipeline, location.Build, location.Job)
+		}
+		for result, err := range reader.SearchEntriesIter(ctx, opts) {
+			if !yield(result, err) {
+				return
+			}
+		}
+	}
+}
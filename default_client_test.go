@@ -0,0 +1,74 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseJobRef(t *testing.T) {
+	const job = "0190a7a4-5b3c-7d1e-9f00-1234567890ab"
+	want := JobLocation{Org: "org", Pipeline: "pipe", Build: "12", Job: job}
+
+	for _, ref := range []string{
+		"org/pipe/12/" + job,
+		"org/pipe/#12/0190A7A4-5B3C-7D1E-9F00-1234567890AB",
+		"https://buildkite.com/org/pipe/builds/12#" + job,
+	} {
+		got, err := ParseJobRef(ref)
+		if err != nil {
+			t.Errorf("ParseJobRef(%q) failed: %v", ref, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseJobRef(%q) = %+v, want %+v", ref, got, want)
+		}
+	}
+
+	for _, ref := range []string{"", "org/pipe/12", "org/pipe/12/not-a-job", "https://buildkite.com/org/pipe/builds/12"} {
+		if _, err := ParseJobRef(ref); err == nil {
+			t.Errorf("ParseJobRef(%q) succeeded, want error", ref)
+		}
+	}
+}
+
+func TestDefaultClient(t *testing.T) {
+	t.Setenv(APITokenEnv, "")
+	previous := SetDefaultClient(nil)
+	t.Cleanup(func() { SetDefaultClient(previous) })
+
+	if _, err := DefaultClient(t.Context()); !errors.Is(err, ErrNoAPIToken) {
+		t.Fatalf("DefaultClient without a token error = %v, want ErrNoAPIToken", err)
+	}
+
+	client := newTestClient(t, newTerminalMock())
+	if old := SetDefaultClient(client); old != nil {
+		t.Errorf("SetDefaultClient returned %p, want nil", old)
+	}
+	if got, err := DefaultClient(t.Context()); err != nil || got != client {
+		t.Fatalf("DefaultClient = %p, %v; want the client set", got, err)
+	}
+
+	const ref = "org/pipe/12/0190a7a4-5b3c-7d1e-9f00-1234567890ab"
+	var results []SearchResult
+	for result, err := range Search(t.Context(), ref, SearchOptions{Pattern: "Test log"}) {
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		results = append(results, result)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if want := "https://buildkite.com/org/pipe/builds/12#0190a7a4-5b3c-7d1e-9f00-1234567890ab/1"; results[0].URL != want {
+		t.Errorf("URL = %q, want %q", results[0].URL, want)
+	}
+
+	reader, err := Open(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer reader.Close()
+	if info, err := reader.GetFileInfo(); err != nil || info.RowCount != 1 {
+		t.Errorf("GetFileInfo = %+v, %v; want 1 row", info, err)
+	}
+}
@@ -0,0 +1,148 @@
+package buildkitelogs
+
+import (
+	"context"
+	"iter"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DockerStep is a step of a docker build reconstructed from its log output. BuildKit
+// plain progress output ("#5 [2/4] RUN make") and the legacy builder's
+// "Step 2/4 : RUN make" lines are both recognised.
+type DockerStep struct {
+	ID       string        `json:"id"`   // BuildKit vertex number, or "Step 2/4" for the legacy builder
+	Name     string        `json:"name"` // e.g. "[2/4] RUN make"
+	Group    string        `json:"group"`
+	Cached   bool          `json:"cached,omitempty"`
+	Done     bool          `json:"done"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"` // Reported by BuildKit, or derived from log timestamps
+	Lines    int           `json:"lines"`    // Output lines attributed to the step
+	FirstRow int64         `json:"first_row"`
+	LastRow  int64         `json:"last_row"`
+
+	firstTimestamp int64
+	lastTimestamp  int64
+	reported       bool // Duration came from a BuildKit DONE line
+}
+
+var (
+	// #5 [2/4] RUN make, #5 0.512 output, #5 DONE 12.3s, #5 CACHED, #5 ERROR: ...
+	buildkitLineRegex   = regexp.MustCompile(`^#(\d+) (.*)$`)
+	buildkitOutputRegex = regexp.MustCompile(`^\d+\.\d+ `)
+	buildkitDoneRegex   = regexp.MustCompile(`^DONE (\d+(?:\.\d+)?)s$`)
+	// Step 2/4 : RUN make
+	legacyStepRegex = regexp.MustCompile(`^Step (\d+/\d+) : (.*)$`)
+)
+
+// DockerBuildSteps reconstructs the docker build steps found in the file, in the order
+// they started.
+func (pr *ParquetReader) DockerBuildSteps(ctx context.Context) ([]DockerStep, error) {
+	return AnalyzeDockerBuild(readParquetFileIter(ctx, pr.source, nil))
+}
+
+// AnalyzeDockerBuild reconstructs docker build steps and their durations from log
+// entries. BuildKit vertex numbers restart for every build, so a step is keyed by its
+// group as well as its ID; steps of separate builds within one group may be merged.
+func AnalyzeDockerBuild(entries iter.Seq2[ParquetLogEntry, error]) ([]DockerStep, error) {
+	var steps []*DockerStep
+	byKey := make(map[string]*DockerStep)
+	var legacy *DockerStep // current legacy builder step, which runs until the next one
+
+	start := func(id, name string, entry ParquetLogEntry) *DockerStep {
+		step := &DockerStep{
+			ID:             id,
+			Name:           name,
+			Group:          entry.Group,
+			FirstRow:       entry.RowNumber,
+			LastRow:        entry.RowNumber,
+			firstTimestamp: entry.Timestamp,
+			lastTimestamp:  entry.Timestamp,
+		}
+		steps = append(steps, step)
+		return step
+	}
+
+	for entry, err := range entries {
+		if err != nil {
+			return nil, err
+		}
+		if entry.IsGroup() {
+			legacy = nil
+			continue
+		}
+
+		content := entry.CleanContent(true)
+
+		if m := legacyStepRegex.FindStringSubmatch(content); m != nil {
+			if legacy != nil {
+				legacy.Done = true
+			}
+			legacy = start("Step "+m[1], m[2], entry)
+			continue
+		}
+
+		m := buildkitLineRegex.FindStringSubmatch(content)
+		if m == nil {
+			if legacy != nil && legacy.Group == entry.Group {
+				legacy.observe(entry)
+				switch {
+				case strings.Contains(content, "Using cache"):
+					legacy.Cached = true
+				case strings.HasPrefix(content, "Successfully built"):
+					legacy.Done = true
+				default:
+					legacy.Lines++
+				}
+			}
+			continue
+		}
+
+		key := entry.Group + "\x00" + m[1]
+		rest := m[2]
+		step, ok := byKey[key]
+		if !ok {
+			byKey[key] = start(m[1], rest, entry)
+			continue
+		}
+		step.observe(entry)
+
+		switch {
+		case rest == "CACHED":
+			step.Cached = true
+			step.Done = true
+		case strings.HasPrefix(rest, "ERROR"):
+			step.Error = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(rest, "ERROR"), ":"))
+		case rest == "CANCELED":
+			step.Error = "canceled"
+		case buildkitDoneRegex.MatchString(rest):
+			secs := buildkitDoneRegex.FindStringSubmatch(rest)[1]
+			if d, err := time.ParseDuration(secs + "s"); err == nil {
+				step.Duration = d
+				step.reported = true
+			}
+			step.Done = true
+		case buildkitOutputRegex.MatchString(rest):
+			step.Lines++
+		}
+	}
+
+	result := make([]DockerStep, len(steps))
+	for i, step := range steps {
+		if !step.reported && step.lastTimestamp > step.firstTimestamp {
+			step.Duration = time.Duration(step.lastTimestamp-step.firstTimestamp) * time.Millisecond
+		}
+		result[i] = *step
+	}
+	return result, nil
+}
+
+// observe extends the step to cover entry.
+func (s *DockerStep) observe(entry ParquetLogEntry) {
+	s.LastRow = entry.RowNumber
+	if entry.Timestamp > s.lastTimestamp {
+		s.lastTimestamp = entry.Timestamp
+	}
+}
@@ -0,0 +1,90 @@
+package buildkitelogs
+
+import (
+	"testing"
+	"time"
+)
+
+func dockerTestEntries(lines ...string) func(yield func(ParquetLogEntry, error) bool) {
+	return func(yield func(ParquetLogEntry, error) bool) {
+		for i, line := range lines {
+			entry := ParquetLogEntry{
+				RowNumber: int64(i),
+				Timestamp: 1745322209000 + int64(i)*1000,
+				Content:   line,
+				Group:     "~~~ :docker: Build image",
+			}
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestAnalyzeDockerBuild_BuildKit(t *testing.T) {
+	steps, err := AnalyzeDockerBuild(dockerTestEntries(
+		"#1 [internal] load build definition from Dockerfile",
+		"#1 DONE 0.1s",
+		"#2 [1/3] FROM docker.io/library/golang:1.25",
+		"#3 [2/3] COPY go.mod go.sum ./",
+		"#2 DONE 2.5s",
+		"#3 CACHED",
+		"#4 [3/3] RUN go build ./...",
+		"#4 0.512 go: downloading github.com/apache/arrow-go/v18",
+		"#4 12.001 building",
+		"#4 DONE 64.2s",
+		"#5 exporting to image",
+		"#5 ERROR: failed to push: denied",
+	))
+	if err != nil {
+		t.Fatalf("AnalyzeDockerBuild failed: %v", err)
+	}
+	if len(steps) != 5 {
+		t.Fatalf("got %d steps, want 5: %+v", len(steps), steps)
+	}
+
+	build := steps[3]
+	if build.ID != "4" || build.Name != "[3/3] RUN go build ./..." {
+		t.Errorf("unexpected step: %+v", build)
+	}
+	if build.Duration != 64200*time.Millisecond || !build.Done || build.Lines != 2 {
+		t.Errorf("unexpected RUN step timing: %+v", build)
+	}
+	if build.FirstRow != 6 || build.LastRow != 9 {
+		t.Errorf("rows = %d-%d, want 6-9", build.FirstRow, build.LastRow)
+	}
+	if !steps[2].Cached {
+		t.Errorf("expected COPY step to be cached: %+v", steps[2])
+	}
+	if steps[4].Error != "failed to push: denied" || steps[4].Done {
+		t.Errorf("unexpected export step: %+v", steps[4])
+	}
+}
+
+func TestAnalyzeDockerBuild_Legacy(t *testing.T) {
+	steps, err := AnalyzeDockerBuild(dockerTestEntries(
+		"Step 1/3 : FROM golang:1.25",
+		" ---> 1b2c3d4e5f6a",
+		"Step 2/3 : COPY . .",
+		" ---> Using cache",
+		"Step 3/3 : RUN go build ./...",
+		" ---> Running in 0a1b2c3d",
+		"building",
+		"Successfully built 9f8e7d6c5b4a",
+	))
+	if err != nil {
+		t.Fatalf("AnalyzeDockerBuild failed: %v", err)
+	}
+	if len(steps) != 3 {
+		t.Fatalf("got %d steps, want 3: %+v", len(steps), steps)
+	}
+	if steps[0].ID != "Step 1/3" || steps[0].Name != "FROM golang:1.25" {
+		t.Errorf("unexpected step: %+v", steps[0])
+	}
+	if !steps[1].Cached {
+		t.Errorf("expected step 2 to be cached: %+v", steps[1])
+	}
+	if !steps[2].Done || steps[2].Duration != 3*time.Second {
+		t.Errorf("unexpected step 3: %+v", steps[2])
+	}
+}
@@ -0,0 +1,69 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultDownloadBuildConcurrency is the number of job logs DownloadBuild downloads at once.
+const DefaultDownloadBuildConcurrency = 4
+
+// DownloadBuild downloads and caches the log of every job in a build in parallel, and
+// returns the cached Parquet file of each, keyed by job ID. Only command jobs that have
+// started are downloaded, and jobs that have been retried are skipped in favour of their
+// latest retry. Like the paths readers use, the files are shared and must not be removed.
+// Clients created WithInMemory cache the logs without local files, so the paths are empty.
+//
+// Jobs whose logs could not be downloaded don't stop the others: their errors are
+// returned joined, together with the paths of the jobs that were cached.
+func (c *Client) DownloadBuild(ctx context.Context, org, pipeline, build string) (map[string]string, error) {
+	provider, ok := c.api.(BuildProvider)
+	if !ok {
+		return nil, fmt.Errorf("API client does not support listing build jobs")
+	}
+	if org == "" || pipeline == "" || build == "" {
+		return nil, fmt.Errorf("organization, pipeline and build are required to download a build")
+	}
+
+	b, err := provider.GetBuild(ctx, org, pipeline, build)
+	if err != nil {
+		return nil, err
+	}
+	// Cache under the build number, so build UUIDs share cache keys with build numbers
+	if b.Number > 0 {
+		build = strconv.Itoa(b.Number)
+	}
+
+	var (
+		mu    sync.Mutex
+		paths = make(map[string]string)
+		errs  []error
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(DefaultDownloadBuildConcurrency)
+	for _, job := range b.Jobs {
+		if job.Type != "script" || job.Retried || job.StartedAt == nil {
+			continue
+		}
+		g.Go(func() error {
+			path, err := c.downloadAndCache(gctx, c.api, org, pipeline, build, job.ID, 0, false)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("job %s: %w", job.ID, err))
+			} else {
+				paths[job.ID] = path
+			}
+			return gctx.Err()
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return paths, errors.Join(errs...)
+}
@@ -0,0 +1,80 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v5"
+)
+
+func TestClient_DownloadBuild(t *testing.T) {
+	started := &buildkite.Timestamp{Time: time.Now()}
+	api := &stepBuildAPI{
+		mockBuildkiteAPI: newTerminalMock(),
+		build: buildkite.Build{
+			ID:     "0190046e-e199-453b-a302-a21a4d649d31",
+			Number: 42,
+			Jobs: []buildkite.Job{
+				{ID: "wait-1", Type: "waiter"},
+				{ID: "lint", Type: "script", StartedAt: started},
+				{ID: "unit-old", Type: "script", StartedAt: started, Retried: true},
+				{ID: "unit", Type: "script", StartedAt: started},
+				{ID: "deploy", Type: "script", State: "scheduled"},
+			},
+		},
+	}
+	client := newTestClient(t, api)
+
+	paths, err := client.DownloadBuild(t.Context(), "org", "pipeline", api.build.ID)
+	if err != nil {
+		t.Fatalf("DownloadBuild failed: %v", err)
+	}
+	if len(paths) != 2 || paths["lint"] == "" || paths["unit"] == "" {
+		t.Fatalf("paths = %v, want lint and unit", paths)
+	}
+	for job, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("cached file of %s: %v", job, err)
+		}
+	}
+	if logCalls, _ := api.calls(); logCalls != 2 {
+		t.Errorf("downloaded %d logs, want 2", logCalls)
+	}
+
+	// Logs are cached under the build number
+	reader, err := client.NewReader(t.Context(), "org", "pipeline", "42", "lint", 0, false)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	reader.Close()
+	if logCalls, _ := api.calls(); logCalls != 2 {
+		t.Errorf("NewReader downloaded the cached log again (%d downloads)", logCalls)
+	}
+}
+
+func TestClient_DownloadBuild_Errors(t *testing.T) {
+	mock := newTerminalMock()
+	mock.logErr = errors.New("log unavailable")
+	api := &stepBuildAPI{
+		mockBuildkiteAPI: mock,
+		build: buildkite.Build{Number: 7, Jobs: []buildkite.Job{
+			{ID: "tests", Type: "script", StartedAt: &buildkite.Timestamp{Time: time.Now()}},
+		}},
+	}
+	client := newTestClient(t, api)
+
+	paths, err := client.DownloadBuild(t.Context(), "org", "pipeline", "7")
+	if err == nil || !strings.Contains(err.Error(), "job tests") {
+		t.Fatalf("error = %v, want the failed job", err)
+	}
+	if len(paths) != 0 {
+		t.Errorf("paths = %v, want none", paths)
+	}
+
+	if _, err := newTestClient(t, newTerminalMock()).DownloadBuild(t.Context(), "org", "pipeline", "7"); err == nil {
+		t.Error("expected error for API without build listing")
+	}
+}
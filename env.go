@@ -0,0 +1,128 @@
+package buildkitelogs
+
+import (
+	"context"
+	"iter"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultEnvironmentGroup is the group name pattern ExtractEnvironment reads variables
+// from by default: any group with "environment" in its name, such as the agent's
+// environment hook groups.
+const DefaultEnvironmentGroup = "environment"
+
+// RedactedValue replaces the values of redacted environment variables.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactedVars are the name patterns, matched case-insensitively with path.Match,
+// of environment variables whose values are redacted. They follow the agent's default
+// BUILDKITE_REDACTED_VARS.
+var DefaultRedactedVars = []string{
+	"*_PASSWORD",
+	"*_SECRET",
+	"*_TOKEN",
+	"*_PRIVATE_KEY",
+	"*_ACCESS_KEY",
+	"*_SECRET_KEY",
+	"*_CONNECTION_STRING",
+}
+
+// EnvironmentOptions configures ExtractEnvironment.
+type EnvironmentOptions struct {
+	GroupPattern string   // Groups to read, matched like FilterByGroupIter (empty = DefaultEnvironmentGroup)
+	RedactVars   []string // Name patterns to redact (nil = DefaultRedactedVars)
+}
+
+// Environment holds the environment variables a job printed.
+type Environment struct {
+	Vars     map[string]string `json:"vars"`
+	Redacted []string          `json:"redacted,omitempty"` // Names whose values were replaced with RedactedValue, sorted
+	Groups   []string          `json:"groups"`             // Groups the variables were read from, in log order
+}
+
+// KEY=VALUE, optionally as printed by `export -p` or `declare -x`
+var envLineRegex = regexp.MustCompile(`^(?:export |declare -x )?([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+
+// Environment extracts the environment variables printed in the file's environment
+// groups, see ExtractEnvironment. Files with a group index only read the matching groups.
+func (pr *ParquetReader) Environment(ctx context.Context, opts EnvironmentOptions) (*Environment, error) {
+	pattern := environmentGroup(opts)
+	return ExtractEnvironment(pr.FilterByGroupIter(ctx, pattern), opts)
+}
+
+// ExtractEnvironment parses the KEY=VALUE lines of the groups matching
+// opts.GroupPattern into a map, for comparing the configuration of builds. Values
+// quoted by `export -p` are unquoted, and a variable printed twice keeps its last value.
+// Values of variables matching opts.RedactVars are replaced with RedactedValue; values
+// the agent already redacted are kept as printed.
+func ExtractEnvironment(entries iter.Seq2[ParquetLogEntry, error], opts EnvironmentOptions) (*Environment, error) {
+	pattern := environmentGroup(opts)
+	redact := opts.RedactVars
+	if redact == nil {
+		redact = DefaultRedactedVars
+	}
+
+	env := &Environment{Vars: make(map[string]string), Groups: []string{}}
+	redacted := make(map[string]bool)
+	for entry, err := range entries {
+		if err != nil {
+			return nil, err
+		}
+		if !groupMatches(entry.Group, pattern) {
+			continue
+		}
+		if len(env.Groups) == 0 || env.Groups[len(env.Groups)-1] != entry.Group {
+			env.Groups = append(env.Groups, entry.Group)
+		}
+		if entry.IsGroup() {
+			continue
+		}
+
+		m := envLineRegex.FindStringSubmatch(entry.CleanContent(true))
+		if m == nil {
+			continue
+		}
+		name, value := m[1], unquoteEnvValue(m[2])
+		if envNameMatches(name, redact) {
+			value = RedactedValue
+			redacted[name] = true
+		}
+		env.Vars[name] = value
+	}
+
+	for name := range redacted {
+		env.Redacted = append(env.Redacted, name)
+	}
+	slices.Sort(env.Redacted)
+	return env, nil
+}
+
+func environmentGroup(opts EnvironmentOptions) string {
+	if opts.GroupPattern == "" {
+		return DefaultEnvironmentGroup
+	}
+	return opts.GroupPattern
+}
+
+// unquoteEnvValue removes the double quotes `export -p` and `declare -x` print around
+// values.
+func unquoteEnvValue(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		return strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+	}
+	return value
+}
+
+// envNameMatches reports whether name matches any of patterns, ignoring case.
+func envNameMatches(name string, patterns []string) bool {
+	name = strings.ToUpper(name)
+	for _, pattern := range patterns {
+		if ok, err := path.Match(strings.ToUpper(pattern), name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package buildkitelogs
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestParquetReader_Environment(t *testing.T) {
+	log := strings.Join([]string{
+		"~~~ Running global environment hook",
+		"export CI=\"true\"",
+		"declare -x BUILDKITE_BRANCH=\"main\"",
+		"GITHUB_TOKEN=ghp_secret",
+		"\x1b[32mGO_VERSION=1.25\x1b[0m",
+		"not a variable",
+		"AWS_SECRET_ACCESS_KEY=[REDACTED]",
+		"~~~ Running commands",
+		"FOO=from-a-command",
+		"~~~ Environment after checkout",
+		"CI=1",
+	}, "\n") + "\n"
+
+	filename := filepath.Join(t.TempDir(), "log.parquet")
+	if err := ExportSeq2ToParquet(logparser.New().All(strings.NewReader(log)), filename); err != nil {
+		t.Fatalf("ExportSeq2ToParquet failed: %v", err)
+	}
+
+	env, err := NewParquetReader(filename).Environment(t.Context(), EnvironmentOptions{})
+	if err != nil {
+		t.Fatalf("Environment failed: %v", err)
+	}
+	want := map[string]string{
+		"CI":                    "1",
+		"BUILDKITE_BRANCH":      "main",
+		"GITHUB_TOKEN":          RedactedValue,
+		"GO_VERSION":            "1.25",
+		"AWS_SECRET_ACCESS_KEY": "[REDACTED]",
+	}
+	if !maps.Equal(env.Vars, want) {
+		t.Errorf("Vars = %v, want %v", env.Vars, want)
+	}
+	if !slices.Equal(env.Redacted, []string{"GITHUB_TOKEN"}) {
+		t.Errorf("Redacted = %v, want [GITHUB_TOKEN]", env.Redacted)
+	}
+	if wantGroups := []string{"~~~ Running global environment hook", "~~~ Environment after checkout"}; !slices.Equal(env.Groups, wantGroups) {
+		t.Errorf("Groups = %q, want %q", env.Groups, wantGroups)
+	}
+
+	env, err = NewParquetReader(filename).Environment(t.Context(), EnvironmentOptions{GroupPattern: "commands", RedactVars: []string{"foo"}})
+	if err != nil {
+		t.Fatalf("Environment failed: %v", err)
+	}
+	if !maps.Equal(env.Vars, map[string]string{"FOO": RedactedValue}) {
+		t.Errorf("Vars of the commands group = %v", env.Vars)
+	}
+}
@@ -0,0 +1,177 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// WithErrorsView makes the client also cache an errors view of every log it caches: a
+// small Parquet file holding only group headers and error or warning lines, stored under
+// ErrorsViewKey. Dashboards can load the view instead of the full log. Default is off.
+func WithErrorsView(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.errorsView = enabled
+	}
+}
+
+// ErrorsViewKey returns the blob key of a job's errors view.
+func ErrorsViewKey(org, pipeline, build, job string) string {
+	return strings.TrimSuffix(GenerateBlobKey(org, pipeline, build, job), ".parquet") + "-errors.parquet"
+}
+
+// IsErrorsViewEntry reports whether an entry belongs in the errors view: a group header,
+// or a line that logparser.IsErrorLine or logparser.IsWarningLine matches.
+func IsErrorsViewEntry(entry *logparser.Entry) bool {
+	return entry.IsGroup() || entry.IsError() || entry.IsWarning()
+}
+
+// NewErrorsReader downloads and caches job logs (if needed) like NewReader, but returns
+// a reader over the job's errors view. The view is built from the cached log if it is
+// missing or older than the log, e.g. because the log was cached without WithErrorsView.
+//
+// Row numbers in the view are positions within the view, not the full log, and readers
+// of the view carry no annotations.
+func (c *Client) NewErrorsReader(ctx context.Context, org, pipeline, build, job string, ttl time.Duration, forceRefresh bool) (*ParquetReader, error) {
+	result, err := c.downloadAndCacheResult(ctx, c.api, org, pipeline, build, job, ttl, forceRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := c.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	blobKey := GenerateBlobKey(org, pipeline, build, job)
+	viewKey := ErrorsViewKey(org, pipeline, build, job)
+	current, err := c.errorsViewCurrent(ctx, blobKey, viewKey)
+	if err != nil {
+		return nil, err
+	}
+	if !current {
+		metadata, err := c.blobStorage.cachedLogMetadata(ctx, blobKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cached log metadata: %w", err)
+		}
+		source, err := c.readerForResult(ctx, result)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.writeErrorsView(ctx, source, viewKey, metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.inMemory {
+		return c.newInMemoryReader(ctx, viewKey)
+	}
+	viewPath, err := c.createLocalCacheFileWithHooks(ctx, org, pipeline, build, job, viewKey)
+	if err != nil {
+		return nil, err
+	}
+	return c.newParquetReader(viewPath), nil
+}
+
+// errorsViewCurrent reports whether the errors view exists and was built from the
+// currently cached log.
+func (c *Client) errorsViewCurrent(ctx context.Context, blobKey, viewKey string) (bool, error) {
+	exists, err := c.blobStorage.Exists(ctx, viewKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to check errors view: %w", err)
+	}
+	if !exists {
+		return false, nil
+	}
+
+	logMetadata, err := c.blobStorage.cachedLogMetadata(ctx, blobKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to read cached log metadata: %w", err)
+	}
+	viewMetadata, err := c.blobStorage.ReadWithMetadata(ctx, viewKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to read errors view metadata: %w", err)
+	}
+	if logMetadata == nil || viewMetadata == nil {
+		return false, nil
+	}
+	return viewMetadata.CachedAt.Equal(logMetadata.CachedAt), nil
+}
+
+// writeErrorsView builds the errors view of the Parquet log source reads and stores it
+// under viewKey. The view's metadata copies the log's, so its CachedAt identifies the
+// log version it was built from.
+func (c *Client) writeErrorsView(ctx context.Context, source *ParquetReader, viewKey string, logMetadata *BlobMetadata) error {
+	spool, err := c.newParquetSpool("bklog-errors-*.parquet")
+	if err != nil {
+		return err
+	}
+	defer spool.remove()
+
+	rows, err := spool.export(logEntriesFromParquet(source.ReadEntriesIter(ctx)), IsErrorsViewEntry)
+	if err != nil {
+		return fmt.Errorf("failed to export errors view: %w", err)
+	}
+	size, err := spool.size()
+	if err != nil {
+		return fmt.Errorf("failed to measure errors view: %w", err)
+	}
+
+	contentSHA256, err := spool.sha256()
+	if err != nil {
+		return fmt.Errorf("failed to hash errors view: %w", err)
+	}
+
+	var metadata *BlobMetadata
+	if logMetadata != nil {
+		copied := *logMetadata
+		copied.ParquetSize = size
+		copied.ContentSHA256 = contentSHA256
+		copied.RowCount = rows
+		copied.ProcessedAt = time.Now()
+		copied.SegmentGeneration = "" // The view is always stored whole
+		metadata = &copied
+	}
+
+	data, err := spool.open()
+	if err != nil {
+		return fmt.Errorf("failed to open errors view: %w", err)
+	}
+	defer data.Close()
+
+	if err := c.blobStorage.WriteWithMetadataFrom(ctx, viewKey, data, metadata); err != nil {
+		return fmt.Errorf("failed to write errors view: %w", err)
+	}
+	return nil
+}
+
+// logEntriesFromParquet converts entries read from a Parquet log back into parser entries,
+// so they can be exported again.
+func logEntriesFromParquet(entries iter.Seq2[ParquetLogEntry, error]) iter.Seq2[*logparser.Entry, error] {
+	return func(yield func(*logparser.Entry, error) bool) {
+		for entry, err := range entries {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			converted := &logparser.Entry{
+				Content:          entry.Content,
+				Group:            entry.Group,
+				ParentGroup:      entry.ParentGroup,
+				Depth:            entry.Depth,
+				InvalidTimestamp: entry.Flags.HasInvalidTimestamp(),
+			}
+			if entry.HasTime() {
+				converted.Timestamp = time.UnixMilli(entry.Timestamp)
+			}
+			if !yield(converted, nil) {
+				return
+			}
+		}
+	}
+}
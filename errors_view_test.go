@@ -0,0 +1,112 @@
+package buildkitelogs
+
+import (
+	"slices"
+	"testing"
+)
+
+const errorsViewTestLog = "\x1b_bk;t=1745322209921\x07~~~ Running tests\n" +
+	"\x1b_bk;t=1745322209922\x07ok   pkg/a\n" +
+	"\x1b_bk;t=1745322209923\x07WARNING: flag -x is deprecated\n" +
+	"\x1b_bk;t=1745322209924\x07ok   pkg/b\n" +
+	"\x1b_bk;t=1745322209925\x07ERROR: connection refused\n"
+
+func readContents(t *testing.T, pr *ParquetReader) []string {
+	t.Helper()
+	var contents []string
+	for entry, err := range pr.ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter() error = %v", err)
+		}
+		contents = append(contents, entry.Content)
+	}
+	return contents
+}
+
+func TestClient_ErrorsViewWrittenOnRefresh(t *testing.T) {
+	api := newTerminalMock()
+	api.logContent = errorsViewTestLog
+	client := newTestClient(t, api, WithErrorsView(true))
+
+	if _, err := client.NewReader(t.Context(), "org", "pipeline", "1", "job", 0, false); err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+
+	viewKey := ErrorsViewKey("org", "pipeline", "1", "job")
+	if viewKey != "org-pipeline-1-job-errors.parquet" {
+		t.Errorf("ErrorsViewKey() = %q", viewKey)
+	}
+	metadata, err := client.blobStorage.ReadWithMetadata(t.Context(), viewKey)
+	if err != nil {
+		t.Fatalf("errors view not written: %v", err)
+	}
+	if metadata == nil || metadata.RowCount != 3 {
+		t.Fatalf("errors view metadata = %+v, want RowCount 3", metadata)
+	}
+
+	reader, err := client.NewErrorsReader(t.Context(), "org", "pipeline", "1", "job", 0, false)
+	if err != nil {
+		t.Fatalf("NewErrorsReader() error = %v", err)
+	}
+	want := []string{"~~~ Running tests", "WARNING: flag -x is deprecated", "ERROR: connection refused"}
+	if got := readContents(t, reader); !slices.Equal(got, want) {
+		t.Errorf("errors view = %q, want %q", got, want)
+	}
+}
+
+func TestClient_NewErrorsReaderBuildsMissingView(t *testing.T) {
+	api := newTerminalMock()
+	api.logContent = errorsViewTestLog
+	client := newTestClient(t, api)
+
+	if _, err := client.NewReader(t.Context(), "org", "pipeline", "1", "job", 0, false); err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	viewKey := ErrorsViewKey("org", "pipeline", "1", "job")
+	if exists, _ := client.blobStorage.Exists(t.Context(), viewKey); exists {
+		t.Fatal("errors view written without WithErrorsView")
+	}
+
+	reader, err := client.NewErrorsReader(t.Context(), "org", "pipeline", "1", "job", 0, false)
+	if err != nil {
+		t.Fatalf("NewErrorsReader() error = %v", err)
+	}
+	if got := readContents(t, reader); len(got) != 3 {
+		t.Errorf("errors view = %q, want 3 entries", got)
+	}
+	if exists, _ := client.blobStorage.Exists(t.Context(), viewKey); !exists {
+		t.Error("errors view not stored")
+	}
+
+	removed, err := client.Invalidate(t.Context(), JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"})
+	if err != nil || !removed {
+		t.Fatalf("Invalidate() = %v, %v", removed, err)
+	}
+	if exists, _ := client.blobStorage.Exists(t.Context(), viewKey); exists {
+		t.Error("errors view not removed by Invalidate")
+	}
+}
+
+func TestClient_NewErrorsReaderKeepsInvalidTimestampFlag(t *testing.T) {
+	api := newTerminalMock()
+	// A timestamp in seconds rather than milliseconds falls outside the parser's bounds
+	api.logContent = errorsViewTestLog + "\x1b_bk;t=1745322209\x07ERROR: clock in seconds\n"
+	client := newTestClient(t, api)
+
+	reader, err := client.NewErrorsReader(t.Context(), "org", "pipeline", "1", "job", 0, false)
+	if err != nil {
+		t.Fatalf("NewErrorsReader() error = %v", err)
+	}
+	var flagged []string
+	for entry, err := range reader.ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter() error = %v", err)
+		}
+		if entry.Flags.HasInvalidTimestamp() {
+			flagged = append(flagged, entry.Content)
+		}
+	}
+	if want := []string{"ERROR: clock in seconds"}; !slices.Equal(flagged, want) {
+		t.Errorf("entries flagged with an invalid timestamp = %q, want %q", flagged, want)
+	}
+}
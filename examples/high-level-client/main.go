@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"time"
 
@@ -50,6 +51,9 @@ func run() error {
 		log.Printf("Downloaded %d bytes in %v", result.LogSize, result.Duration)
 	})
 
+	// Or report every stage of a read as one structured log line
+	buildkiteLogsClient.Hooks().AddSummary(buildkitelogs.NewSlogSummaryHook(slog.Default()))
+
 	org := envOrDefault("BUILDKITE_ORGANIZATION_SLUG", "myorg")
 	pipeline := envOrDefault("BUILDKITE_PIPELINE_SLUG", "mypipeline")
 	build := envOrDefault("BUILDKITE_BUILD_NUMBER", "123")
@@ -0,0 +1,199 @@
+package buildkitelogs
+
+import (
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/metadata"
+)
+
+// ScanStrategy names how a read locates the rows it returns.
+type ScanStrategy string
+
+const (
+	ScanFull       ScanStrategy = "full-scan"     // Every row group is decoded
+	ScanGroupIndex ScanStrategy = "group-index"   // Row groups are chosen from the group index
+	ScanSeek       ScanStrategy = "seek"          // Row groups before the start row are skipped
+	ScanRowRanges  ScanStrategy = "row-ranges"    // Only row groups holding the requested rows are decoded
+	ScanMetadata   ScanStrategy = "metadata-only" // Only the file footer is read
+	ScanStatistics ScanStrategy = "statistics"    // Row groups are chosen from their timestamp statistics
+)
+
+// QueryPlan describes how a read of a Parquet log will execute, without running it. It
+// shows whether row group pruning applies, e.g. when a slow query is reported.
+type QueryPlan struct {
+	Strategy      ScanStrategy `json:"strategy"`
+	Columns       []string     `json:"columns"`         // Projected columns
+	GroupIndex    bool         `json:"group_index"`     // The file has a group index
+	RowGroups     int          `json:"row_groups"`      // Row groups in the file
+	RowGroupsRead []int        `json:"row_groups_read"` // Row groups the read decodes
+	TotalRows     int64        `json:"total_rows"`      // Rows in the file
+	RowsScanned   int64        `json:"rows_scanned"`    // Rows in the row groups read
+	ScanBytes     int64        `json:"scan_bytes"`      // Compressed size of the row groups read
+	FileSize      int64        `json:"file_size_bytes"` // Size of the file
+	Notes         []string     `json:"notes,omitempty"` // Why the strategy was chosen
+}
+
+// ExplainRead explains ReadEntriesIter and other reads of every entry, such as
+// ListGroups and forward searches without a seek position. The columns are those of
+// ReadEntriesIter; ListGroups reads every column.
+func (pr *ParquetReader) ExplainRead() (*QueryPlan, error) {
+	return pr.explain(pr.readColumns(), func(md *metadata.FileMetaData, plan *QueryPlan) {
+		plan.Strategy = ScanFull
+		plan.RowGroupsRead = allRowGroups(md)
+	})
+}
+
+// ExplainFilterByGroup explains FilterByGroupIter with groupPattern.
+func (pr *ParquetReader) ExplainFilterByGroup(groupPattern string) (*QueryPlan, error) {
+	return pr.explain(pr.readColumns("group"), func(md *metadata.FileMetaData, plan *QueryPlan) {
+		index, ok, err := groupIndexFromMetadata(md)
+		switch {
+		case err != nil:
+			plan.Strategy = ScanFull
+			plan.RowGroupsRead = allRowGroups(md)
+			plan.Notes = append(plan.Notes, fmt.Sprintf("group index unreadable (%v), falling back to a full scan", err))
+		case !ok:
+			plan.Strategy = ScanFull
+			plan.RowGroupsRead = allRowGroups(md)
+			plan.Notes = append(plan.Notes, "file has no group index, falling back to a full scan")
+		default:
+			ranges := matchingGroupRanges(index, func(group string) bool { return groupMatches(pr.canonicalGroup(group), groupPattern) })
+			plan.Strategy = ScanGroupIndex
+			plan.RowGroupsRead = rangeRowGroups(md, ranges)
+			plan.Notes = append(plan.Notes, fmt.Sprintf("%d of %d group ranges match %q", len(ranges), len(index), groupPattern))
+		}
+	})
+}
+
+// ExplainSeek explains SeekToRow from startRow.
+func (pr *ParquetReader) ExplainSeek(startRow int64) (*QueryPlan, error) {
+	return pr.explain(pr.readColumns(), func(md *metadata.FileMetaData, plan *QueryPlan) {
+		plan.Strategy = ScanSeek
+		if startRow >= plan.TotalRows {
+			plan.Notes = append(plan.Notes, fmt.Sprintf("start row %d is beyond file bounds (total rows: %d)", startRow, plan.TotalRows))
+			return
+		}
+		plan.RowGroupsRead = rangeRowGroups(md, []GroupRange{{FirstRow: startRow, LastRow: plan.TotalRows - 1}})
+	})
+}
+
+// ExplainSearch explains SearchEntriesIter with options, which skips the row groups
+// before a forward search's SeekStart and those whose timestamps are outside its time
+// range.
+func (pr *ParquetReader) ExplainSearch(options SearchOptions) (*QueryPlan, error) {
+	columns := pr.readColumns("content", "flags")
+	if options.hasTimeRange() {
+		columns = withTimestamp(columns)
+	}
+	return pr.explain(columns, func(md *metadata.FileMetaData, plan *QueryPlan) {
+		startRow := int64(0)
+		if !options.Reverse {
+			startRow = options.SeekStart
+		}
+		switch {
+		case options.hasTimeRange():
+			ranges := timeRangeRowRanges(md, startRow, options)
+			plan.Strategy = ScanStatistics
+			plan.RowGroupsRead = rangeRowGroups(md, ranges)
+			plan.Notes = append(plan.Notes, timeRangeNote(options))
+		case startRow >= plan.TotalRows:
+			plan.Strategy = ScanSeek
+			plan.Notes = append(plan.Notes, fmt.Sprintf("start row %d is beyond file bounds (total rows: %d)", startRow, plan.TotalRows))
+		case startRow > 0:
+			plan.Strategy = ScanSeek
+			plan.RowGroupsRead = rangeRowGroups(md, []GroupRange{{FirstRow: startRow, LastRow: plan.TotalRows - 1}})
+		default:
+			plan.Strategy = ScanFull
+			plan.RowGroupsRead = allRowGroups(md)
+		}
+	})
+}
+
+// ExplainAnnotatedEntries explains AnnotatedEntriesIter with label.
+func (pr *ParquetReader) ExplainAnnotatedEntries(label string) (*QueryPlan, error) {
+	return pr.explain(pr.readColumns(), func(md *metadata.FileMetaData, plan *QueryPlan) {
+		ranges := pr.annotationRanges(label)
+		plan.Strategy = ScanRowRanges
+		plan.RowGroupsRead = rangeRowGroups(md, ranges)
+		plan.Notes = append(plan.Notes, fmt.Sprintf("%d annotated row ranges", len(ranges)))
+	})
+}
+
+// ExplainMetadata explains GetFileInfo and GroupIndex, which read only the file footer.
+func (pr *ParquetReader) ExplainMetadata() (*QueryPlan, error) {
+	return pr.explain(nil, func(md *metadata.FileMetaData, plan *QueryPlan) {
+		plan.Strategy = ScanMetadata
+		plan.Columns = nil
+	})
+}
+
+// explain reads the file footer and lets choose fill in the strategy and row groups read
+// of a read of columns (nil for all).
+func (pr *ParquetReader) explain(columns []string, choose func(md *metadata.FileMetaData, plan *QueryPlan)) (*QueryPlan, error) {
+	opened, err := pr.source()
+	if err != nil {
+		return nil, err
+	}
+	defer opened.Close()
+
+	pf, err := file.NewParquetReader(opened.r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open parquet file: %w", err)
+	}
+	defer pf.Close()
+
+	md := pf.MetaData()
+	plan := &QueryPlan{
+		RowGroups: md.NumRowGroups(),
+		TotalRows: md.GetNumRows(),
+		FileSize:  opened.size,
+	}
+	colIndices, err := projectColumns(md, columns)
+	if err != nil {
+		return nil, err
+	}
+	if colIndices == nil {
+		for i := range md.Schema.NumColumns() {
+			colIndices = append(colIndices, i)
+		}
+	}
+	for _, i := range colIndices {
+		plan.Columns = append(plan.Columns, md.Schema.Column(i).Name())
+	}
+	if _, ok, err := groupIndexFromMetadata(md); err == nil {
+		plan.GroupIndex = ok
+	}
+
+	choose(md, plan)
+
+	for _, i := range plan.RowGroupsRead {
+		rowGroup := md.RowGroup(i)
+		plan.RowsScanned += rowGroup.NumRows()
+		for _, c := range colIndices {
+			chunk, err := rowGroup.ColumnChunk(c)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read column chunk metadata: %w", err)
+			}
+			plan.ScanBytes += chunk.TotalCompressedSize()
+		}
+	}
+	return plan, nil
+}
+
+func allRowGroups(md *metadata.FileMetaData) []int {
+	rowGroups := make([]int, md.NumRowGroups())
+	for i := range rowGroups {
+		rowGroups[i] = i
+	}
+	return rowGroups
+}
+
+// rangeRowGroups returns the row groups that overlap any of the sorted ranges.
+func rangeRowGroups(md *metadata.FileMetaData, ranges []GroupRange) []int {
+	var rowGroups []int
+	for _, run := range rowGroupRuns(md, ranges) {
+		rowGroups = append(rowGroups, run...)
+	}
+	return rowGroups
+}
@@ -0,0 +1,78 @@
+package buildkitelogs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParquetReader_Explain(t *testing.T) {
+	reader := NewParquetReader(writeGroupIndexTestFile(t, groupIndexTestEntries()))
+
+	full, err := reader.ExplainRead()
+	if err != nil {
+		t.Fatalf("ExplainRead failed: %v", err)
+	}
+	if full.Strategy != ScanFull || !full.GroupIndex {
+		t.Errorf("ExplainRead = %+v, want full scan of an indexed file", full)
+	}
+	if want := []string{"timestamp", "content", "group", "flags", "hash"}; !slices.Equal(full.Columns, want) {
+		t.Errorf("Columns = %v, want %v", full.Columns, want)
+	}
+	if full.RowGroups < 2 || len(full.RowGroupsRead) != full.RowGroups {
+		t.Errorf("full scan reads %d of %d row groups", len(full.RowGroupsRead), full.RowGroups)
+	}
+	if full.RowsScanned != full.TotalRows || full.TotalRows != 3413 {
+		t.Errorf("full scan rows = %d of %d, want 3413", full.RowsScanned, full.TotalRows)
+	}
+	if full.ScanBytes <= 0 || full.ScanBytes > full.FileSize {
+		t.Errorf("ScanBytes = %d, file size %d", full.ScanBytes, full.FileSize)
+	}
+
+	cleanup, err := reader.ExplainFilterByGroup("cleanup")
+	if err != nil {
+		t.Fatalf("ExplainFilterByGroup failed: %v", err)
+	}
+	if cleanup.Strategy != ScanGroupIndex {
+		t.Errorf("Strategy = %s, want %s", cleanup.Strategy, ScanGroupIndex)
+	}
+	if cleanup.RowsScanned < 700 || cleanup.RowsScanned >= full.RowsScanned || cleanup.ScanBytes >= full.ScanBytes {
+		t.Errorf("group index plan scans %d rows (%d bytes), full scan %d rows (%d bytes)",
+			cleanup.RowsScanned, cleanup.ScanBytes, full.RowsScanned, full.ScanBytes)
+	}
+
+	seek, err := reader.ExplainSeek(full.TotalRows - 10)
+	if err != nil {
+		t.Fatalf("ExplainSeek failed: %v", err)
+	}
+	if seek.Strategy != ScanSeek || len(seek.RowGroupsRead) != 1 || seek.RowGroupsRead[0] != full.RowGroups-1 {
+		t.Errorf("ExplainSeek = %+v, want only the last row group", seek)
+	}
+
+	meta, err := reader.ExplainMetadata()
+	if err != nil {
+		t.Fatalf("ExplainMetadata failed: %v", err)
+	}
+	if meta.Strategy != ScanMetadata || len(meta.RowGroupsRead) != 0 || meta.ScanBytes != 0 {
+		t.Errorf("ExplainMetadata = %+v, want no row groups read", meta)
+	}
+
+	annotated, err := reader.WithAnnotations([]Annotation{{RowNumber: 5, Label: "x"}}).ExplainAnnotatedEntries("")
+	if err != nil {
+		t.Fatalf("ExplainAnnotatedEntries failed: %v", err)
+	}
+	if annotated.Strategy != ScanRowRanges || !slices.Equal(annotated.RowGroupsRead, []int{0}) {
+		t.Errorf("ExplainAnnotatedEntries = %+v, want only row group 0", annotated)
+	}
+}
+
+func TestParquetReader_ExplainFilterByGroupWithoutIndex(t *testing.T) {
+	reader := NewParquetReader("testdata/bash-example.parquet")
+
+	plan, err := reader.ExplainFilterByGroup("setup")
+	if err != nil {
+		t.Fatalf("ExplainFilterByGroup failed: %v", err)
+	}
+	if plan.Strategy != ScanFull || plan.GroupIndex || len(plan.Notes) == 0 {
+		t.Errorf("ExplainFilterByGroup = %+v, want noted full-scan fallback", plan)
+	}
+}
@@ -0,0 +1,152 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// DefaultSummaryErrorLines is the number of error lines FailureSummary keeps when asked
+// for none.
+const DefaultSummaryErrorLines = 10
+
+// FailureSummary sums up why a job failed: where its log ended, its last error lines and,
+// once WithJobStatus adds them, its exit status and state. WriteMarkdown renders it for
+// buildkite-agent annotate.
+type FailureSummary struct {
+	Name       string            `json:"name,omitempty"`        // Job name, from the job status
+	State      JobState          `json:"state,omitempty"`       // From the job status
+	ExitStatus *int              `json:"exit_status,omitempty"` // From the job status
+	WebURL     string            `json:"web_url,omitempty"`     // From the job status
+	Duration   time.Duration     `json:"duration_ns"`           // Job run time from the job status, or the log's first to last timestamp
+	LastGroup  string            `json:"last_group,omitempty"`  // Group of the log's last entry: where the job was when it exited
+	Entries    int               `json:"entries"`
+	ErrorCount int               `json:"error_count"` // Error lines in the whole log
+	Errors     []ParquetLogEntry `json:"errors"`      // The last error lines, in log order
+}
+
+// FailureSummary reads the file and summarizes it, keeping its last errorLines lines of
+// error severity (see ParquetLogEntry.InferredSeverity); DefaultSummaryErrorLines when
+// errorLines is 0 or less. Group aliases apply; the reader's severity filters don't, so
+// the last group and duration cover the whole log.
+func (pr *ParquetReader) FailureSummary(ctx context.Context, errorLines int) (*FailureSummary, error) {
+	return FailureSummaryFromEntries(pr.aliasedEntries(readParquetFileIter(ctx, pr.source, nil)), errorLines)
+}
+
+// FailureSummaryFromEntries summarizes entries in log order, see
+// ParquetReader.FailureSummary.
+func FailureSummaryFromEntries(entries iter.Seq2[ParquetLogEntry, error], errorLines int) (*FailureSummary, error) {
+	if errorLines <= 0 {
+		errorLines = DefaultSummaryErrorLines
+	}
+
+	summary := &FailureSummary{}
+	var first, last int64 // Timestamps of the log, 0 before the first
+	for entry, err := range entries {
+		if err != nil {
+			return nil, fmt.Errorf("error reading entries: %w", err)
+		}
+		summary.Entries++
+		summary.LastGroup = entry.Group
+		if entry.HasTime() {
+			if first == 0 || entry.Timestamp < first {
+				first = entry.Timestamp
+			}
+			last = max(last, entry.Timestamp)
+		}
+
+		if entry.IsGroup() || entry.InferredSeverity() != logparser.SeverityError {
+			continue
+		}
+		summary.ErrorCount++
+		if len(summary.Errors) == errorLines {
+			summary.Errors = append(summary.Errors[:0], summary.Errors[1:]...)
+		}
+		summary.Errors = append(summary.Errors, entry)
+	}
+
+	if first != 0 {
+		summary.Duration = time.Duration(last-first) * time.Millisecond
+	}
+	return summary, nil
+}
+
+// WithJobStatus adds the job's name, state, exit status and web URL to the summary, and
+// its run time when the job has started and finished. It returns the summary.
+func (s *FailureSummary) WithJobStatus(status *JobStatus) *FailureSummary {
+	if status == nil {
+		return s
+	}
+	s.Name = status.Name
+	s.State = status.State
+	s.ExitStatus = status.ExitStatus
+	s.WebURL = status.WebURL
+	if status.StartedAt != nil && status.FinishedAt != nil {
+		s.Duration = status.FinishedAt.Sub(*status.StartedAt)
+	}
+	return s
+}
+
+// WriteMarkdown writes the summary as Markdown suitable for buildkite-agent annotate:
+// a heading, a table of the exit status, state, duration and last group, and the error
+// lines in a term block, which Buildkite renders with their colors.
+func (s *FailureSummary) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	title := "Job"
+	if s.Name != "" {
+		title = s.Name
+	}
+	switch {
+	case s.ExitStatus != nil && *s.ExitStatus != 0:
+		title += fmt.Sprintf(" failed with exit status %d", *s.ExitStatus)
+	case s.State != "":
+		title += " " + string(s.State)
+	default:
+		title += " summary"
+	}
+	fmt.Fprintf(&b, "### %s\n\n", markdownText(title))
+
+	b.WriteString("| | |\n| --- | --- |\n")
+	if s.ExitStatus != nil {
+		fmt.Fprintf(&b, "| Exit status | `%d` |\n", *s.ExitStatus)
+	}
+	if s.State != "" {
+		fmt.Fprintf(&b, "| State | %s |\n", markdownText(string(s.State)))
+	}
+	fmt.Fprintf(&b, "| Duration | %s |\n", s.Duration.Round(time.Second))
+	if s.LastGroup != "" {
+		fmt.Fprintf(&b, "| Last group | %s |\n", markdownText(StripANSI(s.LastGroup)))
+	}
+	fmt.Fprintf(&b, "| Error lines | %d |\n", s.ErrorCount)
+
+	if len(s.Errors) > 0 {
+		fmt.Fprintf(&b, "\n**Last %d error lines**\n\n```term\n", len(s.Errors))
+		for _, entry := range s.Errors {
+			// A fence in the content would end the block early; a zero-width space breaks it up
+			b.WriteString(strings.ReplaceAll(entry.Content, "```", "`\u200b``"))
+			b.WriteByte('\n')
+		}
+		b.WriteString("```\n")
+	}
+	if s.WebURL != "" {
+		fmt.Fprintf(&b, "\n[View job log](%s)\n", s.WebURL)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownTextReplacer escapes the characters that would end a table cell or start
+// inline formatting.
+var markdownTextReplacer = strings.NewReplacer("|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;", "\n", " ")
+
+// markdownText escapes text for a Markdown heading or table cell.
+func markdownText(text string) string {
+	return markdownTextReplacer.Replace(text)
+}
@@ -0,0 +1,101 @@
+package buildkitelogs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestFailureSummaryFromEntries(t *testing.T) {
+	timed := logparser.LogFlags(1 << logparser.HasTimestamp)
+	header := logparser.LogFlags(1<<logparser.HasTimestamp | 1<<logparser.IsGroup)
+	entries := []ParquetLogEntry{
+		{Timestamp: 1000, Content: "~~~ Error handling setup", Group: "~~~ Error handling setup", Flags: header},
+		{Timestamp: 2000, Content: "ERROR: first", Group: "~~~ Error handling setup", Flags: timed},
+		{Timestamp: 3000, Content: "~~~ Running tests", Group: "~~~ Running tests", Flags: header},
+		{Timestamp: 4000, Content: "WARN flaky", Group: "~~~ Running tests", Flags: timed},
+		{Timestamp: 5000, Content: "FAIL: TestCache", Group: "~~~ Running tests", Flags: timed},
+		{Timestamp: 65000, Content: "exit status 1", Group: "~~~ Running tests", Flags: timed},
+	}
+	seq := func(yield func(ParquetLogEntry, error) bool) {
+		for _, entry := range entries {
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+
+	summary, err := FailureSummaryFromEntries(seq, 2)
+	if err != nil {
+		t.Fatalf("FailureSummaryFromEntries failed: %v", err)
+	}
+	if summary.LastGroup != "~~~ Running tests" {
+		t.Errorf("LastGroup = %q, want ~~~ Running tests", summary.LastGroup)
+	}
+	if summary.Duration != 64*time.Second {
+		t.Errorf("Duration = %v, want 64s", summary.Duration)
+	}
+	if summary.ErrorCount != 3 {
+		t.Errorf("ErrorCount = %d, want 3; headers are never errors", summary.ErrorCount)
+	}
+	if len(summary.Errors) != 2 || summary.Errors[0].Content != "FAIL: TestCache" || summary.Errors[1].Content != "exit status 1" {
+		t.Errorf("Errors = %+v, want the last two error lines", summary.Errors)
+	}
+
+	exitStatus := 1
+	started := time.Date(2025, 4, 22, 21, 0, 0, 0, time.UTC)
+	finished := started.Add(90 * time.Second)
+	summary.WithJobStatus(&JobStatus{
+		Name:       "Run tests",
+		State:      JobStateFailed,
+		ExitStatus: &exitStatus,
+		WebURL:     "https://buildkite.com/myorg/mypipeline/builds/123#job-id",
+		StartedAt:  &started,
+		FinishedAt: &finished,
+	})
+	if summary.Duration != 90*time.Second {
+		t.Errorf("Duration = %v, want the job's 90s", summary.Duration)
+	}
+
+	var out strings.Builder
+	if err := summary.WriteMarkdown(&out); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	markdown := out.String()
+	for _, want := range []string{
+		"### Run tests failed with exit status 1\n",
+		"| Exit status | `1` |\n",
+		"| Duration | 1m30s |\n",
+		"| Last group | ~~~ Running tests |\n",
+		"**Last 2 error lines**\n\n```term\nFAIL: TestCache\nexit status 1\n```\n",
+		"[View job log](https://buildkite.com/myorg/mypipeline/builds/123#job-id)",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown missing %q:\n%s", want, markdown)
+		}
+	}
+}
+
+func TestFailureSummary_WriteMarkdownEscapes(t *testing.T) {
+	summary := &FailureSummary{
+		LastGroup: "--- a | b",
+		Errors:    []ParquetLogEntry{{Content: "```"}},
+	}
+
+	var out strings.Builder
+	if err := summary.WriteMarkdown(&out); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	markdown := out.String()
+	if !strings.HasPrefix(markdown, "### Job summary\n") {
+		t.Errorf("markdown heading = %q, want Job summary without a job status", strings.SplitN(markdown, "\n", 2)[0])
+	}
+	if !strings.Contains(markdown, `| Last group | --- a \| b |`) {
+		t.Errorf("table cell not escaped:\n%s", markdown)
+	}
+	if strings.Count(markdown, "```") != 2 {
+		t.Errorf("fence in content not broken up:\n%s", markdown)
+	}
+}
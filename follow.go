@@ -0,0 +1,113 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// DefaultFollowInterval is how often FollowLogs polls a running job for new output.
+const DefaultFollowInterval = 2 * time.Second
+
+// WithFollowInterval sets how often FollowLogs polls a running job. Default is
+// DefaultFollowInterval.
+func WithFollowInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.followInterval = interval
+	}
+}
+
+// FollowLogs yields a job's log entries as they are written, like tail -f. It yields the
+// entries logged so far, then polls the job while it runs and yields new entries as they
+// appear, returning once the job has finished and its last entries were yielded.
+//
+// Each poll refreshes the cached Parquet file with the log so far, so readers of the cache
+// see the same entries and the finished log is cached when FollowLogs returns. Each poll
+// downloads the whole log again, or only its new output with WithIncrementalRefresh.
+// While the job runs, its last entry is held back until the next one starts, as the line
+// may not be complete yet.
+//
+// Stop following early by breaking out of the loop or canceling ctx. Closing the client
+// ends the iteration with ErrClientClosed.
+func (c *Client) FollowLogs(ctx context.Context, org, pipeline, build, job string) iter.Seq2[ParquetLogEntry, error] {
+	return func(yield func(ParquetLogEntry, error) bool) {
+		if err := ValidateAPIParams(org, pipeline, build, job); err != nil {
+			yield(ParquetLogEntry{}, err)
+			return
+		}
+		if err := validateJobLogAccess(ctx, c.api, org, pipeline, build, job); err != nil {
+			yield(ParquetLogEntry{}, fmt.Errorf("failed to validate job log access: %w", err))
+			return
+		}
+
+		interval := c.followInterval
+		if interval <= 0 {
+			interval = DefaultFollowInterval
+		}
+
+		var next int64
+		for {
+			status, err := c.getJobStatus(ctx, c.api, org, pipeline, build, job)
+			if err != nil {
+				yield(ParquetLogEntry{}, fmt.Errorf("failed to get job status: %w", err))
+				return
+			}
+
+			// A running job's cache is always stale; a finished job's cache is refreshed
+			// only if it was written while the job ran
+			result, err := c.downloadAndCacheResult(ctx, c.api, org, pipeline, build, job, 0, !status.IsTerminal)
+			if err != nil {
+				yield(ParquetLogEntry{}, err)
+				return
+			}
+
+			reader, err := c.readerForResult(ctx, result)
+			if err != nil {
+				yield(ParquetLogEntry{}, err)
+				return
+			}
+			info, err := reader.GetFileInfo()
+			if err != nil {
+				yield(ParquetLogEntry{}, fmt.Errorf("failed to read cached log: %w", err))
+				return
+			}
+			end := info.RowCount
+			if !status.IsTerminal {
+				end--
+			}
+			if next < end {
+				for entry, err := range reader.SeekToRow(ctx, next) {
+					if err != nil {
+						yield(ParquetLogEntry{}, err)
+						return
+					}
+					if entry.RowNumber >= end {
+						break
+					}
+					if !yield(entry, nil) {
+						return
+					}
+					next = entry.RowNumber + 1
+				}
+			}
+
+			if status.IsTerminal {
+				return
+			}
+
+			timer := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				yield(ParquetLogEntry{}, ctx.Err())
+				return
+			case <-c.closeCtx.Done():
+				timer.Stop()
+				yield(ParquetLogEntry{}, ErrClientClosed)
+				return
+			case <-timer.C:
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClient_FollowLogs(t *testing.T) {
+	api := &mockBuildkiteAPI{
+		logContent: "\x1b_bk;t=1745322209921\x07first\n\x1b_bk;t=1745322209922\x07seco",
+		jobStatus:  &JobStatus{ID: "test-job", State: JobStateRunning, IsTerminal: false},
+	}
+	client := newTestClient(t, api, WithFollowInterval(time.Millisecond))
+
+	var got []string
+	for entry, err := range client.FollowLogs(t.Context(), "org", "pipeline", "1", "job") {
+		if err != nil {
+			t.Fatalf("FollowLogs failed: %v", err)
+		}
+		got = append(got, entry.Content)
+		if len(got) == 1 {
+			// The job finishes the partial line and logs another before the next poll
+			api.mu.Lock()
+			api.logContent = "\x1b_bk;t=1745322209921\x07first\n\x1b_bk;t=1745322209922\x07second\n\x1b_bk;t=1745322209923\x07third\n"
+			api.mu.Unlock()
+			api.setJobStatus(&JobStatus{ID: "test-job", State: JobStatePassed, IsTerminal: true})
+		}
+	}
+
+	want := []string{"first", "second", "third"}
+	if len(got) != len(want) {
+		t.Fatalf("FollowLogs yielded %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	// The finished log is cached
+	reader, err := client.NewReader(t.Context(), "org", "pipeline", "1", "job", 0, false)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	if info.RowCount != 3 {
+		t.Errorf("cached RowCount = %d, want 3", info.RowCount)
+	}
+}
+
+func TestClient_FollowLogsCanceled(t *testing.T) {
+	api := &mockBuildkiteAPI{
+		logContent: "\x1b_bk;t=1745322209921\x07first\n",
+		jobStatus:  &JobStatus{ID: "test-job", State: JobStateRunning, IsTerminal: false},
+	}
+	client := newTestClient(t, api, WithFollowInterval(time.Hour))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	var lastErr error
+	for _, err := range client.FollowLogs(ctx, "org", "pipeline", "1", "job") {
+		if err != nil {
+			lastErr = err
+		}
+	}
+	if !errors.Is(lastErr, context.DeadlineExceeded) {
+		t.Errorf("FollowLogs error = %v, want context.DeadlineExceeded", lastErr)
+	}
+}
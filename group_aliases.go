@@ -0,0 +1,283 @@
+package buildkitelogs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GroupAliases canonicalizes group names that drift between builds, such as
+// "Run tests v2" becoming "Run tests", so groups compare across months of builds.
+// Aliases are matched against a group's title: its name without the ~~~, --- or +++
+// marker, ANSI codes and surrounding space. An alias written as /regexp/ matches every
+// title the expression matches. Exact aliases win over patterns, and patterns are tried
+// in the order they were given. A matched title is replaced by the canonical name,
+// keeping the group's marker. A GroupAliases is safe for concurrent use.
+type GroupAliases struct {
+	exact    map[string]string
+	patterns []groupAliasPattern
+}
+
+type groupAliasPattern struct {
+	re        *regexp.Regexp
+	canonical string
+}
+
+// groupMarkers open group header lines, see logparser.Entry.IsGroup
+var groupMarkers = []string{"~~~ ", "--- ", "+++ "}
+
+// NewGroupAliases returns the aliases of a mapping from alias to canonical name. Use
+// ParseGroupAliases to keep the order of /regexp/ aliases.
+func NewGroupAliases(mapping map[string]string) (*GroupAliases, error) {
+	aliases := &GroupAliases{exact: make(map[string]string)}
+	for alias, canonical := range mapping {
+		if err := aliases.add(alias, canonical); err != nil {
+			return nil, err
+		}
+	}
+	return aliases, nil
+}
+
+func (a *GroupAliases) add(alias, canonical string) error {
+	alias, canonical = strings.TrimSpace(alias), strings.TrimSpace(canonical)
+	if alias == "" || canonical == "" {
+		return fmt.Errorf("group alias %q: alias and canonical name must not be empty", alias)
+	}
+	if len(alias) > 2 && strings.HasPrefix(alias, "/") && strings.HasSuffix(alias, "/") {
+		re, err := regexp.Compile(alias[1 : len(alias)-1])
+		if err != nil {
+			return fmt.Errorf("group alias %s: %w", alias, err)
+		}
+		a.patterns = append(a.patterns, groupAliasPattern{re: re, canonical: canonical})
+		return nil
+	}
+	a.exact[alias] = canonical
+	return nil
+}
+
+// Canonical returns the canonical name of a group, or the group unchanged when no
+// alias matches it.
+func (a *GroupAliases) Canonical(group string) string {
+	if a == nil || group == "" {
+		return group
+	}
+	marker, title := "", strings.TrimSpace(StripANSI(group))
+	for _, m := range groupMarkers {
+		if rest, ok := strings.CutPrefix(title, m); ok {
+			marker, title = m, strings.TrimSpace(rest)
+			break
+		}
+	}
+
+	if canonical, ok := a.exact[title]; ok {
+		return marker + canonical
+	}
+	for _, p := range a.patterns {
+		if p.re.MatchString(title) {
+			return marker + p.canonical
+		}
+	}
+	return group
+}
+
+// LoadGroupAliases reads a group alias file, see ParseGroupAliases.
+func LoadGroupAliases(path string) (*GroupAliases, error) {
+	f, err := os.Open(path) //nolint:gosec // path from the caller
+	if err != nil {
+		return nil, fmt.Errorf("failed to open group aliases: %w", err)
+	}
+	defer f.Close()
+	return ParseGroupAliases(f)
+}
+
+// ParseGroupAliases reads group aliases written as a flat YAML mapping from alias to
+// canonical name, one per line:
+//
+//	# Renamed in March
+//	Run tests v2: Run tests
+//	":test_tube: Run tests": Run tests
+//	/^Deploy to (staging|production)$/: Deploy
+//
+// Only this subset of YAML is supported: scalar keys and values, plain or quoted, and
+// comments. Names containing ": " or starting with a quote or # must be quoted.
+func ParseGroupAliases(r io.Reader) (*GroupAliases, error) {
+	aliases := &GroupAliases{exact: make(map[string]string)}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' || strings.HasPrefix(trimmed, "- ") {
+			return nil, fmt.Errorf("group aliases line %d: only a flat mapping of alias: canonical name is supported", lineNum)
+		}
+
+		alias, rest, err := parseYAMLScalar(trimmed, true)
+		if err != nil {
+			return nil, fmt.Errorf("group aliases line %d: %w", lineNum, err)
+		}
+		canonical, rest, err := parseYAMLScalar(rest, false)
+		if err != nil {
+			return nil, fmt.Errorf("group aliases line %d: %w", lineNum, err)
+		}
+		if rest != "" {
+			return nil, fmt.Errorf("group aliases line %d: unexpected %q after the canonical name", lineNum, rest)
+		}
+		if err := aliases.add(alias, canonical); err != nil {
+			return nil, fmt.Errorf("group aliases line %d: %w", lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read group aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// parseYAMLScalar parses a plain or quoted scalar at the start of s. A key must be
+// followed by a colon, which is consumed; a value may be followed by a comment. It
+// returns the scalar and the rest of s.
+func parseYAMLScalar(s string, key bool) (string, string, error) {
+	s = strings.TrimSpace(s)
+	var value string
+	switch {
+	case strings.HasPrefix(s, `"`):
+		end := 1
+		for end < len(s) && s[end] != '"' {
+			if s[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(s) {
+			return "", "", fmt.Errorf("unterminated quoted string %s", s)
+		}
+		unquoted, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return "", "", fmt.Errorf("invalid quoted string %s", s[:end+1])
+		}
+		value, s = unquoted, s[end+1:]
+	case strings.HasPrefix(s, "'"):
+		var b strings.Builder
+		end := 1
+		for ; end < len(s); end++ {
+			if s[end] == '\'' {
+				if end+1 < len(s) && s[end+1] == '\'' {
+					b.WriteByte('\'')
+					end++
+					continue
+				}
+				break
+			}
+			b.WriteByte(s[end])
+		}
+		if end >= len(s) {
+			return "", "", fmt.Errorf("unterminated quoted string %s", s)
+		}
+		value, s = b.String(), s[end+1:]
+	case key:
+		i := strings.Index(s, ": ")
+		if i < 0 {
+			if !strings.HasSuffix(s, ":") {
+				return "", "", fmt.Errorf("missing \": \" after %q", s)
+			}
+			i = len(s) - 1
+		}
+		value, s = s[:i], s[i:]
+	default:
+		value, _, _ = strings.Cut(s, " #")
+		return strings.TrimSpace(value), "", nil
+	}
+
+	s = strings.TrimSpace(s)
+	if key {
+		rest, ok := strings.CutPrefix(s, ":")
+		if !ok {
+			return "", "", fmt.Errorf("missing \":\" after %q", value)
+		}
+		return value, rest, nil
+	}
+	if strings.HasPrefix(s, "#") {
+		s = ""
+	}
+	return value, s, nil
+}
+
+// WithGroupAliases makes the reader report groups by their canonical names: the Group
+// of the entries it returns, group filters and MatchingGroups, ListGroups and
+// GroupTails, search results and annotated entries all use them, so FilterByGroupIter
+// with a canonical name also finds the groups aliased to it. Entry content, including
+// group header lines, is unchanged. Pass nil to report groups as logged again.
+func (pr *ParquetReader) WithGroupAliases(aliases *GroupAliases) *ParquetReader {
+	pr.groupAliases = aliases
+	return pr
+}
+
+// canonicalGroup returns the canonical name of a group under the reader's aliases
+func (pr *ParquetReader) canonicalGroup(group string) string {
+	return pr.groupAliases.Canonical(group)
+}
+
+// aliasedEntries canonicalizes the groups of entries, looking each name up once
+func (pr *ParquetReader) aliasedEntries(entries iter.Seq2[ParquetLogEntry, error]) iter.Seq2[ParquetLogEntry, error] {
+	if pr.groupAliases == nil {
+		return entries
+	}
+	return func(yield func(ParquetLogEntry, error) bool) {
+		canonical := make(map[string]string)
+		lookup := func(group string) string {
+			name, ok := canonical[group]
+			if !ok {
+				name = pr.canonicalGroup(group)
+				canonical[group] = name
+			}
+			return name
+		}
+		for entry, err := range entries {
+			if err == nil {
+				entry.Group = lookup(entry.Group)
+				if entry.ParentGroup != "" {
+					entry.ParentGroup = lookup(entry.ParentGroup)
+				}
+			}
+			if !yield(entry, err) {
+				return
+			}
+		}
+	}
+}
+
+// aliasedSearchResults canonicalizes the groups of search results
+func (pr *ParquetReader) aliasedSearchResults(results iter.Seq2[SearchResult, error]) iter.Seq2[SearchResult, error] {
+	if pr.groupAliases == nil {
+		return results
+	}
+	return func(yield func(SearchResult, error) bool) {
+		for result, err := range results {
+			if err == nil {
+				result.Match.Group = pr.canonicalGroup(result.Match.Group)
+				for i := range result.BeforeContext {
+					result.BeforeContext[i].Group = pr.canonicalGroup(result.BeforeContext[i].Group)
+				}
+				for i := range result.AfterContext {
+					result.AfterContext[i].Group = pr.canonicalGroup(result.AfterContext[i].Group)
+				}
+				if result.GroupHeader != nil {
+					header := *result.GroupHeader
+					header.Group = pr.canonicalGroup(header.Group)
+					result.GroupHeader = &header
+				}
+			}
+			if !yield(result, err) {
+				return
+			}
+		}
+	}
+}
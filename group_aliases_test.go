@@ -0,0 +1,124 @@
+package buildkitelogs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseGroupAliases(t *testing.T) {
+	aliases, err := ParseGroupAliases(strings.NewReader(`---
+# Renamed in March
+Run tests v2: Run tests  # trailing comment
+":test_tube: Run tests": Run tests
+'It''s setup': 'Setup'
+/^Deploy to (staging|production)$/: Deploy
+`))
+	if err != nil {
+		t.Fatalf("ParseGroupAliases failed: %v", err)
+	}
+
+	tests := map[string]string{
+		"~~~ Run tests v2":            "~~~ Run tests",
+		"--- :test_tube: Run tests":   "--- Run tests",
+		"~~~ It's setup":              "~~~ Setup",
+		"+++ Deploy to production":    "+++ Deploy",
+		"\x1b[32m~~~ Run tests v2 ":   "~~~ Run tests",
+		"~~~ Deploy to staging later": "~~~ Deploy to staging later",
+		"Run tests v2":                "Run tests",
+		"":                            "",
+	}
+	for group, want := range tests {
+		if got := aliases.Canonical(group); got != want {
+			t.Errorf("Canonical(%q) = %q, want %q", group, got, want)
+		}
+	}
+
+	var nilAliases *GroupAliases
+	if got := nilAliases.Canonical("~~~ Run tests v2"); got != "~~~ Run tests v2" {
+		t.Errorf("nil aliases changed the group to %q", got)
+	}
+}
+
+func TestParseGroupAliases_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"nested":       "tests:\n  v2: Run tests\n",
+		"list":         "- Run tests\n",
+		"no colon":     "Run tests\n",
+		"unterminated": "\"Run tests: Tests\n",
+		"empty":        "Run tests v2:\n",
+		"regexp":       "/(/: Tests\n",
+		"trailing":     "\"a\": \"b\" c\n",
+	}
+	for name, input := range tests {
+		if _, err := ParseGroupAliases(strings.NewReader(input)); err == nil || !strings.Contains(err.Error(), "line 1") {
+			t.Errorf("%s: error = %v, want an error on line 1", name, err)
+		}
+	}
+}
+
+func TestLoadGroupAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aliases.yaml")
+	if err := os.WriteFile(path, []byte("Running tests: Tests\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	aliases, err := LoadGroupAliases(path)
+	if err != nil {
+		t.Fatalf("LoadGroupAliases failed: %v", err)
+	}
+	if got := aliases.Canonical("--- Running tests"); got != "--- Tests" {
+		t.Errorf("Canonical = %q, want --- Tests", got)
+	}
+	if _, err := LoadGroupAliases(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadGroupAliases succeeded for a missing file")
+	}
+}
+
+func TestParquetReader_WithGroupAliases(t *testing.T) {
+	aliases, err := NewGroupAliases(map[string]string{"Running tests": "Test suite", "/^Clean/": "Teardown"})
+	if err != nil {
+		t.Fatalf("NewGroupAliases failed: %v", err)
+	}
+	reader := NewParquetReader(writeGroupIndexTestFile(t, groupIndexTestEntries())).WithGroupAliases(aliases)
+
+	groups, err := reader.ListGroups(t.Context())
+	if err != nil {
+		t.Fatalf("ListGroups failed: %v", err)
+	}
+	var names []string
+	for _, g := range groups {
+		names = append(names, g.Name)
+	}
+	if want := []string{"", "~~~ Setup", "--- Test suite", "+++ Teardown"}; strings.Join(names, "|") != strings.Join(want, "|") {
+		t.Errorf("groups = %q, want %q", names, want)
+	}
+
+	matching, err := reader.MatchingGroups(t.Context(), "suite")
+	if err != nil || len(matching) != 1 || matching[0] != "--- Test suite" {
+		t.Errorf("MatchingGroups(suite) = %q, %v", matching, err)
+	}
+
+	rows := 0
+	for entry, err := range reader.FilterByGroupIter(t.Context(), "suite") {
+		if err != nil {
+			t.Fatalf("FilterByGroupIter failed: %v", err)
+		}
+		if entry.Group != "--- Test suite" {
+			t.Fatalf("entry group = %q, want --- Test suite", entry.Group)
+		}
+		if rows == 0 && entry.Content != "--- Running tests" {
+			t.Errorf("header content = %q, want the logged name", entry.Content)
+		}
+		rows++
+	}
+	if rows != 1500 {
+		t.Errorf("FilterByGroupIter(suite) = %d rows, want 1500", rows)
+	}
+
+	// Without aliases the logged names are reported again
+	reader.WithGroupAliases(nil)
+	if matching, err := reader.MatchingGroups(t.Context(), "suite"); err != nil || len(matching) != 0 {
+		t.Errorf("MatchingGroups(suite) without aliases = %q, %v", matching, err)
+	}
+}
@@ -0,0 +1,288 @@
+package buildkitelogs
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/metadata"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// GroupIndexMetadataKey is the Parquet key/value metadata key holding the group index.
+const GroupIndexMetadataKey = "buildkite.group_index"
+
+// GroupRange records a contiguous run of rows belonging to a single group.
+// A group that is re-opened later in the log has one range per run.
+type GroupRange struct {
+	Group    string `json:"group"`
+	FirstRow int64  `json:"first_row"`
+	LastRow  int64  `json:"last_row"`
+}
+
+// groupIndexBuilder accumulates group ranges while rows are written.
+type groupIndexBuilder struct {
+	ranges []GroupRange
+	rows   int64
+}
+
+func (b *groupIndexBuilder) add(entries []*logparser.Entry) {
+	for _, entry := range entries {
+		n := len(b.ranges)
+		if n > 0 && b.ranges[n-1].Group == entry.Group {
+			b.ranges[n-1].LastRow = b.rows
+		} else {
+			b.ranges = append(b.ranges, GroupRange{
+				Group:    entry.Group,
+				FirstRow: b.rows,
+				LastRow:  b.rows,
+			})
+		}
+		b.rows++
+	}
+}
+
+func (b *groupIndexBuilder) marshal() (string, error) {
+	ranges := b.ranges
+	if ranges == nil {
+		ranges = []GroupRange{}
+	}
+	data, err := json.Marshal(ranges)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// groupMatches reports whether a group name matches a case-insensitive substring pattern.
+// Entries without a group match against "<no group>".
+func groupMatches(group, pattern string) bool {
+	return strings.Contains(strings.ToLower(groupDisplayName(group)), strings.ToLower(pattern))
+}
+
+// groupDisplayName returns the name a group is matched and listed by: entries without a
+// group are in "<no group>".
+func groupDisplayName(group string) string {
+	if group == "" {
+		return "<no group>"
+	}
+	return group
+}
+
+// readParquetGroupIndex reads the group index from the file's key/value metadata.
+// The boolean result is false when the file was written without an index.
+func readParquetGroupIndex(src parquetSource) ([]GroupRange, bool, error) {
+	opened, err := src()
+	if err != nil {
+		return nil, false, err
+	}
+	defer opened.Close()
+
+	pf, err := file.NewParquetReader(opened.r)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open parquet file: %w", err)
+	}
+	defer pf.Close()
+
+	return groupIndexFromMetadata(pf.MetaData())
+}
+
+func groupIndexFromMetadata(md *metadata.FileMetaData) ([]GroupRange, bool, error) {
+	value := md.KeyValueMetadata().FindValue(GroupIndexMetadataKey)
+	if value == nil {
+		return nil, false, nil
+	}
+
+	var ranges []GroupRange
+	if err := json.Unmarshal([]byte(*value), &ranges); err != nil {
+		return nil, false, fmt.Errorf("failed to decode group index: %w", err)
+	}
+	return ranges, true, nil
+}
+
+// matchingGroupRanges returns the ranges whose group matches, in row order.
+func matchingGroupRanges(index []GroupRange, match func(group string) bool) []GroupRange {
+	var ranges []GroupRange
+	for _, r := range index {
+		if match(r.Group) {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// rowGroupStartRows returns the absolute first row of each row group.
+func rowGroupStartRows(md *metadata.FileMetaData) []int64 {
+	starts := make([]int64, md.NumRowGroups())
+	var row int64
+	for i := range starts {
+		starts[i] = row
+		row += md.RowGroup(i).NumRows()
+	}
+	return starts
+}
+
+// rowGroupRuns returns contiguous runs of row groups that overlap any of the ranges.
+func rowGroupRuns(md *metadata.FileMetaData, ranges []GroupRange) [][]int {
+	starts := rowGroupStartRows(md)
+
+	var runs [][]int
+	var current []int
+	for i, start := range starts {
+		end := start + md.RowGroup(i).NumRows() - 1
+		overlaps := false
+		for _, r := range ranges {
+			if r.FirstRow <= end && r.LastRow >= start {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			current = append(current, i)
+			continue
+		}
+		if len(current) > 0 {
+			runs = append(runs, current)
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		runs = append(runs, current)
+	}
+	return runs
+}
+
+// readParquetFileRowRangesIter streams only the rows covered by ranges, reading just the
+// row groups that overlap them. Ranges must be sorted by FirstRow and must not overlap.
+func readParquetFileRowRangesIter(ctx context.Context, src parquetSource, ranges []GroupRange, columns []string) iter.Seq2[ParquetLogEntry, error] {
+	return func(yield func(ParquetLogEntry, error) bool) {
+		if len(ranges) == 0 {
+			return
+		}
+
+		opened, err := src()
+		if err != nil {
+			yield(ParquetLogEntry{}, err)
+			return
+		}
+		defer opened.Close()
+
+		pf, err := file.NewParquetReader(opened.r)
+		if err != nil {
+			yield(ParquetLogEntry{}, fmt.Errorf("failed to open parquet file: %w", err))
+			return
+		}
+		defer pf.Close()
+
+		arrowReader, err := newArrowFileReader(pf, 5000, memory.NewGoAllocator())
+		if err != nil {
+			yield(ParquetLogEntry{}, fmt.Errorf("failed to create arrow reader: %w", err))
+			return
+		}
+
+		colIndices, err := projectColumns(pf.MetaData(), columns)
+		if err != nil {
+			yield(ParquetLogEntry{}, err)
+			return
+		}
+
+		starts := rowGroupStartRows(pf.MetaData())
+		rangeIdx := 0
+		inRanges := func(row int64) bool {
+			for rangeIdx < len(ranges) && ranges[rangeIdx].LastRow < row {
+				rangeIdx++
+			}
+			return rangeIdx < len(ranges) && ranges[rangeIdx].FirstRow <= row
+		}
+
+		for _, run := range rowGroupRuns(pf.MetaData(), ranges) {
+			// Seek past the rows of the run's first row group that precede the ranges
+			runStart := starts[run[0]]
+			var skip int64
+			if i, _ := slices.BinarySearchFunc(ranges, runStart, func(r GroupRange, row int64) int {
+				return cmp.Compare(r.LastRow, row)
+			}); i < len(ranges) {
+				skip = max(ranges[i].FirstRow-runStart, 0)
+			}
+			if !readRowGroupRun(ctx, arrowReader, colIndices, run, runStart, skip, inRanges, yield) {
+				return
+			}
+		}
+	}
+}
+
+// readRowGroupRun streams the rows of a contiguous run of row groups, starting skip rows
+// after its first row startRow, that satisfy keep. It returns false when iteration should
+// stop.
+func readRowGroupRun(ctx context.Context, arrowReader *pqarrow.FileReader, colIndices, run []int, startRow, skip int64, keep func(int64) bool, yield func(ParquetLogEntry, error) bool) bool {
+	recordReader, err := arrowReader.GetRecordReader(ctx, colIndices, run)
+	if err != nil {
+		yield(ParquetLogEntry{}, fmt.Errorf("failed to create record reader: %w", err))
+		return false
+	}
+	defer recordReader.Release()
+
+	if skip > 0 {
+		if err := recordReader.SeekToRow(skip); err != nil {
+			yield(ParquetLogEntry{}, fmt.Errorf("failed to seek to row %d: %w", startRow+skip, err))
+			return false
+		}
+	}
+
+	var columnIndices *columnMapping
+	currentRowPosition := startRow + skip
+
+	for {
+		if err := ctx.Err(); err != nil {
+			yield(ParquetLogEntry{}, err)
+			return false
+		}
+
+		record, err := recordReader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return true
+			}
+			yield(ParquetLogEntry{}, fmt.Errorf("error reading record: %w", err))
+			return false
+		}
+
+		if columnIndices == nil {
+			columnIndices, err = mapColumns(record.Schema(), colIndices != nil)
+			if err != nil {
+				yield(ParquetLogEntry{}, err)
+				return false
+			}
+		}
+
+		batchRows := record.NumRows()
+		shouldContinue := func() bool {
+			for entry, err := range convertRecordToEntriesIterStreaming(record, columnIndices, currentRowPosition) {
+				if err != nil {
+					yield(ParquetLogEntry{}, err)
+					return false
+				}
+				if !keep(entry.RowNumber) {
+					continue
+				}
+				if !yield(entry, nil) {
+					return false
+				}
+			}
+			return true
+		}()
+		currentRowPosition += batchRows
+
+		if !shouldContinue {
+			return false
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package buildkitelogs
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func groupIndexTestEntries() []*logparser.Entry {
+	baseTime := time.Date(2025, 4, 22, 21, 43, 29, 0, time.UTC)
+	groups := []struct {
+		name  string
+		lines int
+	}{
+		{"", 3},
+		{"~~~ Setup", 1200},
+		{"--- Running tests", 1500},
+		{"~~~ Setup", 10},
+		{"+++ Cleanup", 700},
+	}
+
+	var entries []*logparser.Entry
+	for _, g := range groups {
+		for i := 0; i < g.lines; i++ {
+			content := fmt.Sprintf("line %d", i)
+			if i == 0 && g.name != "" {
+				content = g.name
+			}
+			entries = append(entries, &logparser.Entry{
+				Timestamp: baseTime.Add(time.Duration(len(entries)) * time.Millisecond),
+				Content:   content,
+				Group:     g.name,
+			})
+		}
+	}
+	return entries
+}
+
+func writeGroupIndexTestFile(t *testing.T, entries []*logparser.Entry) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "indexed.parquet")
+	seq := func(yield func(*logparser.Entry, error) bool) {
+		for _, entry := range entries {
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+	if err := ExportSeq2ToParquet(seq, filename); err != nil {
+		t.Fatalf("ExportSeq2ToParquet failed: %v", err)
+	}
+	return filename
+}
+
+func TestParquetWriter_GroupIndex(t *testing.T) {
+	filename := writeGroupIndexTestFile(t, groupIndexTestEntries())
+
+	index, ok, err := NewParquetReader(filename).GroupIndex()
+	if err != nil {
+		t.Fatalf("GroupIndex failed: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected file to contain a group index")
+	}
+
+	want := []GroupRange{
+		{Group: "", FirstRow: 0, LastRow: 2},
+		{Group: "~~~ Setup", FirstRow: 3, LastRow: 1202},
+		{Group: "--- Running tests", FirstRow: 1203, LastRow: 2702},
+		{Group: "~~~ Setup", FirstRow: 2703, LastRow: 2712},
+		{Group: "+++ Cleanup", FirstRow: 2713, LastRow: 3412},
+	}
+	if len(index) != len(want) {
+		t.Fatalf("index has %d ranges, want %d: %+v", len(index), len(want), index)
+	}
+	for i := range want {
+		if index[i] != want[i] {
+			t.Errorf("range %d = %+v, want %+v", i, index[i], want[i])
+		}
+	}
+}
+
+func TestParquetReader_FilterByGroupIterUsesIndex(t *testing.T) {
+	filename := writeGroupIndexTestFile(t, groupIndexTestEntries())
+	reader := NewParquetReader(filename)
+
+	for _, pattern := range []string{"setup", "RUNNING", "cleanup", "<no group>", "missing"} {
+		t.Run(pattern, func(t *testing.T) {
+			var want []ParquetLogEntry
+			for entry, err := range FilterByGroupIter(reader.ReadEntriesIter(t.Context()), pattern) {
+				if err != nil {
+					t.Fatalf("scan failed: %v", err)
+				}
+				want = append(want, entry)
+			}
+
+			var got []ParquetLogEntry
+			for entry, err := range reader.FilterByGroupIter(t.Context(), pattern) {
+				if err != nil {
+					t.Fatalf("FilterByGroupIter failed: %v", err)
+				}
+				got = append(got, entry)
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("got %d entries, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("entry %d = %+v, want %+v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParquetReader_FilterByGroupIterEarlyExit(t *testing.T) {
+	filename := writeGroupIndexTestFile(t, groupIndexTestEntries())
+	reader := NewParquetReader(filename)
+
+	count := 0
+	for entry, err := range reader.FilterByGroupIter(t.Context(), "running") {
+		if err != nil {
+			t.Fatalf("FilterByGroupIter failed: %v", err)
+		}
+		if entry.RowNumber != int64(1203+count) {
+			t.Fatalf("row number = %d, want %d", entry.RowNumber, 1203+count)
+		}
+		count++
+		if count == 5 {
+			break
+		}
+	}
+	if count != 5 {
+		t.Fatalf("read %d entries, want 5", count)
+	}
+}
+
+func TestParquetReader_GroupIndexMissing(t *testing.T) {
+	_, ok, err := NewParquetReader("testdata/bash-example.parquet").GroupIndex()
+	if err != nil {
+		t.Fatalf("GroupIndex failed: %v", err)
+	}
+	if ok {
+		t.Fatal("expected legacy test file to have no group index")
+	}
+}
+
+func TestParquetReader_MatchingGroups(t *testing.T) {
+	reader := NewParquetReader(writeGroupIndexTestFile(t, groupIndexTestEntries()))
+
+	names, err := reader.MatchingGroups(t.Context(), "s")
+	if err != nil {
+		t.Fatalf("MatchingGroups failed: %v", err)
+	}
+	want := []string{"~~~ Setup", "--- Running tests"}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Errorf("MatchingGroups(s) = %q, want %q", names, want)
+	}
+
+	count := 0
+	for entry, err := range reader.FilterByGroupNameIter(t.Context(), "~~~ Setup") {
+		if err != nil {
+			t.Fatalf("FilterByGroupNameIter failed: %v", err)
+		}
+		if entry.Group != "~~~ Setup" {
+			t.Fatalf("entry %d is in group %q", entry.RowNumber, entry.Group)
+		}
+		count++
+	}
+	if count != 1210 {
+		t.Errorf("FilterByGroupNameIter returned %d entries, want 1210", count)
+	}
+
+	for entry, err := range reader.FilterByGroupNameIter(t.Context(), "setup") {
+		t.Fatalf("FilterByGroupNameIter matched case-insensitively: %+v, %v", entry, err)
+	}
+	count = 0
+	for range reader.FilterByGroupNameIter(t.Context(), "<no group>") {
+		count++
+	}
+	if count != 3 {
+		t.Errorf("FilterByGroupNameIter(<no group>) returned %d entries, want 3", count)
+	}
+}
+
+func TestParquetReader_MatchingGroupsWithoutIndex(t *testing.T) {
+	reader := NewParquetReader("testdata/bash-example.parquet")
+
+	names, err := reader.MatchingGroups(t.Context(), "")
+	if err != nil {
+		t.Fatalf("MatchingGroups failed: %v", err)
+	}
+	seen := make(map[string]bool)
+	var want []string
+	for entry, err := range reader.ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		if name := groupDisplayName(entry.Group); !seen[name] {
+			seen[name] = true
+			want = append(want, name)
+		}
+	}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Errorf("MatchingGroups = %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,87 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+// GroupTail holds the last entries of a contiguous run of a group, which is usually where
+// a command's exit message is.
+type GroupTail struct {
+	GroupRange
+	Entries []ParquetLogEntry `json:"entries"`
+}
+
+// GroupTails returns the last n entries of every contiguous group run, in row order.
+// When the file contains a group index only the rows in each tail are read, unless
+// WithMinSeverity or WithLevel is set: the tails then hold the last n entries they keep.
+func (pr *ParquetReader) GroupTails(ctx context.Context, n int) ([]GroupTail, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("tail size must be positive, got %d", n)
+	}
+
+	index, ok, err := readParquetGroupIndex(pr.source)
+	if err != nil || !ok || pr.filtersSeverity() {
+		// Files without an index (or with an unreadable one) fall back to a full scan
+		pr.logUnreadableGroupIndex(err)
+		return GroupTailsFromEntries(pr.viewEntries(readParquetFileIter(ctx, pr.source, pr.readColumns("group"))), n)
+	}
+
+	tails := make([]GroupTail, len(index))
+	ranges := make([]GroupRange, len(index))
+	for i, r := range index {
+		tails[i].GroupRange = r
+		tails[i].Group = pr.canonicalGroup(r.Group)
+		ranges[i] = r
+		ranges[i].FirstRow = max(r.FirstRow, r.LastRow-int64(n)+1)
+	}
+
+	i := 0
+	for entry, err := range pr.viewEntries(readParquetFileRowRangesIter(ctx, pr.source, ranges, pr.readColumns())) {
+		if err != nil {
+			return nil, err
+		}
+		for i < len(tails) && tails[i].LastRow < entry.RowNumber {
+			i++
+		}
+		if i == len(tails) {
+			break
+		}
+		tails[i].Entries = append(tails[i].Entries, entry)
+	}
+	return tails, nil
+}
+
+// GroupTailsFromEntries returns the last n entries of every contiguous group run found in
+// entries, in the order the runs started.
+func GroupTailsFromEntries(entries iter.Seq2[ParquetLogEntry, error], n int) ([]GroupTail, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("tail size must be positive, got %d", n)
+	}
+
+	var tails []GroupTail
+	for entry, err := range entries {
+		if err != nil {
+			return nil, err
+		}
+
+		last := len(tails) - 1
+		if last < 0 || tails[last].Group != entry.Group {
+			tails = append(tails, GroupTail{GroupRange: GroupRange{
+				Group:    entry.Group,
+				FirstRow: entry.RowNumber,
+			}})
+			last++
+		}
+
+		tail := &tails[last]
+		tail.LastRow = entry.RowNumber
+		if len(tail.Entries) == n {
+			copy(tail.Entries, tail.Entries[1:])
+			tail.Entries = tail.Entries[:n-1]
+		}
+		tail.Entries = append(tail.Entries, entry)
+	}
+	return tails, nil
+}
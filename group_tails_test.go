@@ -0,0 +1,73 @@
+package buildkitelogs
+
+import (
+	"testing"
+)
+
+func TestParquetReader_GroupTails(t *testing.T) {
+	reader := NewParquetReader(writeGroupIndexTestFile(t, groupIndexTestEntries()))
+
+	tails, err := reader.GroupTails(t.Context(), 3)
+	if err != nil {
+		t.Fatalf("GroupTails failed: %v", err)
+	}
+	if len(tails) != 5 {
+		t.Fatalf("got %d tails, want 5", len(tails))
+	}
+
+	setup := tails[3]
+	if setup.Group != "~~~ Setup" || setup.FirstRow != 2703 || setup.LastRow != 2712 {
+		t.Errorf("unexpected range: %+v", setup.GroupRange)
+	}
+	if len(setup.Entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(setup.Entries))
+	}
+	for i, entry := range setup.Entries {
+		if want := int64(2710 + i); entry.RowNumber != want {
+			t.Errorf("entry %d row = %d, want %d", i, entry.RowNumber, want)
+		}
+	}
+
+	// The full-scan fallback must agree with the indexed read
+	scanned, err := GroupTailsFromEntries(reader.ReadEntriesIter(t.Context()), 3)
+	if err != nil {
+		t.Fatalf("GroupTailsFromEntries failed: %v", err)
+	}
+	if len(scanned) != len(tails) {
+		t.Fatalf("full scan found %d tails, indexed read found %d", len(scanned), len(tails))
+	}
+	for i := range tails {
+		if scanned[i].GroupRange != tails[i].GroupRange || len(scanned[i].Entries) != len(tails[i].Entries) {
+			t.Errorf("tail %d: full scan %+v, indexed %+v", i, scanned[i].GroupRange, tails[i].GroupRange)
+			continue
+		}
+		for j := range tails[i].Entries {
+			if scanned[i].Entries[j].RowNumber != tails[i].Entries[j].RowNumber {
+				t.Errorf("tail %d entry %d: full scan row %d, indexed row %d", i, j, scanned[i].Entries[j].RowNumber, tails[i].Entries[j].RowNumber)
+			}
+		}
+	}
+}
+
+func TestParquetReader_GroupTailsWithoutIndex(t *testing.T) {
+	tails, err := NewParquetReader("testdata/bash-example.parquet").GroupTails(t.Context(), 2)
+	if err != nil {
+		t.Fatalf("GroupTails failed: %v", err)
+	}
+	if len(tails) == 0 {
+		t.Fatal("expected at least one group tail")
+	}
+	for _, tail := range tails {
+		if len(tail.Entries) == 0 || len(tail.Entries) > 2 {
+			t.Errorf("group %q has %d entries, want 1-2", tail.Group, len(tail.Entries))
+			continue
+		}
+		if last := tail.Entries[len(tail.Entries)-1]; last.RowNumber != tail.LastRow {
+			t.Errorf("group %q tail ends at row %d, want %d", tail.Group, last.RowNumber, tail.LastRow)
+		}
+	}
+
+	if _, err := NewParquetReader("testdata/bash-example.parquet").GroupTails(t.Context(), 0); err == nil {
+		t.Error("expected error for zero tail size")
+	}
+}
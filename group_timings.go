@@ -0,0 +1,123 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// GroupTiming is the time a group of a job log took, see GroupTimings.
+type GroupTiming struct {
+	Name        string        `json:"name"`
+	ParentGroup string        `json:"parent_group,omitempty"`
+	Depth       int           `json:"depth,omitempty"`
+	Entries     int           `json:"entries"`                // Entries of the group itself, not of its nested groups
+	Start       time.Time     `json:"start"`                  // First timestamp of the group or a group nested in it
+	End         time.Time     `json:"end"`                    // Last timestamp of the group or a group nested in it
+	Duration    time.Duration `json:"duration_ns"`            // End minus Start
+	Percent     float64       `json:"percent_of_job"`         // Duration as a percentage of TimingReport.Duration
+	Timed       bool          `json:"timed"`                  // The group has timestamped entries; Start, End and Duration are zero otherwise
+	Gap         time.Duration `json:"gap_before_ns,omitzero"` // Time before each run of the group's entries since the previous group's last entry, summed
+}
+
+// TimingReport breaks the time of a job log down by group, see GroupTimings.
+type TimingReport struct {
+	Start      time.Time     `json:"start"`       // First timestamp of the log
+	End        time.Time     `json:"end"`         // Last timestamp of the log
+	Duration   time.Duration `json:"duration_ns"` // End minus Start
+	Gap        time.Duration `json:"gap_ns"`      // Time between the last entry of a group and the first of the next, summed over the log
+	GapPercent float64       `json:"gap_percent"` // Gap as a percentage of Duration
+	Entries    int           `json:"entries"`
+	Groups     []GroupTiming `json:"groups"` // In order of first appearance
+}
+
+// GroupTimings reports how long each group of the file took: the time from its first
+// timestamp to its last, and that time as a percentage of the whole log's. It answers
+// "which step was slow" without an external tool.
+func (pr *ParquetReader) GroupTimings(ctx context.Context) (*TimingReport, error) {
+	return GroupTimingsFromEntries(pr.viewEntries(readParquetFileIter(ctx, pr.source, nil)))
+}
+
+// GroupTimingsFromEntries builds a TimingReport from entries in log order. A group's
+// span covers the groups nested in it, so the percentages of nested groups overlap
+// their parent's. Gaps are counted wherever consecutive timestamped entries belong to
+// different groups, other than a group and one nested in it; Gap of a group is the sum
+// of the gaps before its runs of entries. Entries without a timestamp are counted but
+// not timed.
+func GroupTimingsFromEntries(entries iter.Seq2[ParquetLogEntry, error]) (*TimingReport, error) {
+	report := &TimingReport{}
+	index := make(map[string]int)
+	var first, last int64 // Timestamps of the log, 0 before the first
+	var prevGroup string
+	var prevTime int64
+
+	timing := func(name, parent string, depth int) *GroupTiming {
+		i, ok := index[name]
+		if !ok {
+			i = len(report.Groups)
+			index[name] = i
+			report.Groups = append(report.Groups, GroupTiming{Name: name, ParentGroup: parent, Depth: depth})
+		}
+		return &report.Groups[i]
+	}
+	extend := func(group *GroupTiming, at time.Time) {
+		if !group.Timed || at.Before(group.Start) {
+			group.Start = at
+		}
+		if !group.Timed || at.After(group.End) {
+			group.End = at
+		}
+		group.Timed = true
+	}
+
+	for entry, err := range entries {
+		if err != nil {
+			return nil, fmt.Errorf("error reading entries: %w", err)
+		}
+		report.Entries++
+		group := timing(entry.Group, entry.ParentGroup, entry.Depth)
+		group.Entries++
+		if !entry.HasTime() {
+			continue
+		}
+
+		at := time.UnixMilli(entry.Timestamp)
+		extend(group, at)
+		if entry.ParentGroup != "" {
+			extend(timing(entry.ParentGroup, "", max(entry.Depth-1, 0)), at)
+		}
+
+		// Time from a group's header to its first nested group is the parent's own
+		if prevTime != 0 && entry.Group != prevGroup && entry.ParentGroup != prevGroup && entry.Timestamp > prevTime {
+			gap := time.Duration(entry.Timestamp-prevTime) * time.Millisecond
+			report.Gap += gap
+			group.Gap += gap
+		}
+		if first == 0 || entry.Timestamp < first {
+			first = entry.Timestamp
+		}
+		last = max(last, entry.Timestamp)
+		prevGroup, prevTime = entry.Group, entry.Timestamp
+	}
+
+	if first != 0 {
+		report.Start, report.End = time.UnixMilli(first), time.UnixMilli(last)
+		report.Duration = report.End.Sub(report.Start)
+	}
+	for i := range report.Groups {
+		group := &report.Groups[i]
+		group.Duration = group.End.Sub(group.Start)
+		group.Percent = percentOf(group.Duration, report.Duration)
+	}
+	report.GapPercent = percentOf(report.Gap, report.Duration)
+	return report, nil
+}
+
+// percentOf returns part as a percentage of whole, 0 when whole is.
+func percentOf(part, whole time.Duration) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return float64(part) / float64(whole) * 100
+}
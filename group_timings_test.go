@@ -0,0 +1,96 @@
+package buildkitelogs
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestGroupTimingsFromEntries(t *testing.T) {
+	timed := logparser.LogFlags(1 << logparser.HasTimestamp)
+	entries := []ParquetLogEntry{
+		{Timestamp: 1000, Group: "~~~ Setup", Flags: timed},
+		{Timestamp: 2000, Group: "~~~ Setup", Flags: timed},
+		{Timestamp: 3000, Group: "~~~ Tests", Flags: timed},
+		{Timestamp: 3500, Group: "--- unit", ParentGroup: "~~~ Tests", Depth: 1, Flags: timed},
+		{Timestamp: 6000, Group: "--- unit", ParentGroup: "~~~ Tests", Depth: 1, Flags: timed},
+		{Timestamp: 7000, Group: "--- lint", ParentGroup: "~~~ Tests", Depth: 1, Flags: timed},
+		{Timestamp: 8000, Group: "--- lint", ParentGroup: "~~~ Tests", Depth: 1, Flags: timed},
+		{Timestamp: 10000, Group: "~~~ Cleanup", Flags: timed},
+		{Group: "~~~ Cleanup"},
+		{Group: "~~~ Untimed"},
+	}
+
+	seq := func(yield func(ParquetLogEntry, error) bool) {
+		for _, entry := range entries {
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+
+	report, err := GroupTimingsFromEntries(seq)
+	if err != nil {
+		t.Fatalf("GroupTimingsFromEntries failed: %v", err)
+	}
+
+	if report.Entries != len(entries) {
+		t.Errorf("Entries = %d, want %d", report.Entries, len(entries))
+	}
+	if report.Duration != 9*time.Second {
+		t.Errorf("Duration = %v, want 9s", report.Duration)
+	}
+	// Setup to Tests, unit to lint and lint to Cleanup; Tests to unit is Tests' own time
+	if report.Gap != 4*time.Second {
+		t.Errorf("Gap = %v, want 4s", report.Gap)
+	}
+
+	var names []string
+	for _, group := range report.Groups {
+		names = append(names, group.Name)
+	}
+	want := []string{"~~~ Setup", "~~~ Tests", "--- unit", "--- lint", "~~~ Cleanup", "~~~ Untimed"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("groups = %v, want %v", names, want)
+	}
+
+	tests := []struct {
+		group    int
+		entries  int
+		duration time.Duration
+		gap      time.Duration
+		depth    int
+	}{
+		{group: 0, entries: 2, duration: time.Second},
+		{group: 1, entries: 1, duration: 5 * time.Second, gap: time.Second},
+		{group: 2, entries: 2, duration: 2500 * time.Millisecond, depth: 1},
+		{group: 3, entries: 2, duration: time.Second, gap: time.Second, depth: 1},
+		{group: 4, entries: 2, gap: 2 * time.Second},
+	}
+	for _, tt := range tests {
+		got := report.Groups[tt.group]
+		if got.Entries != tt.entries || got.Duration != tt.duration || got.Gap != tt.gap || got.Depth != tt.depth || !got.Timed {
+			t.Errorf("%s = %+v, want entries %d, duration %v, gap %v, depth %d", got.Name, got, tt.entries, tt.duration, tt.gap, tt.depth)
+		}
+	}
+
+	// Tests spans 3s to 8s, covering its nested groups
+	if got := report.Groups[1].Percent; got < 55.5 || got > 55.6 {
+		t.Errorf("Tests percent = %.2f, want 55.56", got)
+	}
+	if untimed := report.Groups[5]; untimed.Timed || untimed.Duration != 0 || untimed.Entries != 1 {
+		t.Errorf("Untimed = %+v, want untimed with 1 entry", untimed)
+	}
+}
+
+func TestGroupTimingsFromEntries_Empty(t *testing.T) {
+	report, err := GroupTimingsFromEntries(func(yield func(ParquetLogEntry, error) bool) {})
+	if err != nil {
+		t.Fatalf("GroupTimingsFromEntries failed: %v", err)
+	}
+	if report.Duration != 0 || report.GapPercent != 0 || len(report.Groups) != 0 {
+		t.Errorf("report = %+v, want empty", report)
+	}
+}
@@ -0,0 +1,116 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrStageVetoed is returned when a BeforeStageFunc hook stops a stage from running. The
+// error also wraps the error the hook returned.
+var ErrStageVetoed = errors.New("stage vetoed by hook")
+
+// StageStart describes a stage about to run, passed to BeforeStageFunc hooks.
+type StageStart struct {
+	Org, Pipeline, Build, Job string
+	Stage                     Stage
+	BlobKey                   string
+
+	// Timeout, if set by a hook, bounds the stage: its context is canceled once Timeout
+	// passes. Hooks run in the order they were added and see the value set by earlier
+	// ones; the last value set applies.
+	Timeout time.Duration
+}
+
+// BeforeStageFunc runs before a stage. Returning an error vetoes the stage, which then
+// fails with ErrStageVetoed, such as to skip downloading a log too expensive to refresh
+// now. Setting stage.Timeout bounds the stage.
+//
+// Before hooks run for StageCacheCheck, StageJobStatus, StageLogDownload,
+// StageBlobStorage and StageLocalCache. Logs are parsed as they download, so the
+// StageLogDownload hook covers parsing as well.
+type BeforeStageFunc func(ctx context.Context, stage *StageStart) error
+
+// OnErrorFunc runs when a stage fails, after its After* hooks, including stages vetoed
+// by a BeforeStageFunc. A failed job status lookup that is retried reports to OnRetryFunc
+// hooks instead, and to OnErrorFunc hooks only once no retry follows.
+type OnErrorFunc func(ctx context.Context, stage Stage, err error)
+
+// OnRetryFunc runs when a failed attempt at a stage is about to be retried: job status
+// lookups and log downloads retried by WithJobStatusRetry or the API client's
+// WithRetryPolicy, and cached blob reads retried by WithConsistencyRetry. attempt is the
+// number of the attempt that failed, from 1.
+type OnRetryFunc func(ctx context.Context, stage Stage, attempt int, err error)
+
+func (h *Hooks) AddBeforeStage(hook BeforeStageFunc) {
+	h.OnBeforeStage = append(h.OnBeforeStage, hook)
+}
+
+func (h *Hooks) AddOnError(hook OnErrorFunc) {
+	h.OnError = append(h.OnError, hook)
+}
+
+func (h *Hooks) AddOnRetry(hook OnRetryFunc) {
+	h.OnRetry = append(h.OnRetry, hook)
+}
+
+// beforeStage runs the BeforeStageFunc hooks for a stage and returns the context the
+// stage runs with, which reports the stage's retries to OnRetryFunc hooks. The returned
+// cancel func must be called once the stage is done.
+func (c *Client) beforeStage(ctx context.Context, org, pipeline, build, job string, stage Stage, blobKey string) (context.Context, context.CancelFunc, error) {
+	start := &StageStart{Org: org, Pipeline: pipeline, Build: build, Job: job, Stage: stage, BlobKey: blobKey}
+	for _, hook := range c.hooks.OnBeforeStage {
+		if err := hook(ctx, start); err != nil {
+			err = fmt.Errorf("%w: %s: %w", ErrStageVetoed, stage, err)
+			c.fireErrorHook(ctx, stage, err)
+			return nil, nil, err
+		}
+	}
+
+	ctx = c.stageContext(ctx, stage)
+	if start.Timeout > 0 {
+		ctx, cancel := context.WithTimeout(ctx, start.Timeout)
+		return ctx, cancel, nil
+	}
+	return ctx, func() {}, nil
+}
+
+// retryNotifyKey is the context key of the retryNotify of the running stage.
+type retryNotifyKey struct{}
+
+// retryNotify reports a failed attempt that is about to be retried.
+type retryNotify func(attempt int, err error)
+
+// stageContext returns ctx carrying a retryNotify that fires the OnRetryFunc hooks for
+// stage, so retries made by the API client or by consistency reads reach them.
+func (c *Client) stageContext(ctx context.Context, stage Stage) context.Context {
+	if len(c.hooks.OnRetry) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, retryNotifyKey{}, retryNotify(func(attempt int, err error) {
+		c.fireRetryHook(ctx, stage, attempt, err)
+	}))
+}
+
+// notifyRetry reports a retry to the stage running with ctx, if any.
+func notifyRetry(ctx context.Context, attempt int, err error) {
+	if notify, ok := ctx.Value(retryNotifyKey{}).(retryNotify); ok {
+		notify(attempt, err)
+	}
+}
+
+func (c *Client) fireErrorHook(ctx context.Context, stage Stage, err error) {
+	if err == nil {
+		return
+	}
+	for _, hook := range c.hooks.OnError {
+		hook(ctx, stage, err)
+	}
+}
+
+func (c *Client) fireRetryHook(ctx context.Context, stage Stage, attempt int, err error) {
+	for _, hook := range c.hooks.OnRetry {
+		hook(ctx, stage, attempt, err)
+	}
+}
@@ -0,0 +1,123 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHooks_BeforeStageVetoesDownload(t *testing.T) {
+	api := newTerminalMock()
+	client := newTestClient(t, api)
+
+	errTooExpensive := errors.New("too expensive")
+	var started []Stage
+	client.Hooks().AddBeforeStage(func(ctx context.Context, stage *StageStart) error {
+		started = append(started, stage.Stage)
+		if stage.Stage == StageLogDownload {
+			return errTooExpensive
+		}
+		return nil
+	})
+	var failed []Stage
+	client.Hooks().AddOnError(func(ctx context.Context, stage Stage, err error) {
+		failed = append(failed, stage)
+	})
+
+	_, err := client.DownloadAndCache(t.Context(), JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"}, time.Minute, false)
+	if !errors.Is(err, ErrStageVetoed) || !errors.Is(err, errTooExpensive) {
+		t.Fatalf("DownloadAndCache error = %v, want ErrStageVetoed wrapping the hook's error", err)
+	}
+	if logCalls, _ := api.calls(); logCalls != 0 {
+		t.Errorf("log downloads = %d, want 0", logCalls)
+	}
+	want := []Stage{StageCacheCheck, StageJobStatus, StageLogDownload}
+	if len(started) != len(want) {
+		t.Fatalf("before stage hooks ran for %v, want %v", started, want)
+	}
+	for i := range want {
+		if started[i] != want[i] {
+			t.Errorf("before stage hooks ran for %v, want %v", started, want)
+			break
+		}
+	}
+	if len(failed) != 1 || failed[0] != StageLogDownload {
+		t.Errorf("error hooks ran for %v, want [%s]", failed, StageLogDownload)
+	}
+}
+
+func TestHooks_BeforeStageTimeout(t *testing.T) {
+	api := newTerminalMock()
+	api.logDelay = time.Second
+	client := newTestClient(t, api)
+
+	client.Hooks().AddBeforeStage(func(ctx context.Context, stage *StageStart) error {
+		if stage.Stage == StageLogDownload {
+			stage.Timeout = 10 * time.Millisecond
+		}
+		return nil
+	})
+
+	_, err := client.DownloadAndCache(t.Context(), JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"}, time.Minute, false)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DownloadAndCache error = %v, want the stage's deadline exceeded", err)
+	}
+}
+
+func TestHooks_OnErrorAndRetry(t *testing.T) {
+	api := newTerminalMock()
+	api.statusErr = errors.New("unavailable")
+	client := newTestClient(t, api, WithJobStatusRetry(RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Retryable:      func(error) bool { return true },
+	}))
+
+	var retries []int
+	client.Hooks().AddOnRetry(func(ctx context.Context, stage Stage, attempt int, err error) {
+		if stage != StageJobStatus || !errors.Is(err, api.statusErr) {
+			t.Errorf("retry hook got %s, %v", stage, err)
+		}
+		retries = append(retries, attempt)
+	})
+	var failed []Stage
+	client.Hooks().AddOnError(func(ctx context.Context, stage Stage, err error) {
+		failed = append(failed, stage)
+	})
+
+	if _, err := client.DownloadAndCache(t.Context(), JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"}, time.Minute, false); err == nil {
+		t.Fatal("expected job status error")
+	}
+	if len(retries) != 2 || retries[0] != 1 || retries[1] != 2 {
+		t.Errorf("retried attempts = %v, want [1 2]", retries)
+	}
+	if len(failed) != 1 || failed[0] != StageJobStatus {
+		t.Errorf("error hooks ran for %v, want [%s]", failed, StageJobStatus)
+	}
+}
+
+func TestHooks_ConsistencyRetriesReachStage(t *testing.T) {
+	client := newTestClient(t, newTerminalMock())
+
+	var stages []Stage
+	client.Hooks().AddOnRetry(func(ctx context.Context, stage Stage, attempt int, err error) {
+		stages = append(stages, stage)
+	})
+
+	reads := 0
+	ctx := client.stageContext(t.Context(), StageLocalCache)
+	_, err := readExistingBlob(ctx, RetryPolicy{MaxAttempts: 2, Retryable: func(error) bool { return true }}, "key", func() (int, error) {
+		reads++
+		if reads == 1 {
+			return 0, errors.New("not yet")
+		}
+		return 1, nil
+	})
+	if err != nil {
+		t.Fatalf("readExistingBlob failed: %v", err)
+	}
+	if len(stages) != 1 || stages[0] != StageLocalCache {
+		t.Errorf("retry hooks ran for %v, want [%s]", stages, StageLocalCache)
+	}
+}
@@ -0,0 +1,168 @@
+package buildkitelogs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// summaryStages lists the stages in the order they run, which is the order summaries
+// report them in.
+var summaryStages = []Stage{
+	StageCacheCheck,
+	StageJobStatus,
+	StageLogDownload,
+	StageLogParsing,
+	StageBlobStorage,
+	StageLocalCache,
+}
+
+// OperationSummary aggregates the stages of one cached log read, from the cache check
+// to the local cache file (or the first failing stage).
+type OperationSummary struct {
+	Org, Pipeline, Build, Job string
+	Stages                    map[Stage]time.Duration // Duration of each stage that ran
+	Total                     time.Duration           // Sum of the stage durations
+	Downloaded                bool                    // The log was fetched from the API rather than served from cache
+	LogSize                   int64                   // Bytes downloaded, when Downloaded
+	LogEntries                int                     // Entries parsed, when Downloaded
+	FileSize                  int64                   // Size of the Parquet file handed to the reader
+	Success                   bool
+	Err                       error  // Error of the failing stage
+	RequestID                 string // Buildkite request ID of the failing API call, if known
+}
+
+// LogAttrs returns the summary as slog attributes, with one duration per stage that ran.
+func (s *OperationSummary) LogAttrs() []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("org", s.Org),
+		slog.String("pipeline", s.Pipeline),
+		slog.String("build", s.Build),
+		slog.String("job", s.Job),
+		slog.Duration("total", s.Total),
+		slog.Bool("downloaded", s.Downloaded),
+	}
+	for _, stage := range summaryStages {
+		if d, ok := s.Stages[stage]; ok {
+			attrs = append(attrs, slog.Duration(string(stage), d))
+		}
+	}
+	if s.Downloaded {
+		attrs = append(attrs, slog.Int64("log_size", s.LogSize), slog.Int("log_entries", s.LogEntries))
+	}
+	if s.Success {
+		attrs = append(attrs, slog.Int64("file_size", s.FileSize))
+	} else if s.Err != nil {
+		attrs = append(attrs, slog.String("error", s.Err.Error()))
+		if s.RequestID != "" {
+			attrs = append(attrs, slog.String("request_id", s.RequestID))
+		}
+	}
+	return attrs
+}
+
+// SummaryHook collects the per-stage hook results of each cached log read and reports a
+// single OperationSummary once the read completes or a stage fails. Register it with
+// Hooks.AddSummary.
+//
+// Results are correlated by job, so overlapping reads of the same job may be reported
+// as one operation.
+type SummaryHook struct {
+	emit func(ctx context.Context, summary *OperationSummary)
+
+	mu      sync.Mutex
+	pending map[string]*OperationSummary
+}
+
+// NewSummaryHook creates a SummaryHook that passes each completed summary to emit.
+func NewSummaryHook(emit func(ctx context.Context, summary *OperationSummary)) *SummaryHook {
+	return &SummaryHook{
+		emit:    emit,
+		pending: make(map[string]*OperationSummary),
+	}
+}
+
+// NewSlogSummaryHook creates a SummaryHook that logs each summary to logger, at info
+// level for successful reads and warn level for failed ones.
+func NewSlogSummaryHook(logger *slog.Logger) *SummaryHook {
+	return NewSummaryHook(func(ctx context.Context, summary *OperationSummary) {
+		level := slog.LevelInfo
+		if !summary.Success {
+			level = slog.LevelWarn
+		}
+		logger.LogAttrs(ctx, level, "buildkite log read", summary.LogAttrs()...)
+	})
+}
+
+// AddSummary registers s for every stage hook.
+func (h *Hooks) AddSummary(s *SummaryHook) {
+	h.AddAfterCacheCheck(func(ctx context.Context, result *CacheCheckResult) {
+		s.record(ctx, &result.BaseResult, nil)
+	})
+	h.AddAfterJobStatus(func(ctx context.Context, result *JobStatusResult) {
+		base := result.BaseResult
+		if result.WillRetry {
+			// A retried attempt's time counts towards the stage without ending the operation
+			base.Success, base.Err = true, nil
+		}
+		s.record(ctx, &base, nil)
+	})
+	h.AddAfterLogDownload(func(ctx context.Context, result *LogDownloadResult) {
+		s.record(ctx, &result.BaseResult, func(summary *OperationSummary) {
+			summary.Downloaded = true
+			summary.LogSize = result.LogSize
+		})
+	})
+	h.AddAfterLogParsing(func(ctx context.Context, result *LogParsingResult) {
+		s.record(ctx, &result.BaseResult, func(summary *OperationSummary) {
+			summary.LogEntries = result.LogEntries
+		})
+	})
+	h.AddAfterBlobStorage(func(ctx context.Context, result *BlobStorageResult) {
+		s.record(ctx, &result.BaseResult, nil)
+	})
+	h.AddAfterLocalCache(func(ctx context.Context, result *LocalCacheResult) {
+		s.record(ctx, &result.BaseResult, func(summary *OperationSummary) {
+			summary.FileSize = result.FileSize
+		})
+	})
+}
+
+// record adds a stage result to its operation, emitting the summary when the operation
+// is complete. A cache check always starts a new operation.
+func (s *SummaryHook) record(ctx context.Context, result *BaseResult, update func(*OperationSummary)) {
+	key := GenerateBlobKey(result.Org, result.Pipeline, result.Build, result.Job)
+
+	s.mu.Lock()
+	summary, ok := s.pending[key]
+	if !ok || result.Stage == StageCacheCheck {
+		summary = &OperationSummary{
+			Org:      result.Org,
+			Pipeline: result.Pipeline,
+			Build:    result.Build,
+			Job:      result.Job,
+			Stages:   make(map[Stage]time.Duration),
+		}
+		s.pending[key] = summary
+	}
+
+	summary.Stages[result.Stage] += result.Duration
+	summary.Total += result.Duration
+	if update != nil {
+		update(summary)
+	}
+
+	done := !result.Success || result.Stage == StageLocalCache
+	if done {
+		summary.Success = result.Success
+		summary.Err = result.Err
+		summary.RequestID = result.RequestID
+		delete(s.pending, key)
+	}
+	s.mu.Unlock()
+
+	if done {
+		s.emit(ctx, summary)
+	}
+}
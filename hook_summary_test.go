@@ -0,0 +1,72 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSummaryHook_ReportsEachRead(t *testing.T) {
+	client := newTestClient(t, newTerminalMock())
+
+	var summaries []*OperationSummary
+	client.Hooks().AddSummary(NewSummaryHook(func(ctx context.Context, summary *OperationSummary) {
+		summaries = append(summaries, summary)
+	}))
+
+	for range 2 {
+		reader, err := client.NewReader(t.Context(), "org", "pipeline", "1", "test-job", time.Minute, false)
+		if err != nil {
+			t.Fatalf("NewReader failed: %v", err)
+		}
+		reader.Close()
+	}
+
+	if len(summaries) != 2 {
+		t.Fatalf("got %d summaries, want 2", len(summaries))
+	}
+
+	first := summaries[0]
+	if !first.Success || !first.Downloaded || first.Job != "test-job" {
+		t.Errorf("unexpected first summary: %+v", first)
+	}
+	for _, stage := range []Stage{StageCacheCheck, StageLogDownload, StageLogParsing, StageBlobStorage, StageLocalCache} {
+		if _, ok := first.Stages[stage]; !ok {
+			t.Errorf("first summary is missing stage %s", stage)
+		}
+	}
+
+	second := summaries[1]
+	if !second.Success || second.Downloaded {
+		t.Errorf("expected a cache hit, got %+v", second)
+	}
+	if _, ok := second.Stages[StageLogDownload]; ok {
+		t.Error("cache hit reported a log download")
+	}
+}
+
+func TestSummaryHook_EmitsOnFailedStage(t *testing.T) {
+	var summaries []*OperationSummary
+	hooks := &Hooks{}
+	hooks.AddSummary(NewSummaryHook(func(ctx context.Context, summary *OperationSummary) {
+		summaries = append(summaries, summary)
+	}))
+
+	base := BaseResult{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job", Success: true}
+	check := base
+	check.Stage, check.Duration = StageCacheCheck, time.Millisecond
+	hooks.OnAfterCacheCheck[0](t.Context(), &CacheCheckResult{BaseResult: check})
+
+	failed := base
+	failed.Stage, failed.Duration = StageLogDownload, 2*time.Millisecond
+	failed.Success, failed.Err = false, errors.New("boom")
+	hooks.OnAfterLogDownload[0](t.Context(), &LogDownloadResult{BaseResult: failed})
+
+	if len(summaries) != 1 {
+		t.Fatalf("got %d summaries, want 1", len(summaries))
+	}
+	if got := summaries[0]; got.Success || got.Err == nil || got.Total != 3*time.Millisecond {
+		t.Errorf("unexpected summary: %+v", got)
+	}
+}
@@ -0,0 +1,178 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// WithInMemory makes the client work without writing to the local filesystem, for
+// services that serve queries from memory or remote storage. The cache defaults to
+// MemoryStorageURL when the client is created without a storage URL; another URL, such
+// as s3://bucket, is used as given.
+//
+// Logs are parsed into memory rather than a temp file, and readers returned by the
+// client read the cached blob itself instead of a local copy, using the context passed
+// to the call that returned them. Logs with segments appended by WithIncrementalRefresh
+// are merged in memory. CacheResult.Path is empty, no AfterLocalCache hooks run and
+// WithRefreshLock, which coordinates processes through lock files, has no effect.
+// Default is off.
+func WithInMemory(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.inMemory = enabled
+	}
+}
+
+// parquetSpool holds Parquet data the client writes before storing it: a temp file, or
+// a buffer for clients created WithInMemory.
+type parquetSpool struct {
+	path string // Temp file, "" when buffered
+	buf  *bytes.Buffer
+}
+
+// newParquetSpool creates an empty spool, naming a temp file after pattern.
+func (c *Client) newParquetSpool(pattern string) (*parquetSpool, error) {
+	if c.inMemory {
+		return &parquetSpool{buf: &bytes.Buffer{}}, nil
+	}
+
+	tempFile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		_ = os.Remove(tempFile.Name())
+		return nil, fmt.Errorf("failed to close temp file before export: %w", err)
+	}
+	return &parquetSpool{path: tempFile.Name()}, nil
+}
+
+// export writes the entries of seq that filterFunc accepts (all of them when it is nil)
+// to the spool and returns the number of rows written.
+func (s *parquetSpool) export(seq iter.Seq2[*logparser.Entry, error], filterFunc func(*logparser.Entry) bool, opts ...ParquetWriterOption) (int, error) {
+	if s.buf != nil {
+		return ExportSeq2ToParquetWriterWithFilter(seq, s.buf, filterFunc, opts...)
+	}
+	return ExportSeq2ToParquetWithFilterAndStats(seq, s.path, filterFunc, opts...)
+}
+
+// size returns the size of the spooled data.
+func (s *parquetSpool) size() (int64, error) {
+	if s.buf != nil {
+		return int64(s.buf.Len()), nil
+	}
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// sha256 returns the hex encoded SHA-256 of the spooled data.
+func (s *parquetSpool) sha256() (string, error) {
+	if s.buf != nil {
+		return contentSHA256(bytes.NewReader(s.buf.Bytes()))
+	}
+	return fileSHA256(s.path)
+}
+
+// open returns a reader over the spooled data.
+func (s *parquetSpool) open() (io.ReadCloser, error) {
+	if s.buf != nil {
+		return io.NopCloser(bytes.NewReader(s.buf.Bytes())), nil
+	}
+	return os.Open(s.path) //nolint:gosec // path from os.CreateTemp, not user input
+}
+
+// reader returns a ParquetReader over the spooled data.
+func (s *parquetSpool) reader() *ParquetReader {
+	if s.buf != nil {
+		return NewParquetReaderFromReaderAt(bytes.NewReader(s.buf.Bytes()), int64(s.buf.Len()))
+	}
+	return NewParquetReader(s.path)
+}
+
+// remove deletes the spool's temp file, if any.
+func (s *parquetSpool) remove() {
+	if s.path != "" {
+		_ = os.Remove(s.path)
+	}
+}
+
+// memoryReaderAt is a ReaderAtCloser over bytes in memory.
+type memoryReaderAt struct {
+	*bytes.Reader
+}
+
+func (memoryReaderAt) Close() error {
+	return nil
+}
+
+// openMergedLog returns the parts of a log with segments merged into one Parquet file: a
+// local file, or a buffer for clients created WithInMemory.
+func (c *Client) openMergedLog(ctx context.Context, log *cachedLog, blobKey string) (ReaderAtCloser, error) {
+	if c.inMemory {
+		var buf bytes.Buffer
+		if err := writeMergedLog(ctx, c.blobStorage, log, &buf); err != nil {
+			return nil, err
+		}
+		return memoryReaderAt{bytes.NewReader(buf.Bytes())}, nil
+	}
+
+	path, err := createMergedLocalCacheFile(ctx, c.blobStorage, log, blobKey, c.localCacheDir)
+	if err != nil {
+		return nil, err
+	}
+	return openFileReaderAt(path)
+}
+
+// newInMemoryReader returns a reader over the cached blob at key for clients created
+// WithInMemory: the blob itself, or its parts merged in memory if segments were appended
+// to it.
+func (c *Client) newInMemoryReader(ctx context.Context, key string) (*ParquetReader, error) {
+	log, err := readExistingBlob(ctx, c.consistencyRetry, key, func() (*cachedLog, error) {
+		return readCachedLog(ctx, c.blobStorage, key)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached log: %w", err)
+	}
+	if log.segments() == 0 {
+		return c.newParquetReaderFromBlob(ctx, key), nil
+	}
+
+	merged, err := c.openMergedLog(ctx, log, key)
+	if err != nil {
+		return nil, err
+	}
+	reader := NewParquetReaderFromReaderAt(merged, merged.Size()).WithLogger(c.logger)
+	if c.checksums {
+		reader.WithChecksumValidation()
+	}
+	return reader, nil
+}
+
+// openCachedLog returns a reader over the cached blob at key: a local file holding its
+// content, or the blob itself for clients created WithInMemory.
+func (c *Client) openCachedLog(ctx context.Context, key string) (*ParquetReader, error) {
+	if c.inMemory {
+		return c.newInMemoryReader(ctx, key)
+	}
+	path, err := c.leasedLocalCacheFile(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return c.newParquetReader(path), nil
+}
+
+// readerForResult returns a reader over the log DownloadAndCache made available as result.
+func (c *Client) readerForResult(ctx context.Context, result *CacheResult) (*ParquetReader, error) {
+	if c.inMemory {
+		return c.newInMemoryReader(ctx, result.BlobKey)
+	}
+	return c.newParquetReader(result.Path), nil
+}
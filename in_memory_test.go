@@ -0,0 +1,92 @@
+package buildkitelogs
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestClient_InMemory(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	localCacheDir := t.TempDir()
+
+	api := newTerminalMock()
+	api.logContent = errorsViewTestLog
+	client, err := NewClientWithAPI(t.Context(), api, "", WithInMemory(true), WithErrorsView(true), WithLocalCacheDir(localCacheDir))
+	if err != nil {
+		t.Fatalf("NewClientWithAPI failed: %v", err)
+	}
+	defer client.Close()
+
+	if url := client.blobStorage.URL(); url != MemoryStorageURL {
+		t.Errorf("storage URL = %s, want %s", url, MemoryStorageURL)
+	}
+
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"}
+	result, err := client.DownloadAndCache(t.Context(), location, 0, false)
+	if err != nil {
+		t.Fatalf("DownloadAndCache failed: %v", err)
+	}
+	if result.Path != "" {
+		t.Errorf("Path = %q, want none", result.Path)
+	}
+
+	reader, err := client.NewReader(t.Context(), "org", "pipeline", "1", "job", 0, false)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if got := readContents(t, reader); len(got) != 5 {
+		t.Errorf("entries = %q, want 5", got)
+	}
+
+	errorsReader, err := client.NewErrorsReader(t.Context(), "org", "pipeline", "1", "job", 0, false)
+	if err != nil {
+		t.Fatalf("NewErrorsReader failed: %v", err)
+	}
+	want := []string{"~~~ Running tests", "WARNING: flag -x is deprecated", "ERROR: connection refused"}
+	if got := readContents(t, errorsReader); !slices.Equal(got, want) {
+		t.Errorf("errors view = %q, want %q", got, want)
+	}
+
+	if logCalls, _ := api.calls(); logCalls != 1 {
+		t.Errorf("log downloads = %d, want 1", logCalls)
+	}
+	for _, dir := range []string{tmp, localCacheDir} {
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("files written to %s: %v", dir, entries)
+		}
+	}
+}
+
+func TestClient_InMemoryIncrementalRefresh(t *testing.T) {
+	head := "~~~ Build\n\x1b_bk;t=1745322209921\x07first\n"
+	api := &rangeMockAPI{mockBuildkiteAPI: &mockBuildkiteAPI{
+		logContent: head,
+		jobStatus:  &JobStatus{ID: "test-job", State: JobStateRunning, IsTerminal: false},
+	}}
+	localCacheDir := t.TempDir()
+	client, err := NewClientWithAPI(t.Context(), api, "", WithInMemory(true), WithIncrementalRefresh(true), WithLocalCacheDir(localCacheDir))
+	if err != nil {
+		t.Fatalf("NewClientWithAPI failed: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.NewReader(t.Context(), "org", "pipeline", "1", "job", 0, false); err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	api.mu.Lock()
+	api.logContent = head + "\x1b_bk;t=1745322209922\x07second\n"
+	api.mu.Unlock()
+
+	reader, err := client.NewReader(t.Context(), "org", "pipeline", "1", "job", 0, true)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if want, got := []string{"~~~ Build", "first", "second"}, readContents(t, reader); !slices.Equal(got, want) {
+		t.Errorf("entries = %q, want %q", got, want)
+	}
+	if entries, _ := os.ReadDir(localCacheDir); len(entries) != 0 {
+		t.Errorf("segments merged into local files: %v", entries)
+	}
+}
@@ -0,0 +1,177 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// RangeLogProvider is implemented by log providers that can fetch a job log from a byte
+// offset, so refreshing the cache of a running job only downloads its new output. See
+// WithIncrementalRefresh.
+type RangeLogProvider interface {
+	// GetJobLogFrom returns the log from byte offset onwards, or an empty log when it has
+	// no bytes past offset.
+	GetJobLogFrom(ctx context.Context, org, pipeline, build, job string, offset int64) (io.ReadCloser, error)
+}
+
+// GetJobLogFrom fetches the log of a job from byte offset onwards with Range requests.
+// A first request asks for the single byte at offset, to learn whether the server honors
+// ranges. If it does, the rest is streamed by a second request; if it sends the whole log
+// instead, that response is streamed with its first offset bytes skipped. Nothing is
+// buffered on disk either way.
+func (c *BuildkiteAPIClient) GetJobLogFrom(ctx context.Context, org, pipeline, build, job string, offset int64) (io.ReadCloser, error) {
+	if offset <= 0 {
+		return c.GetJobLog(ctx, org, pipeline, build, job)
+	}
+	if c.requireToken && c.apiToken == "" {
+		return nil, fmt.Errorf("missing Buildkite API token")
+	}
+
+	reader, writer := io.Pipe()
+	u := fmt.Sprintf("v2/organizations/%s/pipelines/%s/builds/%s/jobs/%s/log", org, pipeline, build, job)
+
+	go func() {
+		// As for GetJobLog, a download is only retried until data arrives
+		progress := &writeTracker{w: writer}
+		var partial bool
+		err := c.withRetry(ctx, func() error {
+			var err error
+			partial, err = c.downloadJobLog(ctx, u, fmt.Sprintf("bytes=%d-%d", offset, offset), &skipWriter{w: progress, skip: offset})
+			return err
+		}, func() bool { return !progress.written })
+
+		var apiErr *APIError
+		switch {
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+			err = nil // Nothing was logged past offset
+		case err == nil && partial:
+			err = c.withRetry(ctx, func() error {
+				partial, err := c.downloadJobLog(ctx, u, fmt.Sprintf("bytes=%d-", offset), progress)
+				if err == nil && !partial {
+					err = fmt.Errorf("server sent the whole log for a range request it honored before")
+				}
+				return err
+			}, func() bool { return !progress.written })
+		}
+		if err != nil {
+			err = &logDownloadError{err: err}
+		}
+		_ = writer.CloseWithError(err)
+	}()
+
+	return reader, nil
+}
+
+// skipWriter discards the first skip bytes written to it and passes the rest to w.
+type skipWriter struct {
+	w    io.Writer
+	skip int64
+}
+
+func (s *skipWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if s.skip >= int64(n) {
+		s.skip -= int64(n)
+		return n, nil
+	}
+	p = p[s.skip:]
+	s.skip = 0
+	if _, err := s.w.Write(p); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// WithIncrementalRefresh sets whether refreshing the cache of a running job downloads
+// and parses only the output logged since it was cached, instead of the whole log. This
+// needs an API client that implements RangeLogProvider, such as BuildkiteAPIClient.
+//
+// The new entries are stored as a segment next to the cached Parquet file, so a refresh
+// uploads only what it parsed. Readers get the file and its segments merged into one
+// local file. Once the job finishes, or after DefaultMaxCacheSegments segments, the
+// next refresh compacts the log into a single file again. Logs are downloaded in full
+// when the cache was written by another parser version, was truncated, or records no
+// offset to continue from. Default is off.
+func WithIncrementalRefresh(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.incremental = enabled
+	}
+}
+
+// appendBase is the part of a cached log that an incremental refresh keeps: the rows
+// parsed from the log's first offset bytes, which end with a complete line.
+type appendBase struct {
+	log    *cachedLog
+	offset int64
+	rows   int
+	group  string // Group of the last kept row, which the new output continues
+	parent string // Group that group is nested in
+}
+
+// loadAppendBase returns the part of the cached log at blobKey that a refresh can append
+// to, or nil if the log has to be downloaded in full.
+func (c *Client) loadAppendBase(ctx context.Context, blobKey string) *appendBase {
+	log, err := readCachedLog(ctx, c.blobStorage, blobKey)
+	if err != nil && !IsBlobNotFound(err) {
+		c.logAppendFailure(ctx, blobKey, err)
+	}
+	if err != nil || log.metadata == nil || log.metadata.IsTerminal || log.metadata.LogOffset <= 0 {
+		return nil
+	}
+
+	info, err := c.newParquetReaderFromBlob(ctx, blobKey).GetFileInfo()
+	if err != nil {
+		c.logAppendFailure(ctx, blobKey, err)
+	}
+	if err != nil || info.ParserVersion != logparser.Version {
+		return nil
+	}
+	if log.segments() == 0 && info.RowCount < int64(log.metadata.LogOffsetRows) {
+		return nil
+	}
+	group, parent, err := log.lastKeptGroup(ctx, func(key string) *ParquetReader {
+		return c.newParquetReaderFromBlob(ctx, key)
+	})
+	if err != nil {
+		c.logAppendFailure(ctx, blobKey, err)
+		return nil
+	}
+	return &appendBase{log: log, offset: log.metadata.LogOffset, rows: log.metadata.LogOffsetRows, group: group, parent: parent}
+}
+
+// logAppendFailure logs that the cached log at blobKey could not be read to continue it,
+// so it is downloaded in full.
+func (c *Client) logAppendFailure(ctx context.Context, blobKey string, err error) {
+	c.logger.WarnContext(ctx, "failed to read cached log to continue it, downloading it in full", "blob_key", blobKey, "error", err)
+}
+
+// canAppendSegment reports whether new output of a job in the given state can be stored
+// as another segment of the base, rather than compacting the log into a single file.
+func (b *appendBase) canAppendSegment(jobStatus *JobStatus) bool {
+	return !jobStatus.IsTerminal && b.log.metadata.SegmentGeneration != "" && b.log.segments() < DefaultMaxCacheSegments
+}
+
+// entries returns the kept rows followed by the entries of next.
+func (b *appendBase) entries(ctx context.Context, c *Client, next iter.Seq2[*logparser.Entry, error]) iter.Seq2[*logparser.Entry, error] {
+	kept := b.log.entries(ctx, func(key string) *ParquetReader {
+		return c.newParquetReaderFromBlob(ctx, key)
+	}, false)
+	return func(yield func(*logparser.Entry, error) bool) {
+		for entry, err := range kept {
+			if !yield(entry, err) || err != nil {
+				return
+			}
+		}
+		for entry, err := range next {
+			if !yield(entry, err) {
+				return
+			}
+		}
+	}
+}
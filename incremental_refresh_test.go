@@ -0,0 +1,235 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// rangeMockAPI adds GetJobLogFrom to mockBuildkiteAPI, recording the offsets requested.
+type rangeMockAPI struct {
+	*mockBuildkiteAPI
+	offsets []int64
+}
+
+func (m *rangeMockAPI) GetJobLogFrom(ctx context.Context, org, pipeline, build, job string, offset int64) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.offsets = append(m.offsets, offset)
+	return io.NopCloser(strings.NewReader(m.logContent[offset:])), nil
+}
+
+func TestClient_IncrementalRefresh(t *testing.T) {
+	head := "~~~ Build\n\x1b_bk;t=1745322209921\x07first\n"
+	api := &rangeMockAPI{mockBuildkiteAPI: &mockBuildkiteAPI{
+		logContent: head + "\x1b_bk;t=1745322209922\x07seco",
+		jobStatus:  &JobStatus{ID: "test-job", State: JobStateRunning, IsTerminal: false},
+	}}
+	client := newTestClient(t, api, WithIncrementalRefresh(true))
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"}
+
+	result, err := client.DownloadAndCache(t.Context(), location, 0, false)
+	if err != nil {
+		t.Fatalf("DownloadAndCache failed: %v", err)
+	}
+	if result.Outcome != CacheRefreshed {
+		t.Errorf("first Outcome = %q, want %q", result.Outcome, CacheRefreshed)
+	}
+
+	// The job finishes the partial line and logs another
+	api.mu.Lock()
+	api.logContent = head + "\x1b_bk;t=1745322209922\x07second\n\x1b_bk;t=1745322209923\x07third\n"
+	api.mu.Unlock()
+
+	result, err = client.DownloadAndCache(t.Context(), location, 0, true)
+	if err != nil {
+		t.Fatalf("DownloadAndCache failed: %v", err)
+	}
+	if result.Outcome != CacheExtended {
+		t.Errorf("second Outcome = %q, want %q", result.Outcome, CacheExtended)
+	}
+	if !slices.Equal(api.offsets, []int64{int64(len(head))}) {
+		t.Errorf("requested offsets %v, want [%d]", api.offsets, len(head))
+	}
+	if logCalls, _ := api.calls(); logCalls != 1 {
+		t.Errorf("full downloads = %d, want 1", logCalls)
+	}
+	if result.Sizes.LogBytes != int64(len(api.logContent)) || result.Sizes.Rows != 4 {
+		t.Errorf("Sizes = %+v, want %d log bytes and 4 rows", result.Sizes, len(api.logContent))
+	}
+
+	var contents, groups []string
+	for entry, err := range NewParquetReader(result.Path).ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		contents = append(contents, entry.Content)
+		groups = append(groups, entry.Group)
+	}
+	if want := []string{"~~~ Build", "first", "second", "third"}; !slices.Equal(contents, want) {
+		t.Errorf("contents = %q, want %q", contents, want)
+	}
+	for i, group := range groups {
+		if group != "~~~ Build" {
+			t.Errorf("entry %d group = %q, want %q", i, group, "~~~ Build")
+		}
+	}
+}
+
+func TestClient_IncrementalRefreshSegments(t *testing.T) {
+	api := &rangeMockAPI{mockBuildkiteAPI: &mockBuildkiteAPI{
+		logContent: "\x1b_bk;t=1745322209921\x07first\n",
+		jobStatus:  &JobStatus{ID: "test-job", State: JobStateRunning, IsTerminal: false},
+	}}
+	client := newTestClient(t, api, WithIncrementalRefresh(true))
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"}
+	blobKey := GenerateBlobKey("org", "pipeline", "1", "job")
+
+	if _, err := client.DownloadAndCache(t.Context(), location, 0, false); err != nil {
+		t.Fatalf("DownloadAndCache failed: %v", err)
+	}
+	base, err := client.blobStorage.ReadWithMetadata(t.Context(), blobKey)
+	if err != nil || base == nil || base.SegmentGeneration == "" {
+		t.Fatalf("cached log metadata = %+v, %v, want a segment generation", base, err)
+	}
+
+	lines := []string{"second", "third"}
+	for i, line := range lines {
+		api.mu.Lock()
+		api.logContent += fmt.Sprintf("\x1b_bk;t=%d\x07%s\n", 1745322209922+i, line)
+		api.mu.Unlock()
+
+		result, err := client.DownloadAndCache(t.Context(), location, 0, true)
+		if err != nil {
+			t.Fatalf("DownloadAndCache failed: %v", err)
+		}
+		if result.Outcome != CacheExtended {
+			t.Errorf("Outcome = %q, want %q", result.Outcome, CacheExtended)
+		}
+		if result.Sizes.Rows != i+2 {
+			t.Errorf("Rows = %d, want %d", result.Sizes.Rows, i+2)
+		}
+		if got := readContents(t, NewParquetReader(result.Path)); len(got) != i+2 {
+			t.Errorf("merged log = %q, want %d entries", got, i+2)
+		}
+	}
+
+	// The cached file is left as it was, with one segment per refresh
+	unchanged, err := client.blobStorage.ReadWithMetadata(t.Context(), blobKey)
+	if err != nil || unchanged.RowCount != 1 {
+		t.Fatalf("cached log metadata = %+v, %v, want the first refresh's", unchanged, err)
+	}
+	segments, err := client.blobStorage.segmentKeys(t.Context(), blobKey, base.SegmentGeneration)
+	if err != nil || len(segments) != 2 {
+		t.Fatalf("segments = %q, %v, want 2", segments, err)
+	}
+
+	// Once the job finishes, the log is compacted into a single file
+	api.mu.Lock()
+	api.logContent += "\x1b_bk;t=1745322209924\x07done\n"
+	api.jobStatus = &JobStatus{ID: "test-job", State: JobStatePassed, IsTerminal: true}
+	api.mu.Unlock()
+	result, err := client.DownloadAndCache(t.Context(), location, 0, true)
+	if err != nil {
+		t.Fatalf("DownloadAndCache failed: %v", err)
+	}
+	if result.Outcome != CacheExtended {
+		t.Errorf("Outcome = %q, want %q", result.Outcome, CacheExtended)
+	}
+	compacted, err := client.blobStorage.ReadWithMetadata(t.Context(), blobKey)
+	if err != nil || !compacted.IsTerminal || compacted.RowCount != 4 || compacted.SegmentGeneration != "" {
+		t.Errorf("compacted metadata = %+v, %v, want a terminal log of 4 rows without segments", compacted, err)
+	}
+	if left, err := client.blobStorage.listKeys(t.Context(), blobKey+".seg-"); err != nil || len(left) != 0 {
+		t.Errorf("segments left after compaction = %q, %v", left, err)
+	}
+	want := []string{"first", "second", "third", "done"}
+	if got := readContents(t, NewParquetReader(result.Path)); !slices.Equal(got, want) {
+		t.Errorf("compacted log = %q, want %q", got, want)
+	}
+}
+
+func TestClient_IncrementalRefreshDisabled(t *testing.T) {
+	api := &rangeMockAPI{mockBuildkiteAPI: &mockBuildkiteAPI{
+		logContent: "\x1b_bk;t=1745322209921\x07first\n",
+		jobStatus:  &JobStatus{ID: "test-job", State: JobStateRunning, IsTerminal: false},
+	}}
+	client := newTestClient(t, api)
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"}
+
+	for range 2 {
+		result, err := client.DownloadAndCache(t.Context(), location, 0, true)
+		if err != nil {
+			t.Fatalf("DownloadAndCache failed: %v", err)
+		}
+		if result.Outcome != CacheRefreshed {
+			t.Errorf("Outcome = %q, want %q", result.Outcome, CacheRefreshed)
+		}
+	}
+	if len(api.offsets) != 0 {
+		t.Errorf("requested offsets %v, want none", api.offsets)
+	}
+}
+
+func TestGetJobLogFrom(t *testing.T) {
+	const log = "first\nsecond\nthird\n"
+	tests := []struct {
+		name         string
+		honorRange   bool
+		offset       int64
+		want         string
+		wantRequests []string
+	}{
+		{name: "partial content", honorRange: true, offset: 6, want: "second\nthird\n", wantRequests: []string{"bytes=6-6", "bytes=6-"}},
+		{name: "range ignored", honorRange: false, offset: 6, want: "second\nthird\n", wantRequests: []string{"bytes=6-6"}},
+		{name: "nothing new", honorRange: true, offset: int64(len(log)), want: "", wantRequests: []string{fmt.Sprintf("bytes=%d-%d", len(log), len(log))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRequests []string
+			api := newRetryTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				rng := r.Header.Get("Range")
+				gotRequests = append(gotRequests, rng)
+				if !tt.honorRange {
+					_, _ = io.WriteString(w, log)
+					return
+				}
+				first, last, _ := strings.Cut(strings.TrimPrefix(rng, "bytes="), "-")
+				start, _ := strconv.Atoi(first)
+				end := len(log) - 1
+				if last != "" {
+					end, _ = strconv.Atoi(last)
+				}
+				if start >= len(log) {
+					w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+					_, _ = w.Write([]byte(`{"message":"range not satisfiable"}`))
+					return
+				}
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(log)))
+				w.WriteHeader(http.StatusPartialContent)
+				_, _ = io.WriteString(w, log[start:end+1])
+			}, RetryPolicy{})
+
+			reader, err := api.GetJobLogFrom(t.Context(), "org", "pipeline", "123", "job-1", tt.offset)
+			if err != nil {
+				t.Fatalf("GetJobLogFrom: %v", err)
+			}
+			defer reader.Close()
+			got, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("log = %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(gotRequests, tt.wantRequests) {
+				t.Errorf("Range requests = %q, want %q", gotRequests, tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// JobInfo describes a job and the state of its cached log, without downloading the log.
+type JobInfo struct {
+	Org      string          `json:"organization"`
+	Pipeline string          `json:"pipeline"`
+	Build    string          `json:"build"`
+	Status   *JobStatus      `json:"status"`
+	Cache    *JobCacheStatus `json:"cache"`
+}
+
+// Duration returns how long the job ran, or zero if it has not started and finished.
+func (ji *JobInfo) Duration() time.Duration {
+	if ji.Status == nil || ji.Status.StartedAt == nil || ji.Status.FinishedAt == nil {
+		return 0
+	}
+	return ji.Status.FinishedAt.Sub(*ji.Status.StartedAt)
+}
+
+// JobCacheStatus describes the cached Parquet log for a job in blob storage.
+type JobCacheStatus struct {
+	BlobKey     string    `json:"blob_key"`
+	Cached      bool      `json:"cached"`
+	IsTerminal  bool      `json:"is_terminal,omitempty"`
+	JobState    string    `json:"job_state,omitempty"`
+	CachedAt    time.Time `json:"cached_at,omitzero"`
+	TTL         string    `json:"ttl,omitempty"`
+	LogSize     int64     `json:"log_size_bytes,omitempty"`
+	ParquetSize int64     `json:"parquet_size_bytes,omitempty"`
+	RowCount    int       `json:"row_count,omitempty"`
+}
+
+// JobInfo returns the job's status from the JobStatusProvider together with the cache
+// status of its log. It never downloads or parses the log, making it a cheap first step
+// when triaging a job.
+func (c *Client) JobInfo(ctx context.Context, org, pipeline, build, job string) (*JobInfo, error) {
+	if err := ValidateAPIParams(org, pipeline, build, job); err != nil {
+		return nil, err
+	}
+
+	status, err := c.getJobStatus(ctx, c.api, org, pipeline, build, job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job status: %w", err)
+	}
+
+	cache, err := c.jobCacheStatus(ctx, org, pipeline, build, job)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JobInfo{
+		Org:      org,
+		Pipeline: pipeline,
+		Build:    build,
+		Status:   status,
+		Cache:    cache,
+	}, nil
+}
+
+// JobStatus returns the job's status from the JobStatusProvider, with the Client's job
+// status retries and hooks. Statuses of finished jobs can't change, so they are kept for
+// the life of the Client and later calls for the job make no API requests.
+func (c *Client) JobStatus(ctx context.Context, location JobLocation) (*JobStatus, error) {
+	if err := ValidateAPIParams(location.Org, location.Pipeline, location.Build, location.Job); err != nil {
+		return nil, err
+	}
+
+	key := GenerateBlobKey(location.Org, location.Pipeline, location.Build, location.Job)
+	c.mu.Lock()
+	cached, ok := c.statuses[key]
+	c.mu.Unlock()
+	if ok {
+		status := *cached
+		return &status, nil
+	}
+
+	status, err := c.getJobStatus(ctx, c.api, location.Org, location.Pipeline, location.Build, location.Job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job status: %w", err)
+	}
+
+	if status.IsTerminal {
+		kept := *status
+		c.mu.Lock()
+		if c.statuses == nil {
+			c.statuses = make(map[string]*JobStatus)
+		}
+		c.statuses[key] = &kept
+		c.mu.Unlock()
+	}
+	return status, nil
+}
+
+func (c *Client) jobCacheStatus(ctx context.Context, org, pipeline, build, job string) (*JobCacheStatus, error) {
+	blobKey := GenerateBlobKey(org, pipeline, build, job)
+	cache := &JobCacheStatus{BlobKey: blobKey}
+
+	exists, _, err := c.checkCache(ctx, org, pipeline, build, job, blobKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check blob existence: %w", err)
+	}
+	if !exists {
+		return cache, nil
+	}
+
+	metadata, err := readExistingBlob(c.stageContext(ctx, StageCacheCheck), c.consistencyRetry, blobKey, func() (*BlobMetadata, error) {
+		return c.blobStorage.cachedLogMetadata(ctx, blobKey)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache metadata: %w", err)
+	}
+
+	cache.Cached = true
+	if metadata != nil {
+		cache.IsTerminal = metadata.IsTerminal
+		cache.JobState = metadata.JobState
+		cache.CachedAt = metadata.CachedAt
+		cache.TTL = metadata.TTL
+		cache.LogSize = metadata.LogSize
+		cache.ParquetSize = metadata.ParquetSize
+		cache.RowCount = metadata.RowCount
+	}
+	return cache, nil
+}
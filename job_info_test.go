@@ -0,0 +1,103 @@
+package buildkitelogs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClient_JobInfo(t *testing.T) {
+	api := newTerminalMock()
+	started := time.Date(2025, 4, 22, 21, 43, 0, 0, time.UTC)
+	finished := started.Add(90 * time.Second)
+	api.jobStatus.AgentName = "agent-1"
+	api.jobStatus.StartedAt = &started
+	api.jobStatus.FinishedAt = &finished
+	client := newTestClient(t, api)
+
+	info, err := client.JobInfo(t.Context(), "org", "pipeline", "1", "test-job")
+	if err != nil {
+		t.Fatalf("JobInfo failed: %v", err)
+	}
+	if info.Status.State != JobStatePassed || info.Status.AgentName != "agent-1" {
+		t.Errorf("unexpected status: %+v", info.Status)
+	}
+	if info.Duration() != 90*time.Second {
+		t.Errorf("Duration() = %v, want 90s", info.Duration())
+	}
+	if info.Cache.Cached {
+		t.Error("expected log to be uncached before first read")
+	}
+	if logCalls, _ := api.calls(); logCalls != 0 {
+		t.Errorf("JobInfo downloaded the log %d times", logCalls)
+	}
+
+	reader, err := client.NewReader(t.Context(), "org", "pipeline", "1", "test-job", time.Minute, false)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	reader.Close()
+
+	info, err = client.JobInfo(t.Context(), "org", "pipeline", "1", "test-job")
+	if err != nil {
+		t.Fatalf("JobInfo failed: %v", err)
+	}
+	if !info.Cache.Cached || !info.Cache.IsTerminal {
+		t.Errorf("expected terminal cached log, got %+v", info.Cache)
+	}
+	if info.Cache.RowCount != 1 {
+		t.Errorf("cache row count = %d, want 1", info.Cache.RowCount)
+	}
+	if logCalls, _ := api.calls(); logCalls != 1 {
+		t.Errorf("log downloaded %d times, want 1", logCalls)
+	}
+}
+
+func TestClient_JobInfoValidatesParams(t *testing.T) {
+	client := newTestClient(t, newTerminalMock())
+	if _, err := client.JobInfo(t.Context(), "org", "", "1", "test-job"); err == nil {
+		t.Fatal("expected error for missing pipeline")
+	}
+}
+
+func TestClient_JobStatus(t *testing.T) {
+	api := newTerminalMock()
+	api.jobStatus.IsTerminal = false
+	api.jobStatus.State = JobStateRunning
+	client := newTestClient(t, api)
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "test-job"}
+
+	for range 2 {
+		status, err := client.JobStatus(t.Context(), location)
+		if err != nil {
+			t.Fatalf("JobStatus failed: %v", err)
+		}
+		if status.State != JobStateRunning {
+			t.Errorf("State = %q, want %q", status.State, JobStateRunning)
+		}
+	}
+	if _, statusCalls := api.calls(); statusCalls != 2 {
+		t.Errorf("running job status fetched %d times, want 2", statusCalls)
+	}
+
+	api.setJobStatus(&JobStatus{ID: "test-job", State: JobStatePassed, IsTerminal: true})
+	for range 3 {
+		status, err := client.JobStatus(t.Context(), location)
+		if err != nil {
+			t.Fatalf("JobStatus failed: %v", err)
+		}
+		if status.State != JobStatePassed {
+			t.Errorf("State = %q, want %q", status.State, JobStatePassed)
+		}
+		status.State = JobStateFailed // must not change the kept status
+	}
+	if _, statusCalls := api.calls(); statusCalls != 3 {
+		t.Errorf("status fetched %d times, want 3 (finished status kept)", statusCalls)
+	}
+	if logCalls, _ := api.calls(); logCalls != 0 {
+		t.Errorf("JobStatus downloaded the log %d times", logCalls)
+	}
+
+	if _, err := client.JobStatus(t.Context(), JobLocation{Org: "org", Build: "1", Job: "test-job"}); err == nil {
+		t.Error("expected error for missing pipeline")
+	}
+}
@@ -46,6 +46,18 @@ type JobStatus struct {
 	WebURL     string     `json:"web_url,omitempty"`
 	ExitStatus *int       `json:"exit_status,omitempty"`
 	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	// Optional details; providers fill in what they know
+	Name           string     `json:"name,omitempty"`
+	AgentName      string     `json:"agent_name,omitempty"`
+	AgentHostname  string     `json:"agent_hostname,omitempty"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+	ScheduledAt    *time.Time `json:"scheduled_at,omitempty"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	Retried        bool       `json:"retried,omitempty"`
+	RetriesCount   int        `json:"retries_count,omitempty"`
+	RetriedInJobID string     `json:"retried_in_job_id,omitempty"` // Job that retried this one
+	RetryOfJobID   string     `json:"retry_of_job_id,omitempty"`   // Job this one is a retry of
 }
 
 // terminalStates defines which job states are considered terminal
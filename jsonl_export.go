@@ -0,0 +1,53 @@
+package buildkitelogs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// ExportSeq2ToJSONL writes log entries to w as JSON Lines in the schema of
+// ParquetLogEntry, the same records query commands output, so parsed logs and queried
+// Parquet files can be consumed interchangeably. Row numbers count the entries written,
+// matching the rows of a Parquet export with the same filter. A nil filterFunc writes
+// every entry. It returns the number of entries written.
+func ExportSeq2ToJSONL(seq iter.Seq2[*logparser.Entry, error], w io.Writer, filterFunc func(*logparser.Entry) bool) (int, error) {
+	bw := bufio.NewWriter(w)
+	// Default encoding, like query output, so records are byte-for-byte interchangeable
+	encoder := json.NewEncoder(bw)
+
+	rows := 0
+	for entry, err := range seq {
+		if err != nil {
+			return rows, fmt.Errorf("error during iteration: %w", err)
+		}
+		if filterFunc != nil && !filterFunc(entry) {
+			continue
+		}
+
+		record := ParquetLogEntry{
+			RowNumber:   int64(rows),
+			Timestamp:   entry.Timestamp.UnixMilli(),
+			Content:     entry.Content,
+			Group:       entry.Group,
+			ParentGroup: entry.ParentGroup,
+			Depth:       entry.Depth,
+			Flags:       entry.ComputeFlags(),
+			Hash:        ContentHash(entry.Content),
+			Severity:    entry.Severity(),
+		}
+		if err := encoder.Encode(record); err != nil {
+			return rows, fmt.Errorf("failed to write JSON Lines record: %w", err)
+		}
+		rows++
+	}
+
+	if err := bw.Flush(); err != nil {
+		return rows, fmt.Errorf("failed to write JSON Lines export: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,47 @@
+package buildkitelogs
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestExportSeq2ToJSONL_MatchesQuerySchema(t *testing.T) {
+	log := "\x1b_bk;t=1745322209921\x07~~~ Setup\n\x1b_bk;t=1745322209922\x07ERROR: failed <html>\n\x1b_bk;t=1745322209923\x07--- :heartbeat: keepalive\nno timestamp\n"
+	withoutGroups := func(entry *logparser.Entry) bool { return !entry.IsGroup() }
+
+	var out strings.Builder
+	rows, err := ExportSeq2ToJSONL(logparser.New().All(strings.NewReader(log)), &out, withoutGroups)
+	if err != nil {
+		t.Fatalf("ExportSeq2ToJSONL failed: %v", err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "log.parquet")
+	if err := ExportSeq2ToParquetWithFilter(logparser.New().All(strings.NewReader(log)), filename, withoutGroups); err != nil {
+		t.Fatalf("ExportSeq2ToParquetWithFilter failed: %v", err)
+	}
+	var want []string
+	for entry, err := range NewParquetReader(filename).ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		record, err := json.Marshal(entry)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		want = append(want, string(record))
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if rows != len(want) || len(lines) != len(want) {
+		t.Fatalf("wrote %d rows (%d lines), want %d", rows, len(lines), len(want))
+	}
+	for i, line := range lines {
+		if line != want[i] {
+			t.Errorf("line %d = %s\nwant %s", i, line, want[i])
+		}
+	}
+}
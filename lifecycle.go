@@ -0,0 +1,241 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrClientClosed is returned by operations started after Client.Close.
+var ErrClientClosed = errors.New("client is closed")
+
+// ClientStats is a snapshot of the resources held by a Client.
+type ClientStats struct {
+	ActiveOperations int   `json:"active_operations"` // Reads and background cache refreshes in progress
+	LocalFiles       int   `json:"local_files"`       // Local copies of remote blobs made by this client
+	LocalBytes       int64 `json:"local_bytes"`       // Total size of LocalFiles
+	Closed           bool  `json:"closed"`
+}
+
+// localFile is the client's link to a local copy of a remote blob, see leaseLocalFile.
+type localFile struct {
+	size     int64
+	lastUsed time.Time
+}
+
+// WithIdleCleanup removes the client's links to local copies of remote blobs that no
+// read has returned for maxIdle, and the copies no other client links, checking every
+// maxIdle/2 in a background goroutine that Close stops. Readers held longer than maxIdle
+// without a new read of the same log may find their file gone and should be recreated.
+// file:// caches are read in place and are never removed.
+func WithIdleCleanup(maxIdle time.Duration) ClientOption {
+	return func(c *Client) {
+		c.idleTimeout = maxIdle
+	}
+}
+
+// Stats returns a snapshot of the client's in-flight work and local files.
+func (c *Client) Stats() ClientStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := ClientStats{
+		ActiveOperations: c.activeOps,
+		LocalFiles:       len(c.localFiles),
+		Closed:           c.closed,
+	}
+	for _, f := range c.localFiles {
+		stats.LocalBytes += f.size
+	}
+	return stats
+}
+
+// Close stops background cache refreshes and idle cleanup, waits for in-flight reads to
+// return, removes the client's links to local copies of remote blobs, and the copies
+// no other client links, and closes blob storage. Readers returned by the client must
+// not be used afterwards; readers of other clients sharing the local cache directory
+// are unaffected. Calling Close more than once is safe; later calls return nil.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	if c.closeCancel != nil {
+		c.closeCancel()
+	}
+	c.active.Wait()
+	if c.cleanupDone != nil {
+		<-c.cleanupDone
+	}
+
+	var errs []error
+	c.mu.Lock()
+	for link := range c.localFiles {
+		if err := releaseLocalFile(link); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.localFiles = nil
+	c.mu.Unlock()
+
+	if c.blobStorage != nil {
+		errs = append(errs, c.blobStorage.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// begin registers an operation that Close must wait for. The returned function marks it
+// finished.
+func (c *Client) begin() (func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrClientClosed
+	}
+	c.active.Add(1)
+	c.activeOps++
+	return func() {
+		c.mu.Lock()
+		c.activeOps--
+		c.mu.Unlock()
+		c.active.Done()
+	}, nil
+}
+
+// detach returns a context for shared background work that outlives the caller's
+// cancellation but is canceled when the client closes.
+func (c *Client) detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	if c.closeCtx == nil {
+		return detached, cancel
+	}
+	stop := context.AfterFunc(c.closeCtx, cancel)
+	return detached, func() {
+		stop()
+		cancel()
+	}
+}
+
+// leaseLocalFile returns the client's own hard link to a local copy in the local cache
+// directory, for a reader to open. Copies are shared by every client and process using
+// the directory, and ParquetReader reopens its path on every query, so a client only
+// ever removes its own links (see releaseLocalFile): another client's Close or idle
+// cleanup can't break its readers. Paths outside the directory, such as file:// blobs
+// read in place, are not the client's to remove and are returned as they are. It fails
+// with fs.ErrNotExist if the copy was removed before it could be linked.
+func (c *Client) leaseLocalFile(path string) (string, error) {
+	if filepath.Dir(path) != filepath.Clean(c.localCacheDir) {
+		return path, nil
+	}
+	link := strings.TrimSuffix(path, ".parquet") + "." + c.leaseID + ".parquet"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if f, ok := c.localFiles[link]; ok {
+		f.lastUsed = time.Now()
+		return link, nil
+	}
+
+	if err := os.Link(path, link); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		// Without hard links, readers share the copy as other clients may remove it
+		c.logger.Warn("failed to link local cache file, reading the shared copy", "path", path, "error", err)
+		return path, nil
+	}
+	var size int64
+	if info, err := os.Stat(link); err == nil {
+		size = info.Size()
+	}
+	if c.localFiles == nil {
+		c.localFiles = make(map[string]*localFile)
+	}
+	c.localFiles[link] = &localFile{size: size, lastUsed: time.Now()}
+	return link, nil
+}
+
+// releaseLocalFile removes a client's link to a local copy, and the copy once no
+// client links it. A client linking the copy meanwhile finds it gone and copies the
+// blob again.
+func releaseLocalFile(link string) error {
+	if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// Links are named <copy>.<lease>.parquet
+	base := strings.TrimSuffix(link, ".parquet")
+	shared := strings.TrimSuffix(base, filepath.Ext(base)) + ".parquet"
+	links, err := filepath.Glob(strings.TrimSuffix(shared, ".parquet") + ".*.parquet")
+	if err != nil || len(links) > 0 {
+		return nil
+	}
+	if err := os.Remove(shared); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// releaseLocalFiles removes the client's links to local copies of blobKey.
+func (c *Client) releaseLocalFiles(blobKey string) error {
+	prefix := filepath.Join(filepath.Clean(c.localCacheDir), localCachePrefix(blobKey))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs []error
+	for link := range c.localFiles {
+		if !strings.HasPrefix(link, prefix) {
+			continue
+		}
+		if err := releaseLocalFile(link); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delete(c.localFiles, link)
+	}
+	return errors.Join(errs...)
+}
+
+// startIdleCleanup runs removeIdleLocalFiles until the client closes.
+func (c *Client) startIdleCleanup() {
+	c.cleanupDone = make(chan struct{})
+	go func() {
+		defer close(c.cleanupDone)
+
+		ticker := time.NewTicker(max(c.idleTimeout/2, time.Second))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.closeCtx.Done():
+				return
+			case now := <-ticker.C:
+				c.removeIdleLocalFiles(now)
+			}
+		}
+	}()
+}
+
+// removeIdleLocalFiles releases the client's links to local copies last returned
+// before now minus the idle timeout.
+func (c *Client) removeIdleLocalFiles(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for link, f := range c.localFiles {
+		if now.Sub(f.lastUsed) < c.idleTimeout {
+			continue
+		}
+		if err := releaseLocalFile(link); err != nil {
+			c.logger.Warn("failed to remove idle local cache file", "path", link, "error", err)
+			continue // Retry on the next pass
+		}
+		delete(c.localFiles, link)
+	}
+}
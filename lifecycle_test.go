@@ -0,0 +1,137 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func newMemClient(t *testing.T, opts ...ClientOption) (*Client, string) {
+	t.Helper()
+	dir := t.TempDir()
+	client, err := NewClientWithAPI(t.Context(), newTerminalMock(), "mem://", append([]ClientOption{WithLocalCacheDir(dir)}, opts...)...)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, dir
+}
+
+func TestClient_CloseRemovesLocalCopies(t *testing.T) {
+	client, _ := newMemClient(t)
+
+	reader, err := client.NewReader(t.Context(), "org", "pipeline", "1", "test-job", time.Minute, false)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	path := reader.filename
+	reader.Close()
+
+	stats := client.Stats()
+	if stats.LocalFiles != 1 || stats.LocalBytes == 0 || stats.ActiveOperations != 0 || stats.Closed {
+		t.Errorf("unexpected stats before Close: %+v", stats)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("local copy %s still exists after Close", path)
+	}
+	if stats := client.Stats(); stats.LocalFiles != 0 || !stats.Closed {
+		t.Errorf("unexpected stats after Close: %+v", stats)
+	}
+
+	if _, err := client.NewReader(t.Context(), "org", "pipeline", "1", "test-job", time.Minute, false); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("NewReader after Close error = %v, want ErrClientClosed", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+}
+
+func TestClient_IdleCleanup(t *testing.T) {
+	client, _ := newMemClient(t, WithIdleCleanup(time.Hour))
+
+	reader, err := client.NewReader(t.Context(), "org", "pipeline", "1", "test-job", time.Minute, false)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	path := reader.filename
+	reader.Close()
+
+	client.removeIdleLocalFiles(time.Now())
+	if client.Stats().LocalFiles != 1 {
+		t.Fatal("recently used local copy was removed")
+	}
+
+	client.removeIdleLocalFiles(time.Now().Add(2 * time.Hour))
+	if client.Stats().LocalFiles != 0 {
+		t.Error("idle local copy is still tracked")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("idle local copy %s still exists", path)
+	}
+}
+
+func TestClient_CloseKeepsLocalCopiesOfOtherClients(t *testing.T) {
+	first, dir := newMemClient(t)
+	second, err := NewClientWithAPI(t.Context(), newTerminalMock(), "mem://", WithLocalCacheDir(dir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { second.Close() })
+
+	reader, err := first.NewReader(t.Context(), "org", "pipeline", "1", "test-job", time.Minute, false)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	firstPath := reader.filename
+	reader.Close()
+
+	// The second client reads the same copy, as another process sharing the directory would
+	blobKey := GenerateBlobKey("org", "pipeline", "1", "test-job")
+	version, err := first.blobStorage.ContentVersion(t.Context(), blobKey)
+	if err != nil {
+		t.Fatalf("ContentVersion failed: %v", err)
+	}
+	shared := localCachePath(dir, blobKey, version)
+	secondPath, err := second.leaseLocalFile(shared)
+	if err != nil {
+		t.Fatalf("leaseLocalFile failed: %v", err)
+	}
+	if secondPath == firstPath || secondPath == shared {
+		t.Fatalf("second client reads %s, want a link of its own", secondPath)
+	}
+
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(firstPath); !os.IsNotExist(err) {
+		t.Errorf("first client's link %s still exists after Close", firstPath)
+	}
+	if _, err := os.Stat(shared); err != nil {
+		t.Errorf("copy linked by the second client was removed: %v", err)
+	}
+
+	entries := 0
+	for _, err := range NewParquetReader(secondPath).ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("second client's reader failed after the first closed: %v", err)
+		}
+		entries++
+	}
+	if entries == 0 {
+		t.Error("second client's reader returned no entries")
+	}
+
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	for _, path := range []string{secondPath, shared} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after both clients closed", path)
+		}
+	}
+}
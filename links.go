@@ -0,0 +1,39 @@
+package buildkitelogs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LineURL returns a link to the log line of row in the Buildkite web UI. webURL is the
+// job's web URL (JobStatus.WebURL), which identifies the job in its fragment; the line
+// is appended to the fragment, as in https://buildkite.com/org/pipeline/builds/1#job/42.
+// Without a fragment webURL is returned unchanged, which still opens the build.
+//
+// Lines are counted from 1 in parsed entries, which match the UI's lines except where
+// the parser splits or joins raw lines.
+func LineURL(webURL string, row int64) string {
+	base, fragment, ok := strings.Cut(webURL, "#")
+	if !ok || fragment == "" {
+		return webURL
+	}
+	// Drop a line already in the fragment, so links can be built from other links
+	job, _, _ := strings.Cut(fragment, "/")
+	return fmt.Sprintf("%s#%s/%d", base, job, row+1)
+}
+
+// JobWebURL returns the web URL of a job on buildkite.com, for callers without the
+// JobStatus.WebURL reported by the API.
+func JobWebURL(org, pipeline, build, job string) string {
+	return fmt.Sprintf("https://buildkite.com/%s/%s/builds/%s#%s", org, pipeline, build, job)
+}
+
+// withLineURLs wraps yield to set SearchResult.URL to the line of each match
+func withLineURLs(yield func(SearchResult, error) bool, webURL string) func(SearchResult, error) bool {
+	return func(result SearchResult, err error) bool {
+		if err == nil {
+			result.URL = LineURL(webURL, result.Match.RowNumber)
+		}
+		return yield(result, err)
+	}
+}
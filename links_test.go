@@ -0,0 +1,58 @@
+package buildkitelogs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLineURL(t *testing.T) {
+	tests := []struct {
+		webURL string
+		row    int64
+		want   string
+	}{
+		{"https://buildkite.com/org/pipe/builds/1#job-1", 0, "https://buildkite.com/org/pipe/builds/1#job-1/1"},
+		{"https://buildkite.com/org/pipe/builds/1#job-1", 41, "https://buildkite.com/org/pipe/builds/1#job-1/42"},
+		{"https://buildkite.com/org/pipe/builds/1#job-1/7", 41, "https://buildkite.com/org/pipe/builds/1#job-1/42"},
+		{"https://buildkite.com/org/pipe/builds/1", 41, "https://buildkite.com/org/pipe/builds/1"},
+		{"", 41, ""},
+	}
+	for _, tt := range tests {
+		if got := LineURL(tt.webURL, tt.row); got != tt.want {
+			t.Errorf("LineURL(%q, %d) = %q, want %q", tt.webURL, tt.row, got, tt.want)
+		}
+	}
+
+	if got, want := JobWebURL("org", "pipe", "1", "job-1"), "https://buildkite.com/org/pipe/builds/1#job-1"; got != want {
+		t.Errorf("JobWebURL = %q, want %q", got, want)
+	}
+}
+
+func TestSearchJobURL(t *testing.T) {
+	log := "\x1b_bk;t=1745322209921\x07first\n" +
+		"\x1b_bk;t=1745322209922\x07ERROR one\n" +
+		"\x1b_bk;t=1745322209923\x07second\n" +
+		"\x1b_bk;t=1745322209924\x07ERROR two\n"
+	webURL := "https://buildkite.com/org/pipe/builds/1#job-1"
+
+	var urls []string
+	for result, err := range SearchRawLog(strings.NewReader(log), SearchOptions{Pattern: "ERROR", JobURL: webURL}) {
+		if err != nil {
+			t.Fatalf("search failed: %v", err)
+		}
+		urls = append(urls, result.URL)
+	}
+	want := []string{webURL + "/2", webURL + "/4"}
+	if strings.Join(urls, " ") != strings.Join(want, " ") {
+		t.Errorf("URLs = %v, want %v", urls, want)
+	}
+
+	for result, err := range SearchRawLog(strings.NewReader(log), SearchOptions{Pattern: "ERROR"}) {
+		if err != nil {
+			t.Fatalf("search failed: %v", err)
+		}
+		if result.URL != "" {
+			t.Errorf("URL = %q without SearchOptions.JobURL", result.URL)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package buildkitelogs
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+)
+
+// GroupSort orders the groups returned by ListGroups.
+type GroupSort string
+
+const (
+	GroupSortFirstSeen GroupSort = "first-seen" // Earliest first
+	GroupSortEntries   GroupSort = "entries"    // Most entries first
+	GroupSortDuration  GroupSort = "duration"   // Longest span between first and last entry first
+)
+
+// Duration returns the time between the group's first and last entries.
+func (g GroupInfo) Duration() time.Duration {
+	return g.LastSeen.Sub(g.FirstSeen)
+}
+
+// ListGroups returns statistics for every group in the file, ordered by first appearance.
+func (pr *ParquetReader) ListGroups(ctx context.Context) ([]GroupInfo, error) {
+	groups, _, err := ListGroupsFromEntries(pr.viewEntries(readParquetFileIter(ctx, pr.source, nil)))
+	return groups, err
+}
+
+// ListGroupsFromEntries builds per-group statistics from entries, ordered by first
+// appearance, and returns them with the number of entries read. Entries without a group
+// are collected under the empty name. A group's parent and depth are those of its first
+// entry.
+func ListGroupsFromEntries(entries iter.Seq2[ParquetLogEntry, error]) ([]GroupInfo, int, error) {
+	var groups []GroupInfo
+	index := make(map[string]int)
+	total := 0
+
+	for entry, err := range entries {
+		if err != nil {
+			return nil, 0, fmt.Errorf("error reading entries: %w", err)
+		}
+		total++
+
+		entryTime := time.UnixMilli(entry.Timestamp)
+		i, ok := index[entry.Group]
+		if !ok {
+			i = len(groups)
+			index[entry.Group] = i
+			groups = append(groups, GroupInfo{
+				Name:        entry.Group,
+				ParentGroup: entry.ParentGroup,
+				Depth:       entry.Depth,
+				FirstSeen:   entryTime,
+				LastSeen:    entryTime,
+			})
+		}
+
+		info := &groups[i]
+		info.EntryCount++
+		if entryTime.Before(info.FirstSeen) {
+			info.FirstSeen = entryTime
+		}
+		if entryTime.After(info.LastSeen) {
+			info.LastSeen = entryTime
+		}
+	}
+
+	slices.SortStableFunc(groups, compareFirstSeen)
+	return groups, total, nil
+}
+
+// SortGroups sorts groups in place. Ties keep their existing order.
+func SortGroups(groups []GroupInfo, by GroupSort) error {
+	var compare func(a, b GroupInfo) int
+	switch by {
+	case GroupSortFirstSeen, "":
+		compare = compareFirstSeen
+	case GroupSortEntries:
+		compare = func(a, b GroupInfo) int { return cmp.Compare(b.EntryCount, a.EntryCount) }
+	case GroupSortDuration:
+		compare = func(a, b GroupInfo) int { return cmp.Compare(b.Duration(), a.Duration()) }
+	default:
+		return fmt.Errorf("unknown group sort %q (want %s, %s or %s)", by, GroupSortFirstSeen, GroupSortEntries, GroupSortDuration)
+	}
+	slices.SortStableFunc(groups, compare)
+	return nil
+}
+
+func compareFirstSeen(a, b GroupInfo) int {
+	return a.FirstSeen.Compare(b.FirstSeen)
+}
+
+// GroupNode is a group in the tree of nested groups built by GroupTree.
+type GroupNode struct {
+	GroupInfo
+	Children []*GroupNode `json:"children,omitempty"`
+}
+
+// GroupTree nests groups under their parents, keeping the order of groups among their
+// siblings, and returns the top-level groups. Groups whose parent is not in groups, such
+// as after SortGroups and a cut to the top groups, are returned at the top level.
+func GroupTree(groups []GroupInfo) []*GroupNode {
+	nodes := make([]*GroupNode, len(groups))
+	byName := make(map[string]*GroupNode, len(groups))
+	for i, group := range groups {
+		nodes[i] = &GroupNode{GroupInfo: group}
+		if _, ok := byName[group.Name]; !ok {
+			byName[group.Name] = nodes[i]
+		}
+	}
+
+	var roots []*GroupNode
+	for _, node := range nodes {
+		// Parents are shallower than their children, which also rules out cycles
+		parent, ok := byName[node.ParentGroup]
+		if node.ParentGroup == "" || !ok || parent.Depth >= node.Depth {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+	return roots
+}
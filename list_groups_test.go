@@ -0,0 +1,116 @@
+package buildkitelogs
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestListGroupsFromEntries(t *testing.T) {
+	entries := []ParquetLogEntry{
+		{Timestamp: 1000, Group: "Setup"},
+		{Timestamp: 1100, Group: "Tests"},
+		{Timestamp: 1200, Group: "Tests"},
+		{Timestamp: 9000, Group: "Tests"},
+		{Timestamp: 1300, Group: "Setup"},
+		{Timestamp: 9500, Group: "Cleanup"},
+	}
+
+	seq := func(yield func(ParquetLogEntry, error) bool) {
+		for _, entry := range entries {
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+
+	groups, total, err := ListGroupsFromEntries(seq)
+	if err != nil {
+		t.Fatalf("ListGroupsFromEntries failed: %v", err)
+	}
+	if total != len(entries) {
+		t.Errorf("total = %d, want %d", total, len(entries))
+	}
+	if got := groupNames(groups); !slices.Equal(got, []string{"Setup", "Tests", "Cleanup"}) {
+		t.Errorf("first-seen order = %v", got)
+	}
+
+	if err := SortGroups(groups, GroupSortEntries); err != nil {
+		t.Fatalf("SortGroups failed: %v", err)
+	}
+	if got := groupNames(groups); !slices.Equal(got, []string{"Tests", "Setup", "Cleanup"}) {
+		t.Errorf("entries order = %v", got)
+	}
+
+	if err := SortGroups(groups, GroupSortDuration); err != nil {
+		t.Fatalf("SortGroups failed: %v", err)
+	}
+	if got := groupNames(groups); !slices.Equal(got, []string{"Tests", "Setup", "Cleanup"}) {
+		t.Errorf("duration order = %v", got)
+	}
+	if d := groups[0].Duration().Milliseconds(); d != 7900 {
+		t.Errorf("Tests duration = %dms, want 7900ms", d)
+	}
+
+	if err := SortGroups(groups, "alphabetical"); err == nil {
+		t.Error("expected error for unknown sort order")
+	}
+}
+
+func groupNames(groups []GroupInfo) []string {
+	names := make([]string, len(groups))
+	for i, g := range groups {
+		names[i] = g.Name
+	}
+	return names
+}
+
+func TestGroupTree(t *testing.T) {
+	groups := []GroupInfo{
+		{Name: "~~~ Setup"},
+		{Name: "~~~ Running commands"},
+		{Name: "--- Build", ParentGroup: "~~~ Running commands", Depth: 1},
+		{Name: "--- Test", ParentGroup: "~~~ Running commands", Depth: 1},
+		{Name: "--- Orphan", ParentGroup: "~~~ Missing", Depth: 1},
+	}
+
+	roots := GroupTree(groups)
+	var names []string
+	for _, root := range roots {
+		names = append(names, root.Name)
+	}
+	if !slices.Equal(names, []string{"~~~ Setup", "~~~ Running commands", "--- Orphan"}) {
+		t.Fatalf("roots = %v", names)
+	}
+	children := roots[1].Children
+	if len(children) != 2 || children[0].Name != "--- Build" || children[1].Name != "--- Test" {
+		t.Errorf("children of %s = %+v", roots[1].Name, children)
+	}
+}
+
+func TestListGroups_Nesting(t *testing.T) {
+	log := "~~~ Running commands\n--- Build\ngo build\n--- Test\ngo test\n"
+	path := filepath.Join(t.TempDir(), "nested.parquet")
+	if err := ExportSeq2ToParquet(logparser.New().All(strings.NewReader(log)), path); err != nil {
+		t.Fatalf("ExportSeq2ToParquet failed: %v", err)
+	}
+
+	groups, err := NewParquetReader(path).ListGroups(t.Context())
+	if err != nil {
+		t.Fatalf("ListGroups failed: %v", err)
+	}
+	if len(groups) != 3 {
+		t.Fatalf("groups = %+v, want 3", groups)
+	}
+	for _, group := range groups[1:] {
+		if group.ParentGroup != "~~~ Running commands" || group.Depth != 1 {
+			t.Errorf("group %q has parent %q at depth %d", group.Name, group.ParentGroup, group.Depth)
+		}
+	}
+	if groups[0].ParentGroup != "" || groups[0].Depth != 0 {
+		t.Errorf("top-level group has parent %q at depth %d", groups[0].ParentGroup, groups[0].Depth)
+	}
+}
@@ -0,0 +1,55 @@
+package buildkitelogs
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// LogWriter receives parsed log entries in batches. ParquetWriter writes them to a
+// Parquet file and LokiSink ships them to Grafana Loki. Close flushes anything still
+// buffered; a LogWriter must not be used after Close.
+type LogWriter interface {
+	WriteBatch(entries []*logparser.Entry) error
+	Close() error
+}
+
+// logWriterBatchSize is the number of entries ExportSeq2ToLogWriter passes to each
+// WriteBatch call.
+const logWriterBatchSize = 1000
+
+// ExportSeq2ToLogWriter writes the entries of seq accepted by filterFunc (nil accepts
+// all) to w in batches and returns the number written. It does not close w.
+func ExportSeq2ToLogWriter(seq iter.Seq2[*logparser.Entry, error], w LogWriter, filterFunc func(*logparser.Entry) bool) (int, error) {
+	batch := make([]*logparser.Entry, 0, logWriterBatchSize)
+	rows := 0
+
+	for entry, err := range seq {
+		if err != nil {
+			return rows, fmt.Errorf("error during iteration: %w", err)
+		}
+
+		if filterFunc != nil && !filterFunc(entry) {
+			continue
+		}
+
+		batch = append(batch, entry)
+		rows++
+
+		if len(batch) >= logWriterBatchSize {
+			if err := w.WriteBatch(batch); err != nil {
+				return rows, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := w.WriteBatch(batch); err != nil {
+			return rows, err
+		}
+	}
+
+	return rows, nil
+}
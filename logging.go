@@ -0,0 +1,53 @@
+package buildkitelogs
+
+import (
+	"log/slog"
+)
+
+// discardLogger is the logger of clients and readers given none, which logs nothing.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// WithLogger makes the client log its diagnostics to logger: how each job log was served
+// and job status retries at debug level, and failures it works around, such as a corrupt
+// cached log downloaded again, a stale refresh lock taken over or a local copy it could
+// not remove, at warn level. Readers returned by the client log to it too, see
+// ParquetReader.WithLogger. A nil logger logs nothing, the default.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = orDiscard(logger)
+	}
+}
+
+// WithLogger makes the reader log diagnostics to logger, such as a group index it could
+// not read and replaced with a scan of the whole file. A nil logger logs nothing, the
+// default. It returns the reader.
+func (pr *ParquetReader) WithLogger(logger *slog.Logger) *ParquetReader {
+	pr.logger = orDiscard(logger)
+	return pr
+}
+
+// log returns the reader's logger.
+func (pr *ParquetReader) log() *slog.Logger {
+	return orDiscard(pr.logger)
+}
+
+// logUnreadableGroupIndex logs that the group index of the reader's file could not be read
+// and the file is scanned instead. Files written without an index are not logged.
+func (pr *ParquetReader) logUnreadableGroupIndex(err error) {
+	if err != nil {
+		pr.log().Warn("unreadable group index, scanning the whole file", "file", pr.filename, "error", err)
+	}
+}
+
+// orDiscard returns logger, or discardLogger if it is nil.
+func orDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return discardLogger
+	}
+	return logger
+}
+
+// jobAttrs returns the attributes identifying a job in log records.
+func jobAttrs(org, pipeline, build, job string) slog.Attr {
+	return slog.Group("job", "org", org, "pipeline", pipeline, "build", build, "id", job)
+}
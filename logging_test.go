@@ -0,0 +1,73 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// logRecords returns the messages of the JSON log records in buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for line := range strings.Lines(buf.String()) {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log record %q is not JSON: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestClient_WithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	api := newTerminalMock()
+	client := newTestClient(t, api, WithLogger(logger))
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"}
+
+	if _, err := client.DownloadAndCache(t.Context(), location, 0, false); err != nil {
+		t.Fatalf("DownloadAndCache failed: %v", err)
+	}
+	// Truncate the cached file, so the next read finds it corrupt
+	blobKey := GenerateBlobKey("org", "pipeline", "1", "job")
+	metadata, err := client.blobStorage.ReadWithMetadata(t.Context(), blobKey)
+	if err != nil {
+		t.Fatalf("ReadWithMetadata failed: %v", err)
+	}
+	if err := client.blobStorage.WriteWithMetadata(t.Context(), blobKey, []byte("PAR1"), metadata); err != nil {
+		t.Fatalf("WriteWithMetadata failed: %v", err)
+	}
+	if _, err := client.DownloadAndCache(t.Context(), location, 0, false); err != nil {
+		t.Fatalf("DownloadAndCache failed: %v", err)
+	}
+
+	var messages []string
+	for _, record := range logRecords(t, &buf) {
+		messages = append(messages, record["level"].(string)+" "+record["msg"].(string))
+		if job, ok := record["job"].(map[string]any); !ok || job["id"] != "job" {
+			t.Errorf("record %v does not identify the job", record)
+		}
+	}
+	want := []string{
+		"DEBUG served job log",
+		"WARN cached job log is corrupt, downloading it again",
+		"DEBUG served job log",
+	}
+	if strings.Join(messages, "\n") != strings.Join(want, "\n") {
+		t.Errorf("logged %q, want %q", messages, want)
+	}
+}
+
+func TestClient_NoLoggerLogsNothing(t *testing.T) {
+	client := newTestClient(t, newTerminalMock(), WithLogger(nil))
+	if client.logger == nil || client.logger.Enabled(t.Context(), slog.LevelError) {
+		t.Error("client without a logger should discard records")
+	}
+	if NewParquetReader("x.parquet").log().Enabled(t.Context(), slog.LevelError) {
+		t.Error("reader without a logger should discard records")
+	}
+}
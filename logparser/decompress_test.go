@@ -0,0 +1,83 @@
+package logparser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const decompressTestLog = "\x1b_bk;t=1745322209921\x07~~~ Setup\nhello\n"
+
+// bzip2DecompressTestLog is decompressTestLog compressed with bzip2
+const bzip2DecompressTestLog = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\x0d\xec\xd8\x5c\x00\x00\x01\xfb\x80\x00\x90\x00\x08\x40\x00\x7e\xaa\x08\x00\x92\x4c\xc6\x01\x20\x00\x22\x20\x4c\x9a\x7a\x1a\x6a\x63\x46\x85\x00\x00\x06\x4c\x8f\x34\x90\x59\x85\xda\x4d\xa5\x88\x86\x24\x9c\x33\xce\xf4\x69\xbe\x02\xab\xc5\x01\x7a\x2e\xe4\x8a\x70\xa1\x20\x1b\xd9\xb0\xb8"
+
+func TestNewDecompressingReader(t *testing.T) {
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	_, _ = gz.Write([]byte(decompressTestLog))
+	_ = gz.Close()
+
+	zstdEncoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd.NewWriter() error = %v", err)
+	}
+	zstdData := zstdEncoder.EncodeAll([]byte(decompressTestLog), nil)
+	_ = zstdEncoder.Close()
+
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"plain", []byte(decompressTestLog)},
+		{"gzip", gzipped.Bytes()},
+		{"zstd", zstdData},
+		{"bzip2", []byte(bzip2DecompressTestLog)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewDecompressingReader(bytes.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("NewDecompressingReader() error = %v", err)
+			}
+			defer reader.Close()
+
+			var entries []*Entry
+			for entry, err := range New().All(reader) {
+				if err != nil {
+					t.Fatalf("All() error = %v", err)
+				}
+				entries = append(entries, entry)
+			}
+			if len(entries) != 2 || entries[0].Content != "~~~ Setup" || entries[1].Group != "~~~ Setup" {
+				t.Fatalf("entries = %+v, want the setup group and its line", entries)
+			}
+		})
+	}
+}
+
+func TestNewDecompressingReaderShortInput(t *testing.T) {
+	for _, input := range []string{"", "a", "\x1f"} {
+		reader, err := NewDecompressingReader(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("NewDecompressingReader(%q) error = %v", input, err)
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil || string(data) != input {
+			t.Errorf("read %q, %v; want %q", data, err, input)
+		}
+	}
+}
+
+func TestNewDecompressingReaderCorruptGzip(t *testing.T) {
+	reader, err := NewDecompressingReader(strings.NewReader("\x1f\x8bnot gzip"))
+	if err == nil {
+		_, err = io.ReadAll(reader)
+	}
+	if err == nil {
+		t.Error("corrupt gzip input read without error")
+	}
+}
@@ -11,6 +11,13 @@ type Entry struct {
 	Content   string // Parsed content after OSC processing, may still contain ANSI codes.
 	RawLine   []byte // Parsed line bytes excluding the trailing newline; truncated lines include the suffix.
 	Group     string // The current section/group this entry belongs to.
+	// ParentGroup is the group Group is nested in, "" for a top-level group, and Depth
+	// the nesting depth of Group, from 0. See the Parser for how groups nest.
+	ParentGroup string
+	Depth       int
+	// InvalidTimestamp is set when the OSC timestamp was outside the parser's bounds and
+	// was dropped, see WithTimestampBounds.
+	InvalidTimestamp bool
 }
 
 type LogFlag int32
@@ -18,6 +25,7 @@ type LogFlag int32
 const (
 	HasTimestamp LogFlag = iota
 	IsGroup
+	InvalidTimestamp // The OSC timestamp was out of bounds and dropped
 )
 
 // LogFlags represents a bitwise combination of log flags.
@@ -53,6 +61,11 @@ func (lf LogFlags) IsGroup() bool {
 	return lf.Has(IsGroup)
 }
 
+// HasInvalidTimestamp returns true if InvalidTimestamp flag is set.
+func (lf LogFlags) HasInvalidTimestamp() bool {
+	return lf.Has(InvalidTimestamp)
+}
+
 // HasTimestamp returns true if the log entry has a valid timestamp.
 func (entry *Entry) HasTimestamp() bool {
 	return !entry.Timestamp.IsZero()
@@ -79,5 +92,8 @@ func (entry *Entry) ComputeFlags() LogFlags {
 	if entry.IsGroup() {
 		flags.Set(IsGroup)
 	}
+	if entry.InvalidTimestamp {
+		flags.Set(InvalidTimestamp)
+	}
 	return flags
 }
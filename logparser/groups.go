@@ -0,0 +1,55 @@
+package logparser
+
+import "strings"
+
+// groupRank returns the nesting rank of a group header: "~~~ " headers, which the agent
+// writes for each phase of a job such as "~~~ Running commands", rank 0, and "--- " and
+// "+++ " headers rank 1. Other group names, such as an initial group that is no header,
+// rank 0.
+func groupRank(group string) int {
+	if strings.HasPrefix(group, "--- ") || strings.HasPrefix(group, "+++ ") {
+		return 1
+	}
+	return 0
+}
+
+// groupStack tracks the open groups of a log, outermost first. A group header closes the
+// open groups of the same or a higher rank and opens a group nested in the rest, so
+// "--- " and "+++ " headers nest in the "~~~ " group before them, and a "~~~ " header
+// starts a new top-level group. A header repeating the name of an earlier group re-opens
+// a group of that name at its place in the stack. Lines such as "^^^ +++", which expand
+// the previous group in the Buildkite UI, are no headers and leave the stack as it is.
+type groupStack []string
+
+// newGroupStack returns the stack of a log continuing inside group, nested in parent.
+func newGroupStack(parent, group string) groupStack {
+	var stack groupStack
+	if parent != "" && group != "" {
+		stack = append(stack, parent)
+	}
+	if group != "" {
+		stack = append(stack, group)
+	}
+	return stack
+}
+
+// open closes the groups the header ends and opens it.
+func (s *groupStack) open(header string) {
+	rank := groupRank(header)
+	stack := *s
+	for len(stack) > 0 && groupRank(stack[len(stack)-1]) >= rank {
+		stack = stack[:len(stack)-1]
+	}
+	*s = append(stack, header)
+}
+
+// current returns the innermost open group, its parent and its depth, from 0.
+func (s groupStack) current() (group, parent string, depth int) {
+	switch len(s) {
+	case 0:
+		return "", "", 0
+	case 1:
+		return s[0], "", 0
+	}
+	return s[len(s)-1], s[len(s)-2], len(s) - 1
+}
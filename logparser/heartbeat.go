@@ -0,0 +1,29 @@
+package logparser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// heartbeatRegex matches a whole line of agent heartbeat/keepalive noise, optionally
+// prefixed by bracketed tags or a "source:" label: "[agent] Heartbeat sent",
+// "agent: keepalive", "keep-alive ping" or a bare "pong". Lines that go on to say
+// anything else, e.g. "Heartbeat failed: agent lost connection", do not match.
+var heartbeatRegex = regexp.MustCompile(`(?i)^(?:\[[^\]]*\]\s*)*(?:[\w.-]+:\s*)?(?:heartbeat(?:\s+(?:sent|ok|received|acknowledged))?|keep-?alive(?:\s+(?:sent|ok|ping|pong))?|ping|pong)[.!]?$`)
+
+// colorCodeRegex matches SGR color sequences that may wrap heartbeat lines.
+var colorCodeRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// IsHeartbeat reports whether content is a periodic heartbeat or keepalive line. Lines
+// InferSeverity rates as warnings or errors, e.g. "[error] heartbeat", are never
+// heartbeats, so dropping heartbeats cannot hide a failure.
+func IsHeartbeat(content string) bool {
+	content = strings.TrimSpace(stripColor(content))
+	return heartbeatRegex.MatchString(content) && InferSeverity(content) < SeverityWarn
+}
+
+// IsHeartbeat returns true if the log entry is heartbeat/keepalive noise.
+// Group headers are never treated as heartbeats.
+func (entry *Entry) IsHeartbeat() bool {
+	return !entry.IsGroup() && IsHeartbeat(entry.Content)
+}
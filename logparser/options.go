@@ -1,10 +1,24 @@
 package logparser
 
+import (
+	"io"
+	"time"
+)
+
 const (
 	DefaultBufferSize       = 64 * 1024
 	DefaultMaxLineBytes     = 8 * 1024 * 1024
 	DefaultContextBytes     = 64
 	DefaultTruncationSuffix = "... [truncated]"
+	// DefaultQuarantineMaxBytes caps the records written to a quarantine, see WithQuarantine.
+	DefaultQuarantineMaxBytes = 1024 * 1024
+)
+
+var (
+	// DefaultMinTimestamp and DefaultMaxTimestamp bound the OSC timestamps the parser
+	// accepts, see WithTimestampBounds.
+	DefaultMinTimestamp = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	DefaultMaxTimestamp = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
 )
 
 // Options configures parser and line-reader behavior.
@@ -14,6 +28,16 @@ type Options struct {
 	TruncateLongLines bool
 	TruncationSuffix  string
 	ContextBytes      int
+	MinTimestamp      time.Time // Earliest plausible OSC timestamp
+	MaxTimestamp      time.Time // Latest plausible OSC timestamp
+	// Quarantine receives the lines that did not parse cleanly, see WithQuarantine.
+	Quarantine         io.Writer
+	QuarantineMaxBytes int64
+	// InitialGroup is the group of entries before the first group header, and
+	// InitialParentGroup the group it is nested in, see WithInitialGroup and
+	// WithInitialParentGroup.
+	InitialGroup       string
+	InitialParentGroup string
 }
 
 // Option customizes parser behavior.
@@ -35,6 +59,8 @@ func DefaultOptions() Options {
 		MaxLineBytes:     DefaultMaxLineBytes,
 		TruncationSuffix: DefaultTruncationSuffix,
 		ContextBytes:     DefaultContextBytes,
+		MinTimestamp:     DefaultMinTimestamp,
+		MaxTimestamp:     DefaultMaxTimestamp,
 	}
 }
 
@@ -84,6 +110,47 @@ func WithContextBytes(size int) Option {
 	})
 }
 
+// WithTimestampBounds sets the window OSC timestamps must fall within. Entries whose
+// timestamps fall outside it, such as seconds mistaken for milliseconds, are parsed as
+// content without a timestamp and flagged with InvalidTimestamp, so absurd dates don't
+// break time-range queries. A zero bound keeps its default.
+func WithTimestampBounds(minTime, maxTime time.Time) Option {
+	return optionFunc(func(opts *Options) {
+		opts.MinTimestamp = minTime
+		opts.MaxTimestamp = maxTime
+	})
+}
+
+// WithQuarantine writes every line that did not parse cleanly to w, verbatim with its
+// line number and reason, so parser problems can be reproduced from real logs: lines
+// with an out-of-bounds or malformed OSC timestamp, truncated lines, and the context of
+// a line too long to parse. Records are tab separated, "<line>\t<reason>\t<bytes>\n".
+// Writing stops after maxBytes (0 = DefaultQuarantineMaxBytes), ending with a comment
+// counting the lines left out. Write errors stop the quarantine but not parsing. Each
+// iteration of All writes its own records, so don't share w between concurrent ones.
+func WithQuarantine(w io.Writer, maxBytes int64) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Quarantine = w
+		opts.QuarantineMaxBytes = maxBytes
+	})
+}
+
+// WithInitialGroup sets the group of the entries before the first group header, for
+// parsing the continuation of a log whose earlier part ended inside group.
+func WithInitialGroup(group string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.InitialGroup = group
+	})
+}
+
+// WithInitialParentGroup sets the group that the initial group of WithInitialGroup is
+// nested in, so the continuation of a log keeps the nesting of its earlier part.
+func WithInitialParentGroup(group string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.InitialParentGroup = group
+	})
+}
+
 func normalizeOptions(opts Options) Options {
 	defaults := DefaultOptions()
 	if opts.BufferSize <= 0 {
@@ -95,6 +162,15 @@ func normalizeOptions(opts Options) Options {
 	if opts.TruncationSuffix == "" {
 		opts.TruncationSuffix = defaults.TruncationSuffix
 	}
+	if opts.MinTimestamp.IsZero() {
+		opts.MinTimestamp = defaults.MinTimestamp
+	}
+	if opts.MaxTimestamp.IsZero() {
+		opts.MaxTimestamp = defaults.MaxTimestamp
+	}
+	if opts.QuarantineMaxBytes <= 0 {
+		opts.QuarantineMaxBytes = DefaultQuarantineMaxBytes
+	}
 	if opts.ContextBytes < 0 {
 		opts.ContextBytes = 0
 	}
@@ -9,12 +9,21 @@ import (
 	"time"
 )
 
+// Version identifies the entries the parser produces. It is increased whenever a change
+// alters how the same log is parsed, so files written by older parsers can be found and
+// re-parsed.
+const Version = 3
+
 var oscStart = []byte{0x1b, '_', 'b', 'k', ';', 't', '='}
 
 // Parser handles Buildkite log parsing and group tracking.
+//
+// Groups nest in two levels: "--- " and "+++ " headers open a group nested in the last
+// "~~~ " group, such as "~~~ Running commands", and a "~~~ " header starts a new
+// top-level group. Entries record their group's parent and depth.
 type Parser struct {
-	opts         Options
-	currentGroup string
+	opts   Options
+	groups groupStack
 }
 
 func New(options ...Option) *Parser {
@@ -22,11 +31,18 @@ func New(options ...Option) *Parser {
 }
 
 func newParserWithOptions(opts Options) *Parser {
+	opts = normalizeOptions(opts)
 	return &Parser{
-		opts: normalizeOptions(opts),
+		opts:   opts,
+		groups: newGroupStack(opts.InitialParentGroup, opts.InitialGroup),
 	}
 }
 
+// Options returns the options the parser was created with, after defaults are applied.
+func (p *Parser) Options() Options {
+	return p.opts
+}
+
 func (p *Parser) ParseLine(line string) (*Entry, error) {
 	return p.ParseLineBytes([]byte(line), Line{})
 }
@@ -42,9 +58,9 @@ func (p *Parser) ParseLineBytes(line []byte, meta Line) (*Entry, error) {
 	}
 
 	if entry.IsGroup() {
-		p.currentGroup = entry.Content
+		p.groups.open(entry.Content)
 	}
-	entry.Group = p.currentGroup
+	entry.Group, entry.ParentGroup, entry.Depth = p.groups.current()
 
 	return entry, nil
 }
@@ -55,6 +71,8 @@ func (p *Parser) All(reader io.Reader) iter.Seq2[*Entry, error] {
 	return func(yield func(*Entry, error) bool) {
 		localParser := newParserWithOptions(p.opts)
 		lineReader := newLineReaderWithOptions(reader, p.opts)
+		quarantine := newQuarantine(p.opts)
+		defer quarantine.close()
 
 		for {
 			line, err := lineReader.Next()
@@ -62,6 +80,7 @@ func (p *Parser) All(reader io.Reader) iter.Seq2[*Entry, error] {
 				return
 			}
 			if err != nil {
+				quarantine.addError(err)
 				_ = yield(nil, err)
 				return
 			}
@@ -71,6 +90,7 @@ func (p *Parser) All(reader io.Reader) iter.Seq2[*Entry, error] {
 				_ = yield(nil, err)
 				return
 			}
+			quarantine.addEntry(line, entry)
 			if !yield(entry, nil) {
 				return
 			}
@@ -110,8 +130,17 @@ func parseLine(line []byte, meta Line, opts Options) (*Entry, error) {
 
 	content := contentAfterBEL(line, timestampEnd)
 
+	// Compare in milliseconds: implausible values would overflow time.Unix nanoseconds
+	if timestampMs < opts.MinTimestamp.UnixMilli() || timestampMs > opts.MaxTimestamp.UnixMilli() {
+		return &Entry{
+			Content:          string(content),
+			RawLine:          raw,
+			InvalidTimestamp: true,
+		}, nil
+	}
+
 	return &Entry{
-		Timestamp: time.Unix(0, timestampMs*int64(time.Millisecond)),
+		Timestamp: time.UnixMilli(timestampMs),
 		Content:   string(content),
 		RawLine:   raw,
 	}, nil
@@ -1,11 +1,13 @@
 package logparser
 
 import (
+	"bytes"
 	"errors"
 	"io"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestAllHandlesLinesOverOneMiB(t *testing.T) {
@@ -201,3 +203,281 @@ func TestLineReaderNoFinalNewline(t *testing.T) {
 		t.Fatalf("final error = %v, want EOF", err)
 	}
 }
+
+func TestIsHeartbeat(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"Heartbeat sent", true},
+		{"  heartbeat ok", true},
+		{"[agent] Heartbeat", true},
+		{"[2025-04-22 21:43:29] [agent] keepalive", true},
+		{"agent: keep-alive ping", true},
+		{"\x1b[90mheartbeat\x1b[0m", true},
+		{"pong", true},
+		{"Heartbeat failed: agent lost connection", false},
+		{"agent: keepalive timed out, terminating", false},
+		{"[error] heartbeat", false},
+		{"WARN: keepalive", false},
+		{"heartbeat interval set to 5s", false},
+		{"Running heartbeat service tests", false},
+		{"heartbeats_test.go:12: ok", false},
+		{"~~~ Heartbeat checks", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		entry := &Entry{Content: tt.content}
+		if got := entry.IsHeartbeat(); got != tt.want {
+			t.Errorf("IsHeartbeat(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestIsErrorAndWarning(t *testing.T) {
+	tests := []struct {
+		content     string
+		wantError   bool
+		wantWarning bool
+	}{
+		{"ERROR: connection refused", true, false},
+		{"    FAIL: TestParser (0.01s)", true, false},
+		{"panic: runtime error: index out of range", true, false},
+		{"Traceback (most recent call last):", true, false},
+		{"\x1b[31mFatal\x1b[0m: disk full", true, false},
+		{"WARN deprecated flag -x", false, true},
+		{"npm warning: peer dependency missing", false, true},
+		{"ok  	github.com/buildkite/buildkite-logs	0.5s", false, false},
+		{"0 errors, 0 failures", false, false},
+		{"errorless run", false, false},
+		{"~~~ Error handling tests", false, false},
+		{"", false, false},
+	}
+
+	for _, tt := range tests {
+		entry := &Entry{Content: tt.content}
+		if got := entry.IsError(); got != tt.wantError {
+			t.Errorf("IsError(%q) = %v, want %v", tt.content, got, tt.wantError)
+		}
+		if got := entry.IsWarning(); got != tt.wantWarning {
+			t.Errorf("IsWarning(%q) = %v, want %v", tt.content, got, tt.wantWarning)
+		}
+	}
+}
+
+func TestInferSeverity(t *testing.T) {
+	tests := []struct {
+		content string
+		want    Severity
+	}{
+		{"ERROR: connection refused", SeverityError},
+		{"[warn] cache miss", SeverityWarn},
+		{"2025-04-22T21:43:29Z INFO retrying after error", SeverityInfo},
+		{"21:43:29.123 WARNING disk 90% full", SeverityWarn},
+		{"E0422 21:43:29.123456 1 main.go:12] lost connection", SeverityError},
+		{"I0422 21:43:29.123456 1 main.go:12] failed over", SeverityInfo},
+		{`time=2025-04-22 level=error msg="upload failed"`, SeverityError},
+		{`{"level":"debug","msg":"panic handler installed"}`, SeverityInfo},
+		{"    FAIL: TestParser (0.01s)", SeverityError},
+		{"npm warning: peer dependency missing", SeverityWarn},
+		{"\x1b[31mFatal\x1b[0m: disk full", SeverityError},
+		{"exit status 2", SeverityError},
+		{"Process exited with code 137", SeverityError},
+		{"exit status 0", SeverityInfo},
+		{"errorless run", SeverityInfo},
+		{"information: done", SeverityInfo},
+		{"", SeverityInfo},
+	}
+
+	for _, tt := range tests {
+		if got := InferSeverity(tt.content); got != tt.want {
+			t.Errorf("InferSeverity(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+
+	header := &Entry{Content: "~~~ Error handling tests", Group: "~~~ Error handling tests"}
+	if got := header.Severity(); got != SeverityInfo {
+		t.Errorf("group header severity = %v, want info", got)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	if s, err := ParseSeverity("Warning"); err != nil || s != SeverityWarn {
+		t.Errorf("ParseSeverity(Warning) = %v, %v", s, err)
+	}
+	if _, err := ParseSeverity("critical"); !errors.Is(err, ErrUnknownSeverity) {
+		t.Errorf("ParseSeverity(critical) error = %v, want ErrUnknownSeverity", err)
+	}
+
+	var s Severity
+	if err := s.UnmarshalText([]byte("error")); err != nil || s != SeverityError {
+		t.Errorf("UnmarshalText(error) = %v, %v", s, err)
+	}
+	if text, _ := SeverityWarn.MarshalText(); string(text) != "warn" {
+		t.Errorf("MarshalText = %q, want warn", text)
+	}
+}
+
+func TestParserTimestampBounds(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     []Option
+		input       string
+		wantTime    bool
+		wantInvalid bool
+	}{
+		{"in bounds", nil, "\x1b_bk;t=1745322209921\x07content", true, false},
+		{"seconds instead of milliseconds", nil, "\x1b_bk;t=1745322209\x07content", false, true},
+		{"far future", nil, "\x1b_bk;t=9999999999999999\x07content", false, true},
+		{"negative", nil, "\x1b_bk;t=-5\x07content", false, true},
+		{
+			"custom bounds",
+			[]Option{WithTimestampBounds(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{})},
+			"\x1b_bk;t=1745322209921\x07content",
+			false, true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := New(tt.options...).ParseLine(tt.input)
+			if err != nil {
+				t.Fatalf("ParseLine() error = %v", err)
+			}
+			if entry.Content != "content" {
+				t.Errorf("content = %q, want %q", entry.Content, "content")
+			}
+			if entry.HasTimestamp() != tt.wantTime {
+				t.Errorf("HasTimestamp() = %v, want %v", entry.HasTimestamp(), tt.wantTime)
+			}
+			if entry.InvalidTimestamp != tt.wantInvalid || entry.ComputeFlags().HasInvalidTimestamp() != tt.wantInvalid {
+				t.Errorf("InvalidTimestamp = %v, flags %b, want %v", entry.InvalidTimestamp, entry.ComputeFlags(), tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestParserQuarantine(t *testing.T) {
+	input := "\x1b_bk;t=1745322209921\x07ok\n" +
+		"\x1b_bk;t=1745322209\x07seconds\n" +
+		"plain line\n" +
+		"\x1b_bk;t=abc\x07malformed\n" +
+		"\x1b_bk;t=1745322209922\x07" + strings.Repeat("x", 64) + "\n"
+
+	var quarantined bytes.Buffer
+	parser := New(WithMaxLineBytes(48), WithTruncateLongLines(true), WithQuarantine(&quarantined, 0))
+	count := 0
+	for _, err := range parser.All(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		count++
+	}
+	if count != 5 {
+		t.Errorf("parsed %d entries, want all 5", count)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(quarantined.String(), "\n"), "\n")
+	want := []string{
+		"2\tinvalid_timestamp\t\x1b_bk;t=1745322209\x07seconds",
+		"4\tmalformed_timestamp\t\x1b_bk;t=abc\x07malformed",
+		"5\ttruncated\t",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("quarantine = %q, want %d records", quarantined.String(), len(want))
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("record %d = %q, want prefix %q", i, lines[i], prefix)
+		}
+	}
+}
+
+func TestParserQuarantineLimit(t *testing.T) {
+	input := strings.Repeat("\x1b_bk;t=5\x07bad\n", 10)
+
+	var quarantined bytes.Buffer
+	for _, err := range New(WithQuarantine(&quarantined, 40)).All(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+	}
+
+	// Each record is 33 bytes, so one fits under the limit
+	lines := strings.Split(strings.TrimSuffix(quarantined.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "1\tinvalid_timestamp\t") {
+		t.Fatalf("quarantine = %q, want one record and a note", quarantined.String())
+	}
+	if want := "# 9 more lines not quarantined: limit of 40 bytes reached"; lines[1] != want {
+		t.Errorf("note = %q, want %q", lines[1], want)
+	}
+}
+
+func TestParserGroupNesting(t *testing.T) {
+	log := strings.Join([]string{
+		"before any group",
+		"~~~ Preparing working directory",
+		"$ git clone",
+		"~~~ Running commands",
+		"--- :go: Build",
+		"go build",
+		"+++ :test_tube: Tests",
+		"FAIL",
+		"^^^ +++",
+		"~~~ Running global pre-exit hook",
+	}, "\n") + "\n"
+
+	type group struct {
+		group, parent string
+		depth         int
+	}
+	want := []group{
+		{"", "", 0},
+		{"~~~ Preparing working directory", "", 0},
+		{"~~~ Preparing working directory", "", 0},
+		{"~~~ Running commands", "", 0},
+		{"--- :go: Build", "~~~ Running commands", 1},
+		{"--- :go: Build", "~~~ Running commands", 1},
+		{"+++ :test_tube: Tests", "~~~ Running commands", 1},
+		{"+++ :test_tube: Tests", "~~~ Running commands", 1},
+		{"+++ :test_tube: Tests", "~~~ Running commands", 1},
+		{"~~~ Running global pre-exit hook", "", 0},
+	}
+
+	var got []group
+	for entry, err := range New().All(strings.NewReader(log)) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		got = append(got, group{entry.Group, entry.ParentGroup, entry.Depth})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParserInitialParentGroup(t *testing.T) {
+	parser := New(WithInitialGroup("--- Build"), WithInitialParentGroup("~~~ Running commands"))
+
+	entry, err := parser.ParseLine("still building")
+	if err != nil {
+		t.Fatalf("ParseLine() error = %v", err)
+	}
+	if entry.Group != "--- Build" || entry.ParentGroup != "~~~ Running commands" || entry.Depth != 1 {
+		t.Errorf("continued entry = %q in %q at depth %d", entry.Group, entry.ParentGroup, entry.Depth)
+	}
+
+	// A sibling replaces the initial group, nested in the same parent
+	entry, err = parser.ParseLine("--- Test")
+	if err != nil {
+		t.Fatalf("ParseLine() error = %v", err)
+	}
+	if entry.Group != "--- Test" || entry.ParentGroup != "~~~ Running commands" || entry.Depth != 1 {
+		t.Errorf("sibling header = %q in %q at depth %d", entry.Group, entry.ParentGroup, entry.Depth)
+	}
+}
@@ -0,0 +1,87 @@
+package logparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Reasons recorded for quarantined lines.
+const (
+	quarantineInvalidTimestamp   = "invalid_timestamp"
+	quarantineMalformedTimestamp = "malformed_timestamp"
+	quarantineTruncated          = "truncated"
+)
+
+// quarantine writes the lines of one All iteration that did not parse cleanly to
+// Options.Quarantine. A nil quarantine discards everything.
+type quarantine struct {
+	w       io.Writer
+	limit   int64
+	written int64
+	skipped int
+	failed  bool
+}
+
+func newQuarantine(opts Options) *quarantine {
+	if opts.Quarantine == nil {
+		return nil
+	}
+	return &quarantine{w: opts.Quarantine, limit: opts.QuarantineMaxBytes}
+}
+
+// addEntry records line if parsing it dropped or changed part of it.
+func (q *quarantine) addEntry(line Line, entry *Entry) {
+	if q == nil {
+		return
+	}
+	switch {
+	case line.Truncated:
+		q.add(line.Number, quarantineTruncated, line.Bytes)
+	case entry.InvalidTimestamp:
+		q.add(line.Number, quarantineInvalidTimestamp, line.Bytes)
+	case entry.Timestamp.IsZero() && hasOSCStart(line.Bytes):
+		q.add(line.Number, quarantineMalformedTimestamp, line.Bytes)
+	}
+}
+
+// addError records the bytes around a line that failed to parse.
+func (q *quarantine) addError(err error) {
+	var parseErr *ParseError
+	if q == nil || !errors.As(err, &parseErr) || parseErr.Kind != ErrorKindLineTooLong {
+		return
+	}
+	context := append(append([]byte(nil), parseErr.Before...), parseErr.After...)
+	q.add(parseErr.Line, string(parseErr.Kind), context)
+}
+
+func (q *quarantine) add(number int, reason string, raw []byte) {
+	if q.failed {
+		return
+	}
+	record := make([]byte, 0, len(raw)+len(reason)+16)
+	record = strconv.AppendInt(record, int64(number), 10)
+	record = append(record, '\t')
+	record = append(record, reason...)
+	record = append(record, '\t')
+	record = append(record, raw...)
+	record = append(record, '\n')
+	if q.written+int64(len(record)) > q.limit {
+		q.skipped++
+		return
+	}
+	n, err := q.w.Write(record)
+	q.written += int64(n)
+	if err != nil {
+		q.failed = true
+	}
+}
+
+// close notes how many lines were left out once the size limit was reached.
+func (q *quarantine) close() {
+	if q == nil || q.failed || q.skipped == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(q.w, "# %d more lines not quarantined: limit of %d bytes reached\n", q.skipped, q.limit)
+}
@@ -0,0 +1,168 @@
+package logparser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// errorLineRegex matches common error markers, e.g. "ERROR:", "FAIL: TestX",
+// "panic: runtime error" or "Traceback (most recent call last)".
+var errorLineRegex = regexp.MustCompile(`(?i)\b(?:error|fatal|panic|fail|failed|failure|exception|traceback)\b`)
+
+// warningLineRegex matches common warning markers, e.g. "WARN", "warning:" or
+// "DEPRECATION".
+var warningLineRegex = regexp.MustCompile(`(?i)\b(?:warn|warning|deprecated|deprecation)\b`)
+
+// IsErrorLine reports whether content looks like an error message. It is a keyword
+// heuristic, so lines that merely mention an error ("no error found") also match.
+func IsErrorLine(content string) bool {
+	return errorLineRegex.MatchString(stripColor(content))
+}
+
+// IsWarningLine reports whether content looks like a warning message.
+func IsWarningLine(content string) bool {
+	return warningLineRegex.MatchString(stripColor(content))
+}
+
+func stripColor(content string) string {
+	if strings.Contains(content, "\x1b") {
+		return colorCodeRegex.ReplaceAllString(content, "")
+	}
+	return content
+}
+
+// IsError returns true if the log entry looks like an error message. Group headers are
+// never treated as errors.
+func (entry *Entry) IsError() bool {
+	return !entry.IsGroup() && IsErrorLine(entry.Content)
+}
+
+// IsWarning returns true if the log entry looks like a warning message. Group headers
+// are never treated as warnings.
+func (entry *Entry) IsWarning() bool {
+	return !entry.IsGroup() && IsWarningLine(entry.Content)
+}
+
+// Severity is the inferred level of a log entry. Levels are ordered, so entries at or
+// above a minimum level can be selected with >=.
+type Severity int8
+
+const (
+	SeverityUnknown Severity = iota // Not inferred, e.g. read from a file written without severities
+	SeverityInfo
+	SeverityWarn
+	SeverityError
+)
+
+// ErrUnknownSeverity is returned by ParseSeverity for a name that is not a severity.
+var ErrUnknownSeverity = errors.New("unknown severity")
+
+// ParseSeverity returns the severity named info, warn (or warning) or error.
+func ParseSeverity(name string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "info":
+		return SeverityInfo, nil
+	case "warn", "warning":
+		return SeverityWarn, nil
+	case "error":
+		return SeverityError, nil
+	}
+	return SeverityUnknown, fmt.Errorf("%w: %q (want info, warn or error)", ErrUnknownSeverity, name)
+}
+
+// String returns the severity's name.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarn:
+		return "warn"
+	case SeverityError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the severity as its name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity name.
+func (s *Severity) UnmarshalText(text []byte) error {
+	if string(text) == "unknown" {
+		*s = SeverityUnknown
+		return nil
+	}
+	parsed, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// exitStatusRegex matches a non-zero exit status, e.g. "exit status 1", "exited with
+// status 2" or "Exit code: 137".
+var exitStatusRegex = regexp.MustCompile(`(?i)\bexit(?:ed)?(?: with)? (?:status|code):? *[1-9]\d*\b`)
+
+// levelPrefixRegex matches a log-level prefix, optionally after a timestamp: "ERROR:",
+// "[warn]", "2025-04-22T21:43:29Z INFO ...", or a glog header such as "E0422 21:43:29".
+var levelPrefixRegex = regexp.MustCompile(`(?i)^\s*(?:\d{4}-\d\d-\d\d[T ][\d:.,]+(?:Z|[+-]\d\d:?\d\d)?\s+|\d\d:\d\d:\d\d[.,\d]*\s+)?[\[(<]?(trace|debug|info|notice|warn|warning|err|error|fatal|crit|critical|panic)[\])>]?(?::|\s|$)`)
+
+var glogPrefixRegex = regexp.MustCompile(`^([IWEF])\d{4} \d\d:\d\d:\d\d`)
+
+// levelFieldRegex matches the level field of structured logs: level=warn or "level":"warn".
+var levelFieldRegex = regexp.MustCompile(`(?i)\blevel"?\s*[=:]\s*"?(trace|debug|info|notice|warn|warning|err|error|fatal|crit|critical|panic)\b`)
+
+// InferSeverity infers the severity of a log line. A log-level prefix or level field
+// decides it, so "INFO retrying after error" is info; lines without one fall back to
+// IsErrorLine, a non-zero exit status and IsWarningLine. It never returns SeverityUnknown.
+func InferSeverity(content string) Severity {
+	content = stripColor(content)
+	if m := glogPrefixRegex.FindStringSubmatch(content); m != nil {
+		switch m[1] {
+		case "W":
+			return SeverityWarn
+		case "E", "F":
+			return SeverityError
+		}
+		return SeverityInfo
+	}
+	if m := levelPrefixRegex.FindStringSubmatch(content); m != nil {
+		return levelSeverity(m[1])
+	}
+	if m := levelFieldRegex.FindStringSubmatch(content); m != nil {
+		return levelSeverity(m[1])
+	}
+
+	switch {
+	case errorLineRegex.MatchString(content), exitStatusRegex.MatchString(content):
+		return SeverityError
+	case warningLineRegex.MatchString(content):
+		return SeverityWarn
+	}
+	return SeverityInfo
+}
+
+// levelSeverity maps a log level name to its severity
+func levelSeverity(level string) Severity {
+	switch strings.ToLower(level) {
+	case "warn", "warning":
+		return SeverityWarn
+	case "err", "error", "fatal", "crit", "critical", "panic":
+		return SeverityError
+	}
+	return SeverityInfo
+}
+
+// Severity returns the inferred severity of the log entry, see InferSeverity. Group
+// headers are info.
+func (entry *Entry) Severity() Severity {
+	if entry.IsGroup() {
+		return SeverityInfo
+	}
+	return InferSeverity(entry.Content)
+}
@@ -0,0 +1,257 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// LokiPushPath is the path of Loki's push API. It is appended to LokiOptions.URL when the
+// URL has no path.
+const LokiPushPath = "/loki/api/v1/push"
+
+// DefaultLokiBatchSize is the number of entries a LokiSink sends per push.
+const DefaultLokiBatchSize = 1000
+
+// DefaultLokiRetry retries a failed push four times over about 7s, covering Loki rate
+// limiting (429) and restarts.
+var DefaultLokiRetry = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     4 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// LokiOptions configures a LokiSink.
+type LokiOptions struct {
+	URL        string            // Loki base URL (http://loki:3100) or push endpoint
+	Labels     map[string]string // Stream labels of every entry, such as LokiJobLabels; "group" is added per entry
+	TenantID   string            // Sent as X-Scope-OrgID to multi-tenant Loki (optional)
+	BatchSize  int               // Entries per push (default: DefaultLokiBatchSize)
+	Retry      RetryPolicy       // Retries of failed pushes (default: DefaultLokiRetry when MaxAttempts is 0)
+	HTTPClient *http.Client      // Client for pushes (default: http.DefaultClient)
+}
+
+// LokiJobLabels returns the stream labels identifying a Buildkite job: org, pipeline,
+// build and job.
+func LokiJobLabels(org, pipeline, build, job string) map[string]string {
+	return map[string]string{"org": org, "pipeline": pipeline, "build": build, "job": job}
+}
+
+// LokiPushError is returned when Loki rejects a push.
+type LokiPushError struct {
+	StatusCode int
+	Message    string // Start of the response body
+}
+
+func (e *LokiPushError) Error() string {
+	return fmt.Sprintf("loki push failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// isRetryableLokiError reports whether a push failure looks transient: Loki rate limiting
+// or a server error, or a network error.
+func isRetryableLokiError(err error) bool {
+	var pushErr *LokiPushError
+	if errors.As(err, &pushErr) {
+		return pushErr.StatusCode == http.StatusTooManyRequests || pushErr.StatusCode >= 500
+	}
+	return IsRetryableError(err)
+}
+
+// LokiSink is a LogWriter that ships entries to Grafana Loki through its push API. Each
+// group becomes a stream with the sink's labels plus a "group" label. Entries are
+// buffered and pushed in batches, retrying failed pushes with backoff; Close pushes the
+// rest. Entries without a timestamp take the previous entry's, since Loki requires one.
+// A LokiSink is not safe for concurrent use.
+type LokiSink struct {
+	ctx      context.Context
+	pushURL  string
+	opts     LokiOptions
+	pending  []lokiEntry
+	lastTime time.Time
+	pushed   int
+	closed   bool
+}
+
+type lokiEntry struct {
+	group string
+	time  time.Time
+	line  string
+}
+
+var _ LogWriter = (*LokiSink)(nil)
+
+// NewLokiSink creates a sink pushing to the Loki at opts.URL. Pushes use ctx, so
+// canceling it stops the sink.
+func NewLokiSink(ctx context.Context, opts LokiOptions) (*LokiSink, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Loki URL %q: want http(s)://host[:port]", opts.URL)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = LokiPushPath
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultLokiBatchSize
+	}
+	if opts.Retry.MaxAttempts == 0 {
+		opts.Retry = DefaultLokiRetry
+	}
+	if opts.Retry.Retryable == nil {
+		opts.Retry.Retryable = isRetryableLokiError
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+
+	return &LokiSink{
+		ctx:     ctx,
+		pushURL: u.String(),
+		opts:    opts,
+		pending: make([]lokiEntry, 0, opts.BatchSize),
+	}, nil
+}
+
+// WriteBatch buffers entries, pushing every full batch to Loki.
+func (s *LokiSink) WriteBatch(entries []*logparser.Entry) error {
+	if s.closed {
+		return errors.New("loki sink is closed")
+	}
+
+	for _, entry := range entries {
+		if entry.HasTimestamp() {
+			s.lastTime = entry.Timestamp
+		} else if s.lastTime.IsZero() {
+			s.lastTime = time.Now()
+		}
+		s.pending = append(s.pending, lokiEntry{
+			group: strings.TrimSpace(StripANSI(entry.Group)),
+			time:  s.lastTime,
+			line:  entry.Content,
+		})
+
+		if len(s.pending) >= s.opts.BatchSize {
+			if err := s.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Flush pushes the buffered entries.
+func (s *LokiSink) Flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	body, err := s.pushRequestBody()
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = s.push(body)
+		if err == nil || attempt >= s.opts.Retry.attempts() || !s.opts.Retry.retryable(err) {
+			break
+		}
+		if sleepErr := sleepContext(s.ctx, s.opts.Retry.backoff(attempt+1)); sleepErr != nil {
+			return sleepErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	s.pushed += len(s.pending)
+	s.pending = s.pending[:0]
+	return nil
+}
+
+// Pushed returns the number of entries Loki accepted.
+func (s *LokiSink) Pushed() int {
+	return s.pushed
+}
+
+// Close pushes the buffered entries. Later writes fail.
+func (s *LokiSink) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.Flush()
+}
+
+type lokiPushRequest struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// pushRequestBody encodes the buffered entries as a push request with one stream per
+// group, keeping log order within each stream
+func (s *LokiSink) pushRequestBody() ([]byte, error) {
+	var req lokiPushRequest
+	streamIdx := make(map[string]int)
+	for _, e := range s.pending {
+		i, ok := streamIdx[e.group]
+		if !ok {
+			labels := make(map[string]string, len(s.opts.Labels)+1)
+			for k, v := range s.opts.Labels {
+				labels[k] = v
+			}
+			if e.group != "" {
+				labels["group"] = e.group
+			}
+			i = len(req.Streams)
+			streamIdx[e.group] = i
+			req.Streams = append(req.Streams, lokiStream{Stream: labels})
+		}
+		req.Streams[i].Values = append(req.Streams[i].Values, [2]string{strconv.FormatInt(e.time.UnixNano(), 10), e.line})
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode loki push: %w", err)
+	}
+	return body, nil
+}
+
+func (s *LokiSink) push(body []byte) error {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.pushURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create loki push request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.opts.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", s.opts.TenantID)
+	}
+
+	resp, err := s.opts.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push to loki: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &LokiPushError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
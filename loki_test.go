@@ -0,0 +1,121 @@
+package buildkitelogs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+type lokiTestServer struct {
+	mu       sync.Mutex
+	pushes   []lokiPushRequest
+	statuses []int // Statuses of the next responses, then 204
+	tenants  []string
+}
+
+func (s *lokiTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Path != LokiPushPath {
+		http.NotFound(w, r)
+		return
+	}
+	s.tenants = append(s.tenants, r.Header.Get("X-Scope-OrgID"))
+	if len(s.statuses) > 0 {
+		status := s.statuses[0]
+		s.statuses = s.statuses[1:]
+		http.Error(w, "try later", status)
+		return
+	}
+	var req lokiPushRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.pushes = append(s.pushes, req)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestLokiSink(t *testing.T) {
+	loki := &lokiTestServer{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(loki)
+	defer server.Close()
+
+	sink, err := NewLokiSink(t.Context(), LokiOptions{
+		URL:       server.URL,
+		Labels:    LokiJobLabels("org", "pipeline", "12", "job"),
+		TenantID:  "team-a",
+		BatchSize: 2,
+		Retry:     RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewLokiSink failed: %v", err)
+	}
+
+	log := "\x1b_bk;t=1745322209921\x07~~~ Setup\n\x1b_bk;t=1745322209922\x07installing\nno timestamp\n"
+	rows, err := ExportSeq2ToLogWriter(logparser.New().All(strings.NewReader(log)), sink, nil)
+	if err != nil {
+		t.Fatalf("ExportSeq2ToLogWriter failed: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if rows != 3 || sink.Pushed() != 3 {
+		t.Errorf("rows = %d, pushed = %d, want 3 and 3", rows, sink.Pushed())
+	}
+
+	// The first push was retried after the 503, and the last entry was pushed on Close
+	if len(loki.pushes) != 2 || len(loki.tenants) != 3 || loki.tenants[0] != "team-a" {
+		t.Fatalf("pushes = %d, requests = %d (tenants %v), want 2 and 3", len(loki.pushes), len(loki.tenants), loki.tenants)
+	}
+	stream := loki.pushes[0].Streams[0]
+	if stream.Stream["org"] != "org" || stream.Stream["job"] != "job" || stream.Stream["group"] != "~~~ Setup" {
+		t.Errorf("stream labels = %v", stream.Stream)
+	}
+	if len(stream.Values) != 2 || stream.Values[0] != [2]string{"1745322209921000000", "~~~ Setup"} {
+		t.Errorf("stream values = %v", stream.Values)
+	}
+	// Entries without a timestamp take the previous entry's
+	if last := loki.pushes[1].Streams[0].Values[0]; last != [2]string{"1745322209922000000", "no timestamp"} {
+		t.Errorf("untimestamped entry = %v", last)
+	}
+
+	if err := sink.WriteBatch([]*logparser.Entry{{Content: "late"}}); err == nil {
+		t.Error("WriteBatch after Close succeeded")
+	}
+}
+
+func TestLokiSink_Rejected(t *testing.T) {
+	loki := &lokiTestServer{statuses: []int{http.StatusBadRequest}}
+	server := httptest.NewServer(loki)
+	defer server.Close()
+
+	sink, err := NewLokiSink(t.Context(), LokiOptions{URL: server.URL, Labels: map[string]string{"job": "job"}})
+	if err != nil {
+		t.Fatalf("NewLokiSink failed: %v", err)
+	}
+	if err := sink.WriteBatch([]*logparser.Entry{{Content: "hello"}}); err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+
+	var pushErr *LokiPushError
+	if err := sink.Close(); !errors.As(err, &pushErr) || pushErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Close error = %v, want a 400 LokiPushError", err)
+	}
+	if len(loki.tenants) != 1 {
+		t.Errorf("rejected push was sent %d times, want 1", len(loki.tenants))
+	}
+}
+
+func TestNewLokiSink_InvalidURL(t *testing.T) {
+	if _, err := NewLokiSink(t.Context(), LokiOptions{URL: "localhost:3100"}); err == nil {
+		t.Error("NewLokiSink accepted a URL without a scheme")
+	}
+}
@@ -92,11 +92,9 @@ func TestClient_HeapStable(t *testing.T) {
 	// Warm up: run a few iterations before measuring so one-time init costs
 	// don't skew the baseline.
 	for range 3 {
-		path, err := client.downloadAndCache(ctx, client.api, "org", "pipeline", "123", "job-1", time.Minute, true)
-		if err != nil {
+		if _, err := client.downloadAndCache(ctx, client.api, "org", "pipeline", "123", "job-1", time.Minute, true); err != nil {
 			t.Fatal(err)
 		}
-		os.Remove(path)
 	}
 
 	runtime.GC()
@@ -106,11 +104,9 @@ func TestClient_HeapStable(t *testing.T) {
 	runtime.ReadMemStats(&before)
 
 	for i := range iterations {
-		path, err := client.downloadAndCache(ctx, client.api, "org", "pipeline", fmt.Sprintf("%d", i), "job-1", time.Minute, true)
-		if err != nil {
+		if _, err := client.downloadAndCache(ctx, client.api, "org", "pipeline", fmt.Sprintf("%d", i), "job-1", time.Minute, true); err != nil {
 			t.Fatal(err)
 		}
-		os.Remove(path) // caller is responsible for cleaning up the returned temp file
 	}
 
 	runtime.GC()
@@ -131,8 +127,8 @@ func TestClient_HeapStable(t *testing.T) {
 // not leave behind unreturned bklog-* temp files beyond the one path it returns.
 //
 // The DownloadAndCache implementation creates an intermediate bklog-*.parquet temp
-// file (cleaned up internally via defer) and a final bklog- temp file (returned to
-// the caller). This test verifies the internal temp file is always removed.
+// file (cleaned up internally via defer) and returns the cached blob's path, or a
+// stable local copy of it. This test verifies the internal temp file is always removed.
 func TestClient_NoTempFileAccumulation(t *testing.T) {
 	mock := newTerminalMock()
 	client := newTestClient(t, mock)
@@ -150,7 +146,6 @@ func TestClient_NoTempFileAccumulation(t *testing.T) {
 			t.Fatal(err)
 		}
 		returnedPaths[path] = true
-		t.Cleanup(func() { os.Remove(path) })
 	}
 
 	// Any bklog-* file that appeared after our calls but is NOT a returned path
@@ -0,0 +1,209 @@
+// Package metrics exports Prometheus metrics for the operations of a buildkitelogs.Client:
+// cache checks, log downloads, parsing, blob storage and local cache files. The metrics
+// are collected through the client's hooks, so no other changes to the client are needed:
+//
+//	m := metrics.New()
+//	m.AddHooks(client.Hooks())
+//	prometheus.MustRegister(m)
+package metrics
+
+import (
+	"context"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultNamespace prefixes the metric names unless WithNamespace sets another.
+const DefaultNamespace = "buildkite_logs"
+
+// Option configures Metrics.
+type Option func(*config)
+
+type config struct {
+	namespace       string
+	constLabels     prometheus.Labels
+	durationBuckets []float64
+	sizeBuckets     []float64
+}
+
+// WithNamespace sets the prefix of the metric names. Default is DefaultNamespace.
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithConstLabels adds labels with fixed values to every metric, such as the name of the
+// service embedding the client.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// WithDurationBuckets sets the buckets, in seconds, of the stage duration histogram.
+// Default is prometheus.DefBuckets.
+func WithDurationBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.durationBuckets = buckets
+	}
+}
+
+// WithSizeBuckets sets the buckets, in bytes, of the Parquet size histogram. Default is
+// powers of 4 from 1KiB to 1GiB.
+func WithSizeBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.sizeBuckets = buckets
+	}
+}
+
+// Result label values.
+const (
+	resultSuccess = "success"
+	resultError   = "error"
+	resultHit     = "hit"
+	resultMiss    = "miss"
+)
+
+// Metrics collects the metrics of the clients whose hooks it was added to. It is a
+// prometheus.Collector, so it can be registered on any prometheus.Registerer; one
+// Metrics may serve several clients.
+//
+// The metrics, prefixed with the namespace:
+//
+//	cache_checks_total{result}           Cache lookups: hit (the log was cached, though it may still be refreshed), miss or error
+//	stage_duration_seconds{stage,result} Duration of each stage, see buildkitelogs.Stage; stage="log_parsing" is the parse duration, stage="blob_storage" the blob storage latency
+//	log_download_bytes_total             Bytes of logs downloaded from the Buildkite API
+//	log_entries_total                    Log entries parsed into Parquet
+//	parquet_size_bytes                   Size of the Parquet data of each parsed log
+//	job_status_retries_total             Job status lookups that failed and were retried
+//
+// result is success or error, except for cache checks.
+type Metrics struct {
+	cacheChecks      *prometheus.CounterVec
+	stageDuration    *prometheus.HistogramVec
+	downloadBytes    prometheus.Counter
+	logEntries       prometheus.Counter
+	parquetSize      prometheus.Histogram
+	jobStatusRetries prometheus.Counter
+}
+
+// New creates the metrics, which are collected once AddHooks adds them to a client.
+func New(opts ...Option) *Metrics {
+	c := &config{
+		namespace:       DefaultNamespace,
+		durationBuckets: prometheus.DefBuckets,
+		sizeBuckets:     prometheus.ExponentialBuckets(1<<10, 4, 11),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return &Metrics{
+		cacheChecks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Name:        "cache_checks_total",
+			Help:        "Cache lookups of job logs, by result: hit, miss or error.",
+			ConstLabels: c.constLabels,
+		}, []string{"result"}),
+		stageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   c.namespace,
+			Name:        "stage_duration_seconds",
+			Help:        "Duration of each stage of reading a job log, by stage and result.",
+			ConstLabels: c.constLabels,
+			Buckets:     c.durationBuckets,
+		}, []string{"stage", "result"}),
+		downloadBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Name:        "log_download_bytes_total",
+			Help:        "Bytes of job logs downloaded from the Buildkite API.",
+			ConstLabels: c.constLabels,
+		}),
+		logEntries: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Name:        "log_entries_total",
+			Help:        "Log entries parsed into Parquet.",
+			ConstLabels: c.constLabels,
+		}),
+		parquetSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   c.namespace,
+			Name:        "parquet_size_bytes",
+			Help:        "Size of the Parquet data of each parsed job log.",
+			ConstLabels: c.constLabels,
+			Buckets:     c.sizeBuckets,
+		}),
+		jobStatusRetries: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Name:        "job_status_retries_total",
+			Help:        "Job status lookups that failed and were retried.",
+			ConstLabels: c.constLabels,
+		}),
+	}
+}
+
+// collectors returns every metric.
+func (m *Metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.cacheChecks, m.stageDuration, m.downloadBytes, m.logEntries, m.parquetSize, m.jobStatusRetries}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+// AddHooks registers the hooks that collect the metrics, such as on Client.Hooks().
+func (m *Metrics) AddHooks(hooks *buildkitelogs.Hooks) {
+	hooks.AddAfterCacheCheck(func(ctx context.Context, r *buildkitelogs.CacheCheckResult) {
+		m.observeStage(&r.BaseResult)
+		switch {
+		case !r.Success:
+			m.cacheChecks.WithLabelValues(resultError).Inc()
+		case r.Exists:
+			m.cacheChecks.WithLabelValues(resultHit).Inc()
+		default:
+			m.cacheChecks.WithLabelValues(resultMiss).Inc()
+		}
+	})
+	hooks.AddAfterJobStatus(func(ctx context.Context, r *buildkitelogs.JobStatusResult) {
+		m.observeStage(&r.BaseResult)
+		if r.WillRetry {
+			m.jobStatusRetries.Inc()
+		}
+	})
+	hooks.AddAfterLogDownload(func(ctx context.Context, r *buildkitelogs.LogDownloadResult) {
+		m.observeStage(&r.BaseResult)
+		m.downloadBytes.Add(float64(r.LogSize))
+	})
+	hooks.AddAfterLogParsing(func(ctx context.Context, r *buildkitelogs.LogParsingResult) {
+		m.observeStage(&r.BaseResult)
+		if r.Success {
+			m.logEntries.Add(float64(r.LogEntries))
+			m.parquetSize.Observe(float64(r.ParquetSize))
+		}
+	})
+	hooks.AddAfterBlobStorage(func(ctx context.Context, r *buildkitelogs.BlobStorageResult) {
+		m.observeStage(&r.BaseResult)
+	})
+	hooks.AddAfterLocalCache(func(ctx context.Context, r *buildkitelogs.LocalCacheResult) {
+		m.observeStage(&r.BaseResult)
+	})
+}
+
+// observeStage records the duration of the stage a hook reported.
+func (m *Metrics) observeStage(r *buildkitelogs.BaseResult) {
+	result := resultSuccess
+	if !r.Success {
+		result = resultError
+	}
+	m.stageDuration.WithLabelValues(string(r.Stage), result).Observe(r.Duration.Seconds())
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics_CollectsFromHooks(t *testing.T) {
+	m := New()
+	hooks := &buildkitelogs.Hooks{}
+	m.AddHooks(hooks)
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(m); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	ctx := t.Context()
+	base := buildkitelogs.BaseResult{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job", Success: true}
+	result := func(stage buildkitelogs.Stage, duration time.Duration, err error) buildkitelogs.BaseResult {
+		r := base
+		r.Stage, r.Duration, r.Err, r.Success = stage, duration, err, err == nil
+		return r
+	}
+
+	hooks.OnAfterCacheCheck[0](ctx, &buildkitelogs.CacheCheckResult{BaseResult: result(buildkitelogs.StageCacheCheck, time.Millisecond, nil), Exists: true})
+	hooks.OnAfterCacheCheck[0](ctx, &buildkitelogs.CacheCheckResult{BaseResult: result(buildkitelogs.StageCacheCheck, time.Millisecond, nil)})
+	hooks.OnAfterCacheCheck[0](ctx, &buildkitelogs.CacheCheckResult{BaseResult: result(buildkitelogs.StageCacheCheck, time.Millisecond, errors.New("boom"))})
+	hooks.OnAfterJobStatus[0](ctx, &buildkitelogs.JobStatusResult{BaseResult: result(buildkitelogs.StageJobStatus, time.Millisecond, errors.New("boom")), WillRetry: true})
+	hooks.OnAfterLogDownload[0](ctx, &buildkitelogs.LogDownloadResult{BaseResult: result(buildkitelogs.StageLogDownload, time.Second, nil), LogSize: 2048})
+	hooks.OnAfterLogParsing[0](ctx, &buildkitelogs.LogParsingResult{BaseResult: result(buildkitelogs.StageLogParsing, 2*time.Second, nil), ParquetSize: 4096, LogEntries: 10})
+	hooks.OnAfterBlobStorage[0](ctx, &buildkitelogs.BlobStorageResult{BaseResult: result(buildkitelogs.StageBlobStorage, 50*time.Millisecond, nil)})
+
+	if got := testutil.ToFloat64(m.cacheChecks.WithLabelValues("hit")); got != 1 {
+		t.Errorf("cache hits = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.cacheChecks.WithLabelValues("miss")); got != 1 {
+		t.Errorf("cache misses = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.cacheChecks.WithLabelValues("error")); got != 1 {
+		t.Errorf("cache check errors = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.downloadBytes); got != 2048 {
+		t.Errorf("download bytes = %v, want 2048", got)
+	}
+	if got := testutil.ToFloat64(m.logEntries); got != 10 {
+		t.Errorf("log entries = %v, want 10", got)
+	}
+	if got := testutil.ToFloat64(m.jobStatusRetries); got != 1 {
+		t.Errorf("job status retries = %v, want 1", got)
+	}
+
+	expected := `
+# HELP buildkite_logs_parquet_size_bytes Size of the Parquet data of each parsed job log.
+# TYPE buildkite_logs_parquet_size_bytes histogram
+buildkite_logs_parquet_size_bytes_bucket{le="1024"} 0
+buildkite_logs_parquet_size_bytes_bucket{le="4096"} 1
+buildkite_logs_parquet_size_bytes_bucket{le="16384"} 1
+buildkite_logs_parquet_size_bytes_bucket{le="65536"} 1
+buildkite_logs_parquet_size_bytes_bucket{le="262144"} 1
+buildkite_logs_parquet_size_bytes_bucket{le="1.048576e+06"} 1
+buildkite_logs_parquet_size_bytes_bucket{le="4.194304e+06"} 1
+buildkite_logs_parquet_size_bytes_bucket{le="1.6777216e+07"} 1
+buildkite_logs_parquet_size_bytes_bucket{le="6.7108864e+07"} 1
+buildkite_logs_parquet_size_bytes_bucket{le="2.68435456e+08"} 1
+buildkite_logs_parquet_size_bytes_bucket{le="1.073741824e+09"} 1
+buildkite_logs_parquet_size_bytes_bucket{le="+Inf"} 1
+buildkite_logs_parquet_size_bytes_sum 4096
+buildkite_logs_parquet_size_bytes_count 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "buildkite_logs_parquet_size_bytes"); err != nil {
+		t.Error(err)
+	}
+
+	// Every stage reported a duration, labelled with its result
+	if got := testutil.CollectAndCount(m.stageDuration); got != 6 {
+		t.Errorf("stage duration series = %d, want 6", got)
+	}
+}
+
+func TestMetrics_Options(t *testing.T) {
+	m := New(WithNamespace("custom"), WithConstLabels(prometheus.Labels{"service": "logs"}))
+	hooks := &buildkitelogs.Hooks{}
+	m.AddHooks(hooks)
+
+	hooks.OnAfterLogDownload[0](t.Context(), &buildkitelogs.LogDownloadResult{
+		BaseResult: buildkitelogs.BaseResult{Stage: buildkitelogs.StageLogDownload, Success: true},
+		LogSize:    100,
+	})
+
+	expected := `
+# HELP custom_log_download_bytes_total Bytes of job logs downloaded from the Buildkite API.
+# TYPE custom_log_download_bytes_total counter
+custom_log_download_bytes_total{service="logs"} 100
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(expected), "custom_log_download_bytes_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,133 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoFiles is returned by NewMultiReaderGlob when the pattern matches no files.
+var ErrNoFiles = errors.New("no files match")
+
+// MultiReader reads several Parquet log files as one, such as every job of a build
+// (see Client.DownloadBuild) or a directory of cached logs. Files are read one after
+// another in the order given, and every entry and search result carries the file it
+// came from.
+type MultiReader struct {
+	files   []string
+	readers []*ParquetReader
+}
+
+// MultiFileEntry is a log entry read by a MultiReader.
+type MultiFileEntry struct {
+	ParquetLogEntry
+	File  string `json:"file"`
+	JobID string `json:"job_id,omitempty"` // Job of a cached log, from its file name; empty for other files
+}
+
+// MultiFileSearchResult is a search result of a MultiReader.
+type MultiFileSearchResult struct {
+	SearchResult
+	File  string `json:"file"`
+	JobID string `json:"job_id,omitempty"` // Job of a cached log, from its file name; empty for other files
+}
+
+// NewMultiReader creates a reader over the Parquet files at paths.
+func NewMultiReader(paths ...string) *MultiReader {
+	mr := &MultiReader{files: paths}
+	for _, path := range paths {
+		mr.readers = append(mr.readers, NewParquetReader(path))
+	}
+	return mr
+}
+
+// NewMultiReaderGlob creates a reader over the files matching a filepath.Match pattern,
+// such as "logs/*.parquet", in lexical order.
+func NewMultiReaderGlob(pattern string) (*MultiReader, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w %q", ErrNoFiles, pattern)
+	}
+	return NewMultiReader(paths...), nil
+}
+
+// Files returns the paths of the files the reader reads.
+func (mr *MultiReader) Files() []string {
+	return mr.files
+}
+
+// Readers returns the reader of each file, in the order of Files, to configure them,
+// e.g. with WithGroupAliases or WithChecksumValidation.
+func (mr *MultiReader) Readers() []*ParquetReader {
+	return mr.readers
+}
+
+// Close closes the reader of each file.
+func (mr *MultiReader) Close() error {
+	var errs []error
+	for _, reader := range mr.readers {
+		errs = append(errs, reader.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// ReadEntriesIter returns an iterator over the entries of every file.
+func (mr *MultiReader) ReadEntriesIter(ctx context.Context) iter.Seq2[MultiFileEntry, error] {
+	return func(yield func(MultiFileEntry, error) bool) {
+		for i, reader := range mr.readers {
+			file, job := mr.files[i], jobIDFromPath(mr.files[i])
+			for entry, err := range reader.ReadEntriesIter(ctx) {
+				if err != nil {
+					err = fmt.Errorf("%s: %w", file, err)
+				}
+				if !yield(MultiFileEntry{ParquetLogEntry: entry, File: file, JobID: job}, err) {
+					return
+				}
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// SearchEntriesIter searches every file, see ParquetReader.SearchEntriesIter. Matches
+// are yielded file by file; options.Reverse reverses the order within each file.
+func (mr *MultiReader) SearchEntriesIter(ctx context.Context, options SearchOptions) iter.Seq2[MultiFileSearchResult, error] {
+	return func(yield func(MultiFileSearchResult, error) bool) {
+		for i, reader := range mr.readers {
+			file, job := mr.files[i], jobIDFromPath(mr.files[i])
+			for result, err := range reader.SearchEntriesIter(ctx, options) {
+				if err != nil {
+					err = fmt.Errorf("%s: %w", file, err)
+				}
+				if !yield(MultiFileSearchResult{SearchResult: result, File: file, JobID: job}, err) {
+					return
+				}
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// jobIDFromPath returns the job UUID that ends the name of a cached log file, see
+// GenerateBlobKey, or "" when the name doesn't end in one.
+func jobIDFromPath(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".parquet")
+	const uuidLength = 36
+	if len(name) < uuidLength {
+		return ""
+	}
+	if job := name[len(name)-uuidLength:]; IsUUID(job) {
+		return job
+	}
+	return ""
+}
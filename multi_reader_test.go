@@ -0,0 +1,92 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func writeMultiReaderTestFiles(t *testing.T) (dir string, job string) {
+	t.Helper()
+	dir = t.TempDir()
+	job = "0190a7a4-5b3c-7d1e-9f00-1234567890ab"
+	baseTime := time.Date(2025, 4, 22, 21, 43, 29, 0, time.UTC)
+	files := map[string][]*logparser.Entry{
+		GenerateBlobKey("org", "pipeline", "12", job): {
+			{Timestamp: baseTime, Content: "~~~ Running tests", Group: "~~~ Running tests"},
+			{Timestamp: baseTime, Content: "FAIL TestCache", Group: "~~~ Running tests"},
+		},
+		"local.parquet": {
+			{Timestamp: baseTime, Content: "ok"},
+			{Timestamp: baseTime, Content: "FAIL TestSearch"},
+			{Timestamp: baseTime, Content: "FAIL TestSeek"},
+		},
+	}
+	for name, entries := range files {
+		if err := ExportSeq2ToParquet(entrySeq(entries), filepath.Join(dir, name)); err != nil {
+			t.Fatalf("ExportSeq2ToParquet failed: %v", err)
+		}
+	}
+	return dir, job
+}
+
+func TestMultiReader_SearchEntriesIter(t *testing.T) {
+	dir, job := writeMultiReaderTestFiles(t)
+	reader, err := NewMultiReaderGlob(filepath.Join(dir, "*.parquet"))
+	if err != nil {
+		t.Fatalf("NewMultiReaderGlob failed: %v", err)
+	}
+	defer reader.Close()
+	if len(reader.Files()) != 2 {
+		t.Fatalf("files = %v, want 2", reader.Files())
+	}
+
+	var results []MultiFileSearchResult
+	for result, err := range reader.SearchEntriesIter(t.Context(), SearchOptions{Pattern: "^FAIL"}) {
+		if err != nil {
+			t.Fatalf("SearchEntriesIter failed: %v", err)
+		}
+		results = append(results, result)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d matches, want 3", len(results))
+	}
+	// Glob results are in lexical order: the cached log of "org-..." sorts after "local"
+	first, last := results[0], results[2]
+	if filepath.Base(first.File) != "local.parquet" || first.JobID != "" || first.Match.Content != "FAIL TestSearch" {
+		t.Errorf("first match = %+v", first)
+	}
+	if last.JobID != job || last.Match.Content != "FAIL TestCache" || last.Match.RowNumber != 1 {
+		t.Errorf("last match = %+v, want job %s", last, job)
+	}
+}
+
+func TestMultiReader_ReadEntriesIter(t *testing.T) {
+	dir, job := writeMultiReaderTestFiles(t)
+	reader := NewMultiReader(filepath.Join(dir, GenerateBlobKey("org", "pipeline", "12", job)), filepath.Join(dir, "local.parquet"))
+
+	var files []string
+	for entry, err := range reader.ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		files = append(files, filepath.Base(entry.File))
+	}
+	if len(files) != 5 || files[0] == "local.parquet" || files[4] != "local.parquet" {
+		t.Errorf("entries came from %v, want 2 from the cached log then 3 from local.parquet", files)
+	}
+
+	missing := NewMultiReader(filepath.Join(dir, "missing.parquet"))
+	for _, err := range missing.ReadEntriesIter(t.Context()) {
+		if err == nil {
+			t.Error("reading a missing file succeeded")
+		}
+	}
+
+	if _, err := NewMultiReaderGlob(filepath.Join(dir, "*.log")); !errors.Is(err, ErrNoFiles) {
+		t.Errorf("error = %v, want ErrNoFiles", err)
+	}
+}
@@ -0,0 +1,43 @@
+package buildkitelogs
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/transfermanager"
+	"gocloud.dev/blob"
+)
+
+// WithObjectTags sets whether cached logs written to S3 are also tagged with their
+// organization, pipeline, build and terminal state (tags "organization", "pipeline",
+// "build" and "terminal"), so bucket lifecycle rules and cost allocation reports can
+// select cached logs without reading each object's metadata. The credentials need
+// s3:PutObjectTagging. Other backends have no object tags and ignore it. Default is off.
+func WithObjectTags(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.objectTags = enabled
+	}
+}
+
+// objectTagging returns the S3 tag set for a blob's metadata, in the URL query form
+// S3 expects
+func objectTagging(metadata *BlobMetadata) string {
+	tags := url.Values{}
+	tags.Set("organization", metadata.Organization)
+	tags.Set("pipeline", metadata.Pipeline)
+	tags.Set("build", metadata.Build)
+	tags.Set("terminal", strconv.FormatBool(metadata.IsTerminal))
+	return tags.Encode()
+}
+
+// setObjectTags tags uploads on backends with object tags (S3); it does nothing on others
+func setObjectTags(opts *blob.WriterOptions, metadata *BlobMetadata) {
+	tagging := objectTagging(metadata)
+	opts.BeforeWrite = func(asFunc func(any) bool) error {
+		var input *transfermanager.UploadObjectInput
+		if asFunc(&input) {
+			input.Tagging = &tagging
+		}
+		return nil
+	}
+}
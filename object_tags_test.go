@@ -0,0 +1,52 @@
+package buildkitelogs
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/transfermanager"
+	"gocloud.dev/blob"
+)
+
+func TestObjectTagging(t *testing.T) {
+	metadata := &BlobMetadata{Organization: "org", Pipeline: "my pipeline", Build: "12", IsTerminal: true}
+
+	opts := &blob.WriterOptions{}
+	setObjectTags(opts, metadata)
+
+	input := &transfermanager.UploadObjectInput{}
+	err := opts.BeforeWrite(func(i any) bool {
+		p, ok := i.(**transfermanager.UploadObjectInput)
+		if ok {
+			*p = input
+		}
+		return ok
+	})
+	if err != nil {
+		t.Fatalf("BeforeWrite failed: %v", err)
+	}
+	want := "build=12&organization=org&pipeline=my+pipeline&terminal=true"
+	if input.Tagging == nil || *input.Tagging != want {
+		t.Errorf("Tagging = %v, want %q", input.Tagging, want)
+	}
+
+	// Backends without object tags don't expose an upload input
+	if err := opts.BeforeWrite(func(any) bool { return false }); err != nil {
+		t.Errorf("BeforeWrite on other backends failed: %v", err)
+	}
+}
+
+func TestClient_WithObjectTagsOnFileStorage(t *testing.T) {
+	client := newTestClient(t, newTerminalMock(), WithObjectTags(true))
+	if !client.blobStorage.objectTags {
+		t.Fatal("WithObjectTags did not reach blob storage")
+	}
+
+	reader, err := client.NewReader(t.Context(), "org", "pipeline", "1", "job", 0, false)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+	if _, err := reader.GetFileInfo(); err != nil {
+		t.Errorf("GetFileInfo failed: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	buildkite "github.com/buildkite/go-buildkite/v5"
 )
@@ -52,13 +53,20 @@ func organizationJobPath(organization, jobID, action string) string {
 }
 
 func (c *BuildkiteAPIClient) getJobByOrgResponse(ctx context.Context, org, jobID string) (jobByOrgResponse, error) {
-	req, err := c.client.NewRequest(ctx, "GET", organizationJobPath(org, jobID, ""), nil)
-	if err != nil {
-		return jobByOrgResponse{}, err
-	}
-
 	var job jobByOrgResponse
-	if _, err := c.client.Do(req, &job); err != nil {
+	err := c.withRetry(ctx, func() error {
+		ctx, cancel := c.requestContext(ctx)
+		defer cancel()
+
+		req, err := c.client.NewRequest(ctx, "GET", organizationJobPath(org, jobID, ""), nil)
+		if err != nil {
+			return err
+		}
+		job = jobByOrgResponse{}
+		resp, err := c.client.Do(req, &job)
+		return wrapAPIError(resp, err)
+	}, nil)
+	if err != nil {
 		return jobByOrgResponse{}, fmt.Errorf("failed to get job: %w", err)
 	}
 
@@ -75,15 +83,21 @@ func (c *BuildkiteAPIClient) GetJobByOrg(ctx context.Context, org, jobID string)
 }
 
 // GetJobLogByOrg fetches a job log using the organization-scoped REST endpoint.
+// The log arrives in a single JSON response, so the call is bounded only by ctx.
 func (c *BuildkiteAPIClient) GetJobLogByOrg(ctx context.Context, org, jobID string) (io.ReadCloser, error) {
-	req, err := c.client.NewRequest(ctx, "GET", organizationJobPath(org, jobID, "log"), nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Accept", "application/json")
-
 	var jobLog buildkite.JobLog
-	if _, err := c.client.Do(req, &jobLog); err != nil {
+	err := c.withRetry(ctx, func() error {
+		req, err := c.client.NewRequest(ctx, "GET", organizationJobPath(org, jobID, "log"), nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+
+		jobLog = buildkite.JobLog{}
+		resp, err := c.client.Do(req, &jobLog)
+		return wrapAPIError(resp, err)
+	}, nil)
+	if err != nil {
 		return nil, fmt.Errorf("failed to get job log: %w", err)
 	}
 
@@ -172,24 +186,42 @@ func ValidateOrgJobParams(org, job string) error {
 func jobStatusFromJob(job buildkite.Job) *JobStatus {
 	state := JobState(job.State)
 	status := &JobStatus{
-		ID:         job.ID,
-		State:      state,
-		IsTerminal: IsTerminalState(state),
-		WebURL:     job.WebURL,
+		ID:             job.ID,
+		State:          state,
+		IsTerminal:     IsTerminalState(state),
+		WebURL:         job.WebURL,
+		Name:           job.Name,
+		AgentName:      job.Agent.Name,
+		AgentHostname:  job.Agent.Hostname,
+		Retried:        job.Retried,
+		RetriesCount:   job.RetriesCount,
+		RetriedInJobID: job.RetriedInJobID,
 	}
 
+	if job.RetrySource != nil {
+		status.RetryOfJobID = job.RetrySource.JobID
+	}
 	if job.ExitStatus != nil {
 		status.ExitStatus = job.ExitStatus
 	}
 
-	if job.FinishedAt != nil {
-		finishedAt := job.FinishedAt.Time
-		status.FinishedAt = &finishedAt
-	}
+	status.CreatedAt = timestampPtr(job.CreatedAt)
+	status.ScheduledAt = timestampPtr(job.ScheduledAt)
+	status.StartedAt = timestampPtr(job.StartedAt)
+	status.FinishedAt = timestampPtr(job.FinishedAt)
 
 	return status
 }
 
+// timestampPtr converts an optional Buildkite timestamp to an optional time.Time.
+func timestampPtr(ts *buildkite.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
+
 // orgJobReaderAPI adapts OrgScopedJobAPI for the pipeline-scoped BuildkiteAPI interface
 // while preserving org-only fetch semantics and resolved cache identifiers.
 type orgJobReaderAPI struct {
@@ -205,6 +237,11 @@ func (a *orgJobReaderAPI) JobLogExists(ctx context.Context, _, _, _, _ string) (
 	return a.base.JobLogExists(ctx, a.location.Org, a.location.Pipeline, a.location.Build, a.location.Job)
 }
 
+func (a *orgJobReaderAPI) retriesCalls() bool {
+	retrying, ok := a.base.(retryingAPI)
+	return ok && retrying.retriesCalls()
+}
+
 func (a *orgJobReaderAPI) GetJobStatus(ctx context.Context, _, _, _, _ string) (*JobStatus, error) {
 	job, err := a.base.GetJobByOrg(ctx, a.location.Org, a.location.Job)
 	if err != nil {
@@ -0,0 +1,313 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// OTLPLogsPath is the path of the OTLP/HTTP logs endpoint. It is appended to
+// OTLPOptions.URL when the URL has no path.
+const OTLPLogsPath = "/v1/logs"
+
+// OTLPScopeName is the instrumentation scope of the log records an OTLPSink exports.
+const OTLPScopeName = "github.com/buildkite/buildkite-logs"
+
+// DefaultOTLPServiceName is the service.name resource attribute of exported logs unless
+// OTLPOptions.ResourceAttributes sets one.
+const DefaultOTLPServiceName = "buildkite"
+
+// DefaultOTLPBatchSize is the number of entries an OTLPSink sends per export request.
+const DefaultOTLPBatchSize = 1000
+
+// DefaultOTLPRetry retries a failed export four times over about 7s, covering collector
+// throttling (429, 503) and restarts.
+var DefaultOTLPRetry = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     4 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// OTLPOptions configures an OTLPSink.
+type OTLPOptions struct {
+	URL                string            // Collector base URL (http://otel-collector:4318) or logs endpoint
+	ResourceAttributes map[string]string // Attributes of the resource every record belongs to, such as OTLPJobAttributes
+	Headers            map[string]string // Sent with every request, such as an API key (optional)
+	BatchSize          int               // Entries per export request (default: DefaultOTLPBatchSize)
+	Retry              RetryPolicy       // Retries of failed exports (default: DefaultOTLPRetry when MaxAttempts is 0)
+	HTTPClient         *http.Client      // Client for exports (default: http.DefaultClient)
+}
+
+// OTLPJobAttributes returns the resource attributes identifying a Buildkite job:
+// buildkite.org, buildkite.pipeline, buildkite.build and buildkite.job.
+func OTLPJobAttributes(org, pipeline, build, job string) map[string]string {
+	return map[string]string{"buildkite.org": org, "buildkite.pipeline": pipeline, "buildkite.build": build, "buildkite.job": job}
+}
+
+// OTLPExportError is returned when the collector rejects an export.
+type OTLPExportError struct {
+	StatusCode int
+	Message    string // Start of the response body
+}
+
+func (e *OTLPExportError) Error() string {
+	return fmt.Sprintf("otlp export failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// isRetryableOTLPError reports whether an export failure is one the OTLP/HTTP
+// specification allows retrying (429, 502, 503, 504), or a network error.
+func isRetryableOTLPError(err error) bool {
+	var exportErr *OTLPExportError
+	if errors.As(err, &exportErr) {
+		switch exportErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return IsRetryableError(err)
+}
+
+// OTLPSink is a LogWriter that exports entries as OpenTelemetry log records to an OTLP/HTTP
+// endpoint, in the protocol's JSON encoding, so build logs can land in any backend an
+// OpenTelemetry Collector feeds. Each record's time and observed time are the entry's
+// OSC timestamp, left unset for entries without one; its body is the content with ANSI
+// codes removed; its severity is the inferred one (see logparser.InferSeverity); and its
+// attributes hold the group as buildkite.group, the parent group as
+// buildkite.parent_group and buildkite.is_group for headers. Entries are buffered and
+// exported in batches, retrying failed exports with backoff; Close exports the rest.
+// An OTLPSink is not safe for concurrent use.
+type OTLPSink struct {
+	ctx      context.Context
+	logsURL  string
+	opts     OTLPOptions
+	resource otlpResource
+	pending  []otlpLogRecord
+	exported int
+	closed   bool
+}
+
+var _ LogWriter = (*OTLPSink)(nil)
+
+// NewOTLPSink creates a sink exporting to the OTLP endpoint at opts.URL. Exports use ctx,
+// so canceling it stops the sink.
+func NewOTLPSink(ctx context.Context, opts OTLPOptions) (*OTLPSink, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: want http(s)://host[:port]", opts.URL)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = OTLPLogsPath
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultOTLPBatchSize
+	}
+	if opts.Retry.MaxAttempts == 0 {
+		opts.Retry = DefaultOTLPRetry
+	}
+	if opts.Retry.Retryable == nil {
+		opts.Retry.Retryable = isRetryableOTLPError
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+
+	attributes := map[string]string{"service.name": DefaultOTLPServiceName}
+	maps.Copy(attributes, opts.ResourceAttributes)
+	var resource otlpResource
+	for _, key := range slices.Sorted(maps.Keys(attributes)) {
+		resource.Attributes = append(resource.Attributes, otlpStringAttribute(key, attributes[key]))
+	}
+
+	return &OTLPSink{
+		ctx:      ctx,
+		logsURL:  u.String(),
+		opts:     opts,
+		resource: resource,
+		pending:  make([]otlpLogRecord, 0, opts.BatchSize),
+	}, nil
+}
+
+// WriteBatch buffers entries, exporting every full batch.
+func (s *OTLPSink) WriteBatch(entries []*logparser.Entry) error {
+	if s.closed {
+		return errors.New("otlp sink is closed")
+	}
+
+	for _, entry := range entries {
+		s.pending = append(s.pending, newOTLPLogRecord(entry))
+		if len(s.pending) >= s.opts.BatchSize {
+			if err := s.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Flush exports the buffered entries.
+func (s *OTLPSink) Flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(otlpExportRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource:  s.resource,
+		ScopeLogs: []otlpScopeLogs{{Scope: otlpScope{Name: OTLPScopeName}, LogRecords: s.pending}},
+	}}})
+	if err != nil {
+		return fmt.Errorf("failed to encode otlp export: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = s.export(body)
+		if err == nil || attempt >= s.opts.Retry.attempts() || !s.opts.Retry.retryable(err) {
+			break
+		}
+		if sleepErr := sleepContext(s.ctx, s.opts.Retry.backoff(attempt+1)); sleepErr != nil {
+			return sleepErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	s.exported += len(s.pending)
+	s.pending = s.pending[:0]
+	return nil
+}
+
+// Exported returns the number of entries the endpoint accepted.
+func (s *OTLPSink) Exported() int {
+	return s.exported
+}
+
+// Close exports the buffered entries. Later writes fail.
+func (s *OTLPSink) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.Flush()
+}
+
+// The OTLP/HTTP JSON encoding of ExportLogsServiceRequest, limited to the fields the sink
+// sets. 64-bit integers are encoded as strings, as the encoding requires.
+type otlpExportRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string          `json:"timeUnixNano,omitempty"`
+	ObservedTimeUnixNano string          `json:"observedTimeUnixNano,omitempty"`
+	SeverityNumber       int             `json:"severityNumber"`
+	SeverityText         string          `json:"severityText"`
+	Body                 otlpAnyValue    `json:"body"`
+	Attributes           []otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+func otlpStringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+// otlpSeverityNumbers maps severities to the OpenTelemetry SeverityNumber of their
+// level's first step: INFO 9, WARN 13, ERROR 17.
+var otlpSeverityNumbers = map[logparser.Severity]int{
+	logparser.SeverityInfo:  9,
+	logparser.SeverityWarn:  13,
+	logparser.SeverityError: 17,
+}
+
+// newOTLPLogRecord converts an entry to a log record
+func newOTLPLogRecord(entry *logparser.Entry) otlpLogRecord {
+	body := StripANSI(entry.Content)
+	severity := entry.Severity()
+	record := otlpLogRecord{
+		SeverityNumber: otlpSeverityNumbers[severity],
+		SeverityText:   strings.ToUpper(severity.String()),
+		Body:           otlpAnyValue{StringValue: &body},
+	}
+	if entry.HasTimestamp() {
+		record.TimeUnixNano = strconv.FormatInt(entry.Timestamp.UnixNano(), 10)
+		record.ObservedTimeUnixNano = record.TimeUnixNano
+	}
+
+	if group := strings.TrimSpace(StripANSI(entry.Group)); group != "" {
+		record.Attributes = append(record.Attributes, otlpStringAttribute("buildkite.group", group))
+	}
+	if parent := strings.TrimSpace(StripANSI(entry.ParentGroup)); parent != "" {
+		record.Attributes = append(record.Attributes, otlpStringAttribute("buildkite.parent_group", parent))
+	}
+	if entry.IsGroup() {
+		isGroup := true
+		record.Attributes = append(record.Attributes, otlpAttribute{Key: "buildkite.is_group", Value: otlpAnyValue{BoolValue: &isGroup}})
+	}
+	return record
+}
+
+func (s *OTLPSink) export(body []byte) error {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.logsURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create otlp export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.opts.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.opts.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export to otlp endpoint: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &OTLPExportError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,143 @@
+package buildkitelogs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+type otlpTestServer struct {
+	mu       sync.Mutex
+	exports  []otlpExportRequest
+	statuses []int // Statuses of the next responses, then 200
+	apiKeys  []string
+}
+
+func (s *otlpTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Path != OTLPLogsPath {
+		http.NotFound(w, r)
+		return
+	}
+	s.apiKeys = append(s.apiKeys, r.Header.Get("Api-Key"))
+	if len(s.statuses) > 0 {
+		status := s.statuses[0]
+		s.statuses = s.statuses[1:]
+		http.Error(w, "try later", status)
+		return
+	}
+	var req otlpExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.exports = append(s.exports, req)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte("{}"))
+}
+
+func TestOTLPSink(t *testing.T) {
+	collector := &otlpTestServer{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	sink, err := NewOTLPSink(t.Context(), OTLPOptions{
+		URL:                server.URL,
+		ResourceAttributes: OTLPJobAttributes("org", "pipeline", "12", "job"),
+		Headers:            map[string]string{"Api-Key": "secret"},
+		BatchSize:          3,
+		Retry:              RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewOTLPSink failed: %v", err)
+	}
+
+	log := "\x1b_bk;t=1745322209921\x07~~~ Running tests\n\x1b_bk;t=1745322209922\x07--- unit\n\x1b[31mFAIL: TestCache\x1b[0m\nno timestamp\n"
+	rows, err := ExportSeq2ToLogWriter(logparser.New().All(strings.NewReader(log)), sink, nil)
+	if err != nil {
+		t.Fatalf("ExportSeq2ToLogWriter failed: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if rows != 4 || sink.Exported() != 4 {
+		t.Errorf("rows = %d, exported = %d, want 4 and 4", rows, sink.Exported())
+	}
+
+	// The first export was retried after the 503, and the last entry was exported on Close
+	if len(collector.exports) != 2 || len(collector.apiKeys) != 3 || collector.apiKeys[0] != "secret" {
+		t.Fatalf("exports = %d, requests = %d (keys %v), want 2 and 3", len(collector.exports), len(collector.apiKeys), collector.apiKeys)
+	}
+
+	resourceLogs := collector.exports[0].ResourceLogs[0]
+	resource := make(map[string]string)
+	for _, attribute := range resourceLogs.Resource.Attributes {
+		resource[attribute.Key] = *attribute.Value.StringValue
+	}
+	if resource["service.name"] != DefaultOTLPServiceName || resource["buildkite.job"] != "job" {
+		t.Errorf("resource attributes = %v", resource)
+	}
+	if resourceLogs.ScopeLogs[0].Scope.Name != OTLPScopeName {
+		t.Errorf("scope = %q, want %q", resourceLogs.ScopeLogs[0].Scope.Name, OTLPScopeName)
+	}
+
+	records := resourceLogs.ScopeLogs[0].LogRecords
+	if len(records) != 3 {
+		t.Fatalf("first export has %d records, want 3", len(records))
+	}
+	header := records[0]
+	if header.ObservedTimeUnixNano != "1745322209921000000" || header.TimeUnixNano != header.ObservedTimeUnixNano {
+		t.Errorf("header times = %s, %s", header.TimeUnixNano, header.ObservedTimeUnixNano)
+	}
+	failure := records[2]
+	if *failure.Body.StringValue != "FAIL: TestCache" || failure.SeverityNumber != 17 || failure.SeverityText != "ERROR" {
+		t.Errorf("failure record = %+v", failure)
+	}
+	attributes := make(map[string]string)
+	for _, attribute := range failure.Attributes {
+		attributes[attribute.Key] = *attribute.Value.StringValue
+	}
+	if attributes["buildkite.group"] != "--- unit" || attributes["buildkite.parent_group"] != "~~~ Running tests" {
+		t.Errorf("failure attributes = %v", attributes)
+	}
+
+	if last := collector.exports[1].ResourceLogs[0].ScopeLogs[0].LogRecords[0]; last.TimeUnixNano != "" || last.ObservedTimeUnixNano != "" {
+		t.Errorf("untimestamped entry has times %q, %q", last.TimeUnixNano, last.ObservedTimeUnixNano)
+	}
+}
+
+func TestOTLPSink_Rejected(t *testing.T) {
+	collector := &otlpTestServer{statuses: []int{http.StatusBadRequest}}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	sink, err := NewOTLPSink(t.Context(), OTLPOptions{URL: server.URL})
+	if err != nil {
+		t.Fatalf("NewOTLPSink failed: %v", err)
+	}
+	if err := sink.WriteBatch([]*logparser.Entry{{Content: "hello"}}); err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+
+	var exportErr *OTLPExportError
+	if err := sink.Close(); !errors.As(err, &exportErr) || exportErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Close error = %v, want a 400 OTLPExportError", err)
+	}
+	if len(collector.apiKeys) != 1 {
+		t.Errorf("rejected export was sent %d times, want 1", len(collector.apiKeys))
+	}
+}
+
+func TestNewOTLPSink_InvalidURL(t *testing.T) {
+	if _, err := NewOTLPSink(t.Context(), OTLPOptions{URL: "localhost:4318"}); err == nil {
+		t.Error("NewOTLPSink accepted a URL without a scheme")
+	}
+}
@@ -0,0 +1,116 @@
+package buildkitelogs
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsUUID reports whether s is a UUID such as a Buildkite job or build ID.
+func IsUUID(s string) bool {
+	return uuidRegex.MatchString(s)
+}
+
+// NormalizeBuild validates a build identifier and returns it in canonical form. It
+// accepts a build number ("123" or "#123"), a build UUID, LatestBuild, or a Buildkite
+// build URL, from which the build number is taken.
+func NormalizeBuild(build string) (string, error) {
+	build = strings.TrimSpace(build)
+	switch {
+	case build == "":
+		return "", fmt.Errorf("build is required")
+	case build == LatestBuild:
+		return build, nil
+	case IsUUID(build):
+		return strings.ToLower(build), nil
+	case strings.Contains(build, "://"):
+		location, err := ParseBuildkiteURL(build)
+		if err != nil {
+			return "", err
+		}
+		return location.Build, nil
+	}
+
+	n, err := strconv.Atoi(strings.TrimPrefix(build, "#"))
+	if err != nil || n <= 0 {
+		return "", fmt.Errorf("build must be a build number, UUID, URL or %q, got %q", LatestBuild, build)
+	}
+	return strconv.Itoa(n), nil
+}
+
+// NormalizeJob validates a job ID and returns it in canonical (lowercase) form. A job
+// URL's "#<uuid>" fragment is also accepted.
+func NormalizeJob(job string) (string, error) {
+	job = strings.TrimSpace(job)
+	if strings.Contains(job, "://") {
+		location, err := ParseBuildkiteURL(job)
+		if err != nil {
+			return "", err
+		}
+		if location.Job == "" {
+			return "", fmt.Errorf("URL %q does not identify a job", job)
+		}
+		return location.Job, nil
+	}
+
+	if !IsUUID(job) {
+		return "", fmt.Errorf("job must be a UUID, got %q", job)
+	}
+	return strings.ToLower(job), nil
+}
+
+// ParseBuildkiteURL extracts job location identifiers from a Buildkite web URL
+// (https://buildkite.com/{org}/{pipeline}/builds/{build}#{job}) or REST API URL
+// (.../organizations/{org}/pipelines/{pipeline}/builds/{build}/jobs/{job}). Job is empty
+// when the URL identifies only a build.
+func ParseBuildkiteURL(raw string) (JobLocation, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return JobLocation{}, fmt.Errorf("invalid Buildkite URL: %w", err)
+	}
+
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	var location JobLocation
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] != "builds" {
+			continue
+		}
+		location.Build = segments[i+1]
+		rest := segments[i+2:]
+		if len(rest) >= 2 && rest[0] == "jobs" {
+			location.Job = rest[1]
+		}
+
+		before := segments[:i]
+		switch {
+		case len(before) >= 4 && before[len(before)-4] == "organizations" && before[len(before)-2] == "pipelines":
+			location.Org, location.Pipeline = before[len(before)-3], before[len(before)-1]
+		case len(before) == 2:
+			location.Org, location.Pipeline = before[0], before[1]
+		}
+		break
+	}
+
+	if location.Org == "" || location.Pipeline == "" || location.Build == "" {
+		return JobLocation{}, fmt.Errorf("could not parse organization, pipeline and build from URL %q", raw)
+	}
+	if location.Job == "" && IsUUID(parsed.Fragment) {
+		location.Job = parsed.Fragment
+	}
+
+	build, err := NormalizeBuild(location.Build)
+	if err != nil {
+		return JobLocation{}, err
+	}
+	location.Build = build
+	if location.Job != "" {
+		if location.Job, err = NormalizeJob(location.Job); err != nil {
+			return JobLocation{}, err
+		}
+	}
+	return location, nil
+}
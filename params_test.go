@@ -0,0 +1,87 @@
+package buildkitelogs
+
+import "testing"
+
+func TestNormalizeBuild(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "123", want: "123"},
+		{in: "#123", want: "123"},
+		{in: " 42 ", want: "42"},
+		{in: "latest", want: "latest"},
+		{in: "0190A7A4-5B3C-7D1E-9F00-1234567890AB", want: "0190a7a4-5b3c-7d1e-9f00-1234567890ab"},
+		{in: "https://buildkite.com/myorg/mypipe/builds/77#0190a7a4-5b3c-7d1e-9f00-1234567890ab", want: "77"},
+		{in: "", wantErr: true},
+		{in: "0", wantErr: true},
+		{in: "retry-1", wantErr: true},
+		{in: "https://buildkite.com/myorg", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeBuild(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeBuild(%q) error = %v, wantErr %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeBuild(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeJob(t *testing.T) {
+	const id = "0190a7a4-5b3c-7d1e-9f00-1234567890ab"
+
+	if got, err := NormalizeJob("0190A7A4-5B3C-7D1E-9F00-1234567890AB"); err != nil || got != id {
+		t.Errorf("NormalizeJob(upper) = %q, %v", got, err)
+	}
+	if got, err := NormalizeJob("https://buildkite.com/myorg/mypipe/builds/77#" + id); err != nil || got != id {
+		t.Errorf("NormalizeJob(url) = %q, %v", got, err)
+	}
+
+	_, err := NormalizeJob("retry-1")
+	if err == nil || err.Error() != `job must be a UUID, got "retry-1"` {
+		t.Errorf("NormalizeJob(retry-1) error = %v", err)
+	}
+	if _, err := NormalizeJob("https://buildkite.com/myorg/mypipe/builds/77"); err == nil {
+		t.Error("expected error for a build URL without a job")
+	}
+}
+
+func TestParseBuildkiteURL(t *testing.T) {
+	const id = "0190a7a4-5b3c-7d1e-9f00-1234567890ab"
+
+	tests := []struct {
+		url  string
+		want JobLocation
+	}{
+		{
+			url:  "https://buildkite.com/myorg/mypipe/builds/77#" + id,
+			want: JobLocation{Org: "myorg", Pipeline: "mypipe", Build: "77", Job: id},
+		},
+		{
+			url:  "https://buildkite.com/myorg/mypipe/builds/77",
+			want: JobLocation{Org: "myorg", Pipeline: "mypipe", Build: "77"},
+		},
+		{
+			url:  "https://api.buildkite.com/v2/organizations/myorg/pipelines/mypipe/builds/77/jobs/" + id + "/log",
+			want: JobLocation{Org: "myorg", Pipeline: "mypipe", Build: "77", Job: id},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseBuildkiteURL(tt.url)
+		if err != nil {
+			t.Errorf("ParseBuildkiteURL(%q) failed: %v", tt.url, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBuildkiteURL(%q) = %+v, want %+v", tt.url, got, tt.want)
+		}
+	}
+
+	if _, err := ParseBuildkiteURL("https://buildkite.com/myorg/mypipe"); err == nil {
+		t.Error("expected error for a pipeline URL")
+	}
+}
@@ -5,22 +5,20 @@ import (
 	"io"
 	"iter"
 	"os"
+	"strconv"
 
 	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/array"
 	"github.com/apache/arrow-go/v18/arrow/memory"
 	"github.com/apache/arrow-go/v18/parquet"
-	"github.com/apache/arrow-go/v18/parquet/compress"
 	"github.com/apache/arrow-go/v18/parquet/pqarrow"
 	"github.com/buildkite/buildkite-logs/logparser"
 )
 
-func createNewFileWriter(schema *arrow.Schema, w io.Writer, pool memory.Allocator) (*pqarrow.FileWriter, error) {
+func createNewFileWriter(schema *arrow.Schema, w io.Writer, pool memory.Allocator, props ...parquet.WriterProperty) (*pqarrow.FileWriter, error) {
 	// Create Parquet writer
 	writer, err := pqarrow.NewFileWriter(schema, w,
-		parquet.NewWriterProperties(
-			parquet.WithCompression(compress.Codecs.Zstd),
-		),
+		parquet.NewWriterProperties(props...),
 		pqarrow.NewArrowWriterProperties(
 			pqarrow.WithAllocator(pool),
 			pqarrow.WithCoerceTimestamps(arrow.Millisecond),
@@ -39,6 +37,10 @@ func createArrowSchema() *arrow.Schema {
 		{Name: "content", Type: arrow.BinaryTypes.String, Nullable: false},
 		{Name: "group", Type: arrow.BinaryTypes.String, Nullable: false},
 		{Name: "flags", Type: arrow.PrimitiveTypes.Int32, Nullable: false},
+		{Name: "hash", Type: arrow.PrimitiveTypes.Uint64, Nullable: false},
+		{Name: "severity", Type: arrow.PrimitiveTypes.Int8, Nullable: false},
+		{Name: "parent_group", Type: arrow.BinaryTypes.String, Nullable: false},
+		{Name: "depth", Type: arrow.PrimitiveTypes.Int32, Nullable: false},
 	}, nil)
 }
 
@@ -49,30 +51,62 @@ func (pw *ParquetWriter) createRecord(entries []*logparser.Entry) arrow.RecordBa
 	pw.contentBuilder.Resize(numEntries)
 	pw.groupBuilder.Resize(numEntries)
 	pw.flagsBuilder.Resize(numEntries)
+	pw.hashBuilder.Resize(numEntries)
+	pw.severityBuilder.Resize(numEntries)
+	pw.parentGroupBuilder.Resize(numEntries)
+	pw.depthBuilder.Resize(numEntries)
 
 	for _, entry := range entries {
 		pw.timestampBuilder.Append(entry.Timestamp.UnixMilli())
-		pw.contentBuilder.Append(entry.Content)
+		if pw.omitContent {
+			pw.contentBuilder.Append("")
+			pw.lengthBuilder.Append(int64(len(entry.Content)))
+		} else {
+			pw.contentBuilder.Append(entry.Content)
+		}
 		pw.groupBuilder.Append(entry.Group)
 		pw.flagsBuilder.Append(int32(entry.ComputeFlags()))
+		pw.hashBuilder.Append(ContentHash(entry.Content))
+		pw.severityBuilder.Append(int8(entry.Severity()))
+		pw.parentGroupBuilder.Append(entry.ParentGroup)
+		pw.depthBuilder.Append(int32(entry.Depth))
 	}
 
 	timestampArray := pw.timestampBuilder.NewArray()
 	contentArray := pw.contentBuilder.NewArray()
 	groupArray := pw.groupBuilder.NewArray()
 	flagsArray := pw.flagsBuilder.NewArray()
+	hashArray := pw.hashBuilder.NewArray()
+	severityArray := pw.severityBuilder.NewArray()
+	parentGroupArray := pw.parentGroupBuilder.NewArray()
+	depthArray := pw.depthBuilder.NewArray()
 
 	defer timestampArray.Release()
 	defer contentArray.Release()
 	defer groupArray.Release()
 	defer flagsArray.Release()
+	defer hashArray.Release()
+	defer severityArray.Release()
+	defer parentGroupArray.Release()
+	defer depthArray.Release()
 
-	return array.NewRecordBatch(pw.schema, []arrow.Array{
+	columns := []arrow.Array{
 		timestampArray,
 		contentArray,
 		groupArray,
 		flagsArray,
-	}, int64(numEntries))
+		hashArray,
+		severityArray,
+		parentGroupArray,
+		depthArray,
+	}
+	if pw.omitContent {
+		lengthArray := pw.lengthBuilder.NewArray()
+		defer lengthArray.Release()
+		columns = append(columns, lengthArray)
+	}
+
+	return array.NewRecordBatch(pw.schema, columns, int64(numEntries))
 }
 
 // ParquetWriter provides streaming Parquet writing capabilities
@@ -82,133 +116,244 @@ type ParquetWriter struct {
 	schema *arrow.Schema
 
 	// Persistent builders for string encoding
-	timestampBuilder *array.Int64Builder
-	contentBuilder   *array.StringBuilder
-	groupBuilder     *array.StringBuilder
-	flagsBuilder     *array.Int32Builder
+	timestampBuilder   *array.Int64Builder
+	contentBuilder     *array.StringBuilder
+	groupBuilder       *array.StringBuilder
+	flagsBuilder       *array.Int32Builder
+	hashBuilder        *array.Uint64Builder
+	severityBuilder    *array.Int8Builder
+	parentGroupBuilder *array.StringBuilder
+	depthBuilder       *array.Int32Builder
+	lengthBuilder      *array.Int64Builder // Only with omitContent
+
+	// omitContent writes empty content and a length column, see WithOmitContent
+	omitContent bool
+
+	// groupIndex records group row ranges, stored in file metadata on Close
+	groupIndex groupIndexBuilder
+
+	// sortedBy is stored under SortedByMetadataKey on Close when set
+	sortedBy string
+
+	// checksums records batch checksums, stored in file metadata on Close, when enabled
+	checksumsEnabled bool
+	checksums        *checksumWriter
+
+	// compression is the codec of the column chunks, see WithCompression, and
+	// compressionLevel its level, 0 for the codec's default
+	compression      ParquetCompression
+	compressionLevel int
+
+	// dictionary enables dictionary encoding of columns, see WithDictionary
+	dictionary bool
+
+	// rowGroupSize, when set, buffers entries in pending until a row group is full,
+	// see WithRowGroupSize
+	rowGroupSize int
+	pending      []*logparser.Entry
+
+	// producer is stored under ProducerMetadataKey on Close
+	producer ProducerInfo
+	// inputTruncatedAt, when set, fills in producer.InputTruncatedAt on Close
+	inputTruncatedAt func() int64
 }
 
 // NewParquetWriter creates a new Parquet writer for streaming
-func NewParquetWriter(file *os.File) (*ParquetWriter, error) {
-	return NewParquetWriterForWriter(file)
+func NewParquetWriter(file *os.File, opts ...ParquetWriterOption) (*ParquetWriter, error) {
+	return NewParquetWriterForWriter(file, opts...)
 }
 
 // NewParquetWriterForWriter creates a new Parquet writer backed by any io.Writer.
-func NewParquetWriterForWriter(w io.Writer) (*ParquetWriter, error) {
-	return newParquetWriterWithPool(w, memory.NewGoAllocator())
+func NewParquetWriterForWriter(w io.Writer, opts ...ParquetWriterOption) (*ParquetWriter, error) {
+	return newParquetWriterWithPool(w, memory.NewGoAllocator(), opts...)
 }
 
 // newParquetWriterWithPool creates a ParquetWriter using the provided allocator.
 // Used in tests to inject a memory.NewCheckedAllocator for leak detection.
-func newParquetWriterWithPool(w io.Writer, pool memory.Allocator) (*ParquetWriter, error) {
-	schema := createArrowSchema()
+func newParquetWriterWithPool(w io.Writer, pool memory.Allocator, opts ...ParquetWriterOption) (*ParquetWriter, error) {
+	pw := &ParquetWriter{
+		pool:             pool,
+		schema:           createArrowSchema(),
+		checksumsEnabled: true,
+		compression:      DefaultParquetCompression,
+		dictionary:       true,
+		producer:         currentProducer(),
+	}
+	for _, opt := range opts {
+		opt(pw)
+	}
+	if pw.omitContent {
+		pw.schema = arrow.NewSchema(append(pw.schema.Fields(),
+			arrow.Field{Name: "length", Type: arrow.PrimitiveTypes.Int64, Nullable: false}), nil)
+	}
+	if pw.checksumsEnabled {
+		pw.checksums = newChecksumWriter(w)
+		w = pw.checksums
+	}
 
-	writer, err := createNewFileWriter(schema, w, pool)
+	props := []parquet.WriterProperty{
+		parquet.WithCompression(pw.compression.codec()),
+		parquet.WithDictionaryDefault(pw.dictionary),
+	}
+	if pw.compressionLevel != 0 {
+		props = append(props, parquet.WithCompressionLevel(pw.compressionLevel))
+	}
+	writer, err := createNewFileWriter(pw.schema, w, pool, props...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Parquet writer: %w", err)
 	}
-
-	return &ParquetWriter{
-		writer: writer,
-		pool:   pool,
-		schema: schema,
-
-		// Initialize builders for string encoding
-		timestampBuilder: array.NewInt64Builder(pool),
-		contentBuilder:   array.NewStringBuilder(pool),
-		groupBuilder:     array.NewStringBuilder(pool),
-		flagsBuilder:     array.NewInt32Builder(pool),
-	}, nil
+	pw.writer = writer
+
+	// Initialize builders for string encoding
+	pw.timestampBuilder = array.NewInt64Builder(pool)
+	pw.contentBuilder = array.NewStringBuilder(pool)
+	pw.groupBuilder = array.NewStringBuilder(pool)
+	pw.flagsBuilder = array.NewInt32Builder(pool)
+	pw.hashBuilder = array.NewUint64Builder(pool)
+	pw.severityBuilder = array.NewInt8Builder(pool)
+	pw.parentGroupBuilder = array.NewStringBuilder(pool)
+	pw.depthBuilder = array.NewInt32Builder(pool)
+	if pw.omitContent {
+		pw.lengthBuilder = array.NewInt64Builder(pool)
+	}
+	return pw, nil
 }
 
-// WriteBatch writes a batch of log entries to the Parquet file
+// WriteBatch writes a batch of log entries to the Parquet file. Each batch is written as
+// a row group, unless WithRowGroupSize is set.
 func (pw *ParquetWriter) WriteBatch(entries []*logparser.Entry) error {
 	if len(entries) == 0 {
 		return nil
 	}
+	if pw.rowGroupSize <= 0 {
+		return pw.writeRowGroup(entries)
+	}
+
+	pw.pending = append(pw.pending, entries...)
+	for len(pw.pending) >= pw.rowGroupSize {
+		if err := pw.writeRowGroup(pw.pending[:pw.rowGroupSize]); err != nil {
+			return err
+		}
+		pw.pending = pw.pending[pw.rowGroupSize:]
+	}
+	return nil
+}
 
+// writeRowGroup writes entries as one row group (more when they exceed the Parquet
+// library's maximum row group length)
+func (pw *ParquetWriter) writeRowGroup(entries []*logparser.Entry) error {
 	record := pw.createRecord(entries)
 	defer record.Release()
 
-	return pw.writer.Write(record)
+	if err := pw.writer.Write(record); err != nil {
+		return err
+	}
+	pw.groupIndex.add(entries)
+	// Write flushes every row group it starts, so the batch's bytes are all written
+	if pw.checksums != nil {
+		pw.checksums.mark()
+	}
+	return nil
 }
 
-// Close closes the Parquet writer
+// Close writes the group index, parser version and producer (and sort order and
+// checksum) metadata and closes the Parquet writer
 func (pw *ParquetWriter) Close() error {
+	// Write the last, partial row group of WithRowGroupSize
+	var err error
+	if len(pw.pending) > 0 {
+		err = pw.writeRowGroup(pw.pending)
+		pw.pending = nil
+	}
+
 	// Release all builders
 	pw.timestampBuilder.Release()
 	pw.contentBuilder.Release()
 	pw.groupBuilder.Release()
 	pw.flagsBuilder.Release()
+	pw.hashBuilder.Release()
+	pw.severityBuilder.Release()
+	pw.parentGroupBuilder.Release()
+	pw.depthBuilder.Release()
+	if pw.lengthBuilder != nil {
+		pw.lengthBuilder.Release()
+	}
 
-	return pw.writer.Close()
+	if err == nil {
+		var index string
+		if index, err = pw.groupIndex.marshal(); err == nil {
+			err = pw.writer.AppendKeyValueMetadata(GroupIndexMetadataKey, index)
+		}
+	}
+	if err == nil {
+		err = pw.writer.AppendKeyValueMetadata(ParserVersionMetadataKey, strconv.Itoa(logparser.Version))
+	}
+	if pw.inputTruncatedAt != nil {
+		pw.producer.InputTruncatedAt = pw.inputTruncatedAt()
+	}
+	if err == nil {
+		var producer string
+		if producer, err = pw.producer.marshal(); err == nil {
+			err = pw.writer.AppendKeyValueMetadata(ProducerMetadataKey, producer)
+		}
+	}
+	if err == nil && pw.omitContent {
+		err = pw.writer.AppendKeyValueMetadata(ContentOmittedMetadataKey, "true")
+	}
+	if err == nil && pw.sortedBy != "" {
+		err = pw.writer.AppendKeyValueMetadata(SortedByMetadataKey, pw.sortedBy)
+	}
+	if err == nil && pw.checksums != nil {
+		var checksums string
+		if checksums, err = pw.checksums.marshal(); err == nil {
+			err = pw.writer.AppendKeyValueMetadata(ChecksumsMetadataKey, checksums)
+		}
+	}
+	if closeErr := pw.writer.Close(); closeErr != nil {
+		return closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write file metadata: %w", err)
+	}
+	return nil
 }
 
 // ExportSeq2ToParquet exports log entries using Go 1.23+ iter.Seq2 for efficient iteration
-func ExportSeq2ToParquet(seq iter.Seq2[*logparser.Entry, error], filename string) error {
-	_, err := ExportSeq2ToParquetWithFilterAndStats(seq, filename, nil)
+func ExportSeq2ToParquet(seq iter.Seq2[*logparser.Entry, error], filename string, opts ...ParquetWriterOption) error {
+	_, err := ExportSeq2ToParquetWithFilterAndStats(seq, filename, nil, opts...)
 	return err
 }
 
 // ExportSeq2ToParquetWithFilter exports filtered log entries using iter.Seq2
-func ExportSeq2ToParquetWithFilter(seq iter.Seq2[*logparser.Entry, error], filename string, filterFunc func(*logparser.Entry) bool) error {
-	_, err := ExportSeq2ToParquetWithFilterAndStats(seq, filename, filterFunc)
+func ExportSeq2ToParquetWithFilter(seq iter.Seq2[*logparser.Entry, error], filename string, filterFunc func(*logparser.Entry) bool, opts ...ParquetWriterOption) error {
+	_, err := ExportSeq2ToParquetWithFilterAndStats(seq, filename, filterFunc, opts...)
 	return err
 }
 
 // ExportSeq2ToParquetWithFilterAndStats exports filtered log entries and returns the number of rows written.
-func ExportSeq2ToParquetWithFilterAndStats(seq iter.Seq2[*logparser.Entry, error], filename string, filterFunc func(*logparser.Entry) bool) (int, error) {
+func ExportSeq2ToParquetWithFilterAndStats(seq iter.Seq2[*logparser.Entry, error], filename string, filterFunc func(*logparser.Entry) bool, opts ...ParquetWriterOption) (int, error) {
 	file, err := os.Create(filename) //nolint:gosec // caller-controlled path
 	if err != nil {
 		return 0, err
 	}
 	defer func() { _ = file.Close() }()
 
-	return ExportSeq2ToParquetWriterWithFilter(seq, file, filterFunc)
+	return ExportSeq2ToParquetWriterWithFilter(seq, file, filterFunc, opts...)
 }
 
 // ExportSeq2ToParquetWriter exports log entries to any io.Writer.
-func ExportSeq2ToParquetWriter(seq iter.Seq2[*logparser.Entry, error], w io.Writer) (int, error) {
-	return ExportSeq2ToParquetWriterWithFilter(seq, w, nil)
+func ExportSeq2ToParquetWriter(seq iter.Seq2[*logparser.Entry, error], w io.Writer, opts ...ParquetWriterOption) (int, error) {
+	return ExportSeq2ToParquetWriterWithFilter(seq, w, nil, opts...)
 }
 
 // ExportSeq2ToParquetWriterWithFilter exports filtered log entries to any io.Writer.
-func ExportSeq2ToParquetWriterWithFilter(seq iter.Seq2[*logparser.Entry, error], w io.Writer, filterFunc func(*logparser.Entry) bool) (int, error) {
-	writer, err := NewParquetWriterForWriter(w)
+func ExportSeq2ToParquetWriterWithFilter(seq iter.Seq2[*logparser.Entry, error], w io.Writer, filterFunc func(*logparser.Entry) bool, opts ...ParquetWriterOption) (int, error) {
+	writer, err := NewParquetWriterForWriter(w, opts...)
 	if err != nil {
 		return 0, err
 	}
+	writer.producer.Filtered = filterFunc != nil
 	defer func() { _ = writer.Close() }()
 
-	const batchSize = 1000
-	batch := make([]*logparser.Entry, 0, batchSize)
-	rows := 0
-
-	for entry, err := range seq {
-		if err != nil {
-			return rows, fmt.Errorf("error during iteration: %w", err)
-		}
-
-		if filterFunc != nil && !filterFunc(entry) {
-			continue
-		}
-
-		batch = append(batch, entry)
-		rows++
-
-		if len(batch) >= batchSize {
-			if err := writer.WriteBatch(batch); err != nil {
-				return rows, err
-			}
-			batch = batch[:0]
-		}
-	}
-
-	if len(batch) > 0 {
-		if err := writer.WriteBatch(batch); err != nil {
-			return rows, err
-		}
-	}
-
-	return rows, nil
+	return ExportSeq2ToLogWriter(seq, writer, filterFunc)
 }
@@ -6,6 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
 	"github.com/buildkite/buildkite-logs/logparser"
 )
 
@@ -96,3 +99,45 @@ func TestDictionaryEncodingAcrossBatches(t *testing.T) {
 		t.Errorf("Entry 2 content mismatch: got %s, want %s", entries[2].Content, "repeated content")
 	}
 }
+
+func TestGroupColumnReadAsDictionary(t *testing.T) {
+	entries := groupIndexTestEntries()
+	filename := writeGroupIndexTestFile(t, entries)
+
+	opened, err := fileSource(filename)()
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	defer opened.Close()
+	pf, err := file.NewParquetReader(opened.r)
+	if err != nil {
+		t.Fatalf("Failed to open parquet file: %v", err)
+	}
+	defer pf.Close()
+	arrowReader, err := newArrowFileReader(pf, 100, memory.NewGoAllocator())
+	if err != nil {
+		t.Fatalf("Failed to create arrow reader: %v", err)
+	}
+	schema, err := arrowReader.Schema()
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	if field, ok := schema.FieldsByName("group"); !ok || field[0].Type.ID() != arrow.DICTIONARY {
+		t.Fatalf("group column type = %v, want dictionary", field)
+	}
+
+	// Small batches cross row group and group boundaries
+	row := 0
+	for entry, err := range readParquetFileStreamingIter(t.Context(), fileSource(filename), 100, nil) {
+		if err != nil {
+			t.Fatalf("Failed to read entries: %v", err)
+		}
+		if entry.Group != entries[row].Group {
+			t.Fatalf("row %d group = %q, want %q", row, entry.Group, entries[row].Group)
+		}
+		row++
+	}
+	if row != len(entries) {
+		t.Errorf("read %d entries, want %d", row, len(entries))
+	}
+}
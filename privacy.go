@@ -0,0 +1,17 @@
+package buildkitelogs
+
+// ContentOmittedMetadataKey is the Parquet key/value metadata key marking files written
+// without log content (see WithOmitContent). Its value is "true".
+const ContentOmittedMetadataKey = "buildkite.content_omitted"
+
+// WithOmitContent sets whether the writer leaves log content out of the file, for
+// analytics on pipelines whose logs must not be stored. Timestamps, groups and flags are
+// written as usual, the content column is empty, and a "length" column records each
+// entry's content length in bytes alongside its ContentHash. Group names are kept, so
+// pipelines that put secrets in group headers should not rely on this alone. Default is
+// off.
+func WithOmitContent(enabled bool) ParquetWriterOption {
+	return func(pw *ParquetWriter) {
+		pw.omitContent = enabled
+	}
+}
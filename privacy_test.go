@@ -0,0 +1,70 @@
+package buildkitelogs
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestWithOmitContent(t *testing.T) {
+	ts := time.UnixMilli(1745322209921)
+	entries := []*logparser.Entry{
+		{Timestamp: ts, Content: "~~~ Running tests", Group: "~~~ Running tests"},
+		{Timestamp: ts.Add(time.Second), Content: "password=hunter2", Group: "~~~ Running tests"},
+	}
+	seq := func(yield func(*logparser.Entry, error) bool) {
+		for _, entry := range entries {
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+
+	filename := filepath.Join(t.TempDir(), "private.parquet")
+	if err := ExportSeq2ToParquet(seq, filename, WithOmitContent(true)); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	reader := NewParquetReader(filename)
+
+	i := 0
+	for entry, err := range reader.ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		want := entries[i]
+		if entry.Content != "" {
+			t.Errorf("row %d content = %q, want it omitted", i, entry.Content)
+		}
+		if entry.ContentLength() != int64(len(want.Content)) {
+			t.Errorf("row %d length = %d, want %d", i, entry.ContentLength(), len(want.Content))
+		}
+		if entry.Hash != ContentHash(want.Content) {
+			t.Errorf("row %d hash = %#x, want the hash of the original content", i, entry.Hash)
+		}
+		if entry.Group != want.Group || entry.Timestamp != want.Timestamp.UnixMilli() || entry.IsGroup() != want.IsGroup() {
+			t.Errorf("row %d = %+v, want timestamp, group and flags of %+v", i, entry, *want)
+		}
+		i++
+	}
+	if i != len(entries) {
+		t.Fatalf("read %d entries, want %d", i, len(entries))
+	}
+
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	if !info.ContentOmitted {
+		t.Error("ContentOmitted = false for a file written with WithOmitContent")
+	}
+
+	info, err = NewParquetReader(writeGroupIndexTestFile(t, entries)).GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	if info.ContentOmitted {
+		t.Error("ContentOmitted = true for a file with content")
+	}
+}
@@ -0,0 +1,105 @@
+package buildkitelogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/apache/arrow-go/v18/parquet/metadata"
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// ProducerMetadataKey is the Parquet key/value metadata key describing the program and
+// settings that wrote the file.
+const ProducerMetadataKey = "buildkite.producer"
+
+const modulePath = "github.com/buildkite/buildkite-logs"
+
+// ProducerInfo describes what wrote a Parquet file, so a file can be traced back to the
+// binary that wrote it and files written with old settings can be found.
+type ProducerInfo struct {
+	PackageVersion   string                 `json:"package_version"`              // Version of this module, "(devel)" for untagged builds
+	Binary           string                 `json:"binary,omitempty"`             // Main package path and version of the writing program
+	ParserOptions    *ProducerParserOptions `json:"parser_options,omitempty"`     // Set when the writer was given the parser, see WithSourceParser
+	Filtered         bool                   `json:"filtered"`                     // Entries passed through a filter before being written
+	InputTruncatedAt int64                  `json:"input_truncated_at,omitempty"` // Bytes of input kept when an oversized log was truncated, 0 if complete
+}
+
+// ProducerParserOptions are the parser options that affect the entries written.
+type ProducerParserOptions struct {
+	MaxLineBytes      int    `json:"max_line_bytes"`
+	TruncateLongLines bool   `json:"truncate_long_lines"`
+	TruncationSuffix  string `json:"truncation_suffix,omitempty"`
+}
+
+// WithSourceParser records the options of the parser that produced the written entries
+// in the file's ProducerInfo.
+func WithSourceParser(parser *logparser.Parser) ParquetWriterOption {
+	return func(pw *ParquetWriter) {
+		opts := parser.Options()
+		pw.producer.ParserOptions = &ProducerParserOptions{
+			MaxLineBytes:      opts.MaxLineBytes,
+			TruncateLongLines: opts.TruncateLongLines,
+		}
+		if opts.TruncateLongLines {
+			pw.producer.ParserOptions.TruncationSuffix = opts.TruncationSuffix
+		}
+	}
+}
+
+// withInputTruncation records the result of truncatedAt, called when the writer is
+// closed, as ProducerInfo.InputTruncatedAt.
+func withInputTruncation(truncatedAt func() int64) ParquetWriterOption {
+	return func(pw *ParquetWriter) {
+		pw.inputTruncatedAt = truncatedAt
+	}
+}
+
+// currentProducer describes the running program, from its embedded build information.
+var currentProducer = sync.OnceValue(func() ProducerInfo {
+	info := ProducerInfo{PackageVersion: "unknown"}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.Binary = build.Path
+	if build.Main.Version != "" {
+		info.Binary += "@" + build.Main.Version
+	}
+	if build.Main.Path == modulePath {
+		info.PackageVersion = build.Main.Version
+	}
+	for _, dep := range build.Deps {
+		if dep.Path != modulePath {
+			continue
+		}
+		info.PackageVersion = dep.Version
+		if dep.Replace != nil {
+			info.PackageVersion += " => " + dep.Replace.Path + "@" + dep.Replace.Version
+		}
+	}
+	return info
+})
+
+func (p ProducerInfo) marshal() (string, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func producerFromMetadata(md *metadata.FileMetaData) (*ProducerInfo, error) {
+	value := md.KeyValueMetadata().FindValue(ProducerMetadataKey)
+	if value == nil {
+		return nil, nil
+	}
+
+	var info ProducerInfo
+	if err := json.Unmarshal([]byte(*value), &info); err != nil {
+		return nil, fmt.Errorf("failed to decode producer info: %w", err)
+	}
+	return &info, nil
+}
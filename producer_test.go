@@ -0,0 +1,51 @@
+package buildkitelogs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestProducerMetadata(t *testing.T) {
+	parser := logparser.New(logparser.WithMaxLineBytes(1024), logparser.WithTruncateLongLines(true))
+	seq := func(yield func(*logparser.Entry, error) bool) {
+		for _, content := range []string{"keep", "drop"} {
+			if !yield(&logparser.Entry{Content: content}, nil) {
+				return
+			}
+		}
+	}
+	filename := filepath.Join(t.TempDir(), "produced.parquet")
+	filter := func(entry *logparser.Entry) bool { return entry.Content == "keep" }
+	if err := ExportSeq2ToParquetWithFilter(seq, filename, filter, WithSourceParser(parser)); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	info, err := NewParquetReader(filename).GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	producer := info.Producer
+	if producer == nil {
+		t.Fatal("expected producer info")
+	}
+	if producer.PackageVersion == "" {
+		t.Error("expected a package version")
+	}
+	if !producer.Filtered {
+		t.Error("expected Filtered for a filtered export")
+	}
+	want := ProducerParserOptions{MaxLineBytes: 1024, TruncateLongLines: true, TruncationSuffix: logparser.DefaultTruncationSuffix}
+	if producer.ParserOptions == nil || *producer.ParserOptions != want {
+		t.Errorf("ParserOptions = %+v, want %+v", producer.ParserOptions, want)
+	}
+
+	info, err = NewParquetReader(writeGroupIndexTestFile(t, groupIndexTestEntries())).GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	if info.Producer == nil || info.Producer.Filtered || info.Producer.ParserOptions != nil {
+		t.Errorf("unexpected producer for an unfiltered export without a parser: %+v", info.Producer)
+	}
+}
@@ -2,17 +2,22 @@ package buildkitelogs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"iter"
+	"log/slog"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/array"
 	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
 	"github.com/apache/arrow-go/v18/parquet/file"
 	"github.com/apache/arrow-go/v18/parquet/pqarrow"
 	"github.com/buildkite/buildkite-logs/logparser"
@@ -20,11 +25,16 @@ import (
 
 // ParquetLogEntry represents a log entry read from a Parquet file
 type ParquetLogEntry struct {
-	RowNumber int64              `json:"row_number"` // 0-based row position in the Parquet file
-	Timestamp int64              `json:"timestamp"`
-	Content   string             `json:"content"`
-	Group     string             `json:"group"`
-	Flags     logparser.LogFlags `json:"flags"`
+	RowNumber   int64              `json:"row_number"` // 0-based row position in the Parquet file
+	Timestamp   int64              `json:"timestamp"`
+	Content     string             `json:"content"`
+	Group       string             `json:"group"`
+	ParentGroup string             `json:"parent_group,omitempty"` // Group that Group is nested in; "" for top-level groups and in files written before the parent_group column
+	Depth       int                `json:"depth,omitempty"`        // Nesting depth of Group, from 0; 0 in files written before the depth column
+	Flags       logparser.LogFlags `json:"flags"`
+	Hash        uint64             `json:"hash,omitempty"`     // ContentHash of Content; 0 in files written before the hash column
+	Severity    logparser.Severity `json:"severity,omitempty"` // Inferred severity; SeverityUnknown in files written before the severity column
+	Length      int64              `json:"length,omitempty"`   // Content length in bytes, in files written with WithOmitContent
 }
 
 // HasTime returns true if the entry has a timestamp (backward compatibility)
@@ -37,6 +47,40 @@ func (entry *ParquetLogEntry) IsGroup() bool {
 	return entry.Flags.IsGroup()
 }
 
+// IsHeartbeat returns true if the entry is agent heartbeat/keepalive noise
+func (entry *ParquetLogEntry) IsHeartbeat() bool {
+	return !entry.IsGroup() && logparser.IsHeartbeat(entry.Content)
+}
+
+// ContentHash returns the entry's stored hash, computing it for files without a hash column
+func (entry *ParquetLogEntry) ContentHash() uint64 {
+	if entry.Hash != 0 {
+		return entry.Hash
+	}
+	return ContentHash(entry.Content)
+}
+
+// InferredSeverity returns the entry's stored severity, inferring it from Content and
+// Flags for files without a severity column
+func (entry *ParquetLogEntry) InferredSeverity() logparser.Severity {
+	if entry.Severity != logparser.SeverityUnknown {
+		return entry.Severity
+	}
+	if entry.IsGroup() {
+		return logparser.SeverityInfo
+	}
+	return logparser.InferSeverity(entry.Content)
+}
+
+// ContentLength returns the length in bytes of the entry's content, which is recorded
+// separately in files written without content.
+func (entry *ParquetLogEntry) ContentLength() int64 {
+	if entry.Length != 0 {
+		return entry.Length
+	}
+	return int64(len(entry.Content))
+}
+
 // CleanContent returns the content with optional ANSI stripping and whitespace trimming
 func (entry *ParquetLogEntry) CleanContent(stripANSI bool) string {
 	content := entry.Content
@@ -57,22 +101,45 @@ func (entry *ParquetLogEntry) CleanGroup(stripANSI bool) string {
 
 // GroupInfo contains statistical information about a log group
 type GroupInfo struct {
-	Name       string    `json:"name"`
-	EntryCount int       `json:"entry_count"`
-	FirstSeen  time.Time `json:"first_seen"`
-	LastSeen   time.Time `json:"last_seen"`
+	Name        string    `json:"name"`
+	ParentGroup string    `json:"parent_group,omitempty"` // Group this group is nested in, "" at the top level
+	Depth       int       `json:"depth,omitempty"`        // Nesting depth, from 0
+	EntryCount  int       `json:"entry_count"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
 }
 
 // SearchOptions configures regex search behavior
 type SearchOptions struct {
-	Pattern       string // Regex pattern to search for
-	CaseSensitive bool   // Enable case-sensitive matching
-	InvertMatch   bool   // Show non-matching lines
-	BeforeContext int    // Lines to show before match
-	AfterContext  int    // Lines to show after match
-	Context       int    // Lines to show before and after (overrides BeforeContext/AfterContext)
-	Reverse       bool   // Search backwards from end/seek position
-	SeekStart     int64  // Start search from this row (useful with Reverse)
+	Pattern        string    // Regex pattern to search for
+	CaseSensitive  bool      // Enable case-sensitive matching
+	InvertMatch    bool      // Show non-matching lines
+	BeforeContext  int       // Lines to show before match
+	AfterContext   int       // Lines to show after match
+	Context        int       // Lines to show before and after (overrides BeforeContext/AfterContext)
+	Reverse        bool      // Search backwards from end/seek position
+	SeekStart      int64     // Start search from this row (useful with Reverse)
+	DropHeartbeats bool      // Skip heartbeat/keepalive entries, including in context lines
+	Deadline       time.Time // Stop scanning at this time and yield ErrSearchTimeout (zero = no limit)
+	GroupContext   bool      // Set SearchResult.GroupHeader to the header of each match's group
+	JobURL         string    // Job's web URL (JobStatus.WebURL); sets SearchResult.URL to each match's line
+	MinTimestamp   time.Time // Only search entries logged at or after this time (zero = no limit)
+	MaxTimestamp   time.Time // Only search entries logged at or before this time (zero = no limit)
+
+	Level logparser.Severity // Only report matches of this inferred severity, see ParquetLogEntry.InferredSeverity (SeverityUnknown = any)
+}
+
+// ErrSearchTimeout is yielded, after the results found so far, by a search that reached
+// SearchOptions.Deadline before scanning the whole file.
+var ErrSearchTimeout = errors.New("search stopped at deadline; results are partial")
+
+// searchDeadlineCheckInterval is how many entries a search scans between clock checks.
+const searchDeadlineCheckInterval = 256
+
+// searchDeadlinePassed reports whether a search that has scanned n entries has reached
+// its deadline. The clock is only checked every searchDeadlineCheckInterval entries.
+func searchDeadlinePassed(deadline time.Time, n int64) bool {
+	return !deadline.IsZero() && n%searchDeadlineCheckInterval == 0 && !time.Now().Before(deadline)
 }
 
 // SearchResult represents a match with context lines
@@ -80,6 +147,16 @@ type SearchResult struct {
 	Match         ParquetLogEntry   `json:"match"`
 	BeforeContext []ParquetLogEntry `json:"before_context,omitempty"`
 	AfterContext  []ParquetLogEntry `json:"after_context,omitempty"`
+	GroupHeader   *ParquetLogEntry  `json:"group_header,omitempty"` // Header entry of the match's group, with SearchOptions.GroupContext
+	URL           string            `json:"url,omitempty"`          // Link to the match's line in the Buildkite UI, with SearchOptions.JobURL
+}
+
+// enclosingGroupHeader returns header if it is the header of entry's group.
+func enclosingGroupHeader(header *ParquetLogEntry, entry ParquetLogEntry) *ParquetLogEntry {
+	if header == nil || header.Group != entry.Group {
+		return nil
+	}
+	return header
 }
 
 // QueryStats contains performance and result statistics for queries
@@ -99,16 +176,66 @@ type QueryResult struct {
 
 // ParquetFileInfo contains metadata about a Parquet file
 type ParquetFileInfo struct {
-	RowCount     int64 `json:"row_count"`
-	ColumnCount  int   `json:"column_count"`
-	FileSize     int64 `json:"file_size_bytes"`
-	NumRowGroups int   `json:"num_row_groups"`
+	RowCount       int64         `json:"row_count"`
+	ColumnCount    int           `json:"column_count"`
+	FileSize       int64         `json:"file_size_bytes"`
+	NumRowGroups   int           `json:"num_row_groups"`
+	SortedBy       string        `json:"sorted_by,omitempty"`       // SortedByMetadataKey value, empty for files in log order
+	ContentOmitted bool          `json:"content_omitted,omitempty"` // Written with WithOmitContent, so entries have no content
+	Checksummed    bool          `json:"checksummed"`               // The file records checksums under ChecksumsMetadataKey
+	ParserVersion  int           `json:"parser_version"`            // logparser.Version of the writer, 0 if not recorded
+	Producer       *ProducerInfo `json:"producer,omitempty"`        // What wrote the file, nil if not recorded
 }
 
 // ParquetReader provides functionality to read and query Parquet log files
 type ParquetReader struct {
-	filename string
-	owned    bool // if true, Close() removes the file (it's a temp file we created)
+	filename    string
+	source      parquetSource
+	annotations []Annotation
+	columns     []string // Columns to read, nil for all (see WithColumns)
+
+	groupAliases *GroupAliases      // Canonicalizes group names, see WithGroupAliases
+	minSeverity  logparser.Severity // Entries below it are skipped, see WithMinSeverity
+	level        logparser.Severity // Entries of other severities are skipped, see WithLevel
+
+	validateChecksums bool
+	logger            *slog.Logger // See WithLogger
+}
+
+// parquetSource opens the bytes of a Parquet file for reading.
+type parquetSource func() (*openedParquet, error)
+
+// openedParquet is an open handle on Parquet file bytes.
+type openedParquet struct {
+	r     parquet.ReaderAtSeeker
+	size  int64
+	close func() error
+}
+
+// Close releases the underlying handle.
+func (o *openedParquet) Close() error {
+	if o.close != nil {
+		return o.close()
+	}
+	return nil
+}
+
+// fileSource returns a parquetSource reading the named file from the local filesystem.
+func fileSource(filename string) parquetSource {
+	return func() (*openedParquet, error) {
+		osFile, err := os.Open(filename) //nolint:gosec // caller-controlled path
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file: %w", err)
+		}
+
+		fileInfo, err := osFile.Stat()
+		if err != nil {
+			_ = osFile.Close()
+			return nil, fmt.Errorf("failed to get file info: %w", err)
+		}
+
+		return &openedParquet{r: osFile, size: fileInfo.Size(), close: osFile.Close}, nil
+	}
 }
 
 // NewParquetReader creates a new ParquetReader for the specified file.
@@ -116,64 +243,161 @@ type ParquetReader struct {
 func NewParquetReader(filename string) *ParquetReader {
 	return &ParquetReader{
 		filename: filename,
+		source:   fileSource(filename),
 	}
 }
 
-// newParquetReaderOwned creates a ParquetReader that owns the underlying file.
-// Close() will remove the file.
-func newParquetReaderOwned(filename string) *ParquetReader {
+// NewParquetReaderFromReaderAt creates a ParquetReader over size bytes of Parquet data
+// read through r, such as the handle returned by Client.OpenCached. The caller retains
+// ownership of r and must close it once the reader is no longer used.
+func NewParquetReaderFromReaderAt(r io.ReaderAt, size int64) *ParquetReader {
 	return &ParquetReader{
-		filename: filename,
-		owned:    true,
+		source: func() (*openedParquet, error) {
+			return &openedParquet{r: io.NewSectionReader(r, 0, size), size: size}, nil
+		},
 	}
 }
 
-// Close cleans up resources. If the reader owns the file (created via Client.NewReader),
-// Close removes the temporary file. For readers created via NewParquetReader, Close is a no-op.
-func (pr *ParquetReader) Close() error {
-	if pr.owned {
-		return os.Remove(pr.filename)
+// NewParquetReaderFromBlob creates a ParquetReader over a blob, such as a cached log,
+// without copying it to a local file. Each query opens the blob with
+// BlobStorage.OpenReaderAt using ctx, so remote backends fetch only the footer and the
+// row groups the query reads. Blobs stored as plain files (file://) are read in place.
+func NewParquetReaderFromBlob(ctx context.Context, storage *BlobStorage, key string) *ParquetReader {
+	return &ParquetReader{
+		source: func() (*openedParquet, error) {
+			blob, err := storage.OpenReaderAt(ctx, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open blob: %w", err)
+			}
+			return &openedParquet{r: io.NewSectionReader(blob, 0, blob.Size()), size: blob.Size(), close: blob.Close}, nil
+		},
 	}
+}
+
+// Close releases resources held by the reader. Readers never remove the file they read,
+// so Close is currently a no-op; it is kept so callers don't depend on that.
+func (pr *ParquetReader) Close() error {
 	return nil
 }
 
 // ReadEntriesIter returns an iterator over log entries from the Parquet file
 func (pr *ParquetReader) ReadEntriesIter(ctx context.Context) iter.Seq2[ParquetLogEntry, error] {
-	return readParquetFileIter(ctx, pr.filename)
+	return pr.viewEntries(readParquetFileIter(ctx, pr.source, pr.readColumns()))
 }
 
-// FilterByGroupIter returns an iterator over entries that belong to groups matching the specified name pattern
+// FilterByGroupIter returns an iterator over entries that belong to groups matching the specified name pattern.
+// When the file contains a group index, only the row groups holding matching groups are read.
 func (pr *ParquetReader) FilterByGroupIter(ctx context.Context, groupPattern string) iter.Seq2[ParquetLogEntry, error] {
-	return FilterByGroupIter(pr.ReadEntriesIter(ctx), groupPattern)
+	return pr.filterGroupsIter(ctx, func(group string) bool { return groupMatches(group, groupPattern) })
+}
+
+// FilterByGroupNameIter returns an iterator over entries of the group named exactly name,
+// for when a FilterByGroupIter pattern also matches other groups. Entries without a group
+// are in the group named "<no group>".
+func (pr *ParquetReader) FilterByGroupNameIter(ctx context.Context, name string) iter.Seq2[ParquetLogEntry, error] {
+	return pr.filterGroupsIter(ctx, func(group string) bool { return groupDisplayName(group) == name })
+}
+
+// MatchingGroups returns the distinct names of the groups matching the FilterByGroupIter
+// pattern, in the order they first appear. Each name can be passed to
+// FilterByGroupNameIter. Files with a group index are answered from the index alone.
+func (pr *ParquetReader) MatchingGroups(ctx context.Context, groupPattern string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(group string) {
+		group = pr.canonicalGroup(group)
+		name := groupDisplayName(group)
+		if !seen[name] && groupMatches(group, groupPattern) {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	index, ok, err := readParquetGroupIndex(pr.source)
+	if err == nil && ok {
+		for _, r := range index {
+			add(r.Group)
+		}
+		return names, nil
+	}
+	pr.logUnreadableGroupIndex(err)
+	for entry, err := range readParquetFileIter(ctx, pr.source, []string{"group"}) {
+		if err != nil {
+			return nil, err
+		}
+		add(entry.Group)
+	}
+	return names, nil
+}
+
+func (pr *ParquetReader) filterGroupsIter(ctx context.Context, match func(group string) bool) iter.Seq2[ParquetLogEntry, error] {
+	if pr.groupAliases != nil {
+		matchName := match
+		match = func(group string) bool { return matchName(pr.canonicalGroup(group)) }
+	}
+	return pr.viewEntries(func(yield func(ParquetLogEntry, error) bool) {
+		index, ok, err := readParquetGroupIndex(pr.source)
+		if err != nil || !ok {
+			// Files without an index (or with an unreadable one) fall back to a full scan
+			pr.logUnreadableGroupIndex(err)
+			for entry, err := range readParquetFileIter(ctx, pr.source, pr.readColumns("group")) {
+				if err == nil && !match(entry.Group) {
+					continue
+				}
+				if !yield(entry, err) {
+					return
+				}
+			}
+			return
+		}
+
+		ranges := matchingGroupRanges(index, match)
+		for entry, err := range readParquetFileRowRangesIter(ctx, pr.source, ranges, pr.readColumns("group")) {
+			if !yield(entry, err) {
+				return
+			}
+		}
+	})
+}
+
+// GroupIndex returns the group row ranges recorded when the file was written.
+// The boolean result is false for files written without a group index.
+func (pr *ParquetReader) GroupIndex() ([]GroupRange, bool, error) {
+	return readParquetGroupIndex(pr.source)
 }
 
 // SeekToRow returns an iterator starting from the specified row number (0-based)
 func (pr *ParquetReader) SeekToRow(ctx context.Context, startRow int64) iter.Seq2[ParquetLogEntry, error] {
-	return readParquetFileFromRowIter(ctx, pr.filename, startRow)
+	return pr.viewEntries(readParquetFileFromRowIter(ctx, pr.source, startRow, pr.readColumns()))
 }
 
 // GetFileInfo returns metadata about the Parquet file
 func (pr *ParquetReader) GetFileInfo() (*ParquetFileInfo, error) {
-	return getParquetFileInfo(pr.filename)
+	return getParquetFileInfo(pr.source)
 }
 
 // SearchEntriesIter returns an iterator over search results with context
 func (pr *ParquetReader) SearchEntriesIter(ctx context.Context, options SearchOptions) iter.Seq2[SearchResult, error] {
-	return searchParquetFileIter(ctx, pr.filename, options)
+	columns := pr.readColumns("content", "flags")
+	if columns != nil && options.Level != logparser.SeverityUnknown {
+		columns = append(columns, "severity") // Matches are filtered on their severity
+	}
+	return pr.viewSearchResults(searchParquetFileIter(ctx, pr.source, options, columns))
 }
 
 // ReadParquetFileIter is a convenience function to get an iterator over entries from a Parquet file
 func ReadParquetFileIter(ctx context.Context, filename string) iter.Seq2[ParquetLogEntry, error] {
-	return readParquetFileStreamingIter(ctx, filename, 5000)
+	return readParquetFileStreamingIter(ctx, fileSource(filename), 5000, nil)
 }
 
-// readParquetFileIter reads a Parquet file and returns an iterator over log entries using streaming
-func readParquetFileIter(ctx context.Context, filename string) iter.Seq2[ParquetLogEntry, error] {
-	return readParquetFileStreamingIter(ctx, filename, 5000) // Use 5000 as default batch size
+// readParquetFileIter reads the columns (nil for all) of a Parquet file and returns an
+// iterator over log entries using streaming
+func readParquetFileIter(ctx context.Context, src parquetSource, columns []string) iter.Seq2[ParquetLogEntry, error] {
+	return readParquetFileStreamingIter(ctx, src, 5000, columns) // Use 5000 as default batch size
 }
 
 // readParquetFileStreamingIter reads a Parquet file using GetRecordReader for true streaming
-func readParquetFileStreamingIter(ctx context.Context, filename string, batchSize int64) iter.Seq2[ParquetLogEntry, error] {
+func readParquetFileStreamingIter(ctx context.Context, src parquetSource, batchSize int64, columns []string) iter.Seq2[ParquetLogEntry, error] {
 	return func(yield func(ParquetLogEntry, error) bool) {
 		// Resource management with proper cleanup order
 		resources := make([]func(), 0)
@@ -184,18 +408,18 @@ func readParquetFileStreamingIter(ctx context.Context, filename string, batchSiz
 		}()
 
 		// Open the Parquet file
-		osFile, err := os.Open(filename)
+		opened, err := src()
 		if err != nil {
-			yield(ParquetLogEntry{}, fmt.Errorf("failed to open file: %w", err))
+			yield(ParquetLogEntry{}, err)
 			return
 		}
-		resources = append(resources, func() { _ = osFile.Close() })
+		resources = append(resources, func() { _ = opened.Close() })
 
 		// Create a memory pool
 		pool := memory.NewGoAllocator()
 
 		// Create a Parquet file reader using Arrow v18 API
-		pf, err := file.NewParquetReader(osFile)
+		pf, err := file.NewParquetReader(opened.r)
 		if err != nil {
 			yield(ParquetLogEntry{}, fmt.Errorf("failed to open parquet file: %w", err))
 			return
@@ -203,16 +427,20 @@ func readParquetFileStreamingIter(ctx context.Context, filename string, batchSiz
 		resources = append(resources, func() { _ = pf.Close() })
 
 		// Create an Arrow file reader with streaming configuration
-		arrowReader, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{
-			BatchSize: batchSize, // Configure batch size for streaming
-		}, pool)
+		arrowReader, err := newArrowFileReader(pf, batchSize, pool)
 		if err != nil {
 			yield(ParquetLogEntry{}, fmt.Errorf("failed to create arrow reader: %w", err))
 			return
 		}
 
-		// Get record reader for true streaming (all columns, all row groups)
-		recordReader, err := arrowReader.GetRecordReader(ctx, nil, nil)
+		colIndices, err := projectColumns(pf.MetaData(), columns)
+		if err != nil {
+			yield(ParquetLogEntry{}, err)
+			return
+		}
+
+		// Get record reader for true streaming (projected columns, all row groups)
+		recordReader, err := arrowReader.GetRecordReader(ctx, colIndices, nil)
 		if err != nil {
 			yield(ParquetLogEntry{}, fmt.Errorf("failed to create record reader: %w", err))
 			return
@@ -242,7 +470,7 @@ func readParquetFileStreamingIter(ctx context.Context, filename string, batchSiz
 
 			// Initialize column mapping on first record
 			if columnIndices == nil {
-				columnIndices, err = mapColumns(record.Schema())
+				columnIndices, err = mapColumns(record.Schema(), colIndices != nil)
 				if err != nil {
 					record.Release()
 					yield(ParquetLogEntry{}, err)
@@ -276,15 +504,30 @@ func readParquetFileStreamingIter(ctx context.Context, filename string, batchSiz
 	}
 }
 
+// newArrowFileReader creates an Arrow reader over pf that decodes the group and
+// parent_group columns as dictionaries. Group names repeat on nearly every row, so this
+// lets batches share one string per distinct group instead of copying it for every entry.
+func newArrowFileReader(pf *file.Reader, batchSize int64, pool memory.Allocator) (*pqarrow.FileReader, error) {
+	props := pqarrow.ArrowReadProperties{BatchSize: batchSize}
+	for _, name := range []string{"group", "parent_group"} {
+		if idx := pf.MetaData().Schema.ColumnIndexByName(name); idx >= 0 {
+			props.SetReadDict(idx, true)
+		}
+	}
+	return pqarrow.NewFileReader(pf, props, pool)
+}
+
 // columnMapping holds column indices for efficient access
 type columnMapping struct {
-	timestampIdx, contentIdx, groupIdx, flagsIdx int
+	timestampIdx, contentIdx, groupIdx, flagsIdx, hashIdx, severityIdx, lengthIdx int
+	parentGroupIdx, depthIdx                                                      int
 }
 
 // mapColumns maps column names to indices from schema
-func mapColumns(schema *arrow.Schema) (*columnMapping, error) {
+func mapColumns(schema *arrow.Schema, projected bool) (*columnMapping, error) {
 	mapping := &columnMapping{
-		timestampIdx: -1, contentIdx: -1, groupIdx: -1, flagsIdx: -1,
+		timestampIdx: -1, contentIdx: -1, groupIdx: -1, flagsIdx: -1, hashIdx: -1, severityIdx: -1, lengthIdx: -1,
+		parentGroupIdx: -1, depthIdx: -1,
 	}
 
 	for i, field := range schema.Fields() {
@@ -297,10 +540,21 @@ func mapColumns(schema *arrow.Schema) (*columnMapping, error) {
 			mapping.groupIdx = i
 		case "flags":
 			mapping.flagsIdx = i
+		case "hash":
+			mapping.hashIdx = i
+		case "severity":
+			mapping.severityIdx = i
+		case "length":
+			mapping.lengthIdx = i
+		case "parent_group":
+			mapping.parentGroupIdx = i
+		case "depth":
+			mapping.depthIdx = i
 		}
 	}
 
-	if mapping.timestampIdx == -1 || mapping.contentIdx == -1 {
+	// A projection may leave out any column
+	if !projected && (mapping.timestampIdx == -1 || mapping.contentIdx == -1) {
 		return nil, fmt.Errorf("required columns 'timestamp' and 'content' not found")
 	}
 
@@ -313,16 +567,49 @@ func convertRecordToEntriesIterStreaming(record arrow.RecordBatch, mapping *colu
 		numRows := int(record.NumRows())
 
 		// Get column arrays
-		timestampCol := record.Column(mapping.timestampIdx)
-		contentCol := record.Column(mapping.contentIdx)
-
-		var groupCol, flagsCol arrow.Array
+		var timestampCol, contentCol, groupCol, flagsCol, hashCol, severityCol, lengthCol arrow.Array
+		if mapping.timestampIdx >= 0 {
+			timestampCol = record.Column(mapping.timestampIdx)
+		}
+		if mapping.contentIdx >= 0 {
+			contentCol = record.Column(mapping.contentIdx)
+		}
+		var groupDict *array.Dictionary
+		var groupNames []string // Dictionary values, materialized on first use
+		var groupDecoded []bool
 		if mapping.groupIdx >= 0 {
 			groupCol = record.Column(mapping.groupIdx)
+			if dict, ok := groupCol.(*array.Dictionary); ok {
+				groupDict = dict
+				groupNames = make([]string, dict.Dictionary().Len())
+				groupDecoded = make([]bool, len(groupNames))
+			}
 		}
 		if mapping.flagsIdx >= 0 {
 			flagsCol = record.Column(mapping.flagsIdx)
 		}
+		if mapping.hashIdx >= 0 {
+			hashCol = record.Column(mapping.hashIdx)
+		}
+		if mapping.severityIdx >= 0 {
+			severityCol = record.Column(mapping.severityIdx)
+		}
+		if mapping.lengthIdx >= 0 {
+			lengthCol = record.Column(mapping.lengthIdx)
+		}
+		var parentGroupCol, depthCol arrow.Array
+		var parentNames []string // Dictionary values of parent_group, materialized on first use
+		var parentDecoded []bool
+		if mapping.parentGroupIdx >= 0 {
+			parentGroupCol = record.Column(mapping.parentGroupIdx)
+			if dict, ok := parentGroupCol.(*array.Dictionary); ok {
+				parentNames = make([]string, dict.Dictionary().Len())
+				parentDecoded = make([]bool, len(parentNames))
+			}
+		}
+		if mapping.depthIdx >= 0 {
+			depthCol = record.Column(mapping.depthIdx)
+		}
 
 		// Convert each row
 		for i := 0; i < numRows; i++ {
@@ -330,8 +617,8 @@ func convertRecordToEntriesIterStreaming(record arrow.RecordBatch, mapping *colu
 				RowNumber: startRowNumber + int64(i), // Set the absolute row position
 			}
 
-			// Timestamp (required)
-			if timestampCol.IsNull(i) {
+			// Timestamp (required unless projected away)
+			if timestampCol == nil || timestampCol.IsNull(i) {
 				entry.Timestamp = 0
 			} else {
 				switch ts := timestampCol.(type) {
@@ -343,8 +630,8 @@ func convertRecordToEntriesIterStreaming(record arrow.RecordBatch, mapping *colu
 				}
 			}
 
-			// Content (required)
-			if contentCol.IsNull(i) {
+			// Content (required unless projected away)
+			if contentCol == nil || contentCol.IsNull(i) {
 				entry.Content = ""
 			} else {
 				switch content := contentCol.(type) {
@@ -361,6 +648,13 @@ func convertRecordToEntriesIterStreaming(record arrow.RecordBatch, mapping *colu
 			// Group (optional)
 			if groupCol != nil && !groupCol.IsNull(i) {
 				switch group := groupCol.(type) {
+				case *array.Dictionary:
+					idx := groupDict.GetValueIndex(i)
+					if !groupDecoded[idx] {
+						groupNames[idx] = dictionaryString(group.Dictionary(), idx)
+						groupDecoded[idx] = true
+					}
+					entry.Group = groupNames[idx]
 				case *array.String:
 					entry.Group = group.Value(i)
 				case *array.Binary:
@@ -368,6 +662,28 @@ func convertRecordToEntriesIterStreaming(record arrow.RecordBatch, mapping *colu
 				}
 			}
 
+			// Parent group and depth (optional, absent in older files)
+			if parentGroupCol != nil && !parentGroupCol.IsNull(i) {
+				switch parent := parentGroupCol.(type) {
+				case *array.Dictionary:
+					idx := parent.GetValueIndex(i)
+					if !parentDecoded[idx] {
+						parentNames[idx] = dictionaryString(parent.Dictionary(), idx)
+						parentDecoded[idx] = true
+					}
+					entry.ParentGroup = parentNames[idx]
+				case *array.String:
+					entry.ParentGroup = parent.Value(i)
+				case *array.Binary:
+					entry.ParentGroup = string(parent.Value(i))
+				}
+			}
+			if depthCol != nil && !depthCol.IsNull(i) {
+				if intCol, ok := depthCol.(*array.Int32); ok {
+					entry.Depth = int(intCol.Value(i))
+				}
+			}
+
 			// Flags field (optional)
 			if flagsCol != nil && !flagsCol.IsNull(i) {
 				if intCol, ok := flagsCol.(*array.Int32); ok {
@@ -375,6 +691,27 @@ func convertRecordToEntriesIterStreaming(record arrow.RecordBatch, mapping *colu
 				}
 			}
 
+			// Hash field (optional, absent in older files)
+			if hashCol != nil && !hashCol.IsNull(i) {
+				if uintCol, ok := hashCol.(*array.Uint64); ok {
+					entry.Hash = uintCol.Value(i)
+				}
+			}
+
+			// Severity field (optional, absent in older files)
+			if severityCol != nil && !severityCol.IsNull(i) {
+				if intCol, ok := severityCol.(*array.Int8); ok {
+					entry.Severity = logparser.Severity(intCol.Value(i))
+				}
+			}
+
+			// Length field (only in files written without content)
+			if lengthCol != nil && !lengthCol.IsNull(i) {
+				if intCol, ok := lengthCol.(*array.Int64); ok {
+					entry.Length = intCol.Value(i)
+				}
+			}
+
 			if !yield(entry, nil) {
 				return
 			}
@@ -382,6 +719,17 @@ func convertRecordToEntriesIterStreaming(record arrow.RecordBatch, mapping *colu
 	}
 }
 
+// dictionaryString returns value i of a string or binary dictionary.
+func dictionaryString(dict arrow.Array, i int) string {
+	switch values := dict.(type) {
+	case *array.String:
+		return values.Value(i)
+	case *array.Binary:
+		return string(values.Value(i))
+	}
+	return ""
+}
+
 // FilterByGroupIter returns an iterator over entries that belong to groups matching the specified pattern
 func FilterByGroupIter(entries iter.Seq2[ParquetLogEntry, error], groupPattern string) iter.Seq2[ParquetLogEntry, error] {
 	return func(yield func(ParquetLogEntry, error) bool) {
@@ -393,37 +741,47 @@ func FilterByGroupIter(entries iter.Seq2[ParquetLogEntry, error], groupPattern s
 				continue
 			}
 
-			entryGroup := entry.Group
-			if entryGroup == "" {
-				entryGroup = "<no group>"
+			if groupMatches(entry.Group, groupPattern) {
+				if !yield(entry, nil) {
+					return
+				}
 			}
+		}
+	}
+}
 
-			if strings.Contains(strings.ToLower(entryGroup), strings.ToLower(groupPattern)) {
-				if !yield(entry, nil) {
+// DropHeartbeatsIter returns an iterator over entries with heartbeat/keepalive noise removed
+func DropHeartbeatsIter(entries iter.Seq2[ParquetLogEntry, error]) iter.Seq2[ParquetLogEntry, error] {
+	return func(yield func(ParquetLogEntry, error) bool) {
+		for entry, err := range entries {
+			if err != nil {
+				if !yield(ParquetLogEntry{}, err) {
 					return
 				}
+				continue
+			}
+
+			if entry.IsHeartbeat() {
+				continue
+			}
+			if !yield(entry, nil) {
+				return
 			}
 		}
 	}
 }
 
 // getParquetFileInfo returns metadata about the Parquet file
-func getParquetFileInfo(filename string) (*ParquetFileInfo, error) {
+func getParquetFileInfo(src parquetSource) (*ParquetFileInfo, error) {
 	// Open the file to get file size
-	osFile, err := os.Open(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
-	}
-	defer osFile.Close()
-
-	// Get file size
-	fileInfo, err := osFile.Stat()
+	opened, err := src()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get file info: %w", err)
+		return nil, err
 	}
+	defer opened.Close()
 
 	// Create Parquet file reader
-	pf, err := file.NewParquetReader(osFile)
+	pf, err := file.NewParquetReader(opened.r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open parquet file: %w", err)
 	}
@@ -441,15 +799,28 @@ func getParquetFileInfo(filename string) (*ParquetFileInfo, error) {
 	info := &ParquetFileInfo{
 		RowCount:     metadata.GetNumRows(),
 		ColumnCount:  columnCount,
-		FileSize:     fileInfo.Size(),
+		FileSize:     opened.size,
 		NumRowGroups: metadata.NumRowGroups(),
 	}
+	if sortedBy := metadata.KeyValueMetadata().FindValue(SortedByMetadataKey); sortedBy != nil {
+		info.SortedBy = *sortedBy
+	}
+	info.Checksummed = metadata.KeyValueMetadata().FindValue(ChecksumsMetadataKey) != nil
+	info.ContentOmitted = metadata.KeyValueMetadata().FindValue(ContentOmittedMetadataKey) != nil
+	if parserVersion := metadata.KeyValueMetadata().FindValue(ParserVersionMetadataKey); parserVersion != nil {
+		if info.ParserVersion, err = strconv.Atoi(*parserVersion); err != nil {
+			return nil, fmt.Errorf("failed to decode parser version: %w", err)
+		}
+	}
+	if info.Producer, err = producerFromMetadata(metadata); err != nil {
+		return nil, err
+	}
 
 	return info, nil
 }
 
 // readParquetFileFromRowIter reads a Parquet file starting from a specific row
-func readParquetFileFromRowIter(ctx context.Context, filename string, startRow int64) iter.Seq2[ParquetLogEntry, error] {
+func readParquetFileFromRowIter(ctx context.Context, src parquetSource, startRow int64, columns []string) iter.Seq2[ParquetLogEntry, error] {
 	return func(yield func(ParquetLogEntry, error) bool) {
 		// Resource management with proper cleanup order
 		resources := make([]func(), 0)
@@ -460,18 +831,18 @@ func readParquetFileFromRowIter(ctx context.Context, filename string, startRow i
 		}()
 
 		// Open the Parquet file
-		osFile, err := os.Open(filename)
+		opened, err := src()
 		if err != nil {
-			yield(ParquetLogEntry{}, fmt.Errorf("failed to open file: %w", err))
+			yield(ParquetLogEntry{}, err)
 			return
 		}
-		resources = append(resources, func() { _ = osFile.Close() })
+		resources = append(resources, func() { _ = opened.Close() })
 
 		// Create a memory pool
 		pool := memory.NewGoAllocator()
 
 		// Create a Parquet file reader using Arrow v18 API
-		pf, err := file.NewParquetReader(osFile)
+		pf, err := file.NewParquetReader(opened.r)
 		if err != nil {
 			yield(ParquetLogEntry{}, fmt.Errorf("failed to open parquet file: %w", err))
 			return
@@ -486,16 +857,20 @@ func readParquetFileFromRowIter(ctx context.Context, filename string, startRow i
 		}
 
 		// Create an Arrow file reader
-		arrowReader, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{
-			BatchSize: 5000, // Default batch size
-		}, pool)
+		arrowReader, err := newArrowFileReader(pf, 5000, pool) // Default batch size
 		if err != nil {
 			yield(ParquetLogEntry{}, fmt.Errorf("failed to create arrow reader: %w", err))
 			return
 		}
 
-		// Get record reader for all columns and row groups
-		recordReader, err := arrowReader.GetRecordReader(ctx, nil, nil)
+		colIndices, err := projectColumns(pf.MetaData(), columns)
+		if err != nil {
+			yield(ParquetLogEntry{}, err)
+			return
+		}
+
+		// Get record reader for the projected columns and all row groups
+		recordReader, err := arrowReader.GetRecordReader(ctx, colIndices, nil)
 		if err != nil {
 			yield(ParquetLogEntry{}, fmt.Errorf("failed to create record reader: %w", err))
 			return
@@ -527,7 +902,7 @@ func readParquetFileFromRowIter(ctx context.Context, filename string, startRow i
 
 			// Initialize column mapping on first record
 			if columnIndices == nil {
-				columnIndices, err = mapColumns(record.Schema())
+				columnIndices, err = mapColumns(record.Schema(), colIndices != nil)
 				if err != nil {
 					record.Release()
 					yield(ParquetLogEntry{}, err)
@@ -562,8 +937,12 @@ func readParquetFileFromRowIter(ctx context.Context, filename string, startRow i
 }
 
 // searchParquetFileIter implements streaming search with context
-func searchParquetFileIter(ctx context.Context, filename string, options SearchOptions) iter.Seq2[SearchResult, error] {
+func searchParquetFileIter(ctx context.Context, src parquetSource, options SearchOptions, columns []string) iter.Seq2[SearchResult, error] {
 	return func(yield func(SearchResult, error) bool) {
+		if options.JobURL != "" {
+			yield = withLineURLs(yield, options.JobURL)
+		}
+
 		// Compile regex pattern
 		regex, err := compileRegexPattern(options.Pattern, options.CaseSensitive)
 		if err != nil {
@@ -571,49 +950,98 @@ func searchParquetFileIter(ctx context.Context, filename string, options SearchO
 			return
 		}
 
-		// Determine context lines
-		beforeContext := options.BeforeContext
-		afterContext := options.AfterContext
-		if options.Context > 0 {
-			beforeContext = options.Context
-			afterContext = options.Context
-		}
+		beforeContext, afterContext := searchContextLines(options)
 
 		// Handle reverse search by collecting all entries first
 		if options.Reverse {
-			searchReverseParquetFileIter(ctx, filename, options, regex, beforeContext, afterContext, yield)
+			entryIter := readParquetFileIter(ctx, src, columns)
+			if options.hasTimeRange() {
+				entryIter = readTimeRangeIter(ctx, src, 0, options, columns)
+			}
+			if options.DropHeartbeats {
+				entryIter = DropHeartbeatsIter(entryIter)
+			}
+			searchReverseEntries(entryIter, options, regex, beforeContext, afterContext, yield)
 			return
 		}
 
 		// Forward search (original implementation)
-		searchForwardParquetFileIter(ctx, filename, options, regex, beforeContext, afterContext, yield)
+		searchForwardParquetFileIter(ctx, src, columns, options, regex, beforeContext, afterContext, yield)
+	}
+}
+
+// searchContextLines returns the before and after context lines of options
+func searchContextLines(options SearchOptions) (int, int) {
+	if options.Context > 0 {
+		return options.Context, options.Context
 	}
+	return options.BeforeContext, options.AfterContext
 }
 
 // searchForwardParquetFileIter implements forward search (original behavior)
-func searchForwardParquetFileIter(ctx context.Context, filename string, options SearchOptions, regex *regexp.Regexp, beforeContext, afterContext int, yield func(SearchResult, error) bool) {
+func searchForwardParquetFileIter(ctx context.Context, src parquetSource, columns []string, options SearchOptions, regex *regexp.Regexp, beforeContext, afterContext int, yield func(SearchResult, error) bool) {
 	// Stream entries and perform search with context buffering
-	var beforeBuffer []ParquetLogEntry
-	var afterCollecting int
-	var currentResult *SearchResult
 	totalEntries := int64(0)
 
 	// Determine starting iterator
 	var entryIter iter.Seq2[ParquetLogEntry, error]
-	if options.SeekStart > 0 {
-		entryIter = readParquetFileFromRowIter(ctx, filename, options.SeekStart)
+	if options.hasTimeRange() {
+		// Row groups outside the time range are skipped using their statistics
+		entryIter = readTimeRangeIter(ctx, src, options.SeekStart, options, columns)
+		totalEntries = options.SeekStart
+	} else if options.SeekStart > 0 {
+		entryIter = readParquetFileFromRowIter(ctx, src, options.SeekStart, columns)
 		totalEntries = options.SeekStart
 	} else {
-		entryIter = readParquetFileIter(ctx, filename)
+		entryIter = readParquetFileIter(ctx, src, columns)
+	}
+	if options.DropHeartbeats {
+		entryIter = DropHeartbeatsIter(entryIter)
+	}
+
+	// The header of the group being scanned; with a seek it may precede the start row
+	var groupHeader *ParquetLogEntry
+	if options.GroupContext && options.SeekStart > 0 {
+		var err error
+		if groupHeader, err = groupHeaderBefore(ctx, src, options.SeekStart); err != nil {
+			yield(SearchResult{}, err)
+			return
+		}
 	}
 
+	searchForwardEntries(entryIter, options, regex, beforeContext, afterContext, groupHeader, totalEntries, yield)
+}
+
+// searchForwardEntries searches entries in order. groupHeader is the header of the group
+// the first entry belongs to, and totalEntries the number of entries before it.
+func searchForwardEntries(entryIter iter.Seq2[ParquetLogEntry, error], options SearchOptions, regex *regexp.Regexp, beforeContext, afterContext int, groupHeader *ParquetLogEntry, totalEntries int64, yield func(SearchResult, error) bool) {
+	var beforeBuffer []ParquetLogEntry
+	var afterCollecting int
+	var currentResult *SearchResult
+
 	for entry, err := range entryIter {
 		if err != nil {
 			yield(SearchResult{}, err)
 			return
 		}
 
+		if searchDeadlinePassed(options.Deadline, totalEntries) {
+			// Return the pending match with the after-context collected so far
+			if currentResult != nil && !yield(*currentResult, nil) {
+				return
+			}
+			yield(SearchResult{}, ErrSearchTimeout)
+			return
+		}
 		totalEntries++
+		if options.GroupContext && entry.IsGroup() {
+			header := entry
+			groupHeader = &header
+		}
+		// Sources that can't seek yield the rows before the start to track groups
+		if entry.RowNumber < options.SeekStart {
+			continue
+		}
 
 		// Handle after-context collection
 		if afterCollecting > 0 && currentResult != nil {
@@ -633,12 +1061,14 @@ func searchForwardParquetFileIter(ctx context.Context, filename string, options
 		if options.InvertMatch {
 			isMatch = !isMatch
 		}
+		isMatch = isMatch && options.matchesLevel(&entry)
 
 		if isMatch {
 			result := SearchResult{
 				Match:         entry,
 				BeforeContext: make([]ParquetLogEntry, len(beforeBuffer)),
 				AfterContext:  make([]ParquetLogEntry, 0, afterContext),
+				GroupHeader:   enclosingGroupHeader(groupHeader, entry),
 			}
 			copy(result.BeforeContext, beforeBuffer)
 
@@ -670,19 +1100,60 @@ func searchForwardParquetFileIter(ctx context.Context, filename string, options
 	}
 }
 
-// searchReverseParquetFileIter implements reverse search by collecting entries first
-func searchReverseParquetFileIter(ctx context.Context, filename string, options SearchOptions, regex *regexp.Regexp, beforeContext, afterContext int, yield func(SearchResult, error) bool) {
+// groupHeaderBefore returns the last group header before row, or nil if there is none.
+// With a group index it reads only from the start of the group run holding row-1.
+func groupHeaderBefore(ctx context.Context, src parquetSource, row int64) (*ParquetLogEntry, error) {
+	index, ok, err := readParquetGroupIndex(src)
+	if err != nil {
+		return nil, err
+	}
+	first := int64(0)
+	if ok {
+		for _, r := range index {
+			if r.FirstRow < row && row-1 <= r.LastRow {
+				first = r.FirstRow
+				break
+			}
+		}
+	}
+
+	var header *ParquetLogEntry
+	for entry, err := range readParquetFileRowRangesIter(ctx, src, []GroupRange{{FirstRow: first, LastRow: row - 1}}, nil) {
+		if err != nil {
+			return nil, err
+		}
+		if entry.IsGroup() {
+			found := entry
+			header = &found
+		}
+	}
+	return header, nil
+}
+
+// searchReverseEntries implements reverse search by collecting entries first
+func searchReverseEntries(entryIter iter.Seq2[ParquetLogEntry, error], options SearchOptions, regex *regexp.Regexp, beforeContext, afterContext int, yield func(SearchResult, error) bool) {
 	// First, collect all entries into a slice
 	var allEntries []ParquetLogEntry
-
-	// For reverse search, we always need to read all entries first
-	entryIter := readParquetFileIter(ctx, filename)
+	// With GroupContext, the index in allEntries of the last group header at each entry
+	var headerIndexes []int
+	lastHeader := -1
 
 	for entry, err := range entryIter {
 		if err != nil {
 			yield(SearchResult{}, err)
 			return
 		}
+		if searchDeadlinePassed(options.Deadline, int64(len(allEntries))) {
+			// No match has been found yet, as matching starts from the end
+			yield(SearchResult{}, ErrSearchTimeout)
+			return
+		}
+		if options.GroupContext {
+			if entry.IsGroup() {
+				lastHeader = len(allEntries)
+			}
+			headerIndexes = append(headerIndexes, lastHeader)
+		}
 		allEntries = append(allEntries, entry)
 	}
 
@@ -691,13 +1162,21 @@ func searchReverseParquetFileIter(ctx context.Context, filename string, options
 	}
 
 	// Determine the starting position for reverse search
+	// Row numbers are ascending but may have gaps when heartbeats are dropped,
+	// so start from the last entry at or before SeekStart
 	startIdx := len(allEntries) - 1
-	if options.SeekStart > 0 && options.SeekStart < int64(len(allEntries)) {
-		startIdx = int(options.SeekStart)
+	if options.SeekStart > 0 {
+		startIdx = sort.Search(len(allEntries), func(i int) bool {
+			return allEntries[i].RowNumber > options.SeekStart
+		}) - 1
 	}
 
 	// Search backwards from startIdx
 	for i := startIdx; i >= 0; i-- {
+		if searchDeadlinePassed(options.Deadline, int64(startIdx-i)) {
+			yield(SearchResult{}, ErrSearchTimeout)
+			return
+		}
 		entry := allEntries[i]
 
 		// Test match
@@ -705,11 +1184,16 @@ func searchReverseParquetFileIter(ctx context.Context, filename string, options
 		if options.InvertMatch {
 			isMatch = !isMatch
 		}
+		isMatch = isMatch && options.matchesLevel(&entry)
 
 		if isMatch {
 			result := SearchResult{
 				Match: entry,
 			}
+			if options.GroupContext && headerIndexes[i] >= 0 {
+				header := allEntries[headerIndexes[i]]
+				result.GroupHeader = enclosingGroupHeader(&header, entry)
+			}
 
 			// Collect before context (entries that come before in reverse = higher indices)
 			if beforeContext > 0 {
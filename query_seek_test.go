@@ -150,7 +150,7 @@ func TestReadParquetFileFromRowIter_Standalone(t *testing.T) {
 
 	// Test seeking to row 0
 	entryCount := 0
-	for entry, err := range readParquetFileFromRowIter(context.Background(), testFile, 0) {
+	for entry, err := range readParquetFileFromRowIter(context.Background(), fileSource(testFile), 0, nil) {
 		if err != nil {
 			t.Fatalf("readParquetFileFromRowIter failed: %v", err)
 		}
@@ -179,7 +179,7 @@ func TestGetParquetFileInfo_Standalone(t *testing.T) {
 		t.Skip("test data not found")
 	}
 
-	info, err := getParquetFileInfo(testFile)
+	info, err := getParquetFileInfo(fileSource(testFile))
 	if err != nil {
 		t.Fatalf("getParquetFileInfo failed: %v", err)
 	}
@@ -199,7 +199,7 @@ func TestGetParquetFileInfo_Standalone(t *testing.T) {
 }
 
 func TestGetParquetFileInfo_NonExistent(t *testing.T) {
-	_, err := getParquetFileInfo("nonexistent.parquet")
+	_, err := getParquetFileInfo(fileSource("nonexistent.parquet"))
 	if err == nil {
 		t.Error("Expected error for non-existent file")
 	}
@@ -1,7 +1,11 @@
 package buildkitelogs
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -278,6 +282,86 @@ func TestFilterByGroupIter_Standalone(t *testing.T) {
 	}
 }
 
+func TestDropHeartbeatsIter(t *testing.T) {
+	testEntries := []ParquetLogEntry{
+		{Content: "starting build"},
+		{Content: "[agent] Heartbeat sent"},
+		{Content: "~~~ Heartbeat checks", Flags: 1 << logparser.IsGroup},
+		{Content: "keepalive"},
+		{Content: "build finished"},
+	}
+
+	entryIter := func(yield func(ParquetLogEntry, error) bool) {
+		for _, entry := range testEntries {
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+
+	var contents []string
+	for entry, err := range DropHeartbeatsIter(entryIter) {
+		if err != nil {
+			t.Fatalf("DropHeartbeatsIter failed: %v", err)
+		}
+		contents = append(contents, entry.Content)
+	}
+
+	expected := []string{"starting build", "~~~ Heartbeat checks", "build finished"}
+	if strings.Join(contents, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %v, got %v", expected, contents)
+	}
+}
+
+func TestSearchDropHeartbeats(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "heartbeats.parquet")
+	testEntries := []ParquetLogEntry{
+		{Content: "step one"},
+		{Content: "heartbeat"},
+		{Content: "step two failed"},
+		{Content: "heartbeat"},
+		{Content: "step three"},
+	}
+	if err := writeTestParquetFile(testFile, testEntries); err != nil {
+		t.Fatalf("Failed to create test parquet file: %v", err)
+	}
+
+	reader := NewParquetReader(testFile)
+	for _, reverse := range []bool{false, true} {
+		options := SearchOptions{
+			Pattern:        "failed",
+			Context:        1,
+			Reverse:        reverse,
+			DropHeartbeats: true,
+		}
+
+		var results []SearchResult
+		for result, err := range reader.SearchEntriesIter(t.Context(), options) {
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			results = append(results, result)
+		}
+
+		if len(results) != 1 {
+			t.Fatalf("reverse=%v: expected 1 match, got %d", reverse, len(results))
+		}
+		result := results[0]
+		if result.Match.RowNumber != 2 {
+			t.Errorf("reverse=%v: expected match at row 2, got %d", reverse, result.Match.RowNumber)
+		}
+		context := append(result.BeforeContext, result.AfterContext...)
+		for _, entry := range context {
+			if entry.IsHeartbeat() {
+				t.Errorf("reverse=%v: heartbeat in context: %+v", reverse, entry)
+			}
+		}
+		if len(context) != 2 {
+			t.Errorf("reverse=%v: expected 2 context lines, got %d", reverse, len(context))
+		}
+	}
+}
+
 func TestReadParquetFileIter(t *testing.T) {
 	testFile := "test_logs.parquet"
 	if _, err := os.Stat(testFile); os.IsNotExist(err) {
@@ -603,3 +687,80 @@ func writeTestParquetFile(filename string, entries []ParquetLogEntry) error {
 
 	return writer.WriteBatch(logEntries)
 }
+
+func TestSearchDeadline(t *testing.T) {
+	reader := NewParquetReader(writeGroupIndexTestFile(t, groupIndexTestEntries()))
+
+	search := func(options SearchOptions) (int, error) {
+		matches := 0
+		for _, err := range reader.SearchEntriesIter(t.Context(), options) {
+			if err != nil {
+				return matches, err
+			}
+			matches++
+		}
+		return matches, nil
+	}
+
+	matches, err := search(SearchOptions{Pattern: "line", Deadline: time.Now().Add(time.Hour)})
+	if err != nil || matches != 3409 {
+		t.Fatalf("search before deadline = %d matches, %v; want 3409, nil", matches, err)
+	}
+
+	// The first clock check after row 1 happens at entry 256, so earlier matches are returned
+	matches, err = search(SearchOptions{Pattern: "line", SeekStart: 1, Deadline: time.Now().Add(-time.Second)})
+	if !errors.Is(err, ErrSearchTimeout) {
+		t.Fatalf("expected ErrSearchTimeout, got %v", err)
+	}
+	if matches == 0 || matches >= 3409 {
+		t.Errorf("expected partial results before the timeout, got %d matches", matches)
+	}
+
+	_, err = search(SearchOptions{Pattern: "line", Reverse: true, Deadline: time.Now().Add(-time.Second)})
+	if !errors.Is(err, ErrSearchTimeout) {
+		t.Errorf("reverse search: expected ErrSearchTimeout, got %v", err)
+	}
+}
+
+func TestSearchGroupContext(t *testing.T) {
+	reader := NewParquetReader(writeGroupIndexTestFile(t, groupIndexTestEntries()))
+
+	// "line 1" is the second row of each group: one in the ungrouped prefix and one per group
+	headerRows := func(t *testing.T, options SearchOptions) []int64 {
+		t.Helper()
+		options.Pattern = "^line 1$"
+		var rows []int64
+		for result, err := range reader.SearchEntriesIter(t.Context(), options) {
+			if err != nil {
+				t.Fatalf("search failed: %v", err)
+			}
+			row := int64(-1)
+			if result.GroupHeader != nil {
+				row = result.GroupHeader.RowNumber
+				if result.GroupHeader.Group != result.Match.Group || !result.GroupHeader.IsGroup() {
+					t.Errorf("match at row %d has header %+v", result.Match.RowNumber, *result.GroupHeader)
+				}
+			}
+			rows = append(rows, row)
+		}
+		return rows
+	}
+
+	tests := []struct {
+		name    string
+		options SearchOptions
+		want    []int64
+	}{
+		{"forward", SearchOptions{GroupContext: true}, []int64{-1, 3, 1203, 2703, 2713}},
+		{"seek past header", SearchOptions{GroupContext: true, SeekStart: 2704}, []int64{2703, 2713}},
+		{"reverse", SearchOptions{GroupContext: true, Reverse: true}, []int64{2713, 2703, 1203, 3, -1}},
+		{"disabled", SearchOptions{}, []int64{-1, -1, -1, -1, -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := headerRows(t, tt.options); !slices.Equal(got, tt.want) {
+				t.Errorf("header rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
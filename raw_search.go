@@ -0,0 +1,70 @@
+package buildkitelogs
+
+import (
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// SearchRawLog searches a raw Buildkite log, such as a downloaded job log, without
+// converting it to Parquet. It supports the same options as ParquetReader.SearchEntriesIter
+// and yields the same results for the same log: row numbers are entry positions in the
+// log, and entries are parsed as the Client parses them before caching.
+//
+// Forward searches stream the log. Reverse searches hold every entry in memory, so for
+// repeated or reverse searches of large logs converting to Parquet is cheaper.
+func SearchRawLog(r io.Reader, options SearchOptions) iter.Seq2[SearchResult, error] {
+	return func(yield func(SearchResult, error) bool) {
+		if options.JobURL != "" {
+			yield = withLineURLs(yield, options.JobURL)
+		}
+
+		regex, err := compileRegexPattern(options.Pattern, options.CaseSensitive)
+		if err != nil {
+			yield(SearchResult{}, fmt.Errorf("invalid regex: %w", err))
+			return
+		}
+		beforeContext, afterContext := searchContextLines(options)
+
+		parser := logparser.New(logparser.WithTruncateLongLines(true))
+		entries := rawLogEntriesIter(parser.All(r))
+		if options.DropHeartbeats {
+			entries = DropHeartbeatsIter(entries)
+		}
+
+		if options.Reverse {
+			searchReverseEntries(entries, options, regex, beforeContext, afterContext, yield)
+			return
+		}
+		searchForwardEntries(entries, options, regex, beforeContext, afterContext, nil, 0, yield)
+	}
+}
+
+// rawLogEntriesIter converts parsed entries to the form they are read back from Parquet
+func rawLogEntriesIter(entries iter.Seq2[*logparser.Entry, error]) iter.Seq2[ParquetLogEntry, error] {
+	return func(yield func(ParquetLogEntry, error) bool) {
+		row := int64(0)
+		for entry, err := range entries {
+			if err != nil {
+				yield(ParquetLogEntry{}, err)
+				return
+			}
+			if !yield(ParquetLogEntry{
+				RowNumber:   row,
+				Timestamp:   entry.Timestamp.UnixMilli(),
+				Content:     entry.Content,
+				Group:       entry.Group,
+				ParentGroup: entry.ParentGroup,
+				Depth:       entry.Depth,
+				Flags:       entry.ComputeFlags(),
+				Hash:        ContentHash(entry.Content),
+				Severity:    entry.Severity(),
+			}, nil) {
+				return
+			}
+			row++
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package buildkitelogs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestSearchRawLog(t *testing.T) {
+	const rawPath = "testdata/bash-example.log"
+	parquetPath := filepath.Join(t.TempDir(), "logs.parquet")
+	if _, err := ReparseFile(rawPath, parquetPath, logparser.WithTruncateLongLines(true)); err != nil {
+		t.Fatalf("ReparseFile failed: %v", err)
+	}
+	reader := NewParquetReader(parquetPath)
+
+	tests := []struct {
+		name    string
+		options SearchOptions
+	}{
+		{"forward", SearchOptions{Pattern: "BUILDKITE_GIT"}},
+		{"context", SearchOptions{Pattern: "buildkite", Context: 2}},
+		{"group context", SearchOptions{Pattern: "BUILDKITE_BUILD_", GroupContext: true, BeforeContext: 1}},
+		{"seek", SearchOptions{Pattern: "BUILDKITE_", SeekStart: 60, GroupContext: true}},
+		{"case sensitive", SearchOptions{Pattern: "Example", CaseSensitive: true}},
+		{"invert", SearchOptions{Pattern: ".", InvertMatch: true}},
+		{"reverse", SearchOptions{Pattern: "BUILDKITE_GIT", Reverse: true, AfterContext: 1, GroupContext: true}},
+		{"reverse seek", SearchOptions{Pattern: "BUILDKITE_", Reverse: true, SeekStart: 60}},
+		{"drop heartbeats", SearchOptions{Pattern: "root", DropHeartbeats: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want []SearchResult
+			for result, err := range reader.SearchEntriesIter(t.Context(), tt.options) {
+				if err != nil {
+					t.Fatalf("SearchEntriesIter failed: %v", err)
+				}
+				want = append(want, result)
+			}
+			if len(want) == 0 {
+				t.Fatal("expected the Parquet search to match")
+			}
+
+			raw, err := os.Open(rawPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer raw.Close()
+
+			var got []SearchResult
+			for result, err := range SearchRawLog(raw, tt.options) {
+				if err != nil {
+					t.Fatalf("SearchRawLog failed: %v", err)
+				}
+				got = append(got, result)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("SearchRawLog returned %d results differing from the Parquet search's %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestSearchRawLog_InvalidPattern(t *testing.T) {
+	for _, err := range SearchRawLog(nil, SearchOptions{Pattern: "("}) {
+		if err == nil {
+			t.Fatal("expected an error for an invalid pattern")
+		}
+		return
+	}
+	t.Fatal("expected an error result")
+}
@@ -0,0 +1,158 @@
+package buildkitelogs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// refreshLockPoll is how often a client waiting for another process's refresh lock
+// checks whether it was released.
+const refreshLockPoll = 100 * time.Millisecond
+
+// WithRefreshLock makes the client hold a lock file in the local cache directory while
+// it downloads and caches a job's log, so processes on one host sharing a cache, such
+// as concurrent CLI invocations, download each log once: the others wait for the lock
+// and then read the log it cached. A lock untouched for staleAfter is taken over, so a
+// killed process can't block the others; a refresh in progress touches its lock every
+// staleAfter/3. 0 disables the lock, the default. Concurrent reads through one client
+// share a single refresh with or without it.
+//
+// Only processes sharing the local cache directory (WithLocalCacheDir) see the lock;
+// clients on other hosts sharing remote storage still refresh independently. Clients
+// created WithInMemory take no lock.
+func WithRefreshLock(staleAfter time.Duration) ClientOption {
+	return func(c *Client) {
+		c.refreshLock = staleAfter
+	}
+}
+
+// acquireRefreshLock takes the refresh lock of blobKey, waiting while another process
+// holds it. waited reports whether it had to wait, in which case the holder may have
+// cached the log meanwhile. release removes the lock, unless it was taken over.
+//
+// The lock file holds a token unique to each hold, so a lock is only removed by its
+// holder or by the one waiter that wins the takeover of that stale hold.
+func (c *Client) acquireRefreshLock(ctx context.Context, blobKey string) (release func(), waited bool, err error) {
+	if c.refreshLock <= 0 || c.inMemory {
+		return func() {}, false, nil
+	}
+	if err := os.MkdirAll(c.localCacheDir, 0o755); err != nil {
+		return nil, false, fmt.Errorf("failed to create local cache directory: %w", err)
+	}
+
+	path := refreshLockPath(c.localCacheDir, blobKey)
+	token := newRefreshLockToken()
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600) //nolint:gosec // path within the local cache directory
+		if err == nil {
+			_, err = fmt.Fprintf(f, "%s\n", token)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				_ = os.Remove(path)
+				return nil, waited, fmt.Errorf("failed to write refresh lock: %w", err)
+			}
+			return c.holdRefreshLock(path, token), waited, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, waited, fmt.Errorf("failed to create refresh lock: %w", err)
+		}
+		if held, modTime, err := readRefreshLock(path); err == nil && time.Since(modTime) > c.refreshLock {
+			// The holder exited without releasing it
+			c.logger.WarnContext(ctx, "taking over stale refresh lock", "path", path, "last_touched", modTime)
+			c.removeRefreshLock(path, held)
+			continue
+		}
+
+		waited = true
+		select {
+		case <-ctx.Done():
+			return nil, waited, ctx.Err()
+		case <-time.After(refreshLockPoll):
+		}
+	}
+}
+
+// holdRefreshLock keeps a taken lock fresh until the returned release removes it.
+func (c *Client) holdRefreshLock(path, token string) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(max(c.refreshLock/3, 10*time.Millisecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				now := time.Now()
+				_ = os.Chtimes(path, now, now)
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+		c.removeRefreshLock(path, token)
+	}
+}
+
+// removeRefreshLock removes the lock at path if it is still held with token. Removals of
+// one hold are serialized by a marker file named after its token, so of several waiters
+// finding the same stale lock only the first removes it, and the others find the lock
+// it took in its place. A marker older than the lock's staleness, left by a process
+// killed while removing a lock, is cleared.
+func (c *Client) removeRefreshLock(path, token string) {
+	marker := path + "." + token
+	m, err := os.OpenFile(marker, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600) //nolint:gosec // path within the local cache directory
+	if err != nil {
+		if info, statErr := os.Stat(marker); statErr == nil && time.Since(info.ModTime()) > c.refreshLock {
+			_ = os.Remove(marker)
+		}
+		return
+	}
+	_ = m.Close()
+	defer func() {
+		_ = os.Remove(marker)
+	}()
+
+	if held, _, err := readRefreshLock(path); err == nil && held == token {
+		_ = os.Remove(path)
+	}
+}
+
+// readRefreshLock returns the token of the lock at path and when it was last touched.
+func readRefreshLock(path string) (string, time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // path within the local cache directory
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return strings.TrimSpace(string(data)), info.ModTime(), nil
+}
+
+// newRefreshLockToken returns a token identifying one hold of a refresh lock: the
+// process ID and random bytes.
+func newRefreshLockToken() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return fmt.Sprintf("%d-%s", os.Getpid(), hex.EncodeToString(b[:]))
+}
+
+// refreshLockPath returns the lock file of a blob key's refresh.
+func refreshLockPath(dir, blobKey string) string {
+	return filepath.Join(dir, localCachePrefix(blobKey)+"refresh.lock")
+}
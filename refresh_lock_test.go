@@ -0,0 +1,139 @@
+package buildkitelogs
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestClient_RefreshLockWaitsForOtherProcess(t *testing.T) {
+	storageDir, localDir := t.TempDir(), t.TempDir()
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"}
+	blobKey := GenerateBlobKey(location.Org, location.Pipeline, location.Build, location.Job)
+
+	waitingAPI := newTerminalMock()
+	waiting, err := NewClientWithAPI(t.Context(), waitingAPI, "file://"+storageDir, WithLocalCacheDir(localDir), WithRefreshLock(time.Minute))
+	if err != nil {
+		t.Fatalf("NewClientWithAPI failed: %v", err)
+	}
+	defer waiting.Close()
+	holder, err := NewClientWithAPI(t.Context(), newTerminalMock(), "file://"+storageDir)
+	if err != nil {
+		t.Fatalf("NewClientWithAPI failed: %v", err)
+	}
+	defer holder.Close()
+
+	// Another process is refreshing the job
+	lockPath := refreshLockPath(localDir, blobKey)
+	if err := os.WriteFile(lockPath, []byte("1\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	type outcome struct {
+		result *CacheResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := waiting.DownloadAndCache(t.Context(), location, 0, false)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("DownloadAndCache returned while the lock was held")
+	case <-time.After(3 * refreshLockPoll):
+	}
+	if _, err := holder.DownloadAndCache(t.Context(), location, 0, false); err != nil {
+		t.Fatalf("holder DownloadAndCache failed: %v", err)
+	}
+	if err := os.Remove(lockPath); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+
+	got := <-done
+	if got.err != nil {
+		t.Fatalf("DownloadAndCache failed: %v", got.err)
+	}
+	if got.result.Outcome != CacheHit {
+		t.Errorf("Outcome = %q, want %q from the other process's cache", got.result.Outcome, CacheHit)
+	}
+	if logCalls, _ := waitingAPI.calls(); logCalls != 0 {
+		t.Errorf("waiting client downloaded the log %d times, want 0", logCalls)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+func TestClient_RefreshLockTakesOverStaleLock(t *testing.T) {
+	localDir := t.TempDir()
+	api := newTerminalMock()
+	client := newTestClient(t, api, WithLocalCacheDir(localDir), WithRefreshLock(time.Minute))
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"}
+
+	// Left behind by a killed process
+	lockPath := refreshLockPath(localDir, GenerateBlobKey(location.Org, location.Pipeline, location.Build, location.Job))
+	if err := os.WriteFile(lockPath, []byte("1\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	old := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+
+	result, err := client.DownloadAndCache(t.Context(), location, 0, false)
+	if err != nil {
+		t.Fatalf("DownloadAndCache failed: %v", err)
+	}
+	if result.Outcome != CacheRefreshed {
+		t.Errorf("Outcome = %q, want %q", result.Outcome, CacheRefreshed)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+func TestClient_RefreshLockTakeoverKeepsNewerHold(t *testing.T) {
+	localDir := t.TempDir()
+	client := newTestClient(t, newTerminalMock(), WithLocalCacheDir(localDir), WithRefreshLock(time.Minute))
+	lockPath := refreshLockPath(localDir, GenerateBlobKey("org", "pipeline", "1", "job"))
+
+	// A waiter that saw the stale hold "1-stale" removes it only while it is still that
+	// hold, not the lock another waiter took in its place
+	if err := os.WriteFile(lockPath, []byte("2-fresh\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	client.removeRefreshLock(lockPath, "1-stale")
+	if _, err := os.Stat(lockPath); err != nil {
+		t.Fatalf("newer lock removed: %v", err)
+	}
+
+	client.removeRefreshLock(lockPath, "2-fresh")
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("lock not removed by its holder: %v", err)
+	}
+	if _, err := os.Stat(lockPath + ".2-fresh"); !os.IsNotExist(err) {
+		t.Errorf("removal marker left behind: %v", err)
+	}
+}
+
+func TestClient_RefreshLockReleaseKeepsTakenOverLock(t *testing.T) {
+	localDir := t.TempDir()
+	client := newTestClient(t, newTerminalMock(), WithLocalCacheDir(localDir), WithRefreshLock(time.Minute))
+
+	release, _, err := client.acquireRefreshLock(t.Context(), GenerateBlobKey("org", "pipeline", "1", "job"))
+	if err != nil {
+		t.Fatalf("acquireRefreshLock failed: %v", err)
+	}
+	// Another process took the lock over while this one stalled
+	lockPath := refreshLockPath(localDir, GenerateBlobKey("org", "pipeline", "1", "job"))
+	if err := os.WriteFile(lockPath, []byte("2-other\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	release()
+	if _, err := os.Stat(lockPath); err != nil {
+		t.Errorf("release removed the other process's lock: %v", err)
+	}
+}
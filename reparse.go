@@ -0,0 +1,83 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// ParserVersionMetadataKey is the Parquet key/value metadata key recording the
+// logparser.Version that produced the file's entries.
+const ParserVersionMetadataKey = "buildkite.parser_version"
+
+// ReparseFile parses the raw log at rawPath with the current parser and replaces the
+// Parquet file at parquetPath with the result. The file is written next to parquetPath
+// and renamed into place, so readers see either the old or the new file, never a partial
+// one. It returns the number of entries written.
+func ReparseFile(rawPath, parquetPath string, opts ...logparser.Option) (int, error) {
+	raw, err := os.Open(rawPath) //nolint:gosec // caller-controlled path
+	if err != nil {
+		return 0, fmt.Errorf("failed to open raw log: %w", err)
+	}
+	defer raw.Close()
+
+	tempFile, err := os.CreateTemp(filepath.Dir(parquetPath), "."+filepath.Base(parquetPath)+"-*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempPath := tempFile.Name()
+	if err := tempFile.Close(); err != nil {
+		return 0, fmt.Errorf("failed to close temp file before export: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tempPath)
+	}()
+
+	parser := logparser.New(opts...)
+	rows, err := ExportSeq2ToParquetWithFilterAndStats(parser.All(raw), tempPath, nil, WithSourceParser(parser))
+	if err != nil {
+		return 0, fmt.Errorf("failed to export re-parsed log: %w", err)
+	}
+	// Keep the permissions of the file being replaced rather than the temp file's
+	if existing, err := os.Stat(parquetPath); err == nil {
+		if err := os.Chmod(tempPath, existing.Mode().Perm()); err != nil {
+			return 0, fmt.Errorf("failed to set permissions: %w", err)
+		}
+	}
+	if err := os.Rename(tempPath, parquetPath); err != nil {
+		return 0, fmt.Errorf("failed to replace %s: %w", parquetPath, err)
+	}
+	return rows, nil
+}
+
+// Reparse re-parses a job's cached log when the cached file was written by an older
+// parser (ParquetFileInfo.ParserVersion below logparser.Version), or always when force is
+// set, which also replaces a cached file that cannot be read. Raw logs are not kept in
+// the cache, so the log is downloaded again and the cached file replaced as for a forced
+// refresh; readers of the previous version keep their local copy. It reports whether the
+// log was re-parsed, and returns ErrNotCached if the job has no cache entry.
+func (c *Client) Reparse(ctx context.Context, location JobLocation, force bool) (bool, error) {
+	cached, err := c.OpenCached(ctx, location)
+	if err != nil {
+		return false, err
+	}
+	info, err := NewParquetReaderFromReaderAt(cached, cached.Size()).GetFileInfo()
+	_ = cached.Close()
+	if !force {
+		// An unreadable cached file is reported rather than treated as stale
+		if err != nil {
+			return false, fmt.Errorf("failed to read cached log info: %w", err)
+		}
+		if info.ParserVersion >= logparser.Version {
+			return false, nil
+		}
+	}
+
+	if _, err := c.downloadAndCache(ctx, c.api, location.Org, location.Pipeline, location.Build, location.Job, 0, true); err != nil {
+		return false, err
+	}
+	return true, nil
+}
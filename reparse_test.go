@@ -0,0 +1,87 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestReparseFile(t *testing.T) {
+	dir := t.TempDir()
+	parquetPath := filepath.Join(dir, "logs.parquet")
+	if err := os.WriteFile(parquetPath, []byte("stale"), 0o644); err != nil { //nolint:gosec // test file
+		t.Fatal(err)
+	}
+
+	rows, err := ReparseFile("testdata/bash-example.log", parquetPath)
+	if err != nil {
+		t.Fatalf("ReparseFile failed: %v", err)
+	}
+
+	info, err := NewParquetReader(parquetPath).GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	if info.RowCount != int64(rows) || rows == 0 {
+		t.Errorf("RowCount = %d, ReparseFile reported %d rows", info.RowCount, rows)
+	}
+	if info.ParserVersion != logparser.Version {
+		t.Errorf("ParserVersion = %d, want %d", info.ParserVersion, logparser.Version)
+	}
+
+	stat, err := os.Stat(parquetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Mode().Perm() != 0o644 {
+		t.Errorf("mode = %v, want the replaced file's 0644", stat.Mode().Perm())
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected only the replaced file to remain, found %d files", len(files))
+	}
+}
+
+func TestClient_Reparse(t *testing.T) {
+	api := newTerminalMock()
+	client := newTestClient(t, api)
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "test-job"}
+
+	if _, err := client.Reparse(t.Context(), location, false); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("Reparse before caching error = %v, want ErrNotCached", err)
+	}
+
+	if _, err := client.NewReader(t.Context(), "org", "pipeline", "1", "test-job", time.Minute, false); err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	logCalls, _ := api.calls()
+
+	reparsed, err := client.Reparse(t.Context(), location, false)
+	if err != nil {
+		t.Fatalf("Reparse failed: %v", err)
+	}
+	if reparsed {
+		t.Error("expected a log cached by the current parser to be left alone")
+	}
+	if calls, _ := api.calls(); calls != logCalls {
+		t.Errorf("log downloaded %d more times, want 0", calls-logCalls)
+	}
+
+	reparsed, err = client.Reparse(t.Context(), location, true)
+	if err != nil {
+		t.Fatalf("forced Reparse failed: %v", err)
+	}
+	if !reparsed {
+		t.Error("expected forced Reparse to re-parse")
+	}
+	if calls, _ := api.calls(); calls != logCalls+1 {
+		t.Errorf("log downloaded %d more times, want 1", calls-logCalls)
+	}
+}
@@ -0,0 +1,52 @@
+package buildkitelogs
+
+// GroupRetryAttempts groups cached job logs into the attempts of each logical step,
+// following the RetryOfJobID and RetriedInJobID links of their metadata, such as those
+// listed by BlobStorage.Catalog. Each group lists its attempts from the first to the
+// latest; jobs that were never retried are groups of one. Groups are in the order their
+// first listed member appears in metadata, and nil metadata is skipped.
+func GroupRetryAttempts(metadata []*BlobMetadata) [][]*BlobMetadata {
+	byID := make(map[string]*BlobMetadata, len(metadata))
+	var jobs []*BlobMetadata
+	for _, m := range metadata {
+		if m == nil || byID[m.JobID] != nil {
+			continue
+		}
+		byID[m.JobID] = m
+		jobs = append(jobs, m)
+	}
+
+	// Link each attempt to the next, from whichever side recorded the link
+	next := make(map[string]string)
+	prev := make(map[string]string)
+	link := func(from, to string) {
+		if byID[from] != nil && byID[to] != nil && from != to && next[from] == "" && prev[to] == "" {
+			next[from], prev[to] = to, from
+		}
+	}
+	for _, m := range jobs {
+		link(m.RetryOfJobID, m.JobID)
+		link(m.JobID, m.RetriedInJobID)
+	}
+
+	var groups [][]*BlobMetadata
+	grouped := make(map[string]bool, len(jobs))
+	for _, m := range jobs {
+		if grouped[m.JobID] {
+			continue
+		}
+		// Walk back to the first attempt, then forward through the retries
+		first := m.JobID
+		for seen := map[string]bool{first: true}; prev[first] != "" && !seen[prev[first]]; {
+			first = prev[first]
+			seen[first] = true
+		}
+		var group []*BlobMetadata
+		for id := first; id != "" && !grouped[id]; id = next[id] {
+			grouped[id] = true
+			group = append(group, byID[id])
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
@@ -0,0 +1,35 @@
+package buildkitelogs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGroupRetryAttempts(t *testing.T) {
+	metadata := []*BlobMetadata{
+		{JobID: "lint"},
+		{JobID: "test-3", RetryOfJobID: "test-2"},
+		{JobID: "test-1", RetriedInJobID: "test-2"}, // Cached before its retry's retry_source was known
+		nil,
+		{JobID: "test-2", RetryOfJobID: "test-1"},
+		{JobID: "deploy-2", RetryOfJobID: "deploy-1"}, // First attempt not cached
+	}
+
+	var got [][]string
+	for _, group := range GroupRetryAttempts(metadata) {
+		var ids []string
+		for _, m := range group {
+			ids = append(ids, m.JobID)
+		}
+		got = append(got, ids)
+	}
+	want := [][]string{{"lint"}, {"test-1", "test-2", "test-3"}, {"deploy-2"}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("GroupRetryAttempts = %v, want %v", got, want)
+	}
+
+	cycle := []*BlobMetadata{{JobID: "a", RetryOfJobID: "b"}, {JobID: "b", RetryOfJobID: "a"}}
+	if groups := GroupRetryAttempts(cycle); len(groups) != 1 || len(groups[0]) != 2 {
+		t.Errorf("GroupRetryAttempts of a cycle = %v, want one group of both jobs", groups)
+	}
+}
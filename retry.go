@@ -0,0 +1,122 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v5"
+)
+
+// RetryPolicy configures how failed calls, such as job status lookups, are retried. The
+// zero value makes a single attempt.
+type RetryPolicy struct {
+	MaxAttempts    int              // Total attempts, including the first (default: 1)
+	InitialBackoff time.Duration    // Delay before the second attempt
+	MaxBackoff     time.Duration    // Upper bound on any delay, including waits a response asks for (0 means no bound)
+	Multiplier     float64          // Delay growth per attempt (default: 2)
+	Jitter         float64          // Fraction of each delay that is randomized, from 0 to 1
+	Retryable      func(error) bool // Classifies errors (default: IsRetryableError)
+}
+
+// DefaultRetryPolicy retries transient failures twice, starting at 250ms. Clients make a
+// single attempt unless configured with WithJobStatusRetry or WithRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 250 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// WithJobStatusRetry sets the retry policy for job status lookups. Every attempt is
+// reported to AfterJobStatus hooks with its attempt number. It defers to an API client
+// that retries calls itself (a BuildkiteAPIClient with WithRetryPolicy), so lookups are
+// never retried by both.
+func WithJobStatusRetry(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.jobStatusRetry = policy
+	}
+}
+
+// IsRetryableError reports whether err looks transient: a Buildkite API response with
+// status 408, 429 or 5xx, or a network error. Context cancellation and other API
+// responses are not retried.
+func IsRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *buildkite.ErrorResponse
+	if errors.As(err, &apiErr) && apiErr.Response != nil {
+		status := apiErr.Response.StatusCode
+		return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// attempts returns the number of attempts the policy allows.
+func (p RetryPolicy) attempts() int {
+	return max(p.MaxAttempts, 1)
+}
+
+// retryable reports whether the policy retries err.
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryableError(err)
+}
+
+// backoff returns the delay before the given attempt (2 for the first retry).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	delay := float64(p.InitialBackoff)
+	for range attempt - 2 {
+		delay *= multiplier
+	}
+	if p.MaxBackoff > 0 {
+		delay = min(delay, float64(p.MaxBackoff))
+	}
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		delay -= delay * jitter * rand.Float64() //nolint:gosec // jitter does not need a secure source
+	}
+	return time.Duration(delay)
+}
+
+// delay returns the wait before the given attempt after err: the policy's backoff, or
+// longer if the failed response asked for it (Retry-After, or the rate limit reset of a
+// 429), so retries don't spend attempts while rate limited. ok is false when the response
+// asked for a longer wait than MaxBackoff, as retrying sooner would fail again.
+func (p RetryPolicy) delay(attempt int, err error) (wait time.Duration, ok bool) {
+	requested := serverRetryWait(err)
+	if p.MaxBackoff > 0 && requested > p.MaxBackoff {
+		return 0, false
+	}
+	return max(p.backoff(attempt), requested), true
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
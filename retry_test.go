@@ -0,0 +1,127 @@
+package buildkitelogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v5"
+)
+
+type flakyStatusAPI struct {
+	*mockBuildkiteAPI
+	failures int
+	err      error
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (a *flakyStatusAPI) GetJobStatus(ctx context.Context, org, pipeline, build, job string) (*JobStatus, error) {
+	a.mu.Lock()
+	a.calls++
+	fail := a.calls <= a.failures
+	a.mu.Unlock()
+	if fail {
+		return nil, a.err
+	}
+	return a.mockBuildkiteAPI.GetJobStatus(ctx, org, pipeline, build, job)
+}
+
+func TestClient_JobStatusRetry(t *testing.T) {
+	api := &flakyStatusAPI{
+		mockBuildkiteAPI: newTerminalMock(),
+		failures:         2,
+		err:              &net.OpError{Op: "dial", Err: errors.New("connection refused")},
+	}
+	client := newTestClient(t, api, WithJobStatusRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+	var attempts []*JobStatusResult
+	client.Hooks().AddAfterJobStatus(func(ctx context.Context, result *JobStatusResult) {
+		attempts = append(attempts, result)
+	})
+
+	reader, err := client.NewReader(t.Context(), "org", "pipeline", "1", "test-job", time.Minute, false)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	reader.Close()
+
+	if len(attempts) != 3 {
+		t.Fatalf("got %d job status attempts, want 3", len(attempts))
+	}
+	for i, result := range attempts {
+		if result.Attempt != i+1 {
+			t.Errorf("attempt %d reported as %d", i+1, result.Attempt)
+		}
+		if result.WillRetry != (i < 2) {
+			t.Errorf("attempt %d WillRetry = %t", i+1, result.WillRetry)
+		}
+	}
+	if !attempts[2].Success {
+		t.Error("final attempt should succeed")
+	}
+}
+
+func TestClient_JobStatusRetryStopsOnPermanentError(t *testing.T) {
+	api := &flakyStatusAPI{
+		mockBuildkiteAPI: newTerminalMock(),
+		failures:         5,
+		err:              errors.New("job not found"),
+	}
+	client := newTestClient(t, api, WithJobStatusRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+	if _, err := client.NewReader(t.Context(), "org", "pipeline", "1", "test-job", time.Minute, false); err == nil {
+		t.Fatal("expected job status error")
+	}
+	if api.calls != 1 {
+		t.Errorf("GetJobStatus calls = %d, want 1", api.calls)
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	apiError := func(status int) error {
+		return fmt.Errorf("failed to get job: %w", &buildkite.ErrorResponse{Response: &http.Response{StatusCode: status}})
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limited", apiError(http.StatusTooManyRequests), true},
+		{"server error", apiError(http.StatusBadGateway), true},
+		{"not found", apiError(http.StatusNotFound), false},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("refused")}, true},
+		{"canceled", context.Canceled, false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableError(tt.err); got != tt.want {
+				t.Errorf("IsRetryableError() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	for attempt, want := range map[int]time.Duration{2: 100 * time.Millisecond, 3: 200 * time.Millisecond, 4: 300 * time.Millisecond} {
+		if got := policy.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for range 20 {
+		if got := policy.backoff(2); got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("jittered backoff %v outside [50ms, 100ms]", got)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package buildkitelogs
+
+// WithRowGroupSize makes the writer buffer entries and write them in row groups of size
+// rows, instead of one row group per WriteBatch call (ExportSeq2ToParquet writes batches
+// of 1000). Larger row groups compress better and mean fewer requests when files are
+// scanned from object storage, at the cost of holding a row group of entries in memory.
+// Buffered entries are not in a Snapshot until their row group is written. Default is 0,
+// a row group per batch.
+func WithRowGroupSize(size int) ParquetWriterOption {
+	return func(pw *ParquetWriter) {
+		pw.rowGroupSize = size
+	}
+}
+
+// WithDictionary sets whether the writer dictionary-encodes columns. Dictionary encoding
+// shrinks repetitive columns such as group, but costs time on logs of mostly unique
+// lines. Default is on.
+func WithDictionary(enabled bool) ParquetWriterOption {
+	return func(pw *ParquetWriter) {
+		pw.dictionary = enabled
+	}
+}
@@ -0,0 +1,77 @@
+package buildkitelogs
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestWithRowGroupSize(t *testing.T) {
+	baseTime := time.Date(2025, 4, 22, 21, 43, 29, 0, time.UTC)
+	var entries []*logparser.Entry
+	for i := range 2500 {
+		group := "~~~ Build"
+		if i >= 2000 {
+			group = "~~~ Test"
+		}
+		entries = append(entries, &logparser.Entry{Timestamp: baseTime, Content: fmt.Sprintf("line %d", i), Group: group})
+	}
+
+	filename := filepath.Join(t.TempDir(), "log.parquet")
+	if err := ExportSeq2ToParquet(entrySeq(entries), filename, WithRowGroupSize(1500), WithDictionary(false)); err != nil {
+		t.Fatalf("ExportSeq2ToParquet failed: %v", err)
+	}
+
+	pf, err := file.OpenParquetFile(filename, false)
+	if err != nil {
+		t.Fatalf("OpenParquetFile failed: %v", err)
+	}
+	var sizes []int64
+	for i := range pf.NumRowGroups() {
+		sizes = append(sizes, pf.MetaData().RowGroup(i).NumRows())
+	}
+	if len(sizes) != 2 || sizes[0] != 1500 || sizes[1] != 1000 {
+		t.Errorf("row groups = %v, want [1500 1000]", sizes)
+	}
+	chunk, err := pf.MetaData().RowGroup(0).ColumnChunk(2)
+	if err != nil {
+		t.Fatalf("ColumnChunk failed: %v", err)
+	}
+	if chunk.HasDictionaryPage() {
+		t.Error("group column is dictionary-encoded with WithDictionary(false)")
+	}
+	_ = pf.Close()
+
+	reader := NewParquetReader(filename)
+	rows := 0
+	for entry, err := range reader.ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		if entry.Content != fmt.Sprintf("line %d", rows) {
+			t.Fatalf("row %d = %q", rows, entry.Content)
+		}
+		rows++
+	}
+	if rows != len(entries) {
+		t.Errorf("read %d rows, want %d", rows, len(entries))
+	}
+
+	tests := 0
+	for entry, err := range reader.FilterByGroupIter(t.Context(), "Test") {
+		if err != nil {
+			t.Fatalf("FilterByGroupIter failed: %v", err)
+		}
+		if entry.RowNumber < 2000 {
+			t.Errorf("row %d is not in the Test group", entry.RowNumber)
+		}
+		tests++
+	}
+	if tests != 500 {
+		t.Errorf("Test group has %d rows, want 500", tests)
+	}
+}
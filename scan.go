@@ -0,0 +1,180 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	buildkite "github.com/buildkite/go-buildkite/v5"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultScanConcurrency is the number of job logs Scan downloads and searches at once.
+const DefaultScanConcurrency = 4
+
+// OrgBuildLister lists the builds across every pipeline in an organization, including
+// their jobs.
+type OrgBuildLister interface {
+	ListOrgBuilds(ctx context.Context, org string, since time.Time, state string) ([]buildkite.Build, error)
+}
+
+// ListOrgBuilds lists the organization's builds created since the given time, following
+// pagination. An empty state matches builds in any state.
+func (c *BuildkiteAPIClient) ListOrgBuilds(ctx context.Context, org string, since time.Time, state string) ([]buildkite.Build, error) {
+	opts := &buildkite.BuildsListOptions{
+		CreatedFrom: since,
+		ListOptions: buildkite.ListOptions{PerPage: 100},
+	}
+	if state != "" {
+		opts.State = []string{state}
+	}
+
+	var builds []buildkite.Build
+	for {
+		var page []buildkite.Build
+		var resp *buildkite.Response
+		err := c.withRetry(ctx, func() error {
+			pageCtx, cancel := c.requestContext(ctx)
+			defer cancel()
+
+			var err error
+			page, resp, err = c.client.Builds.ListByOrg(pageCtx, org, opts)
+			return wrapAPIError(resp, err)
+		}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list builds: %w", err)
+		}
+		builds = append(builds, page...)
+		if resp == nil || resp.NextPage == 0 {
+			return builds, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ScanOptions configures an organization-wide log scan.
+type ScanOptions struct {
+	Since         time.Time     // Only scan builds created at or after this time
+	State         string        // Job state to scan, e.g. "failed" (default: "failed")
+	Pattern       string        // Regex pattern to search job logs for
+	CaseSensitive bool          // Enable case-sensitive matching
+	Concurrency   int           // Logs downloaded and searched at once (default: DefaultScanConcurrency)
+	TTL           time.Duration // Cache TTL for non-terminal jobs
+}
+
+// ScanResult describes a job whose log matched a scan pattern, or that could not be
+// scanned.
+type ScanResult struct {
+	Org        string `json:"organization"`
+	Pipeline   string `json:"pipeline"`
+	Build      string `json:"build"`
+	Job        string `json:"job"`
+	Label      string `json:"label,omitempty"`
+	State      string `json:"state"`
+	WebURL     string `json:"web_url,omitempty"`
+	Matches    int    `json:"matches"`
+	FirstMatch string `json:"first_match,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Scan enumerates jobs in the given state across every pipeline in an organization,
+// downloads and caches their logs concurrently, and returns the jobs whose logs match
+// the pattern. Jobs whose logs could not be downloaded or searched are returned with
+// Error set rather than failing the whole scan.
+func (c *Client) Scan(ctx context.Context, org string, opts ScanOptions) ([]ScanResult, error) {
+	if org == "" {
+		return nil, fmt.Errorf("organization is required")
+	}
+	if opts.Pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+	if _, err := compileRegexPattern(opts.Pattern, opts.CaseSensitive); err != nil {
+		return nil, fmt.Errorf("invalid regex pattern: %w", err)
+	}
+
+	lister, ok := c.api.(OrgBuildLister)
+	if !ok {
+		return nil, fmt.Errorf("API client does not support listing organization builds")
+	}
+
+	state := opts.State
+	if state == "" {
+		state = string(JobStateFailed)
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultScanConcurrency
+	}
+
+	// A job's state does not determine its build's: soft-failed jobs fail in passed builds,
+	// and jobs fail in builds that are still running or were canceled. List builds in every
+	// state and filter on the job.
+	builds, err := lister.ListOrgBuilds(ctx, org, opts.Since, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []ScanResult
+	for _, b := range builds {
+		if b.Pipeline == nil {
+			continue
+		}
+		for _, job := range b.Jobs {
+			if job.Type != "script" || job.State != state {
+				continue
+			}
+			candidates = append(candidates, ScanResult{
+				Org:      org,
+				Pipeline: b.Pipeline.Slug,
+				Build:    strconv.Itoa(b.Number),
+				Job:      job.ID,
+				Label:    stepLabel(job),
+				State:    job.State,
+				WebURL:   job.WebURL,
+			})
+		}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i := range candidates {
+		g.Go(func() error {
+			c.scanJob(gctx, &candidates[i], opts)
+			return gctx.Err()
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var results []ScanResult
+	for _, result := range candidates {
+		if result.Matches > 0 || result.Error != "" {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// scanJob downloads (or reuses the cached) log for a job and counts pattern matches.
+func (c *Client) scanJob(ctx context.Context, result *ScanResult, opts ScanOptions) {
+	reader, err := c.NewReader(ctx, result.Org, result.Pipeline, result.Build, result.Job, opts.TTL, false)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+	defer reader.Close()
+
+	search := SearchOptions{Pattern: opts.Pattern, CaseSensitive: opts.CaseSensitive}
+	for match, err := range reader.SearchEntriesIter(ctx, search) {
+		if err != nil {
+			result.Error = err.Error()
+			return
+		}
+		if result.Matches == 0 {
+			result.FirstMatch = match.Match.CleanContent(true)
+		}
+		result.Matches++
+	}
+}
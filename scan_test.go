@@ -0,0 +1,106 @@
+package buildkitelogs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v5"
+)
+
+type scanBuildAPI struct {
+	*mockBuildkiteAPI
+	builds []buildkite.Build
+}
+
+func (a *scanBuildAPI) ListOrgBuilds(ctx context.Context, org string, since time.Time, state string) ([]buildkite.Build, error) {
+	var builds []buildkite.Build
+	for _, b := range a.builds {
+		if state == "" || b.State == state {
+			builds = append(builds, b)
+		}
+	}
+	return builds, nil
+}
+
+func TestClient_Scan(t *testing.T) {
+	api := &scanBuildAPI{
+		mockBuildkiteAPI: newTerminalMock(),
+		builds: []buildkite.Build{
+			{
+				Number:   7,
+				State:    "failed",
+				Pipeline: &buildkite.Pipeline{Slug: "api"},
+				Jobs: []buildkite.Job{
+					{ID: "failed-job", Type: "script", Label: "Tests", State: "failed"},
+					{ID: "passed-job", Type: "script", Label: "Lint", State: "passed"},
+					{ID: "wait", Type: "waiter"},
+				},
+			},
+		},
+	}
+	client := newTestClient(t, api)
+
+	results, err := client.Scan(t.Context(), "org", ScanOptions{Pattern: "test log"})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1: %+v", len(results), results)
+	}
+	got := results[0]
+	if got.Pipeline != "api" || got.Build != "7" || got.Job != "failed-job" || got.Matches != 1 {
+		t.Errorf("unexpected result: %+v", got)
+	}
+	if got.FirstMatch != "Test log entry" {
+		t.Errorf("first match = %q", got.FirstMatch)
+	}
+	if logCalls, _ := api.calls(); logCalls != 1 {
+		t.Errorf("downloaded %d logs, want 1", logCalls)
+	}
+
+	results, err = client.Scan(t.Context(), "org", ScanOptions{Pattern: "OOMKilled"})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected no matches, got %+v", results)
+	}
+}
+
+func TestClient_ScanFailedJobInPassedBuild(t *testing.T) {
+	api := &scanBuildAPI{
+		mockBuildkiteAPI: newTerminalMock(),
+		builds: []buildkite.Build{
+			{
+				Number:   8,
+				State:    "passed",
+				Pipeline: &buildkite.Pipeline{Slug: "api"},
+				Jobs: []buildkite.Job{
+					{ID: "soft-failed-job", Type: "script", Label: "Flaky", State: "failed", SoftFailed: true},
+					{ID: "passed-job", Type: "script", Label: "Lint", State: "passed"},
+				},
+			},
+		},
+	}
+	client := newTestClient(t, api)
+
+	results, err := client.Scan(t.Context(), "org", ScanOptions{State: "failed", Pattern: "test log"})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Job != "soft-failed-job" {
+		t.Errorf("results = %+v, want the failed job of the passed build", results)
+	}
+}
+
+func TestClient_ScanValidation(t *testing.T) {
+	client := newTestClient(t, newTerminalMock())
+
+	if _, err := client.Scan(t.Context(), "org", ScanOptions{Pattern: "["}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	if _, err := client.Scan(t.Context(), "org", ScanOptions{Pattern: "error"}); err == nil {
+		t.Error("expected error for API without organization build listing")
+	}
+}
@@ -0,0 +1,197 @@
+package buildkitelogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// DefaultSearchStreamHeartbeat is how often SearchStreamHandler writes a heartbeat comment
+// while a search has found nothing new, so proxies keep the connection open.
+const DefaultSearchStreamHeartbeat = 15 * time.Second
+
+// SearchStreamPattern is the http.ServeMux pattern SearchStreamHandler is meant to be
+// served at. {ref} is a path-escaped Buildkite job URL, or a job UUID when
+// SearchStreamHandler.Org is set.
+const SearchStreamPattern = "GET /jobs/{ref}/search/stream"
+
+// SearchStreamHandler streams the matches of a job log search as Server-Sent Events, so
+// UIs can show results while a search of a large log is still running:
+//
+//	mux.Handle(buildkitelogs.SearchStreamPattern, &buildkitelogs.SearchStreamHandler{Client: client})
+//
+// The search is configured by query parameters: pattern (required), case_sensitive,
+// invert_match, context, before, after, reverse, group_context and level (info, warn or
+// error, see SearchOptions.Level). Each match is sent as a "result" event with the JSON
+// SearchResult as data and its row number as id; the stream ends with a "done" event
+// holding the number of matches, or an "error" event. The search stops when the client
+// disconnects.
+type SearchStreamHandler struct {
+	Client    *Client
+	Org       string        // Organization of job UUID refs; UUID refs are rejected when empty
+	TTL       time.Duration // Cache TTL of downloaded logs (0 = default)
+	Heartbeat time.Duration // Interval between heartbeat comments (0 = DefaultSearchStreamHeartbeat)
+}
+
+type searchStreamEvent struct {
+	result SearchResult
+	err    error
+}
+
+func (h *SearchStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	options, err := searchOptionsFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	ref := r.PathValue("ref")
+	var reader *ParquetReader
+	switch {
+	case IsUUID(ref) && h.Org != "":
+		reader, err = h.Client.NewReaderByJobID(ctx, h.Org, ref, h.TTL, false)
+	case IsUUID(ref):
+		http.Error(w, "job UUID refs require an organization; use a job URL", http.StatusBadRequest)
+		return
+	default:
+		location, parseErr := ParseBuildkiteURL(ref)
+		if parseErr != nil || location.Job == "" {
+			http.Error(w, fmt.Sprintf("invalid job ref %q: want a job URL or UUID", ref), http.StatusBadRequest)
+			return
+		}
+		reader, err = h.Client.NewReader(ctx, location.Org, location.Pipeline, location.Build, location.Job, h.TTL, false)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer reader.Close()
+
+	heartbeat := h.Heartbeat
+	if heartbeat <= 0 {
+		heartbeat = DefaultSearchStreamHeartbeat
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	_ = rc.Flush()
+
+	// Search in the background so heartbeats can be written while no match is found
+	events := make(chan searchStreamEvent)
+	go func() {
+		defer close(events)
+		for result, err := range reader.SearchEntriesIter(ctx, options) {
+			select {
+			case events <- searchStreamEvent{result: result, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	matches := 0
+	for {
+		select {
+		case <-ctx.Done():
+			// Client disconnected; the search stops on the canceled context
+			return
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				writeSSE(w, "done", "", map[string]int{"matches": matches})
+				_ = rc.Flush()
+				return
+			}
+			if event.err != nil {
+				writeSSE(w, "error", "", map[string]string{"error": event.err.Error()})
+				_ = rc.Flush()
+				return
+			}
+			matches++
+			if err := writeSSE(w, "result", strconv.FormatInt(event.result.Match.RowNumber, 10), event.result); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeSSE writes one Server-Sent Event with data encoded as JSON.
+func writeSSE(w io.Writer, event, id string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded)
+	return err
+}
+
+// searchOptionsFromQuery builds search options from the query parameters of a
+// SearchStreamHandler request.
+func searchOptionsFromQuery(r *http.Request) (SearchOptions, error) {
+	query := r.URL.Query()
+	options := SearchOptions{Pattern: query.Get("pattern")}
+	if options.Pattern == "" {
+		return SearchOptions{}, fmt.Errorf("pattern is required")
+	}
+
+	for name, dst := range map[string]*bool{
+		"case_sensitive": &options.CaseSensitive,
+		"invert_match":   &options.InvertMatch,
+		"reverse":        &options.Reverse,
+		"group_context":  &options.GroupContext,
+	} {
+		if value := query.Get(name); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return SearchOptions{}, fmt.Errorf("invalid %s %q", name, value)
+			}
+			*dst = b
+		}
+	}
+	for name, dst := range map[string]*int{
+		"context": &options.Context,
+		"before":  &options.BeforeContext,
+		"after":   &options.AfterContext,
+	} {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return SearchOptions{}, fmt.Errorf("invalid %s %q", name, value)
+			}
+			*dst = n
+		}
+	}
+	if value := query.Get("level"); value != "" {
+		level, err := logparser.ParseSeverity(value)
+		if err != nil {
+			return SearchOptions{}, fmt.Errorf("invalid level %q", value)
+		}
+		options.Level = level
+	}
+	return options, nil
+}
@@ -0,0 +1,62 @@
+package buildkitelogs
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSearchStreamHandler(t *testing.T) {
+	client := newTestClient(t, newTerminalMock())
+	mux := http.NewServeMux()
+	mux.Handle(SearchStreamPattern, &SearchStreamHandler{Client: client})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ref := url.PathEscape("https://buildkite.com/org/pipeline/builds/1#0190a7a4-5b3c-7d1e-9f00-1234567890ab")
+	resp, err := http.Get(server.URL + "/jobs/" + ref + "/search/stream?pattern=log+entry")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading stream failed: %v", err)
+	}
+	stream := string(body)
+	if !strings.Contains(stream, "id: 0\nevent: result\ndata: {") || !strings.Contains(stream, "Test log entry") {
+		t.Errorf("stream has no result event:\n%s", stream)
+	}
+	if !strings.HasSuffix(stream, "event: done\ndata: {\"matches\":1}\n\n") {
+		t.Errorf("stream doesn't end with done event:\n%s", stream)
+	}
+}
+
+func TestSearchStreamHandler_BadRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle(SearchStreamPattern, &SearchStreamHandler{Client: newTestClient(t, newTerminalMock())})
+
+	for _, target := range []string{
+		"/jobs/0190a7a4-5b3c-7d1e-9f00-1234567890ab/search/stream?pattern=x", // UUID without Org
+		"/jobs/not-a-job/search/stream?pattern=x",
+		"/jobs/" + url.PathEscape("https://buildkite.com/org/pipeline/builds/1") + "/search/stream?pattern=x",
+		"/jobs/0190a7a4-5b3c-7d1e-9f00-1234567890ab/search/stream",
+		"/jobs/0190a7a4-5b3c-7d1e-9f00-1234567890ab/search/stream?pattern=x&context=-1",
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", target, rec.Code)
+		}
+	}
+}
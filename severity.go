@@ -0,0 +1,79 @@
+package buildkitelogs
+
+import (
+	"iter"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// WithMinSeverity makes the reader return only entries whose severity (see
+// ParquetLogEntry.InferredSeverity) is at least min: ReadEntriesIter, SeekToRow, the
+// group filters, ListGroups, GroupTails and AnnotatedEntriesIter skip the others, and
+// SearchEntriesIter only reports matches at or above it, with their context unfiltered.
+// Files written with a severity column are filtered on it; older files infer severities
+// from content while reading. Pass logparser.SeverityUnknown to return every entry again.
+func (pr *ParquetReader) WithMinSeverity(min logparser.Severity) *ParquetReader {
+	pr.minSeverity = min
+	return pr
+}
+
+// WithLevel makes the reader return only entries whose severity (see
+// ParquetLogEntry.InferredSeverity) is level, such as only the errors of a log, with the
+// same reach as WithMinSeverity. SearchOptions.Level does the same for a single search.
+// Pass logparser.SeverityUnknown to return every entry again.
+func (pr *ParquetReader) WithLevel(level logparser.Severity) *ParquetReader {
+	pr.level = level
+	return pr
+}
+
+// matchesLevel reports whether entry is of the search's level, see SearchOptions.Level
+func (o SearchOptions) matchesLevel(entry *ParquetLogEntry) bool {
+	return o.Level == logparser.SeverityUnknown || entry.InferredSeverity() == o.Level
+}
+
+// filtersSeverity reports whether the reader skips entries by severity
+func (pr *ParquetReader) filtersSeverity() bool {
+	return pr.minSeverity != logparser.SeverityUnknown || pr.level != logparser.SeverityUnknown
+}
+
+// severityFiltered reports whether the reader's minimum severity or level skips entry
+func (pr *ParquetReader) severityFiltered(entry *ParquetLogEntry) bool {
+	severity := entry.InferredSeverity()
+	return severity < pr.minSeverity || (pr.level != logparser.SeverityUnknown && severity != pr.level)
+}
+
+// viewEntries applies the reader's group aliases, minimum severity and level to entries
+func (pr *ParquetReader) viewEntries(entries iter.Seq2[ParquetLogEntry, error]) iter.Seq2[ParquetLogEntry, error] {
+	entries = pr.aliasedEntries(entries)
+	if !pr.filtersSeverity() {
+		return entries
+	}
+	return func(yield func(ParquetLogEntry, error) bool) {
+		for entry, err := range entries {
+			if err == nil && pr.severityFiltered(&entry) {
+				continue
+			}
+			if !yield(entry, err) {
+				return
+			}
+		}
+	}
+}
+
+// viewSearchResults applies the reader's group aliases, minimum severity and level to results
+func (pr *ParquetReader) viewSearchResults(results iter.Seq2[SearchResult, error]) iter.Seq2[SearchResult, error] {
+	results = pr.aliasedSearchResults(results)
+	if !pr.filtersSeverity() {
+		return results
+	}
+	return func(yield func(SearchResult, error) bool) {
+		for result, err := range results {
+			if err == nil && pr.severityFiltered(&result.Match) {
+				continue
+			}
+			if !yield(result, err) {
+				return
+			}
+		}
+	}
+}
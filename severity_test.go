@@ -0,0 +1,125 @@
+package buildkitelogs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func writeSeverityTestFile(t *testing.T) string {
+	t.Helper()
+	entries := []*logparser.Entry{
+		{Content: "~~~ Running tests", Group: "~~~ Running tests"},
+		{Content: "INFO starting after error budget reset", Group: "~~~ Running tests"},
+		{Content: "WARN flaky test retried", Group: "~~~ Running tests"},
+		{Content: "FAIL: TestCache (0.01s)", Group: "~~~ Running tests"},
+		{Content: "~~~ Cleanup", Group: "~~~ Cleanup"},
+		{Content: "level=error msg=\"upload failed\"", Group: "~~~ Cleanup"},
+	}
+	filename := filepath.Join(t.TempDir(), "severity.parquet")
+	if err := ExportSeq2ToParquet(entrySeq(entries), filename); err != nil {
+		t.Fatalf("ExportSeq2ToParquet failed: %v", err)
+	}
+	return filename
+}
+
+func TestParquetWriter_Severity(t *testing.T) {
+	reader := NewParquetReader(writeSeverityTestFile(t))
+
+	want := []logparser.Severity{
+		logparser.SeverityInfo, logparser.SeverityInfo, logparser.SeverityWarn,
+		logparser.SeverityError, logparser.SeverityInfo, logparser.SeverityError,
+	}
+	var got []logparser.Severity
+	for entry, err := range reader.ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		got = append(got, entry.Severity)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("read %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d severity = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	// Files written before the severity column infer it from content
+	old := ParquetLogEntry{Content: "ERROR: disk full"}
+	if old.InferredSeverity() != logparser.SeverityError {
+		t.Errorf("inferred severity = %v, want error", old.InferredSeverity())
+	}
+}
+
+func TestParquetReader_WithMinSeverity(t *testing.T) {
+	reader := NewParquetReader(writeSeverityTestFile(t)).WithMinSeverity(logparser.SeverityWarn).WithColumns("content")
+
+	var rows []int64
+	for entry, err := range reader.FilterByGroupIter(t.Context(), "tests") {
+		if err != nil {
+			t.Fatalf("FilterByGroupIter failed: %v", err)
+		}
+		rows = append(rows, entry.RowNumber)
+	}
+	if len(rows) != 2 || rows[0] != 2 || rows[1] != 3 {
+		t.Errorf("rows = %v, want the warning and failure, 2 and 3", rows)
+	}
+
+	matches := 0
+	for result, err := range reader.SearchEntriesIter(t.Context(), SearchOptions{Pattern: "error|fail"}) {
+		if err != nil {
+			t.Fatalf("SearchEntriesIter failed: %v", err)
+		}
+		if result.Match.RowNumber == 1 {
+			t.Error("search matched the info entry")
+		}
+		matches++
+	}
+	if matches != 2 {
+		t.Errorf("got %d matches, want 2", matches)
+	}
+
+	tails, err := reader.WithMinSeverity(logparser.SeverityError).GroupTails(t.Context(), 1)
+	if err != nil {
+		t.Fatalf("GroupTails failed: %v", err)
+	}
+	if len(tails) != 2 || tails[0].Entries[0].RowNumber != 3 || tails[1].Entries[0].RowNumber != 5 {
+		t.Errorf("tails = %+v, want the last error of each group", tails)
+	}
+}
+
+func TestParquetReader_WithLevel(t *testing.T) {
+	reader := NewParquetReader(writeSeverityTestFile(t)).WithLevel(logparser.SeverityWarn)
+
+	var rows []int64
+	for entry, err := range reader.ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		rows = append(rows, entry.RowNumber)
+	}
+	if len(rows) != 1 || rows[0] != 2 {
+		t.Errorf("rows = %v, want the warning, 2", rows)
+	}
+}
+
+func TestSearchOptions_Level(t *testing.T) {
+	reader := NewParquetReader(writeSeverityTestFile(t)).WithColumns("content")
+
+	var rows []int64
+	for result, err := range reader.SearchEntriesIter(t.Context(), SearchOptions{Pattern: "error|fail", Level: logparser.SeverityError, BeforeContext: 1}) {
+		if err != nil {
+			t.Fatalf("SearchEntriesIter failed: %v", err)
+		}
+		rows = append(rows, result.Match.RowNumber)
+		if result.Match.RowNumber == 3 && (len(result.BeforeContext) != 1 || result.BeforeContext[0].RowNumber != 2) {
+			t.Errorf("context = %+v, want the warning before the failure", result.BeforeContext)
+		}
+	}
+	if len(rows) != 2 || rows[0] != 3 || rows[1] != 5 {
+		t.Errorf("matches = %v, want the errors, 3 and 5", rows)
+	}
+}
@@ -0,0 +1,122 @@
+package buildkitelogs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ParquetSnapshot describes a consistent, readable prefix of a Parquet file that is
+// still being written. It pairs the bytes of every completed row group with a footer
+// describing exactly those row groups, so readers never observe a partial batch.
+type ParquetSnapshot struct {
+	Rows      int64 // number of rows visible through the snapshot
+	RowGroups int   // number of row groups visible through the snapshot
+	DataSize  int64 // length of the file prefix covered by the snapshot
+
+	footer []byte
+}
+
+// Snapshot captures the rows written so far. Readers opened with
+// NewParquetReaderFromSnapshot see exactly these rows, regardless of any batches the
+// writer appends afterwards.
+//
+// Snapshot must be called from the goroutine driving the writer, between calls to
+// WriteBatch. The group index is only written on Close, so FilterByGroupIter on a
+// snapshot falls back to a full scan.
+func (pw *ParquetWriter) Snapshot() (*ParquetSnapshot, error) {
+	md, err := pw.writer.FileMetadata()
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot file metadata: %w", err)
+	}
+
+	var footer bytes.Buffer
+	n, err := md.WriteTo(&footer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize file metadata: %w", err)
+	}
+	if err := binary.Write(&footer, binary.LittleEndian, uint32(n)); err != nil {
+		return nil, fmt.Errorf("failed to serialize file metadata: %w", err)
+	}
+	footer.WriteString("PAR1")
+
+	return &ParquetSnapshot{
+		Rows:      md.NumRows,
+		RowGroups: md.NumRowGroups(),
+		DataSize:  pw.writer.TotalBytesWritten(),
+		footer:    footer.Bytes(),
+	}, nil
+}
+
+// NewParquetReaderFromSnapshot creates a ParquetReader over a file that is still being
+// written, exposing only the rows captured by snapshot. The caller retains ownership of
+// the file; Close() is a no-op.
+func NewParquetReaderFromSnapshot(filename string, snapshot *ParquetSnapshot) *ParquetReader {
+	return &ParquetReader{
+		filename: filename,
+		source:   snapshotSource(filename, snapshot),
+	}
+}
+
+// snapshotSource returns a parquetSource presenting the snapshot's file prefix followed
+// by its footer, as if the writer had been closed at the time of the snapshot.
+func snapshotSource(filename string, snapshot *ParquetSnapshot) parquetSource {
+	return func() (*openedParquet, error) {
+		osFile, err := os.Open(filename) //nolint:gosec // caller-controlled path
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file: %w", err)
+		}
+
+		fileInfo, err := osFile.Stat()
+		if err != nil {
+			_ = osFile.Close()
+			return nil, fmt.Errorf("failed to get file info: %w", err)
+		}
+		if fileInfo.Size() < snapshot.DataSize {
+			_ = osFile.Close()
+			return nil, fmt.Errorf("file is shorter than snapshot: %d < %d bytes", fileInfo.Size(), snapshot.DataSize)
+		}
+
+		size := snapshot.DataSize + int64(len(snapshot.footer))
+		r := &concatReaderAt{
+			head:     io.NewSectionReader(osFile, 0, snapshot.DataSize),
+			headSize: snapshot.DataSize,
+			tail:     bytes.NewReader(snapshot.footer),
+		}
+		return &openedParquet{
+			r:     io.NewSectionReader(r, 0, size),
+			size:  size,
+			close: osFile.Close,
+		}, nil
+	}
+}
+
+// concatReaderAt joins two ReaderAts end to end.
+type concatReaderAt struct {
+	head     io.ReaderAt
+	headSize int64
+	tail     io.ReaderAt
+}
+
+func (c *concatReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= c.headSize {
+		return c.tail.ReadAt(p, off-c.headSize)
+	}
+
+	want := min(int64(len(p)), c.headSize-off)
+	n, err := c.head.ReadAt(p[:want], off)
+	if err != nil && err != io.EOF {
+		return n, err
+	}
+	if n < int(want) {
+		return n, io.ErrUnexpectedEOF
+	}
+	if n == len(p) {
+		return n, nil
+	}
+
+	m, err := c.tail.ReadAt(p[n:], 0)
+	return n + m, err
+}
@@ -0,0 +1,112 @@
+package buildkitelogs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func snapshotTestBatch(start, n int) []*logparser.Entry {
+	baseTime := time.Date(2025, 4, 22, 21, 43, 29, 0, time.UTC)
+	entries := make([]*logparser.Entry, n)
+	for i := range entries {
+		row := start + i
+		entries[i] = &logparser.Entry{
+			Timestamp: baseTime.Add(time.Duration(row) * time.Millisecond),
+			Content:   fmt.Sprintf("line %d", row),
+			Group:     "~~~ Build",
+		}
+	}
+	return entries
+}
+
+func TestParquetReaderFromSnapshot(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "appending.parquet")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer file.Close()
+
+	writer, err := NewParquetWriter(file)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+
+	empty, err := writer.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+
+	for _, batch := range [][]*logparser.Entry{snapshotTestBatch(0, 100), snapshotTestBatch(100, 50)} {
+		if err := writer.WriteBatch(batch); err != nil {
+			t.Fatalf("WriteBatch failed: %v", err)
+		}
+	}
+
+	snapshot, err := writer.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	if snapshot.Rows != 150 || snapshot.RowGroups != 2 {
+		t.Fatalf("snapshot = %d rows in %d row groups, want 150 in 2", snapshot.Rows, snapshot.RowGroups)
+	}
+
+	// The writer keeps appending while the snapshot is read.
+	if err := writer.WriteBatch(snapshotTestBatch(150, 25)); err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+
+	reader := NewParquetReaderFromSnapshot(filename, snapshot)
+	count := 0
+	for entry, err := range reader.ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		if want := fmt.Sprintf("line %d", count); entry.Content != want {
+			t.Fatalf("entry %d content = %q, want %q", count, entry.Content, want)
+		}
+		count++
+	}
+	if count != 150 {
+		t.Errorf("read %d entries through snapshot, want 150", count)
+	}
+
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	if info.RowCount != 150 {
+		t.Errorf("RowCount = %d, want 150", info.RowCount)
+	}
+
+	emptyCount := 0
+	for _, err := range NewParquetReaderFromSnapshot(filename, empty).ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter on empty snapshot failed: %v", err)
+		}
+		emptyCount++
+	}
+	if emptyCount != 0 {
+		t.Errorf("read %d entries through empty snapshot, want 0", emptyCount)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	total := 0
+	for _, err := range NewParquetReader(filename).ReadEntriesIter(t.Context()) {
+		if err != nil {
+			t.Fatalf("ReadEntriesIter failed: %v", err)
+		}
+		total++
+	}
+	if total != 175 {
+		t.Errorf("read %d entries after close, want 175", total)
+	}
+}
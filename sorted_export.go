@@ -0,0 +1,311 @@
+package buildkitelogs
+
+import (
+	"bufio"
+	"cmp"
+	"container/heap"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// SortedByMetadataKey is the Parquet key/value metadata key recording the order rows
+// were written in. It is absent for files in log order.
+const SortedByMetadataKey = "buildkite.sorted_by"
+
+// SortedByTimestamp is the SortedByMetadataKey value of files written by the sorted
+// export functions.
+const SortedByTimestamp = "timestamp"
+
+// DefaultSortBufferEntries is the default number of entries sorted in memory before a
+// sorted run is spilled to disk.
+const DefaultSortBufferEntries = 200_000
+
+// SortOptions configures sorted Parquet export.
+type SortOptions struct {
+	MaxBufferedEntries int    // Entries sorted in memory before spilling a run to disk (0 = DefaultSortBufferEntries)
+	TempDir            string // Directory for spilled runs (default os.TempDir())
+}
+
+// ExportSeq2ToParquetSorted exports filtered log entries to filename ordered by timestamp.
+// See ExportSeq2ToParquetWriterSorted.
+func ExportSeq2ToParquetSorted(seq iter.Seq2[*logparser.Entry, error], filename string, filterFunc func(*logparser.Entry) bool, opts SortOptions, writerOpts ...ParquetWriterOption) (int, error) {
+	file, err := os.Create(filename) //nolint:gosec // caller-controlled path
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = file.Close() }()
+
+	return ExportSeq2ToParquetWriterSorted(seq, file, filterFunc, opts, writerOpts...)
+}
+
+// ExportSeq2ToParquetWriterSorted exports filtered log entries to w ordered by timestamp,
+// and records SortedByTimestamp in the file metadata. The sort is stable: entries with
+// equal timestamps keep their log order. Entries without a timestamp sort with the
+// closest timestamped entry before them, so they stay next to it.
+//
+// Inputs larger than opts.MaxBufferedEntries are sorted in runs spilled to temporary
+// files and merged, so memory use stays bounded.
+func ExportSeq2ToParquetWriterSorted(seq iter.Seq2[*logparser.Entry, error], w io.Writer, filterFunc func(*logparser.Entry) bool, opts SortOptions, writerOpts ...ParquetWriterOption) (int, error) {
+	maxBuffered := opts.MaxBufferedEntries
+	if maxBuffered <= 0 {
+		maxBuffered = DefaultSortBufferEntries
+	}
+
+	var runs []*sortRun
+	defer func() {
+		for _, run := range runs {
+			run.remove()
+		}
+	}()
+
+	buffer := make([]sortEntry, 0, min(maxBuffered, 1024))
+	var lastKey int64
+	rows := 0
+	for entry, err := range seq {
+		if err != nil {
+			return rows, fmt.Errorf("error during iteration: %w", err)
+		}
+		if filterFunc != nil && !filterFunc(entry) {
+			continue
+		}
+
+		item := newSortEntry(entry, lastKey)
+		lastKey = item.Key
+		buffer = append(buffer, item)
+		rows++
+
+		if len(buffer) >= maxBuffered {
+			run, err := spillSortRun(buffer, opts.TempDir)
+			if err != nil {
+				return rows, err
+			}
+			runs = append(runs, run)
+			buffer = buffer[:0]
+		}
+	}
+	sortEntries(buffer)
+
+	writer, err := NewParquetWriterForWriter(w, writerOpts...)
+	if err != nil {
+		return 0, err
+	}
+	writer.sortedBy = SortedByTimestamp
+	writer.producer.Filtered = filterFunc != nil
+	defer func() { _ = writer.Close() }()
+
+	if len(runs) == 0 {
+		return rows, writeSortedEntries(writer, slices.Values(buffer))
+	}
+
+	// The in-memory remainder is the last run in input order
+	sources := make([]iter.Seq2[sortEntry, error], 0, len(runs)+1)
+	for _, run := range runs {
+		sources = append(sources, run.entries())
+	}
+	sources = append(sources, func(yield func(sortEntry, error) bool) {
+		for _, item := range buffer {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	})
+	return rows, writeMergedEntries(writer, sources)
+}
+
+// sortEntry is an entry being sorted. Key is its timestamp, or the timestamp of the
+// closest timestamped entry before it.
+type sortEntry struct {
+	Key       int64
+	Timestamp int64 // Unix milliseconds, or 0 without a timestamp
+	HasTime   bool
+	Content   string
+	Group     string
+}
+
+func newSortEntry(entry *logparser.Entry, lastKey int64) sortEntry {
+	item := sortEntry{Key: lastKey, Content: entry.Content, Group: entry.Group}
+	if entry.HasTimestamp() {
+		item.Timestamp = entry.Timestamp.UnixMilli()
+		item.HasTime = true
+		item.Key = item.Timestamp
+	}
+	return item
+}
+
+func (s sortEntry) logEntry() *logparser.Entry {
+	entry := &logparser.Entry{Content: s.Content, Group: s.Group}
+	if s.HasTime {
+		entry.Timestamp = time.UnixMilli(s.Timestamp)
+	}
+	return entry
+}
+
+func sortEntries(entries []sortEntry) {
+	slices.SortStableFunc(entries, func(a, b sortEntry) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+}
+
+func writeSortedEntries(writer *ParquetWriter, entries iter.Seq[sortEntry]) error {
+	const batchSize = 1000
+	batch := make([]*logparser.Entry, 0, batchSize)
+	for item := range entries {
+		batch = append(batch, item.logEntry())
+		if len(batch) >= batchSize {
+			if err := writer.WriteBatch(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	return writer.WriteBatch(batch)
+}
+
+// writeMergedEntries merges sorted sources in key order. Ties go to the earlier source,
+// which keeps the merge stable because sources are runs in input order.
+func writeMergedEntries(writer *ParquetWriter, sources []iter.Seq2[sortEntry, error]) error {
+	var merge mergeHeap
+	for i, source := range sources {
+		next, stop := iter.Pull2(source)
+		defer stop()
+		cursor := &mergeCursor{source: i, next: next}
+		ok, err := cursor.advance()
+		if err != nil {
+			return err
+		}
+		if ok {
+			merge = append(merge, cursor)
+		}
+	}
+	heap.Init(&merge)
+
+	var mergeErr error
+	merged := func(yield func(sortEntry) bool) {
+		for merge.Len() > 0 {
+			cursor := merge[0]
+			if !yield(cursor.head) {
+				return
+			}
+			ok, err := cursor.advance()
+			if err != nil {
+				mergeErr = err
+				return
+			}
+			if ok {
+				heap.Fix(&merge, 0)
+			} else {
+				heap.Pop(&merge)
+			}
+		}
+	}
+	if err := writeSortedEntries(writer, merged); err != nil {
+		return err
+	}
+	return mergeErr
+}
+
+type mergeCursor struct {
+	source int
+	head   sortEntry
+	next   func() (sortEntry, error, bool)
+}
+
+func (c *mergeCursor) advance() (bool, error) {
+	item, err, ok := c.next()
+	if !ok {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	c.head = item
+	return true, nil
+}
+
+type mergeHeap []*mergeCursor
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	return cmp.Or(cmp.Compare(h[i].head.Key, h[j].head.Key), cmp.Compare(h[i].source, h[j].source)) < 0
+}
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)   { *h = append(*h, x.(*mergeCursor)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// sortRun is a sorted run of entries spilled to a temporary file.
+type sortRun struct {
+	path string
+}
+
+// spillSortRun sorts entries and writes them to a temporary file in dir.
+func spillSortRun(entries []sortEntry, dir string) (*sortRun, error) {
+	sortEntries(entries)
+
+	file, err := os.CreateTemp(dir, "bklog-sort-*.run")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sort run: %w", err)
+	}
+	run := &sortRun{path: file.Name()}
+
+	buffered := bufio.NewWriter(file)
+	encoder := gob.NewEncoder(buffered)
+	for _, item := range entries {
+		if err = encoder.Encode(item); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		run.remove()
+		return nil, fmt.Errorf("failed to write sort run: %w", err)
+	}
+	return run, nil
+}
+
+// entries streams the run's entries back in sorted order.
+func (r *sortRun) entries() iter.Seq2[sortEntry, error] {
+	return func(yield func(sortEntry, error) bool) {
+		file, err := os.Open(r.path)
+		if err != nil {
+			yield(sortEntry{}, fmt.Errorf("failed to open sort run: %w", err))
+			return
+		}
+		defer file.Close()
+
+		decoder := gob.NewDecoder(bufio.NewReader(file))
+		for {
+			var item sortEntry
+			if err := decoder.Decode(&item); err != nil {
+				if !errors.Is(err, io.EOF) {
+					yield(sortEntry{}, fmt.Errorf("failed to read sort run: %w", err))
+				}
+				return
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (r *sortRun) remove() {
+	_ = os.Remove(r.path)
+}
@@ -0,0 +1,78 @@
+package buildkitelogs
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestExportSeq2ToParquetSorted(t *testing.T) {
+	base := time.Date(2025, 4, 22, 21, 43, 29, 0, time.UTC)
+	at := func(ms int) time.Time { return base.Add(time.Duration(ms) * time.Millisecond) }
+	input := []*logparser.Entry{
+		{Timestamp: at(30), Content: "c1"},
+		{Content: "c1 continued"}, // No timestamp: stays after c1
+		{Timestamp: at(10), Content: "a1"},
+		{Timestamp: at(20), Content: "b1"},
+		{Timestamp: at(10), Content: "a2"}, // Ties keep log order
+		{Timestamp: at(40), Content: "skip"},
+		{Timestamp: at(20), Content: "b2"},
+		{Timestamp: at(10), Content: "a3"},
+	}
+	seq := func(yield func(*logparser.Entry, error) bool) {
+		for _, entry := range input {
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+	filter := func(entry *logparser.Entry) bool { return entry.Content != "skip" }
+	want := []string{"a1", "a2", "a3", "b1", "b2", "c1", "c1 continued"}
+
+	for _, bufferSize := range []int{0, 1, 3} {
+		filename := filepath.Join(t.TempDir(), "sorted.parquet")
+		rows, err := ExportSeq2ToParquetSorted(seq, filename, filter, SortOptions{MaxBufferedEntries: bufferSize, TempDir: t.TempDir()})
+		if err != nil {
+			t.Fatalf("buffer %d: export failed: %v", bufferSize, err)
+		}
+		if rows != len(want) {
+			t.Errorf("buffer %d: exported %d rows, want %d", bufferSize, rows, len(want))
+		}
+
+		reader := NewParquetReader(filename)
+		var got []string
+		for entry, err := range reader.ReadEntriesIter(t.Context()) {
+			if err != nil {
+				t.Fatalf("buffer %d: read failed: %v", bufferSize, err)
+			}
+			got = append(got, entry.Content)
+			if entry.Content == "c1 continued" && entry.HasTime() {
+				t.Errorf("buffer %d: entry without timestamp gained one", bufferSize)
+			}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("buffer %d: order = %v, want %v", bufferSize, got, want)
+		}
+
+		info, err := reader.GetFileInfo()
+		if err != nil {
+			t.Fatalf("GetFileInfo failed: %v", err)
+		}
+		if info.SortedBy != SortedByTimestamp {
+			t.Errorf("buffer %d: SortedBy = %q, want %q", bufferSize, info.SortedBy, SortedByTimestamp)
+		}
+	}
+}
+
+func TestExportUnsortedHasNoSortMetadata(t *testing.T) {
+	info, err := NewParquetReader(writeGroupIndexTestFile(t, groupIndexTestEntries())).GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	if info.SortedBy != "" {
+		t.Errorf("SortedBy = %q, want empty", info.SortedBy)
+	}
+}
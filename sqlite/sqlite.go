@@ -0,0 +1,180 @@
+// Package sqlite exports parsed Buildkite logs to a SQLite database, for ad-hoc SQL over
+// a job's logs where Parquet tooling is unavailable. Parquet remains the cache format;
+// SQLite is an interchange format. Databases are written with modernc.org/sqlite, a pure
+// Go driver, so no cgo is needed:
+//
+//	rows, err := sqlite.ExportSeq2ToSQLite(logparser.New().All(logReader), "logs.db")
+//
+// then, for example:
+//
+//	sqlite3 logs.db "SELECT group_name, count(*) FROM logs WHERE severity = 'error' GROUP BY 1"
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"os"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/buildkite-logs/logparser"
+	_ "modernc.org/sqlite" // Registers the "sqlite" database/sql driver
+)
+
+// TableName is the table a Writer creates and inserts into.
+const TableName = "logs"
+
+// Schema creates the table a Writer inserts into. Rows are numbered from 0 in the order
+// they are written. Timestamps are Unix milliseconds, NULL for lines without one;
+// datetime(timestamp / 1000, 'unixepoch') converts them. Groups are named group_name,
+// as in buildkitelogs.ClickHouseTableSchema, since GROUP is a keyword. flags holds the
+// Parquet flags bits and severity the inferred severity (info, warn or error).
+const Schema = `CREATE TABLE logs (
+    row_number   INTEGER PRIMARY KEY,
+    timestamp    INTEGER,
+    group_name   TEXT NOT NULL,
+    parent_group TEXT NOT NULL,
+    depth        INTEGER NOT NULL,
+    is_group     INTEGER NOT NULL,
+    flags        INTEGER NOT NULL,
+    severity     TEXT NOT NULL,
+    content      TEXT NOT NULL
+)`
+
+// indexes are created once the rows are written, which is faster than keeping them up to
+// date during the inserts.
+var indexes = []string{
+	`CREATE INDEX logs_timestamp ON logs (timestamp)`,
+	`CREATE INDEX logs_group_name ON logs (group_name)`,
+}
+
+const insertRow = `INSERT INTO logs (row_number, timestamp, group_name, parent_group, depth, is_group, flags, severity, content)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// Writer is a buildkitelogs.LogWriter that writes entries to the logs table of a new
+// SQLite database (see Schema) in a single transaction. Close indexes the table on
+// timestamp and group_name and commits. A Writer is not safe for concurrent use.
+type Writer struct {
+	db     *sql.DB
+	tx     *sql.Tx
+	insert *sql.Stmt
+	rows   int
+	closed bool
+}
+
+var _ buildkitelogs.LogWriter = (*Writer)(nil)
+
+// NewWriter creates a database at dbPath, replacing any file there, and its logs table.
+func NewWriter(dbPath string) (*Writer, error) {
+	if err := os.Remove(dbPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to replace SQLite database: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	// The transaction holds the only connection
+	db.SetMaxOpenConns(1)
+
+	tx, err := db.Begin()
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	if _, err := tx.Exec(Schema); err != nil {
+		_ = tx.Rollback()
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create %s table: %w", TableName, err)
+	}
+	insert, err := tx.Prepare(insertRow)
+	if err != nil {
+		_ = tx.Rollback()
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to prepare insert: %w", err)
+	}
+
+	return &Writer{db: db, tx: tx, insert: insert}, nil
+}
+
+// WriteBatch inserts entries into the table.
+func (w *Writer) WriteBatch(entries []*logparser.Entry) error {
+	if w.closed {
+		return errors.New("sqlite writer is closed")
+	}
+
+	for _, entry := range entries {
+		timestamp := sql.NullInt64{Int64: entry.Timestamp.UnixMilli(), Valid: entry.HasTimestamp()}
+		_, err := w.insert.Exec(
+			w.rows,
+			timestamp,
+			entry.Group,
+			entry.ParentGroup,
+			entry.Depth,
+			entry.IsGroup(),
+			int64(entry.ComputeFlags()),
+			entry.Severity().String(),
+			entry.Content,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert row %d: %w", w.rows, err)
+		}
+		w.rows++
+	}
+	return nil
+}
+
+// Rows returns the number of entries written.
+func (w *Writer) Rows() int {
+	return w.rows
+}
+
+// Close indexes the table, commits the entries written and closes the database. Later
+// writes fail.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	err := w.commit()
+	if closeErr := w.db.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close SQLite database: %w", closeErr)
+	}
+	return err
+}
+
+func (w *Writer) commit() error {
+	if err := w.insert.Close(); err != nil {
+		_ = w.tx.Rollback()
+		return fmt.Errorf("failed to finish inserts: %w", err)
+	}
+	for _, index := range indexes {
+		if _, err := w.tx.Exec(index); err != nil {
+			_ = w.tx.Rollback()
+			return fmt.Errorf("failed to index %s table: %w", TableName, err)
+		}
+	}
+	if err := w.tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit SQLite database: %w", err)
+	}
+	return nil
+}
+
+// ExportSeq2ToSQLite writes log entries to a new SQLite database at dbPath, replacing any
+// file there, with the logs table indexed on timestamp and group_name (see Writer). It
+// returns the number of entries written.
+func ExportSeq2ToSQLite(seq iter.Seq2[*logparser.Entry, error], dbPath string) (int, error) {
+	w, err := NewWriter(dbPath)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := buildkitelogs.ExportSeq2ToLogWriter(seq, w, nil)
+	if err != nil {
+		_ = w.Close()
+		return rows, err
+	}
+	return rows, w.Close()
+}
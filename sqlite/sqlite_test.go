@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestExportSeq2ToSQLite(t *testing.T) {
+	log := "\x1b_bk;t=1745322209921\x07~~~ Setup\n\x1b_bk;t=1745322209922\x07ERROR: failed\nno timestamp\n"
+	dbPath := filepath.Join(t.TempDir(), "logs.db")
+
+	// A second export replaces the first
+	for range 2 {
+		rows, err := ExportSeq2ToSQLite(logparser.New().All(strings.NewReader(log)), dbPath)
+		if err != nil {
+			t.Fatalf("ExportSeq2ToSQLite failed: %v", err)
+		}
+		if rows != 3 {
+			t.Errorf("rows = %d, want 3", rows)
+		}
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM logs`).Scan(&count); err != nil {
+		t.Fatalf("count failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("count = %d, want 3", count)
+	}
+
+	var (
+		timestamp sql.NullInt64
+		group     string
+		isGroup   bool
+		severity  string
+		content   string
+	)
+	row := db.QueryRow(`SELECT timestamp, group_name, is_group, severity, content FROM logs WHERE row_number = 1`)
+	if err := row.Scan(&timestamp, &group, &isGroup, &severity, &content); err != nil {
+		t.Fatalf("row 1 failed: %v", err)
+	}
+	if timestamp.Int64 != 1745322209922 || group != "~~~ Setup" || isGroup || severity != "error" || content != "ERROR: failed" {
+		t.Errorf("row 1 = %v %q %v %q %q", timestamp, group, isGroup, severity, content)
+	}
+
+	if err := db.QueryRow(`SELECT timestamp FROM logs WHERE row_number = 2`).Scan(&timestamp); err != nil {
+		t.Fatalf("row 2 failed: %v", err)
+	}
+	if timestamp.Valid {
+		t.Errorf("row 2 timestamp = %d, want NULL for a line without one", timestamp.Int64)
+	}
+
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'logs' ORDER BY name`)
+	if err != nil {
+		t.Fatalf("index query failed: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		names = append(names, name)
+	}
+	if want := []string{"logs_group_name", "logs_timestamp"}; !slices.Equal(names, want) {
+		t.Errorf("indexes = %v, want %v", names, want)
+	}
+}
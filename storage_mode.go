@@ -0,0 +1,82 @@
+package buildkitelogs
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StorageModeEnv names the environment variable that selects the StorageMode when none is
+// configured, e.g. BKLOG_STORAGE_MODE=desktop keeps CI runs caching in ~/.bklog.
+const StorageModeEnv = "BKLOG_STORAGE_MODE"
+
+// StorageMode selects where logs are cached when no storage URL is given.
+type StorageMode string
+
+const (
+	// StorageModeAuto uses StorageModeEnv if set, otherwise StorageModeContainer when
+	// IsContainerizedEnvironment reports a container or CI, and StorageModeDesktop if not.
+	StorageModeAuto StorageMode = ""
+	// StorageModeDesktop caches in ~/.bklog, or the temp directory without a home directory.
+	StorageModeDesktop StorageMode = "desktop"
+	// StorageModeContainer caches in bklog under the temp directory.
+	StorageModeContainer StorageMode = "container"
+	// StorageModeCustom requires a storage URL, so a missing one is an error instead of
+	// silently falling back to a default location.
+	StorageModeCustom StorageMode = "custom"
+	// StorageModeMemory caches in memory (MemoryStorageURL), so nothing is kept between
+	// runs. See WithInMemory to keep the client off the filesystem entirely.
+	StorageModeMemory StorageMode = "memory"
+)
+
+// ParseStorageMode parses a StorageMode name. The empty string and "auto" are
+// StorageModeAuto.
+func ParseStorageMode(s string) (StorageMode, error) {
+	switch mode := StorageMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "auto":
+		return StorageModeAuto, nil
+	case StorageModeAuto, StorageModeDesktop, StorageModeContainer, StorageModeCustom, StorageModeMemory:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown storage mode %q (want auto, %s, %s, %s or %s)", s, StorageModeDesktop, StorageModeContainer, StorageModeCustom, StorageModeMemory)
+}
+
+// ResolveStorageMode returns the mode StorageModeAuto stands for in this environment, or
+// mode itself if it is anything else.
+func ResolveStorageMode(mode StorageMode) (StorageMode, error) {
+	if mode != StorageModeAuto {
+		return mode, nil
+	}
+	if value := os.Getenv(StorageModeEnv); value != "" {
+		envMode, err := ParseStorageMode(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s: %w", StorageModeEnv, err)
+		}
+		if envMode != StorageModeAuto {
+			return envMode, nil
+		}
+	}
+	if IsContainerizedEnvironment() {
+		return StorageModeContainer, nil
+	}
+	return StorageModeDesktop, nil
+}
+
+// WithStorageMode sets how the client picks its cache location when it is created without
+// a storage URL. Default is StorageModeAuto.
+func WithStorageMode(mode StorageMode) ClientOption {
+	return func(c *Client) {
+		c.storageMode = mode
+	}
+}
+
+// defaultStorageDir returns the cache directory of a resolved desktop or container mode.
+func defaultStorageDir(mode StorageMode) string {
+	if mode == StorageModeDesktop {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return fmt.Sprintf("%s/.bklog", homeDir)
+		}
+	}
+	// Containers, and desktops without a home directory
+	return fmt.Sprintf("%s/bklog", os.TempDir())
+}
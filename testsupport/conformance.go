@@ -0,0 +1,312 @@
+// Package testsupport provides conformance tests for custom implementations of the
+// buildkitelogs extension points, so they can be checked against the semantics the
+// Client relies on. Call the suites from a regular test:
+//
+//	func TestMyAPI(t *testing.T) {
+//		testsupport.TestBuildkiteAPI(t, func(t *testing.T) testsupport.APIFixture {
+//			return testsupport.APIFixture{API: newMyAPI(t), Job: finished, Log: rawLog, Missing: missing}
+//		})
+//	}
+package testsupport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// APIFixture is a BuildkiteAPI under test and the jobs it is expected to know about.
+type APIFixture struct {
+	API     buildkitelogs.BuildkiteAPI
+	Job     buildkitelogs.JobLocation // A finished job whose log is accessible
+	Log     string                    // Raw log of Job; its content is checked when non-empty
+	Missing buildkitelogs.JobLocation // A job whose log does not exist
+}
+
+// TestBuildkiteAPI checks a BuildkiteAPI implementation: job status, log existence
+// checks, log downloads, error propagation for missing logs, context cancellation, and
+// that a Client caches logs from it with every entry in log order.
+//
+// fixture is called once per subtest, so implementations with state can start fresh.
+func TestBuildkiteAPI(t *testing.T, fixture func(t *testing.T) APIFixture) {
+	t.Run("GetJobStatus", func(t *testing.T) {
+		f := fixture(t)
+		status, err := f.API.GetJobStatus(t.Context(), f.Job.Org, f.Job.Pipeline, f.Job.Build, f.Job.Job)
+		if err != nil {
+			t.Fatalf("GetJobStatus: %v", err)
+		}
+		if status == nil {
+			t.Fatal("GetJobStatus returned a nil status without an error")
+		}
+		if !status.IsTerminal {
+			t.Errorf("GetJobStatus IsTerminal = false for a finished job (state %q)", status.State)
+		}
+	})
+
+	t.Run("JobLogExists", func(t *testing.T) {
+		f := fixture(t)
+		exists, err := f.API.JobLogExists(t.Context(), f.Job.Org, f.Job.Pipeline, f.Job.Build, f.Job.Job)
+		if err != nil {
+			t.Fatalf("JobLogExists: %v", err)
+		}
+		if !exists {
+			t.Error("JobLogExists = false for an accessible log")
+		}
+	})
+
+	t.Run("JobLogExistsMissing", func(t *testing.T) {
+		f := fixture(t)
+		exists, err := f.API.JobLogExists(t.Context(), f.Missing.Org, f.Missing.Pipeline, f.Missing.Build, f.Missing.Job)
+		if err != nil {
+			t.Fatalf("JobLogExists must return a nil error for a missing log, got %v", err)
+		}
+		if exists {
+			t.Error("JobLogExists = true for a missing log")
+		}
+	})
+
+	t.Run("GetJobLog", func(t *testing.T) {
+		f := fixture(t)
+		body, err := f.API.GetJobLog(t.Context(), f.Job.Org, f.Job.Pipeline, f.Job.Build, f.Job.Job)
+		if err != nil {
+			t.Fatalf("GetJobLog: %v", err)
+		}
+		if body == nil {
+			t.Fatal("GetJobLog returned a nil reader without an error")
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("reading log: %v", err)
+		}
+		if err := body.Close(); err != nil {
+			t.Errorf("closing log: %v", err)
+		}
+		if f.Log != "" && string(data) != f.Log {
+			t.Errorf("GetJobLog returned %d bytes, want the %d bytes of the fixture log", len(data), len(f.Log))
+		}
+	})
+
+	t.Run("GetJobLogMissing", func(t *testing.T) {
+		f := fixture(t)
+		body, err := f.API.GetJobLog(t.Context(), f.Missing.Org, f.Missing.Pipeline, f.Missing.Build, f.Missing.Job)
+		if err == nil {
+			body.Close()
+			t.Fatal("GetJobLog returned no error for a missing log")
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		f := fixture(t)
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		if _, err := f.API.GetJobStatus(ctx, f.Job.Org, f.Job.Pipeline, f.Job.Build, f.Job.Job); !errors.Is(err, context.Canceled) {
+			t.Errorf("GetJobStatus with a canceled context = %v, want context.Canceled", err)
+		}
+		if _, err := f.API.JobLogExists(ctx, f.Job.Org, f.Job.Pipeline, f.Job.Build, f.Job.Job); !errors.Is(err, context.Canceled) {
+			t.Errorf("JobLogExists with a canceled context = %v, want context.Canceled", err)
+		}
+		// Streaming implementations may only notice the cancellation once the body is read
+		body, err := f.API.GetJobLog(ctx, f.Job.Org, f.Job.Pipeline, f.Job.Build, f.Job.Job)
+		if err == nil {
+			_, err = io.ReadAll(body)
+			body.Close()
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("GetJobLog with a canceled context = %v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("Client", func(t *testing.T) {
+		f := fixture(t)
+		client, err := buildkitelogs.NewClientWithAPI(t.Context(), f.API, "file://"+t.TempDir(), buildkitelogs.WithMaxLogBytes(0))
+		if err != nil {
+			t.Fatalf("NewClientWithAPI: %v", err)
+		}
+		defer client.Close()
+
+		reader, err := client.NewReader(t.Context(), f.Job.Org, f.Job.Pipeline, f.Job.Build, f.Job.Job, time.Minute, false)
+		if err != nil {
+			t.Fatalf("NewReader: %v", err)
+		}
+		defer reader.Close()
+
+		var got []string
+		for entry, err := range reader.ReadEntriesIter(t.Context()) {
+			if err != nil {
+				t.Fatalf("reading cached entries: %v", err)
+			}
+			got = append(got, entry.Content)
+		}
+		if f.Log == "" {
+			return
+		}
+
+		var want []string
+		for entry, err := range logparser.New(logparser.WithTruncateLongLines(true)).All(strings.NewReader(f.Log)) {
+			if err != nil {
+				t.Fatalf("parsing fixture log: %v", err)
+			}
+			want = append(want, entry.Content)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("cached %d entries, want %d", len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("entry %d = %q, want %q", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("ClientMissing", func(t *testing.T) {
+		f := fixture(t)
+		client, err := buildkitelogs.NewClientWithAPI(t.Context(), f.API, "file://"+t.TempDir())
+		if err != nil {
+			t.Fatalf("NewClientWithAPI: %v", err)
+		}
+		defer client.Close()
+
+		reader, err := client.NewReader(t.Context(), f.Missing.Org, f.Missing.Pipeline, f.Missing.Build, f.Missing.Job, time.Minute, false)
+		if err == nil {
+			reader.Close()
+		}
+		if !errors.Is(err, buildkitelogs.ErrJobLogUnavailable) {
+			t.Errorf("NewReader for a missing log = %v, want ErrJobLogUnavailable", err)
+		}
+	})
+}
+
+// TestBlobStorage checks that a storage backend behaves as the Client's cache expects:
+// writes with metadata round trip, overwrites change the content version, reads return
+// what was written, and deleted blobs no longer exist.
+//
+// storageURL is called once per subtest and should return an empty bucket, e.g.
+// "file://" + t.TempDir() or a prefixed URL of a test bucket.
+func TestBlobStorage(t *testing.T, storageURL func(t *testing.T) string) {
+	open := func(t *testing.T) *buildkitelogs.BlobStorage {
+		t.Helper()
+		storage, err := buildkitelogs.NewBlobStorage(t.Context(), storageURL(t), nil)
+		if err != nil {
+			t.Fatalf("NewBlobStorage: %v", err)
+		}
+		t.Cleanup(func() { storage.Close() })
+		return storage
+	}
+	const key = "conformance/job.parquet"
+
+	t.Run("Metadata", func(t *testing.T) {
+		storage := open(t)
+		ctx := t.Context()
+
+		if exists, err := storage.Exists(ctx, key); err != nil || exists {
+			t.Fatalf("Exists before writing = %v, %v; want false, nil", exists, err)
+		}
+
+		cachedAt := time.Now().UTC().Truncate(time.Second)
+		want := &buildkitelogs.BlobMetadata{
+			JobID:        "job",
+			JobState:     string(buildkitelogs.JobStatePassed),
+			IsTerminal:   true,
+			CachedAt:     cachedAt,
+			TTL:          "30s",
+			Organization: "org",
+			Pipeline:     "pipeline",
+			Build:        "1",
+			LogSize:      42,
+			RowCount:     3,
+
+			RetryOfJobID: "first-attempt",
+		}
+		if err := storage.WriteWithMetadata(ctx, key, []byte("data"), want); err != nil {
+			t.Fatalf("WriteWithMetadata: %v", err)
+		}
+		if exists, err := storage.Exists(ctx, key); err != nil || !exists {
+			t.Fatalf("Exists after writing = %v, %v; want true, nil", exists, err)
+		}
+
+		got, err := storage.ReadWithMetadata(ctx, key)
+		if err != nil {
+			t.Fatalf("ReadWithMetadata: %v", err)
+		}
+		if got == nil {
+			t.Fatal("ReadWithMetadata returned no metadata")
+		}
+		if got.JobID != want.JobID || got.JobState != want.JobState || got.IsTerminal != want.IsTerminal ||
+			!got.CachedAt.Equal(want.CachedAt) || got.TTL != want.TTL || got.Organization != want.Organization ||
+			got.Pipeline != want.Pipeline || got.Build != want.Build || got.LogSize != want.LogSize || got.RowCount != want.RowCount ||
+			got.RetryOfJobID != want.RetryOfJobID || got.RetriedInJobID != want.RetriedInJobID {
+			t.Errorf("ReadWithMetadata = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("Content", func(t *testing.T) {
+		storage := open(t)
+		ctx := t.Context()
+
+		if err := storage.WriteWithMetadata(ctx, key, []byte("first version"), nil); err != nil {
+			t.Fatalf("WriteWithMetadata: %v", err)
+		}
+		first, err := storage.ContentVersion(ctx, key)
+		if err != nil {
+			t.Fatalf("ContentVersion: %v", err)
+		}
+		if err := storage.WriteWithMetadataFrom(ctx, key, strings.NewReader("second version"), nil); err != nil {
+			t.Fatalf("WriteWithMetadataFrom: %v", err)
+		}
+		second, err := storage.ContentVersion(ctx, key)
+		if err != nil {
+			t.Fatalf("ContentVersion: %v", err)
+		}
+		if first == second {
+			t.Errorf("ContentVersion did not change after overwriting the blob: %q", first)
+		}
+
+		reader, err := storage.Reader(ctx, key)
+		if err != nil {
+			t.Fatalf("Reader: %v", err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil || string(data) != "second version" {
+			t.Errorf("Reader returned %q, %v; want %q", data, err, "second version")
+		}
+
+		readerAt, err := storage.OpenReaderAt(ctx, key)
+		if err != nil {
+			t.Fatalf("OpenReaderAt: %v", err)
+		}
+		defer readerAt.Close()
+		if readerAt.Size() != int64(len("second version")) {
+			t.Errorf("OpenReaderAt Size = %d, want %d", readerAt.Size(), len("second version"))
+		}
+		buf := make([]byte, 7)
+		if n, err := readerAt.ReadAt(buf, 7); n != 7 || (err != nil && !errors.Is(err, io.EOF)) || string(buf) != "version" {
+			t.Errorf("ReadAt(7) = %q, %d, %v; want %q", buf[:n], n, err, "version")
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		storage := open(t)
+		ctx := t.Context()
+
+		if err := storage.WriteWithMetadata(ctx, key, []byte("data"), nil); err != nil {
+			t.Fatalf("WriteWithMetadata: %v", err)
+		}
+		if err := storage.Delete(ctx, key); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if exists, err := storage.Exists(ctx, key); err != nil || exists {
+			t.Errorf("Exists after Delete = %v, %v; want false, nil", exists, err)
+		}
+		if _, err := storage.Reader(ctx, key); err == nil {
+			t.Error("Reader of a deleted blob returned no error")
+		}
+	})
+}
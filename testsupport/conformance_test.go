@@ -0,0 +1,63 @@
+package testsupport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	buildkitelogs "github.com/buildkite/buildkite-logs"
+)
+
+// memoryAPI serves logs from a map keyed by job ID
+type memoryAPI struct {
+	logs map[string]string
+}
+
+func (m *memoryAPI) GetJobStatus(ctx context.Context, org, pipeline, build, job string) (*buildkitelogs.JobStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &buildkitelogs.JobStatus{ID: job, State: buildkitelogs.JobStatePassed, IsTerminal: true}, nil
+}
+
+func (m *memoryAPI) JobLogExists(ctx context.Context, org, pipeline, build, job string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	_, ok := m.logs[job]
+	return ok, nil
+}
+
+func (m *memoryAPI) GetJobLog(ctx context.Context, org, pipeline, build, job string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	log, ok := m.logs[job]
+	if !ok {
+		return nil, fmt.Errorf("job %s: log not found", job)
+	}
+	return io.NopCloser(strings.NewReader(log)), nil
+}
+
+func TestBuildkiteAPIConformance(t *testing.T) {
+	log := "\x1b_bk;t=1745322209921\x07~~~ Setup\n" +
+		"\x1b_bk;t=1745322209922\x07first line\n" +
+		"\x1b_bk;t=1745322209923\x07second line\n"
+
+	TestBuildkiteAPI(t, func(t *testing.T) APIFixture {
+		return APIFixture{
+			API:     &memoryAPI{logs: map[string]string{"job-1": log}},
+			Job:     buildkitelogs.JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job-1"},
+			Log:     log,
+			Missing: buildkitelogs.JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job-2"},
+		}
+	})
+}
+
+func TestBlobStorageConformance(t *testing.T) {
+	TestBlobStorage(t, func(t *testing.T) string {
+		return "file://" + t.TempDir()
+	})
+}
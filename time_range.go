@@ -0,0 +1,136 @@
+package buildkitelogs
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/metadata"
+)
+
+// hasTimeRange reports whether the search is limited to a time range.
+func (o SearchOptions) hasTimeRange() bool {
+	return !o.MinTimestamp.IsZero() || !o.MaxTimestamp.IsZero()
+}
+
+// inTimeRange reports whether an entry's timestamp (Unix milliseconds) is within the
+// search's time range. Entries without a timestamp are outside any range.
+func (o SearchOptions) inTimeRange(timestamp int64) bool {
+	if timestamp == 0 {
+		return false
+	}
+	if !o.MinTimestamp.IsZero() && timestamp < o.MinTimestamp.UnixMilli() {
+		return false
+	}
+	return o.MaxTimestamp.IsZero() || timestamp <= o.MaxTimestamp.UnixMilli()
+}
+
+// timeRangeRowRanges returns the rows from startRow onwards of the row groups whose
+// timestamp statistics overlap the search's time range, merging adjacent row groups.
+// Row groups without statistics are kept.
+func timeRangeRowRanges(md *metadata.FileMetaData, startRow int64, options SearchOptions) []GroupRange {
+	column := md.Schema.ColumnIndexByName("timestamp")
+	starts := rowGroupStartRows(md)
+
+	var ranges []GroupRange
+	for i, start := range starts {
+		end := start + md.RowGroup(i).NumRows() - 1
+		if end < startRow || !rowGroupOverlapsTimeRange(md.RowGroup(i), column, options) {
+			continue
+		}
+		first := max(start, startRow)
+		if n := len(ranges); n > 0 && ranges[n-1].LastRow == first-1 {
+			ranges[n-1].LastRow = end
+			continue
+		}
+		ranges = append(ranges, GroupRange{FirstRow: first, LastRow: end})
+	}
+	return ranges
+}
+
+// rowGroupOverlapsTimeRange reports whether the timestamp statistics of a row group
+// allow rows within the search's time range.
+func rowGroupOverlapsTimeRange(rowGroup *metadata.RowGroupMetaData, column int, options SearchOptions) bool {
+	if column < 0 {
+		return true
+	}
+	chunk, err := rowGroup.ColumnChunk(column)
+	if err != nil {
+		return true
+	}
+	if set, err := chunk.StatsSet(); err != nil || !set {
+		return true
+	}
+	stats, err := chunk.Statistics()
+	if err != nil {
+		return true
+	}
+	timestamps, ok := stats.(*metadata.Int64Statistics)
+	if !ok || !timestamps.HasMinMax() {
+		return true
+	}
+
+	if !options.MinTimestamp.IsZero() && timestamps.Max() < options.MinTimestamp.UnixMilli() {
+		return false
+	}
+	return options.MaxTimestamp.IsZero() || timestamps.Min() <= options.MaxTimestamp.UnixMilli()
+}
+
+// readTimeRangeIter reads the entries from startRow onwards within the search's time
+// range, skipping the row groups whose statistics rule them out.
+func readTimeRangeIter(ctx context.Context, src parquetSource, startRow int64, options SearchOptions, columns []string) iter.Seq2[ParquetLogEntry, error] {
+	return func(yield func(ParquetLogEntry, error) bool) {
+		ranges, err := readTimeRangeRowRanges(src, startRow, options)
+		if err != nil {
+			yield(ParquetLogEntry{}, err)
+			return
+		}
+
+		for entry, err := range readParquetFileRowRangesIter(ctx, src, ranges, withTimestamp(columns)) {
+			if err == nil && !options.inTimeRange(entry.Timestamp) {
+				continue
+			}
+			if !yield(entry, err) {
+				return
+			}
+		}
+	}
+}
+
+func readTimeRangeRowRanges(src parquetSource, startRow int64, options SearchOptions) ([]GroupRange, error) {
+	opened, err := src()
+	if err != nil {
+		return nil, err
+	}
+	defer opened.Close()
+
+	pf, err := file.NewParquetReader(opened.r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open parquet file: %w", err)
+	}
+	defer pf.Close()
+
+	return timeRangeRowRanges(pf.MetaData(), startRow, options), nil
+}
+
+// withTimestamp adds the timestamp column to a column projection
+func withTimestamp(columns []string) []string {
+	if columns == nil {
+		return nil
+	}
+	return append(columns[:len(columns):len(columns)], "timestamp")
+}
+
+// timeRangeNote describes a search's time range for plans and errors
+func timeRangeNote(options SearchOptions) string {
+	from, to := "start", "end"
+	if !options.MinTimestamp.IsZero() {
+		from = options.MinTimestamp.UTC().Format(time.RFC3339)
+	}
+	if !options.MaxTimestamp.IsZero() {
+		to = options.MaxTimestamp.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprintf("time range %s to %s", from, to)
+}
@@ -0,0 +1,62 @@
+package buildkitelogs
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParquetReader_SearchTimeRange(t *testing.T) {
+	reader := NewParquetReader(writeGroupIndexTestFile(t, groupIndexTestEntries()))
+	baseTime := time.Date(2025, 4, 22, 21, 43, 29, 0, time.UTC)
+
+	// Rows 3400-3409 are "line 687" to "line 696" of the cleanup group
+	options := SearchOptions{
+		Pattern:      "line",
+		MinTimestamp: baseTime.Add(3400 * time.Millisecond),
+		MaxTimestamp: baseTime.Add(3409 * time.Millisecond),
+	}
+
+	for _, reverse := range []bool{false, true} {
+		options.Reverse = reverse
+		var rows []int64
+		for result, err := range reader.SearchEntriesIter(t.Context(), options) {
+			if err != nil {
+				t.Fatalf("SearchEntriesIter failed: %v", err)
+			}
+			rows = append(rows, result.Match.RowNumber)
+		}
+		want := []int64{3400, 3401, 3402, 3403, 3404, 3405, 3406, 3407, 3408, 3409}
+		if reverse {
+			slices.Reverse(want)
+		}
+		if !slices.Equal(rows, want) {
+			t.Errorf("reverse=%v matched rows %v, want %v", reverse, rows, want)
+		}
+	}
+
+	full, err := reader.ExplainRead()
+	if err != nil {
+		t.Fatalf("ExplainRead failed: %v", err)
+	}
+	plan, err := reader.ExplainSearch(options)
+	if err != nil {
+		t.Fatalf("ExplainSearch failed: %v", err)
+	}
+	if plan.Strategy != ScanStatistics || !slices.Equal(plan.RowGroupsRead, []int{full.RowGroups - 1}) {
+		t.Errorf("ExplainSearch = %+v, want only the last of %d row groups", plan, full.RowGroups)
+	}
+
+	// A range before the log matches nothing and reads nothing
+	options.MinTimestamp, options.MaxTimestamp = time.Time{}, baseTime.Add(-time.Second)
+	for result, err := range reader.SearchEntriesIter(t.Context(), options) {
+		t.Fatalf("unexpected result %+v, %v", result, err)
+	}
+	plan, err = reader.ExplainSearch(options)
+	if err != nil {
+		t.Fatalf("ExplainSearch failed: %v", err)
+	}
+	if len(plan.RowGroupsRead) != 0 {
+		t.Errorf("ExplainSearch reads row groups %v, want none", plan.RowGroupsRead)
+	}
+}
@@ -0,0 +1,131 @@
+package buildkitelogs
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+// ErrCannotVerify is returned by VerifyRawLog for files whose entries can't be compared
+// with their raw log row by row: files written from filtered or re-sorted entries.
+var ErrCannotVerify = errors.New("file cannot be verified against its raw log")
+
+// LogVerification is the result of comparing a Parquet log file with its raw log.
+type LogVerification struct {
+	Match         bool   `json:"match"`
+	CachedRows    int    `json:"cached_rows"`
+	RawRows       int    `json:"raw_rows"`
+	CachedDigest  string `json:"cached_digest"`  // Digest of the file's entry hashes, in order
+	RawDigest     string `json:"raw_digest"`     // Digest of the re-parsed raw log's entry hashes
+	FirstMismatch int64  `json:"first_mismatch"` // Row of the first differing entry, -1 when they match
+}
+
+// VerifyRawLog parses a raw log and checks that it produces the entries of a Parquet
+// file: the same number of entries with the same content, compared by ContentHash. A
+// mismatch means the log changed after the file was written, such as a log that was
+// still being written when a supposedly finished job was cached.
+//
+// The raw log is parsed with the parser options recorded in the file (see
+// WithSourceParser), then opts, and truncated as the file's input was (see
+// WithTruncateLargeLogs), so files written by the client's cache compare equal to a
+// fresh download. Disable the reader's group aliases and severity filter, which change
+// the entries read. Files written from filtered or sorted entries return ErrCannotVerify.
+func VerifyRawLog(ctx context.Context, reader *ParquetReader, raw io.Reader, opts ...logparser.Option) (*LogVerification, error) {
+	info, err := reader.GetFileInfo()
+	if err != nil {
+		return nil, err
+	}
+	if info.SortedBy != "" {
+		return nil, fmt.Errorf("%w: entries are sorted by %s", ErrCannotVerify, info.SortedBy)
+	}
+	if info.Producer != nil && info.Producer.Filtered {
+		return nil, fmt.Errorf("%w: entries were filtered", ErrCannotVerify)
+	}
+
+	var parserOpts []logparser.Option
+	if info.Producer != nil && info.Producer.ParserOptions != nil {
+		recorded := info.Producer.ParserOptions
+		parserOpts = append(parserOpts,
+			logparser.WithMaxLineBytes(recorded.MaxLineBytes),
+			logparser.WithTruncateLongLines(recorded.TruncateLongLines),
+		)
+		if recorded.TruncationSuffix != "" {
+			parserOpts = append(parserOpts, logparser.WithTruncationSuffix(recorded.TruncationSuffix))
+		}
+	}
+	parser := logparser.New(append(parserOpts, opts...)...)
+	if info.Producer != nil && info.Producer.InputTruncatedAt > 0 {
+		raw = &truncatingReadCloser{rc: io.NopCloser(raw), limit: info.Producer.InputTruncatedAt}
+	}
+
+	var cached []uint64
+	for entry, err := range reader.ReadEntriesIter(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		hash := entry.Hash
+		if hash == 0 {
+			// Written before the hash column
+			hash = ContentHash(entry.Content)
+		}
+		cached = append(cached, hash)
+	}
+
+	result := &LogVerification{CachedRows: len(cached), FirstMismatch: -1}
+	cachedDigest, rawDigest := fnv.New64a(), fnv.New64a()
+	for _, hash := range cached {
+		cachedDigest.Write(binary.LittleEndian.AppendUint64(nil, hash))
+	}
+	for entry, err := range parser.All(raw) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse raw log: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hash := ContentHash(entry.Content)
+		rawDigest.Write(binary.LittleEndian.AppendUint64(nil, hash))
+		if result.FirstMismatch < 0 && (result.RawRows >= len(cached) || cached[result.RawRows] != hash) {
+			result.FirstMismatch = int64(result.RawRows)
+		}
+		result.RawRows++
+	}
+	if result.FirstMismatch < 0 && result.RawRows < len(cached) {
+		result.FirstMismatch = int64(result.RawRows)
+	}
+
+	result.CachedDigest = strconv.FormatUint(cachedDigest.Sum64(), 16)
+	result.RawDigest = strconv.FormatUint(rawDigest.Sum64(), 16)
+	result.Match = result.FirstMismatch < 0
+	return result, nil
+}
+
+// VerifyCache downloads a job's raw log again and checks that it still produces the
+// entries of the cached log (see VerifyRawLog), detecting caches of finished jobs whose
+// logs changed after they were cached, such as logs cached while still truncated. It
+// returns ErrNotCached if the job has no cache entry. The cache is left as it is; use
+// Reparse with force to replace a stale entry.
+func (c *Client) VerifyCache(ctx context.Context, location JobLocation) (*LogVerification, error) {
+	cached, err := c.OpenCached(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	defer cached.Close()
+
+	if err := validateJobLogAccess(ctx, c.api, location.Org, location.Pipeline, location.Build, location.Job); err != nil {
+		return nil, fmt.Errorf("failed to validate job log access: %w", err)
+	}
+	raw, err := c.api.GetJobLog(ctx, location.Org, location.Pipeline, location.Build, location.Job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch logs from API: %w", err)
+	}
+	defer raw.Close()
+
+	return VerifyRawLog(ctx, NewParquetReaderFromReaderAt(cached, cached.Size()), raw, c.parserOptions...)
+}
@@ -0,0 +1,82 @@
+package buildkitelogs
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/buildkite-logs/logparser"
+)
+
+func TestVerifyRawLog(t *testing.T) {
+	log := "\x1b_bk;t=1745322209921\x07~~~ Setup\n\x1b_bk;t=1745322209922\x07building\n\x1b_bk;t=1745322209923\x07done\n"
+	filename := filepath.Join(t.TempDir(), "log.parquet")
+	if err := ExportSeq2ToParquet(logparser.New().All(strings.NewReader(log)), filename); err != nil {
+		t.Fatalf("ExportSeq2ToParquet failed: %v", err)
+	}
+	reader := NewParquetReader(filename)
+
+	result, err := VerifyRawLog(t.Context(), reader, strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("VerifyRawLog failed: %v", err)
+	}
+	if !result.Match || result.CachedRows != 3 || result.RawRows != 3 || result.FirstMismatch != -1 || result.CachedDigest != result.RawDigest {
+		t.Errorf("matching log = %+v", result)
+	}
+
+	changed := strings.Replace(log, "building", "rebuilding", 1)
+	if result, err = VerifyRawLog(t.Context(), reader, strings.NewReader(changed)); err != nil {
+		t.Fatalf("VerifyRawLog failed: %v", err)
+	}
+	if result.Match || result.FirstMismatch != 1 || result.CachedDigest == result.RawDigest {
+		t.Errorf("changed log = %+v, want a mismatch at row 1", result)
+	}
+
+	if result, err = VerifyRawLog(t.Context(), reader, strings.NewReader(log[:strings.Index(log, "\x1b_bk;t=1745322209923")])); err != nil {
+		t.Fatalf("VerifyRawLog failed: %v", err)
+	}
+	if result.Match || result.RawRows != 2 || result.FirstMismatch != 2 {
+		t.Errorf("shorter log = %+v, want a mismatch at row 2", result)
+	}
+
+	sorted := filepath.Join(t.TempDir(), "sorted.parquet")
+	if _, err := ExportSeq2ToParquetSorted(logparser.New().All(strings.NewReader(log)), sorted, nil, SortOptions{}); err != nil {
+		t.Fatalf("ExportSeq2ToParquetSorted failed: %v", err)
+	}
+	if _, err := VerifyRawLog(t.Context(), NewParquetReader(sorted), strings.NewReader(log)); !errors.Is(err, ErrCannotVerify) {
+		t.Errorf("error = %v, want ErrCannotVerify", err)
+	}
+}
+
+func TestClient_VerifyCache(t *testing.T) {
+	api := newTerminalMock()
+	client := newTestClient(t, api)
+	location := JobLocation{Org: "org", Pipeline: "pipeline", Build: "1", Job: "job"}
+
+	if _, err := client.VerifyCache(t.Context(), location); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("error = %v, want ErrNotCached", err)
+	}
+
+	if _, err := client.DownloadAndCache(t.Context(), location, 0, false); err != nil {
+		t.Fatalf("DownloadAndCache failed: %v", err)
+	}
+	result, err := client.VerifyCache(t.Context(), location)
+	if err != nil {
+		t.Fatalf("VerifyCache failed: %v", err)
+	}
+	if !result.Match {
+		t.Errorf("fresh cache = %+v, want a match", result)
+	}
+
+	// The log grew after the finished job was cached
+	api.mu.Lock()
+	api.logContent += "\x1b_bk;t=1745322209922\x07late line\n"
+	api.mu.Unlock()
+	if result, err = client.VerifyCache(t.Context(), location); err != nil {
+		t.Fatalf("VerifyCache failed: %v", err)
+	}
+	if result.Match || result.CachedRows != 1 || result.RawRows != 2 || result.FirstMismatch != 1 {
+		t.Errorf("stale cache = %+v, want a mismatch at row 1", result)
+	}
+}